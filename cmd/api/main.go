@@ -2,13 +2,30 @@ package main
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
+	"gojwt-rest-api/internal/cache"
+	"gojwt-rest-api/internal/captcha"
 	"gojwt-rest-api/internal/config"
+	"gojwt-rest-api/internal/domain"
+	"gojwt-rest-api/internal/events"
 	"gojwt-rest-api/internal/handler"
+	"gojwt-rest-api/internal/kms"
+	"gojwt-rest-api/internal/lifecycle"
+	"gojwt-rest-api/internal/mailer"
+	"gojwt-rest-api/internal/metrics"
 	"gojwt-rest-api/internal/middleware"
+	"gojwt-rest-api/internal/pii"
+	"gojwt-rest-api/internal/plugin"
 	"gojwt-rest-api/internal/repository"
+	"gojwt-rest-api/internal/routecheck"
+	"gojwt-rest-api/internal/scheduler"
 	"gojwt-rest-api/internal/service"
+	"gojwt-rest-api/internal/storage"
+	"gojwt-rest-api/internal/tenant"
+	"gojwt-rest-api/internal/utils"
 	"gojwt-rest-api/migrations"
+	"gojwt-rest-api/pkg/kv"
 	"gojwt-rest-api/pkg/logger"
 	"gojwt-rest-api/pkg/validator"
 	"net/http"
@@ -16,17 +33,23 @@ import (
 	"os/signal"
 	"syscall"
 	"time"
+	// Embed the time zone database for user timezones on hosts without one
+	_ "time/tzdata"
 
 	"github.com/gin-gonic/gin"
+	"github.com/redis/go-redis/v9"
 )
 
 const (
+	usersCacheGroup  = "users"
 	welcomeMessage   = "Welcome to Go JWT REST API"
 	apiVersion       = "1.0.0"
 	serverStatus     = "running"
 	healthEndpoint   = "/health"
+	readyEndpoint    = "/health/ready"
 	registerEndpoint = "/api/v1/auth/register"
 	loginEndpoint    = "/api/v1/auth/login"
+	refreshEndpoint  = "/api/v1/auth/refresh"
 	usersEndpoint    = "/api/v1/users (requires auth)"
 	documentationURL = "https://github.com/prassaaa/gojwt-rest-api"
 )
@@ -41,11 +64,38 @@ func main() {
 		appLogger.Fatal("Failed to load configuration:", err)
 	}
 
+	// Log the effective configuration, so misconfigurations show on boot
+	banner := cfg.Banner()
+	if data, err := json.Marshal(banner); err == nil {
+		appLogger.Infof("Effective configuration: %s", data)
+	}
+	for _, warning := range banner.Warnings {
+		appLogger.Warn("Configuration:", warning)
+	}
+
 	// Set Gin mode
 	if cfg.AppEnv == "production" {
 		gin.SetMode(gin.ReleaseMode)
 	}
 
+	// Decrypt secrets stored encrypted with the KMS key
+	decrypter, err := kms.New(cfg.KMS)
+	if err != nil {
+		appLogger.Fatal("Failed to create KMS client:", err)
+	}
+	if err := kms.ResolveSecrets(context.Background(), cfg, decrypter); err != nil {
+		appLogger.Fatal("Failed to decrypt secrets:", err)
+	}
+
+	// Encrypt personal data at rest
+	if cfg.PII.Enabled() {
+		keyring, err := pii.NewKeyring(cfg.PII.EncryptionKey, cfg.PII.PreviousKeys, cfg.PII.BlindIndexKey)
+		if err != nil {
+			appLogger.Fatal("Failed to load PII encryption keys:", err)
+		}
+		pii.Use(keyring)
+	}
+
 	// Initialize database
 	db, err := config.NewDatabase(cfg, appLogger)
 	if err != nil {
@@ -56,39 +106,507 @@ func main() {
 	if err := migrations.Migrate(db); err != nil {
 		appLogger.Fatal("Failed to run migrations:", err)
 	}
+	if cfg.Database.UserSearch == config.UserSearchFullText {
+		if err := migrations.EnsureUserFullTextIndex(db); err != nil {
+			appLogger.Fatal("Failed to create full-text index:", err)
+		}
+	}
 	appLogger.Info("Database migrations completed successfully")
 
+	// Warn about indexes hot queries rely on that are missing, e.g. after a manual schema change
+	if warnings, err := migrations.AuditIndexes(db); err != nil {
+		appLogger.Error("Failed to audit database indexes:", err)
+	} else {
+		for _, warning := range warnings {
+			appLogger.Warn("Index audit:", warning)
+		}
+	}
+
+	// Scope statements of tenant requests to their organization
+	if cfg.Tenancy.Enabled {
+		if err := db.Use(tenant.Plugin{}); err != nil {
+			appLogger.Fatal("Failed to register tenant scoping:", err)
+		}
+	}
+
 	// Initialize dependencies
 		validator, err := validator.New()
 	if err != nil {
 		appLogger.Fatal("Failed to create validator:", err)
 	}
-	rateLimiter := middleware.NewRateLimiter(cfg.RateLimit)
+
+	// Initialize Redis (optional)
+	var redisClient *redis.Client
+	if cfg.Redis.Addr != "" {
+		redisClient, err = config.NewRedisClient(cfg, appLogger)
+		if err != nil {
+			appLogger.Fatal("Failed to connect to redis:", err)
+		}
+	}
+
+	// Initialize response cache
+	responseCache, err := cache.NewStore(cfg.Cache, redisClient)
+	if err != nil {
+		appLogger.Fatal("Failed to create response cache:", err)
+	}
+
+	// Propagate invalidations of the per-instance memory cache to other instances
+	cacheListenerCtx, stopCacheListener := context.WithCancel(context.Background())
+	defer stopCacheListener()
+	if redisClient != nil && cfg.Cache.Driver != "redis" {
+		broadcastCache := cache.NewBroadcastStore(responseCache, cache.NewRedisBroadcaster(redisClient, cfg.Cache.InvalidationChannel))
+		go func() {
+			if err := broadcastCache.Listen(cacheListenerCtx); err != nil {
+				appLogger.Error("Cache invalidation listener stopped:", err)
+			}
+		}()
+		responseCache = broadcastCache
+	}
+	// Admin user reads are only cached when enabled, services changing users
+	// then drop the cached responses
+	var userCache service.UserCache
+	if cfg.Cache.Enabled {
+		userCache = cache.NewGroup(responseCache, usersCacheGroup)
+	}
+
+	// Short-lived counters of rate limiters and two-factor attempts, shared
+	// between instances with the redis driver
+	counterStore, err := kv.NewStore(cfg.RateLimit.Driver, cfg.RateLimit.CleanupInterval, redisClient)
+	if err != nil {
+		appLogger.Fatal("Failed to create rate limit store:", err)
+	}
+
+	// Initialize mailer
+	mail, err := mailer.New(cfg.Mail, appLogger)
+	if err != nil {
+		appLogger.Fatal("Failed to create mailer:", err)
+	}
+
+	// Initialize event bus and subscribers
+	eventBus := events.NewBus(appLogger)
+	notificationService := service.NewNotificationService(mail, cfg.Tenancy.InvitationURL,
+		service.WithEmailRevertURL(cfg.EmailChange.RevertURL),
+		service.WithAccountUnlockURL(cfg.AccountLock.UnlockURL),
+		service.WithPasswordResetURL(cfg.PasswordReset.URL),
+	)
+	eventBus.Subscribe(events.APIKeyRotationDue, notificationService.SendAPIKeyRotationReminder)
+	eventBus.Subscribe(events.OrganizationInvitationCreated, notificationService.SendOrganizationInvitation)
+	eventBus.Subscribe(events.OrganizationMemberRemoved, notificationService.SendOrganizationMemberRemoved)
+	eventBus.Subscribe(events.OrganizationOwnershipTransferred, notificationService.SendOrganizationOwnershipTransferred)
+	eventBus.Subscribe(events.UserInactivityWarning, notificationService.SendInactivityWarning)
+	eventBus.Subscribe(events.UserEmailChanged, notificationService.SendEmailChangeNotice)
+	eventBus.Subscribe(events.UserLocked, notificationService.SendAccountLockedNotice)
+	eventBus.Subscribe(events.UserUnlockRequested, notificationService.SendAccountLockedNotice)
+	eventBus.Subscribe(events.UserPasswordResetRequested, notificationService.SendPasswordResetLink)
+	if cfg.Signup.ConcealExistingEmail {
+		eventBus.Subscribe(events.UserRegistrationAttempted, notificationService.SendRegistrationAttemptNotice)
+	}
+
+	// Plugins compiled in (see plugins.go) register their extensions
+	pluginHost := plugin.NewHost(cfg, db, appLogger, eventBus)
+	if err := pluginHost.Load(plugin.Registered()...); err != nil {
+		appLogger.Fatal("Failed to load plugins:", err)
+	}
 
 	// Initialize repositories
-	userRepo := repository.NewUserRepository(db)
+	var userRepoOpts []repository.UserRepositoryOption
+	if cfg.Database.UserSearch == config.UserSearchFullText {
+		userRepoOpts = append(userRepoOpts, repository.WithFullTextSearch())
+	}
+	userRepo := repository.NewUserRepository(db, userRepoOpts...)
 	tokenRepo := repository.NewTokenRepository(db)
+	apiKeyRepo := repository.NewAPIKeyRepository(db)
+	orgRepo := repository.NewOrganizationRepository(db)
+	ssoRepo := repository.NewSSOConnectionRepository(db)
+	auditRepo := repository.NewAuditLogRepository(db)
+	webhookDeliveryRepo := repository.NewWebhookDeliveryRepository(db)
+	oneTimeTokenRepo := repository.NewOneTimeTokenRepository(db)
+	loginLocationRepo := repository.NewLoginLocationRepository(db)
+	onboardingRepo := repository.NewOnboardingRepository(db)
+	signingKeyRepo := repository.NewSigningKeyRepository(db)
+	clientVersionRepo := repository.NewClientVersionRepository(db)
+	profileHistoryRepo := repository.NewProfileHistoryRepository(db)
+	sessionAnalyticsRepo := repository.NewSessionAnalyticsRepository(db)
+	abuseReportRepo := repository.NewAbuseReportRepository(db)
 
 	// Initialize services
+	auditService := service.NewAuditService(auditRepo)
+	profileHistoryService := service.NewProfileHistoryService(profileHistoryRepo)
+	oneTimeTokenService := service.NewOneTimeTokenService(oneTimeTokenRepo)
+	onboardingService := service.NewOnboardingService(onboardingRepo, mail)
+	if cfg.Onboarding.WelcomeEmailEnabled {
+		eventBus.Subscribe(events.UserFirstLogin, onboardingService.SendWelcomeEmail)
+	}
+	settingsService := service.NewSettingsService(orgRepo, domain.TenantSettings{
+		AccessTokenTTL:  cfg.JWT.AccessTokenExpiration,
+		RefreshTokenTTL: cfg.JWT.RefreshTokenExpiration,
+		PasswordPolicy: domain.PasswordPolicy{
+			MinLength:        cfg.Password.MinLength,
+			RequireUppercase: cfg.Password.RequireUppercase,
+			RequireDigit:     cfg.Password.RequireDigit,
+			RequireSymbol:    cfg.Password.RequireSymbol,
+		},
+	}, cfg.Tenancy.SettingsCacheTTL)
+	twoFactorService := service.NewTwoFactorService(userRepo, settingsService, cfg.TwoFactor.Issuer, cfg.TwoFactor.RequiredRoles,
+		service.WithTwoFactorOnboardingTracker(onboardingService),
+		service.WithTwoFactorUserCache(userCache),
+		service.WithTwoFactorAttemptLimit(kv.WithPrefix(counterStore, "2fa-attempts:"), cfg.TwoFactor.MaxAttempts, cfg.TwoFactor.LockoutDuration))
+	// Access tokens are signed with JWT_SECRET, or the first standby key once
+	// it was reported compromised. Refuse to start without a usable key.
+	signingKeyService := service.NewSigningKeyService(signingKeyRepo,
+		utils.NewKeySet(append([]string{cfg.JWT.Secret}, cfg.JWT.StandbySecrets...)...))
+	if err := signingKeyService.Refresh(); err != nil {
+		appLogger.Fatal("Failed to check JWT signing keys:", err)
+	}
+	userOpts := []service.UserServiceOption{
+		service.WithSigningKeys(signingKeyService.Keys()),
+		service.WithPasswordLimiter(utils.NewPasswordLimiter(cfg.Password.MaxConcurrentChecks, cfg.Password.QueueTimeout)),
+		service.WithEventPublisher(eventBus),
+		service.WithLogger(appLogger),
+		service.WithSettingsService(settingsService),
+		service.WithTwoFactorService(twoFactorService),
+		service.WithAuditService(auditService),
+		service.WithProfileHistory(profileHistoryService),
+		service.WithOnboardingTracker(onboardingService),
+		service.WithUserCache(userCache),
+	}
+	accountLockService := service.NewAccountLockService(userRepo, tokenRepo, loginLocationRepo, oneTimeTokenService, auditService,
+		service.AccountLockPolicy{
+			LockOnTokenReuse: cfg.AccountLock.LockOnTokenReuse,
+			MaxCountries:     cfg.AccountLock.MaxCountries,
+			CountryWindow:    cfg.AccountLock.CountryWindow,
+			UnlockTokenTTL:   cfg.AccountLock.UnlockTokenTTL,
+		},
+		service.WithAccountLockEventPublisher(eventBus),
+		service.WithAccountLockSettingsService(settingsService),
+		service.WithAccountLockOnboardingTracker(onboardingService),
+		service.WithAccountLockUserCache(userCache),
+	)
+	userOpts = append(userOpts, service.WithAccountLock(accountLockService))
+	passwordResetService := service.NewPasswordResetService(userRepo, tokenRepo, oneTimeTokenService, auditService, cfg.PasswordReset.TokenTTL,
+		service.WithPasswordResetEventPublisher(eventBus),
+		service.WithPasswordResetSettingsService(settingsService),
+		service.WithPasswordResetOnboardingTracker(onboardingService),
+		service.WithPasswordResetUserCache(userCache),
+	)
+	// Session event streams receive the events concerning their user
+	sessionEvents := events.NewStream()
+	if cfg.Session.NotifyConcurrentLogin {
+		userOpts = append(userOpts, service.WithConcurrentLoginNotification())
+		eventBus.Subscribe(events.UserConcurrentLogin, sessionEvents.Handle)
+	}
+	if len(cfg.Session.Clients) > 0 {
+		userOpts = append(userOpts, service.WithClientApplications(cfg.Session.Clients, cfg.Session.ClientIDRequired))
+	}
+	clientVersionService := service.NewClientVersionService(clientVersionRepo, auditService, cfg.Session.ClientVersionCacheTTL,
+		service.WithClientVersionClients(cfg.Session.Clients))
+	userOpts = append(userOpts, service.WithAccessSchedule(
+		service.NewAccessScheduleService(cfg.Access.Roles, settingsService, auditService)))
+	if cfg.EmailChange.RevertWindow > 0 {
+		userOpts = append(userOpts, service.WithEmailChangeRevert(oneTimeTokenService, cfg.EmailChange.RevertWindow))
+	}
+	// Organization quotas are only enforced in multi-tenant mode
+	var quotaService service.QuotaService
+	var orgService service.OrganizationService
+	if cfg.Tenancy.Enabled {
+		quotaService = service.NewQuotaService(orgRepo, userRepo, cfg.Tenancy.QuotaCacheTTL)
+		userOpts = append(userOpts,
+			service.WithQuotaService(quotaService),
+			service.WithClaimsEnricher(service.NewPlanClaimsEnricher(orgRepo)),
+		)
+		orgService = service.NewOrganizationService(
+			orgRepo,
+			userRepo,
+			quotaService,
+			service.WithOrganizationEventPublisher(eventBus),
+			service.WithInvitationTTL(cfg.Tenancy.InvitationTTL),
+			service.WithOrganizationSettings(settingsService),
+			service.WithOrganizationAuditService(auditService),
+			service.WithOrganizationOnboardingTracker(onboardingService),
+			service.WithOrganizationUserCache(userCache),
+		)
+	}
+	if !cfg.Signup.AllowSelfRegistration {
+		// Invited users can still register, to accept their invitation
+		var invitations service.InvitationVerifier
+		if orgService != nil {
+			invitations = orgService
+		}
+		userOpts = append(userOpts, service.WithSelfRegistrationDisabled(invitations))
+	}
+	for _, enricher := range pluginHost.ClaimsEnrichers() {
+		userOpts = append(userOpts, service.WithClaimsEnricher(enricher))
+	}
 	userService := service.NewUserService(
 		userRepo,
 		tokenRepo,
 		cfg.JWT.Secret,
 		cfg.JWT.AccessTokenExpiration,
 		cfg.JWT.RefreshTokenExpiration,
+		userOpts...,
 	)
+	apiKeyOpts := []service.APIKeyServiceOption{service.WithAPIKeyEventPublisher(eventBus)}
+	if cfg.APIKey.AutoExpire {
+		apiKeyOpts = append(apiKeyOpts, service.WithAutoExpire(cfg.APIKey.AutoExpireGrace))
+	}
+	apiKeyService := service.NewAPIKeyService(apiKeyRepo, cfg.APIKey.RotationAge, apiKeyOpts...)
+	apiKeyUsage := service.NewAPIKeyUsageTracker(apiKeyRepo)
+	webhookService := service.NewWebhookService(webhookDeliveryRepo, cfg.Webhook.URLs, cfg.Webhook.Secret, cfg.Webhook.Timeout)
+	webhookService.Subscribe(eventBus)
+	ssoOpts := []service.SSOServiceOption{
+		service.WithSSOOnboardingTracker(onboardingService),
+		service.WithSSOProfileHistory(profileHistoryService),
+		service.WithSSOUserCache(userCache),
+	}
+	if quotaService != nil {
+		ssoOpts = append(ssoOpts, service.WithSSOQuotaService(quotaService))
+	}
+	ssoService := service.NewSSOService(ssoRepo, orgRepo, userRepo, auditService, ssoOpts...)
+	userDetailsService := service.NewUserDetailsService(userRepo, tokenRepo, ssoRepo, auditService)
+	inactivityService := service.NewInactivityService(userRepo, tokenRepo, auditService, service.InactivityPolicy{
+		WarnAfter:      cfg.Inactivity.WarnAfter,
+		SuspendAfter:   cfg.Inactivity.SuspendAfter,
+		AnonymizeAfter: cfg.Inactivity.AnonymizeAfter,
+		AccessTokenTTL: cfg.JWT.AccessTokenExpiration,
+	}, service.WithInactivityEventPublisher(eventBus), service.WithInactivityUserCache(userCache))
+
+	// Initialize background jobs
+	registry := metrics.NewRegistry()
+	jobs := scheduler.New(appLogger, scheduler.WithObserver(metrics.NewJobMetrics(registry)))
+	jobs.Every("api-key-rotation", cfg.APIKey.RotationCheckInterval, func(ctx context.Context) error {
+		report, err := apiKeyService.CheckRotation()
+		if err == nil && report.Notified > 0 {
+			appLogger.Infof("API key rotation check: %d stale, %d owners notified, %d expiring", report.Stale, report.Notified, report.Expiring)
+		}
+		return err
+	})
+	jobs.Every("api-key-usage-flush", cfg.APIKey.UsageFlushInterval, apiKeyUsage.Flush)
+	// Signing keys reported compromised by another instance are dropped
+	jobs.Every("signing-key-refresh", cfg.JWT.KeyRefreshInterval, func(ctx context.Context) error {
+		return signingKeyService.Refresh()
+	})
+	if cfg.Inactivity.Enabled() {
+		jobs.Every("user-inactivity", cfg.Inactivity.CheckInterval, func(ctx context.Context) error {
+			report, err := inactivityService.Run(ctx)
+			if report != nil && report.Warned+report.Suspended+report.Anonymized > 0 {
+				appLogger.Infof("User inactivity policy: %d warned, %d suspended, %d anonymized", report.Warned, report.Suspended, report.Anonymized)
+			}
+			return err
+		})
+	}
 
 	// Initialize handlers
-	authHandler := handler.NewAuthHandler(userService, validator)
+	var authOpts []handler.AuthHandlerOption
+	if cfg.Signup.ConcealExistingEmail {
+		authOpts = append(authOpts, handler.WithConcealedRegistration())
+	}
+	authHandler := handler.NewAuthHandler(userService, validator, authOpts...)
+	accountLockHandler := handler.NewAccountLockHandler(accountLockService, validator)
+	clientVersionHandler := handler.NewClientVersionHandler(clientVersionService, validator)
 	userHandler := handler.NewUserHandler(userService, validator)
+	userDetailsHandler := handler.NewUserDetailsHandler(userDetailsService, userService)
+	profileHistoryHandler := handler.NewProfileHistoryHandler(profileHistoryService, userService)
+	sessionAnalyticsHandler := handler.NewSessionAnalyticsHandler(service.NewSessionAnalyticsService(sessionAnalyticsRepo))
+	abuseReportHandler := handler.NewAbuseReportHandler(service.NewAbuseReportService(abuseReportRepo, auditService,
+		service.WithAbuseReportEventPublisher(eventBus)), validator)
+	inactivityHandler := handler.NewInactivityHandler(inactivityService, validator)
 	profileHandler := handler.NewProfileHandler(userService, validator)
+	sessionEventsHandler := handler.NewSessionEventsHandler(sessionEvents)
+	onboardingHandler := handler.NewOnboardingHandler(onboardingService)
+	apiKeyHandler := handler.NewAPIKeyHandler(apiKeyService, validator)
+	ssoHandler := handler.NewSSOHandler(ssoService, userService, validator)
+	auditHandler := handler.NewAuditHandler(auditService)
+	webhookHandler := handler.NewWebhookHandler(webhookService)
+	twoFactorHandler := handler.NewTwoFactorHandler(twoFactorService, userService, validator)
+	// Organizations only exist in multi-tenant mode
+	var orgHandler *handler.OrganizationHandler
+	var settingsHandler *handler.SettingsHandler
+	if orgService != nil {
+		orgHandler = handler.NewOrganizationHandler(orgService, quotaService, validator)
+		settingsHandler = handler.NewSettingsHandler(settingsService, validator)
+	}
+	// Readiness checks the dependencies registered here. The server keeps
+	// serving without the mailer, the scheduler and a Redis only used for
+	// cache invalidation, so those are reported without failing readiness.
+	dependencies := lifecycle.NewHealthRegistry(
+		lifecycle.WithHealthObserver(metrics.NewDependencyMetrics(registry)),
+		lifecycle.WithHealthCheckTimeout(cfg.Server.HealthCheckTimeout),
+	)
+	dependencies.Register("database", lifecycle.HealthCheckFunc(func(ctx context.Context) error {
+		return config.PingDatabase(ctx, db)
+	}))
+	if redisClient != nil {
+		pingRedis := lifecycle.HealthCheckFunc(func(ctx context.Context) error {
+			return redisClient.Ping(ctx).Err()
+		})
+		if cfg.Cache.Driver == "redis" || cfg.RateLimit.Driver == "redis" {
+			dependencies.Register("redis", pingRedis)
+		} else {
+			dependencies.RegisterOptional("redis", pingRedis)
+		}
+	}
+	if checker, ok := mail.(lifecycle.HealthChecker); ok {
+		dependencies.RegisterOptional("mailer", checker)
+	}
+	dependencies.RegisterOptional("scheduler", jobs)
+
+	drainer := lifecycle.NewDrainer()
+	healthHandler := handler.NewHealthHandler(drainer, dependencies)
+
+	// Initialize metrics
+	httpMetrics := metrics.NewHTTPMetrics(registry)
+	rateLimitMetrics := metrics.NewRateLimitMetrics(registry)
+	sloTracker := metrics.NewSLOTracker(
+		registry,
+		cfg.SLO.AvailabilityTarget,
+		cfg.SLO.AuthSuccessTarget,
+		loginEndpoint,
+		refreshEndpoint,
+	)
+
+	// Purge data past its retention window, archiving audit logs first if enabled
+	var retentionOpts []service.RetentionServiceOption
+	if cfg.Retention.ArchiveAuditLogs {
+		archive, err := storage.New(cfg.Storage)
+		if err != nil {
+			appLogger.Fatal("Failed to create storage client:", err)
+		}
+		retentionOpts = append(retentionOpts, service.WithAuditLogArchive(archive, cfg.Retention.AuditLogArchivePrefix))
+	}
+	retentionService := service.NewRetentionService(tokenRepo, auditRepo, service.RetentionPolicy{
+		RevokedTokens:  cfg.Retention.RevokedTokens,
+		LoginHistory:   cfg.Retention.LoginHistory,
+		TokenBlacklist: cfg.Retention.TokenBlacklist,
+		AuditLogs:      cfg.Retention.AuditLogs,
+	}, retentionOpts...)
+	retentionMetrics := metrics.NewRetentionMetrics(registry)
+	jobs.Every("data-retention", cfg.Retention.PurgeInterval, func(ctx context.Context) error {
+		report, err := retentionService.Purge(ctx)
+		retentionMetrics.Observe(report.Purged)
+		return err
+	})
+
+	// Rejections of access tokens tell expired from invalid ones unless
+	// configured otherwise, and tokens about to expire are hinted for renewal
+	tokenAuthOpts := []middleware.AuthOption{middleware.WithRenewalHint(cfg.JWT.RenewalHintWindow)}
+	// Tokens are verified with the key they name, unless it was reported compromised
+	tokenAuthOpts = append(tokenAuthOpts, middleware.WithKeySet(signingKeyService.Keys()))
+	// Tokens of an older format are accepted until the cutoff, counted by
+	// format to follow the migration
+	tokenAuthOpts = append(tokenAuthOpts, middleware.WithTokenFormatPolicy(middleware.TokenFormatPolicy{
+		LegacyCutoff: cfg.JWT.LegacyFormatCutoff,
+		Observer:     metrics.NewTokenFormatMetrics(registry),
+	}))
+	if cfg.JWT.GenericTokenErrors {
+		tokenAuthOpts = append(tokenAuthOpts, middleware.WithGenericTokenErrors())
+	}
+
+	// Access tokens are read from the enabled sources, and query tokens are
+	// kept out of the access log
+	tokenSources := middleware.TokenSources{Header: cfg.JWT.TokenFromHeader}
+	var redactedParams []string
+	if cfg.JWT.TokenFromCookie {
+		tokenSources.Cookie = cfg.JWT.TokenCookieName
+	}
+	if cfg.JWT.TokenFromQuery {
+		tokenSources.QueryParam = cfg.JWT.TokenQueryParam
+		redactedParams = append(redactedParams, cfg.JWT.TokenQueryParam)
+	}
+	tokenAuthOpts = append(tokenAuthOpts, middleware.WithTokenSources(tokenSources))
+
+	// Rate limiters report rejections and tracked visitors for tuning. Callers
+	// inside the trust boundary get the internal limit.
+	rateLimiterOpts := []middleware.RateLimiterOption{
+		middleware.WithRateLimitObserver(rateLimitMetrics),
+		middleware.WithRateLimitStore(counterStore),
+	}
+	if cfg.Trust.Enabled() {
+		rateLimiterOpts = append(rateLimiterOpts, middleware.WithInternalLimit(cfg.RateLimit.InternalRequestsPerDuration))
+	}
+	rateLimiter := middleware.NewRateLimiter(cfg.RateLimit, rateLimiterOpts...)
+	limiters := []*middleware.RateLimiter{rateLimiter}
+	var emailCheckLimiter *middleware.RateLimiter
+	if cfg.Signup.EmailCheckEnabled {
+		emailCheckLimiter = middleware.NewRateLimiter(config.RateLimitConfig{
+			RequestsPerDuration: cfg.Signup.EmailCheckRequests,
+			Duration:            cfg.Signup.EmailCheckWindow,
+			CleanupInterval:     cfg.RateLimit.CleanupInterval,
+		}, middleware.WithLimiterName("email-check"), middleware.WithRateLimitObserver(rateLimitMetrics),
+			middleware.WithRateLimitStore(counterStore))
+		limiters = append(limiters, emailCheckLimiter)
+	}
+	// Reset requests are limited per client IP and per email
+	passwordResetLimiter := middleware.NewRateLimiter(config.RateLimitConfig{
+		RequestsPerDuration: cfg.PasswordReset.RequestsPerIP,
+		Duration:            cfg.PasswordReset.RateWindow,
+		CleanupInterval:     cfg.RateLimit.CleanupInterval,
+	}, middleware.WithLimiterName("password-reset"), middleware.WithRateLimitObserver(rateLimitMetrics),
+		middleware.WithRateLimitStore(counterStore))
+	passwordResetEmailLimiter := middleware.NewRateLimiter(config.RateLimitConfig{
+		RequestsPerDuration: cfg.PasswordReset.RequestsPerEmail,
+		Duration:            cfg.PasswordReset.RateWindow,
+		CleanupInterval:     cfg.RateLimit.CleanupInterval,
+	}, middleware.WithLimiterName("password-reset-email"), middleware.WithRateLimitObserver(rateLimitMetrics),
+		middleware.WithRateLimitStore(counterStore))
+	limiters = append(limiters, passwordResetLimiter, passwordResetEmailLimiter)
+	passwordResetHandler := handler.NewPasswordResetHandler(passwordResetService, validator, appLogger,
+		handler.WithResetQueue(cfg.PasswordReset.Workers, cfg.PasswordReset.QueueSize),
+		handler.WithResetEmailLimiter(passwordResetEmailLimiter),
+	)
+	var abuseReportLimiter *middleware.RateLimiter
+	if cfg.AbuseReport.Enabled {
+		abuseReportLimiter = middleware.NewRateLimiter(config.RateLimitConfig{
+			RequestsPerDuration: cfg.AbuseReport.Requests,
+			Duration:            cfg.AbuseReport.Window,
+			CleanupInterval:     cfg.RateLimit.CleanupInterval,
+		}, middleware.WithLimiterName("abuse-report"), middleware.WithRateLimitObserver(rateLimitMetrics),
+			middleware.WithRateLimitStore(counterStore))
+		limiters = append(limiters, abuseReportLimiter)
+	}
+
+	management := &managementRoutes{
+		jwtSecret:        cfg.JWT.Secret,
+		authOptions:      tokenAuthOpts,
+		clientPrincipals: cfg.Server.AdminClientPrincipals,
+		userService:      userService,
+		healthHandler:    healthHandler,
+		metricsHandler:   handler.NewMetricsHandler(httpMetrics, sloTracker),
+		rateLimitHandler: handler.NewRateLimitHandler(limiters...),
+		registry:         registry,
+	}
+
+	// Client IPs, which rate limits are keyed on, come from X-Forwarded-For
+	// only when set by a trusted proxy
+	trustedProxies := make([]string, len(cfg.Server.TrustedProxies))
+	for i, network := range cfg.Server.TrustedProxies {
+		trustedProxies[i] = network.String()
+	}
 
 	// Initialize Gin router
-	router := gin.Default()
+	router := gin.New()
+	router.NoRoute(middleware.NotFoundHandler)
+	if err := router.SetTrustedProxies(trustedProxies); err != nil {
+		appLogger.Fatal("Failed to set trusted proxies:", err)
+	}
 
-	// Apply global middlewares
+	// Apply global middlewares, after the wiring check probe so probes have no effect
+	router.Use(routecheck.Middleware())
+	router.Use(middleware.RequestIDMiddleware())
+	router.Use(gin.LoggerWithFormatter(middleware.NewRequestLogFormatter(redactedParams...)))
+	router.Use(gin.CustomRecovery(middleware.RecoveryHandler))
+	router.Use(middleware.InFlightMiddleware(drainer))
+	router.Use(middleware.MetricsMiddleware(httpMetrics))
+	router.Use(middleware.SLOMiddleware(sloTracker))
 	router.Use(middleware.CORSMiddleware(cfg.CORS))
+	if cfg.Trust.Enabled() {
+		router.Use(middleware.TrustBoundaryMiddleware(middleware.TrustBoundary{
+			Networks:    cfg.Trust.InternalNetworks,
+			ClientCerts: cfg.Trust.ClientCerts,
+			APIKeyIDs:   cfg.Trust.APIKeyIDs,
+		}))
+	}
 	router.Use(middleware.RateLimitMiddleware(rateLimiter))
+	router.Use(middleware.APIKeyMiddleware(apiKeyService, apiKeyUsage))
 
 	// Welcome endpoint
 	router.GET("/", func(c *gin.Context) {
@@ -98,6 +616,7 @@ func main() {
 			"status":  serverStatus,
 			"endpoints": gin.H{
 				"health":   healthEndpoint,
+				"ready":    readyEndpoint,
 				"register": registerEndpoint,
 				"login":    loginEndpoint,
 				"users":    usersEndpoint,
@@ -106,91 +625,395 @@ func main() {
 		})
 	})
 
-	// Health check endpoint
-	router.GET("/health", func(c *gin.Context) {
-		c.JSON(http.StatusOK, gin.H{
-			"status": "ok",
-			"time":   time.Now(),
-		})
-	})
+	// Management endpoints - served on the public listener unless a management port is configured
+	var adminRouter *gin.Engine
+	if cfg.Server.AdminPort != "" {
+		adminRouter = gin.New()
+		if err := adminRouter.SetTrustedProxies(trustedProxies); err != nil {
+			appLogger.Fatal("Failed to set trusted proxies:", err)
+		}
+		adminRouter.Use(routecheck.Middleware())
+		adminRouter.Use(middleware.RequestIDMiddleware())
+		adminRouter.Use(gin.CustomRecovery(middleware.RecoveryHandler))
+		management.register(adminRouter, true)
+	} else {
+		management.register(router, false)
+	}
+
+	// Routes for callers inside the trust boundary, hidden from others
+	internal := router.Group("/internal", middleware.InternalOnlyMiddleware())
+	{
+		internal.GET("/health", healthHandler.Dependencies)
+	}
+
+	// Development-only debugging routes, opted into and only served to local callers
+	if cfg.Server.DevRoutes {
+		devHandler := handler.NewDevHandler(cfg.JWT.Secret, validator)
+		dev := router.Group("/_dev", middleware.LoopbackOnlyMiddleware())
+		{
+			dev.POST("/tokens/inspect", devHandler.InspectToken)
+			dev.POST("/sso/:id/login", ssoHandler.DevLogin)
+		}
+		appLogger.Warn("Development routes are enabled under /_dev")
+	}
+
+	// Restricted tokens are only accepted by the routes of their scope
+	scopedAuthOpts := func(scope string) []middleware.AuthOption {
+		return append([]middleware.AuthOption{middleware.AllowScopes(scope)}, tokenAuthOpts...)
+	}
+
+	// Middlewares of protected routes. API keys are only accepted on the
+	// self-service routes of their scopes.
+	if err := checkAPIKeyRoutes(apiKeyRoutes); err != nil {
+		appLogger.Fatal("API key route check failed:", err)
+	}
+	protected := []gin.HandlerFunc{
+		middleware.AuthMiddleware(cfg.JWT.Secret, append([]middleware.AuthOption{middleware.AcceptAPIKeys(apiKeyRoutes)}, tokenAuthOpts...)...),
+		middleware.RevocationMiddleware(userService),
+	}
+	if quotaService != nil {
+		protected = append(protected,
+			middleware.OrganizationQuotaMiddleware(quotaService),
+			middleware.TenantMiddleware(userService),
+		)
+	}
+	// Sensitive actions additionally require a recent authentication
+	recentAuth := middleware.RecentAuthMiddleware(cfg.JWT.ReauthMaxAge)
 
 	// API v1 routes
 	v1 := router.Group("/api/v1")
+	// Outdated app versions are refused before anything else of the API
+	v1.Use(middleware.ClientVersionMiddleware(clientVersionService))
+	v1.Use(pluginHost.Middleware()...)
 	{
 		// Auth routes (public)
 		auth := v1.Group("/auth")
 		{
-			auth.POST("/register", authHandler.Register)
-			auth.POST("/login", authHandler.Login)
+			auth.POST("/register", middleware.InvalidateCacheMiddleware(responseCache, usersCacheGroup), authHandler.Register)
+			if emailCheckLimiter != nil {
+				auth.POST("/check-email",
+					middleware.RateLimitMiddleware(emailCheckLimiter),
+					middleware.CaptchaMiddleware(captcha.New(cfg.Captcha)),
+					authHandler.CheckEmail,
+				)
+			}
+			auth.POST("/login/start", ssoHandler.LoginStart)
+			auth.POST("/login", middleware.ClientCountryMiddleware(cfg.AccountLock.CountryHeader), authHandler.Login)
 			auth.POST("/refresh", authHandler.RefreshToken)
+			auth.POST("/email-change/revert", authHandler.RevertEmailChange)
+			auth.POST("/unlock/request", accountLockHandler.RequestUnlock)
+			auth.POST("/unlock", accountLockHandler.Unlock)
+			auth.POST("/password/forgot", middleware.RateLimitMiddleware(passwordResetLimiter), passwordResetHandler.ForgotPassword)
+			auth.POST("/password/reset", passwordResetHandler.ResetPassword)
+		}
+
+		// Abuse and security reports (public)
+		if abuseReportLimiter != nil {
+			v1.POST("/report",
+				middleware.RateLimitMiddleware(abuseReportLimiter),
+				middleware.CaptchaMiddleware(captcha.New(cfg.Captcha)),
+				abuseReportHandler.Submit,
+			)
 		}
 
-		// Logout route (protected - requires authentication)
-		logout := v1.Group("/auth")
-		logout.Use(middleware.AuthMiddleware(cfg.JWT.Secret))
+		// Auth routes (protected - requires authentication)
+		authProtected := v1.Group("/auth")
+		authProtected.Use(protected...)
 		{
-			logout.POST("/logout", authHandler.Logout)
+			authProtected.POST("/logout", authHandler.Logout)
+			authProtected.GET("/me", authHandler.Me)
+			authProtected.POST("/reauthenticate", authHandler.Reauthenticate)
+		}
+
+		// Two-factor routes (accept the restricted tokens issued during login)
+		v1.POST("/auth/login/2fa",
+			middleware.AuthMiddleware(cfg.JWT.Secret, scopedAuthOpts(domain.ScopeTwoFactorVerify)...),
+			middleware.RevocationMiddleware(userService),
+			twoFactorHandler.VerifyLogin,
+		)
+		twoFactor := v1.Group("/auth/2fa")
+		twoFactor.Use(
+			middleware.AuthMiddleware(cfg.JWT.Secret, scopedAuthOpts(domain.ScopeTwoFactorEnroll)...),
+			middleware.RevocationMiddleware(userService),
+		)
+		{
+			twoFactor.POST("/enroll", twoFactorHandler.BeginEnrollment)
+			twoFactor.POST("/enroll/confirm", twoFactorHandler.ConfirmEnrollment)
 		}
 
 		// Profile routes (protected - user self-service)
 		profile := v1.Group("/profile")
-		profile.Use(middleware.AuthMiddleware(cfg.JWT.Secret))
+		profile.Use(protected...)
 		{
 			profile.GET("", profileHandler.GetOwnProfile)
-			profile.PUT("", profileHandler.UpdateOwnProfile)
+			profile.PUT("", middleware.InvalidateCacheMiddleware(responseCache, usersCacheGroup), profileHandler.UpdateOwnProfile)
 			profile.PUT("/password", profileHandler.ChangePassword)
+			profile.GET("/onboarding", onboardingHandler.GetOnboardingStatus)
+			profile.GET("/sessions/events", sessionEventsHandler.StreamSessionEvents)
+			profile.POST("/sessions/revoke", profileHandler.RevokeClientSessions)
 		}
 
 		// User routes (protected)
 		users := v1.Group("/users")
-		users.Use(middleware.AuthMiddleware(cfg.JWT.Secret))
+		users.Use(protected...)
 		{
 			users.GET("/profile", userHandler.GetProfile)
-			// Admin-only routes
-			admin := users.Group("")
-			admin.Use(middleware.AdminMiddleware(userService))
+			// Admin routes, authorized by delegated admin scope
+			cached := func(c *gin.Context) { c.Next() }
+			if cfg.Cache.Enabled {
+				cached = middleware.ResponseCacheMiddleware(responseCache, usersCacheGroup, cfg.Cache.TTL)
+			}
+			invalidate := middleware.InvalidateCacheMiddleware(responseCache, usersCacheGroup)
+			userRead := middleware.AdminScopeMiddleware(userService, domain.ScopeAdminUserRead)
+			userWrite := middleware.AdminScopeMiddleware(userService, domain.ScopeAdminUserWrite)
+
+			users.GET("", userRead, cached, userHandler.GetAllUsers)
+			users.GET("/suggest", userRead, userHandler.SuggestUsers)
+			users.GET("/:id", userRead, cached, userHandler.GetUserByID)
+			users.GET("/:id/details", userRead, userDetailsHandler.GetUserDetails)
+			// Users see their own history too, redacted
+			users.GET("/:id/history", middleware.AdminScopeOrSelfMiddleware(userService, domain.ScopeAdminUserRead), profileHistoryHandler.GetHistory)
+			users.PUT("/:id", userWrite, invalidate, userHandler.UpdateUser)
+			users.DELETE("/:id", userWrite, recentAuth, invalidate, userHandler.DeleteUser)
+			users.PUT("/:id/admin-scopes", middleware.AdminMiddleware(userService), recentAuth, invalidate, userHandler.UpdateAdminScopes)
+			users.PUT("/:id/inactivity-exempt", userWrite, invalidate, inactivityHandler.UpdateExempt)
+		}
+
+		// API key routes (protected - user self-service)
+		apiKeys := v1.Group("/api-keys")
+		apiKeys.Use(protected...)
+		{
+			apiKeys.POST("", recentAuth, apiKeyHandler.CreateAPIKey)
+			apiKeys.GET("", apiKeyHandler.ListOwnAPIKeys)
+			apiKeys.DELETE("/:id", apiKeyHandler.RevokeAPIKey)
+		}
+
+		// Organization membership routes (protected - authorized by organization role)
+		if orgHandler != nil {
+			orgs := v1.Group("/organizations")
+			orgs.Use(protected...)
+			{
+				orgs.GET("/:id/members", orgHandler.ListMembers)
+				orgs.DELETE("/:id/members/:userId", orgHandler.RemoveMember)
+				orgs.POST("/:id/owner", recentAuth, orgHandler.TransferOwnership)
+				orgs.POST("/:id/sessions/revoke", recentAuth, orgHandler.RevokeSessions)
+				orgs.POST("/:id/invitations", orgHandler.InviteMember)
+				orgs.GET("/:id/invitations", orgHandler.ListInvitations)
+			}
+
+			invitations := v1.Group("/invitations")
+			invitations.Use(protected...)
 			{
-				admin.GET("", userHandler.GetAllUsers)
-				admin.GET("/:id", userHandler.GetUserByID)
-				admin.PUT("/:id", userHandler.UpdateUser)
-				admin.DELETE("/:id", userHandler.DeleteUser)
+				invitations.POST("/accept", orgHandler.AcceptInvitation)
 			}
 		}
+
+		// Admin routes authorized by delegated admin scope
+		delegatedAPI := v1.Group("/admin")
+		delegatedAPI.Use(protected...)
+		{
+			tokenAdmin := middleware.AdminScopeMiddleware(userService, domain.ScopeAdminTokenAdmin)
+
+			delegatedAPI.GET("/api-keys", tokenAdmin, apiKeyHandler.ListAPIKeys)
+			delegatedAPI.POST("/api-keys/rotation-check", tokenAdmin, apiKeyHandler.CheckRotation)
+			delegatedAPI.GET("/api-keys/:id/usage", tokenAdmin, apiKeyHandler.GetUsage)
+			delegatedAPI.POST("/clients/:client_id/sessions/revoke", tokenAdmin, recentAuth, userHandler.RevokeClientSessions)
+			delegatedAPI.GET("/audit-logs", middleware.AdminScopeMiddleware(userService, domain.ScopeAdminAuditRead), auditHandler.ListAuditLogs)
+		}
+
+		// Admin routes (protected - admin only)
+		adminAPI := v1.Group("/admin")
+		adminAPI.Use(protected...)
+		adminAPI.Use(middleware.AdminMiddleware(userService))
+		{
+			adminAPI.POST("/sso-connections", ssoHandler.CreateConnection)
+			adminAPI.GET("/sso-connections", ssoHandler.ListConnections)
+			adminAPI.PUT("/sso-connections/:id/provisioning", ssoHandler.UpdateProvisioningRules)
+			adminAPI.DELETE("/sso-connections/:id", ssoHandler.DeleteConnection)
+
+			// User inactivity policy
+			adminAPI.POST("/inactivity-check", inactivityHandler.RunPolicy)
+
+			// Minimum app versions of client applications
+			adminAPI.GET("/client-versions", clientVersionHandler.ListMinVersions)
+			adminAPI.PUT("/client-versions/:client_id", clientVersionHandler.SetMinVersion)
+			adminAPI.DELETE("/client-versions/:client_id", clientVersionHandler.RemoveMinVersion)
+
+			// Session activity and token table growth, for capacity planning
+			adminAPI.GET("/session-analytics", sessionAnalyticsHandler.GetSessionAnalytics)
+
+			// Triage of abuse and security reports
+			adminAPI.GET("/reports", abuseReportHandler.ListReports)
+			adminAPI.GET("/reports/:id", abuseReportHandler.GetReport)
+			adminAPI.PUT("/reports/:id", abuseReportHandler.TriageReport)
+
+			// Webhook delivery log
+			adminAPI.GET("/webhooks/deliveries", webhookHandler.ListDeliveries)
+			adminAPI.GET("/webhooks/deliveries/:id", webhookHandler.GetDelivery)
+			adminAPI.POST("/webhooks/deliveries/:id/replay", webhookHandler.ReplayDelivery)
+
+			// Organizations and plans (multi-tenant mode)
+			if orgHandler != nil {
+				adminAPI.POST("/plans", orgHandler.CreatePlan)
+				adminAPI.GET("/plans", orgHandler.ListPlans)
+				adminAPI.POST("/organizations", orgHandler.CreateOrganization)
+				adminAPI.GET("/organizations", orgHandler.ListOrganizations)
+				adminAPI.GET("/organizations/:id", orgHandler.GetOrganization)
+				adminAPI.POST("/organizations/:id/members", orgHandler.AddMember)
+				adminAPI.GET("/organizations/:id/usage", orgHandler.GetUsage)
+				adminAPI.GET("/organizations/:id/settings", settingsHandler.GetOrganizationSettings)
+				adminAPI.PUT("/organizations/:id/settings", settingsHandler.UpdateOrganizationSettings)
+			}
+		}
+	}
+
+	// Plugin routes, mounted apart from the core routes
+	pluginPublicRoutes := pluginHost.MountRoutes(v1, plugin.RouteDeps{
+		Protected: protected,
+		Admin:     []gin.HandlerFunc{middleware.AdminMiddleware(userService)},
+		Users:     userService,
+		Audit:     auditService,
+		Validator: validator,
+	})
+
+	// SCIM 2.0 provisioning (enabled when a provisioning token is configured)
+	if cfg.SCIM.BearerToken != "" {
+		provisioningOpts := []service.ProvisioningServiceOption{
+			service.WithProvisioningProfileHistory(profileHistoryService),
+			service.WithProvisioningAPIKeys(apiKeyRepo),
+			service.WithProvisioningUserCache(userCache),
+		}
+		if quotaService != nil {
+			provisioningOpts = append(provisioningOpts, service.WithProvisioningQuotaService(quotaService))
+		}
+		scimHandler := handler.NewSCIMHandler(service.NewProvisioningService(userRepo, tokenRepo, provisioningOpts...), validator)
+		scimUsers := router.Group("/scim/v2/Users")
+		scimUsers.Use(middleware.SCIMAuthMiddleware(cfg.SCIM.BearerToken))
+		{
+			invalidate := middleware.InvalidateCacheMiddleware(responseCache, usersCacheGroup)
+
+			scimUsers.GET("", scimHandler.ListUsers)
+			scimUsers.POST("", invalidate, scimHandler.CreateUser)
+			scimUsers.GET("/:id", scimHandler.GetUser)
+			scimUsers.PUT("/:id", invalidate, scimHandler.ReplaceUser)
+			scimUsers.PATCH("/:id", invalidate, scimHandler.PatchUser)
+			scimUsers.DELETE("/:id", invalidate, scimHandler.DeleteUser)
+		}
+	}
+
+	// Fail fast on wiring regressions, like a route missing its middleware
+	if err := routecheck.Verify(router, append(wiringRules(pluginPublicRoutes...), publicListenerRules...), requiredRoutes); err != nil {
+		appLogger.Fatal("Route wiring check failed:", err)
+	}
+	if adminRouter != nil {
+		if err := routecheck.Verify(adminRouter, wiringRules(), nil); err != nil {
+			appLogger.Fatal("Management route wiring check failed:", err)
+		}
 	}
 
 	// Create server
-	addr := fmt.Sprintf("%s:%s", cfg.Server.Host, cfg.Server.Port)
+	addr := cfg.Server.ListenAddress()
+	listener, err := lifecycle.Listen(addr, cfg.Server.SocketMode)
+	if err != nil {
+		appLogger.Fatalf("Failed to listen on %s: %v", addr, err)
+	}
 	srv := &http.Server{
-		Addr:         addr,
 		Handler:      router,
 		ReadTimeout:  cfg.Server.ReadTimeout,
 		WriteTimeout: cfg.Server.WriteTimeout,
 		IdleTimeout:  cfg.Server.IdleTimeout,
 	}
+	// End session event streams, which would otherwise hold up shutdown
+	srv.RegisterOnShutdown(sessionEvents.Close)
 
 	// Start server in a goroutine
 	go func() {
 		appLogger.Infof("Server starting on %s", addr)
-		if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+		if err := srv.Serve(listener); err != nil && err != http.ErrServerClosed {
 			appLogger.Fatalf("Failed to start server: %v", err)
 		}
 	}()
 
-	// Wait for interrupt signal to gracefully shutdown the server
+	// Start background jobs
+	jobs.Start()
+
+	// Create and start management server if configured
+	var adminSrv *http.Server
+	if adminRouter != nil {
+		adminAddr := fmt.Sprintf("%s:%s", cfg.Server.AdminHost, cfg.Server.AdminPort)
+		adminTLS, err := config.NewAdminTLSConfig(cfg.Server)
+		if err != nil {
+			appLogger.Fatal("Failed to configure management TLS:", err)
+		}
+		adminSrv = &http.Server{
+			Addr:        adminAddr,
+			Handler:     adminRouter,
+			ReadTimeout: cfg.Server.ReadTimeout,
+			IdleTimeout: cfg.Server.IdleTimeout,
+			TLSConfig:   adminTLS,
+		}
+
+		go func() {
+			appLogger.Infof("Management server starting on %s", adminAddr)
+			serve := adminSrv.ListenAndServe
+			if adminTLS != nil {
+				// Certificates are taken from TLSConfig
+				serve = func() error { return adminSrv.ListenAndServeTLS("", "") }
+			}
+			if err := serve(); err != nil && err != http.ErrServerClosed {
+				appLogger.Fatalf("Failed to start management server: %v", err)
+			}
+		}()
+	}
+
+	// Wait for interrupt signal or drain request to gracefully shutdown the server
 	quit := make(chan os.Signal, 1)
 	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
-	<-quit
+	select {
+	case <-quit:
+	case <-drainer.Draining():
+	}
+
+	// Fail readiness and give load balancers time to stop routing traffic
+	drainer.Start()
+	appLogger.Infof("Draining server for %s (%d requests in flight)...", cfg.Server.DrainPeriod, drainer.InFlight())
+	time.Sleep(cfg.Server.DrainPeriod)
 
 	appLogger.Info("Shutting down server...")
 
-	// Graceful shutdown with timeout
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	// Graceful shutdown with timeout, waiting for in-flight requests
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.Server.ShutdownTimeout)
 	defer cancel()
 
 	if err := srv.Shutdown(ctx); err != nil {
 		appLogger.Fatal("Server forced to shutdown:", err)
 	}
 
+	if adminSrv != nil {
+		if err := adminSrv.Shutdown(ctx); err != nil {
+			appLogger.Error("Management server forced to shutdown:", err)
+		}
+	}
+
+	// Stop background jobs and write out pending API key usage
+	jobs.Stop()
+	if err := apiKeyUsage.Flush(context.Background()); err != nil {
+		appLogger.Error("Error flushing API key usage:", err)
+	}
+
+	// Let pending password reset requests and in-flight event handlers
+	// (webhooks, emails) finish
+	passwordResetHandler.Wait()
+	eventBus.Wait()
+
+	// Close Redis connection
+	stopCacheListener()
+	if redisClient != nil {
+		if err := redisClient.Close(); err != nil {
+			appLogger.Error("Error closing redis:", err)
+		}
+	}
+
 	// Close database connection
 	if err := config.CloseDatabase(db); err != nil {
 		appLogger.Error("Error closing database:", err)
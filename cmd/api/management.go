@@ -0,0 +1,76 @@
+package main
+
+import (
+	"gojwt-rest-api/internal/handler"
+	"gojwt-rest-api/internal/metrics"
+	"gojwt-rest-api/internal/middleware"
+	"gojwt-rest-api/internal/service"
+	"net/http/pprof"
+
+	"github.com/gin-gonic/gin"
+)
+
+// managementRoutes holds the dependencies of the operational endpoints
+type managementRoutes struct {
+	jwtSecret   string
+	authOptions []middleware.AuthOption
+	// clientPrincipals authenticate mTLS clients of the management listener
+	clientPrincipals map[string]uint
+	userService      service.UserService
+	healthHandler    *handler.HealthHandler
+	metricsHandler   *handler.MetricsHandler
+	rateLimitHandler *handler.RateLimitHandler
+	registry         *metrics.Registry
+}
+
+// register mounts health, metrics and admin operations on router.
+// managementListener is set on the internal management listener: pprof is
+// only exposed there, and on the public listener metrics are only served to
+// callers inside the trust boundary.
+func (m *managementRoutes) register(router *gin.Engine, managementListener bool) {
+	// Health check endpoints
+	router.GET("/health", m.healthHandler.Health)
+	router.GET("/health/ready", m.healthHandler.Ready)
+
+	// Metrics endpoint
+	metricsRoutes := router.Group("/metrics")
+	if !managementListener {
+		metricsRoutes.Use(middleware.InternalOnlyMiddleware())
+	}
+	{
+		metricsRoutes.GET("", gin.WrapH(m.registry.Handler()))
+		metricsRoutes.GET("/routes", m.metricsHandler.RouteStats)
+	}
+
+	// Admin operations (admin only)
+	adminOps := router.Group("/admin")
+	if len(m.clientPrincipals) > 0 {
+		adminOps.Use(middleware.ClientCertMiddleware(m.clientPrincipals))
+	}
+	adminOps.Use(middleware.AuthMiddleware(m.jwtSecret, m.authOptions...))
+	adminOps.Use(middleware.RevocationMiddleware(m.userService))
+	adminOps.Use(middleware.AdminMiddleware(m.userService))
+	{
+		// Drain - fails readiness, then shuts down gracefully
+		adminOps.POST("/drain", m.healthHandler.Drain)
+		// SLO compliance summary
+		adminOps.GET("/slo", m.metricsHandler.SLO)
+		// Rate limiter state, for tuning limits
+		adminOps.GET("/rate-limits", m.rateLimitHandler.Stats)
+	}
+
+	if managementListener {
+		debug := router.Group("/debug/pprof")
+		{
+			debug.GET("/", gin.WrapF(pprof.Index))
+			debug.GET("/cmdline", gin.WrapF(pprof.Cmdline))
+			debug.GET("/profile", gin.WrapF(pprof.Profile))
+			debug.GET("/symbol", gin.WrapF(pprof.Symbol))
+			debug.POST("/symbol", gin.WrapF(pprof.Symbol))
+			debug.GET("/trace", gin.WrapF(pprof.Trace))
+			debug.GET("/:profile", func(c *gin.Context) {
+				pprof.Handler(c.Param("profile")).ServeHTTP(c.Writer, c.Request)
+			})
+		}
+	}
+}
@@ -0,0 +1,11 @@
+package main
+
+// Plugins are enabled by importing them here for their side effect of
+// registering with the plugin package, so that forks add features without
+// patching the core files:
+//
+//	import (
+//		_ "gojwt-rest-api/plugins/auditexport"
+//	)
+//
+// See internal/plugin for what plugins can extend.
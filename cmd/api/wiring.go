@@ -0,0 +1,121 @@
+package main
+
+import (
+	"fmt"
+	"gojwt-rest-api/internal/domain"
+	"gojwt-rest-api/internal/middleware"
+	"gojwt-rest-api/internal/routecheck"
+	"net/http"
+	"strings"
+)
+
+// publicAPIRoutes are the only /api/v1 routes served without authentication
+var publicAPIRoutes = []routecheck.Route{
+	{Method: http.MethodPost, Path: "/api/v1/auth/register"},
+	{Method: http.MethodPost, Path: "/api/v1/auth/check-email"},
+	{Method: http.MethodPost, Path: "/api/v1/auth/login/start"},
+	{Method: http.MethodPost, Path: "/api/v1/auth/login"},
+	{Method: http.MethodPost, Path: "/api/v1/auth/refresh"},
+	{Method: http.MethodPost, Path: "/api/v1/auth/email-change/revert"},
+	{Method: http.MethodPost, Path: "/api/v1/auth/unlock/request"},
+	{Method: http.MethodPost, Path: "/api/v1/auth/unlock"},
+	{Method: http.MethodPost, Path: "/api/v1/auth/password/forgot"},
+	{Method: http.MethodPost, Path: "/api/v1/auth/password/reset"},
+	{Method: http.MethodPost, Path: "/api/v1/report"},
+}
+
+// apiKeyRoutes are the only routes accepting API keys, with the scope the key
+// must hold
+var apiKeyRoutes = middleware.APIKeyRoutes{
+	"GET /api/v1/profile":            domain.ScopeProfileRead,
+	"PUT /api/v1/profile":            domain.ScopeProfileWrite,
+	"GET /api/v1/profile/onboarding": domain.ScopeProfileRead,
+	"GET /api/v1/users/profile":      domain.ScopeProfileRead,
+}
+
+// apiKeyRefusedPrefixes are the routes API keys never act on: admin
+// operations, API key management, authentication and organization management
+var apiKeyRefusedPrefixes = []string{
+	"/admin",
+	"/api/v1/admin",
+	"/api/v1/api-keys",
+	"/api/v1/auth",
+	"/api/v1/organizations",
+	"/api/v1/invitations",
+}
+
+// checkAPIKeyRoutes verifies that no route accepting API keys is below one of
+// apiKeyRefusedPrefixes
+func checkAPIKeyRoutes(routes middleware.APIKeyRoutes) error {
+	for route := range routes {
+		_, path, _ := strings.Cut(route, " ")
+		for _, prefix := range apiKeyRefusedPrefixes {
+			if routecheck.PathPrefix(prefix)("", path) {
+				return fmt.Errorf("%s: API keys are refused below %s", route, prefix)
+			}
+		}
+	}
+	return nil
+}
+
+// requiredRoutes must be registered on the public listener
+var requiredRoutes = []routecheck.Route{
+	{Method: http.MethodPost, Path: "/api/v1/auth/register"},
+	{Method: http.MethodPost, Path: "/api/v1/auth/login"},
+	{Method: http.MethodPost, Path: "/api/v1/auth/refresh"},
+	{Method: http.MethodPost, Path: "/api/v1/auth/logout"},
+}
+
+// publicListenerRules are the invariants checked on the routes of the public
+// listener only
+var publicListenerRules = []routecheck.Rule{
+	{
+		Description: "metrics must only be served to internal callers on the public listener",
+		Match:       routecheck.PathPrefix("/metrics"),
+		AnyOf:       []interface{}{middleware.InternalOnlyMiddleware},
+	},
+}
+
+// wiringRules are the invariants checked on every route at startup.
+// pluginPublic are the public routes registered by plugins.
+func wiringRules(pluginPublic ...routecheck.Route) []routecheck.Rule {
+	public := append(append([]routecheck.Route{}, publicAPIRoutes...), pluginPublic...)
+	return []routecheck.Rule{
+		{
+			Description: "authentication middleware is missing, or add the route to publicAPIRoutes",
+			Match:       routecheck.PathPrefix("/api/v1", public...),
+			AnyOf:       []interface{}{middleware.AuthMiddleware},
+		},
+		{
+			Description: "revocation middleware is missing after the authentication middleware",
+			Match:       routecheck.PathPrefix("/"),
+			When:        []interface{}{middleware.AuthMiddleware},
+			AnyOf:       []interface{}{middleware.RevocationMiddleware},
+		},
+		{
+			Description: "admin middleware is missing",
+			Match:       routecheck.PathPrefix("/api/v1/admin"),
+			AnyOf:       []interface{}{middleware.AdminMiddleware, middleware.AdminScopeMiddleware},
+		},
+		{
+			Description: "authentication middleware is missing",
+			Match:       routecheck.PathPrefix("/admin"),
+			AnyOf:       []interface{}{middleware.AuthMiddleware},
+		},
+		{
+			Description: "admin middleware is missing",
+			Match:       routecheck.PathPrefix("/admin"),
+			AnyOf:       []interface{}{middleware.AdminMiddleware},
+		},
+		{
+			Description: "development routes must only be served to local callers",
+			Match:       routecheck.PathPrefix("/_dev"),
+			AnyOf:       []interface{}{middleware.LoopbackOnlyMiddleware},
+		},
+		{
+			Description: "SCIM authentication middleware is missing",
+			Match:       routecheck.PathPrefix("/scim"),
+			AnyOf:       []interface{}{middleware.SCIMAuthMiddleware},
+		},
+	}
+}
@@ -0,0 +1,162 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"gojwt-rest-api/internal/accounts"
+	"gojwt-rest-api/internal/config"
+	"gojwt-rest-api/internal/repository"
+	"gojwt-rest-api/pkg/logger"
+	"io"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+)
+
+// emailList is a flag collecting emails, repeated or comma-separated
+type emailList []string
+
+func (l *emailList) String() string {
+	return strings.Join(*l, ",")
+}
+
+func (l *emailList) Set(value string) error {
+	for _, email := range strings.Split(value, ",") {
+		if email = strings.TrimSpace(email); email != "" {
+			*l = append(*l, email)
+		}
+	}
+	return nil
+}
+
+// runExportUsers writes the selected accounts of the configured database to
+// an archive and returns the process exit code
+func runExportUsers(args []string, stderr io.Writer) int {
+	fs := flag.NewFlagSet("export-users", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	var emails emailList
+	fs.Var(&emails, "email", "email of an account to export, repeatable or comma-separated")
+	all := fs.Bool("all", false, "export every account")
+	activeOnly := fs.Bool("active-only", false, "skip deactivated accounts")
+	withoutPasswords := fs.Bool("without-passwords", false, "leave password hashes out, imported accounts cannot log in")
+	output := fs.String("output", "accounts.json", "archive file to write")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if *all == (len(emails) > 0) {
+		fmt.Fprintln(stderr, "pass either --email or --all")
+		return 2
+	}
+
+	appLogger := logger.New()
+
+	cfg, err := config.Load()
+	if err != nil {
+		appLogger.Error("Failed to load configuration:", err)
+		return 1
+	}
+	db, err := openDatabase(cfg, appLogger)
+	if err != nil {
+		appLogger.Error("Failed to open database:", err)
+		return 1
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	archive, err := accounts.NewExporter(repository.NewUserRepository(db)).Export(ctx, accounts.ExportOptions{
+		Emails:           emails,
+		All:              *all,
+		ActiveOnly:       *activeOnly,
+		WithoutPasswords: *withoutPasswords,
+	})
+	if err != nil {
+		appLogger.Error("Export failed:", err)
+		return 1
+	}
+
+	// The archive holds password hashes, keep it private
+	file, err := os.OpenFile(*output, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0o600)
+	if err != nil {
+		appLogger.Error("Failed to create output file:", err)
+		return 1
+	}
+	defer file.Close()
+	encoder := json.NewEncoder(file)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(archive); err != nil {
+		appLogger.Error("Failed to write output file:", err)
+		return 1
+	}
+
+	appLogger.Infof("Exported %d accounts to %s", len(archive.Accounts), *output)
+	return 0
+}
+
+// runImportUsers creates the accounts of an archive in the configured
+// database and returns the process exit code
+func runImportUsers(args []string, stderr io.Writer) int {
+	fs := flag.NewFlagSet("import-users", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	input := fs.String("input", "accounts.json", "archive file to read")
+	onConflict := fs.String("on-conflict", accounts.ConflictSkip, "existing emails: skip, overwrite or fail")
+	dryRun := fs.Bool("dry-run", false, "report what would be imported without writing")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+
+	appLogger := logger.New()
+
+	file, err := os.Open(*input)
+	if err != nil {
+		appLogger.Error("Failed to open input file:", err)
+		return 1
+	}
+	defer file.Close()
+	var archive accounts.Archive
+	if err := json.NewDecoder(file).Decode(&archive); err != nil {
+		appLogger.Error("Failed to read input file:", err)
+		return 1
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		appLogger.Error("Failed to load configuration:", err)
+		return 1
+	}
+	db, err := openDatabase(cfg, appLogger)
+	if err != nil {
+		appLogger.Error("Failed to open database:", err)
+		return 1
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	importer := accounts.NewImporter(repository.NewUserRepository(db), repository.NewTokenRepository(db))
+	report, err := importer.Import(ctx, &archive, accounts.ImportOptions{OnConflict: *onConflict, DryRun: *dryRun})
+	if errors.Is(err, accounts.ErrConflict) {
+		appLogger.Errorf("Import aborted, nothing was written: %v", err)
+		return 1
+	}
+	if err != nil {
+		if report != nil {
+			appLogger.Errorf("Import failed after creating %d and updating %d accounts: %v", report.Created, report.Updated, err)
+		} else {
+			appLogger.Error("Import failed:", err)
+		}
+		return 1
+	}
+
+	prefix := "Imported"
+	if *dryRun {
+		prefix = "Dry run, would import"
+	}
+	appLogger.Infof("%s %d accounts: %d created, %d updated, %d skipped", prefix,
+		len(archive.Accounts), report.Created, report.Updated, report.Skipped)
+	return 0
+}
@@ -0,0 +1,44 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"gojwt-rest-api/internal/config"
+	"gojwt-rest-api/internal/kms"
+	"gojwt-rest-api/internal/pii"
+	"gojwt-rest-api/migrations"
+	"gojwt-rest-api/pkg/logger"
+
+	"gorm.io/gorm"
+)
+
+// openDatabase connects to the configured database like the API does:
+// decrypting KMS secrets, enabling PII encryption and running migrations
+func openDatabase(cfg *config.Config, appLogger *logger.Logger) (*gorm.DB, error) {
+	// Decrypt secrets stored encrypted with the KMS key
+	decrypter, err := kms.New(cfg.KMS)
+	if err != nil {
+		return nil, fmt.Errorf("create KMS client: %w", err)
+	}
+	if err := kms.ResolveSecrets(context.Background(), cfg, decrypter); err != nil {
+		return nil, fmt.Errorf("decrypt secrets: %w", err)
+	}
+
+	// Encrypt personal data like the API does
+	if cfg.PII.Enabled() {
+		keyring, err := pii.NewKeyring(cfg.PII.EncryptionKey, cfg.PII.PreviousKeys, cfg.PII.BlindIndexKey)
+		if err != nil {
+			return nil, fmt.Errorf("load PII encryption keys: %w", err)
+		}
+		pii.Use(keyring)
+	}
+
+	db, err := config.NewDatabase(cfg, appLogger)
+	if err != nil {
+		return nil, fmt.Errorf("connect to database: %w", err)
+	}
+	if err := migrations.Migrate(db); err != nil {
+		return nil, fmt.Errorf("run migrations: %w", err)
+	}
+	return db, nil
+}
@@ -0,0 +1,133 @@
+package main
+
+import (
+	"context"
+	"encoding/csv"
+	"flag"
+	"fmt"
+	"gojwt-rest-api/internal/config"
+	"gojwt-rest-api/internal/loadgen"
+	"gojwt-rest-api/internal/repository"
+	"gojwt-rest-api/internal/service"
+	"gojwt-rest-api/internal/utils"
+	"gojwt-rest-api/pkg/logger"
+	"io"
+	"os"
+	"os/signal"
+	"strconv"
+	"syscall"
+	"time"
+)
+
+// loadgenEnvironments are the values of APP_ENV loadgen runs with without
+// --allow-production. An unset APP_ENV is not one of them, as it may be a
+// production deployment relying on the default.
+var loadgenEnvironments = map[string]bool{"development": true, "test": true, "staging": true}
+
+// runLoadgen creates synthetic users in the configured database, writing their
+// credentials to a CSV file, and returns the process exit code
+func runLoadgen(args []string, stderr io.Writer) int {
+	fs := flag.NewFlagSet("loadgen", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	count := fs.Int("count", 1000, "number of users to create")
+	start := fs.Int("start", 1, "sequence number of the first user")
+	prefix := fs.String("email-prefix", loadgen.DefaultEmailPrefix, "email prefix, emails are <prefix>-<n>@<domain>")
+	domain := fs.String("email-domain", loadgen.DefaultEmailDomain, "email domain")
+	password := fs.String("password", loadgen.DefaultPassword, "password of every user")
+	batchSize := fs.Int("batch-size", loadgen.DefaultBatchSize, "users per insert statement")
+	workers := fs.Int("workers", 0, "passwords hashed in parallel (default number of CPUs)")
+	sessions := fs.Bool("sessions", false, "issue an access and refresh token for each user")
+	clientID := fs.String("client-id", "", "client application sessions are issued for")
+	output := fs.String("output", "loadgen_users.csv", "CSV file receiving the credentials")
+	allowProduction := fs.Bool("allow-production", false, "allow running without APP_ENV set to development, test or staging")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if *count <= 0 {
+		fmt.Fprintln(stderr, "--count must be positive")
+		return 2
+	}
+
+	appLogger := logger.New()
+
+	cfg, err := config.Load()
+	if err != nil {
+		appLogger.Error("Failed to load configuration:", err)
+		return 1
+	}
+	// config.Load exported the variables of .env, APP_ENV is unset only when
+	// neither sets it
+	if appEnv := os.Getenv("APP_ENV"); !loadgenEnvironments[appEnv] && !*allowProduction {
+		appLogger.Errorf("Refusing to generate users with APP_ENV=%q, set APP_ENV to development, test or staging or pass --allow-production to override", appEnv)
+		return 1
+	}
+
+	db, err := openDatabase(cfg, appLogger)
+	if err != nil {
+		appLogger.Error("Failed to open database:", err)
+		return 1
+	}
+
+	file, err := os.Create(*output)
+	if err != nil {
+		appLogger.Error("Failed to create output file:", err)
+		return 1
+	}
+	defer file.Close()
+	writer := csv.NewWriter(file)
+	if err := writer.Write([]string{"user_id", "email", "password", "access_token", "refresh_token"}); err != nil {
+		appLogger.Error("Failed to write output file:", err)
+		return 1
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	// Sessions are issued by the user service, signed with the current
+	// signing key and carrying the claims of regular logins
+	signingKeys := service.NewSigningKeyService(repository.NewSigningKeyRepository(db),
+		utils.NewKeySet(append([]string{cfg.JWT.Secret}, cfg.JWT.StandbySecrets...)...))
+	if err := signingKeys.Refresh(); err != nil {
+		appLogger.Error("Failed to check JWT signing keys:", err)
+		return 1
+	}
+	userOpts := []service.UserServiceOption{service.WithSigningKeys(signingKeys.Keys())}
+	if len(cfg.Session.Clients) > 0 {
+		userOpts = append(userOpts, service.WithClientApplications(cfg.Session.Clients, cfg.Session.ClientIDRequired))
+	}
+	userService := service.NewUserService(repository.NewUserRepository(db), repository.NewTokenRepository(db), cfg.JWT.Secret,
+		cfg.JWT.AccessTokenExpiration, cfg.JWT.RefreshTokenExpiration, userOpts...)
+
+	generator := loadgen.NewGenerator(repository.NewUserRepository(db), userService)
+	began := time.Now()
+	created, err := generator.Generate(ctx, loadgen.Options{
+		Count:       *count,
+		Start:       *start,
+		EmailPrefix: *prefix,
+		EmailDomain: *domain,
+		Password:    *password,
+		BatchSize:   *batchSize,
+		Workers:     *workers,
+		Sessions:    *sessions,
+		ClientID:    *clientID,
+	}, func(credential *loadgen.Credential) error {
+		return writer.Write([]string{
+			strconv.FormatUint(uint64(credential.UserID), 10),
+			credential.Email,
+			credential.Password,
+			credential.AccessToken,
+			credential.RefreshToken,
+		})
+	})
+	writer.Flush()
+	if flushErr := writer.Error(); err == nil {
+		err = flushErr
+	}
+	if err != nil {
+		appLogger.Errorf("Load generation failed after %d users: %v", created, err)
+		return 1
+	}
+
+	appLogger.Infof("Created %d users in %s, credentials written to %s", created, time.Since(began).Round(time.Millisecond), *output)
+	return 0
+}
@@ -0,0 +1,49 @@
+// Command gojwt provides operational subcommands for a deployed API.
+//
+//	gojwt smoke --base-url https://api.example.com
+//	gojwt loadgen --count 10000 --sessions
+//	gojwt export-users --email jane@example.com --output accounts.json
+//	gojwt import-users --input accounts.json --on-conflict skip
+//	gojwt rotate-signing-key --reason "key leaked in CI logs"
+package main
+
+import (
+	"fmt"
+	"os"
+)
+
+const usage = `Usage: gojwt <command> [flags]
+
+Commands:
+  smoke         Run register, login, refresh, profile and logout against a deployed instance
+  loadgen       Create synthetic users with known credentials for performance tests
+  export-users  Export selected accounts, without sessions, to a JSON archive
+  import-users  Import an exported archive, resolving existing emails with --on-conflict
+  rotate-signing-key
+                Report a signing key compromised, reject the access tokens it signed and print a new key
+`
+
+func main() {
+	if len(os.Args) < 2 {
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+
+	switch os.Args[1] {
+	case "smoke":
+		os.Exit(runSmoke(os.Args[2:], os.Stdout, os.Stderr))
+	case "loadgen":
+		os.Exit(runLoadgen(os.Args[2:], os.Stderr))
+	case "export-users":
+		os.Exit(runExportUsers(os.Args[2:], os.Stderr))
+	case "import-users":
+		os.Exit(runImportUsers(os.Args[2:], os.Stderr))
+	case "rotate-signing-key":
+		os.Exit(runRotateSigningKey(os.Args[2:], os.Stdout, os.Stderr))
+	case "help", "-h", "--help":
+		fmt.Fprint(os.Stdout, usage)
+	default:
+		fmt.Fprintf(os.Stderr, "unknown command %q\n\n%s", os.Args[1], usage)
+		os.Exit(2)
+	}
+}
@@ -0,0 +1,109 @@
+package main
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"flag"
+	"fmt"
+	"gojwt-rest-api/internal/config"
+	"gojwt-rest-api/internal/domain"
+	"gojwt-rest-api/internal/repository"
+	"gojwt-rest-api/internal/service"
+	"gojwt-rest-api/internal/utils"
+	"gojwt-rest-api/pkg/logger"
+	"io"
+)
+
+// signingKeyBytes is the size of generated signing keys (256 bits)
+const signingKeyBytes = 32
+
+// runRotateSigningKey reports a signing key of the configured key set as
+// compromised, the one tokens are currently signed with by default, prints
+// the key tokens are signed with from now on and a replacement key, and
+// returns the process exit code
+func runRotateSigningKey(args []string, stdout, stderr io.Writer) int {
+	fs := flag.NewFlagSet("rotate-signing-key", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	reason := fs.String("reason", "", "why the key is considered compromised, recorded with the incident")
+	keyID := fs.String("key-id", "", "ID (kid) of the compromised key, the current signing key by default")
+	revokeRefreshTokens := fs.Bool("revoke-refresh-tokens", false, "also revoke refresh tokens, every user must log in again")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if *reason == "" {
+		fmt.Fprintln(stderr, "--reason is required")
+		return 2
+	}
+
+	appLogger := logger.New()
+
+	cfg, err := config.Load()
+	if err != nil {
+		appLogger.Error("Failed to load configuration:", err)
+		return 1
+	}
+	db, err := openDatabase(cfg, appLogger)
+	if err != nil {
+		appLogger.Error("Failed to open database:", err)
+		return 1
+	}
+
+	secrets := append([]string{cfg.JWT.Secret}, cfg.JWT.StandbySecrets...)
+	signingKeys := service.NewSigningKeyService(repository.NewSigningKeyRepository(db), utils.NewKeySet(secrets...))
+	if err := signingKeys.Refresh(); err != nil && !errors.Is(err, domain.ErrNoSigningKey) {
+		appLogger.Error("Failed to load compromised signing keys:", err)
+		return 1
+	}
+	if *keyID == "" {
+		if *keyID, err = signingKeys.Keys().SigningKeyID(); err != nil {
+			fmt.Fprintln(stderr, "Every configured signing key was already reported compromised, deploy a new JWT_SECRET")
+			return 1
+		}
+	}
+	var compromised string
+	for _, secret := range secrets {
+		if domain.SigningKeyFingerprint(secret) == *keyID {
+			compromised = secret
+		}
+	}
+	if compromised == "" {
+		fmt.Fprintf(stderr, "No key of JWT_SECRET and JWT_STANDBY_SECRETS has ID %q\n", *keyID)
+		return 2
+	}
+
+	replacement := make([]byte, signingKeyBytes)
+	if _, err := rand.Read(replacement); err != nil {
+		appLogger.Error("Failed to generate signing key:", err)
+		return 1
+	}
+
+	incident, err := signingKeys.ReportCompromise(&domain.SigningKeyCompromise{
+		Secret:              compromised,
+		Reason:              *reason,
+		RevokeRefreshTokens: *revokeRefreshTokens,
+	})
+	if err != nil {
+		appLogger.Error("Failed to record signing key incident:", err)
+		return 1
+	}
+
+	fmt.Fprintf(stdout, "Signing key %s reported compromised (incident %d)\n", *keyID, incident.ID)
+	fmt.Fprintf(stdout, "Access tokens signed with it are rejected by every server within JWT_KEY_REFRESH_INTERVAL (%s)\n", cfg.JWT.KeyRefreshInterval)
+	next, err := signingKeys.Keys().SigningKeyID()
+	if err != nil {
+		fmt.Fprintln(stdout, "No standby key is left: servers cannot issue access tokens until the replacement key is deployed")
+	} else {
+		fmt.Fprintf(stdout, "New access tokens are signed with standby key %s\n", next)
+	}
+	fmt.Fprintf(stdout, "Active sessions: %d\n", incident.OutstandingSessions)
+	if *revokeRefreshTokens {
+		fmt.Fprintf(stdout, "Refresh tokens revoked: %d, those users must log in again\n", incident.RefreshTokensRevoked)
+	} else {
+		fmt.Fprintln(stdout, "Refresh tokens kept: clients refresh to get access tokens signed with the standby key")
+	}
+	fmt.Fprintln(stdout)
+	fmt.Fprintln(stdout, "Deploy the replacement key and remove the compromised one from JWT_SECRET and JWT_STANDBY_SECRETS:")
+	fmt.Fprintf(stdout, "JWT_SECRET=%s\n", base64.StdEncoding.EncodeToString(replacement))
+	return 0
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"gojwt-rest-api/internal/smoke"
+	"io"
+	"net/http"
+	"os"
+	"time"
+)
+
+// runSmoke runs the smoke test and returns the process exit code. The
+// password of the smoke test account is read from SMOKE_PASSWORD, so that
+// it does not show up in the process list.
+func runSmoke(args []string, stdout, stderr io.Writer) int {
+	fs := flag.NewFlagSet("smoke", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	baseURL := fs.String("base-url", "", "base URL of the deployed API, e.g. https://api.example.com")
+	email := fs.String("email", os.Getenv("SMOKE_EMAIL"), "email of the smoke test account (default $SMOKE_EMAIL)")
+	timeout := fs.Duration("timeout", 10*time.Second, "timeout of each request")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if *baseURL == "" {
+		fmt.Fprintln(stderr, "--base-url is required")
+		return 2
+	}
+	password := os.Getenv("SMOKE_PASSWORD")
+	if *email == "" || password == "" {
+		fmt.Fprintln(stderr, "--email (or SMOKE_EMAIL) and SMOKE_PASSWORD of the smoke test account are required")
+		return 2
+	}
+
+	runner := smoke.New(*baseURL, *email, password, smoke.WithHTTPClient(&http.Client{Timeout: *timeout}))
+	results := runner.Run(context.Background())
+
+	fmt.Fprintf(stdout, "Smoke test against %s as %s\n", *baseURL, *email)
+	for _, result := range results {
+		switch {
+		case result.Skipped:
+			fmt.Fprintf(stdout, "SKIP  %-9s\n", result.Step)
+		case result.Passed:
+			fmt.Fprintf(stdout, "PASS  %-9s %d  %s\n", result.Step, result.Status, result.Duration.Round(time.Millisecond))
+		default:
+			fmt.Fprintf(stdout, "FAIL  %-9s %d  %s  %v\n", result.Step, result.Status, result.Duration.Round(time.Millisecond), result.Err)
+		}
+	}
+
+	if !smoke.Passed(results) {
+		fmt.Fprintln(stdout, "Smoke test failed")
+		return 1
+	}
+	fmt.Fprintln(stdout, "Smoke test passed")
+	return 0
+}
@@ -0,0 +1,68 @@
+// Command reencrypt encrypts personal data with the current PII encryption key.
+// Run it after enabling PII encryption or rotating PII_ENCRYPTION_KEY, then
+// remove the old key from PII_PREVIOUS_ENCRYPTION_KEYS.
+package main
+
+import (
+	"context"
+	"gojwt-rest-api/internal/config"
+	"gojwt-rest-api/internal/kms"
+	"gojwt-rest-api/internal/pii"
+	"gojwt-rest-api/migrations"
+	"gojwt-rest-api/pkg/logger"
+)
+
+func main() {
+	appLogger := logger.New()
+
+	cfg, err := config.Load()
+	if err != nil {
+		appLogger.Fatal("Failed to load configuration:", err)
+	}
+
+	// Decrypt secrets stored encrypted with the KMS key
+	decrypter, err := kms.New(cfg.KMS)
+	if err != nil {
+		appLogger.Fatal("Failed to create KMS client:", err)
+	}
+	if err := kms.ResolveSecrets(context.Background(), cfg, decrypter); err != nil {
+		appLogger.Fatal("Failed to decrypt secrets:", err)
+	}
+
+	if !cfg.PII.Enabled() {
+		appLogger.Fatal("PII_ENCRYPTION_KEY is not set")
+	}
+
+	keyring, err := pii.NewKeyring(cfg.PII.EncryptionKey, cfg.PII.PreviousKeys, cfg.PII.BlindIndexKey)
+	if err != nil {
+		appLogger.Fatal("Failed to load PII encryption keys:", err)
+	}
+
+	db, err := config.NewDatabase(cfg, appLogger)
+	if err != nil {
+		appLogger.Fatal("Failed to connect to database:", err)
+	}
+
+	// Add the blind index column before filling it in
+	if err := migrations.Migrate(db); err != nil {
+		appLogger.Fatal("Failed to run migrations:", err)
+	}
+
+	updated, err := migrations.ReencryptUserPII(db, keyring)
+	if err != nil {
+		appLogger.Fatalf("Re-encryption failed after %d users: %v", updated, err)
+	}
+	appLogger.Infof("Re-encrypted %d users", updated)
+
+	updated, err = migrations.ReencryptProfileHistory(db, keyring)
+	if err != nil {
+		appLogger.Fatalf("Re-encryption failed after %d profile changes: %v", updated, err)
+	}
+	appLogger.Infof("Re-encrypted %d profile changes", updated)
+
+	updated, err = migrations.ReencryptAbuseReports(db, keyring)
+	if err != nil {
+		appLogger.Fatalf("Re-encryption failed after %d abuse reports: %v", updated, err)
+	}
+	appLogger.Infof("Re-encrypted %d abuse reports", updated)
+}
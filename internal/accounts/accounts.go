@@ -0,0 +1,340 @@
+// Package accounts exports user accounts to a JSON archive and imports them
+// into another environment, to seed staging or move users between
+// deployments. Archives carry password hashes, never sessions, API keys or
+// two-factor secrets.
+package accounts
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"gojwt-rest-api/internal/domain"
+	"gojwt-rest-api/internal/repository"
+	"gojwt-rest-api/internal/utils"
+	"strings"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// FormatVersion is the version of the archive format written by Export
+const FormatVersion = 1
+
+// exportPageSize is the number of users read per query when exporting all users
+const exportPageSize = 500
+
+// Conflict policies, applied to accounts whose email already exists in the
+// target environment
+const (
+	// ConflictSkip leaves the existing account untouched
+	ConflictSkip = "skip"
+	// ConflictOverwrite replaces the existing account with the archived one
+	ConflictOverwrite = "overwrite"
+	// ConflictFail aborts the import before writing anything
+	ConflictFail = "fail"
+)
+
+// ErrConflict is returned by Import with ConflictFail when archived accounts
+// already exist
+var ErrConflict = errors.New("accounts already exist")
+
+// Archive is the exported file
+type Archive struct {
+	Version    int        `json:"version"`
+	ExportedAt time.Time  `json:"exported_at"`
+	Accounts   []*Account `json:"accounts"`
+}
+
+// Account is an exported user. Organization memberships are not exported, as
+// organizations are not shared between environments.
+type Account struct {
+	Email string `json:"email"`
+	Name  string `json:"name"`
+	// FirstName, LastName and DisplayName are missing from archives exported
+	// before users had name parts, which are imported from Name instead
+	FirstName   string `json:"first_name,omitempty"`
+	LastName    string `json:"last_name,omitempty"`
+	DisplayName string `json:"display_name,omitempty"`
+	// PasswordHash is the bcrypt hash of the password, empty when exported
+	// without passwords
+	PasswordHash     string     `json:"password_hash,omitempty"`
+	IsAdmin          bool       `json:"is_admin"`
+	AdminScopes      string     `json:"admin_scopes,omitempty"`
+	InactivityExempt bool       `json:"inactivity_exempt"`
+	DeactivatedAt    *time.Time `json:"deactivated_at,omitempty"`
+	CreatedAt        time.Time  `json:"created_at"`
+}
+
+// ExportOptions selects the accounts to export
+type ExportOptions struct {
+	// Emails selects accounts by email; every email must exist
+	Emails []string
+	// All selects every account instead
+	All bool
+	// ActiveOnly skips deactivated accounts
+	ActiveOnly bool
+	// WithoutPasswords leaves password hashes out, so imported accounts
+	// cannot be logged into
+	WithoutPasswords bool
+}
+
+// ImportOptions controls how an archive is imported
+type ImportOptions struct {
+	// OnConflict is the conflict policy, ConflictSkip by default
+	OnConflict string
+	// DryRun reports what would be imported without writing anything
+	DryRun bool
+}
+
+// Report summarizes an import
+type Report struct {
+	Created int
+	Updated int
+	Skipped int
+	// Conflicts lists the archived emails that already existed
+	Conflicts []string
+}
+
+// Exporter reads accounts into archives
+type Exporter struct {
+	userRepo repository.UserRepository
+}
+
+// NewExporter creates an exporter reading from userRepo
+func NewExporter(userRepo repository.UserRepository) *Exporter {
+	return &Exporter{userRepo: userRepo}
+}
+
+// Export returns the archive of the accounts selected by opts
+func (e *Exporter) Export(ctx context.Context, opts ExportOptions) (*Archive, error) {
+	if opts.All == (len(opts.Emails) > 0) {
+		return nil, fmt.Errorf("select accounts either by email or all of them")
+	}
+
+	var users []*domain.User
+	if opts.All {
+		var active *bool
+		if opts.ActiveOnly {
+			active = &opts.ActiveOnly
+		}
+		filter := &domain.UserFilter{Active: active}
+		for offset := 0; ; offset += exportPageSize {
+			if err := ctx.Err(); err != nil {
+				return nil, err
+			}
+			page, _, err := e.userRepo.FindByFilter(filter, offset, exportPageSize)
+			if err != nil {
+				return nil, fmt.Errorf("list users: %w", err)
+			}
+			users = append(users, page...)
+			if len(page) < exportPageSize {
+				break
+			}
+		}
+	} else {
+		for _, email := range opts.Emails {
+			user, err := e.userRepo.FindByEmail(email)
+			if err != nil {
+				return nil, fmt.Errorf("find %s: %w", email, err)
+			}
+			if opts.ActiveOnly && user.DeactivatedAt != nil {
+				continue
+			}
+			users = append(users, user)
+		}
+	}
+
+	archive := &Archive{
+		Version:    FormatVersion,
+		ExportedAt: time.Now().UTC(),
+		Accounts:   make([]*Account, 0, len(users)),
+	}
+	for _, user := range users {
+		// Anonymized users have no personal data left to move
+		if user.AnonymizedAt != nil {
+			continue
+		}
+		account := &Account{
+			Email:            user.Email,
+			Name:             user.FullName(),
+			FirstName:        user.FirstName,
+			LastName:         user.LastName,
+			DisplayName:      user.DisplayName,
+			PasswordHash:     user.Password,
+			IsAdmin:          user.IsAdmin,
+			AdminScopes:      user.AdminScopes,
+			InactivityExempt: user.InactivityExempt,
+			DeactivatedAt:    user.DeactivatedAt,
+			CreatedAt:        user.CreatedAt,
+		}
+		if opts.WithoutPasswords {
+			account.PasswordHash = ""
+		}
+		archive.Accounts = append(archive.Accounts, account)
+	}
+	return archive, nil
+}
+
+// Importer writes archived accounts
+type Importer struct {
+	userRepo  repository.UserRepository
+	tokenRepo repository.TokenRepository
+}
+
+// NewImporter creates an importer writing to userRepo, revoking the sessions
+// of overwritten accounts in tokenRepo
+func NewImporter(userRepo repository.UserRepository, tokenRepo repository.TokenRepository) *Importer {
+	return &Importer{
+		userRepo:  userRepo,
+		tokenRepo: tokenRepo,
+	}
+}
+
+// Import creates the archived accounts, resolving existing emails with
+// opts.OnConflict. The archive is validated, and with ConflictFail checked
+// for conflicts, before anything is written.
+func (i *Importer) Import(ctx context.Context, archive *Archive, opts ImportOptions) (*Report, error) {
+	if opts.OnConflict == "" {
+		opts.OnConflict = ConflictSkip
+	}
+	if opts.OnConflict != ConflictSkip && opts.OnConflict != ConflictOverwrite && opts.OnConflict != ConflictFail {
+		return nil, fmt.Errorf("unknown conflict policy %q", opts.OnConflict)
+	}
+	if err := validate(archive); err != nil {
+		return nil, err
+	}
+
+	existing := make([]*domain.User, len(archive.Accounts))
+	report := &Report{}
+	for n, account := range archive.Accounts {
+		user, err := i.userRepo.FindByEmail(account.Email)
+		if err == nil {
+			existing[n] = user
+			report.Conflicts = append(report.Conflicts, account.Email)
+		} else if err != domain.ErrUserNotFound {
+			return nil, fmt.Errorf("find %s: %w", account.Email, err)
+		}
+	}
+	if opts.OnConflict == ConflictFail && len(report.Conflicts) > 0 {
+		return report, fmt.Errorf("%w: %s", ErrConflict, strings.Join(report.Conflicts, ", "))
+	}
+
+	for n, account := range archive.Accounts {
+		if err := ctx.Err(); err != nil {
+			return report, err
+		}
+
+		user := existing[n]
+		switch {
+		case user == nil:
+			if !opts.DryRun {
+				if err := i.create(account); err != nil {
+					return report, fmt.Errorf("create %s: %w", account.Email, err)
+				}
+			}
+			report.Created++
+		case opts.OnConflict == ConflictOverwrite:
+			if !opts.DryRun {
+				if err := i.overwrite(user, account); err != nil {
+					return report, fmt.Errorf("overwrite %s: %w", account.Email, err)
+				}
+			}
+			report.Updated++
+		default:
+			report.Skipped++
+		}
+	}
+	return report, nil
+}
+
+// create inserts the account, with an unusable password when the archive has none
+func (i *Importer) create(account *Account) error {
+	password := account.PasswordHash
+	if password == "" {
+		var err error
+		if password, err = unusablePassword(); err != nil {
+			return err
+		}
+	}
+	user := &domain.User{
+		Email:            account.Email,
+		Password:         password,
+		IsAdmin:          account.IsAdmin,
+		AdminScopes:      account.AdminScopes,
+		InactivityExempt: account.InactivityExempt,
+		DeactivatedAt:    account.DeactivatedAt,
+		CreatedAt:        account.CreatedAt,
+	}
+	account.setName(user)
+	return i.userRepo.Create(user)
+}
+
+// overwrite replaces user with the account, keeping the user's password when
+// the archive has none. A replaced password ends the user's sessions.
+func (i *Importer) overwrite(user *domain.User, account *Account) error {
+	if user.IsAdmin != account.IsAdmin || user.AdminScopes != account.AdminScopes {
+		user.PermVersion++
+	}
+	account.setName(user)
+	user.IsAdmin = account.IsAdmin
+	user.AdminScopes = account.AdminScopes
+	user.InactivityExempt = account.InactivityExempt
+	user.DeactivatedAt = account.DeactivatedAt
+
+	passwordChanged := account.PasswordHash != "" && account.PasswordHash != user.Password
+	if passwordChanged {
+		user.Password = account.PasswordHash
+		user.SessionEpoch++
+	}
+	if err := i.userRepo.Update(user); err != nil {
+		return err
+	}
+	if passwordChanged {
+		return i.tokenRepo.RevokeAllUserRefreshTokens(user.ID)
+	}
+	return nil
+}
+
+// validate rejects archives of another version, and accounts without an
+// email, listed twice or with a password hash that is not bcrypt
+func validate(archive *Archive) error {
+	if archive.Version != FormatVersion {
+		return fmt.Errorf("unsupported archive version %d", archive.Version)
+	}
+	seen := make(map[string]bool, len(archive.Accounts))
+	for n, account := range archive.Accounts {
+		if account == nil || account.Email == "" {
+			return fmt.Errorf("account %d has no email", n)
+		}
+		email := strings.ToLower(account.Email)
+		if seen[email] {
+			return fmt.Errorf("account %s is listed twice", account.Email)
+		}
+		seen[email] = true
+		if account.PasswordHash != "" {
+			if _, err := bcrypt.Cost([]byte(account.PasswordHash)); err != nil {
+				return fmt.Errorf("account %s has an invalid password hash", account.Email)
+			}
+		}
+	}
+	return nil
+}
+
+// unusablePassword hashes a random password nobody knows
+func unusablePassword() (string, error) {
+	password, err := utils.GenerateRandomPassword()
+	if err != nil {
+		return "", err
+	}
+	return utils.HashPassword(password)
+}
+
+// setName replaces the name of user with the name of the account
+func (a *Account) setName(user *domain.User) {
+	if a.FirstName == "" && a.LastName == "" && a.DisplayName == "" {
+		user.SetName(a.Name)
+		return
+	}
+	user.FirstName, user.LastName, user.DisplayName = a.FirstName, a.LastName, a.DisplayName
+	user.Name = user.FullName()
+}
@@ -0,0 +1,133 @@
+package cache
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// Invalidation is a cache invalidation propagated to other server instances
+type Invalidation struct {
+	Origin string   `json:"origin"`
+	Keys   []string `json:"keys,omitempty"`
+	Prefix string   `json:"prefix,omitempty"`
+}
+
+// Broadcaster delivers invalidations between server instances
+type Broadcaster interface {
+	Publish(ctx context.Context, inv Invalidation) error
+	// Subscribe calls handle for every received invalidation until ctx is done
+	Subscribe(ctx context.Context, handle func(Invalidation)) error
+}
+
+// BroadcastStore wraps a process-local store and propagates its deletions to
+// the stores of other instances, so that a user updated or deleted on one
+// node is not served from the stale cache of another.
+type BroadcastStore struct {
+	Store
+	broadcaster Broadcaster
+	origin      string
+}
+
+// NewBroadcastStore creates a store propagating invalidations of local
+func NewBroadcastStore(local Store, broadcaster Broadcaster) *BroadcastStore {
+	origin := make([]byte, 8)
+	_, _ = rand.Read(origin)
+
+	return &BroadcastStore{
+		Store:       local,
+		broadcaster: broadcaster,
+		origin:      hex.EncodeToString(origin),
+	}
+}
+
+// Delete removes the given keys locally and on other instances
+func (s *BroadcastStore) Delete(ctx context.Context, keys ...string) error {
+	if err := s.Store.Delete(ctx, keys...); err != nil {
+		return err
+	}
+	if len(keys) == 0 {
+		return nil
+	}
+	return s.broadcaster.Publish(ctx, Invalidation{Origin: s.origin, Keys: keys})
+}
+
+// DeletePrefix removes every key starting with prefix locally and on other instances
+func (s *BroadcastStore) DeletePrefix(ctx context.Context, prefix string) error {
+	if err := s.Store.DeletePrefix(ctx, prefix); err != nil {
+		return err
+	}
+	return s.broadcaster.Publish(ctx, Invalidation{Origin: s.origin, Prefix: prefix})
+}
+
+// Listen applies invalidations published by other instances until ctx is done
+func (s *BroadcastStore) Listen(ctx context.Context) error {
+	return s.broadcaster.Subscribe(ctx, s.apply)
+}
+
+// apply performs a received invalidation on the local store
+func (s *BroadcastStore) apply(inv Invalidation) {
+	if inv.Origin == s.origin {
+		return
+	}
+
+	ctx := context.Background()
+	if len(inv.Keys) > 0 {
+		_ = s.Store.Delete(ctx, inv.Keys...)
+	}
+	if inv.Prefix != "" {
+		_ = s.Store.DeletePrefix(ctx, inv.Prefix)
+	}
+}
+
+// RedisBroadcaster delivers invalidations over a Redis pub/sub channel
+type RedisBroadcaster struct {
+	client  *redis.Client
+	channel string
+}
+
+// NewRedisBroadcaster creates a broadcaster publishing on channel
+func NewRedisBroadcaster(client *redis.Client, channel string) *RedisBroadcaster {
+	return &RedisBroadcaster{client: client, channel: channel}
+}
+
+// Publish sends an invalidation to every subscribed instance
+func (b *RedisBroadcaster) Publish(ctx context.Context, inv Invalidation) error {
+	data, err := json.Marshal(inv)
+	if err != nil {
+		return err
+	}
+	return b.client.Publish(ctx, b.channel, data).Err()
+}
+
+// Subscribe receives invalidations until ctx is done. Messages published while
+// the connection is down are lost, the cache TTL bounds how long they stay stale.
+func (b *RedisBroadcaster) Subscribe(ctx context.Context, handle func(Invalidation)) error {
+	pubsub := b.client.Subscribe(ctx, b.channel)
+	defer pubsub.Close()
+
+	if _, err := pubsub.Receive(ctx); err != nil {
+		return err
+	}
+
+	messages := pubsub.Channel(redis.WithChannelHealthCheckInterval(time.Minute))
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case msg, ok := <-messages:
+			if !ok {
+				return nil
+			}
+			var inv Invalidation
+			if err := json.Unmarshal([]byte(msg.Payload), &inv); err != nil {
+				continue
+			}
+			handle(inv)
+		}
+	}
+}
@@ -0,0 +1,53 @@
+package cache
+
+import (
+	"context"
+	"gojwt-rest-api/internal/config"
+	"gojwt-rest-api/pkg/kv"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// ErrCacheMiss is returned when a key is not present in the cache
+var ErrCacheMiss = kv.ErrNotFound
+
+// responseKeyPrefix prefixes the keys of cached responses
+const responseKeyPrefix = "resp:"
+
+// Store defines the interface for cache storage backends
+type Store = kv.Store
+
+// NewStore creates a cache store for the configured driver
+func NewStore(cfg config.CacheConfig, redisClient *redis.Client) (Store, error) {
+	return kv.NewStore(cfg.Driver, cfg.CleanupInterval, redisClient)
+}
+
+// NewMemoryStore creates a new in-memory cache store.
+// NOTE: Entries are local to the process, so multiple server instances
+// will each keep their own copy. Use a Redis store to share cached entries,
+// or wrap it in a BroadcastStore to propagate invalidations.
+func NewMemoryStore(cleanupInterval time.Duration) *kv.MemoryStore {
+	return kv.NewMemoryStore(cleanupInterval)
+}
+
+// GroupKeyPrefix is the prefix of the keys of the cached responses of a route group
+func GroupKeyPrefix(group string) string {
+	return responseKeyPrefix + group + ":"
+}
+
+// Group is the cached responses of a route group
+type Group struct {
+	store Store
+	name  string
+}
+
+// NewGroup returns the cached responses of the route group name in store
+func NewGroup(store Store, name string) *Group {
+	return &Group{store: store, name: name}
+}
+
+// Invalidate removes every cached response of the group
+func (g *Group) Invalidate(ctx context.Context) error {
+	return g.store.DeletePrefix(ctx, GroupKeyPrefix(g.name))
+}
@@ -0,0 +1,88 @@
+// Package captcha verifies CAPTCHA responses solved by clients with the
+// siteverify API shared by Cloudflare Turnstile, hCaptcha and reCAPTCHA.
+package captcha
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"gojwt-rest-api/internal/config"
+	"gojwt-rest-api/internal/domain"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// requestTimeout bounds every call to the siteverify API
+const requestTimeout = 5 * time.Second
+
+// Verifier verifies the response of a CAPTCHA solved by a client
+type Verifier interface {
+	// Verify returns domain.ErrCaptchaInvalid when the response was not
+	// accepted, and other errors when it could not be verified
+	Verify(ctx context.Context, response, remoteIP string) error
+}
+
+// New creates the verifier of the configured provider, or nil when no
+// secret is configured
+func New(cfg config.CaptchaConfig) Verifier {
+	if cfg.Secret == "" {
+		return nil
+	}
+	return NewSiteVerifier(cfg.VerifyURL, cfg.Secret, &http.Client{Timeout: requestTimeout})
+}
+
+// SiteVerifier verifies responses with a siteverify endpoint
+type SiteVerifier struct {
+	verifyURL string
+	secret    string
+	client    *http.Client
+}
+
+// NewSiteVerifier creates a verifier posting to the siteverify endpoint verifyURL
+func NewSiteVerifier(verifyURL, secret string, client *http.Client) *SiteVerifier {
+	return &SiteVerifier{
+		verifyURL: verifyURL,
+		secret:    secret,
+		client:    client,
+	}
+}
+
+// Verify asks the provider whether response is a valid, unused solution for
+// the site's secret
+func (v *SiteVerifier) Verify(ctx context.Context, response, remoteIP string) error {
+	if response == "" {
+		return domain.ErrCaptchaInvalid
+	}
+
+	form := url.Values{"secret": {v.secret}, "response": {response}}
+	if remoteIP != "" {
+		form.Set("remoteip", remoteIP)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, v.verifyURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := v.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("captcha siteverify responded with status %d", resp.StatusCode)
+	}
+	var out struct {
+		Success bool `json:"success"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+		return err
+	}
+	if !out.Success {
+		return domain.ErrCaptchaInvalid
+	}
+	return nil
+}
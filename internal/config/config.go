@@ -2,21 +2,52 @@ package config
 
 import (
 	"fmt"
+	"gojwt-rest-api/internal/domain"
+	"net"
+	"net/netip"
 	"os"
+	"runtime"
 	"strconv"
+	"strings"
 	"time"
-
-	"github.com/joho/godotenv"
 )
 
 // Config holds all configuration for the application
 type Config struct {
-	Server   ServerConfig
-	Database DatabaseConfig
-	JWT      JWTConfig
-	RateLimit RateLimitConfig
-	CORS     CORSConfig
-	AppEnv   string
+	Server        ServerConfig
+	Database      DatabaseConfig
+	JWT           JWTConfig
+	RateLimit     RateLimitConfig
+	CORS          CORSConfig
+	Redis         RedisConfig
+	Cache         CacheConfig
+	Password      PasswordConfig
+	Mail          MailConfig
+	Webhook       WebhookConfig
+	Onboarding    OnboardingConfig
+	SLO           SLOConfig
+	APIKey        APIKeyConfig
+	Inactivity    InactivityConfig
+	Retention     RetentionConfig
+	Tenancy       TenancyConfig
+	SCIM          SCIMConfig
+	EmailChange   EmailChangeConfig
+	AccountLock   AccountLockConfig
+	PasswordReset PasswordResetConfig
+	Signup        SignupConfig
+	Captcha       CaptchaConfig
+	AbuseReport   AbuseReportConfig
+	TwoFactor     TwoFactorConfig
+	Access        AccessScheduleConfig
+	Session       SessionConfig
+	PII           PIIConfig
+	KMS           KMSConfig
+	Storage       StorageConfig
+	Trust         TrustConfig
+	AppEnv        string
+
+	// settings records the effective value and source of every variable
+	settings []Setting
 }
 
 // ServerConfig holds server configuration
@@ -26,6 +57,34 @@ type ServerConfig struct {
 	ReadTimeout  time.Duration
 	WriteTimeout time.Duration
 	IdleTimeout  time.Duration
+	// DrainPeriod is how long readiness fails before shutdown begins,
+	// giving load balancers time to stop routing traffic
+	DrainPeriod     time.Duration
+	ShutdownTimeout time.Duration
+	// HealthCheckTimeout bounds the dependency checks of the readiness endpoint
+	HealthCheckTimeout time.Duration
+	// AdminPort enables a separate management listener for health, metrics,
+	// pprof and admin operations. Empty serves them on the public listener.
+	AdminHost string
+	AdminPort string
+	// AdminTLSCert and AdminTLSKey serve the management listener over TLS.
+	// With AdminClientCA, client certificates signed by it are verified (mTLS).
+	AdminTLSCert  string
+	AdminTLSKey   string
+	AdminClientCA string
+	// AdminClientPrincipals maps client certificate identities (URI SAN or
+	// subject CN) to the ID of the user they act as
+	AdminClientPrincipals map[string]uint
+	// Listen overrides Host/Port, e.g. "unix:///var/run/gojwt.sock"
+	Listen     string
+	SocketMode os.FileMode
+	// DevRoutes mounts the /_dev debugging routes, which sign in as any user.
+	// It requires a public listener only reachable from the local host.
+	DevRoutes bool
+	// TrustedProxies are the networks of the reverse proxies whose
+	// X-Forwarded-For header gives the client IP used by rate limits. Without
+	// any, the client IP is the address of the connection peer.
+	TrustedProxies []netip.Prefix
 }
 
 // DatabaseConfig holds database configuration
@@ -35,20 +94,60 @@ type DatabaseConfig struct {
 	User     string
 	Password string
 	DBName   string
+	// UserSearch selects how user listings are searched: "like" or "fulltext"
+	UserSearch string
 }
 
+// User search modes
+const (
+	UserSearchLike     = "like"
+	UserSearchFullText = "fulltext"
+)
+
 // JWTConfig holds JWT configuration
 type JWTConfig struct {
 	Secret                string
 	AccessTokenExpiration time.Duration
 	RefreshTokenExpiration time.Duration
+	// ReauthMaxAge is how recent the authentication of a session must be for
+	// sensitive actions such as creating API keys
+	ReauthMaxAge time.Duration
+	// GenericTokenErrors rejects expired and invalid access tokens alike,
+	// instead of telling clients to refresh expired ones
+	GenericTokenErrors bool
+	// RenewalHintWindow is how long before expiry access tokens are hinted
+	// for renewal with the X-Token-Expires-In header; zero disables the hint
+	RenewalHintWindow time.Duration
+	// StandbySecrets are further signing keys: access tokens signed with them
+	// are accepted, and new tokens are signed with the first of them once
+	// Secret is reported compromised
+	StandbySecrets []string
+	// KeyRefreshInterval is how often the signing keys reported compromised
+	// are dropped, i.e. how long other instances accept tokens signed with a
+	// key after gojwt rotate-signing-key
+	KeyRefreshInterval time.Duration
+	// LegacyFormatCutoff is when access tokens issued in a format older than
+	// the current one stop being accepted, zero to keep accepting them
+	LegacyFormatCutoff time.Time
+	// TokenFromHeader, TokenFromCookie and TokenFromQuery enable the sources
+	// of access tokens, tried in this order
+	TokenFromHeader bool
+	TokenFromCookie bool
+	TokenCookieName string
+	TokenFromQuery  bool
+	TokenQueryParam string
 }
 
 // RateLimitConfig holds rate limiting configuration
 type RateLimitConfig struct {
+	// Driver stores request counters, "redis" sharing them between instances
+	Driver              string // "memory" or "redis"
 	RequestsPerDuration int
 	Duration            time.Duration
 	CleanupInterval     time.Duration
+	// InternalRequestsPerDuration limits callers inside the trust boundary
+	// instead, 0 not limiting them
+	InternalRequestsPerDuration int
 }
 
 // CORSConfig holds CORS configuration
@@ -56,80 +155,800 @@ type CORSConfig struct {
 	AllowedOrigins string
 }
 
+// RedisConfig holds Redis connection configuration
+type RedisConfig struct {
+	Addr     string
+	Password string
+	DB       int
+}
+
+// CacheConfig holds response cache configuration
+type CacheConfig struct {
+	// Enabled caches the responses of the admin user reads, off by default
+	Enabled         bool
+	Driver          string // "memory" or "redis"
+	TTL             time.Duration
+	CleanupInterval time.Duration
+	// InvalidationChannel is the Redis pub/sub channel propagating
+	// invalidations between instances using the memory driver
+	InvalidationChannel string
+}
+
+// PasswordConfig holds password verification configuration
+type PasswordConfig struct {
+	MaxConcurrentChecks int
+	QueueTimeout        time.Duration
+	// Global password policy, organizations may override it
+	MinLength        int
+	RequireUppercase bool
+	RequireDigit     bool
+	RequireSymbol    bool
+}
+
+// MailConfig holds outgoing email configuration
+type MailConfig struct {
+	Driver       string // "log" or "smtp"
+	From         string
+	SMTPHost     string
+	SMTPPort     string
+	SMTPUser     string
+	SMTPPassword string
+}
+
+// WebhookConfig holds outgoing webhook configuration
+type WebhookConfig struct {
+	URLs []string
+	// Secret signs deliveries (see pkg/webhooks), empty sends them unsigned
+	Secret  string
+	Timeout time.Duration
+}
+
+// OnboardingConfig holds onboarding configuration
+type OnboardingConfig struct {
+	WelcomeEmailEnabled bool
+}
+
+// SLOConfig holds service level objective targets
+type SLOConfig struct {
+	AvailabilityTarget float64
+	AuthSuccessTarget  float64
+}
+
+// APIKeyConfig holds API key rotation policy
+type APIKeyConfig struct {
+	// RotationAge is the age after which owners are reminded to rotate a key, zero disables reminders
+	RotationAge           time.Duration
+	RotationCheckInterval time.Duration
+	// AutoExpire expires keys AutoExpireGrace after their owner was reminded
+	AutoExpire      bool
+	AutoExpireGrace time.Duration
+	// UsageFlushInterval is how often aggregated usage is written to the database
+	UsageFlushInterval time.Duration
+}
+
+// InactivityConfig holds the policy for accounts without a login. Each stage
+// applies after the user was inactive for its duration, zero disables it.
+type InactivityConfig struct {
+	// WarnAfter emails the user that their account will be suspended
+	WarnAfter time.Duration
+	// SuspendAfter deactivates the account
+	SuspendAfter time.Duration
+	// AnonymizeAfter erases the personal data of the account
+	AnonymizeAfter time.Duration
+	CheckInterval  time.Duration
+}
+
+// Enabled reports whether any inactivity policy stage is enabled
+func (c InactivityConfig) Enabled() bool {
+	return c.WarnAfter > 0 || c.SuspendAfter > 0 || c.AnonymizeAfter > 0
+}
+
+// RetentionConfig holds how long data is kept once it is no longer needed,
+// zero keeps it forever
+type RetentionConfig struct {
+	// RevokedTokens is measured from revocation, revoked refresh tokens are kept at least until they expire
+	RevokedTokens time.Duration
+	// LoginHistory is measured from the expiry of a session's refresh token
+	LoginHistory time.Duration
+	// TokenBlacklist is measured from the expiry of the blacklisted token
+	TokenBlacklist time.Duration
+	// AuditLogs is measured from creation
+	AuditLogs time.Duration
+	// ArchiveAuditLogs exports audit logs to object storage before they are
+	// purged, under AuditLogArchivePrefix
+	ArchiveAuditLogs      bool
+	AuditLogArchivePrefix string
+	PurgeInterval         time.Duration
+}
+
+// TenancyConfig holds multi-tenant mode configuration
+type TenancyConfig struct {
+	Enabled bool
+	// QuotaCacheTTL is how long organization plans and counters are cached
+	QuotaCacheTTL time.Duration
+	// SettingsCacheTTL is how long organization setting overrides are cached
+	SettingsCacheTTL time.Duration
+	// InvitationTTL is how long organization invitations can be accepted
+	InvitationTTL time.Duration
+	// InvitationURL is the frontend page accepting invitations, linked from invitation emails
+	InvitationURL string
+}
+
+// EmailChangeConfig holds how email changes can be reverted from the previous address
+type EmailChangeConfig struct {
+	// RevertWindow is how long the previous address can revert a change, zero disables reverting
+	RevertWindow time.Duration
+	// RevertURL is the frontend page reverting changes, linked from the notice sent to the previous address
+	RevertURL string
+}
+
+// AccountLockConfig holds the risk signals locking accounts for suspicious
+// activity and the self-service unlock
+type AccountLockConfig struct {
+	// LockOnTokenReuse locks accounts whose rotated refresh tokens are presented again
+	LockOnTokenReuse bool
+	// MaxCountries locks accounts logging in from more countries within
+	// CountryWindow, zero disables the check
+	MaxCountries  int
+	CountryWindow time.Duration
+	// CountryHeader is the header a trusted proxy sets to the client's
+	// country (e.g. CF-IPCountry), empty disables the country check
+	CountryHeader string
+	// UnlockTokenTTL is how long an emailed unlock link is valid
+	UnlockTokenTTL time.Duration
+	// UnlockURL is the frontend page unlocking accounts, linked from the locked account notice
+	UnlockURL string
+}
+
+// PasswordResetConfig holds the self-service reset of forgotten passwords
+type PasswordResetConfig struct {
+	// TokenTTL is how long an emailed reset link is valid
+	TokenTTL time.Duration
+	// URL is the frontend page resetting passwords, linked from the reset email
+	URL string
+	// Workers process the queued reset requests, at most QueueSize of which
+	// wait; further requests are dropped
+	Workers   int
+	QueueSize int
+	// RequestsPerIP and RequestsPerEmail limit the reset requests of a client
+	// IP and for an email per RateWindow
+	RequestsPerIP    int
+	RequestsPerEmail int
+	RateWindow       time.Duration
+}
+
+// SignupConfig holds the privacy controls deciding who can learn whether an
+// email is registered
+type SignupConfig struct {
+	// EmailCheckEnabled exposes POST /auth/check-email, which requires a CAPTCHA
+	EmailCheckEnabled bool
+	// EmailCheckRequests is how many emails a client IP can check per EmailCheckWindow
+	EmailCheckRequests int
+	EmailCheckWindow   time.Duration
+	// ConcealExistingEmail answers registrations with a registered email like
+	// successful ones, and emails the owner instead
+	ConcealExistingEmail bool
+	// AllowSelfRegistration lets anyone register. When false, only invited
+	// users can register, and other accounts are provisioned by admins.
+	AllowSelfRegistration bool
+}
+
+// CaptchaConfig holds the siteverify API verifying CAPTCHA responses
+type CaptchaConfig struct {
+	// Secret is the site's secret key, empty disables CAPTCHA verification
+	Secret string
+	// VerifyURL is the siteverify endpoint of the provider
+	VerifyURL string
+}
+
+// AbuseReportConfig holds the public endpoint receiving abuse and security
+// reports for admin triage
+type AbuseReportConfig struct {
+	// Enabled exposes POST /report, which requires a CAPTCHA
+	Enabled bool
+	// Requests is how many reports a client IP can submit per Window
+	Requests int
+	Window   time.Duration
+}
+
+// SCIMConfig holds SCIM provisioning configuration
+type SCIMConfig struct {
+	// BearerToken authenticates identity providers, empty disables the SCIM endpoints
+	BearerToken string
+}
+
+// TwoFactorConfig holds two-factor authentication configuration
+type TwoFactorConfig struct {
+	// Issuer is the account issuer shown in authenticator apps
+	Issuer string
+	// RequiredRoles lists global roles (e.g. "admin") and organization roles
+	// prefixed with "org:" (e.g. "org:owner") that must use two-factor authentication
+	RequiredRoles []string
+	// MaxAttempts invalid codes lock the verification of a user's codes out
+	// for LockoutDuration, counted from the first invalid code
+	MaxAttempts     int
+	LockoutDuration time.Duration
+}
+
+// AccessScheduleConfig holds the hours in which roles may log in
+type AccessScheduleConfig struct {
+	// Roles maps global roles (e.g. "user") and organization roles prefixed
+	// with "org:" (e.g. "org:member") to the schedule their users must log in within
+	Roles map[string]*domain.AccessSchedule
+}
+
+// SessionConfig holds the handling of concurrent sessions
+type SessionConfig struct {
+	// NotifyConcurrentLogin publishes a user.concurrent_login event, to
+	// webhooks and the session event streams of the user, whenever a user logs
+	// in while holding other sessions
+	NotifyConcurrentLogin bool
+	// Clients are the first-party client applications, by ID, that sessions
+	// can be started from. Empty accepts any client ID.
+	Clients map[string]*domain.ClientApplication
+	// ClientIDRequired refuses logins that don't name their client application
+	ClientIDRequired bool
+	// ClientVersionCacheTTL is how long the minimum app versions of the
+	// clients are cached, i.e. how long other instances take to apply changes
+	ClientVersionCacheTTL time.Duration
+}
+
+// PIIConfig holds application-level encryption of personal data at rest
+type PIIConfig struct {
+	// EncryptionKey is the current key as "<id>:<base64 32 bytes>", empty disables encryption
+	EncryptionKey string
+	// PreviousKeys are retired keys still needed to decrypt until re-encryption completes
+	PreviousKeys []string
+	// BlindIndexKey is the base64 HMAC key of the blind indexes used for lookups
+	BlindIndexKey string
+}
+
+// Enabled reports whether PII encryption is configured
+func (c PIIConfig) Enabled() bool {
+	return c.EncryptionKey != ""
+}
+
+// KMSConfig holds the key management service decrypting secrets stored
+// encrypted with a "kms:" prefix
+type KMSConfig struct {
+	Provider string // "", "aws" or "gcp"
+	// KeyName is the GCP crypto key resource name, e.g.
+	// projects/p/locations/global/keyRings/r/cryptoKeys/k
+	KeyName string
+	// Endpoint overrides the provider API endpoint, e.g. for VPC endpoints
+	Endpoint           string
+	AWSRegion          string
+	AWSAccessKeyID     string
+	AWSSecretAccessKey string
+	AWSSessionToken    string
+	// GCPMetadataHost is the metadata server providing GCP access tokens
+	GCPMetadataHost string
+}
+
+// KMS providers
+const (
+	KMSProviderAWS = "aws"
+	KMSProviderGCP = "gcp"
+)
+
+// StorageConfig holds the object storage receiving exports, such as
+// archived audit logs. It shares the cloud credentials of KMSConfig.
+type StorageConfig struct {
+	Provider string // "", "s3" or "gcs"
+	Bucket   string
+	// Endpoint overrides the provider API endpoint, e.g. for S3 compatible
+	// stores, which are then addressed path-style
+	Endpoint           string
+	AWSRegion          string
+	AWSAccessKeyID     string
+	AWSSecretAccessKey string
+	AWSSessionToken    string
+	// GCPMetadataHost is the metadata server providing GCP access tokens
+	GCPMetadataHost string
+}
+
+// TrustConfig holds the trust boundary separating internal callers, such as
+// other services of the deployment, from external ones
+type TrustConfig struct {
+	// InternalNetworks are the networks of internal callers, matched against
+	// the connection peer rather than forwarded headers
+	InternalNetworks []netip.Prefix
+	// ClientCerts trusts callers presenting a verified client certificate
+	ClientCerts bool
+	// APIKeyIDs trusts callers authenticated with one of these API keys.
+	// Every user can create API keys, so only the keys of service accounts
+	// are listed.
+	APIKeyIDs []uint
+}
+
+// Enabled reports whether any caller can be internal
+func (c TrustConfig) Enabled() bool {
+	return len(c.InternalNetworks) > 0 || c.ClientCerts || len(c.APIKeyIDs) > 0
+}
+
+// Storage providers
+const (
+	StorageProviderS3  = "s3"
+	StorageProviderGCS = "gcs"
+)
+
 // Load loads configuration from environment variables
 func Load() (*Config, error) {
 	// Load .env file if exists (for development)
-	_ = godotenv.Load()
+	env := newEnvReader()
 
 	config := &Config{
 		Server: ServerConfig{
-			Port:         getEnv("SERVER_PORT", "8080"),
-			Host:         getEnv("SERVER_HOST", "localhost"),
-			ReadTimeout:  parseDuration(getEnv("SERVER_READ_TIMEOUT", "15s")),
-			WriteTimeout: parseDuration(getEnv("SERVER_WRITE_TIMEOUT", "15s")),
-			IdleTimeout:  parseDuration(getEnv("SERVER_IDLE_TIMEOUT", "60s")),
+			Port:               env.get("SERVER_PORT", "8080"),
+			Host:               env.get("SERVER_HOST", "localhost"),
+			ReadTimeout:        env.getDuration("SERVER_READ_TIMEOUT", "15s"),
+			WriteTimeout:       env.getDuration("SERVER_WRITE_TIMEOUT", "15s"),
+			IdleTimeout:        env.getDuration("SERVER_IDLE_TIMEOUT", "60s"),
+			DrainPeriod:        env.getDuration("SERVER_DRAIN_PERIOD", "5s"),
+			ShutdownTimeout:    env.getDuration("SERVER_SHUTDOWN_TIMEOUT", "10s"),
+			HealthCheckTimeout: env.getDuration("SERVER_HEALTH_CHECK_TIMEOUT", "2s"),
+			AdminHost:          env.get("SERVER_ADMIN_HOST", "127.0.0.1"),
+			AdminPort:          env.get("SERVER_ADMIN_PORT", ""),
+			AdminTLSCert:       env.get("SERVER_ADMIN_TLS_CERT", ""),
+			AdminTLSKey:        env.get("SERVER_ADMIN_TLS_KEY", ""),
+			AdminClientCA:      env.get("SERVER_ADMIN_CLIENT_CA", ""),
+			Listen:             env.get("SERVER_LISTEN", ""),
+			SocketMode:         env.getFileMode("SERVER_SOCKET_MODE", "0660"),
+			DevRoutes:          env.getBool("DEV_ROUTES_ENABLED", false),
 		},
 		Database: DatabaseConfig{
-			Host:     getEnv("DB_HOST", "localhost"),
-			Port:     getEnv("DB_PORT", "3306"),
-			User:     getEnv("DB_USER", "root"),
-			Password: getEnv("DB_PASSWORD", ""),
-			DBName:   getEnv("DB_NAME", "gojwt_db"),
+			Host:       env.get("DB_HOST", "localhost"),
+			Port:       env.get("DB_PORT", "3306"),
+			User:       env.get("DB_USER", "root"),
+			Password:   env.get("DB_PASSWORD", ""),
+			DBName:     env.get("DB_NAME", "gojwt_db"),
+			UserSearch: env.get("DB_USER_SEARCH", UserSearchLike),
 		},
 		JWT: JWTConfig{
-			Secret:                 getEnv("JWT_SECRET", ""),
-			AccessTokenExpiration:  parseDuration(getEnv("JWT_ACCESS_EXPIRATION", "15m")),
-			RefreshTokenExpiration: parseDuration(getEnv("JWT_REFRESH_EXPIRATION", "168h")), // 7 days
+			Secret:                 env.get("JWT_SECRET", ""),
+			AccessTokenExpiration:  env.getDuration("JWT_ACCESS_EXPIRATION", "15m"),
+			RefreshTokenExpiration: env.getDuration("JWT_REFRESH_EXPIRATION", "168h"), // 7 days
+			ReauthMaxAge:           env.getDuration("JWT_REAUTH_MAX_AGE", "5m"),
+			GenericTokenErrors:     env.getBool("JWT_GENERIC_TOKEN_ERRORS", false),
+			RenewalHintWindow:      env.getDuration("JWT_RENEWAL_HINT_WINDOW", "2m"),
+			StandbySecrets:         env.getList("JWT_STANDBY_SECRETS"),
+			KeyRefreshInterval:     env.getDuration("JWT_KEY_REFRESH_INTERVAL", "10s"),
+			TokenFromHeader:        env.getBool("AUTH_TOKEN_FROM_HEADER", true),
+			TokenFromCookie:        env.getBool("AUTH_TOKEN_FROM_COOKIE", false),
+			TokenCookieName:        env.get("AUTH_TOKEN_COOKIE_NAME", "access_token"),
+			TokenFromQuery:         env.getBool("AUTH_TOKEN_FROM_QUERY", false),
+			TokenQueryParam:        env.get("AUTH_TOKEN_QUERY_PARAM", "access_token"),
 		},
 		RateLimit: RateLimitConfig{
-			RequestsPerDuration: getEnvAsInt("RATE_LIMIT_REQUESTS", 100),
-			Duration:            parseDuration(getEnv("RATE_LIMIT_DURATION", "1m")),
-			CleanupInterval:     parseDuration(getEnv("RATE_LIMIT_CLEANUP_INTERVAL", "1m")),
+			Driver:                      env.get("RATE_LIMIT_DRIVER", "memory"),
+			RequestsPerDuration:         env.getInt("RATE_LIMIT_REQUESTS", 100),
+			Duration:                    env.getDuration("RATE_LIMIT_DURATION", "1m"),
+			CleanupInterval:             env.getDuration("RATE_LIMIT_CLEANUP_INTERVAL", "1m"),
+			InternalRequestsPerDuration: env.getInt("RATE_LIMIT_INTERNAL_REQUESTS", 0),
 		},
 		CORS: CORSConfig{
-			AllowedOrigins: getEnv("CORS_ALLOWED_ORIGINS", "*"),
+			AllowedOrigins: env.get("CORS_ALLOWED_ORIGINS", "*"),
+		},
+		Redis: RedisConfig{
+			Addr:     env.get("REDIS_ADDR", ""),
+			Password: env.get("REDIS_PASSWORD", ""),
+			DB:       env.getInt("REDIS_DB", 0),
+		},
+		Cache: CacheConfig{
+			Enabled:             env.getBool("CACHE_ENABLED", false),
+			Driver:              env.get("CACHE_DRIVER", "memory"),
+			TTL:                 env.getDuration("CACHE_TTL", "30s"),
+			CleanupInterval:     env.getDuration("CACHE_CLEANUP_INTERVAL", "1m"),
+			InvalidationChannel: env.get("CACHE_INVALIDATION_CHANNEL", "cache:invalidate"),
+		},
+		Password: PasswordConfig{
+			MaxConcurrentChecks: env.getInt("PASSWORD_MAX_CONCURRENT_CHECKS", runtime.NumCPU()),
+			QueueTimeout:        env.getDuration("PASSWORD_QUEUE_TIMEOUT", "2s"),
+			MinLength:           env.getInt("PASSWORD_MIN_LENGTH", 6),
+			RequireUppercase:    env.getBool("PASSWORD_REQUIRE_UPPERCASE", false),
+			RequireDigit:        env.getBool("PASSWORD_REQUIRE_DIGIT", false),
+			RequireSymbol:       env.getBool("PASSWORD_REQUIRE_SYMBOL", false),
+		},
+		Mail: MailConfig{
+			Driver:       env.get("MAIL_DRIVER", "log"),
+			From:         env.get("MAIL_FROM", "no-reply@localhost"),
+			SMTPHost:     env.get("SMTP_HOST", "localhost"),
+			SMTPPort:     env.get("SMTP_PORT", "587"),
+			SMTPUser:     env.get("SMTP_USER", ""),
+			SMTPPassword: env.get("SMTP_PASSWORD", ""),
+		},
+		Webhook: WebhookConfig{
+			URLs:    env.getList("WEBHOOK_URLS"),
+			Secret:  env.get("WEBHOOK_SECRET", ""),
+			Timeout: env.getDuration("WEBHOOK_TIMEOUT", "5s"),
+		},
+		Onboarding: OnboardingConfig{
+			WelcomeEmailEnabled: env.getBool("WELCOME_EMAIL_ENABLED", false),
+		},
+		SLO: SLOConfig{
+			AvailabilityTarget: env.getFloat("SLO_AVAILABILITY_TARGET", 0.999),
+			AuthSuccessTarget:  env.getFloat("SLO_AUTH_SUCCESS_TARGET", 0.9),
+		},
+		APIKey: APIKeyConfig{
+			RotationAge:           env.getDuration("API_KEY_ROTATION_AGE", "2160h"), // 90 days
+			RotationCheckInterval: env.getDuration("API_KEY_ROTATION_CHECK_INTERVAL", "1h"),
+			AutoExpire:            env.getBool("API_KEY_AUTO_EXPIRE", false),
+			AutoExpireGrace:       env.getDuration("API_KEY_AUTO_EXPIRE_GRACE", "336h"), // 14 days
+			UsageFlushInterval:    env.getDuration("API_KEY_USAGE_FLUSH_INTERVAL", "30s"),
+		},
+		Inactivity: InactivityConfig{
+			WarnAfter:      env.getDuration("INACTIVITY_WARN_AFTER", "0"),
+			SuspendAfter:   env.getDuration("INACTIVITY_SUSPEND_AFTER", "0"),
+			AnonymizeAfter: env.getDuration("INACTIVITY_ANONYMIZE_AFTER", "0"),
+			CheckInterval:  env.getDuration("INACTIVITY_CHECK_INTERVAL", "24h"),
+		},
+		Retention: RetentionConfig{
+			RevokedTokens:         env.getDuration("RETENTION_REVOKED_TOKENS", "720h"), // 30 days
+			LoginHistory:          env.getDuration("RETENTION_LOGIN_HISTORY", "2160h"), // 90 days
+			TokenBlacklist:        env.getDuration("RETENTION_TOKEN_BLACKLIST", "24h"),
+			AuditLogs:             env.getDuration("RETENTION_AUDIT_LOGS", "0"),
+			ArchiveAuditLogs:      env.getBool("RETENTION_AUDIT_LOGS_ARCHIVE", false),
+			AuditLogArchivePrefix: env.get("RETENTION_AUDIT_LOGS_ARCHIVE_PREFIX", "audit-logs/"),
+			PurgeInterval:         env.getDuration("RETENTION_PURGE_INTERVAL", "1h"),
+		},
+		Tenancy: TenancyConfig{
+			Enabled:          env.getBool("MULTI_TENANT_ENABLED", false),
+			QuotaCacheTTL:    env.getDuration("TENANT_QUOTA_CACHE_TTL", "30s"),
+			SettingsCacheTTL: env.getDuration("TENANT_SETTINGS_CACHE_TTL", "30s"),
+			InvitationTTL:    env.getDuration("ORG_INVITATION_TTL", "168h"),
+			InvitationURL:    env.get("ORG_INVITATION_URL", ""),
+		},
+		SCIM: SCIMConfig{
+			BearerToken: env.get("SCIM_BEARER_TOKEN", ""),
+		},
+		EmailChange: EmailChangeConfig{
+			RevertWindow: env.getDuration("EMAIL_CHANGE_REVERT_WINDOW", "72h"),
+			RevertURL:    env.get("EMAIL_CHANGE_REVERT_URL", ""),
+		},
+		AccountLock: AccountLockConfig{
+			LockOnTokenReuse: env.getBool("ACCOUNT_LOCK_ON_TOKEN_REUSE", true),
+			MaxCountries:     env.getInt("ACCOUNT_LOCK_MAX_COUNTRIES", 3),
+			CountryWindow:    env.getDuration("ACCOUNT_LOCK_COUNTRY_WINDOW", "24h"),
+			CountryHeader:    env.get("ACCOUNT_LOCK_COUNTRY_HEADER", ""),
+			UnlockTokenTTL:   env.getDuration("ACCOUNT_UNLOCK_TOKEN_TTL", "1h"),
+			UnlockURL:        env.get("ACCOUNT_UNLOCK_URL", ""),
+		},
+		PasswordReset: PasswordResetConfig{
+			TokenTTL:         env.getDuration("PASSWORD_RESET_TOKEN_TTL", "1h"),
+			URL:              env.get("PASSWORD_RESET_URL", ""),
+			Workers:          env.getInt("PASSWORD_RESET_WORKERS", 4),
+			QueueSize:        env.getInt("PASSWORD_RESET_QUEUE_SIZE", 100),
+			RequestsPerIP:    env.getInt("PASSWORD_RESET_RATE_LIMIT", 10),
+			RequestsPerEmail: env.getInt("PASSWORD_RESET_EMAIL_RATE_LIMIT", 3),
+			RateWindow:       env.getDuration("PASSWORD_RESET_RATE_WINDOW", "1h"),
+		},
+		Signup: SignupConfig{
+			EmailCheckEnabled:     env.getBool("SIGNUP_EMAIL_CHECK_ENABLED", false),
+			EmailCheckRequests:    env.getInt("SIGNUP_EMAIL_CHECK_RATE_LIMIT", 5),
+			EmailCheckWindow:      env.getDuration("SIGNUP_EMAIL_CHECK_RATE_WINDOW", "1m"),
+			ConcealExistingEmail:  env.getBool("SIGNUP_CONCEAL_EXISTING_EMAIL", false),
+			AllowSelfRegistration: env.getBool("ALLOW_SELF_REGISTRATION", true),
+		},
+		Captcha: CaptchaConfig{
+			Secret:    env.get("CAPTCHA_SECRET", ""),
+			VerifyURL: env.get("CAPTCHA_VERIFY_URL", "https://challenges.cloudflare.com/turnstile/v0/siteverify"),
 		},
-		AppEnv: getEnv("APP_ENV", "development"),
+		AbuseReport: AbuseReportConfig{
+			Enabled:  env.getBool("ABUSE_REPORT_ENABLED", false),
+			Requests: env.getInt("ABUSE_REPORT_RATE_LIMIT", 3),
+			Window:   env.getDuration("ABUSE_REPORT_RATE_WINDOW", "1h"),
+		},
+		TwoFactor: TwoFactorConfig{
+			Issuer:          env.get("TWO_FACTOR_ISSUER", "GoJWT"),
+			RequiredRoles:   env.getList("TWO_FACTOR_REQUIRED_ROLES"),
+			MaxAttempts:     env.getInt("TWO_FACTOR_MAX_ATTEMPTS", 5),
+			LockoutDuration: env.getDuration("TWO_FACTOR_LOCKOUT_DURATION", "15m"),
+		},
+		Session: SessionConfig{
+			NotifyConcurrentLogin: env.getBool("SESSION_NOTIFY_CONCURRENT_LOGIN", false),
+			ClientIDRequired:      env.getBool("SESSION_CLIENT_ID_REQUIRED", false),
+			ClientVersionCacheTTL: env.getDuration("CLIENT_VERSION_CACHE_TTL", "30s"),
+		},
+		PII: PIIConfig{
+			EncryptionKey: env.get("PII_ENCRYPTION_KEY", ""),
+			PreviousKeys:  env.getList("PII_PREVIOUS_ENCRYPTION_KEYS"),
+			BlindIndexKey: env.get("PII_BLIND_INDEX_KEY", ""),
+		},
+		KMS: KMSConfig{
+			Provider:           env.get("KMS_PROVIDER", ""),
+			KeyName:            env.get("KMS_KEY_NAME", ""),
+			Endpoint:           env.get("KMS_ENDPOINT", ""),
+			AWSRegion:          env.get("AWS_REGION", ""),
+			AWSAccessKeyID:     env.get("AWS_ACCESS_KEY_ID", ""),
+			AWSSecretAccessKey: env.get("AWS_SECRET_ACCESS_KEY", ""),
+			AWSSessionToken:    env.get("AWS_SESSION_TOKEN", ""),
+			GCPMetadataHost:    env.get("GCE_METADATA_HOST", "metadata.google.internal"),
+		},
+		Storage: StorageConfig{
+			Provider: env.get("STORAGE_PROVIDER", ""),
+			Bucket:   env.get("STORAGE_BUCKET", ""),
+			Endpoint: env.get("STORAGE_ENDPOINT", ""),
+		},
+		Trust: TrustConfig{
+			ClientCerts: env.getBool("TRUST_INTERNAL_CLIENT_CERTS", false),
+		},
+		AppEnv: env.get("APP_ENV", "development"),
 	}
+	config.Storage.AWSRegion = config.KMS.AWSRegion
+	config.Storage.AWSAccessKeyID = config.KMS.AWSAccessKeyID
+	config.Storage.AWSSecretAccessKey = config.KMS.AWSSecretAccessKey
+	config.Storage.AWSSessionToken = config.KMS.AWSSessionToken
+	config.Storage.GCPMetadataHost = config.KMS.GCPMetadataHost
+
+	principals, err := parsePrincipals(env.getList("SERVER_ADMIN_CLIENT_PRINCIPALS"))
+	if err != nil {
+		return nil, err
+	}
+	config.Server.AdminClientPrincipals = principals
+	networks, err := parseNetworks("TRUST_INTERNAL_NETWORKS", env.getList("TRUST_INTERNAL_NETWORKS"))
+	if err != nil {
+		return nil, err
+	}
+	config.Trust.InternalNetworks = networks
+	apiKeyIDs, err := parseIDs("TRUST_INTERNAL_API_KEY_IDS", env.getList("TRUST_INTERNAL_API_KEY_IDS"))
+	if err != nil {
+		return nil, err
+	}
+	config.Trust.APIKeyIDs = apiKeyIDs
+	proxies, err := parseNetworks("SERVER_TRUSTED_PROXIES", env.getList("SERVER_TRUSTED_PROXIES"))
+	if err != nil {
+		return nil, err
+	}
+	config.Server.TrustedProxies = proxies
+	schedules, err := parseAccessSchedules(env.getList("ACCESS_SCHEDULES"))
+	if err != nil {
+		return nil, err
+	}
+	config.Access.Roles = schedules
+	cutoff, err := parseCutoff("JWT_LEGACY_FORMAT_CUTOFF", env.get("JWT_LEGACY_FORMAT_CUTOFF", ""))
+	if err != nil {
+		return nil, err
+	}
+	config.JWT.LegacyFormatCutoff = cutoff
+	clients, err := parseClientApplications(env.getList("SESSION_CLIENTS"))
+	if err != nil {
+		return nil, err
+	}
+	config.Session.Clients = clients
+	config.settings = env.settings
 
 	// Validate required fields
 	if config.JWT.Secret == "" {
 		return nil, fmt.Errorf("JWT_SECRET is required")
 	}
+	if config.JWT.KeyRefreshInterval <= 0 {
+		return nil, fmt.Errorf("JWT_KEY_REFRESH_INTERVAL must be positive")
+	}
+	if config.TwoFactor.MaxAttempts <= 0 || config.TwoFactor.LockoutDuration <= 0 {
+		return nil, fmt.Errorf("TWO_FACTOR_MAX_ATTEMPTS and TWO_FACTOR_LOCKOUT_DURATION must be positive")
+	}
+	if config.PasswordReset.Workers <= 0 || config.PasswordReset.QueueSize <= 0 {
+		return nil, fmt.Errorf("PASSWORD_RESET_WORKERS and PASSWORD_RESET_QUEUE_SIZE must be positive")
+	}
+	if !config.JWT.TokenFromHeader && !config.JWT.TokenFromCookie && !config.JWT.TokenFromQuery {
+		return nil, fmt.Errorf("at least one of AUTH_TOKEN_FROM_HEADER, AUTH_TOKEN_FROM_COOKIE and AUTH_TOKEN_FROM_QUERY must be enabled")
+	}
+	if config.Cache.Driver == "redis" && config.Redis.Addr == "" {
+		return nil, fmt.Errorf("REDIS_ADDR is required when CACHE_DRIVER is redis")
+	}
+	if config.RateLimit.Driver == "redis" && config.Redis.Addr == "" {
+		return nil, fmt.Errorf("REDIS_ADDR is required when RATE_LIMIT_DRIVER is redis")
+	}
+	if config.Database.UserSearch != UserSearchLike && config.Database.UserSearch != UserSearchFullText {
+		return nil, fmt.Errorf("DB_USER_SEARCH must be %q or %q", UserSearchLike, UserSearchFullText)
+	}
+	if (config.Server.AdminTLSCert == "") != (config.Server.AdminTLSKey == "") {
+		return nil, fmt.Errorf("SERVER_ADMIN_TLS_CERT and SERVER_ADMIN_TLS_KEY must be set together")
+	}
+	if config.Server.AdminClientCA != "" && config.Server.AdminTLSCert == "" {
+		return nil, fmt.Errorf("SERVER_ADMIN_CLIENT_CA requires SERVER_ADMIN_TLS_CERT and SERVER_ADMIN_TLS_KEY")
+	}
+	if config.Server.AdminTLSCert != "" && config.Server.AdminPort == "" {
+		return nil, fmt.Errorf("SERVER_ADMIN_TLS_CERT requires SERVER_ADMIN_PORT")
+	}
+	if config.Server.DevRoutes {
+		if config.AppEnv == "production" {
+			return nil, fmt.Errorf("DEV_ROUTES_ENABLED cannot be used with APP_ENV=production")
+		}
+		if !config.Server.LocalOnly() {
+			return nil, fmt.Errorf("DEV_ROUTES_ENABLED requires a loopback SERVER_HOST or a unix socket SERVER_LISTEN, got %s", config.Server.ListenAddress())
+		}
+	}
+	switch config.KMS.Provider {
+	case "":
+	case KMSProviderAWS:
+		if config.KMS.AWSRegion == "" || config.KMS.AWSAccessKeyID == "" || config.KMS.AWSSecretAccessKey == "" {
+			return nil, fmt.Errorf("AWS_REGION, AWS_ACCESS_KEY_ID and AWS_SECRET_ACCESS_KEY are required when KMS_PROVIDER is aws")
+		}
+	case KMSProviderGCP:
+		if config.KMS.KeyName == "" {
+			return nil, fmt.Errorf("KMS_KEY_NAME is required when KMS_PROVIDER is gcp")
+		}
+	default:
+		return nil, fmt.Errorf("KMS_PROVIDER must be %q or %q", KMSProviderAWS, KMSProviderGCP)
+	}
+	switch config.Storage.Provider {
+	case "":
+		if config.Retention.ArchiveAuditLogs {
+			return nil, fmt.Errorf("STORAGE_PROVIDER is required when RETENTION_AUDIT_LOGS_ARCHIVE is true")
+		}
+	case StorageProviderS3:
+		if config.Storage.AWSRegion == "" || config.Storage.AWSAccessKeyID == "" || config.Storage.AWSSecretAccessKey == "" {
+			return nil, fmt.Errorf("AWS_REGION, AWS_ACCESS_KEY_ID and AWS_SECRET_ACCESS_KEY are required when STORAGE_PROVIDER is s3")
+		}
+	case StorageProviderGCS:
+	default:
+		return nil, fmt.Errorf("STORAGE_PROVIDER must be %q or %q", StorageProviderS3, StorageProviderGCS)
+	}
+	if config.Storage.Provider != "" && config.Storage.Bucket == "" {
+		return nil, fmt.Errorf("STORAGE_BUCKET is required when STORAGE_PROVIDER is set")
+	}
+	if config.Signup.EmailCheckEnabled && config.Captcha.Secret == "" {
+		return nil, fmt.Errorf("CAPTCHA_SECRET is required when SIGNUP_EMAIL_CHECK_ENABLED is true")
+	}
+	if config.AbuseReport.Enabled && config.Captcha.Secret == "" {
+		return nil, fmt.Errorf("CAPTCHA_SECRET is required when ABUSE_REPORT_ENABLED is true")
+	}
+	if config.Session.ClientIDRequired && len(config.Session.Clients) == 0 {
+		return nil, fmt.Errorf("SESSION_CLIENTS is required when SESSION_CLIENT_ID_REQUIRED is true")
+	}
+	if err := config.Inactivity.validate(); err != nil {
+		return nil, err
+	}
+	if config.PII.Enabled() {
+		if config.PII.BlindIndexKey == "" {
+			return nil, fmt.Errorf("PII_BLIND_INDEX_KEY is required when PII_ENCRYPTION_KEY is set")
+		}
+		if config.Database.UserSearch == UserSearchFullText {
+			return nil, fmt.Errorf("DB_USER_SEARCH=%s cannot search encrypted emails", UserSearchFullText)
+		}
+	}
 
 	return config, nil
 }
 
-// getEnv gets environment variable with fallback
-func getEnv(key, fallback string) string {
-	if value := os.Getenv(key); value != "" {
-		return value
+// validate checks that the enabled inactivity stages are in order
+func (c InactivityConfig) validate() error {
+	if c.WarnAfter > 0 && c.SuspendAfter > 0 && c.WarnAfter >= c.SuspendAfter {
+		return fmt.Errorf("INACTIVITY_WARN_AFTER must be shorter than INACTIVITY_SUSPEND_AFTER")
+	}
+	if c.SuspendAfter > 0 && c.AnonymizeAfter > 0 && c.SuspendAfter >= c.AnonymizeAfter {
+		return fmt.Errorf("INACTIVITY_SUSPEND_AFTER must be shorter than INACTIVITY_ANONYMIZE_AFTER")
+	}
+	if c.WarnAfter > 0 && c.AnonymizeAfter > 0 && c.WarnAfter >= c.AnonymizeAfter {
+		return fmt.Errorf("INACTIVITY_WARN_AFTER must be shorter than INACTIVITY_ANONYMIZE_AFTER")
+	}
+	return nil
+}
+
+// parsePrincipals parses "identity=userID" entries
+func parsePrincipals(entries []string) (map[string]uint, error) {
+	principals := make(map[string]uint, len(entries))
+	for _, entry := range entries {
+		i := strings.LastIndex(entry, "=")
+		if i <= 0 {
+			return nil, fmt.Errorf("invalid client principal %q, expected identity=userID", entry)
+		}
+		userID, err := strconv.ParseUint(entry[i+1:], 10, 32)
+		if err != nil || userID == 0 {
+			return nil, fmt.Errorf("invalid user ID in client principal %q", entry)
+		}
+		principals[entry[:i]] = uint(userID)
+	}
+	return principals, nil
+}
+
+// parseIDs parses the record IDs of the list variable key
+func parseIDs(key string, entries []string) ([]uint, error) {
+	ids := make([]uint, 0, len(entries))
+	for _, entry := range entries {
+		id, err := strconv.ParseUint(entry, 10, 32)
+		if err != nil || id == 0 {
+			return nil, fmt.Errorf("invalid ID %q in %s", entry, key)
+		}
+		ids = append(ids, uint(id))
+	}
+	return ids, nil
+}
+
+// parseAccessSchedules parses "role=schedule" entries
+func parseAccessSchedules(entries []string) (map[string]*domain.AccessSchedule, error) {
+	schedules := make(map[string]*domain.AccessSchedule, len(entries))
+	for _, entry := range entries {
+		role, spec, ok := strings.Cut(entry, "=")
+		role = strings.TrimSpace(role)
+		if !ok || role == "" {
+			return nil, fmt.Errorf("invalid access schedule %q in ACCESS_SCHEDULES, expected role=schedule", entry)
+		}
+		schedule, err := domain.ParseAccessSchedule(spec)
+		if err != nil {
+			return nil, fmt.Errorf("ACCESS_SCHEDULES: %w", err)
+		}
+		schedules[role] = schedule
+	}
+	return schedules, nil
+}
+
+// parseClientApplications parses "id" and "id=access/refresh" entries
+func parseClientApplications(entries []string) (map[string]*domain.ClientApplication, error) {
+	clients := make(map[string]*domain.ClientApplication, len(entries))
+	for _, entry := range entries {
+		client, err := domain.ParseClientApplication(entry)
+		if err != nil {
+			return nil, fmt.Errorf("SESSION_CLIENTS: %w", err)
+		}
+		clients[client.ID] = client
+	}
+	return clients, nil
+}
+
+// parseCutoff parses the date, midnight UTC, or RFC 3339 time of key, empty
+// for none
+func parseCutoff(key, raw string) (time.Time, error) {
+	if raw == "" {
+		return time.Time{}, nil
+	}
+	if t, err := time.Parse("2006-01-02", raw); err == nil {
+		return t, nil
 	}
-	return fallback
+	t, err := time.Parse(time.RFC3339, raw)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid %s %q, expected a date such as 2026-12-31 or an RFC 3339 time", key, raw)
+	}
+	return t, nil
 }
 
-// getEnvAsInt gets environment variable as integer with fallback
-func getEnvAsInt(key string, fallback int) int {
-	if value := os.Getenv(key); value != "" {
-		if intVal, err := strconv.Atoi(value); err == nil {
-			return intVal
+// parseNetworks parses the CIDR prefixes, or single addresses, of key
+func parseNetworks(key string, entries []string) ([]netip.Prefix, error) {
+	networks := make([]netip.Prefix, 0, len(entries))
+	for _, entry := range entries {
+		if addr, err := netip.ParseAddr(entry); err == nil {
+			networks = append(networks, netip.PrefixFrom(addr.Unmap(), addr.Unmap().BitLen()))
+			continue
+		}
+		prefix, err := netip.ParsePrefix(entry)
+		if err != nil {
+			return nil, fmt.Errorf("invalid network %q in %s, expected a CIDR or an address", entry, key)
 		}
+		networks = append(networks, prefix.Masked())
 	}
-	return fallback
+	return networks, nil
 }
 
-// parseDuration parses duration string with fallback
-func parseDuration(value string) time.Duration {
-	duration, err := time.ParseDuration(value)
+// ListenAddress returns the address the public server listens on
+func (s ServerConfig) ListenAddress() string {
+	if s.Listen != "" {
+		return s.Listen
+	}
+	return fmt.Sprintf("%s:%s", s.Host, s.Port)
+}
+
+// LocalOnly reports whether the public listener is only reachable from the
+// local host, i.e. a unix socket or a loopback address
+func (s ServerConfig) LocalOnly() bool {
+	address := s.ListenAddress()
+	if strings.HasPrefix(address, "unix://") {
+		return true
+	}
+	host, _, err := net.SplitHostPort(strings.TrimPrefix(address, "tcp://"))
 	if err != nil {
-		return time.Minute
+		return false
+	}
+	if host == "localhost" {
+		return true
 	}
-	return duration
+	addr, err := netip.ParseAddr(host)
+	return err == nil && addr.Unmap().IsLoopback()
 }
 
-// GetDSN returns MySQL DSN string
+// GetDSN returns MySQL DSN string. Timestamps are stored and read as UTC.
 func (c *Config) GetDSN() string {
-	return fmt.Sprintf("%s:%s@tcp(%s:%s)/%s?charset=utf8mb4&parseTime=True&loc=Local",
+	return fmt.Sprintf("%s:%s@tcp(%s:%s)/%s?charset=utf8mb4&parseTime=True&loc=UTC",
 		c.Database.User,
 		c.Database.Password,
 		c.Database.Host,
@@ -1,6 +1,7 @@
 package config
 
 import (
+	"context"
 	"fmt"
 	"time"
 
@@ -25,7 +26,7 @@ func NewDatabase(cfg *Config, appLogger *logger.Logger) (*gorm.DB, error) {
 	db, err := gorm.Open(mysql.Open(dsn), &gorm.Config{
 		Logger: gormLogger,
 		NowFunc: func() time.Time {
-			return time.Now().Local()
+			return time.Now().UTC()
 		},
 	})
 	if err != nil {
@@ -56,3 +57,12 @@ func CloseDatabase(db *gorm.DB) error {
 	}
 	return sqlDB.Close()
 }
+
+// PingDatabase checks that the database accepts connections
+func PingDatabase(ctx context.Context, db *gorm.DB) error {
+	sqlDB, err := db.DB()
+	if err != nil {
+		return err
+	}
+	return sqlDB.PingContext(ctx)
+}
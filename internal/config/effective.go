@@ -0,0 +1,325 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/joho/godotenv"
+)
+
+// Sources of a setting
+const (
+	// SourceEnv is a value set in the process environment
+	SourceEnv = "env"
+	// SourceDotEnv is a value loaded from the .env file
+	SourceDotEnv = ".env"
+	// SourceDefault is the default value of an unset variable
+	SourceDefault = "default"
+)
+
+// redacted replaces the value of secret settings in the banner
+const redacted = "[redacted]"
+
+// secretKeys are the variables whose values are never logged
+var secretKeys = map[string]bool{
+	"JWT_SECRET":                   true,
+	"JWT_STANDBY_SECRETS":          true,
+	"SERVER_ADMIN_TLS_KEY":         true,
+	"DB_PASSWORD":                  true,
+	"REDIS_PASSWORD":               true,
+	"SMTP_PASSWORD":                true,
+	"WEBHOOK_URLS":                 true,
+	"WEBHOOK_SECRET":               true,
+	"SCIM_BEARER_TOKEN":            true,
+	"CAPTCHA_SECRET":               true,
+	"PII_ENCRYPTION_KEY":           true,
+	"PII_PREVIOUS_ENCRYPTION_KEYS": true,
+	"PII_BLIND_INDEX_KEY":          true,
+	"AWS_ACCESS_KEY_ID":            true,
+	"AWS_SECRET_ACCESS_KEY":        true,
+	"AWS_SESSION_TOKEN":            true,
+}
+
+// bareNumber matches durations missing their unit, like "15" for 15 minutes
+var bareNumber = regexp.MustCompile(`^[0-9]+$`)
+
+// Setting is the effective value of an environment variable
+type Setting struct {
+	Key    string `json:"key"`
+	Value  string `json:"value"`
+	Source string `json:"source"`
+	// Warning explains why the configured value was replaced
+	Warning string `json:"warning,omitempty"`
+}
+
+// Subsystem is an optional part of the application and whether the
+// configuration enables it
+type Subsystem struct {
+	Name    string `json:"name"`
+	Enabled bool   `json:"enabled"`
+	Detail  string `json:"detail,omitempty"`
+}
+
+// Banner is the redacted effective configuration logged on boot
+type Banner struct {
+	AppEnv string `json:"app_env"`
+	// Sources counts the settings by source
+	Sources map[string]int `json:"sources"`
+	// Overridden lists the variables that are set, in the environment or .env
+	Overridden []string    `json:"overridden"`
+	Settings   []Setting   `json:"settings"`
+	Subsystems []Subsystem `json:"subsystems"`
+	// Warnings lists values that were replaced or look wrong
+	Warnings []string `json:"warnings,omitempty"`
+}
+
+// Banner returns the effective configuration with secrets redacted
+func (c *Config) Banner() *Banner {
+	banner := &Banner{
+		AppEnv:     c.AppEnv,
+		Sources:    map[string]int{SourceEnv: 0, SourceDotEnv: 0, SourceDefault: 0},
+		Overridden: []string{},
+		Settings:   c.settings,
+		Subsystems: c.subsystems(),
+	}
+	for _, setting := range c.settings {
+		banner.Sources[setting.Source]++
+		if setting.Source != SourceDefault {
+			banner.Overridden = append(banner.Overridden, setting.Key)
+		}
+		if setting.Warning != "" {
+			banner.Warnings = append(banner.Warnings, setting.Key+": "+setting.Warning)
+		}
+	}
+
+	if c.JWT.AccessTokenExpiration >= c.JWT.RefreshTokenExpiration {
+		banner.Warnings = append(banner.Warnings, fmt.Sprintf("JWT_ACCESS_EXPIRATION (%s) is not shorter than JWT_REFRESH_EXPIRATION (%s)",
+			c.JWT.AccessTokenExpiration, c.JWT.RefreshTokenExpiration))
+	}
+	if c.AppEnv == "production" && c.CORS.AllowedOrigins == "*" {
+		banner.Warnings = append(banner.Warnings, "CORS_ALLOWED_ORIGINS allows every origin in production")
+	}
+	return banner
+}
+
+// subsystems lists the optional subsystems and whether they are enabled
+func (c *Config) subsystems() []Subsystem {
+	adminListener := ""
+	if c.Server.AdminPort != "" {
+		adminListener = c.Server.AdminHost + ":" + c.Server.AdminPort
+		if c.Server.AdminTLSCert != "" {
+			adminListener += " (TLS)"
+		}
+	}
+	kms := c.KMS.Provider
+	var trustSources []string
+	if len(c.Trust.InternalNetworks) > 0 {
+		trustSources = append(trustSources, fmt.Sprintf("%d networks", len(c.Trust.InternalNetworks)))
+	}
+	if c.Trust.ClientCerts {
+		trustSources = append(trustSources, "client certificates")
+	}
+	if len(c.Trust.APIKeyIDs) > 0 {
+		trustSources = append(trustSources, fmt.Sprintf("%d api keys", len(c.Trust.APIKeyIDs)))
+	}
+	scheduledRoles := make([]string, 0, len(c.Access.Roles))
+	for role := range c.Access.Roles {
+		scheduledRoles = append(scheduledRoles, role)
+	}
+	sort.Strings(scheduledRoles)
+	clients := make([]string, 0, len(c.Session.Clients))
+	for id := range c.Session.Clients {
+		clients = append(clients, id)
+	}
+	sort.Strings(clients)
+	legacyCutoff := ""
+	if !c.JWT.LegacyFormatCutoff.IsZero() {
+		legacyCutoff = c.JWT.LegacyFormatCutoff.UTC().Format(time.RFC3339)
+	}
+	return []Subsystem{
+		{Name: "admin_listener", Enabled: c.Server.AdminPort != "", Detail: adminListener},
+		{Name: "dev_routes", Enabled: c.Server.DevRoutes},
+		{Name: "cache", Enabled: true, Detail: c.Cache.Driver},
+		{Name: "rate_limit_store", Enabled: true, Detail: c.RateLimit.Driver},
+		{Name: "redis", Enabled: c.Redis.Addr != ""},
+		{Name: "mail", Enabled: true, Detail: c.Mail.Driver},
+		{Name: "webhooks", Enabled: len(c.Webhook.URLs) > 0, Detail: fmt.Sprintf("%d endpoints", len(c.Webhook.URLs))},
+		{Name: "welcome_email", Enabled: c.Onboarding.WelcomeEmailEnabled},
+		{Name: "multi_tenancy", Enabled: c.Tenancy.Enabled},
+		{Name: "scim", Enabled: c.SCIM.BearerToken != ""},
+		{Name: "inactivity_policy", Enabled: c.Inactivity.Enabled()},
+		{Name: "audit_log_retention", Enabled: c.Retention.AuditLogs > 0},
+		{Name: "audit_log_archive", Enabled: c.Retention.ArchiveAuditLogs, Detail: c.Storage.Provider},
+		{Name: "api_key_auto_expire", Enabled: c.APIKey.AutoExpire},
+		{Name: "account_lock_on_token_reuse", Enabled: c.AccountLock.LockOnTokenReuse},
+		{Name: "signup_email_check", Enabled: c.Signup.EmailCheckEnabled},
+		{Name: "signup_conceal_existing_email", Enabled: c.Signup.ConcealExistingEmail},
+		{Name: "self_registration", Enabled: c.Signup.AllowSelfRegistration},
+		{Name: "captcha", Enabled: c.Captcha.Secret != ""},
+		{Name: "abuse_reports", Enabled: c.AbuseReport.Enabled},
+		{Name: "two_factor_required", Enabled: len(c.TwoFactor.RequiredRoles) > 0, Detail: strings.Join(c.TwoFactor.RequiredRoles, ",")},
+		{Name: "access_schedules", Enabled: len(c.Access.Roles) > 0, Detail: strings.Join(scheduledRoles, ",")},
+		{Name: "concurrent_login_notification", Enabled: c.Session.NotifyConcurrentLogin},
+		{Name: "client_applications", Enabled: len(clients) > 0, Detail: strings.Join(clients, ",")},
+		{Name: "legacy_token_format_cutoff", Enabled: !c.JWT.LegacyFormatCutoff.IsZero(), Detail: legacyCutoff},
+		{Name: "pii_encryption", Enabled: c.PII.Enabled()},
+		{Name: "kms", Enabled: kms != "", Detail: kms},
+		{Name: "trust_boundary", Enabled: c.Trust.Enabled(), Detail: strings.Join(trustSources, ",")},
+	}
+}
+
+// envReader reads environment variables, recording the effective value and
+// source of each
+type envReader struct {
+	// dotEnv holds the variables loaded from the .env file
+	dotEnv   map[string]bool
+	settings []Setting
+}
+
+// newEnvReader loads the .env file, if any, into the environment without
+// overriding variables already set
+func newEnvReader() *envReader {
+	r := &envReader{dotEnv: make(map[string]bool)}
+	values, err := godotenv.Read()
+	if err != nil {
+		return r
+	}
+	for key := range values {
+		if _, set := os.LookupEnv(key); !set {
+			r.dotEnv[key] = true
+		}
+	}
+	_ = godotenv.Load()
+	return r
+}
+
+// lookup returns the raw value of key and its source, empty values counting as unset
+func (r *envReader) lookup(key string) (string, string) {
+	value := os.Getenv(key)
+	switch {
+	case value == "":
+		return "", SourceDefault
+	case r.dotEnv[key]:
+		return value, SourceDotEnv
+	default:
+		return value, SourceEnv
+	}
+}
+
+// record adds the effective value of key
+func (r *envReader) record(key, value, source, warning string) {
+	if secretKeys[key] && value != "" {
+		value = redacted
+	}
+	r.settings = append(r.settings, Setting{Key: key, Value: value, Source: source, Warning: warning})
+}
+
+// get gets environment variable with fallback
+func (r *envReader) get(key, fallback string) string {
+	value, source := r.lookup(key)
+	if source == SourceDefault {
+		value = fallback
+	}
+	r.record(key, value, source, "")
+	return value
+}
+
+// getInt gets environment variable as integer with fallback
+func (r *envReader) getInt(key string, fallback int) int {
+	raw, source := r.lookup(key)
+	value, warning := fallback, ""
+	if source != SourceDefault {
+		if intVal, err := strconv.Atoi(raw); err == nil {
+			value = intVal
+		} else {
+			warning = fmt.Sprintf("invalid integer %q, using %d", raw, fallback)
+		}
+	}
+	r.record(key, strconv.Itoa(value), source, warning)
+	return value
+}
+
+// getFloat gets environment variable as float with fallback
+func (r *envReader) getFloat(key string, fallback float64) float64 {
+	raw, source := r.lookup(key)
+	value, warning := fallback, ""
+	if source != SourceDefault {
+		if floatVal, err := strconv.ParseFloat(raw, 64); err == nil {
+			value = floatVal
+		} else {
+			warning = fmt.Sprintf("invalid number %q, using %v", raw, fallback)
+		}
+	}
+	r.record(key, strconv.FormatFloat(value, 'g', -1, 64), source, warning)
+	return value
+}
+
+// getBool gets environment variable as boolean with fallback
+func (r *envReader) getBool(key string, fallback bool) bool {
+	raw, source := r.lookup(key)
+	value, warning := fallback, ""
+	if source != SourceDefault {
+		if boolVal, err := strconv.ParseBool(raw); err == nil {
+			value = boolVal
+		} else {
+			warning = fmt.Sprintf("invalid boolean %q, using %t", raw, fallback)
+		}
+	}
+	r.record(key, strconv.FormatBool(value), source, warning)
+	return value
+}
+
+// getList gets a comma-separated environment variable as a list
+func (r *envReader) getList(key string) []string {
+	raw, source := r.lookup(key)
+	var list []string
+	for _, item := range strings.Split(raw, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			list = append(list, item)
+		}
+	}
+	r.record(key, strings.Join(list, ","), source, "")
+	return list
+}
+
+// getDuration gets environment variable as a duration with fallback. Invalid
+// values fall back to one minute.
+func (r *envReader) getDuration(key, fallback string) time.Duration {
+	raw, source := r.lookup(key)
+	if source == SourceDefault {
+		raw = fallback
+	}
+	value, err := time.ParseDuration(raw)
+	warning := ""
+	if err != nil {
+		value = time.Minute
+		warning = fmt.Sprintf("invalid duration %q, using %s", raw, value)
+		if bareNumber.MatchString(raw) {
+			warning += ", durations need a unit such as " + raw + "s, " + raw + "m or " + raw + "h"
+		}
+	}
+	r.record(key, value.String(), source, warning)
+	return value
+}
+
+// getFileMode gets environment variable as an octal file mode with fallback.
+// Invalid values fall back to 0660.
+func (r *envReader) getFileMode(key, fallback string) os.FileMode {
+	raw, source := r.lookup(key)
+	if source == SourceDefault {
+		raw = fallback
+	}
+	value, warning := os.FileMode(0660), ""
+	if mode, err := strconv.ParseUint(raw, 8, 32); err == nil {
+		value = os.FileMode(mode)
+	} else {
+		warning = fmt.Sprintf("invalid file mode %q, using %#o", raw, uint32(value))
+	}
+	r.record(key, fmt.Sprintf("%#o", uint32(value)), source, warning)
+	return value
+}
@@ -0,0 +1,35 @@
+package config
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"gojwt-rest-api/pkg/logger"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// redisPingTimeout bounds the connectivity check on startup
+const redisPingTimeout = 5 * time.Second
+
+// NewRedisClient creates a new Redis client and verifies the connection
+func NewRedisClient(cfg *Config, appLogger *logger.Logger) (*redis.Client, error) {
+	client := redis.NewClient(&redis.Options{
+		Addr:     cfg.Redis.Addr,
+		Password: cfg.Redis.Password,
+		DB:       cfg.Redis.DB,
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), redisPingTimeout)
+	defer cancel()
+
+	if err := client.Ping(ctx).Err(); err != nil {
+		_ = client.Close()
+		return nil, fmt.Errorf("failed to connect to redis: %w", err)
+	}
+
+	appLogger.Info("Redis connection established successfully")
+
+	return client, nil
+}
@@ -0,0 +1,110 @@
+package domain
+
+import "time"
+
+// Categories of abuse reports
+const (
+	AbuseReportCategoryAbuse    = "abuse"
+	AbuseReportCategorySecurity = "security"
+	AbuseReportCategoryOther    = "other"
+)
+
+// Triage statuses of abuse reports
+const (
+	AbuseReportStatusOpen      = "open"
+	AbuseReportStatusInReview  = "in_review"
+	AbuseReportStatusResolved  = "resolved"
+	AbuseReportStatusDismissed = "dismissed"
+)
+
+// AbuseReport is an abuse or security report submitted by anyone through the
+// public report endpoint, kept for admin triage
+type AbuseReport struct {
+	ID       uint   `gorm:"primaryKey"`
+	Category string `gorm:"type:varchar(20);not null;index"`
+	// ReportedUserID is the user the report is about, as given by the
+	// reporter. It is not checked, so the endpoint doesn't reveal which
+	// users exist.
+	ReportedUserID *uint `gorm:"index"`
+	// SessionReference identifies the reported session as the reporter knows
+	// it, such as a client application or device
+	SessionReference string `gorm:"type:varchar(255)"`
+	// ContactEmail is where the reporter can be reached, encrypted like the
+	// email of users
+	ContactEmail string `gorm:"size:512;serializer:pii"`
+	Message      string `gorm:"type:text;not null"`
+	ReporterIP   string `gorm:"type:varchar(45)"`
+	Status       string `gorm:"type:varchar(20);not null;default:open;index"`
+	// TriageNote is the admin's note on the outcome of the report
+	TriageNote string `gorm:"type:text"`
+	// TriagedBy is the admin who last changed the status
+	TriagedBy *uint
+	CreatedAt time.Time `gorm:"autoCreateTime;index"`
+	UpdatedAt time.Time `gorm:"autoUpdateTime"`
+}
+
+// TableName specifies the table name for GORM
+func (AbuseReport) TableName() string {
+	return "abuse_reports"
+}
+
+// AbuseReportFilter narrows a report listing. Zero values match everything.
+type AbuseReportFilter struct {
+	Category string
+	Status   string
+}
+
+// SubmitAbuseReportRequest represents the public abuse report request
+type SubmitAbuseReportRequest struct {
+	Category         string `json:"category" validate:"required,oneof=abuse security other"`
+	ReportedUserID   *uint  `json:"reported_user_id" validate:"omitempty,min=1"`
+	SessionReference string `json:"session_reference" validate:"max=255"`
+	ContactEmail     string `json:"contact_email" validate:"omitempty,email,max=255"`
+	Message          string `json:"message" validate:"required,min=10,max=5000"`
+}
+
+// TriageAbuseReportRequest sets the triage status of an abuse report
+type TriageAbuseReportRequest struct {
+	Status string `json:"status" validate:"required,oneof=open in_review resolved dismissed"`
+	Note   string `json:"note" validate:"max=2000"`
+}
+
+// AbuseReportReceipt is returned to the reporter, without the report content
+type AbuseReportReceipt struct {
+	ID        uint      `json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// AbuseReportResponse represents an abuse report as shown to admins
+type AbuseReportResponse struct {
+	ID               uint      `json:"id"`
+	Category         string    `json:"category"`
+	ReportedUserID   *uint     `json:"reported_user_id,omitempty"`
+	SessionReference string    `json:"session_reference,omitempty"`
+	ContactEmail     string    `json:"contact_email,omitempty"`
+	Message          string    `json:"message"`
+	ReporterIP       string    `json:"reporter_ip"`
+	Status           string    `json:"status"`
+	TriageNote       string    `json:"triage_note,omitempty"`
+	TriagedBy        *uint     `json:"triaged_by,omitempty"`
+	CreatedAt        time.Time `json:"created_at"`
+	UpdatedAt        time.Time `json:"updated_at"`
+}
+
+// ToResponse converts AbuseReport to AbuseReportResponse
+func (r *AbuseReport) ToResponse() *AbuseReportResponse {
+	return &AbuseReportResponse{
+		ID:               r.ID,
+		Category:         r.Category,
+		ReportedUserID:   r.ReportedUserID,
+		SessionReference: r.SessionReference,
+		ContactEmail:     r.ContactEmail,
+		Message:          r.Message,
+		ReporterIP:       r.ReporterIP,
+		Status:           r.Status,
+		TriageNote:       r.TriageNote,
+		TriagedBy:        r.TriagedBy,
+		CreatedAt:        r.CreatedAt.UTC(),
+		UpdatedAt:        r.UpdatedAt.UTC(),
+	}
+}
@@ -0,0 +1,116 @@
+package domain
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// weekdays maps the day names of access schedules to weekdays
+var weekdays = map[string]time.Weekday{
+	"sun": time.Sunday,
+	"mon": time.Monday,
+	"tue": time.Tuesday,
+	"wed": time.Wednesday,
+	"thu": time.Thursday,
+	"fri": time.Friday,
+	"sat": time.Saturday,
+}
+
+// AccessSchedule restricts when users may log in and refresh their sessions.
+// It is written as days and a time window, optionally followed by the IANA
+// timezone of the window (UTC by default), e.g. "mon-fri 08:00-20:00",
+// "sat+sun 10:00-14:00" or "mon-fri 08:00-20:00 Asia/Jakarta". Windows ending
+// before they start run past midnight, into the next day. The timezone is
+// part of the schedule rather than taken from the user's profile, which users
+// change themselves.
+type AccessSchedule struct {
+	spec string
+	days [7]bool
+	// start and end are minutes since midnight
+	start, end int
+	location   *time.Location
+}
+
+// ParseAccessSchedule parses an access schedule, returning
+// ErrInvalidAccessSchedule for malformed schedules
+func ParseAccessSchedule(spec string) (*AccessSchedule, error) {
+	fields := strings.Fields(spec)
+	if len(fields) != 2 && len(fields) != 3 {
+		return nil, fmt.Errorf("%w: %q, expected days and a time window such as \"mon-fri 08:00-20:00\", optionally followed by a timezone", ErrInvalidAccessSchedule, spec)
+	}
+	fields[0], fields[1] = strings.ToLower(fields[0]), strings.ToLower(fields[1])
+	schedule := &AccessSchedule{spec: strings.Join(fields, " "), location: time.UTC}
+	if len(fields) == 3 {
+		// Local depends on the server, it is not a timezone of the schedule
+		loc, err := time.LoadLocation(fields[2])
+		if err != nil || fields[2] == "Local" {
+			return nil, fmt.Errorf("%w: unknown timezone %q in %q", ErrInvalidAccessSchedule, fields[2], spec)
+		}
+		schedule.location = loc
+	}
+
+	for _, days := range strings.Split(fields[0], "+") {
+		from, to, isRange := strings.Cut(days, "-")
+		if !isRange {
+			to = from
+		}
+		first, knownFirst := weekdays[from]
+		last, knownLast := weekdays[to]
+		if !knownFirst || !knownLast {
+			return nil, fmt.Errorf("%w: unknown days %q in %q", ErrInvalidAccessSchedule, days, spec)
+		}
+		for day := first; ; day = (day + 1) % 7 {
+			schedule.days[day] = true
+			if day == last {
+				break
+			}
+		}
+	}
+
+	from, to, ok := strings.Cut(fields[1], "-")
+	start, startErr := parseClock(from)
+	end, endErr := parseClock(to)
+	if !ok || startErr != nil || endErr != nil || start == end || start == 24*60 {
+		return nil, fmt.Errorf("%w: invalid time window %q in %q", ErrInvalidAccessSchedule, fields[1], spec)
+	}
+	schedule.start, schedule.end = start, end
+	return schedule, nil
+}
+
+// parseClock parses a time of day from 00:00 to 24:00 into minutes since midnight
+func parseClock(value string) (int, error) {
+	if value == "24:00" {
+		return 24 * 60, nil
+	}
+	t, err := time.Parse("15:04", value)
+	if err != nil {
+		return 0, err
+	}
+	return t.Hour()*60 + t.Minute(), nil
+}
+
+// Allows reports whether the schedule allows access at t, in the timezone of
+// the schedule
+func (s *AccessSchedule) Allows(t time.Time) bool {
+	t = t.In(s.location)
+	minute := t.Hour()*60 + t.Minute()
+	day := t.Weekday()
+	if s.start < s.end {
+		return s.days[day] && minute >= s.start && minute < s.end
+	}
+	// The window runs past midnight: the early hours belong to the window
+	// of the previous day
+	previous := (day + 6) % 7
+	return (s.days[day] && minute >= s.start) || (s.days[previous] && minute < s.end)
+}
+
+// Location returns the timezone the schedule is evaluated in
+func (s *AccessSchedule) Location() *time.Location {
+	return s.location
+}
+
+// String returns the schedule as written
+func (s *AccessSchedule) String() string {
+	return s.spec
+}
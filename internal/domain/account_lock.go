@@ -0,0 +1,27 @@
+package domain
+
+import "time"
+
+// Risk signals locking an account for suspicious activity
+const (
+	// LockReasonTokenReuse is a rotated refresh token presented again
+	LockReasonTokenReuse = "token_reuse"
+	// LockReasonManyCountries is logins from too many countries in a short time
+	LockReasonManyCountries = "many_countries"
+)
+
+// LoginLocation is the country a user logged in from. Only the locations
+// within the window of the suspicious activity check are kept.
+type LoginLocation struct {
+	ID     uint `gorm:"primaryKey"`
+	UserID uint `gorm:"not null;index:idx_login_locations_user_created,priority:1"`
+	// Country is an ISO 3166-1 alpha-2 code
+	Country   string    `gorm:"not null;type:varchar(2)"`
+	CreatedAt time.Time `gorm:"autoCreateTime;index:idx_login_locations_user_created,priority:2"`
+	User      User      `gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE"`
+}
+
+// TableName specifies the table name for GORM
+func (LoginLocation) TableName() string {
+	return "login_locations"
+}
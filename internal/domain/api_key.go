@@ -0,0 +1,137 @@
+package domain
+
+import (
+	"strings"
+	"time"
+)
+
+// API key rotation statuses
+const (
+	APIKeyStatusActive      = "active"
+	APIKeyStatusRotationDue = "rotation_due"
+	APIKeyStatusExpired     = "expired"
+	APIKeyStatusRevoked     = "revoked"
+)
+
+// APIKeyScopes are the scopes an API key can be granted. Keys are only
+// accepted on the self-service routes mapped to one of them, never on admin,
+// API key, authentication or organization routes.
+var APIKeyScopes = []string{ScopeProfileRead, ScopeProfileWrite}
+
+// APIKey represents a long-lived credential issued to a user for programmatic access.
+// Only the SHA-256 hash of the key is stored; the plaintext is shown once on creation.
+type APIKey struct {
+	ID                 uint   `gorm:"primaryKey"`
+	UserID             uint   `gorm:"not null;index"`
+	Name               string `gorm:"not null;type:varchar(100)"`
+	Prefix             string `gorm:"not null;index;type:varchar(20)"` // Non-secret identifier shown to humans
+	KeyHash            string `gorm:"unique;not null;type:varchar(64)"`
+	Scopes             string `gorm:"type:varchar(255)"` // Comma-separated scopes granted to the key
+	ExpiresAt          *time.Time
+	LastUsedAt         *time.Time
+	RotationNotifiedAt *time.Time // Set once the owner was reminded to rotate the key
+	RevokedAt          *time.Time
+	CreatedAt          time.Time `gorm:"autoCreateTime"`
+	UpdatedAt          time.Time `gorm:"autoUpdateTime"`
+	User               User      `gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE"`
+}
+
+// TableName specifies the table name for GORM
+func (APIKey) TableName() string {
+	return "api_keys"
+}
+
+// IsValid checks if the API key can still be used
+func (k *APIKey) IsValid(now time.Time) bool {
+	return k.RevokedAt == nil && (k.ExpiresAt == nil || now.Before(*k.ExpiresAt))
+}
+
+// ScopeList returns the scopes granted to the key
+func (k *APIKey) ScopeList() []string {
+	scopes := []string{}
+	for _, s := range strings.Split(k.Scopes, ",") {
+		if s = strings.TrimSpace(s); s != "" {
+			scopes = append(scopes, s)
+		}
+	}
+	return scopes
+}
+
+// RotationStatus reports whether the key is active, due for rotation (older than maxAge),
+// expired or revoked. A zero maxAge disables rotation reminders.
+func (k *APIKey) RotationStatus(maxAge time.Duration, now time.Time) string {
+	switch {
+	case k.RevokedAt != nil:
+		return APIKeyStatusRevoked
+	case k.ExpiresAt != nil && !now.Before(*k.ExpiresAt):
+		return APIKeyStatusExpired
+	case maxAge > 0 && now.Sub(k.CreatedAt) >= maxAge:
+		return APIKeyStatusRotationDue
+	default:
+		return APIKeyStatusActive
+	}
+}
+
+// APIKeyResponse represents the API key response (without the key hash)
+type APIKeyResponse struct {
+	ID                 uint       `json:"id"`
+	UserID             uint       `json:"user_id"`
+	Name               string     `json:"name"`
+	Prefix             string     `json:"prefix"`
+	Scopes             []string   `json:"scopes"`
+	Status             string     `json:"status"`
+	ExpiresAt          *time.Time `json:"expires_at,omitempty"`
+	LastUsedAt         *time.Time `json:"last_used_at,omitempty"`
+	RotationNotifiedAt *time.Time `json:"rotation_notified_at,omitempty"`
+	RevokedAt          *time.Time `json:"revoked_at,omitempty"`
+	CreatedAt          time.Time  `json:"created_at"`
+}
+
+// ToResponse converts APIKey to APIKeyResponse, evaluating its rotation status against maxAge
+func (k *APIKey) ToResponse(maxAge time.Duration) *APIKeyResponse {
+	return &APIKeyResponse{
+		ID:                 k.ID,
+		UserID:             k.UserID,
+		Name:               k.Name,
+		Prefix:             k.Prefix,
+		Scopes:             k.ScopeList(),
+		Status:             k.RotationStatus(maxAge, time.Now()),
+		ExpiresAt:          timeIn(k.ExpiresAt, time.UTC),
+		LastUsedAt:         timeIn(k.LastUsedAt, time.UTC),
+		RotationNotifiedAt: timeIn(k.RotationNotifiedAt, time.UTC),
+		RevokedAt:          timeIn(k.RevokedAt, time.UTC),
+		CreatedAt:          k.CreatedAt.UTC(),
+	}
+}
+
+// APIKeyUsage holds the aggregated request count of an API key per endpoint
+type APIKeyUsage struct {
+	ID           uint      `gorm:"primaryKey"`
+	APIKeyID     uint      `gorm:"not null;uniqueIndex:idx_api_key_usage_endpoint"`
+	Method       string    `gorm:"not null;type:varchar(10);uniqueIndex:idx_api_key_usage_endpoint"`
+	Route        string    `gorm:"not null;type:varchar(255);uniqueIndex:idx_api_key_usage_endpoint"`
+	RequestCount int64     `gorm:"not null;default:0"`
+	LastUsedAt   time.Time `gorm:"not null"`
+	APIKey       APIKey    `gorm:"foreignKey:APIKeyID;constraint:OnDelete:CASCADE"`
+}
+
+// TableName specifies the table name for GORM
+func (APIKeyUsage) TableName() string {
+	return "api_key_usage"
+}
+
+// EndpointUsage represents the usage of a single endpoint
+type EndpointUsage struct {
+	Method     string    `json:"method"`
+	Route      string    `json:"route"`
+	Requests   int64     `json:"requests"`
+	LastUsedAt time.Time `json:"last_used_at"`
+}
+
+// APIKeyUsageResponse represents the usage summary of an API key
+type APIKeyUsageResponse struct {
+	APIKey        *APIKeyResponse  `json:"api_key"`
+	TotalRequests int64            `json:"total_requests"`
+	LastUsedAt    *time.Time       `json:"last_used_at,omitempty"`
+	TopEndpoints  []*EndpointUsage `json:"top_endpoints"`
+}
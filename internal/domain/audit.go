@@ -0,0 +1,78 @@
+package domain
+
+import "time"
+
+// Audit actions
+const (
+	AuditSSOUserProvisioned    = "sso.user_provisioned"
+	AuditSSOUserUpdated        = "sso.user_updated"
+	AuditSSOProvisioningDenied = "sso.provisioning_denied"
+	AuditUserDeleted           = "user.deleted"
+	AuditUserInactivityWarned  = "user.inactivity_warned"
+	AuditUserSuspended         = "user.suspended_inactive"
+	AuditUserAnonymized        = "user.anonymized"
+	AuditUserInactivityExempt  = "user.inactivity_exempt_changed"
+	AuditUserEmailChanged      = "user.email_changed"
+	AuditUserEmailReverted     = "user.email_change_reverted"
+	AuditUserLocked            = "user.locked"
+	AuditUserUnlocked          = "user.unlocked"
+	AuditUserPasswordReset     = "user.password_reset"
+	AuditOrgSessionsRevoked    = "organization.sessions_revoked"
+	AuditClientSessionsRevoked = "client.sessions_revoked"
+	AuditClientMinVersionSet   = "client.min_version_set"
+	AuditClientMinVersionUnset = "client.min_version_removed"
+	AuditAccessScheduleDenied  = "user.access_schedule_denied"
+	AuditAbuseReportTriaged    = "abuse_report.triaged"
+)
+
+// AuditLog is an append-only record of a security relevant event
+type AuditLog struct {
+	ID     uint   `gorm:"primaryKey"`
+	Action string `gorm:"not null;index;type:varchar(100)"`
+	// ActorID is the user who performed the action, nil for the system or an identity provider
+	ActorID        *uint `gorm:"index"`
+	UserID         *uint `gorm:"index"`
+	OrganizationID *uint `gorm:"index"`
+	// Source identifies where the event originated, e.g. "sso_connection:3"
+	Source    string    `gorm:"type:varchar(100)"`
+	Detail    string    `gorm:"type:text"`
+	CreatedAt time.Time `gorm:"autoCreateTime;index"`
+}
+
+// TableName specifies the table name for GORM
+func (AuditLog) TableName() string {
+	return "audit_logs"
+}
+
+// AuditLogFilter narrows an audit log listing. Zero values match everything.
+type AuditLogFilter struct {
+	Action         string
+	UserID         uint
+	OrganizationID uint
+}
+
+// AuditLogResponse represents the audit log response
+type AuditLogResponse struct {
+	ID             uint      `json:"id"`
+	Action         string    `json:"action"`
+	ActorID        *uint     `json:"actor_id"`
+	UserID         *uint     `json:"user_id"`
+	OrganizationID *uint     `json:"organization_id"`
+	Source         string    `json:"source"`
+	Detail         string    `json:"detail"`
+	CreatedAt      time.Time `json:"created_at"`
+}
+
+// ToResponse converts AuditLog to AuditLogResponse
+func (l *AuditLog) ToResponse() *AuditLogResponse {
+	return &AuditLogResponse{
+		ID:             l.ID,
+		Action:         l.Action,
+		ActorID:        l.ActorID,
+		UserID:         l.UserID,
+		OrganizationID: l.OrganizationID,
+		Source:         l.Source,
+		Detail:         l.Detail,
+		CreatedAt:      l.CreatedAt.UTC(),
+	}
+}
@@ -0,0 +1,109 @@
+package domain
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// clientIDPattern is the form of client application IDs
+var clientIDPattern = regexp.MustCompile(`^[a-z0-9][a-z0-9._-]{0,49}$`)
+
+// ClientApplication is a first-party client, such as the web app or a mobile
+// app, that sessions are started from. Each refresh token records the client
+// of its session, so that the sessions of one client can be revoked, e.g. of
+// a compromised old app version.
+type ClientApplication struct {
+	ID string
+	// AccessTokenTTL and RefreshTokenTTL override the token lifetimes of the
+	// user's organization for sessions of the client, zero keeps them
+	AccessTokenTTL  time.Duration
+	RefreshTokenTTL time.Duration
+}
+
+// ParseClientApplication parses a client application written as its ID,
+// optionally followed by its token lifetimes, e.g. "web", "ios=15m/720h" or
+// "android=/720h", returning ErrInvalidClientApplication for malformed ones
+func ParseClientApplication(spec string) (*ClientApplication, error) {
+	id, lifetimes, hasLifetimes := strings.Cut(strings.TrimSpace(spec), "=")
+	if !clientIDPattern.MatchString(id) {
+		return nil, fmt.Errorf("%w: %q, IDs are lowercase letters, digits, '.', '_' and '-'", ErrInvalidClientApplication, spec)
+	}
+	client := &ClientApplication{ID: id}
+	if !hasLifetimes {
+		return client, nil
+	}
+
+	access, refresh, ok := strings.Cut(lifetimes, "/")
+	if !ok {
+		return nil, fmt.Errorf("%w: %q, expected id=access/refresh such as \"ios=15m/720h\"", ErrInvalidClientApplication, spec)
+	}
+	var err error
+	if client.AccessTokenTTL, err = parseLifetime(access); err != nil {
+		return nil, fmt.Errorf("%w: %q: %v", ErrInvalidClientApplication, spec, err)
+	}
+	if client.RefreshTokenTTL, err = parseLifetime(refresh); err != nil {
+		return nil, fmt.Errorf("%w: %q: %v", ErrInvalidClientApplication, spec, err)
+	}
+	return client, nil
+}
+
+// parseLifetime parses a positive duration, empty for none
+func parseLifetime(s string) (time.Duration, error) {
+	if s == "" {
+		return 0, nil
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil {
+		return 0, err
+	}
+	if d <= 0 {
+		return 0, fmt.Errorf("lifetime %s must be positive", s)
+	}
+	return d, nil
+}
+
+// ValidClientID reports whether id is a well-formed client application ID:
+// lowercase letters, digits, '.', '_' and '-', at most 50 characters
+func ValidClientID(id string) bool {
+	return clientIDPattern.MatchString(id)
+}
+
+// TokenLifetimes returns the access and refresh token lifetimes of sessions
+// of the client for users with settings
+func (c *ClientApplication) TokenLifetimes(settings *TenantSettings) (access, refresh time.Duration) {
+	access, refresh = settings.AccessTokenTTL, settings.RefreshTokenTTL
+	if c == nil {
+		return access, refresh
+	}
+	if c.AccessTokenTTL > 0 {
+		access = c.AccessTokenTTL
+	}
+	if c.RefreshTokenTTL > 0 {
+		refresh = c.RefreshTokenTTL
+	}
+	return access, refresh
+}
+
+// ClientSessionCutoff revokes the access tokens of a client application's
+// sessions issued up to RevokedAt, of UserID or of every user when nil.
+// Access tokens carry their client ID, so cutoffs are checked on every
+// authenticated request.
+type ClientSessionCutoff struct {
+	ID        uint      `gorm:"primaryKey"`
+	ClientID  string    `gorm:"type:varchar(50);not null;index:idx_client_session_cutoffs_client_user,priority:1"`
+	UserID    *uint     `gorm:"index:idx_client_session_cutoffs_client_user,priority:2"`
+	RevokedAt time.Time `gorm:"not null"`
+}
+
+// ClientSessionRevocation reports the revocation of the sessions of a client
+// application, of one user or of every user
+type ClientSessionRevocation struct {
+	ClientID string `json:"client_id"`
+	// UserID is the user whose sessions were revoked, nil for every user
+	UserID *uint `json:"user_id,omitempty"`
+	// RefreshTokens is the number of refresh tokens revoked
+	RefreshTokens int64     `json:"refresh_tokens_revoked"`
+	RevokedAt     time.Time `json:"revoked_at"`
+}
@@ -0,0 +1,99 @@
+package domain
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ClientVersion is a dotted numeric app version such as 2.4.1. Pre-release
+// and build suffixes ("2.4.1-beta", "2.4.1+42") are ignored when comparing.
+type ClientVersion []int
+
+// ParseClientVersion parses a version of up to four numeric parts, with an
+// optional "v" prefix, returning ErrInvalidClientVersion for malformed ones
+func ParseClientVersion(s string) (ClientVersion, error) {
+	core := strings.TrimPrefix(strings.TrimSpace(s), "v")
+	if i := strings.IndexAny(core, "-+"); i >= 0 {
+		core = core[:i]
+	}
+	parts := strings.Split(core, ".")
+	if core == "" || len(parts) > 4 {
+		return nil, fmt.Errorf("%w: %q", ErrInvalidClientVersion, s)
+	}
+	version := make(ClientVersion, len(parts))
+	for i, part := range parts {
+		n, err := strconv.Atoi(part)
+		if err != nil || n < 0 {
+			return nil, fmt.Errorf("%w: %q", ErrInvalidClientVersion, s)
+		}
+		version[i] = n
+	}
+	return version, nil
+}
+
+// Less reports whether v is an older version than other. Missing parts count
+// as zero, so 2.4 and 2.4.0 are the same version.
+func (v ClientVersion) Less(other ClientVersion) bool {
+	for i := 0; i < len(v) || i < len(other); i++ {
+		var a, b int
+		if i < len(v) {
+			a = v[i]
+		}
+		if i < len(other) {
+			b = other[i]
+		}
+		if a != b {
+			return a < b
+		}
+	}
+	return false
+}
+
+// ClientVersionPolicy is the minimum app version of a client application.
+// Requests from older versions are refused until the app is upgraded.
+type ClientVersionPolicy struct {
+	ClientID   string `gorm:"primaryKey;type:varchar(50)"`
+	MinVersion string `gorm:"not null;type:varchar(32)"`
+	// UpdatedBy is the admin who set the minimum version
+	UpdatedBy *uint
+	UpdatedAt time.Time `gorm:"autoUpdateTime"`
+}
+
+// TableName specifies the table name for GORM
+func (ClientVersionPolicy) TableName() string {
+	return "client_version_policies"
+}
+
+// ToResponse converts ClientVersionPolicy to ClientVersionPolicyResponse
+func (p *ClientVersionPolicy) ToResponse() *ClientVersionPolicyResponse {
+	return &ClientVersionPolicyResponse{
+		ClientID:   p.ClientID,
+		MinVersion: p.MinVersion,
+		UpdatedBy:  p.UpdatedBy,
+		UpdatedAt:  p.UpdatedAt.UTC(),
+	}
+}
+
+// ClientVersionPolicyResponse represents the client version policy response
+type ClientVersionPolicyResponse struct {
+	ClientID   string    `json:"client_id"`
+	MinVersion string    `json:"min_version"`
+	UpdatedBy  *uint     `json:"updated_by,omitempty"`
+	UpdatedAt  time.Time `json:"updated_at"`
+}
+
+// SetClientMinVersionRequest sets the minimum app version of a client application
+type SetClientMinVersionRequest struct {
+	MinVersion string `json:"min_version" validate:"required,max=32"`
+}
+
+// ClientUpgradeRequired is the error detail of requests refused for an
+// outdated app version
+type ClientUpgradeRequired struct {
+	Code           string `json:"code"`
+	ClientID       string `json:"client_id"`
+	ClientVersion  string `json:"client_version"`
+	MinimumVersion string `json:"min_version"`
+}
@@ -1,25 +1,63 @@
 package domain
 
+import "time"
+
 // RegisterRequest represents registration request
 type RegisterRequest struct {
-	Name     string `json:"name" validate:"required,min=2,max=100"`
+	FirstName   string `json:"first_name" validate:"required_without=Name,omitempty,max=50"`
+	LastName    string `json:"last_name" validate:"omitempty,max=50"`
+	DisplayName string `json:"display_name" validate:"omitempty,min=2,max=100"`
+	// Name is the full name sent by clients predating the name parts, split
+	// at its last space. It is ignored when FirstName is set.
+	Name     string `json:"name" validate:"required_without=FirstName,omitempty,min=2,max=100"`
 	Email    string `json:"email" validate:"required,email"`
 	Password string `json:"password" validate:"required,min=6"`
+	// InvitationToken is the token of an organization invitation sent to
+	// Email, required when self-registration is disabled
+	InvitationToken string `json:"invitation_token"`
 }
 
 // LoginRequest represents login request
 type LoginRequest struct {
 	Email    string `json:"email" validate:"required,email"`
 	Password string `json:"password" validate:"required"`
+	// ClientID is the client application the session is started from
+	ClientID string `json:"client_id,omitempty" validate:"omitempty,max=50"`
+	// Country is where the request came from, set by the handler when known
+	Country string `json:"-"`
 }
 
-// LoginResponse represents login response with tokens
+// LoginResponse represents login response with tokens. When a second factor is
+// needed, Status is set and AccessToken is a restricted token for the two-factor
+// endpoints, without refresh token.
 type LoginResponse struct {
 	User         *UserResponse `json:"user"`
+	Status       string        `json:"status,omitempty"`
 	AccessToken  string        `json:"access_token"`
-	RefreshToken string        `json:"refresh_token"`
+	RefreshToken string        `json:"refresh_token,omitempty"`
 	ExpiresIn    int64         `json:"expires_in"` // seconds until access token expires
 	TokenType    string        `json:"token_type"`
+	Scope        string        `json:"scope,omitempty"`
+}
+
+// TwoFactorCodeRequest carries a TOTP code
+type TwoFactorCodeRequest struct {
+	Code string `json:"code" validate:"required,len=6,numeric"`
+}
+
+// ReauthenticateRequest confirms the identity of a logged-in user with their
+// password or, when enrolled, a TOTP code
+type ReauthenticateRequest struct {
+	Password string `json:"password" validate:"required_without=Code"`
+	Code     string `json:"code" validate:"omitempty,len=6,numeric"`
+}
+
+// ReauthenticateResponse carries a short-lived access token with a fresh auth_time
+type ReauthenticateResponse struct {
+	AccessToken string    `json:"access_token"`
+	ExpiresIn   int64     `json:"expires_in"`
+	TokenType   string    `json:"token_type"`
+	AuthTime    time.Time `json:"auth_time"`
 }
 
 // RefreshTokenRequest represents refresh token request
@@ -42,8 +80,26 @@ type LogoutRequest struct {
 
 // UpdateUserRequest represents update user request
 type UpdateUserRequest struct {
+	FirstName string `json:"first_name" validate:"omitempty,max=50"`
+	// LastName and DisplayName are cleared when set to an empty string
+	LastName    *string `json:"last_name" validate:"omitempty,max=50"`
+	DisplayName *string `json:"display_name" validate:"omitempty,max=100"`
+	// Name replaces the whole name, for clients predating the name parts. It
+	// is ignored when any name part is set.
 	Name  string `json:"name" validate:"omitempty,min=2,max=100"`
 	Email string `json:"email" validate:"omitempty,email"`
+	// IsAdmin promotes or demotes the user when set
+	IsAdmin *bool `json:"is_admin"`
+}
+
+// UpdateAdminScopesRequest replaces the delegated admin scopes of a user
+type UpdateAdminScopesRequest struct {
+	Scopes []string `json:"scopes" validate:"dive,oneof=admin:user-read admin:user-write admin:token-admin admin:audit-read"`
+}
+
+// UpdateInactivityExemptRequest opts a user out of (or back into) the inactivity policy
+type UpdateInactivityExemptRequest struct {
+	Exempt *bool `json:"exempt" validate:"required"`
 }
 
 // PaginationQuery represents pagination parameters
@@ -51,6 +107,9 @@ type PaginationQuery struct {
 	Page     int    `json:"page" form:"page"`
 	PageSize int    `json:"page_size" form:"page_size"`
 	Search   string `json:"search" form:"search"`
+	// Sort is a comma-separated list of fields, each prefixed with "-" for
+	// descending order, e.g. "-created_at,name"
+	Sort string `json:"sort" form:"sort"`
 }
 
 // PaginatedResponse represents paginated response
@@ -70,6 +129,210 @@ type ChangePasswordRequest struct {
 
 // UpdateProfileRequest represents update own profile request for self-service
 type UpdateProfileRequest struct {
+	FirstName string `json:"first_name" validate:"omitempty,max=50"`
+	// LastName and DisplayName are cleared when set to an empty string
+	LastName    *string `json:"last_name" validate:"omitempty,max=50"`
+	DisplayName *string `json:"display_name" validate:"omitempty,max=100"`
+	// Name replaces the whole name, for clients predating the name parts. It
+	// is ignored when any name part is set.
 	Name  string `json:"name" validate:"omitempty,min=2,max=100"`
 	Email string `json:"email" validate:"omitempty,email"`
+	// Timezone is the IANA time zone profile timestamps are rendered in, e.g.
+	// "Asia/Jakarta"; "UTC" resets it
+	Timezone *string `json:"timezone" validate:"omitempty,timezone"`
+	// LogoutOtherSessionsOnLogin logs out every other session whenever the user logs in
+	LogoutOtherSessionsOnLogin *bool `json:"logout_other_sessions_on_login"`
+}
+
+// TokenClaimsResponse represents the decoded claims of an access token
+type TokenClaimsResponse struct {
+	UserID    uint      `json:"user_id"`
+	Email     string    `json:"email"`
+	IssuedAt  time.Time `json:"issued_at"`
+	ExpiresAt time.Time `json:"expires_at"`
+	ExpiresIn int64     `json:"expires_in"` // seconds until the token expires
+	// AuthTime is when the user last authenticated, absent on refreshed tokens
+	AuthTime *time.Time `json:"auth_time,omitempty"`
+	// Tenant claims, present in multi-tenant mode
+	OrganizationID uint     `json:"org_id,omitempty"`
+	Plan           string   `json:"plan,omitempty"`
+	Entitlements   []string `json:"entitlements,omitempty"`
+}
+
+// MeResponse represents the current token's claims and the resolved user
+type MeResponse struct {
+	Claims *TokenClaimsResponse `json:"claims"`
+	User   *UserResponse        `json:"user"`
+	Roles  []string             `json:"roles"`
+	Scopes []string             `json:"scopes"`
+}
+
+// InspectTokenRequest represents a development token inspection request
+type InspectTokenRequest struct {
+	Token string `json:"token" validate:"required"`
+}
+
+// CreateAPIKeyRequest represents a request to issue a new API key
+type CreateAPIKeyRequest struct {
+	Name          string   `json:"name" validate:"required,min=2,max=100"`
+	ExpiresInDays int      `json:"expires_in_days" validate:"omitempty,min=1,max=3650"`
+	Scopes        []string `json:"scopes" validate:"required,min=1,dive,oneof=profile:read profile:write"`
+}
+
+// CreateAPIKeyResponse represents a newly issued API key.
+// Key holds the plaintext credential and is only returned once.
+type CreateAPIKeyResponse struct {
+	Key    string          `json:"key"`
+	APIKey *APIKeyResponse `json:"api_key"`
+}
+
+// APIKeyRotationReport summarizes a run of the API key rotation check
+type APIKeyRotationReport struct {
+	Checked    time.Time `json:"checked_at"`
+	Stale      int       `json:"stale"`    // Keys older than MaxAge
+	Notified   int       `json:"notified"` // Owners reminded during this run
+	Expiring   int       `json:"expiring"` // Keys scheduled to auto-expire during this run
+	MaxAge     string    `json:"max_age"`
+	AutoExpire bool      `json:"auto_expire"`
+}
+
+// CreatePlanRequest represents a request to create a plan. Zero limits are unlimited.
+type CreatePlanRequest struct {
+	Name              string   `json:"name" validate:"required,min=2,max=50"`
+	RequestsPerMinute int      `json:"requests_per_minute" validate:"min=0"`
+	MaxActiveUsers    int      `json:"max_active_users" validate:"min=0"`
+	MaxSessions       int      `json:"max_sessions" validate:"min=0"`
+	Entitlements      []string `json:"entitlements" validate:"dive,required,max=50,excludesall=0x2C"`
+}
+
+// CreateOrganizationRequest represents a request to create an organization
+type CreateOrganizationRequest struct {
+	Name   string `json:"name" validate:"required,min=2,max=100"`
+	PlanID uint   `json:"plan_id" validate:"required"`
+}
+
+// AddOrganizationMemberRequest represents a request to add a user to an organization
+type AddOrganizationMemberRequest struct {
+	UserID uint   `json:"user_id" validate:"required"`
+	Role   string `json:"role" validate:"omitempty,oneof=owner admin member"`
+}
+
+// InviteMemberRequest represents a request to invite an email address to an organization
+type InviteMemberRequest struct {
+	Email string `json:"email" validate:"required,email"`
+	Role  string `json:"role" validate:"omitempty,oneof=admin member"`
+}
+
+// RevertEmailChangeRequest carries the token emailed to the previous address of a user
+type RevertEmailChangeRequest struct {
+	Token string `json:"token" validate:"required"`
+}
+
+// RevertEmailChangeResponse carries the password reset token returned by an
+// email change revert, to replace the password the revert discarded
+type RevertEmailChangeResponse struct {
+	PasswordResetToken string `json:"password_reset_token"`
+	ExpiresIn          int64  `json:"expires_in"`
+}
+
+// CheckEmailRequest asks whether an email is registered
+type CheckEmailRequest struct {
+	Email string `json:"email" validate:"required,email"`
+}
+
+// CheckEmailResponse tells whether an email can be used to register
+type CheckEmailResponse struct {
+	Available bool `json:"available"`
+}
+
+// RequestAccountUnlockRequest asks for a new unlock link for a locked account
+type RequestAccountUnlockRequest struct {
+	Email string `json:"email" validate:"required,email"`
+}
+
+// UnlockAccountRequest carries the token emailed to a locked user and the
+// password replacing the current one
+type UnlockAccountRequest struct {
+	Token       string `json:"token" validate:"required"`
+	NewPassword string `json:"new_password" validate:"required,min=6"`
+}
+
+// ForgotPasswordRequest asks for a password reset link to be emailed
+type ForgotPasswordRequest struct {
+	Email string `json:"email" validate:"required,email"`
+}
+
+// ResetPasswordRequest carries the token emailed by a forgot password
+// request and the new password
+type ResetPasswordRequest struct {
+	Token       string `json:"token" validate:"required"`
+	NewPassword string `json:"new_password" validate:"required,min=6"`
+}
+
+// AcceptInvitationRequest represents a request to accept an organization invitation
+type AcceptInvitationRequest struct {
+	Token string `json:"token" validate:"required"`
+}
+
+// TransferOwnershipRequest represents a request to transfer organization ownership to another member
+type TransferOwnershipRequest struct {
+	UserID uint `json:"user_id" validate:"required"`
+}
+
+// UpdateOrganizationSettingsRequest replaces an organization's setting overrides.
+// Omitted or null fields inherit the global configuration.
+type UpdateOrganizationSettingsRequest struct {
+	AccessTokenTTLSeconds    *int     `json:"access_token_ttl_seconds" validate:"omitempty,min=60,max=86400"`
+	RefreshTokenTTLSeconds   *int     `json:"refresh_token_ttl_seconds" validate:"omitempty,min=300,max=7776000"`
+	PasswordMinLength        *int     `json:"password_min_length" validate:"omitempty,min=6,max=128"`
+	PasswordRequireUppercase *bool    `json:"password_require_uppercase"`
+	PasswordRequireDigit     *bool    `json:"password_require_digit"`
+	PasswordRequireSymbol    *bool    `json:"password_require_symbol"`
+	AllowedEmailDomains      []string `json:"allowed_email_domains" validate:"dive,required,fqdn"`
+	Require2FA               *bool    `json:"require_2fa"`
+	// AccessSchedule restricts when members may log in, e.g. "mon-fri 08:00-20:00"
+	AccessSchedule string `json:"access_schedule" validate:"max=100"`
+}
+
+// LoginStartRequest represents the first step of login, before the client knows how the user signs in
+type LoginStartRequest struct {
+	Email string `json:"email" validate:"required,email"`
+}
+
+// CreateSSOConnectionRequest represents a request to create an SSO connection
+type CreateSSOConnectionRequest struct {
+	Name           string               `json:"name" validate:"required,min=2,max=100"`
+	Protocol       string               `json:"protocol" validate:"required,oneof=oidc saml"`
+	LoginURL       string               `json:"login_url" validate:"required,url,max=500"`
+	OrganizationID *uint                `json:"organization_id"`
+	Domains        []string             `json:"domains" validate:"required,min=1,dive,required,fqdn"`
+	Provisioning   SSOProvisioningRules `json:"provisioning"`
+}
+
+// SSOLoginRequest carries the attributes of a verified identity provider assertion
+type SSOLoginRequest struct {
+	Attributes map[string]string `json:"attributes" validate:"required"`
+	// ClientID is the client application the session is started from
+	ClientID string `json:"client_id,omitempty" validate:"omitempty,max=50"`
+}
+
+// RevokeClientSessionsRequest asks to log out the sessions of a client application
+type RevokeClientSessionsRequest struct {
+	ClientID string `json:"client_id" validate:"required,max=50"`
+}
+
+// User filter operators
+const (
+	FilterEqual      = "eq"
+	FilterContains   = "co"
+	FilterStartsWith = "sw"
+)
+
+// UserFilter narrows a user listing down to users whose Field ("email" or "name")
+// matches Value using Operator, and optionally to active or deactivated users
+type UserFilter struct {
+	Field    string
+	Operator string
+	Value    string
+	Active   *bool
 }
@@ -19,16 +19,95 @@ var (
 	ErrInvalidSigningMethod       = errors.New("invalid signing method")
 	ErrAuthHeaderRequired         = errors.New("authorization header required")
 	ErrInvalidAuthHeaderFormat    = errors.New("invalid authorization header format")
+	ErrCookieTokenHeaderRequired  = errors.New("requests authenticated by cookie must send the X-Requested-With header")
 	ErrInvalidOrExpiredToken      = errors.New("invalid or expired token")
 	ErrRateLimitExceeded          = errors.New("rate limit exceeded")
+	ErrPasswordCheckBusy          = errors.New("too many concurrent login attempts, please retry later")
+	ErrUserDeactivated            = errors.New("user account has been deactivated")
+	ErrPasswordPolicyViolation    = errors.New("password does not meet the password policy")
+	ErrAccountLocked              = errors.New("account locked due to suspicious activity, follow the link emailed to you to unlock it")
+	ErrPasswordUnchanged          = errors.New("new password must differ from the current password")
+	ErrCaptchaInvalid             = errors.New("captcha verification failed")
+	ErrCaptchaUnavailable         = errors.New("captcha verification is unavailable, try again later")
+	ErrInvalidSort                = errors.New("invalid sort parameter")
+	ErrInvalidFilter              = errors.New("invalid filter parameter")
+	ErrInvalidAnalyticsRange      = errors.New("invalid analytics range")
+	ErrSelfRegistrationDisabled   = errors.New("registration requires an invitation")
+	ErrOutsideAccessSchedule      = errors.New("access is not allowed at this time by your access schedule")
+	ErrInvalidAccessSchedule      = errors.New("invalid access schedule")
+	ErrUnknownClient              = errors.New("unknown client application")
+	ErrClientIDRequired           = errors.New("client_id is required")
+	ErrInvalidClientApplication   = errors.New("invalid client application")
+	ErrInvalidClientVersion       = errors.New("invalid client version")
+	ErrClientUpgradeRequired      = errors.New("this app version is no longer supported, upgrade the app")
+	ErrClientMinVersionNotSet     = errors.New("client has no minimum version")
+
+	// Admin safeguard errors
+	ErrCannotDeleteSelf           = errors.New("you cannot delete your own account")
+	ErrLastAdmin                  = errors.New("operation would leave no active admin")
+	ErrAdminRoleRequired          = errors.New("only admins can modify admin accounts")
+
+	// Two-factor errors
+	ErrInvalidTwoFactorCode       = errors.New("invalid two-factor authentication code")
+	ErrTwoFactorNotEnrolled       = errors.New("two-factor authentication is not enrolled")
+	ErrTwoFactorAlreadyEnrolled   = errors.New("two-factor authentication is already enrolled")
+	ErrTooManyTwoFactorAttempts   = errors.New("too many invalid two-factor authentication codes, try again later")
+	ErrRestrictedToken            = errors.New("token is restricted to two-factor authentication")
+	ErrReauthenticationRequired   = errors.New("recent authentication required, reauthenticate and retry")
 
 	// Token errors
 	ErrTokenNotFound              = errors.New("token not found")
 	ErrTokenExpired               = errors.New("token has expired")
+	ErrPermissionsChanged         = errors.New("permissions changed, refresh the access token")
+	ErrSigningKeyRotated          = errors.New("token was signed with a rotated signing key, refresh the access token")
+	ErrUnknownSigningKey          = errors.New("token was signed with an unknown signing key")
+	ErrTokenFormatRetired         = errors.New("token format is no longer accepted, refresh the access token")
+	ErrNoSigningKey               = errors.New("every signing key was reported compromised, deploy a new JWT_SECRET")
 	ErrTokenRevoked               = errors.New("token has been revoked")
 	ErrTokenReused                = errors.New("token reuse detected - potential security breach")
 	ErrInvalidRefreshToken        = errors.New("invalid refresh token")
 	ErrFailedToCreateRefreshToken = errors.New("failed to create refresh token")
+	ErrOneTimeTokenNotFound       = errors.New("one-time token not found")
+	ErrOneTimeTokenInvalid        = errors.New("token is invalid, expired or already used")
+
+	// API key errors
+	ErrAPIKeyNotFound             = errors.New("api key not found")
+	ErrInvalidAPIKey              = errors.New("invalid or expired api key")
+	ErrAPIKeyNotAccepted          = errors.New("api keys are not accepted on this route")
+	ErrAPIKeyScopeMissing         = errors.New("api key lacks the scope required by this route")
+
+	// Organization errors
+	ErrOrganizationNotFound       = errors.New("organization not found")
+	ErrOrganizationAlreadyExists  = errors.New("organization with this name already exists")
+	ErrPlanNotFound               = errors.New("plan not found")
+	ErrPlanAlreadyExists          = errors.New("plan with this name already exists")
+	ErrRequestQuotaExceeded       = errors.New("organization request quota exceeded")
+	ErrUserQuotaExceeded          = errors.New("organization active user limit reached")
+	ErrSessionQuotaExceeded       = errors.New("organization session limit reached")
+	ErrQuotaUnavailable           = errors.New("organization quota cannot be checked, try again later")
+	ErrEntitlementRequired        = errors.New("your plan does not include this feature")
+	ErrOrganizationForbidden      = errors.New("insufficient organization role")
+	ErrNotOrganizationMember      = errors.New("user is not a member of this organization")
+	ErrAlreadyOrganizationMember  = errors.New("user already belongs to an organization")
+	ErrCannotRemoveOwner          = errors.New("organization owner cannot be removed, transfer ownership first")
+	ErrInvitationNotFound         = errors.New("invitation not found")
+	ErrInvitationExpired          = errors.New("invitation has expired or was already accepted")
+	ErrInvitationEmailMismatch    = errors.New("invitation was sent to a different email address")
+	ErrEmailDomainNotAllowed      = errors.New("email domain is not allowed by the organization")
+
+	// SSO errors
+	ErrSSOConnectionNotFound      = errors.New("sso connection not found")
+	ErrSSOConnectionAlreadyExists = errors.New("sso connection with this name already exists")
+	ErrSSODomainTaken             = errors.New("email domain is already mapped to an sso connection")
+	ErrSSOEmailDomainMismatch     = errors.New("asserted email domain is not mapped to the sso connection")
+	ErrJITProvisioningDisabled    = errors.New("user does not exist and just-in-time provisioning is disabled")
+
+	// Webhook errors
+	ErrWebhookDeliveryNotFound    = errors.New("webhook delivery not found")
+	ErrWebhookEndpointRemoved     = errors.New("webhook endpoint is no longer configured")
+
+	// Abuse report errors
+	ErrAbuseReportNotFound        = errors.New("abuse report not found")
 )
 
 type ValidationError struct {
@@ -0,0 +1,58 @@
+package domain
+
+import "time"
+
+// Inactivity policy stages, applied in this order
+const (
+	InactivityStageWarn      = "warn"
+	InactivityStageSuspend   = "suspend"
+	InactivityStageAnonymize = "anonymize"
+)
+
+// InactiveUserQuery selects users due for an inactivity policy stage. A user's
+// last activity is their last login, or their registration if they never logged in.
+type InactiveUserQuery struct {
+	Stage string
+	// LastActiveBefore selects users inactive since before this time
+	LastActiveBefore time.Time
+	// WarnedBefore and SuspendedBefore, if set, require the earlier stages to
+	// have been applied before these times, so that e.g. a warning precedes
+	// suspension by the configured period
+	WarnedBefore    *time.Time
+	SuspendedBefore *time.Time
+	Limit           int
+}
+
+// UserAnonymization describes the erasure of an inactive user's personal data
+type UserAnonymization struct {
+	UserID uint
+	// Name and Email replace the user's personal data
+	Name  string
+	Email string
+	// Password replaces the password hash, locking the account
+	Password string
+	// AccessTokensRevokedUntil is how long the user's access tokens stay blacklisted
+	AccessTokensRevokedUntil time.Time
+	// Audit is recorded in the same transaction as the anonymization
+	Audit *AuditLog
+}
+
+// InactivityReport summarizes a run of the inactivity policy
+type InactivityReport struct {
+	Checked    time.Time `json:"checked"`
+	Warned     int       `json:"warned"`
+	Suspended  int       `json:"suspended"`
+	Anonymized int       `json:"anonymized"`
+}
+
+// LastActiveAt returns the user's last login, or their registration if they never logged in
+func (u *User) LastActiveAt() time.Time {
+	switch {
+	case u.LastLoginAt != nil:
+		return *u.LastLoginAt
+	case u.FirstLoginAt != nil:
+		return *u.FirstLoginAt
+	default:
+		return u.CreatedAt
+	}
+}
@@ -0,0 +1,76 @@
+package domain
+
+import "time"
+
+// Onboarding steps of a new user
+const (
+	// OnboardingStepEmailVerified is the user proving they receive mail at
+	// their email, e.g. by following an emailed link or signing in via SSO
+	OnboardingStepEmailVerified = "email_verified"
+	// OnboardingStepProfileCompleted is the user saving their own profile
+	OnboardingStepProfileCompleted = "profile_completed"
+	// OnboardingStepTwoFactorEnrolled is the user confirming a TOTP enrollment
+	OnboardingStepTwoFactorEnrolled = "two_factor_enrolled"
+)
+
+// OnboardingSteps lists the onboarding steps in the order they are presented
+var OnboardingSteps = []string{
+	OnboardingStepEmailVerified,
+	OnboardingStepProfileCompleted,
+	OnboardingStepTwoFactorEnrolled,
+}
+
+// OnboardingStep is an onboarding step a user completed
+type OnboardingStep struct {
+	UserID      uint      `gorm:"primaryKey;autoIncrement:false"`
+	Step        string    `gorm:"primaryKey;type:varchar(30)"`
+	CompletedAt time.Time `gorm:"not null"`
+	User        User      `gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE"`
+}
+
+// TableName specifies the table name for GORM
+func (OnboardingStep) TableName() string {
+	return "user_onboarding_steps"
+}
+
+// OnboardingStepStatus represents the completion of one onboarding step
+type OnboardingStepStatus struct {
+	Step        string     `json:"step"`
+	Completed   bool       `json:"completed"`
+	CompletedAt *time.Time `json:"completed_at,omitempty"`
+}
+
+// OnboardingStatusResponse summarizes the onboarding of a user
+type OnboardingStatusResponse struct {
+	Steps          []OnboardingStepStatus `json:"steps"`
+	CompletedSteps int                    `json:"completed_steps"`
+	TotalSteps     int                    `json:"total_steps"`
+	// Percent is the share of completed steps, rounded down
+	Percent   int  `json:"percent"`
+	Completed bool `json:"completed"`
+}
+
+// NewOnboardingStatusResponse summarizes the completed steps of a user over
+// all OnboardingSteps. Steps that are no longer defined are ignored.
+func NewOnboardingStatusResponse(completed []*OnboardingStep) *OnboardingStatusResponse {
+	completedAt := make(map[string]time.Time, len(completed))
+	for _, step := range completed {
+		completedAt[step.Step] = step.CompletedAt
+	}
+
+	status := &OnboardingStatusResponse{
+		Steps:      make([]OnboardingStepStatus, len(OnboardingSteps)),
+		TotalSteps: len(OnboardingSteps),
+	}
+	for i, step := range OnboardingSteps {
+		status.Steps[i] = OnboardingStepStatus{Step: step}
+		if at, ok := completedAt[step]; ok {
+			status.Steps[i].Completed = true
+			status.Steps[i].CompletedAt = &at
+			status.CompletedSteps++
+		}
+	}
+	status.Percent = status.CompletedSteps * 100 / status.TotalSteps
+	status.Completed = status.CompletedSteps == status.TotalSteps
+	return status
+}
@@ -0,0 +1,64 @@
+package domain
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// One-time token purposes. A token is only accepted for the purpose it was issued for.
+const (
+	OneTimeTokenPurposeInvitation        = "organization_invitation"
+	OneTimeTokenPurposeEmailChangeRevert = "email_change_revert"
+	OneTimeTokenPurposeAccountUnlock     = "account_unlock"
+	OneTimeTokenPurposePasswordReset     = "password_reset"
+)
+
+// OneTimeToken is a single-use secret sent to a user, e.g. in an email link.
+// Only the hash of the token is stored. Each flow keeps its own data in
+// Payload as JSON instead of in a table of its own.
+type OneTimeToken struct {
+	ID      uint   `gorm:"primaryKey"`
+	Purpose string `gorm:"not null;type:varchar(50);index:idx_one_time_tokens_subject,priority:1;index:idx_one_time_tokens_resource,priority:1"`
+	// Subject is who the token was issued to, e.g. an email address
+	Subject string `gorm:"not null;type:varchar(255);index:idx_one_time_tokens_subject,priority:2"`
+	// ResourceID is the record the token acts on, e.g. the organization of an invitation
+	ResourceID *uint      `gorm:"index:idx_one_time_tokens_resource,priority:2"`
+	TokenHash  string     `gorm:"unique;not null;type:varchar(64)"`
+	Payload    string     `gorm:"type:text"`
+	ExpiresAt  time.Time  `gorm:"not null;index"`
+	ConsumedAt *time.Time `gorm:"index"`
+	CreatedAt  time.Time  `gorm:"autoCreateTime"`
+	UpdatedAt  time.Time  `gorm:"autoUpdateTime"`
+}
+
+// TableName specifies the table name for GORM
+func (OneTimeToken) TableName() string {
+	return "one_time_tokens"
+}
+
+// IsUsable reports whether the token can still be consumed
+func (t *OneTimeToken) IsUsable(now time.Time) bool {
+	return t.ConsumedAt == nil && now.Before(t.ExpiresAt)
+}
+
+// SetPayload stores payload as JSON, clearing it when payload is nil
+func (t *OneTimeToken) SetPayload(payload interface{}) error {
+	if payload == nil {
+		t.Payload = ""
+		return nil
+	}
+	raw, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	t.Payload = string(raw)
+	return nil
+}
+
+// DecodePayload decodes the JSON payload into payload. An empty payload leaves it unchanged.
+func (t *OneTimeToken) DecodePayload(payload interface{}) error {
+	if t.Payload == "" {
+		return nil
+	}
+	return json.Unmarshal([]byte(t.Payload), payload)
+}
@@ -0,0 +1,226 @@
+package domain
+
+import (
+	"strings"
+	"time"
+)
+
+// Plan is a subscription tier defining organization-level limits.
+// A zero limit means unlimited.
+type Plan struct {
+	ID                uint   `gorm:"primaryKey"`
+	Name              string `gorm:"unique;not null;type:varchar(50)"`
+	RequestsPerMinute int    `gorm:"not null;default:0"`
+	MaxActiveUsers    int    `gorm:"not null;default:0"`
+	MaxSessions       int    `gorm:"not null;default:0"`
+	// Entitlements is a comma-separated list of features included in the plan, e.g. "sso,audit_log"
+	Entitlements string    `gorm:"type:varchar(500)"`
+	CreatedAt    time.Time `gorm:"autoCreateTime"`
+	UpdatedAt    time.Time `gorm:"autoUpdateTime"`
+}
+
+// TableName specifies the table name for GORM
+func (Plan) TableName() string {
+	return "plans"
+}
+
+// EntitlementList returns the features included in the plan
+func (p *Plan) EntitlementList() []string {
+	entitlements := []string{}
+	for _, e := range strings.Split(p.Entitlements, ",") {
+		if e = strings.TrimSpace(e); e != "" {
+			entitlements = append(entitlements, e)
+		}
+	}
+	return entitlements
+}
+
+// Organization represents a tenant grouping users under a plan
+type Organization struct {
+	ID        uint      `gorm:"primaryKey"`
+	Name      string    `gorm:"unique;not null;type:varchar(100)"`
+	PlanID    uint      `gorm:"not null;index"`
+	Plan      Plan      `gorm:"foreignKey:PlanID"`
+	CreatedAt time.Time `gorm:"autoCreateTime"`
+	UpdatedAt time.Time `gorm:"autoUpdateTime"`
+}
+
+// TableName specifies the table name for GORM
+func (Organization) TableName() string {
+	return "organizations"
+}
+
+// PlanResponse represents the plan response
+type PlanResponse struct {
+	ID                uint     `json:"id"`
+	Name              string   `json:"name"`
+	RequestsPerMinute int      `json:"requests_per_minute"`
+	MaxActiveUsers    int      `json:"max_active_users"`
+	MaxSessions       int      `json:"max_sessions"`
+	Entitlements      []string `json:"entitlements"`
+}
+
+// ToResponse converts Plan to PlanResponse
+func (p *Plan) ToResponse() *PlanResponse {
+	return &PlanResponse{
+		ID:                p.ID,
+		Name:              p.Name,
+		RequestsPerMinute: p.RequestsPerMinute,
+		MaxActiveUsers:    p.MaxActiveUsers,
+		MaxSessions:       p.MaxSessions,
+		Entitlements:      p.EntitlementList(),
+	}
+}
+
+// OrganizationResponse represents the organization response
+type OrganizationResponse struct {
+	ID        uint          `json:"id"`
+	Name      string        `json:"name"`
+	Plan      *PlanResponse `json:"plan"`
+	CreatedAt time.Time     `json:"created_at"`
+}
+
+// ToResponse converts Organization to OrganizationResponse
+func (o *Organization) ToResponse() *OrganizationResponse {
+	return &OrganizationResponse{
+		ID:        o.ID,
+		Name:      o.Name,
+		Plan:      o.Plan.ToResponse(),
+		CreatedAt: o.CreatedAt.UTC(),
+	}
+}
+
+// OrganizationUsage reports an organization's consumption against its plan limits
+type OrganizationUsage struct {
+	OrganizationID     uint   `json:"organization_id"`
+	Plan               string `json:"plan"`
+	RequestsThisMinute int    `json:"requests_this_minute"`
+	RequestsPerMinute  int    `json:"requests_per_minute"`
+	ActiveUsers        int64  `json:"active_users"`
+	MaxActiveUsers     int    `json:"max_active_users"`
+	Sessions           int64  `json:"sessions"`
+	MaxSessions        int    `json:"max_sessions"`
+}
+
+// OrganizationSessionRevocation reports an organization wide forced logout
+type OrganizationSessionRevocation struct {
+	OrganizationID uint `json:"organization_id"`
+	// Members is the number of members whose access tokens were invalidated
+	Members int64 `json:"members"`
+	// RefreshTokens is the number of refresh tokens revoked
+	RefreshTokens int64     `json:"refresh_tokens_revoked"`
+	RevokedAt     time.Time `json:"revoked_at"`
+}
+
+// Organization roles
+const (
+	OrgRoleOwner  = "owner"
+	OrgRoleAdmin  = "admin"
+	OrgRoleMember = "member"
+)
+
+// IsOrganizationMember reports whether the user belongs to the organization
+func (u *User) IsOrganizationMember(orgID uint) bool {
+	return u.OrganizationID != nil && *u.OrganizationID == orgID
+}
+
+// CanManageOrganization reports whether the user may invite and remove members of the organization
+func (u *User) CanManageOrganization(orgID uint) bool {
+	if u.IsAdmin {
+		return true
+	}
+	return u.IsOrganizationMember(orgID) && (u.OrganizationRole == OrgRoleOwner || u.OrganizationRole == OrgRoleAdmin)
+}
+
+// OrganizationInvitation invites an email address to join an organization.
+// It is stored as a one-time token issued to the email address for the
+// organization; only the hash of the invitation token is stored.
+type OrganizationInvitation struct {
+	ID             uint
+	OrganizationID uint
+	Email          string
+	Role           string
+	TokenHash      string
+	InvitedByID    uint
+	ExpiresAt      time.Time
+	AcceptedAt     *time.Time
+	CreatedAt      time.Time
+	UpdatedAt      time.Time
+	Organization   Organization
+}
+
+// invitationPayload is the data of an invitation kept in its one-time token
+type invitationPayload struct {
+	Role        string `json:"role"`
+	InvitedByID uint   `json:"invited_by_id"`
+}
+
+// ToOneTimeToken returns the one-time token storing the invitation
+func (i *OrganizationInvitation) ToOneTimeToken() (*OneTimeToken, error) {
+	orgID := i.OrganizationID
+	token := &OneTimeToken{
+		ID:         i.ID,
+		Purpose:    OneTimeTokenPurposeInvitation,
+		Subject:    i.Email,
+		ResourceID: &orgID,
+		TokenHash:  i.TokenHash,
+		ExpiresAt:  i.ExpiresAt,
+		ConsumedAt: i.AcceptedAt,
+		CreatedAt:  i.CreatedAt,
+		UpdatedAt:  i.UpdatedAt,
+	}
+	if err := token.SetPayload(&invitationPayload{Role: i.Role, InvitedByID: i.InvitedByID}); err != nil {
+		return nil, err
+	}
+	return token, nil
+}
+
+// InvitationFromOneTimeToken restores an invitation from its one-time token
+func InvitationFromOneTimeToken(token *OneTimeToken) (*OrganizationInvitation, error) {
+	var payload invitationPayload
+	if err := token.DecodePayload(&payload); err != nil {
+		return nil, err
+	}
+	invitation := &OrganizationInvitation{
+		ID:          token.ID,
+		Email:       token.Subject,
+		Role:        payload.Role,
+		TokenHash:   token.TokenHash,
+		InvitedByID: payload.InvitedByID,
+		ExpiresAt:   token.ExpiresAt,
+		AcceptedAt:  token.ConsumedAt,
+		CreatedAt:   token.CreatedAt,
+		UpdatedAt:   token.UpdatedAt,
+	}
+	if token.ResourceID != nil {
+		invitation.OrganizationID = *token.ResourceID
+	}
+	return invitation, nil
+}
+
+// IsPending reports whether the invitation can still be accepted
+func (i *OrganizationInvitation) IsPending(now time.Time) bool {
+	return i.AcceptedAt == nil && now.Before(i.ExpiresAt)
+}
+
+// OrganizationInvitationResponse represents the invitation response
+type OrganizationInvitationResponse struct {
+	ID             uint      `json:"id"`
+	OrganizationID uint      `json:"organization_id"`
+	Email          string    `json:"email"`
+	Role           string    `json:"role"`
+	ExpiresAt      time.Time `json:"expires_at"`
+	CreatedAt      time.Time `json:"created_at"`
+}
+
+// ToResponse converts OrganizationInvitation to OrganizationInvitationResponse
+func (i *OrganizationInvitation) ToResponse() *OrganizationInvitationResponse {
+	return &OrganizationInvitationResponse{
+		ID:             i.ID,
+		OrganizationID: i.OrganizationID,
+		Email:          i.Email,
+		Role:           i.Role,
+		ExpiresAt:      i.ExpiresAt.UTC(),
+		CreatedAt:      i.CreatedAt.UTC(),
+	}
+}
@@ -0,0 +1,192 @@
+package domain
+
+import (
+	"encoding/json"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// Sources of profile changes
+const (
+	ProfileChangeSourceSelf        = "profile"
+	ProfileChangeSourceAdmin       = "admin"
+	ProfileChangeSourceEmailRevert = "email_revert"
+	ProfileChangeSourceSCIM        = "scim"
+	ProfileChangeSourceSSO         = "sso"
+)
+
+// Fields tracked by the profile history
+const (
+	ProfileFieldFirstName   = "first_name"
+	ProfileFieldLastName    = "last_name"
+	ProfileFieldDisplayName = "display_name"
+	ProfileFieldEmail       = "email"
+)
+
+// Who made a profile change, as shown to the user in their redacted history
+const (
+	ProfileChangedBySelf   = "self"
+	ProfileChangedByAdmin  = "admin"
+	ProfileChangedBySystem = "system"
+)
+
+// ProfileChange is a version of the profile of a user, recording who changed
+// which fields. Versions are numbered from 1 for each user.
+type ProfileChange struct {
+	ID      uint `gorm:"primaryKey"`
+	UserID  uint `gorm:"not null;uniqueIndex:idx_profile_changes_user_version"`
+	Version uint `gorm:"not null;uniqueIndex:idx_profile_changes_user_version"`
+	// ActorID is the user who made the change, nil for the system, an identity
+	// provider or a revert link
+	ActorID *uint  `gorm:"index"`
+	Source  string `gorm:"type:varchar(20);not null"`
+	// Changes is the JSON encoded []ProfileFieldChange. It holds email
+	// addresses and is encrypted like the email of users.
+	Changes   string    `gorm:"type:text;not null;serializer:pii"`
+	CreatedAt time.Time `gorm:"autoCreateTime"`
+}
+
+// TableName specifies the table name for GORM
+func (ProfileChange) TableName() string {
+	return "profile_changes"
+}
+
+// ProfileFieldChange is the previous and new value of a profile field
+type ProfileFieldChange struct {
+	Field    string `json:"field"`
+	OldValue string `json:"old_value"`
+	NewValue string `json:"new_value"`
+}
+
+// Fields decodes the changed fields
+func (c *ProfileChange) Fields() ([]ProfileFieldChange, error) {
+	var fields []ProfileFieldChange
+	if err := json.Unmarshal([]byte(c.Changes), &fields); err != nil {
+		return nil, err
+	}
+	return fields, nil
+}
+
+// SetFields encodes the changed fields
+func (c *ProfileChange) SetFields(fields []ProfileFieldChange) error {
+	data, err := json.Marshal(fields)
+	if err != nil {
+		return err
+	}
+	c.Changes = string(data)
+	return nil
+}
+
+// ProfileSnapshot is the state of the tracked fields of a profile
+type ProfileSnapshot struct {
+	FirstName   string
+	LastName    string
+	DisplayName string
+	Email       string
+}
+
+// SnapshotProfile returns the tracked fields of the profile of user. Users
+// created with only a full name have it split, as when they are saved.
+func SnapshotProfile(user *User) ProfileSnapshot {
+	firstName, lastName := user.FirstName, user.LastName
+	if !user.hasNameParts() {
+		firstName, lastName = SplitName(user.Name)
+	}
+	return ProfileSnapshot{
+		FirstName:   firstName,
+		LastName:    lastName,
+		DisplayName: user.DisplayName,
+		Email:       user.Email,
+	}
+}
+
+// Diff lists the fields changed from s to other
+func (s ProfileSnapshot) Diff(other ProfileSnapshot) []ProfileFieldChange {
+	var changes []ProfileFieldChange
+	for _, field := range []ProfileFieldChange{
+		{Field: ProfileFieldFirstName, OldValue: s.FirstName, NewValue: other.FirstName},
+		{Field: ProfileFieldLastName, OldValue: s.LastName, NewValue: other.LastName},
+		{Field: ProfileFieldDisplayName, OldValue: s.DisplayName, NewValue: other.DisplayName},
+		{Field: ProfileFieldEmail, OldValue: s.Email, NewValue: other.Email},
+	} {
+		if field.OldValue != field.NewValue {
+			changes = append(changes, field)
+		}
+	}
+	return changes
+}
+
+// ProfileChangeResponse represents a profile change as shown to admins
+type ProfileChangeResponse struct {
+	Version   uint                 `json:"version"`
+	ActorID   *uint                `json:"actor_id"`
+	Source    string               `json:"source"`
+	Changes   []ProfileFieldChange `json:"changes"`
+	CreatedAt time.Time            `json:"created_at"`
+}
+
+// ToResponse converts ProfileChange to ProfileChangeResponse
+func (c *ProfileChange) ToResponse() (*ProfileChangeResponse, error) {
+	fields, err := c.Fields()
+	if err != nil {
+		return nil, err
+	}
+	return &ProfileChangeResponse{
+		Version:   c.Version,
+		ActorID:   c.ActorID,
+		Source:    c.Source,
+		Changes:   fields,
+		CreatedAt: c.CreatedAt.UTC(),
+	}, nil
+}
+
+// OwnProfileChangeResponse represents a profile change as shown to the user:
+// admins are not identified and email addresses are masked, so a stolen
+// session does not reveal the previous addresses of the account
+type OwnProfileChangeResponse struct {
+	Version uint `json:"version"`
+	// ChangedBy is ProfileChangedBySelf, ProfileChangedByAdmin or ProfileChangedBySystem
+	ChangedBy string               `json:"changed_by"`
+	Changes   []ProfileFieldChange `json:"changes"`
+	CreatedAt time.Time            `json:"created_at"`
+}
+
+// ToOwnResponse converts ProfileChange to the redacted OwnProfileChangeResponse
+func (c *ProfileChange) ToOwnResponse() (*OwnProfileChangeResponse, error) {
+	fields, err := c.Fields()
+	if err != nil {
+		return nil, err
+	}
+	for i, field := range fields {
+		if field.Field == ProfileFieldEmail {
+			fields[i].OldValue = MaskEmail(field.OldValue)
+			fields[i].NewValue = MaskEmail(field.NewValue)
+		}
+	}
+
+	changedBy := ProfileChangedBySystem
+	switch {
+	case c.ActorID != nil && *c.ActorID == c.UserID:
+		changedBy = ProfileChangedBySelf
+	case c.ActorID != nil || c.Source == ProfileChangeSourceAdmin:
+		changedBy = ProfileChangedByAdmin
+	}
+	return &OwnProfileChangeResponse{
+		Version:   c.Version,
+		ChangedBy: changedBy,
+		Changes:   fields,
+		CreatedAt: c.CreatedAt.UTC(),
+	}, nil
+}
+
+// MaskEmail keeps the first character of the local part and the domain of
+// email, e.g. "j***@example.com"
+func MaskEmail(email string) string {
+	local, domainPart, ok := strings.Cut(email, "@")
+	if !ok || local == "" {
+		return "***"
+	}
+	first, _ := utf8.DecodeRuneInString(local)
+	return string(first) + "***@" + domainPart
+}
@@ -8,6 +8,27 @@ type Response struct {
 	Error   interface{} `json:"error,omitempty"`
 }
 
+// Codes of rejected access tokens, carried in the error of 401 responses so
+// clients can tell a token to refresh from one to discard
+const (
+	// ErrorCodeTokenExpired means the token was valid but expired; refreshing
+	// the session yields a new one
+	ErrorCodeTokenExpired = "TOKEN_EXPIRED"
+	// ErrorCodeTokenInvalid means the token is malformed, forged or not
+	// accepted; the user must log in again
+	ErrorCodeTokenInvalid = "TOKEN_INVALID"
+	// ErrorCodePermissionsChanged means the roles of the user changed since
+	// the token was issued; refreshing the session yields a token with the
+	// current roles
+	ErrorCodePermissionsChanged = "PERMISSIONS_CHANGED"
+	// ErrorCodeOutsideAccessSchedule means the access schedule of the user's
+	// roles or organization does not allow logging in or refreshing now
+	ErrorCodeOutsideAccessSchedule = "OUTSIDE_ACCESS_SCHEDULE"
+	// ErrorCodeUpgradeRequired means the app version sent in the
+	// X-Client-Version header is below the minimum version of the client
+	ErrorCodeUpgradeRequired = "UPGRADE_REQUIRED"
+)
+
 // SuccessResponse creates a success response
 func SuccessResponse(message string, data interface{}) *Response {
 	return &Response{
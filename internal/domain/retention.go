@@ -0,0 +1,18 @@
+package domain
+
+import "time"
+
+// Datasets purged by the retention policy
+const (
+	RetentionRevokedTokens  = "revoked_refresh_tokens"
+	RetentionLoginHistory   = "login_history"
+	RetentionTokenBlacklist = "token_blacklist"
+	RetentionAuditLogs      = "audit_logs"
+)
+
+// RetentionReport summarizes a run of the retention policy
+type RetentionReport struct {
+	Checked time.Time `json:"checked"`
+	// Purged is the number of rows deleted per dataset
+	Purged map[string]int64 `json:"purged"`
+}
@@ -0,0 +1,85 @@
+package domain
+
+import "strings"
+
+// Role names
+const (
+	RoleUser  = "user"
+	RoleAdmin = "admin"
+)
+
+// Scope names granted by roles
+const (
+	ScopeProfileRead  = "profile:read"
+	ScopeProfileWrite = "profile:write"
+	ScopeAdmin        = "admin"
+)
+
+// Delegated admin scopes. Admins hold all of them; other users can be granted
+// a subset, e.g. support staff who may view users but not delete them.
+const (
+	ScopeAdminUserRead   = "admin:user-read"
+	ScopeAdminUserWrite  = "admin:user-write"
+	ScopeAdminTokenAdmin = "admin:token-admin"
+	ScopeAdminAuditRead  = "admin:audit-read"
+)
+
+// AdminScopes lists the delegated admin scopes
+var AdminScopes = []string{ScopeAdminUserRead, ScopeAdminUserWrite, ScopeAdminTokenAdmin, ScopeAdminAuditRead}
+
+// Scopes of restricted tokens issued during login, only accepted by the
+// two-factor endpoints
+const (
+	ScopeTwoFactorVerify = "2fa:verify"
+	ScopeTwoFactorEnroll = "2fa:enroll"
+)
+
+// Roles returns the roles granted to the user
+func (u *User) Roles() []string {
+	roles := []string{RoleUser}
+	if u.IsAdmin {
+		roles = append(roles, RoleAdmin)
+	}
+	return roles
+}
+
+// Scopes returns the effective scopes granted by the user's roles
+func (u *User) Scopes() []string {
+	scopes := []string{ScopeProfileRead, ScopeProfileWrite}
+	if u.IsAdmin {
+		scopes = append(scopes, ScopeAdmin)
+	}
+	return append(scopes, u.AdminScopeList()...)
+}
+
+// AdminScopeList returns the delegated admin scopes held by the user. Admins
+// hold every scope.
+func (u *User) AdminScopeList() []string {
+	if u.IsAdmin {
+		return append([]string{}, AdminScopes...)
+	}
+	scopes := []string{}
+	for _, s := range strings.Split(u.AdminScopes, ",") {
+		if s = strings.TrimSpace(s); s != "" {
+			scopes = append(scopes, s)
+		}
+	}
+	return scopes
+}
+
+// TokenVersions are the versions of the user's state that access tokens
+// carry, to tell when a token was issued before a change
+type TokenVersions struct {
+	SessionEpoch uint
+	PermVersion  uint
+}
+
+// HasAdminScope reports whether the user holds the delegated admin scope
+func (u *User) HasAdminScope(scope string) bool {
+	for _, s := range u.AdminScopeList() {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
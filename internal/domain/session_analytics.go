@@ -0,0 +1,87 @@
+package domain
+
+import (
+	"fmt"
+	"time"
+)
+
+// Tables whose growth is reported by the session analytics
+const (
+	TokenTableRefreshTokens = "refresh_tokens"
+	TokenTableBlacklist     = "token_blacklist"
+)
+
+// MaxAnalyticsBuckets bounds the number of buckets of a session analytics
+// report
+const MaxAnalyticsBuckets = 168
+
+// AnalyticsBuckets divides the time from From into Count buckets of Interval
+type AnalyticsBuckets struct {
+	From     time.Time
+	Interval time.Duration
+	Count    int
+}
+
+// NewAnalyticsBuckets divides from to to into buckets of interval, returning
+// ErrInvalidAnalyticsRange for empty ranges, intervals under a minute and
+// more than MaxAnalyticsBuckets buckets. A last partial bucket is extended to
+// a full interval.
+func NewAnalyticsBuckets(from, to time.Time, interval time.Duration) (*AnalyticsBuckets, error) {
+	if !from.Before(to) {
+		return nil, fmt.Errorf("%w: from must be before to", ErrInvalidAnalyticsRange)
+	}
+	if interval < time.Minute {
+		return nil, fmt.Errorf("%w: interval must be at least 1m", ErrInvalidAnalyticsRange)
+	}
+	count := int((to.Sub(from) + interval - 1) / interval)
+	if count > MaxAnalyticsBuckets {
+		return nil, fmt.Errorf("%w: at most %d intervals are reported, widen the interval", ErrInvalidAnalyticsRange, MaxAnalyticsBuckets)
+	}
+	return &AnalyticsBuckets{From: from, Interval: interval, Count: count}, nil
+}
+
+// Start returns when bucket i starts
+func (b *AnalyticsBuckets) Start(i int) time.Time {
+	return b.From.Add(time.Duration(i) * b.Interval)
+}
+
+// To returns when the last bucket ends
+func (b *AnalyticsBuckets) To() time.Time {
+	return b.Start(b.Count)
+}
+
+// SessionAnalyticsReport reports session activity and token table growth
+// over time, for sizing the database and tuning token lifetimes
+type SessionAnalyticsReport struct {
+	From     time.Time                `json:"from"`
+	To       time.Time                `json:"to"`
+	Interval string                   `json:"interval"`
+	Points   []*SessionAnalyticsPoint `json:"points"`
+	Tables   []*TokenTableStats       `json:"tables"`
+}
+
+// SessionAnalyticsPoint is the session activity of one interval
+type SessionAnalyticsPoint struct {
+	// Time is the start of the interval
+	Time time.Time `json:"time"`
+	// ActiveSessions is the number of sessions holding a valid refresh token at Time
+	ActiveSessions int64 `json:"active_sessions"`
+	// Refreshes is the number of refresh token rotations during the interval
+	Refreshes        int64   `json:"refreshes"`
+	RefreshesPerHour float64 `json:"refreshes_per_hour"`
+	// RefreshTokensCreated and BlacklistEntriesCreated are the rows added to
+	// the token tables during the interval
+	RefreshTokensCreated    int64 `json:"refresh_tokens_created"`
+	BlacklistEntriesCreated int64 `json:"blacklist_entries_created"`
+}
+
+// TokenTableStats is the current size and the growth of a token table
+type TokenTableStats struct {
+	Table string `json:"table"`
+	Rows  int64  `json:"rows"`
+	// DataBytes and IndexBytes are the storage estimates of the database
+	DataBytes  int64 `json:"data_bytes"`
+	IndexBytes int64 `json:"index_bytes"`
+	// RowsCreatedPerDay is the average number of rows added per day over the report
+	RowsCreatedPerDay float64 `json:"rows_created_per_day"`
+}
@@ -0,0 +1,198 @@
+package domain
+
+import (
+	"strings"
+	"time"
+	"unicode"
+)
+
+// PasswordPolicy defines the requirements new passwords must meet
+type PasswordPolicy struct {
+	MinLength        int  `json:"min_length"`
+	RequireUppercase bool `json:"require_uppercase"`
+	RequireDigit     bool `json:"require_digit"`
+	RequireSymbol    bool `json:"require_symbol"`
+}
+
+// Check returns ErrPasswordPolicyViolation if the password does not meet the policy
+func (p PasswordPolicy) Check(password string) error {
+	if len([]rune(password)) < p.MinLength {
+		return ErrPasswordPolicyViolation
+	}
+
+	var hasUpper, hasDigit, hasSymbol bool
+	for _, r := range password {
+		switch {
+		case unicode.IsUpper(r):
+			hasUpper = true
+		case unicode.IsDigit(r):
+			hasDigit = true
+		case unicode.IsPunct(r) || unicode.IsSymbol(r):
+			hasSymbol = true
+		}
+	}
+	if (p.RequireUppercase && !hasUpper) || (p.RequireDigit && !hasDigit) || (p.RequireSymbol && !hasSymbol) {
+		return ErrPasswordPolicyViolation
+	}
+	return nil
+}
+
+// TenantSettings are the settings in effect for an organization: the global
+// configuration with the organization's overrides applied
+type TenantSettings struct {
+	AccessTokenTTL  time.Duration
+	RefreshTokenTTL time.Duration
+	PasswordPolicy  PasswordPolicy
+	// AllowedEmailDomains restricts member email addresses, empty allows any domain
+	AllowedEmailDomains []string
+	Require2FA          bool
+	// AccessSchedule restricts when members may log in, nil allows any time
+	AccessSchedule *AccessSchedule
+}
+
+// AllowsEmail reports whether the email address may belong to a member
+func (s *TenantSettings) AllowsEmail(email string) bool {
+	if len(s.AllowedEmailDomains) == 0 {
+		return true
+	}
+	at := strings.LastIndex(email, "@")
+	if at < 0 {
+		return false
+	}
+	domain := strings.ToLower(email[at+1:])
+	for _, allowed := range s.AllowedEmailDomains {
+		if domain == allowed {
+			return true
+		}
+	}
+	return false
+}
+
+// OrganizationSettings stores an organization's overrides of global settings.
+// Nil fields inherit the global configuration.
+type OrganizationSettings struct {
+	OrganizationID           uint `gorm:"primaryKey;autoIncrement:false"`
+	AccessTokenTTLSeconds    *int
+	RefreshTokenTTLSeconds   *int
+	PasswordMinLength        *int
+	PasswordRequireUppercase *bool
+	PasswordRequireDigit     *bool
+	PasswordRequireSymbol    *bool
+	// AllowedEmailDomains is a comma-separated list of domains, empty allows any domain
+	AllowedEmailDomains string `gorm:"type:varchar(1000)"`
+	Require2FA          *bool  `gorm:"column:require_2fa"`
+	// AccessSchedule restricts when members may log in, e.g. "mon-fri 08:00-20:00"
+	AccessSchedule string    `gorm:"type:varchar(100)"`
+	UpdatedAt      time.Time `gorm:"autoUpdateTime"`
+}
+
+// TableName specifies the table name for GORM
+func (OrganizationSettings) TableName() string {
+	return "organization_settings"
+}
+
+// DomainList returns the allowed email domains
+func (o *OrganizationSettings) DomainList() []string {
+	domains := []string{}
+	for _, d := range strings.Split(o.AllowedEmailDomains, ",") {
+		if d = strings.TrimSpace(d); d != "" {
+			domains = append(domains, d)
+		}
+	}
+	return domains
+}
+
+// Apply returns the defaults with the organization's overrides applied
+func (o *OrganizationSettings) Apply(defaults TenantSettings) *TenantSettings {
+	settings := defaults
+	if o.AccessTokenTTLSeconds != nil {
+		settings.AccessTokenTTL = time.Duration(*o.AccessTokenTTLSeconds) * time.Second
+	}
+	if o.RefreshTokenTTLSeconds != nil {
+		settings.RefreshTokenTTL = time.Duration(*o.RefreshTokenTTLSeconds) * time.Second
+	}
+	if o.PasswordMinLength != nil {
+		settings.PasswordPolicy.MinLength = *o.PasswordMinLength
+	}
+	if o.PasswordRequireUppercase != nil {
+		settings.PasswordPolicy.RequireUppercase = *o.PasswordRequireUppercase
+	}
+	if o.PasswordRequireDigit != nil {
+		settings.PasswordPolicy.RequireDigit = *o.PasswordRequireDigit
+	}
+	if o.PasswordRequireSymbol != nil {
+		settings.PasswordPolicy.RequireSymbol = *o.PasswordRequireSymbol
+	}
+	if domains := o.DomainList(); len(domains) > 0 {
+		settings.AllowedEmailDomains = domains
+	}
+	if o.Require2FA != nil {
+		settings.Require2FA = *o.Require2FA
+	}
+	// Schedules are validated when saved
+	if schedule, err := ParseAccessSchedule(o.AccessSchedule); o.AccessSchedule != "" && err == nil {
+		settings.AccessSchedule = schedule
+	}
+	return &settings
+}
+
+// EffectiveSettingsResponse represents the settings in effect for an organization
+type EffectiveSettingsResponse struct {
+	AccessTokenTTLSeconds  int            `json:"access_token_ttl_seconds"`
+	RefreshTokenTTLSeconds int            `json:"refresh_token_ttl_seconds"`
+	PasswordPolicy         PasswordPolicy `json:"password_policy"`
+	AllowedEmailDomains    []string       `json:"allowed_email_domains"`
+	Require2FA             bool           `json:"require_2fa"`
+	AccessSchedule         string         `json:"access_schedule,omitempty"`
+}
+
+// ToResponse converts TenantSettings to EffectiveSettingsResponse
+func (s *TenantSettings) ToResponse() *EffectiveSettingsResponse {
+	domains := s.AllowedEmailDomains
+	if domains == nil {
+		domains = []string{}
+	}
+	response := &EffectiveSettingsResponse{
+		AccessTokenTTLSeconds:  int(s.AccessTokenTTL / time.Second),
+		RefreshTokenTTLSeconds: int(s.RefreshTokenTTL / time.Second),
+		PasswordPolicy:         s.PasswordPolicy,
+		AllowedEmailDomains:    domains,
+		Require2FA:             s.Require2FA,
+	}
+	if s.AccessSchedule != nil {
+		response.AccessSchedule = s.AccessSchedule.String()
+	}
+	return response
+}
+
+// OrganizationSettingsResponse represents an organization's overrides and the resulting settings
+type OrganizationSettingsResponse struct {
+	OrganizationID           uint                       `json:"organization_id"`
+	AccessTokenTTLSeconds    *int                       `json:"access_token_ttl_seconds"`
+	RefreshTokenTTLSeconds   *int                       `json:"refresh_token_ttl_seconds"`
+	PasswordMinLength        *int                       `json:"password_min_length"`
+	PasswordRequireUppercase *bool                      `json:"password_require_uppercase"`
+	PasswordRequireDigit     *bool                      `json:"password_require_digit"`
+	PasswordRequireSymbol    *bool                      `json:"password_require_symbol"`
+	AllowedEmailDomains      []string                   `json:"allowed_email_domains"`
+	Require2FA               *bool                      `json:"require_2fa"`
+	AccessSchedule           string                     `json:"access_schedule"`
+	Effective                *EffectiveSettingsResponse `json:"effective"`
+}
+
+// ToResponse converts OrganizationSettings to OrganizationSettingsResponse
+func (o *OrganizationSettings) ToResponse(defaults TenantSettings) *OrganizationSettingsResponse {
+	return &OrganizationSettingsResponse{
+		OrganizationID:           o.OrganizationID,
+		AccessTokenTTLSeconds:    o.AccessTokenTTLSeconds,
+		RefreshTokenTTLSeconds:   o.RefreshTokenTTLSeconds,
+		PasswordMinLength:        o.PasswordMinLength,
+		PasswordRequireUppercase: o.PasswordRequireUppercase,
+		PasswordRequireDigit:     o.PasswordRequireDigit,
+		PasswordRequireSymbol:    o.PasswordRequireSymbol,
+		AllowedEmailDomains:      o.DomainList(),
+		Require2FA:               o.Require2FA,
+		AccessSchedule:           o.AccessSchedule,
+		Effective:                o.Apply(defaults).ToResponse(),
+	}
+}
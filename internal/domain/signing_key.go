@@ -0,0 +1,46 @@
+package domain
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"time"
+)
+
+// SigningKeyIncident records a signing key reported compromised. Its ID is
+// the global key epoch: access tokens carry the epoch they were issued at and
+// are rejected once a later incident is recorded, so clients refresh their
+// sessions and get tokens signed with the rotated key.
+type SigningKeyIncident struct {
+	ID uint `gorm:"primaryKey"`
+	// KeyFingerprint identifies the compromised key, see SigningKeyFingerprint.
+	// Servers refuse to start with a key that was reported compromised.
+	KeyFingerprint string `gorm:"not null;index;type:varchar(64)"`
+	Reason         string `gorm:"type:varchar(255)"`
+	// OutstandingSessions is the number of sessions whose access tokens were
+	// invalidated, RefreshTokensRevoked those that must log in again
+	OutstandingSessions  int64     `gorm:"not null;default:0"`
+	RefreshTokensRevoked int64     `gorm:"not null;default:0"`
+	CreatedAt            time.Time `gorm:"autoCreateTime"`
+}
+
+// TableName specifies the table name for GORM
+func (SigningKeyIncident) TableName() string {
+	return "signing_key_incidents"
+}
+
+// SigningKeyCompromise reports the signing key in use as compromised
+type SigningKeyCompromise struct {
+	// Secret is the compromised signing key, only its fingerprint is stored
+	Secret string
+	Reason string
+	// RevokeRefreshTokens also revokes the outstanding refresh tokens, e.g.
+	// when the database may have leaked with the key, so that every user logs
+	// in again instead of refreshing
+	RevokeRefreshTokens bool
+}
+
+// SigningKeyFingerprint returns a non-reversible identifier of a signing key
+func SigningKeyFingerprint(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:16])
+}
@@ -0,0 +1,155 @@
+package domain
+
+import (
+	"strings"
+	"time"
+)
+
+// SSO connection protocols
+const (
+	SSOProtocolOIDC = "oidc"
+	SSOProtocolSAML = "saml"
+)
+
+// Login methods returned by home realm discovery
+const (
+	LoginMethodPassword = "password"
+	LoginMethodSSO      = "sso"
+)
+
+// SSOConnection is an enterprise identity provider that users of the mapped
+// email domains sign in with instead of a password
+type SSOConnection struct {
+	ID       uint   `gorm:"primaryKey"`
+	Name     string `gorm:"unique;not null;type:varchar(100)"`
+	Protocol string `gorm:"not null;type:varchar(10)"`
+	// LoginURL is the identity provider endpoint clients are redirected to
+	LoginURL       string               `gorm:"not null;type:varchar(500)"`
+	OrganizationID *uint                `gorm:"index"`
+	Provisioning   SSOProvisioningRules `gorm:"embedded;embeddedPrefix:jit_"`
+	Domains        []SSODomain          `gorm:"foreignKey:ConnectionID;constraint:OnDelete:CASCADE"`
+	CreatedAt      time.Time            `gorm:"autoCreateTime"`
+	UpdatedAt      time.Time            `gorm:"autoUpdateTime"`
+}
+
+// Default assertion attributes of the provisioning rules
+const (
+	DefaultSSOEmailAttribute = "email"
+	DefaultSSONameAttribute  = "name"
+)
+
+// SSOProvisioningRules control just-in-time provisioning of users signing in
+// through a connection. Attribute fields name the identity provider assertion
+// attributes mapped onto the user.
+type SSOProvisioningRules struct {
+	// Enabled creates unknown users on their first SSO login
+	Enabled             bool   `gorm:"not null;default:false" json:"enabled"`
+	EmailAttribute      string `gorm:"type:varchar(100)" json:"email_attribute" validate:"omitempty,max=100"`
+	NameAttribute       string `gorm:"type:varchar(100)" json:"name_attribute" validate:"omitempty,max=100"`
+	GivenNameAttribute  string `gorm:"type:varchar(100)" json:"given_name_attribute" validate:"omitempty,max=100"`
+	FamilyNameAttribute string `gorm:"type:varchar(100)" json:"family_name_attribute" validate:"omitempty,max=100"`
+	// DefaultRole is granted to provisioned users
+	DefaultRole string `gorm:"type:varchar(20)" json:"default_role" validate:"omitempty,oneof=user admin"`
+	// UpdateOnLogin overwrites the user's name with the assertion on every login
+	UpdateOnLogin bool `gorm:"not null;default:false" json:"update_on_login"`
+}
+
+// WithDefaults fills unset attribute names and the default role
+func (r SSOProvisioningRules) WithDefaults() SSOProvisioningRules {
+	if r.EmailAttribute == "" {
+		r.EmailAttribute = DefaultSSOEmailAttribute
+	}
+	if r.NameAttribute == "" {
+		r.NameAttribute = DefaultSSONameAttribute
+	}
+	if r.DefaultRole == "" {
+		r.DefaultRole = RoleUser
+	}
+	return r
+}
+
+// MapIdentity maps assertion attributes to the user's email and name. The name
+// falls back to the given and family name attributes, then to the email.
+func (r SSOProvisioningRules) MapIdentity(attributes map[string]string) (email, name string) {
+	email = strings.ToLower(strings.TrimSpace(attributes[r.EmailAttribute]))
+	name = strings.TrimSpace(attributes[r.NameAttribute])
+	if name == "" {
+		name = strings.TrimSpace(attributes[r.GivenNameAttribute] + " " + attributes[r.FamilyNameAttribute])
+	}
+	if name == "" {
+		name = email
+	}
+	return email, name
+}
+
+// TableName specifies the table name for GORM
+func (SSOConnection) TableName() string {
+	return "sso_connections"
+}
+
+// HasDomain reports whether the email domain is mapped to the connection
+func (c *SSOConnection) HasDomain(emailDomain string) bool {
+	for _, d := range c.Domains {
+		if d.Domain == emailDomain {
+			return true
+		}
+	}
+	return false
+}
+
+// SSODomain maps an email domain to an SSO connection. A domain belongs to at most one connection.
+type SSODomain struct {
+	ID           uint   `gorm:"primaryKey"`
+	ConnectionID uint   `gorm:"not null;index"`
+	Domain       string `gorm:"unique;not null;type:varchar(255)"`
+}
+
+// TableName specifies the table name for GORM
+func (SSODomain) TableName() string {
+	return "sso_domains"
+}
+
+// DomainList returns the email domains mapped to the connection
+func (c *SSOConnection) DomainList() []string {
+	domains := make([]string, len(c.Domains))
+	for i, d := range c.Domains {
+		domains[i] = d.Domain
+	}
+	return domains
+}
+
+// SSOConnectionResponse represents the SSO connection response
+type SSOConnectionResponse struct {
+	ID             uint                 `json:"id"`
+	Name           string               `json:"name"`
+	Protocol       string               `json:"protocol"`
+	LoginURL       string               `json:"login_url"`
+	OrganizationID *uint                `json:"organization_id"`
+	Provisioning   SSOProvisioningRules `json:"provisioning"`
+	Domains        []string             `json:"domains"`
+	CreatedAt      time.Time            `json:"created_at"`
+}
+
+// ToResponse converts SSOConnection to SSOConnectionResponse
+func (c *SSOConnection) ToResponse() *SSOConnectionResponse {
+	return &SSOConnectionResponse{
+		ID:             c.ID,
+		Name:           c.Name,
+		Protocol:       c.Protocol,
+		LoginURL:       c.LoginURL,
+		OrganizationID: c.OrganizationID,
+		Provisioning:   c.Provisioning,
+		Domains:        c.DomainList(),
+		CreatedAt:      c.CreatedAt.UTC(),
+	}
+}
+
+// LoginStartResponse tells the client how the user should sign in: with a
+// password, or by being redirected to the identity provider of the connection
+type LoginStartResponse struct {
+	Method       string `json:"method"`
+	ConnectionID uint   `json:"connection_id,omitempty"`
+	Connection   string `json:"connection,omitempty"`
+	Protocol     string `json:"protocol,omitempty"`
+	RedirectURL  string `json:"redirect_url,omitempty"`
+}
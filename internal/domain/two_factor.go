@@ -0,0 +1,37 @@
+package domain
+
+import "time"
+
+// Login statuses returned instead of a session when a second factor is needed
+const (
+	LoginStatusTwoFactorRequired           = "2fa_required"
+	LoginStatusTwoFactorEnrollmentRequired = "2fa_enrollment_required"
+)
+
+// UserTwoFactor holds a user's TOTP enrollment. The enrollment is pending
+// until the user confirms it with a valid code.
+type UserTwoFactor struct {
+	UserID      uint   `gorm:"primaryKey;autoIncrement:false"`
+	Secret      string `gorm:"not null;type:varchar(64)"`
+	ConfirmedAt *time.Time
+	// LastUsedStep is the TOTP time step of the last accepted code, preventing code replay
+	LastUsedStep int64     `gorm:"not null;default:0"`
+	CreatedAt    time.Time `gorm:"autoCreateTime"`
+	UpdatedAt    time.Time `gorm:"autoUpdateTime"`
+}
+
+// TableName specifies the table name for GORM
+func (UserTwoFactor) TableName() string {
+	return "user_two_factors"
+}
+
+// IsConfirmed reports whether the enrollment was confirmed
+func (t *UserTwoFactor) IsConfirmed() bool {
+	return t.ConfirmedAt != nil
+}
+
+// TwoFactorEnrollmentResponse carries the secret to add to an authenticator app
+type TwoFactorEnrollmentResponse struct {
+	Secret     string `json:"secret"`
+	OTPAuthURL string `json:"otpauth_url"`
+}
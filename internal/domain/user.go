@@ -1,18 +1,66 @@
 package domain
 
 import (
+	"gojwt-rest-api/internal/pii"
+	"strconv"
+	"strings"
 	"time"
+
+	"gorm.io/gorm"
 )
 
 // User represents the user entity
 type User struct {
-	ID        uint      `gorm:"primaryKey"`
-	Name      string    `gorm:"not null"`
-	Email     string    `gorm:"unique;not null"`
-	Password  string    `gorm:"not null"`
-	IsAdmin   bool      `gorm:"default:false"`
-	CreatedAt time.Time `gorm:"autoCreateTime"`
-	UpdatedAt time.Time `gorm:"autoUpdateTime"`
+	ID uint `gorm:"primaryKey"`
+	// Name is the full name shown for the user: DisplayName when set, otherwise
+	// FirstName and LastName. It is maintained on save for search and sorting.
+	Name         string `gorm:"not null;index"`
+	FirstName    string `gorm:"type:varchar(50);not null;default:''"`
+	LastName     string `gorm:"type:varchar(50);not null;default:''"`
+	DisplayName  string `gorm:"type:varchar(100);not null;default:''"`
+	Email        string `gorm:"unique;not null;size:512;serializer:pii"`
+	Password     string `gorm:"not null"`
+	IsAdmin      bool   `gorm:"default:false"`
+	FirstLoginAt *time.Time
+	// OrganizationID is the tenant the user belongs to in multi-tenant mode
+	OrganizationID *uint `gorm:"index"`
+	// OrganizationRole is the user's role within the organization (owner, admin or member)
+	OrganizationRole string `gorm:"type:varchar(20)"`
+	// AdminScopes is a comma-separated list of delegated admin scopes granted
+	// to a non-admin user, e.g. "admin:user-read,admin:audit-read"
+	AdminScopes string `gorm:"type:varchar(255)"`
+	// EmailHash is the blind index of the email when PII encryption is
+	// enabled, as encrypted emails cannot be looked up directly
+	EmailHash *string `gorm:"type:varchar(64);uniqueIndex"`
+	// LastLoginAt is the time of the most recent login
+	LastLoginAt *time.Time `gorm:"index"`
+	// InactivityExempt opts the user out of the inactivity policy
+	InactivityExempt bool `gorm:"default:false"`
+	// InactivityWarnedAt is set when the user was warned about upcoming
+	// suspension for inactivity, and cleared on their next login
+	InactivityWarnedAt *time.Time
+	// AnonymizedAt is set when the personal data of an inactive user was erased
+	AnonymizedAt *time.Time
+	// DeactivatedAt is set when the user is deprovisioned; deactivated users cannot log in
+	DeactivatedAt *time.Time
+	// Timezone is the IANA time zone the user prefers timestamps in, e.g.
+	// "Asia/Jakarta"; empty means UTC
+	Timezone string `gorm:"type:varchar(64);not null;default:''"`
+	// LogoutOtherSessionsOnLogin logs out every other session of the user
+	// whenever they log in
+	LogoutOtherSessionsOnLogin bool `gorm:"not null;default:false"`
+	// LockedAt is set when the account was locked for suspicious activity, for
+	// LockReason; locked users cannot log in until they unlock it by email
+	LockedAt   *time.Time
+	LockReason string `gorm:"type:varchar(30)"`
+	// SessionEpoch is carried by access tokens; bumping it invalidates every
+	// access token issued before
+	SessionEpoch uint `gorm:"not null;default:0"`
+	// PermVersion is bumped whenever the user's roles or admin scopes change;
+	// access tokens carrying an older version have stale role claims
+	PermVersion uint      `gorm:"not null;default:0"`
+	CreatedAt   time.Time `gorm:"autoCreateTime"`
+	UpdatedAt   time.Time `gorm:"autoUpdateTime"`
 }
 
 // TableName specifies the table name for GORM
@@ -20,14 +68,80 @@ func (User) TableName() string {
 	return "users"
 }
 
+// BeforeSave maintains the blind index of the email and the full name. Users
+// created with only a full name have it split into first and last name.
+func (u *User) BeforeSave(tx *gorm.DB) error {
+	u.EmailHash = pii.BlindIndex(u.Email)
+	if !u.hasNameParts() {
+		u.FirstName, u.LastName = SplitName(u.Name)
+	}
+	u.Name = u.FullName()
+	return nil
+}
+
+// FullName returns the display name of the user, or else the first and last
+// name joined
+func (u *User) FullName() string {
+	if u.DisplayName != "" {
+		return u.DisplayName
+	}
+	if !u.hasNameParts() {
+		return u.Name
+	}
+	return strings.TrimSpace(u.FirstName + " " + u.LastName)
+}
+
+// SetName replaces the name of the user with a full name, split into first
+// and last name, clearing the display name
+func (u *User) SetName(name string) {
+	u.FirstName, u.LastName = SplitName(name)
+	u.DisplayName = ""
+	u.Name = u.FullName()
+}
+
+// UpdateName changes the parts of the name that are set: an empty first name
+// and nil last or display name are left unchanged
+func (u *User) UpdateName(firstName string, lastName, displayName *string) {
+	if !u.hasNameParts() {
+		u.FirstName, u.LastName = SplitName(u.Name)
+	}
+	if firstName != "" {
+		u.FirstName = firstName
+	}
+	if lastName != nil {
+		u.LastName = *lastName
+	}
+	if displayName != nil {
+		u.DisplayName = *displayName
+	}
+	u.Name = u.FullName()
+}
+
+func (u *User) hasNameParts() bool {
+	return u.FirstName != "" || u.LastName != "" || u.DisplayName != ""
+}
+
+// SplitName splits a full name at its last space into first and last name,
+// e.g. "Mary Ann Smith" into "Mary Ann" and "Smith". Single-word names have
+// no last name.
+func SplitName(name string) (firstName, lastName string) {
+	name = strings.Join(strings.Fields(name), " ")
+	i := strings.LastIndex(name, " ")
+	if i < 0 {
+		return name, ""
+	}
+	return name[:i], name[i+1:]
+}
+
 // RefreshToken represents the refresh token entity
 type RefreshToken struct {
 	ID           uint      `gorm:"primaryKey"`
-	UserID       uint      `gorm:"not null;index"`
+	UserID       uint      `gorm:"not null;index:idx_refresh_tokens_user_revoked,priority:1"`
 	Token        string    `gorm:"unique;not null;type:varchar(500)"`
 	TokenFamily  string    `gorm:"not null;index;type:varchar(100)"` // For detecting token reuse
+	ClientID     string    `gorm:"type:varchar(50);index"`           // Client application the session was started from, empty if none
 	ExpiresAt    time.Time `gorm:"not null;index"`
-	IsRevoked    bool      `gorm:"default:false;index"`
+	IsRevoked    bool      `gorm:"default:false;index;index:idx_refresh_tokens_user_revoked,priority:2"`
 	RevokedAt    *time.Time
 	ReplacedBy   *string   `gorm:"type:varchar(500)"` // Track token rotation
 	CreatedAt    time.Time `gorm:"autoCreateTime"`
@@ -47,8 +161,8 @@ func (rt *RefreshToken) IsValid() bool {
 // TokenBlacklist represents blacklisted access tokens (for logout)
 type TokenBlacklist struct {
 	ID        uint      `gorm:"primaryKey"`
-	Token     string    `gorm:"unique;not null;type:varchar(500);index"`
-	ExpiresAt time.Time `gorm:"not null;index"`
+	Token     string    `gorm:"unique;not null;type:varchar(500);index:idx_token_blacklist_token_expires,priority:1"`
+	ExpiresAt time.Time `gorm:"not null;index;index:idx_token_blacklist_token_expires,priority:2"`
 	CreatedAt time.Time `gorm:"autoCreateTime"`
 }
 
@@ -57,24 +171,170 @@ func (TokenBlacklist) TableName() string {
 	return "token_blacklist"
 }
 
+// UserTokensBlacklistKey returns the blacklist entry that revokes every
+// access token of a user
+func UserTokensBlacklistKey(userID uint) string {
+	return "user:" + strconv.FormatUint(uint64(userID), 10)
+}
+
+// EmailChangeRevert is the payload of the token emailed to the previous
+// address of a user, which restores it
+type EmailChangeRevert struct {
+	UserID        uint   `json:"user_id"`
+	PreviousEmail string `json:"previous_email"`
+	NewEmail      string `json:"new_email"`
+}
+
+// AccountUnlock is the payload of the token emailed to a locked user, which
+// unlocks the account
+type AccountUnlock struct {
+	UserID uint `json:"user_id"`
+}
+
+// PasswordReset is the payload of the token emailed to a user who forgot
+// their password
+type PasswordReset struct {
+	UserID uint `json:"user_id"`
+}
+
+// UserDeletion describes a user deletion and the cleanup performed with it
+type UserDeletion struct {
+	UserID uint
+	// AccessTokensRevokedUntil is how long the user's access tokens stay
+	// blacklisted, i.e. until every issued token has expired
+	AccessTokensRevokedUntil time.Time
+	// Audit is recorded in the same transaction as the deletion
+	Audit *AuditLog
+}
+
+// IsActive reports whether the user has not been deactivated
+func (u *User) IsActive() bool {
+	return u.DeactivatedAt == nil
+}
+
+// IsLocked reports whether the account is locked for suspicious activity
+func (u *User) IsLocked() bool {
+	return u.LockedAt != nil
+}
+
 // UserResponse represents the user response (without password)
 type UserResponse struct {
-	ID        uint      `json:"id"`
-	Name      string    `json:"name"`
-	Email     string    `json:"email"`
-	IsAdmin   bool      `json:"is_admin"`
-	CreatedAt time.Time `json:"created_at"`
-	UpdatedAt time.Time `json:"updated_at"`
+	ID uint `json:"id"`
+	// Name is the computed full name, kept for clients predating the name parts
+	Name             string     `json:"name"`
+	FirstName        string     `json:"first_name"`
+	LastName         string     `json:"last_name"`
+	DisplayName      string     `json:"display_name,omitempty"`
+	Timezone         string     `json:"timezone,omitempty"`
+	Email            string     `json:"email"`
+	IsAdmin          bool       `json:"is_admin"`
+	AdminScopes      []string   `json:"admin_scopes,omitempty"`
+	FirstLoginAt     *time.Time `json:"first_login_at,omitempty"`
+	LastLoginAt      *time.Time `json:"last_login_at,omitempty"`
+	InactivityExempt bool       `json:"inactivity_exempt"`
+	OrganizationID   *uint      `json:"organization_id,omitempty"`
+	OrganizationRole string     `json:"organization_role,omitempty"`
+	Active           bool       `json:"active"`
+	LockedAt         *time.Time `json:"locked_at,omitempty"`
+	LockReason       string     `json:"lock_reason,omitempty"`
+	CreatedAt        time.Time  `json:"created_at"`
+	UpdatedAt        time.Time  `json:"updated_at"`
+
+	// LogoutOtherSessionsOnLogin is the session preference of the user
+	LogoutOtherSessionsOnLogin bool `json:"logout_other_sessions_on_login,omitempty"`
 }
 
-// ToResponse converts User to UserResponse
+// ToResponse converts User to UserResponse, with timestamps in UTC
 func (u *User) ToResponse() *UserResponse {
-	return &UserResponse{
-		ID:        u.ID,
-		Name:      u.Name,
-		Email:     u.Email,
-		IsAdmin:   u.IsAdmin,
-		CreatedAt: u.CreatedAt,
-		UpdatedAt: u.UpdatedAt,
+	firstName, lastName := u.FirstName, u.LastName
+	if !u.hasNameParts() {
+		firstName, lastName = SplitName(u.Name)
+	}
+	return (&UserResponse{
+		ID:               u.ID,
+		Name:             u.FullName(),
+		FirstName:        firstName,
+		LastName:         lastName,
+		DisplayName:      u.DisplayName,
+		Timezone:         u.Timezone,
+		Email:            u.Email,
+		IsAdmin:          u.IsAdmin,
+		AdminScopes:      u.AdminScopeList(),
+		FirstLoginAt:     u.FirstLoginAt,
+		LastLoginAt:      u.LastLoginAt,
+		InactivityExempt: u.InactivityExempt,
+		OrganizationID:   u.OrganizationID,
+		OrganizationRole: u.OrganizationRole,
+		Active:           u.IsActive(),
+		LockedAt:         u.LockedAt,
+		LockReason:       u.LockReason,
+		CreatedAt:        u.CreatedAt,
+		UpdatedAt:        u.UpdatedAt,
+
+		LogoutOtherSessionsOnLogin: u.LogoutOtherSessionsOnLogin,
+	}).In(time.UTC)
+}
+
+// ToProfileResponse converts User to UserResponse for the user themselves,
+// with timestamps in their preferred timezone
+func (u *User) ToProfileResponse() *UserResponse {
+	return u.ToResponse().In(u.Location())
+}
+
+// Location returns the preferred timezone of the user, UTC when unset or
+// unknown to the host
+func (u *User) Location() *time.Location {
+	if u.Timezone == "" {
+		return time.UTC
+	}
+	loc, err := time.LoadLocation(u.Timezone)
+	if err != nil {
+		return time.UTC
+	}
+	return loc
+}
+
+// In converts the timestamps of the response to loc
+func (r *UserResponse) In(loc *time.Location) *UserResponse {
+	r.FirstLoginAt = timeIn(r.FirstLoginAt, loc)
+	r.LastLoginAt = timeIn(r.LastLoginAt, loc)
+	r.LockedAt = timeIn(r.LockedAt, loc)
+	r.CreatedAt = r.CreatedAt.In(loc)
+	r.UpdatedAt = r.UpdatedAt.In(loc)
+	return r
+}
+
+func timeIn(t *time.Time, loc *time.Location) *time.Time {
+	if t == nil {
+		return nil
 	}
+	converted := t.In(loc)
+	return &converted
+}
+
+// UserSuggestion is a lightweight user row returned by search-as-you-type lookups
+type UserSuggestion struct {
+	ID    uint   `json:"id"`
+	Name  string `json:"name"`
+	Email string `json:"email" gorm:"serializer:pii"`
+}
+
+// LinkedProviderResponse describes a way the user signs in
+type LinkedProviderResponse struct {
+	// Method is LoginMethodPassword or LoginMethodSSO
+	Method       string `json:"method"`
+	ConnectionID uint   `json:"connection_id,omitempty"`
+	Connection   string `json:"connection,omitempty"`
+	Protocol     string `json:"protocol,omitempty"`
+}
+
+// UserDetailsResponse represents a user together with aggregate account
+// information for admin detail screens
+type UserDetailsResponse struct {
+	User              *UserResponse             `json:"user"`
+	ActiveSessions    int64                     `json:"active_sessions"`
+	LastLoginAt       *time.Time                `json:"last_login_at,omitempty"`
+	TwoFactorEnabled  bool                      `json:"two_factor_enabled"`
+	LinkedProviders   []*LinkedProviderResponse `json:"linked_providers"`
+	RecentAuditEvents []*AuditLogResponse       `json:"recent_audit_events,omitzero"`
 }
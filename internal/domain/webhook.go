@@ -0,0 +1,75 @@
+package domain
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// WebhookDelivery is an attempt to deliver an event to a webhook endpoint
+type WebhookDelivery struct {
+	ID        uint   `gorm:"primaryKey"`
+	EventID   string `gorm:"not null;index;type:varchar(64)"`
+	EventType string `gorm:"not null;index;type:varchar(100)"`
+	URL       string `gorm:"not null;type:varchar(500)"`
+	// Payload is the envelope sent, replayed as-is
+	Payload string `gorm:"type:text"`
+	// StatusCode is 0 when the endpoint did not respond
+	StatusCode int
+	Success    bool   `gorm:"index"`
+	Error      string `gorm:"type:varchar(500)"`
+	DurationMs int64
+	// ResponseSnippet is the beginning of the response body
+	ResponseSnippet string `gorm:"type:text"`
+	// ReplayOf is the delivery this attempt replayed
+	ReplayOf  *uint     `gorm:"index"`
+	CreatedAt time.Time `gorm:"autoCreateTime;index"`
+}
+
+// TableName specifies the table name for GORM
+func (WebhookDelivery) TableName() string {
+	return "webhook_deliveries"
+}
+
+// WebhookDeliveryFilter narrows a delivery listing. Zero values match everything.
+type WebhookDeliveryFilter struct {
+	EventType string
+	// Success filters successful (true) or failed (false) deliveries
+	Success *bool
+}
+
+// WebhookDeliveryResponse represents the webhook delivery response
+type WebhookDeliveryResponse struct {
+	ID              uint            `json:"id"`
+	EventID         string          `json:"event_id"`
+	EventType       string          `json:"event_type"`
+	URL             string          `json:"url"`
+	StatusCode      int             `json:"status_code"`
+	Success         bool            `json:"success"`
+	Error           string          `json:"error,omitempty"`
+	DurationMs      int64           `json:"duration_ms"`
+	ResponseSnippet string          `json:"response_snippet,omitempty"`
+	ReplayOf        *uint           `json:"replay_of,omitempty"`
+	Payload         json.RawMessage `json:"payload,omitempty"`
+	CreatedAt       time.Time       `json:"created_at"`
+}
+
+// ToResponse converts WebhookDelivery to WebhookDeliveryResponse
+func (d *WebhookDelivery) ToResponse() *WebhookDeliveryResponse {
+	response := &WebhookDeliveryResponse{
+		ID:              d.ID,
+		EventID:         d.EventID,
+		EventType:       d.EventType,
+		URL:             d.URL,
+		StatusCode:      d.StatusCode,
+		Success:         d.Success,
+		Error:           d.Error,
+		DurationMs:      d.DurationMs,
+		ResponseSnippet: d.ResponseSnippet,
+		ReplayOf:        d.ReplayOf,
+		CreatedAt:       d.CreatedAt.UTC(),
+	}
+	if json.Valid([]byte(d.Payload)) {
+		response.Payload = json.RawMessage(d.Payload)
+	}
+	return response
+}
@@ -0,0 +1,68 @@
+package events
+
+import (
+	"fmt"
+	"gojwt-rest-api/pkg/logger"
+	"sync"
+)
+
+// Bus is an in-process event bus.
+// Handlers run asynchronously so slow subscribers (webhooks, email)
+// never block the request that published the event.
+type Bus struct {
+	mu       sync.RWMutex
+	handlers map[string][]Handler
+	logger   *logger.Logger
+	wg       sync.WaitGroup
+}
+
+// NewBus creates a new event bus
+func NewBus(appLogger *logger.Logger) *Bus {
+	return &Bus{
+		handlers: make(map[string][]Handler),
+		logger:   appLogger,
+	}
+}
+
+// Subscribe registers a handler for an event type, or AllEvents
+func (b *Bus) Subscribe(eventType string, handler Handler) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.handlers[eventType] = append(b.handlers[eventType], handler)
+}
+
+// Publish dispatches an event to its subscribers
+func (b *Bus) Publish(eventType string, data interface{}) {
+	event := NewEvent(eventType, data)
+
+	b.mu.RLock()
+	handlers := make([]Handler, 0, len(b.handlers[eventType])+len(b.handlers[AllEvents]))
+	handlers = append(handlers, b.handlers[eventType]...)
+	handlers = append(handlers, b.handlers[AllEvents]...)
+	b.mu.RUnlock()
+
+	for _, handler := range handlers {
+		b.wg.Add(1)
+		go b.dispatch(handler, event)
+	}
+}
+
+// dispatch runs a single handler, recovering from panics
+func (b *Bus) dispatch(handler Handler, event Event) {
+	defer b.wg.Done()
+	defer func() {
+		if r := recover(); r != nil {
+			b.logger.Errorf("Event handler for %s panicked: %v", event.Type, r)
+		}
+	}()
+
+	if err := handler(event); err != nil {
+		b.logger.Error(fmt.Sprintf("Event handler for %s (%s) failed:", event.Type, event.ID), err)
+	}
+}
+
+// Wait blocks until all dispatched handlers have finished
+func (b *Bus) Wait() {
+	b.wg.Wait()
+}
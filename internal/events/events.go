@@ -0,0 +1,231 @@
+package events
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"time"
+)
+
+// Event types
+const (
+	UserFirstLogin                   = "user.first_login"
+	APIKeyRotationDue                = "api_key.rotation_due"
+	OrganizationInvitationCreated    = "organization.invitation_created"
+	OrganizationMemberRemoved        = "organization.member_removed"
+	OrganizationOwnershipTransferred = "organization.ownership_transferred"
+	UserDeleted                      = "user.deleted"
+	UserInactivityWarning            = "user.inactivity_warning"
+	UserSuspendedInactive            = "user.suspended_inactive"
+	UserAnonymized                   = "user.anonymized"
+	UserEmailChanged                 = "user.email_changed"
+	UserEmailChangeReverted          = "user.email_change_reverted"
+	UserLocked                       = "user.locked"
+	UserUnlockRequested              = "user.unlock_requested"
+	UserUnlocked                     = "user.unlocked"
+	UserPasswordResetRequested       = "user.password_reset_requested"
+	UserPasswordReset                = "user.password_reset"
+	OrganizationSessionsRevoked      = "organization.sessions_revoked"
+	UserRegistrationAttempted        = "user.registration_attempted"
+	UserConcurrentLogin              = "user.concurrent_login"
+	AbuseReportSubmitted             = "abuse_report.submitted"
+)
+
+// AllEvents subscribes a handler to every event type
+const AllEvents = "*"
+
+// Event represents something that happened in the system
+type Event struct {
+	ID      string      `json:"id"`
+	Type    string      `json:"type"`
+	Created time.Time   `json:"created"`
+	Data    interface{} `json:"data"`
+}
+
+// UserFirstLoginData is the payload of UserFirstLogin events
+type UserFirstLoginData struct {
+	UserID       uint      `json:"user_id"`
+	Email        string    `json:"email"`
+	Name         string    `json:"name"`
+	FirstLoginAt time.Time `json:"first_login_at"`
+}
+
+// APIKeyRotationDueData is the payload of APIKeyRotationDue events
+type APIKeyRotationDueData struct {
+	APIKeyID  uint       `json:"api_key_id"`
+	Name      string     `json:"name"`
+	Prefix    string     `json:"prefix"`
+	UserID    uint       `json:"user_id"`
+	Email     string     `json:"email"`
+	CreatedAt time.Time  `json:"created_at"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"` // Set when the key is scheduled to auto-expire
+}
+
+// OrganizationInvitationData is the payload of OrganizationInvitationCreated events
+type OrganizationInvitationData struct {
+	InvitationID     uint      `json:"invitation_id"`
+	OrganizationID   uint      `json:"organization_id"`
+	OrganizationName string    `json:"organization_name"`
+	Email            string    `json:"email"`
+	Role             string    `json:"role"`
+	InvitedByID      uint      `json:"invited_by_id"`
+	ExpiresAt        time.Time `json:"expires_at"`
+	// Token is only delivered to the invitee, never to webhook subscribers
+	Token string `json:"-"`
+}
+
+// OrganizationMembershipData is the payload of OrganizationMemberRemoved and
+// OrganizationOwnershipTransferred events. For ownership transfers the user is the new owner.
+type OrganizationMembershipData struct {
+	OrganizationID   uint   `json:"organization_id"`
+	OrganizationName string `json:"organization_name"`
+	UserID           uint   `json:"user_id"`
+	Email            string `json:"email"`
+	ActorID          uint   `json:"actor_id"`
+}
+
+// OrganizationSessionsRevokedData is the payload of OrganizationSessionsRevoked events
+type OrganizationSessionsRevokedData struct {
+	OrganizationID   uint      `json:"organization_id"`
+	OrganizationName string    `json:"organization_name"`
+	ActorID          uint      `json:"actor_id"`
+	Members          int64     `json:"members"`
+	RevokedAt        time.Time `json:"revoked_at"`
+}
+
+// UserDeletedData is the payload of UserDeleted events
+type UserDeletedData struct {
+	UserID    uint      `json:"user_id"`
+	Email     string    `json:"email"`
+	ActorID   uint      `json:"actor_id"`
+	DeletedAt time.Time `json:"deleted_at"`
+}
+
+// UserInactivityData is the payload of UserInactivityWarning,
+// UserSuspendedInactive and UserAnonymized events. Anonymized users have no email.
+type UserInactivityData struct {
+	UserID       uint      `json:"user_id"`
+	Email        string    `json:"email,omitempty"`
+	Name         string    `json:"name,omitempty"`
+	LastActiveAt time.Time `json:"last_active_at"`
+	// SuspendAt is when the account will be suspended, set for warnings when suspension is enabled
+	SuspendAt *time.Time `json:"suspend_at,omitempty"`
+}
+
+// UserEmailChangedData is the payload of UserEmailChanged events
+type UserEmailChangedData struct {
+	UserID        uint      `json:"user_id"`
+	Name          string    `json:"name"`
+	PreviousEmail string    `json:"previous_email"`
+	NewEmail      string    `json:"new_email"`
+	ActorID       uint      `json:"actor_id"`
+	ChangedAt     time.Time `json:"changed_at"`
+	// RevertExpiresAt is set when the change can be reverted from the previous address
+	RevertExpiresAt *time.Time `json:"revert_expires_at,omitempty"`
+	// RevertToken is only delivered to the previous address, never to webhook subscribers
+	RevertToken string `json:"-"`
+}
+
+// UserEmailChangeRevertedData is the payload of UserEmailChangeReverted events
+type UserEmailChangeRevertedData struct {
+	UserID     uint      `json:"user_id"`
+	Email      string    `json:"email"`
+	RevertedAt time.Time `json:"reverted_at"`
+}
+
+// UserLockedData is the payload of UserLocked and UserUnlockRequested events
+type UserLockedData struct {
+	UserID          uint      `json:"user_id"`
+	Name            string    `json:"name"`
+	Email           string    `json:"email"`
+	Reason          string    `json:"reason"`
+	LockedAt        time.Time `json:"locked_at"`
+	UnlockExpiresAt time.Time `json:"unlock_expires_at"`
+	// UnlockToken is only delivered to the user, never to webhook subscribers
+	UnlockToken string `json:"-"`
+}
+
+// UserUnlockedData is the payload of UserUnlocked events
+type UserUnlockedData struct {
+	UserID     uint      `json:"user_id"`
+	UnlockedAt time.Time `json:"unlocked_at"`
+}
+
+// UserPasswordResetRequestedData is the payload of UserPasswordResetRequested events
+type UserPasswordResetRequestedData struct {
+	UserID      uint      `json:"user_id"`
+	Name        string    `json:"name"`
+	Email       string    `json:"email"`
+	RequestedAt time.Time `json:"requested_at"`
+	ExpiresAt   time.Time `json:"expires_at"`
+	// ResetToken is only delivered to the user, never to webhook subscribers
+	ResetToken string `json:"-"`
+}
+
+// UserPasswordResetData is the payload of UserPasswordReset events
+type UserPasswordResetData struct {
+	UserID  uint      `json:"user_id"`
+	ResetAt time.Time `json:"reset_at"`
+}
+
+// UserRegistrationAttemptedData is the payload of UserRegistrationAttempted
+// events, published when someone registers with the email of an existing user
+type UserRegistrationAttemptedData struct {
+	UserID      uint      `json:"user_id"`
+	Name        string    `json:"name"`
+	Email       string    `json:"email"`
+	AttemptedAt time.Time `json:"attempted_at"`
+}
+
+// UserConcurrentLoginData is the payload of UserConcurrentLogin events,
+// published when a user logs in while holding other sessions
+type UserConcurrentLoginData struct {
+	UserID uint   `json:"user_id"`
+	Email  string `json:"email"`
+	// OtherSessions is the number of sessions the user held before logging in
+	OtherSessions int64 `json:"other_sessions"`
+	// OtherSessionsRevoked is set when the other sessions were logged out, by
+	// the preference of the user
+	OtherSessionsRevoked bool      `json:"other_sessions_revoked"`
+	LoginAt              time.Time `json:"login_at"`
+}
+
+// StreamUserID returns the user whose streams receive the event
+func (d *UserConcurrentLoginData) StreamUserID() uint {
+	return d.UserID
+}
+
+// AbuseReportSubmittedData is the payload of AbuseReportSubmitted events. The
+// message and contact of the reporter are only shown to admins in the API.
+type AbuseReportSubmittedData struct {
+	ReportID       uint      `json:"report_id"`
+	Category       string    `json:"category"`
+	ReportedUserID *uint     `json:"reported_user_id,omitempty"`
+	SubmittedAt    time.Time `json:"submitted_at"`
+}
+
+// Handler handles a published event
+type Handler func(event Event) error
+
+// Publisher publishes events to interested subscribers
+type Publisher interface {
+	Publish(eventType string, data interface{})
+}
+
+// NewEvent creates a new event with a unique ID
+func NewEvent(eventType string, data interface{}) Event {
+	return Event{
+		ID:      newEventID(),
+		Type:    eventType,
+		Created: time.Now().UTC(),
+		Data:    data,
+	}
+}
+
+// newEventID generates a random event ID
+func newEventID() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return time.Now().UTC().Format("20060102150405.000000000")
+	}
+	return "evt_" + hex.EncodeToString(b)
+}
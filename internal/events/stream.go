@@ -0,0 +1,90 @@
+package events
+
+import "sync"
+
+// streamBuffer is how many events a subscriber may lag behind before events
+// are dropped for it
+const streamBuffer = 8
+
+// UserScoped is implemented by the payloads of events concerning one user,
+// which a Stream delivers to that user's subscribers
+type UserScoped interface {
+	StreamUserID() uint
+}
+
+// Stream delivers events to the live connections of their user, such as
+// server-sent event streams. Subscribe it to the bus for the event types to
+// deliver; events whose payload is not UserScoped are ignored.
+type Stream struct {
+	mu          sync.Mutex
+	subscribers map[uint]map[chan Event]struct{}
+	closed      bool
+}
+
+// NewStream creates a stream without subscribers
+func NewStream() *Stream {
+	return &Stream{subscribers: make(map[uint]map[chan Event]struct{})}
+}
+
+// Subscribe returns a channel receiving the events of a user, until cancel is
+// called or the stream is closed, which closes the channel
+func (s *Stream) Subscribe(userID uint) (events <-chan Event, cancel func()) {
+	ch := make(chan Event, streamBuffer)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		close(ch)
+		return ch, func() {}
+	}
+	if s.subscribers[userID] == nil {
+		s.subscribers[userID] = make(map[chan Event]struct{})
+	}
+	s.subscribers[userID][ch] = struct{}{}
+
+	return ch, func() {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		if _, ok := s.subscribers[userID][ch]; !ok {
+			return
+		}
+		delete(s.subscribers[userID], ch)
+		if len(s.subscribers[userID]) == 0 {
+			delete(s.subscribers, userID)
+		}
+		close(ch)
+	}
+}
+
+// Handle delivers an event to the subscribers of its user, without waiting
+// for subscribers that fell behind
+func (s *Stream) Handle(event Event) error {
+	scoped, ok := event.Data.(UserScoped)
+	if !ok {
+		return nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for ch := range s.subscribers[scoped.StreamUserID()] {
+		select {
+		case ch <- event:
+		default:
+		}
+	}
+	return nil
+}
+
+// Close ends every subscription, e.g. on shutdown so that open connections
+// do not hold up the server
+func (s *Stream) Close() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.closed = true
+	for userID, subscribers := range s.subscribers {
+		for ch := range subscribers {
+			close(ch)
+		}
+		delete(s.subscribers, userID)
+	}
+}
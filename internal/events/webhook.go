@@ -0,0 +1,106 @@
+package events
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"gojwt-rest-api/pkg/webhooks"
+	"io"
+	"net/http"
+	"time"
+)
+
+// WebhookSubscriber delivers events to an HTTP endpoint as a webhooks.Envelope
+type WebhookSubscriber struct {
+	url    string
+	secret []byte
+	client *http.Client
+}
+
+// NewWebhookSubscriber creates a new webhook subscriber. Deliveries are
+// signed with secret unless it is empty.
+func NewWebhookSubscriber(url, secret string, timeout time.Duration) *WebhookSubscriber {
+	return &WebhookSubscriber{
+		url:    url,
+		secret: []byte(secret),
+		client: &http.Client{Timeout: timeout},
+	}
+}
+
+// responseSnippetSize is how much of the response body a delivery result keeps
+const responseSnippetSize = 512
+
+// DeliveryResult describes an attempt to deliver a payload
+type DeliveryResult struct {
+	// StatusCode is 0 when no response was received
+	StatusCode int
+	Duration   time.Duration
+	// Response is the beginning of the response body
+	Response string
+	Err      error
+}
+
+// URL returns the endpoint the subscriber delivers to
+func (w *WebhookSubscriber) URL() string {
+	return w.url
+}
+
+// Handle posts the event to the webhook URL
+func (w *WebhookSubscriber) Handle(event Event) error {
+	body, err := Envelope(event)
+	if err != nil {
+		return err
+	}
+	return w.Send(event.ID, body).Err
+}
+
+// Send posts an encoded envelope, signed with the current time, e.g. to
+// replay a previous delivery
+func (w *WebhookSubscriber) Send(eventID string, body []byte) *DeliveryResult {
+	result := &DeliveryResult{}
+
+	req, err := http.NewRequest(http.MethodPost, w.url, bytes.NewReader(body))
+	if err != nil {
+		result.Err = err
+		return result
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(webhooks.HeaderID, eventID)
+	if len(w.secret) > 0 {
+		req.Header.Set(webhooks.HeaderSignature, webhooks.Sign(w.secret, time.Now(), body))
+	}
+
+	start := time.Now()
+	resp, err := w.client.Do(req)
+	if err != nil {
+		result.Duration = time.Since(start)
+		result.Err = err
+		return result
+	}
+	defer resp.Body.Close()
+
+	snippet, _ := io.ReadAll(io.LimitReader(resp.Body, responseSnippetSize))
+	result.Duration = time.Since(start)
+	result.StatusCode = resp.StatusCode
+	result.Response = string(snippet)
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		result.Err = fmt.Errorf("webhook responded with status %d", resp.StatusCode)
+	}
+	return result
+}
+
+// Envelope encodes an event in the versioned webhook payload format
+func Envelope(event Event) ([]byte, error) {
+	data, err := json.Marshal(event.Data)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(webhooks.Envelope{
+		ID:            event.ID,
+		Type:          event.Type,
+		Created:       event.Created,
+		SchemaVersion: webhooks.SchemaVersion,
+		Data:          data,
+	})
+}
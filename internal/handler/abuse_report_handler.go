@@ -0,0 +1,151 @@
+package handler
+
+import (
+	"gojwt-rest-api/internal/domain"
+	"gojwt-rest-api/internal/middleware"
+	"gojwt-rest-api/internal/service"
+	"gojwt-rest-api/pkg/validator"
+	"math"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// AbuseReportHandler handles the submission and triage of abuse reports
+type AbuseReportHandler struct {
+	reportService service.AbuseReportService
+	validator     *validator.Validator
+}
+
+// NewAbuseReportHandler creates a new abuse report handler
+func NewAbuseReportHandler(reportService service.AbuseReportService, validator *validator.Validator) *AbuseReportHandler {
+	return &AbuseReportHandler{
+		reportService: reportService,
+		validator:     validator,
+	}
+}
+
+// Submit stores an abuse or security report. The reporter only gets a
+// receipt back, never whether the reported user exists.
+func (h *AbuseReportHandler) Submit(c *gin.Context) {
+	req, ok := Bind[domain.SubmitAbuseReportRequest](c, h.validator)
+	if !ok {
+		return
+	}
+
+	report, err := h.reportService.Submit(req, c.ClientIP())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, domain.ErrorResponse("failed to submit report", err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusCreated, domain.SuccessResponse("report submitted", &domain.AbuseReportReceipt{
+		ID:        report.ID,
+		CreatedAt: report.CreatedAt,
+	}))
+}
+
+// ListReports lists abuse reports, filtered by category and status
+func (h *AbuseReportHandler) ListReports(c *gin.Context) {
+	var pagination domain.PaginationQuery
+
+	page, err := strconv.Atoi(c.DefaultQuery("page", "1"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, domain.ErrorResponse("invalid page parameter", err.Error()))
+		return
+	}
+	pageSize, err := strconv.Atoi(c.DefaultQuery("page_size", "10"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, domain.ErrorResponse("invalid page_size parameter", err.Error()))
+		return
+	}
+	pagination.Page = page
+	pagination.PageSize = pageSize
+
+	filter := domain.AbuseReportFilter{Category: c.Query("category"), Status: c.Query("status")}
+	switch filter.Category {
+	case "", domain.AbuseReportCategoryAbuse, domain.AbuseReportCategorySecurity, domain.AbuseReportCategoryOther:
+	default:
+		c.JSON(http.StatusBadRequest, domain.ErrorResponse("invalid category parameter", "category must be abuse, security or other"))
+		return
+	}
+	switch filter.Status {
+	case "", domain.AbuseReportStatusOpen, domain.AbuseReportStatusInReview, domain.AbuseReportStatusResolved, domain.AbuseReportStatusDismissed:
+	default:
+		c.JSON(http.StatusBadRequest, domain.ErrorResponse("invalid status parameter", "status must be open, in_review, resolved or dismissed"))
+		return
+	}
+
+	reports, total, err := h.reportService.List(&filter, &pagination)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, domain.ErrorResponse("failed to retrieve reports", err.Error()))
+		return
+	}
+
+	responses := make([]*domain.AbuseReportResponse, len(reports))
+	for i, report := range reports {
+		responses[i] = report.ToResponse()
+	}
+
+	response := domain.PaginatedResponse{
+		Data:       responses,
+		Page:       pagination.Page,
+		PageSize:   pagination.PageSize,
+		TotalItems: total,
+		TotalPages: int(math.Ceil(float64(total) / float64(pagination.PageSize))),
+	}
+
+	c.JSON(http.StatusOK, domain.SuccessResponse("reports retrieved", response))
+}
+
+// GetReport gets an abuse report
+func (h *AbuseReportHandler) GetReport(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, domain.ErrorResponse("invalid report ID", err.Error()))
+		return
+	}
+
+	report, err := h.reportService.Get(uint(id))
+	if err != nil {
+		switch err {
+		case domain.ErrAbuseReportNotFound:
+			c.JSON(http.StatusNotFound, domain.ErrorResponse(err.Error(), nil))
+		default:
+			c.JSON(http.StatusInternalServerError, domain.ErrorResponse("failed to retrieve report", err.Error()))
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, domain.SuccessResponse("report retrieved", report.ToResponse()))
+}
+
+// TriageReport sets the status of an abuse report
+func (h *AbuseReportHandler) TriageReport(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, domain.ErrorResponse("invalid report ID", err.Error()))
+		return
+	}
+
+	req, ok := Bind[domain.TriageAbuseReportRequest](c, h.validator)
+	if !ok {
+		return
+	}
+
+	actorID, _ := middleware.GetUserID(c)
+
+	report, err := h.reportService.Triage(actorID, uint(id), req)
+	if err != nil {
+		switch err {
+		case domain.ErrAbuseReportNotFound:
+			c.JSON(http.StatusNotFound, domain.ErrorResponse(err.Error(), nil))
+		default:
+			c.JSON(http.StatusInternalServerError, domain.ErrorResponse("failed to triage report", err.Error()))
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, domain.SuccessResponse("report triaged", report.ToResponse()))
+}
@@ -0,0 +1,61 @@
+package handler
+
+import (
+	"gojwt-rest-api/internal/domain"
+	"gojwt-rest-api/internal/service"
+	"gojwt-rest-api/pkg/validator"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// AccountLockHandler handles the self-service unlock of accounts locked for
+// suspicious activity
+type AccountLockHandler struct {
+	accountLock service.AccountLockService
+	validator   *validator.Validator
+}
+
+// NewAccountLockHandler creates a new account lock handler
+func NewAccountLockHandler(accountLock service.AccountLockService, validator *validator.Validator) *AccountLockHandler {
+	return &AccountLockHandler{
+		accountLock: accountLock,
+		validator:   validator,
+	}
+}
+
+// RequestUnlock emails a new unlock link to a locked account. The response
+// is the same whether or not the account exists or is locked.
+func (h *AccountLockHandler) RequestUnlock(c *gin.Context) {
+	req, ok := Bind[domain.RequestAccountUnlockRequest](c, h.validator)
+	if !ok {
+		return
+	}
+
+	if err := h.accountLock.RequestUnlock(req.Email); err != nil {
+		c.JSON(http.StatusInternalServerError, domain.ErrorResponse("failed to request unlock", err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, domain.SuccessResponse("if the account is locked, an unlock link has been emailed", nil))
+}
+
+// Unlock unlocks an account with the emailed token and a new password
+func (h *AccountLockHandler) Unlock(c *gin.Context) {
+	req, ok := Bind[domain.UnlockAccountRequest](c, h.validator)
+	if !ok {
+		return
+	}
+
+	if _, err := h.accountLock.Unlock(req); err != nil {
+		switch err {
+		case domain.ErrOneTimeTokenInvalid, domain.ErrPasswordPolicyViolation, domain.ErrPasswordUnchanged:
+			c.JSON(http.StatusBadRequest, domain.ErrorResponse(err.Error(), nil))
+		default:
+			c.JSON(http.StatusInternalServerError, domain.ErrorResponse("failed to unlock account", err.Error()))
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, domain.SuccessResponse("account unlocked, log in with your new password", nil))
+}
@@ -0,0 +1,160 @@
+package handler
+
+import (
+	"gojwt-rest-api/internal/domain"
+	"gojwt-rest-api/internal/middleware"
+	"gojwt-rest-api/internal/service"
+	"gojwt-rest-api/pkg/validator"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// APIKeyHandler handles API key management requests
+type APIKeyHandler struct {
+	apiKeyService service.APIKeyService
+	validator     *validator.Validator
+}
+
+// NewAPIKeyHandler creates a new API key handler
+func NewAPIKeyHandler(apiKeyService service.APIKeyService, validator *validator.Validator) *APIKeyHandler {
+	return &APIKeyHandler{
+		apiKeyService: apiKeyService,
+		validator:     validator,
+	}
+}
+
+// CreateAPIKey issues a new API key for the authenticated user
+func (h *APIKeyHandler) CreateAPIKey(c *gin.Context) {
+	userID, exists := middleware.GetUserID(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, domain.ErrorResponse("user not authenticated", nil))
+		return
+	}
+
+	req, ok := Bind[domain.CreateAPIKeyRequest](c, h.validator)
+	if !ok {
+		return
+	}
+
+	key, plaintext, err := h.apiKeyService.CreateAPIKey(userID, req)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, domain.ErrorResponse("failed to create api key", err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusCreated, domain.SuccessResponse("api key created, store it now as it will not be shown again", &domain.CreateAPIKeyResponse{
+		Key:    plaintext,
+		APIKey: key.ToResponse(h.apiKeyService.RotationMaxAge()),
+	}))
+}
+
+// ListOwnAPIKeys lists the authenticated user's API keys
+func (h *APIKeyHandler) ListOwnAPIKeys(c *gin.Context) {
+	userID, exists := middleware.GetUserID(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, domain.ErrorResponse("user not authenticated", nil))
+		return
+	}
+
+	keys, err := h.apiKeyService.ListUserAPIKeys(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, domain.ErrorResponse("failed to retrieve api keys", err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, domain.SuccessResponse("api keys retrieved", h.toResponses(keys, "")))
+}
+
+// RevokeAPIKey revokes one of the authenticated user's API keys
+func (h *APIKeyHandler) RevokeAPIKey(c *gin.Context) {
+	userID, exists := middleware.GetUserID(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, domain.ErrorResponse("user not authenticated", nil))
+		return
+	}
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, domain.ErrorResponse("invalid api key ID", err.Error()))
+		return
+	}
+
+	if err := h.apiKeyService.RevokeAPIKey(userID, uint(id)); err != nil {
+		switch err {
+		case domain.ErrAPIKeyNotFound:
+			c.JSON(http.StatusNotFound, domain.ErrorResponse(domain.ErrAPIKeyNotFound.Error(), err.Error()))
+		default:
+			c.JSON(http.StatusInternalServerError, domain.ErrorResponse("failed to revoke api key", err.Error()))
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, domain.SuccessResponse("api key revoked", nil))
+}
+
+// ListAPIKeys lists all API keys with their rotation status (admin only).
+// The optional status query parameter filters by rotation status, e.g. ?status=rotation_due
+func (h *APIKeyHandler) ListAPIKeys(c *gin.Context) {
+	keys, err := h.apiKeyService.ListAPIKeys()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, domain.ErrorResponse("failed to retrieve api keys", err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, domain.SuccessResponse("api keys retrieved", h.toResponses(keys, c.Query("status"))))
+}
+
+// GetUsage returns an API key's request count, last-used time and top endpoints (admin only)
+func (h *APIKeyHandler) GetUsage(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, domain.ErrorResponse("invalid api key ID", err.Error()))
+		return
+	}
+
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "10"))
+	if err != nil || limit < 1 {
+		c.JSON(http.StatusBadRequest, domain.ErrorResponse("invalid limit parameter", nil))
+		return
+	}
+
+	usage, err := h.apiKeyService.GetUsage(uint(id), limit)
+	if err != nil {
+		switch err {
+		case domain.ErrAPIKeyNotFound:
+			c.JSON(http.StatusNotFound, domain.ErrorResponse(domain.ErrAPIKeyNotFound.Error(), err.Error()))
+		default:
+			c.JSON(http.StatusInternalServerError, domain.ErrorResponse("failed to retrieve api key usage", err.Error()))
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, domain.SuccessResponse("api key usage retrieved", usage))
+}
+
+// CheckRotation runs the API key rotation check immediately (admin only)
+func (h *APIKeyHandler) CheckRotation(c *gin.Context) {
+	report, err := h.apiKeyService.CheckRotation()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, domain.ErrorResponse("failed to check api key rotation", err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, domain.SuccessResponse("api key rotation checked", report))
+}
+
+// toResponses converts keys to responses, keeping only those with the given status if set
+func (h *APIKeyHandler) toResponses(keys []*domain.APIKey, status string) []*domain.APIKeyResponse {
+	maxAge := h.apiKeyService.RotationMaxAge()
+	responses := make([]*domain.APIKeyResponse, 0, len(keys))
+	for _, key := range keys {
+		response := key.ToResponse(maxAge)
+		if status != "" && response.Status != status {
+			continue
+		}
+		responses = append(responses, response)
+	}
+	return responses
+}
@@ -0,0 +1,78 @@
+package handler
+
+import (
+	"gojwt-rest-api/internal/domain"
+	"gojwt-rest-api/internal/service"
+	"math"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// AuditHandler handles audit log requests
+type AuditHandler struct {
+	auditService service.AuditService
+}
+
+// NewAuditHandler creates a new audit handler
+func NewAuditHandler(auditService service.AuditService) *AuditHandler {
+	return &AuditHandler{auditService: auditService}
+}
+
+// ListAuditLogs lists audit logs, filtered by action, user_id and organization_id
+func (h *AuditHandler) ListAuditLogs(c *gin.Context) {
+	var pagination domain.PaginationQuery
+
+	page, err := strconv.Atoi(c.DefaultQuery("page", "1"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, domain.ErrorResponse("invalid page parameter", err.Error()))
+		return
+	}
+	pageSize, err := strconv.Atoi(c.DefaultQuery("page_size", "10"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, domain.ErrorResponse("invalid page_size parameter", err.Error()))
+		return
+	}
+	pagination.Page = page
+	pagination.PageSize = pageSize
+
+	filter := domain.AuditLogFilter{Action: c.Query("action")}
+	if userID := c.Query("user_id"); userID != "" {
+		id, err := strconv.ParseUint(userID, 10, 32)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, domain.ErrorResponse("invalid user_id parameter", err.Error()))
+			return
+		}
+		filter.UserID = uint(id)
+	}
+	if orgID := c.Query("organization_id"); orgID != "" {
+		id, err := strconv.ParseUint(orgID, 10, 32)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, domain.ErrorResponse("invalid organization_id parameter", err.Error()))
+			return
+		}
+		filter.OrganizationID = uint(id)
+	}
+
+	logs, total, err := h.auditService.WithContext(c.Request.Context()).List(&filter, &pagination)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, domain.ErrorResponse("failed to retrieve audit logs", err.Error()))
+		return
+	}
+
+	responses := make([]*domain.AuditLogResponse, len(logs))
+	for i, log := range logs {
+		responses[i] = log.ToResponse()
+	}
+
+	response := domain.PaginatedResponse{
+		Data:       responses,
+		Page:       pagination.Page,
+		PageSize:   pagination.PageSize,
+		TotalItems: total,
+		TotalPages: int(math.Ceil(float64(total) / float64(pagination.PageSize))),
+	}
+
+	c.JSON(http.StatusOK, domain.SuccessResponse("audit logs retrieved", response))
+}
@@ -2,49 +2,76 @@ package handler
 
 import (
 	"gojwt-rest-api/internal/domain"
+	"gojwt-rest-api/internal/middleware"
 	"gojwt-rest-api/internal/service"
 	"gojwt-rest-api/pkg/validator"
 	"net/http"
+	"time"
 
 	"github.com/gin-gonic/gin"
 )
 
+// retryAfterSeconds is the Retry-After hint sent when password checks are saturated
+const retryAfterSeconds = "1"
+
 // AuthHandler handles authentication requests
 type AuthHandler struct {
 	userService service.UserService
 	validator   *validator.Validator
+	// concealRegistration answers registrations of registered emails like new ones
+	concealRegistration bool
+}
+
+// AuthHandlerOption configures optional auth handler behavior
+type AuthHandlerOption func(*AuthHandler)
+
+// WithConcealedRegistration answers every accepted registration with 202 and
+// no user data, whether or not the email was registered before, so that
+// registering cannot be used to find out which emails are registered
+func WithConcealedRegistration() AuthHandlerOption {
+	return func(h *AuthHandler) {
+		h.concealRegistration = true
+	}
 }
 
 // NewAuthHandler creates a new auth handler
-func NewAuthHandler(userService service.UserService, validator *validator.Validator) *AuthHandler {
-	return &AuthHandler{
+func NewAuthHandler(userService service.UserService, validator *validator.Validator, opts ...AuthHandlerOption) *AuthHandler {
+	h := &AuthHandler{
 		userService: userService,
 		validator:   validator,
 	}
+	for _, opt := range opts {
+		opt(h)
+	}
+	return h
 }
 
 // Register handles user registration
 func (h *AuthHandler) Register(c *gin.Context) {
-	var req domain.RegisterRequest
-
-	// Bind JSON
-	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, domain.ErrorResponse(domain.ErrInvalidRequest.Error(), err))
+	req, ok := Bind[domain.RegisterRequest](c, h.validator)
+	if !ok {
 		return
 	}
 
-	// Validate request
-	if validationErrors := h.validator.Validate(&req); len(validationErrors) > 0 {
-		c.JSON(http.StatusBadRequest, domain.ErrorResponse(domain.ErrValidationFailed.Error(), validationErrors))
+	// Register user
+	user, err := h.userService.Register(req)
+	if h.concealRegistration && (err == nil || err == domain.ErrUserAlreadyExists) {
+		// The owner of a registered email is notified instead
+		c.JSON(http.StatusAccepted, domain.SuccessResponse("registration received, log in to continue", nil))
 		return
 	}
-
-	// Register user
-	user, err := h.userService.Register(&req)
 	if err != nil {
 		switch err {
 		case domain.ErrUserAlreadyExists:
 			c.JSON(http.StatusConflict, domain.ErrorResponse(domain.ErrUserAlreadyExists.Error(), err))
+		case domain.ErrPasswordPolicyViolation:
+			c.JSON(http.StatusBadRequest, domain.ErrorResponse(domain.ErrPasswordPolicyViolation.Error(), nil))
+		case domain.ErrSelfRegistrationDisabled, domain.ErrInvitationEmailMismatch, domain.ErrUserQuotaExceeded:
+			c.JSON(http.StatusForbidden, domain.ErrorResponse(err.Error(), nil))
+		case domain.ErrInvitationNotFound:
+			c.JSON(http.StatusNotFound, domain.ErrorResponse(err.Error(), nil))
+		case domain.ErrInvitationExpired:
+			c.JSON(http.StatusGone, domain.ErrorResponse(err.Error(), nil))
 		default:
 			c.JSON(http.StatusInternalServerError, domain.ErrorResponse(domain.ErrRegistrationFailed.Error(), err.Error()))
 		}
@@ -54,55 +81,71 @@ func (h *AuthHandler) Register(c *gin.Context) {
 	c.JSON(http.StatusCreated, domain.SuccessResponse("user registered successfully", user.ToResponse()))
 }
 
-// Login handles user login
-func (h *AuthHandler) Login(c *gin.Context) {
-	var req domain.LoginRequest
+// CheckEmail tells whether an email can be used to register, for signup
+// forms. The route is rate limited and requires a CAPTCHA.
+func (h *AuthHandler) CheckEmail(c *gin.Context) {
+	req, ok := Bind[domain.CheckEmailRequest](c, h.validator)
+	if !ok {
+		return
+	}
 
-	// Bind JSON
-	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, domain.ErrorResponse(domain.ErrInvalidRequest.Error(), err))
+	available, err := h.userService.EmailAvailable(req.Email)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, domain.ErrorResponse("failed to check email", err.Error()))
 		return
 	}
 
-	// Validate request
-	if validationErrors := h.validator.Validate(&req); len(validationErrors) > 0 {
-		c.JSON(http.StatusBadRequest, domain.ErrorResponse(domain.ErrValidationFailed.Error(), validationErrors))
+	c.JSON(http.StatusOK, domain.SuccessResponse("email checked", &domain.CheckEmailResponse{Available: available}))
+}
+
+// Login handles user login
+func (h *AuthHandler) Login(c *gin.Context) {
+	req, ok := Bind[domain.LoginRequest](c, h.validator)
+	if !ok {
 		return
 	}
 
 	// Login user
-	response, err := h.userService.Login(&req)
+	req.Country = middleware.GetClientCountry(c)
+	response, err := h.userService.Login(req)
 	if err != nil {
 		switch err {
 		case domain.ErrInvalidCredentials:
 			c.JSON(http.StatusUnauthorized, domain.ErrorResponse(domain.ErrInvalidCredentials.Error(), err))
+		case domain.ErrPasswordCheckBusy:
+			middleware.MarkServerFailure(c)
+			c.Header("Retry-After", retryAfterSeconds)
+			c.JSON(http.StatusTooManyRequests, domain.ErrorResponse(domain.ErrPasswordCheckBusy.Error(), nil))
+		case domain.ErrUserDeactivated, domain.ErrAccountLocked:
+			c.JSON(http.StatusForbidden, domain.ErrorResponse(err.Error(), nil))
+		case domain.ErrOutsideAccessSchedule:
+			c.JSON(http.StatusForbidden, domain.ErrorResponse(err.Error(), gin.H{"code": domain.ErrorCodeOutsideAccessSchedule}))
+		case domain.ErrSessionQuotaExceeded:
+			c.JSON(http.StatusTooManyRequests, domain.ErrorResponse(domain.ErrSessionQuotaExceeded.Error(), nil))
+		case domain.ErrUnknownClient, domain.ErrClientIDRequired:
+			c.JSON(http.StatusBadRequest, domain.ErrorResponse(err.Error(), nil))
 		default:
 			c.JSON(http.StatusInternalServerError, domain.ErrorResponse(domain.ErrLoginFailed.Error(), err.Error()))
 		}
 		return
 	}
 
+	if response.Status != "" {
+		c.JSON(http.StatusOK, domain.SuccessResponse("two-factor authentication required", response))
+		return
+	}
 	c.JSON(http.StatusOK, domain.SuccessResponse("login successful", response))
 }
 
 // RefreshToken handles token refresh
 func (h *AuthHandler) RefreshToken(c *gin.Context) {
-	var req domain.RefreshTokenRequest
-
-	// Bind JSON
-	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, domain.ErrorResponse(domain.ErrInvalidRequest.Error(), err))
-		return
-	}
-
-	// Validate request
-	if validationErrors := h.validator.Validate(&req); len(validationErrors) > 0 {
-		c.JSON(http.StatusBadRequest, domain.ErrorResponse(domain.ErrValidationFailed.Error(), validationErrors))
+	req, ok := Bind[domain.RefreshTokenRequest](c, h.validator)
+	if !ok {
 		return
 	}
 
 	// Refresh token
-	response, err := h.userService.RefreshToken(&req)
+	response, err := h.userService.RefreshToken(req)
 	if err != nil {
 		switch err {
 		case domain.ErrInvalidRefreshToken:
@@ -111,6 +154,10 @@ func (h *AuthHandler) RefreshToken(c *gin.Context) {
 			c.JSON(http.StatusUnauthorized, domain.ErrorResponse(domain.ErrTokenExpired.Error(), err))
 		case domain.ErrTokenReused:
 			c.JSON(http.StatusUnauthorized, domain.ErrorResponse(domain.ErrTokenReused.Error(), err))
+		case domain.ErrUserDeactivated, domain.ErrAccountLocked:
+			c.JSON(http.StatusForbidden, domain.ErrorResponse(err.Error(), nil))
+		case domain.ErrOutsideAccessSchedule:
+			c.JSON(http.StatusForbidden, domain.ErrorResponse(err.Error(), gin.H{"code": domain.ErrorCodeOutsideAccessSchedule}))
 		default:
 			c.JSON(http.StatusInternalServerError, domain.ErrorResponse("failed to refresh token", err.Error()))
 		}
@@ -146,3 +193,120 @@ func (h *AuthHandler) Logout(c *gin.Context) {
 	c.JSON(http.StatusOK, domain.SuccessResponse("logout successful", nil))
 }
 
+// Reauthenticate confirms the identity of the logged-in user and returns a
+// short-lived access token accepted by the routes requiring a recent authentication
+func (h *AuthHandler) Reauthenticate(c *gin.Context) {
+	userID, exists := middleware.GetUserID(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, domain.ErrorResponse("user not authenticated", nil))
+		return
+	}
+
+	req, ok := Bind[domain.ReauthenticateRequest](c, h.validator)
+	if !ok {
+		return
+	}
+	// The new token belongs to the session of the presenting token
+	var clientID string
+	if claims, ok := middleware.GetClaims(c); ok {
+		clientID = claims.ClientID
+	}
+
+	response, err := h.userService.Reauthenticate(userID, clientID, req)
+	if err != nil {
+		switch err {
+		case domain.ErrInvalidCredentials, domain.ErrInvalidTwoFactorCode:
+			c.JSON(http.StatusUnauthorized, domain.ErrorResponse(err.Error(), nil))
+		case domain.ErrTwoFactorNotEnrolled:
+			c.JSON(http.StatusBadRequest, domain.ErrorResponse(domain.ErrTwoFactorNotEnrolled.Error(), nil))
+		case domain.ErrTooManyTwoFactorAttempts:
+			c.JSON(http.StatusTooManyRequests, domain.ErrorResponse(err.Error(), nil))
+		case domain.ErrPasswordCheckBusy:
+			c.Header("Retry-After", retryAfterSeconds)
+			c.JSON(http.StatusTooManyRequests, domain.ErrorResponse(domain.ErrPasswordCheckBusy.Error(), nil))
+		case domain.ErrUserDeactivated, domain.ErrAccountLocked:
+			c.JSON(http.StatusForbidden, domain.ErrorResponse(err.Error(), nil))
+		case domain.ErrUserNotFound:
+			c.JSON(http.StatusNotFound, domain.ErrorResponse(domain.ErrUserNotFound.Error(), nil))
+		default:
+			c.JSON(http.StatusInternalServerError, domain.ErrorResponse("failed to reauthenticate", err.Error()))
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, domain.SuccessResponse("reauthentication successful", response))
+}
+
+// RevertEmailChange restores the previous email address of an account with the
+// token emailed to that address, ends the account's sessions and returns a
+// password reset token to replace the password
+func (h *AuthHandler) RevertEmailChange(c *gin.Context) {
+	req, ok := Bind[domain.RevertEmailChangeRequest](c, h.validator)
+	if !ok {
+		return
+	}
+
+	_, resetToken, err := h.userService.RevertEmailChange(req.Token)
+	if err != nil {
+		switch err {
+		case domain.ErrOneTimeTokenInvalid, domain.ErrUserNotFound:
+			c.JSON(http.StatusBadRequest, domain.ErrorResponse(domain.ErrOneTimeTokenInvalid.Error(), nil))
+		case domain.ErrEmailAlreadyInUse:
+			c.JSON(http.StatusConflict, domain.ErrorResponse(domain.ErrEmailAlreadyInUse.Error(), nil))
+		default:
+			c.JSON(http.StatusInternalServerError, domain.ErrorResponse("failed to revert email change", err.Error()))
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, domain.SuccessResponse("email change reverted, all sessions have been signed out, set a new password with the reset token",
+		&domain.RevertEmailChangeResponse{
+			PasswordResetToken: resetToken,
+			ExpiresIn:          int64(service.RevertPasswordResetTTL.Seconds()),
+		}))
+}
+
+// Me returns the decoded claims of the presented token with the resolved user
+func (h *AuthHandler) Me(c *gin.Context) {
+	claims, exists := middleware.GetClaims(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, domain.ErrorResponse("user not authenticated", nil))
+		return
+	}
+
+	user, err := h.userService.GetUserByID(claims.UserID)
+	if err != nil {
+		switch err {
+		case domain.ErrUserNotFound:
+			c.JSON(http.StatusNotFound, domain.ErrorResponse(domain.ErrUserNotFound.Error(), err.Error()))
+		default:
+			c.JSON(http.StatusInternalServerError, domain.ErrorResponse("failed to retrieve user", err.Error()))
+		}
+		return
+	}
+
+	tokenClaims := &domain.TokenClaimsResponse{
+		UserID:         claims.UserID,
+		Email:          claims.Email,
+		OrganizationID: claims.OrganizationID,
+		Plan:           claims.Plan,
+		Entitlements:   claims.Entitlements,
+	}
+	if claims.IssuedAt != nil {
+		tokenClaims.IssuedAt = claims.IssuedAt.Time
+	}
+	if claims.ExpiresAt != nil {
+		tokenClaims.ExpiresAt = claims.ExpiresAt.Time
+		tokenClaims.ExpiresIn = int64(time.Until(claims.ExpiresAt.Time).Seconds())
+	}
+	if claims.AuthTime != nil {
+		tokenClaims.AuthTime = &claims.AuthTime.Time
+	}
+
+	c.JSON(http.StatusOK, domain.SuccessResponse("token claims retrieved", &domain.MeResponse{
+		Claims: tokenClaims,
+		User:   user.ToProfileResponse(),
+		Roles:  user.Roles(),
+		Scopes: user.Scopes(),
+	}))
+}
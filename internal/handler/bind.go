@@ -0,0 +1,27 @@
+package handler
+
+import (
+	"gojwt-rest-api/internal/domain"
+	"gojwt-rest-api/pkg/validator"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Bind decodes the JSON body of a request into a T and validates it. On
+// failure it responds with a 400 and returns false: domain.ErrInvalidRequest
+// with the decoding error for malformed bodies, domain.ErrValidationFailed
+// with the field errors for invalid ones.
+func Bind[T any](c *gin.Context, v *validator.Validator) (*T, bool) {
+	var req T
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, domain.ErrorResponse(domain.ErrInvalidRequest.Error(), err.Error()))
+		return nil, false
+	}
+
+	if validationErrors := v.Validate(&req); len(validationErrors) > 0 {
+		c.JSON(http.StatusBadRequest, domain.ErrorResponse(domain.ErrValidationFailed.Error(), validationErrors))
+		return nil, false
+	}
+	return &req, true
+}
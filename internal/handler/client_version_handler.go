@@ -0,0 +1,82 @@
+package handler
+
+import (
+	"gojwt-rest-api/internal/domain"
+	"gojwt-rest-api/internal/middleware"
+	"gojwt-rest-api/internal/service"
+	"gojwt-rest-api/pkg/validator"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ClientVersionHandler handles the minimum app versions of client applications
+type ClientVersionHandler struct {
+	clientVersions service.ClientVersionService
+	validator      *validator.Validator
+}
+
+// NewClientVersionHandler creates a new client version handler
+func NewClientVersionHandler(clientVersions service.ClientVersionService, validator *validator.Validator) *ClientVersionHandler {
+	return &ClientVersionHandler{
+		clientVersions: clientVersions,
+		validator:      validator,
+	}
+}
+
+// ListMinVersions returns the client applications having a minimum version
+func (h *ClientVersionHandler) ListMinVersions(c *gin.Context) {
+	policies, err := h.clientVersions.List()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, domain.ErrorResponse("failed to retrieve client versions", err.Error()))
+		return
+	}
+
+	responses := make([]*domain.ClientVersionPolicyResponse, len(policies))
+	for i, policy := range policies {
+		responses[i] = policy.ToResponse()
+	}
+	c.JSON(http.StatusOK, domain.SuccessResponse("client versions retrieved", responses))
+}
+
+// SetMinVersion refuses requests from older app versions of a client application
+func (h *ClientVersionHandler) SetMinVersion(c *gin.Context) {
+	req, ok := Bind[domain.SetClientMinVersionRequest](c, h.validator)
+	if !ok {
+		return
+	}
+
+	actorID, _ := middleware.GetUserID(c)
+
+	policy, err := h.clientVersions.SetMinVersion(actorID, c.Param("client_id"), req.MinVersion)
+	if err != nil {
+		switch err {
+		case domain.ErrInvalidClientVersion, domain.ErrInvalidClientApplication:
+			c.JSON(http.StatusBadRequest, domain.ErrorResponse(err.Error(), nil))
+		case domain.ErrUnknownClient:
+			c.JSON(http.StatusNotFound, domain.ErrorResponse(err.Error(), nil))
+		default:
+			c.JSON(http.StatusInternalServerError, domain.ErrorResponse("failed to set client minimum version", err.Error()))
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, domain.SuccessResponse("client minimum version set", policy.ToResponse()))
+}
+
+// RemoveMinVersion accepts any app version of a client application again
+func (h *ClientVersionHandler) RemoveMinVersion(c *gin.Context) {
+	actorID, _ := middleware.GetUserID(c)
+
+	if err := h.clientVersions.RemoveMinVersion(actorID, c.Param("client_id")); err != nil {
+		switch err {
+		case domain.ErrClientMinVersionNotSet:
+			c.JSON(http.StatusNotFound, domain.ErrorResponse(err.Error(), nil))
+		default:
+			c.JSON(http.StatusInternalServerError, domain.ErrorResponse("failed to remove client minimum version", err.Error()))
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, domain.SuccessResponse("client minimum version removed", nil))
+}
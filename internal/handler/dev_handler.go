@@ -0,0 +1,35 @@
+package handler
+
+import (
+	"gojwt-rest-api/internal/domain"
+	"gojwt-rest-api/internal/utils"
+	"gojwt-rest-api/pkg/validator"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// DevHandler handles development-only debugging endpoints.
+// It must never be registered in production.
+type DevHandler struct {
+	jwtSecret string
+	validator *validator.Validator
+}
+
+// NewDevHandler creates a new dev handler
+func NewDevHandler(jwtSecret string, validator *validator.Validator) *DevHandler {
+	return &DevHandler{
+		jwtSecret: jwtSecret,
+		validator: validator,
+	}
+}
+
+// InspectToken decodes an arbitrary JWT and explains why validation fails
+func (h *DevHandler) InspectToken(c *gin.Context) {
+	req, ok := Bind[domain.InspectTokenRequest](c, h.validator)
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusOK, domain.SuccessResponse("token inspected", utils.InspectToken(req.Token, h.jwtSecret)))
+}
@@ -0,0 +1,75 @@
+package handler
+
+import (
+	"gojwt-rest-api/internal/domain"
+	"gojwt-rest-api/internal/lifecycle"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// HealthHandler handles health, readiness and drain requests
+type HealthHandler struct {
+	drainer      *lifecycle.Drainer
+	dependencies *lifecycle.HealthRegistry
+}
+
+// NewHealthHandler creates a new health handler, whose readiness check also
+// checks the dependencies registered with dependencies
+func NewHealthHandler(drainer *lifecycle.Drainer, dependencies *lifecycle.HealthRegistry) *HealthHandler {
+	return &HealthHandler{
+		drainer:      drainer,
+		dependencies: dependencies,
+	}
+}
+
+// Health reports that the process is alive
+func (h *HealthHandler) Health(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{
+		"status": "ok",
+		"time":   time.Now(),
+	})
+}
+
+// Ready reports whether the server accepts new traffic.
+// It fails once draining has started so load balancers stop routing to this
+// instance, and while a critical dependency is down.
+func (h *HealthHandler) Ready(c *gin.Context) {
+	if !h.drainer.IsReady() {
+		c.JSON(http.StatusServiceUnavailable, gin.H{
+			"status":    "draining",
+			"in_flight": h.drainer.InFlight(),
+		})
+		return
+	}
+
+	report := h.dependencies.Check(c.Request.Context())
+	if !report.Healthy {
+		c.JSON(http.StatusServiceUnavailable, gin.H{
+			"status": "unavailable",
+			"checks": report.Checks,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status": "ready",
+		"checks": report.Checks,
+	})
+}
+
+// Dependencies reports the health of every registered dependency, failing
+// or not, for internal monitoring
+func (h *HealthHandler) Dependencies(c *gin.Context) {
+	c.JSON(http.StatusOK, domain.SuccessResponse("dependency health retrieved", h.dependencies.Check(c.Request.Context())))
+}
+
+// Drain starts a graceful drain followed by shutdown
+func (h *HealthHandler) Drain(c *gin.Context) {
+	h.drainer.Start()
+
+	c.JSON(http.StatusAccepted, domain.SuccessResponse("drain started", gin.H{
+		"in_flight": h.drainer.InFlight(),
+	}))
+}
@@ -0,0 +1,66 @@
+package handler
+
+import (
+	"gojwt-rest-api/internal/domain"
+	"gojwt-rest-api/internal/middleware"
+	"gojwt-rest-api/internal/service"
+	"gojwt-rest-api/pkg/validator"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// InactivityHandler handles user inactivity policy requests
+type InactivityHandler struct {
+	inactivityService service.InactivityService
+	validator         *validator.Validator
+}
+
+// NewInactivityHandler creates a new inactivity handler
+func NewInactivityHandler(inactivityService service.InactivityService, validator *validator.Validator) *InactivityHandler {
+	return &InactivityHandler{
+		inactivityService: inactivityService,
+		validator:         validator,
+	}
+}
+
+// UpdateExempt opts a user out of (or back into) the inactivity policy
+func (h *InactivityHandler) UpdateExempt(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, domain.ErrorResponse("invalid user ID", err.Error()))
+		return
+	}
+
+	req, ok := Bind[domain.UpdateInactivityExemptRequest](c, h.validator)
+	if !ok {
+		return
+	}
+
+	actorID, _ := middleware.GetUserID(c)
+
+	user, err := h.inactivityService.SetExempt(actorID, uint(id), *req.Exempt)
+	if err != nil {
+		switch err {
+		case domain.ErrUserNotFound:
+			c.JSON(http.StatusNotFound, domain.ErrorResponse(domain.ErrUserNotFound.Error(), nil))
+		default:
+			c.JSON(http.StatusInternalServerError, domain.ErrorResponse(domain.ErrFailedToUpdateUser.Error(), err.Error()))
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, domain.SuccessResponse("inactivity exemption updated", user.ToResponse()))
+}
+
+// RunPolicy applies the inactivity policy immediately (admin only)
+func (h *InactivityHandler) RunPolicy(c *gin.Context) {
+	report, err := h.inactivityService.Run(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, domain.ErrorResponse("failed to apply inactivity policy", err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, domain.SuccessResponse("inactivity policy applied", report))
+}
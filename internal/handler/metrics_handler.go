@@ -0,0 +1,33 @@
+package handler
+
+import (
+	"gojwt-rest-api/internal/domain"
+	"gojwt-rest-api/internal/metrics"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// MetricsHandler handles metrics summary requests
+type MetricsHandler struct {
+	httpMetrics *metrics.HTTPMetrics
+	sloTracker  *metrics.SLOTracker
+}
+
+// NewMetricsHandler creates a new metrics handler
+func NewMetricsHandler(httpMetrics *metrics.HTTPMetrics, sloTracker *metrics.SLOTracker) *MetricsHandler {
+	return &MetricsHandler{
+		httpMetrics: httpMetrics,
+		sloTracker:  sloTracker,
+	}
+}
+
+// RouteStats returns per-route request counts, error rate and p95 latency
+func (h *MetricsHandler) RouteStats(c *gin.Context) {
+	c.JSON(http.StatusOK, domain.SuccessResponse("route statistics retrieved", h.httpMetrics.RouteStats()))
+}
+
+// SLO summarizes current compliance and burn rates of the service level objectives
+func (h *MetricsHandler) SLO(c *gin.Context) {
+	c.JSON(http.StatusOK, domain.SuccessResponse("slo compliance retrieved", h.sloTracker.Stats()))
+}
@@ -0,0 +1,44 @@
+package handler
+
+import (
+	"gojwt-rest-api/internal/domain"
+	"gojwt-rest-api/internal/service"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// OnboardingHandler handles the onboarding status of the authenticated user
+type OnboardingHandler struct {
+	onboarding service.OnboardingTracker
+}
+
+// NewOnboardingHandler creates a new onboarding handler
+func NewOnboardingHandler(onboarding service.OnboardingTracker) *OnboardingHandler {
+	return &OnboardingHandler{onboarding: onboarding}
+}
+
+// GetOnboardingStatus summarizes the onboarding steps the user completed
+// @Summary Get onboarding status
+// @Description Get which onboarding steps the authenticated user completed, and the share of completed steps
+// @Tags profile
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} domain.Response
+// @Failure 401 {object} domain.Response
+// @Router /api/v1/profile/onboarding [get]
+func (h *OnboardingHandler) GetOnboardingStatus(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, domain.ErrorResponse("Unauthorized", nil))
+		return
+	}
+
+	status, err := h.onboarding.Status(userID.(uint))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, domain.ErrorResponse("Failed to get onboarding status", err))
+		return
+	}
+
+	c.JSON(http.StatusOK, domain.SuccessResponse("Onboarding status retrieved successfully", status))
+}
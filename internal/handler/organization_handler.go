@@ -0,0 +1,354 @@
+package handler
+
+import (
+	"gojwt-rest-api/internal/domain"
+	"gojwt-rest-api/internal/middleware"
+	"gojwt-rest-api/internal/service"
+	"gojwt-rest-api/pkg/validator"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// OrganizationHandler handles organization and plan administration requests
+type OrganizationHandler struct {
+	orgService   service.OrganizationService
+	quotaService service.QuotaService
+	validator    *validator.Validator
+}
+
+// NewOrganizationHandler creates a new organization handler
+func NewOrganizationHandler(orgService service.OrganizationService, quotaService service.QuotaService, validator *validator.Validator) *OrganizationHandler {
+	return &OrganizationHandler{
+		orgService:   orgService,
+		quotaService: quotaService,
+		validator:    validator,
+	}
+}
+
+// CreatePlan creates a new plan
+func (h *OrganizationHandler) CreatePlan(c *gin.Context) {
+	req, ok := Bind[domain.CreatePlanRequest](c, h.validator)
+	if !ok {
+		return
+	}
+
+	plan, err := h.orgService.CreatePlan(req)
+	if err != nil {
+		switch err {
+		case domain.ErrPlanAlreadyExists:
+			c.JSON(http.StatusConflict, domain.ErrorResponse(domain.ErrPlanAlreadyExists.Error(), err.Error()))
+		default:
+			c.JSON(http.StatusInternalServerError, domain.ErrorResponse("failed to create plan", err.Error()))
+		}
+		return
+	}
+
+	c.JSON(http.StatusCreated, domain.SuccessResponse("plan created", plan.ToResponse()))
+}
+
+// ListPlans lists all plans
+func (h *OrganizationHandler) ListPlans(c *gin.Context) {
+	plans, err := h.orgService.ListPlans()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, domain.ErrorResponse("failed to retrieve plans", err.Error()))
+		return
+	}
+
+	responses := make([]*domain.PlanResponse, len(plans))
+	for i, plan := range plans {
+		responses[i] = plan.ToResponse()
+	}
+
+	c.JSON(http.StatusOK, domain.SuccessResponse("plans retrieved", responses))
+}
+
+// CreateOrganization creates a new organization
+func (h *OrganizationHandler) CreateOrganization(c *gin.Context) {
+	req, ok := Bind[domain.CreateOrganizationRequest](c, h.validator)
+	if !ok {
+		return
+	}
+
+	org, err := h.orgService.CreateOrganization(req)
+	if err != nil {
+		switch err {
+		case domain.ErrOrganizationAlreadyExists:
+			c.JSON(http.StatusConflict, domain.ErrorResponse(domain.ErrOrganizationAlreadyExists.Error(), err.Error()))
+		case domain.ErrPlanNotFound:
+			c.JSON(http.StatusBadRequest, domain.ErrorResponse(domain.ErrPlanNotFound.Error(), err.Error()))
+		default:
+			c.JSON(http.StatusInternalServerError, domain.ErrorResponse("failed to create organization", err.Error()))
+		}
+		return
+	}
+
+	c.JSON(http.StatusCreated, domain.SuccessResponse("organization created", org.ToResponse()))
+}
+
+// ListOrganizations lists all organizations
+func (h *OrganizationHandler) ListOrganizations(c *gin.Context) {
+	orgs, err := h.orgService.ListOrganizations()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, domain.ErrorResponse("failed to retrieve organizations", err.Error()))
+		return
+	}
+
+	responses := make([]*domain.OrganizationResponse, len(orgs))
+	for i, org := range orgs {
+		responses[i] = org.ToResponse()
+	}
+
+	c.JSON(http.StatusOK, domain.SuccessResponse("organizations retrieved", responses))
+}
+
+// GetOrganization gets an organization by ID
+func (h *OrganizationHandler) GetOrganization(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, domain.ErrorResponse("invalid organization ID", err.Error()))
+		return
+	}
+
+	org, err := h.orgService.GetOrganization(uint(id))
+	if err != nil {
+		switch err {
+		case domain.ErrOrganizationNotFound:
+			c.JSON(http.StatusNotFound, domain.ErrorResponse(domain.ErrOrganizationNotFound.Error(), err.Error()))
+		default:
+			c.JSON(http.StatusInternalServerError, domain.ErrorResponse("failed to retrieve organization", err.Error()))
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, domain.SuccessResponse("organization retrieved", org.ToResponse()))
+}
+
+// AddMember adds a user to an organization
+func (h *OrganizationHandler) AddMember(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, domain.ErrorResponse("invalid organization ID", err.Error()))
+		return
+	}
+
+	req, ok := Bind[domain.AddOrganizationMemberRequest](c, h.validator)
+	if !ok {
+		return
+	}
+
+	if err := h.orgService.AddMember(uint(id), req.UserID, req.Role); err != nil {
+		switch err {
+		case domain.ErrOrganizationNotFound, domain.ErrUserNotFound:
+			c.JSON(http.StatusNotFound, domain.ErrorResponse(err.Error(), err.Error()))
+		case domain.ErrUserQuotaExceeded, domain.ErrEmailDomainNotAllowed:
+			c.JSON(http.StatusForbidden, domain.ErrorResponse(err.Error(), nil))
+		case domain.ErrCannotRemoveOwner:
+			c.JSON(http.StatusConflict, domain.ErrorResponse(domain.ErrCannotRemoveOwner.Error(), nil))
+		default:
+			c.JSON(http.StatusInternalServerError, domain.ErrorResponse("failed to add organization member", err.Error()))
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, domain.SuccessResponse("organization member added", nil))
+}
+
+// GetUsage reports an organization's consumption against its plan limits
+func (h *OrganizationHandler) GetUsage(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, domain.ErrorResponse("invalid organization ID", err.Error()))
+		return
+	}
+
+	usage, err := h.quotaService.GetUsage(uint(id))
+	if err != nil {
+		switch err {
+		case domain.ErrOrganizationNotFound:
+			c.JSON(http.StatusNotFound, domain.ErrorResponse(domain.ErrOrganizationNotFound.Error(), err.Error()))
+		default:
+			c.JSON(http.StatusInternalServerError, domain.ErrorResponse("failed to retrieve organization usage", err.Error()))
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, domain.SuccessResponse("organization usage retrieved", usage))
+}
+
+// ListMembers lists the members of an organization
+func (h *OrganizationHandler) ListMembers(c *gin.Context) {
+	actorID, orgID, ok := h.membershipParams(c)
+	if !ok {
+		return
+	}
+
+	members, err := h.orgService.ListMembers(actorID, orgID)
+	if err != nil {
+		membershipError(c, err, "failed to retrieve organization members")
+		return
+	}
+
+	responses := make([]*domain.UserResponse, len(members))
+	for i, member := range members {
+		responses[i] = member.ToResponse()
+	}
+
+	c.JSON(http.StatusOK, domain.SuccessResponse("organization members retrieved", responses))
+}
+
+// RemoveMember removes a member from an organization, or lets a member leave it
+func (h *OrganizationHandler) RemoveMember(c *gin.Context) {
+	actorID, orgID, ok := h.membershipParams(c)
+	if !ok {
+		return
+	}
+
+	userID, err := strconv.ParseUint(c.Param("userId"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, domain.ErrorResponse("invalid user ID", err.Error()))
+		return
+	}
+
+	if err := h.orgService.RemoveMember(actorID, orgID, uint(userID)); err != nil {
+		membershipError(c, err, "failed to remove organization member")
+		return
+	}
+
+	c.JSON(http.StatusOK, domain.SuccessResponse("organization member removed", nil))
+}
+
+// TransferOwnership transfers the ownership of an organization to another member
+func (h *OrganizationHandler) TransferOwnership(c *gin.Context) {
+	actorID, orgID, ok := h.membershipParams(c)
+	if !ok {
+		return
+	}
+
+	req, ok := Bind[domain.TransferOwnershipRequest](c, h.validator)
+	if !ok {
+		return
+	}
+
+	if err := h.orgService.TransferOwnership(actorID, orgID, req.UserID); err != nil {
+		membershipError(c, err, "failed to transfer organization ownership")
+		return
+	}
+
+	c.JSON(http.StatusOK, domain.SuccessResponse("organization ownership transferred", nil))
+}
+
+// RevokeSessions logs out every member of an organization
+func (h *OrganizationHandler) RevokeSessions(c *gin.Context) {
+	actorID, orgID, ok := h.membershipParams(c)
+	if !ok {
+		return
+	}
+
+	revocation, err := h.orgService.RevokeSessions(actorID, orgID)
+	if err != nil {
+		membershipError(c, err, "failed to revoke organization sessions")
+		return
+	}
+
+	c.JSON(http.StatusOK, domain.SuccessResponse("all sessions of the organization have been revoked", revocation))
+}
+
+// InviteMember invites an email address to an organization
+func (h *OrganizationHandler) InviteMember(c *gin.Context) {
+	actorID, orgID, ok := h.membershipParams(c)
+	if !ok {
+		return
+	}
+
+	req, ok := Bind[domain.InviteMemberRequest](c, h.validator)
+	if !ok {
+		return
+	}
+
+	invitation, err := h.orgService.InviteMember(actorID, orgID, req)
+	if err != nil {
+		membershipError(c, err, "failed to invite organization member")
+		return
+	}
+
+	c.JSON(http.StatusCreated, domain.SuccessResponse("invitation sent", invitation.ToResponse()))
+}
+
+// ListInvitations lists the pending invitations of an organization
+func (h *OrganizationHandler) ListInvitations(c *gin.Context) {
+	actorID, orgID, ok := h.membershipParams(c)
+	if !ok {
+		return
+	}
+
+	invitations, err := h.orgService.ListInvitations(actorID, orgID)
+	if err != nil {
+		membershipError(c, err, "failed to retrieve invitations")
+		return
+	}
+
+	responses := make([]*domain.OrganizationInvitationResponse, len(invitations))
+	for i, invitation := range invitations {
+		responses[i] = invitation.ToResponse()
+	}
+
+	c.JSON(http.StatusOK, domain.SuccessResponse("invitations retrieved", responses))
+}
+
+// AcceptInvitation adds the authenticated user to the organization of an invitation
+func (h *OrganizationHandler) AcceptInvitation(c *gin.Context) {
+	userID, exists := middleware.GetUserID(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, domain.ErrorResponse("user not authenticated", nil))
+		return
+	}
+
+	req, ok := Bind[domain.AcceptInvitationRequest](c, h.validator)
+	if !ok {
+		return
+	}
+
+	org, err := h.orgService.AcceptInvitation(userID, req.Token)
+	if err != nil {
+		membershipError(c, err, "failed to accept invitation")
+		return
+	}
+
+	c.JSON(http.StatusOK, domain.SuccessResponse("invitation accepted", org.ToResponse()))
+}
+
+// membershipParams reads the authenticated user and the organization ID of a membership request
+func (h *OrganizationHandler) membershipParams(c *gin.Context) (actorID uint, orgID uint, ok bool) {
+	actorID, exists := middleware.GetUserID(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, domain.ErrorResponse("user not authenticated", nil))
+		return 0, 0, false
+	}
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, domain.ErrorResponse("invalid organization ID", err.Error()))
+		return 0, 0, false
+	}
+	return actorID, uint(id), true
+}
+
+// membershipError maps membership errors to responses
+func membershipError(c *gin.Context, err error, message string) {
+	switch err {
+	case domain.ErrOrganizationNotFound, domain.ErrUserNotFound, domain.ErrInvitationNotFound:
+		c.JSON(http.StatusNotFound, domain.ErrorResponse(err.Error(), nil))
+	case domain.ErrOrganizationForbidden, domain.ErrInvitationEmailMismatch, domain.ErrUserQuotaExceeded, domain.ErrEmailDomainNotAllowed:
+		c.JSON(http.StatusForbidden, domain.ErrorResponse(err.Error(), nil))
+	case domain.ErrNotOrganizationMember:
+		c.JSON(http.StatusBadRequest, domain.ErrorResponse(err.Error(), nil))
+	case domain.ErrAlreadyOrganizationMember, domain.ErrCannotRemoveOwner:
+		c.JSON(http.StatusConflict, domain.ErrorResponse(err.Error(), nil))
+	case domain.ErrInvitationExpired:
+		c.JSON(http.StatusGone, domain.ErrorResponse(err.Error(), nil))
+	default:
+		c.JSON(http.StatusInternalServerError, domain.ErrorResponse(message, err.Error()))
+	}
+}
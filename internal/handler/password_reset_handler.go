@@ -0,0 +1,171 @@
+package handler
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"gojwt-rest-api/internal/domain"
+	"gojwt-rest-api/internal/middleware"
+	"gojwt-rest-api/internal/service"
+	"gojwt-rest-api/pkg/logger"
+	"gojwt-rest-api/pkg/validator"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+)
+
+// forgotPasswordMessage is the only answer to forgot password requests
+const forgotPasswordMessage = "if an account uses this email, a password reset link has been emailed"
+
+// Default size of the queue of reset requests and number of its workers
+const (
+	defaultResetQueueSize = 100
+	defaultResetWorkers   = 4
+)
+
+// PasswordResetHandler handles the self-service reset of forgotten passwords
+type PasswordResetHandler struct {
+	passwordReset service.PasswordResetService
+	validator     *validator.Validator
+	logger        *logger.Logger
+	// emailLimiter limits the reset requests per email, when set
+	emailLimiter *middleware.RateLimiter
+
+	// requests queues the emails of reset requests for the workers; requests
+	// are dropped when it is full
+	requests   chan string
+	workers    int
+	queueSize  int
+	mu         sync.RWMutex
+	closed     bool
+	processing sync.WaitGroup
+}
+
+// PasswordResetHandlerOption configures optional password reset handler behavior
+type PasswordResetHandlerOption func(*PasswordResetHandler)
+
+// WithResetQueue processes reset requests with workers goroutines, queueing
+// at most size requests
+func WithResetQueue(workers, size int) PasswordResetHandlerOption {
+	return func(h *PasswordResetHandler) {
+		h.workers = workers
+		h.queueSize = size
+	}
+}
+
+// WithResetEmailLimiter limits the reset requests for each email with limiter,
+// on top of the limits per client IP
+func WithResetEmailLimiter(limiter *middleware.RateLimiter) PasswordResetHandlerOption {
+	return func(h *PasswordResetHandler) {
+		h.emailLimiter = limiter
+	}
+}
+
+// NewPasswordResetHandler creates a new password reset handler and starts the
+// workers of its queue. Failures of reset requests, which are not reported to
+// the client, are logged to appLogger.
+func NewPasswordResetHandler(passwordReset service.PasswordResetService, validator *validator.Validator, appLogger *logger.Logger, opts ...PasswordResetHandlerOption) *PasswordResetHandler {
+	h := &PasswordResetHandler{
+		passwordReset: passwordReset,
+		validator:     validator,
+		logger:        appLogger,
+		workers:       defaultResetWorkers,
+		queueSize:     defaultResetQueueSize,
+	}
+	for _, opt := range opts {
+		opt(h)
+	}
+
+	h.requests = make(chan string, h.queueSize)
+	h.processing.Add(h.workers)
+	for i := 0; i < h.workers; i++ {
+		go h.work()
+	}
+	return h
+}
+
+// work processes queued reset requests until the queue is closed
+func (h *PasswordResetHandler) work() {
+	defer h.processing.Done()
+	for email := range h.requests {
+		if err := h.passwordReset.RequestReset(email); err != nil {
+			h.logger.Error("Failed to process password reset request:", err)
+		}
+	}
+}
+
+// enqueue queues a reset request, returning false when the queue is full or closed
+func (h *PasswordResetHandler) enqueue(email string) bool {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	if h.closed {
+		return false
+	}
+	select {
+	case h.requests <- email:
+		return true
+	default:
+		return false
+	}
+}
+
+// ForgotPassword emails a password reset link. The request is queued and
+// processed after the response is sent, so that the status, body and response
+// time are the same whether or not the email is registered. Requests are
+// dropped while the queue is full.
+func (h *PasswordResetHandler) ForgotPassword(c *gin.Context) {
+	req, ok := Bind[domain.ForgotPasswordRequest](c, h.validator)
+	if !ok {
+		return
+	}
+	if h.emailLimiter != nil && !h.emailLimiter.Allow(c.Request.Context(), resetLimitKey(req.Email), c.FullPath()) {
+		c.JSON(http.StatusTooManyRequests, domain.ErrorResponse(domain.ErrRateLimitExceeded.Error(), nil))
+		return
+	}
+
+	if !h.enqueue(req.Email) {
+		h.logger.Warn("Password reset queue is full, dropping a reset request")
+	}
+
+	c.JSON(http.StatusAccepted, domain.SuccessResponse(forgotPasswordMessage, nil))
+}
+
+// ResetPassword replaces the password with the emailed token
+func (h *PasswordResetHandler) ResetPassword(c *gin.Context) {
+	req, ok := Bind[domain.ResetPasswordRequest](c, h.validator)
+	if !ok {
+		return
+	}
+
+	if _, err := h.passwordReset.Reset(req); err != nil {
+		switch err {
+		case domain.ErrOneTimeTokenInvalid, domain.ErrPasswordPolicyViolation:
+			c.JSON(http.StatusBadRequest, domain.ErrorResponse(err.Error(), nil))
+		default:
+			c.JSON(http.StatusInternalServerError, domain.ErrorResponse("failed to reset password", err.Error()))
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, domain.SuccessResponse("password reset, log in with your new password", nil))
+}
+
+// Wait stops accepting reset requests and blocks until the queued ones have
+// been processed
+func (h *PasswordResetHandler) Wait() {
+	h.mu.Lock()
+	if !h.closed {
+		h.closed = true
+		close(h.requests)
+	}
+	h.mu.Unlock()
+	h.processing.Wait()
+}
+
+// resetLimitKey is the rate limit key of the reset requests for email, a hash
+// so that rate limiter statistics do not list email addresses
+func resetLimitKey(email string) string {
+	sum := sha256.Sum256([]byte(strings.ToLower(strings.TrimSpace(email))))
+	return hex.EncodeToString(sum[:])
+}
@@ -2,6 +2,7 @@ package handler
 
 import (
 	"gojwt-rest-api/internal/domain"
+	"gojwt-rest-api/internal/middleware"
 	"gojwt-rest-api/internal/service"
 	"gojwt-rest-api/pkg/validator"
 	"net/http"
@@ -47,7 +48,7 @@ func (h *ProfileHandler) GetOwnProfile(c *gin.Context) {
 		return
 	}
 
-	c.JSON(http.StatusOK, domain.SuccessResponse("Profile retrieved successfully", user.ToResponse()))
+	c.JSON(http.StatusOK, domain.SuccessResponse("Profile retrieved successfully", user.ToProfileResponse()))
 }
 
 // UpdateOwnProfile updates the authenticated user's profile
@@ -71,23 +72,23 @@ func (h *ProfileHandler) UpdateOwnProfile(c *gin.Context) {
 		return
 	}
 
-	var req domain.UpdateProfileRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, domain.ErrorResponse("Invalid request body", err))
+	req, ok := Bind[domain.UpdateProfileRequest](c, h.validator)
+	if !ok {
 		return
 	}
-
-	// Validate request
-	if validationErrors := h.validator.Validate(&req); len(validationErrors) > 0 {
-		c.JSON(http.StatusBadRequest, domain.ErrorResponse("Validation failed", validationErrors))
+	// An API key cannot take over its owner's account by changing the email
+	if _, keyAuth := middleware.GetAPIKeyID(c); keyAuth && req.Email != "" {
+		c.JSON(http.StatusForbidden, domain.ErrorResponse(domain.ErrAPIKeyNotAccepted.Error(), "email changes require a session"))
 		return
 	}
 
-	user, err := h.userService.UpdateOwnProfile(userID.(uint), &req)
+	user, err := h.userService.UpdateOwnProfile(userID.(uint), req)
 	if err != nil {
 		switch err {
 		case domain.ErrEmailAlreadyInUse:
 			c.JSON(http.StatusConflict, domain.ErrorResponse("Email already in use", err))
+		case domain.ErrEmailDomainNotAllowed:
+			c.JSON(http.StatusBadRequest, domain.ErrorResponse(domain.ErrEmailDomainNotAllowed.Error(), nil))
 		case domain.ErrUserNotFound:
 			c.JSON(http.StatusNotFound, domain.ErrorResponse("User not found", err))
 		default:
@@ -96,7 +97,7 @@ func (h *ProfileHandler) UpdateOwnProfile(c *gin.Context) {
 		return
 	}
 
-	c.JSON(http.StatusOK, domain.SuccessResponse("Profile updated successfully", user.ToResponse()))
+	c.JSON(http.StatusOK, domain.SuccessResponse("Profile updated successfully", user.ToProfileResponse()))
 }
 
 // ChangePassword changes the authenticated user's password
@@ -119,23 +120,21 @@ func (h *ProfileHandler) ChangePassword(c *gin.Context) {
 		return
 	}
 
-	var req domain.ChangePasswordRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, domain.ErrorResponse("Invalid request body", err))
-		return
-	}
-
-	// Validate request
-	if validationErrors := h.validator.Validate(&req); len(validationErrors) > 0 {
-		c.JSON(http.StatusBadRequest, domain.ErrorResponse("Validation failed", validationErrors))
+	req, ok := Bind[domain.ChangePasswordRequest](c, h.validator)
+	if !ok {
 		return
 	}
 
-	err := h.userService.ChangePassword(userID.(uint), &req)
+	err := h.userService.ChangePassword(userID.(uint), req)
 	if err != nil {
 		switch err {
 		case domain.ErrInvalidCredentials:
 			c.JSON(http.StatusUnauthorized, domain.ErrorResponse("Old password is incorrect", err))
+		case domain.ErrPasswordPolicyViolation:
+			c.JSON(http.StatusBadRequest, domain.ErrorResponse(domain.ErrPasswordPolicyViolation.Error(), nil))
+		case domain.ErrPasswordCheckBusy:
+			c.Header("Retry-After", retryAfterSeconds)
+			c.JSON(http.StatusTooManyRequests, domain.ErrorResponse(domain.ErrPasswordCheckBusy.Error(), nil))
 		case domain.ErrUserNotFound:
 			c.JSON(http.StatusNotFound, domain.ErrorResponse("User not found", err))
 		default:
@@ -146,3 +145,39 @@ func (h *ProfileHandler) ChangePassword(c *gin.Context) {
 
 	c.JSON(http.StatusOK, domain.SuccessResponse("Password changed successfully", nil))
 }
+
+// RevokeClientSessions logs the authenticated user out of every session of a
+// client application, e.g. after losing a device
+// @Summary Revoke own sessions of a client application
+// @Description Revoke the refresh tokens of the authenticated user's sessions started from a client application
+// @Tags profile
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body domain.RevokeClientSessionsRequest true "Revoke client sessions request"
+// @Success 200 {object} domain.Response
+// @Failure 400 {object} domain.Response
+// @Failure 401 {object} domain.Response
+// @Router /api/v1/profile/sessions/revoke [post]
+func (h *ProfileHandler) RevokeClientSessions(c *gin.Context) {
+	// Get user ID from context (set by auth middleware)
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, domain.ErrorResponse("Unauthorized", nil))
+		return
+	}
+
+	req, ok := Bind[domain.RevokeClientSessionsRequest](c, h.validator)
+	if !ok {
+		return
+	}
+
+	id := userID.(uint)
+	revocation, err := h.userService.RevokeClientSessions(id, &id, req.ClientID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, domain.ErrorResponse("Failed to revoke sessions", err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, domain.SuccessResponse("Sessions of the client have been revoked", revocation))
+}
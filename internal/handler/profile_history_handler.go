@@ -0,0 +1,91 @@
+package handler
+
+import (
+	"gojwt-rest-api/internal/domain"
+	"gojwt-rest-api/internal/middleware"
+	"gojwt-rest-api/internal/service"
+	"math"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ProfileHistoryHandler handles profile change history requests
+type ProfileHistoryHandler struct {
+	historyService service.ProfileHistoryService
+	userService    service.UserService
+}
+
+// NewProfileHistoryHandler creates a new profile history handler
+func NewProfileHistoryHandler(historyService service.ProfileHistoryService, userService service.UserService) *ProfileHistoryHandler {
+	return &ProfileHistoryHandler{
+		historyService: historyService,
+		userService:    userService,
+	}
+}
+
+// GetHistory lists the profile changes of a user, newest first. Users
+// viewing their own history get it redacted (see domain.OwnProfileChangeResponse).
+// Users out of the caller's tenant scope are not found.
+func (h *ProfileHistoryHandler) GetHistory(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, domain.ErrorResponse("invalid user ID", err.Error()))
+		return
+	}
+
+	var pagination domain.PaginationQuery
+	page, err := strconv.Atoi(c.DefaultQuery("page", "1"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, domain.ErrorResponse("invalid page parameter", err.Error()))
+		return
+	}
+	pageSize, err := strconv.Atoi(c.DefaultQuery("page_size", "10"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, domain.ErrorResponse("invalid page_size parameter", err.Error()))
+		return
+	}
+	pagination.Page = page
+	pagination.PageSize = pageSize
+
+	// Profile changes are not scoped to tenants, the user is
+	if _, err := h.userService.WithContext(c.Request.Context()).GetUserByID(uint(id)); err != nil {
+		switch err {
+		case domain.ErrUserNotFound:
+			c.JSON(http.StatusNotFound, domain.ErrorResponse(domain.ErrUserNotFound.Error(), nil))
+		default:
+			c.JSON(http.StatusInternalServerError, domain.ErrorResponse("failed to retrieve profile history", err.Error()))
+		}
+		return
+	}
+
+	changes, total, err := h.historyService.List(uint(id), &pagination)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, domain.ErrorResponse("failed to retrieve profile history", err.Error()))
+		return
+	}
+
+	responses := make([]interface{}, len(changes))
+	for i, change := range changes {
+		if middleware.IsSelfAccess(c) {
+			responses[i], err = change.ToOwnResponse()
+		} else {
+			responses[i], err = change.ToResponse()
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, domain.ErrorResponse("failed to retrieve profile history", err.Error()))
+			return
+		}
+	}
+
+	response := domain.PaginatedResponse{
+		Data:       responses,
+		Page:       pagination.Page,
+		PageSize:   pagination.PageSize,
+		TotalItems: total,
+		TotalPages: int(math.Ceil(float64(total) / float64(pagination.PageSize))),
+	}
+
+	c.JSON(http.StatusOK, domain.SuccessResponse("profile history retrieved", response))
+}
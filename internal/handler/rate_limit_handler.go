@@ -0,0 +1,38 @@
+package handler
+
+import (
+	"gojwt-rest-api/internal/domain"
+	"gojwt-rest-api/internal/middleware"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RateLimitHandler handles rate limiter statistics requests
+type RateLimitHandler struct {
+	limiters []*middleware.RateLimiter
+}
+
+// NewRateLimitHandler creates a new rate limit handler reporting on limiters
+func NewRateLimitHandler(limiters ...*middleware.RateLimiter) *RateLimitHandler {
+	return &RateLimitHandler{
+		limiters: limiters,
+	}
+}
+
+// Stats returns the tracked visitors, rejections per route and top
+// offending IPs of every rate limiter (admin only)
+func (h *RateLimitHandler) Stats(c *gin.Context) {
+	top, err := strconv.Atoi(c.DefaultQuery("top", "10"))
+	if err != nil || top < 1 {
+		c.JSON(http.StatusBadRequest, domain.ErrorResponse("invalid top parameter", nil))
+		return
+	}
+
+	stats := make([]*middleware.RateLimitStats, 0, len(h.limiters))
+	for _, limiter := range h.limiters {
+		stats = append(stats, limiter.Stats(top))
+	}
+	c.JSON(http.StatusOK, domain.SuccessResponse("rate limiter statistics retrieved", stats))
+}
@@ -0,0 +1,254 @@
+package handler
+
+import (
+	"errors"
+	"gojwt-rest-api/internal/domain"
+	"gojwt-rest-api/internal/scim"
+	"gojwt-rest-api/internal/service"
+	"gojwt-rest-api/pkg/validator"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	scimUsersPath    = "/scim/v2/Users"
+	scimDefaultCount = 100
+	scimMaxCount     = 200
+)
+
+// scimUserRequest validates the attributes of a provisioned user
+type scimUserRequest struct {
+	Name  string `validate:"required,min=2,max=100"`
+	Email string `validate:"required,email"`
+}
+
+// SCIMHandler handles SCIM 2.0 user provisioning requests
+type SCIMHandler struct {
+	provisioningService service.ProvisioningService
+	validator           *validator.Validator
+}
+
+// NewSCIMHandler creates a new SCIM handler
+func NewSCIMHandler(provisioningService service.ProvisioningService, validator *validator.Validator) *SCIMHandler {
+	return &SCIMHandler{
+		provisioningService: provisioningService,
+		validator:           validator,
+	}
+}
+
+// ListUsers lists users, supporting filter, startIndex and count
+func (h *SCIMHandler) ListUsers(c *gin.Context) {
+	filter, err := scim.ParseFilter(c.Query("filter"))
+	if err != nil {
+		h.error(c, http.StatusBadRequest, scim.ErrorTypeInvalidFilter, err.Error())
+		return
+	}
+
+	startIndex, err := strconv.Atoi(c.DefaultQuery("startIndex", "1"))
+	if err != nil || startIndex < 1 {
+		startIndex = 1
+	}
+	count, err := strconv.Atoi(c.DefaultQuery("count", strconv.Itoa(scimDefaultCount)))
+	if err != nil || count < 0 {
+		count = scimDefaultCount
+	}
+	count = min(count, scimMaxCount)
+
+	users, total, err := h.provisioningService.ListUsers(filter, startIndex-1, count)
+	if errors.Is(err, domain.ErrInvalidFilter) {
+		h.error(c, http.StatusBadRequest, scim.ErrorTypeInvalidFilter, err.Error())
+		return
+	}
+	if err != nil {
+		h.error(c, http.StatusInternalServerError, "", err.Error())
+		return
+	}
+
+	resources := make([]*scim.User, len(users))
+	for i, user := range users {
+		resources[i] = scim.FromUser(user, h.usersURL(c))
+	}
+
+	h.respond(c, http.StatusOK, &scim.ListResponse{
+		Schemas:      []string{scim.SchemaListResponse},
+		TotalResults: total,
+		StartIndex:   startIndex,
+		ItemsPerPage: len(resources),
+		Resources:    resources,
+	})
+}
+
+// GetUser gets a user by ID
+func (h *SCIMHandler) GetUser(c *gin.Context) {
+	id, ok := h.userID(c)
+	if !ok {
+		return
+	}
+
+	user, err := h.provisioningService.GetUser(id)
+	if err != nil {
+		h.serviceError(c, err)
+		return
+	}
+
+	h.respond(c, http.StatusOK, scim.FromUser(user, h.usersURL(c)))
+}
+
+// CreateUser provisions a new user
+func (h *SCIMHandler) CreateUser(c *gin.Context) {
+	var resource scim.User
+	if err := c.ShouldBindJSON(&resource); err != nil {
+		h.error(c, http.StatusBadRequest, scim.ErrorTypeInvalidValue, err.Error())
+		return
+	}
+
+	attrs, ok := h.provisionedUser(c, &resource)
+	if !ok {
+		return
+	}
+
+	user, err := h.provisioningService.CreateUser(attrs)
+	if err != nil {
+		h.serviceError(c, err)
+		return
+	}
+
+	h.respond(c, http.StatusCreated, scim.FromUser(user, h.usersURL(c)))
+}
+
+// ReplaceUser replaces a user's attributes
+func (h *SCIMHandler) ReplaceUser(c *gin.Context) {
+	id, ok := h.userID(c)
+	if !ok {
+		return
+	}
+
+	var resource scim.User
+	if err := c.ShouldBindJSON(&resource); err != nil {
+		h.error(c, http.StatusBadRequest, scim.ErrorTypeInvalidValue, err.Error())
+		return
+	}
+
+	h.replace(c, id, &resource)
+}
+
+// PatchUser applies PATCH operations to a user, e.g. {"op":"replace","path":"active","value":false}
+func (h *SCIMHandler) PatchUser(c *gin.Context) {
+	id, ok := h.userID(c)
+	if !ok {
+		return
+	}
+
+	var patch scim.PatchRequest
+	if err := c.ShouldBindJSON(&patch); err != nil {
+		h.error(c, http.StatusBadRequest, scim.ErrorTypeInvalidValue, err.Error())
+		return
+	}
+
+	user, err := h.provisioningService.GetUser(id)
+	if err != nil {
+		h.serviceError(c, err)
+		return
+	}
+
+	resource := scim.FromUser(user, h.usersURL(c))
+	if err := patch.Apply(resource); err != nil {
+		scimType := scim.ErrorTypeInvalidValue
+		if err == scim.ErrInvalidPath {
+			scimType = scim.ErrorTypeInvalidPath
+		}
+		h.error(c, http.StatusBadRequest, scimType, err.Error())
+		return
+	}
+
+	h.replace(c, id, resource)
+}
+
+// DeleteUser deprovisions a user. Users are deactivated rather than deleted
+// so their history is kept and they can be reactivated.
+func (h *SCIMHandler) DeleteUser(c *gin.Context) {
+	id, ok := h.userID(c)
+	if !ok {
+		return
+	}
+
+	if err := h.provisioningService.DeactivateUser(id); err != nil {
+		h.serviceError(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// replace validates the resource and replaces the user's attributes with it
+func (h *SCIMHandler) replace(c *gin.Context, id uint, resource *scim.User) {
+	attrs, ok := h.provisionedUser(c, resource)
+	if !ok {
+		return
+	}
+
+	user, err := h.provisioningService.ReplaceUser(id, attrs)
+	if err != nil {
+		h.serviceError(c, err)
+		return
+	}
+
+	h.respond(c, http.StatusOK, scim.FromUser(user, h.usersURL(c)))
+}
+
+// provisionedUser extracts and validates the attributes managed through SCIM
+func (h *SCIMHandler) provisionedUser(c *gin.Context, resource *scim.User) (*service.ProvisionedUser, bool) {
+	req := &scimUserRequest{Name: resource.FullName(), Email: resource.Email()}
+	if validationErrors := h.validator.Validate(req); len(validationErrors) > 0 {
+		h.error(c, http.StatusBadRequest, scim.ErrorTypeInvalidValue, validationErrors[0].Field+": "+validationErrors[0].Error)
+		return nil, false
+	}
+
+	return &service.ProvisionedUser{Name: req.Name, Email: req.Email, Active: resource.IsActive()}, true
+}
+
+// userID parses the user ID path parameter
+func (h *SCIMHandler) userID(c *gin.Context) (uint, bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		h.error(c, http.StatusNotFound, "", domain.ErrUserNotFound.Error())
+		return 0, false
+	}
+	return uint(id), true
+}
+
+// serviceError maps service errors to SCIM errors
+func (h *SCIMHandler) serviceError(c *gin.Context, err error) {
+	switch err {
+	case domain.ErrUserNotFound:
+		h.error(c, http.StatusNotFound, "", err.Error())
+	case domain.ErrUserAlreadyExists:
+		h.error(c, http.StatusConflict, scim.ErrorTypeUniqueness, err.Error())
+	case domain.ErrUserQuotaExceeded:
+		h.error(c, http.StatusForbidden, "", err.Error())
+	default:
+		h.error(c, http.StatusInternalServerError, "", err.Error())
+	}
+}
+
+// usersURL returns the absolute URL of the Users endpoint for meta.location
+func (h *SCIMHandler) usersURL(c *gin.Context) string {
+	scheme := "http"
+	if c.Request.TLS != nil || c.GetHeader("X-Forwarded-Proto") == "https" {
+		scheme = "https"
+	}
+	return scheme + "://" + c.Request.Host + scimUsersPath
+}
+
+// respond writes a SCIM JSON response
+func (h *SCIMHandler) respond(c *gin.Context, status int, body interface{}) {
+	c.Header("Content-Type", scim.ContentType)
+	c.JSON(status, body)
+}
+
+// error writes a SCIM error response
+func (h *SCIMHandler) error(c *gin.Context, status int, scimType, detail string) {
+	h.respond(c, status, scim.NewError(status, scimType, detail))
+}
@@ -0,0 +1,67 @@
+package handler
+
+import (
+	"errors"
+	"gojwt-rest-api/internal/domain"
+	"gojwt-rest-api/internal/service"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// defaultAnalyticsBuckets is the number of intervals reported when no range is given
+const defaultAnalyticsBuckets = 24
+
+// SessionAnalyticsHandler handles session analytics requests
+type SessionAnalyticsHandler struct {
+	analyticsService service.SessionAnalyticsService
+}
+
+// NewSessionAnalyticsHandler creates a new session analytics handler
+func NewSessionAnalyticsHandler(analyticsService service.SessionAnalyticsService) *SessionAnalyticsHandler {
+	return &SessionAnalyticsHandler{analyticsService: analyticsService}
+}
+
+// GetSessionAnalytics reports sessions by interval (default 1h) between from
+// and to (RFC 3339). Without them the last 24 intervals are reported, up to
+// the current one.
+func (h *SessionAnalyticsHandler) GetSessionAnalytics(c *gin.Context) {
+	interval, err := time.ParseDuration(c.DefaultQuery("interval", "1h"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, domain.ErrorResponse("invalid interval parameter", err.Error()))
+		return
+	}
+	if interval <= 0 {
+		c.JSON(http.StatusBadRequest, domain.ErrorResponse("invalid interval parameter", nil))
+		return
+	}
+
+	to := time.Now().UTC().Truncate(interval).Add(interval)
+	if value := c.Query("to"); value != "" {
+		if to, err = time.Parse(time.RFC3339, value); err != nil {
+			c.JSON(http.StatusBadRequest, domain.ErrorResponse("invalid to parameter", err.Error()))
+			return
+		}
+	}
+	from := to.Add(-defaultAnalyticsBuckets * interval)
+	if value := c.Query("from"); value != "" {
+		if from, err = time.Parse(time.RFC3339, value); err != nil {
+			c.JSON(http.StatusBadRequest, domain.ErrorResponse("invalid from parameter", err.Error()))
+			return
+		}
+	}
+
+	report, err := h.analyticsService.Report(from.UTC(), to.UTC(), interval)
+	if err != nil {
+		switch {
+		case errors.Is(err, domain.ErrInvalidAnalyticsRange):
+			c.JSON(http.StatusBadRequest, domain.ErrorResponse(domain.ErrInvalidAnalyticsRange.Error(), err.Error()))
+		default:
+			c.JSON(http.StatusInternalServerError, domain.ErrorResponse("failed to report session analytics", err.Error()))
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, domain.SuccessResponse("session analytics retrieved", report))
+}
@@ -0,0 +1,76 @@
+package handler
+
+import (
+	"gojwt-rest-api/internal/domain"
+	"gojwt-rest-api/internal/events"
+	"gojwt-rest-api/internal/middleware"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// sessionEventsKeepAlive is how often an idle stream sends a comment, so that
+// proxies do not close it
+const sessionEventsKeepAlive = 30 * time.Second
+
+// SessionEventsHandler streams the session events of the authenticated user,
+// such as logins from elsewhere, as server-sent events
+type SessionEventsHandler struct {
+	stream *events.Stream
+}
+
+// NewSessionEventsHandler creates a new session events handler
+func NewSessionEventsHandler(stream *events.Stream) *SessionEventsHandler {
+	return &SessionEventsHandler{stream: stream}
+}
+
+// StreamSessionEvents streams session events until the client disconnects
+// @Summary Stream session events
+// @Description Stream events concerning the sessions of the authenticated user, such as user.concurrent_login, as server-sent events
+// @Tags profile
+// @Produce text/event-stream
+// @Security BearerAuth
+// @Success 200 {string} string "event stream"
+// @Failure 401 {object} domain.Response
+// @Router /api/v1/profile/sessions/events [get]
+func (h *SessionEventsHandler) StreamSessionEvents(c *gin.Context) {
+	userID, exists := middleware.GetUserID(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, domain.ErrorResponse("Unauthorized", nil))
+		return
+	}
+
+	// The stream outlives the write timeout of the server
+	_ = http.NewResponseController(c.Writer).SetWriteDeadline(time.Time{})
+
+	received, cancel := h.stream.Subscribe(userID)
+	defer cancel()
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	// Disables response buffering of nginx
+	c.Header("X-Accel-Buffering", "no")
+	c.Status(http.StatusOK)
+	c.Writer.Flush()
+
+	keepAlive := time.NewTicker(sessionEventsKeepAlive)
+	defer keepAlive.Stop()
+	for {
+		select {
+		case <-c.Request.Context().Done():
+			return
+		case event, ok := <-received:
+			if !ok {
+				return
+			}
+			c.SSEvent(event.Type, event)
+		case <-keepAlive.C:
+			if _, err := c.Writer.WriteString(": keep-alive\n\n"); err != nil {
+				return
+			}
+		}
+		c.Writer.Flush()
+	}
+}
@@ -0,0 +1,76 @@
+package handler
+
+import (
+	"gojwt-rest-api/internal/domain"
+	"gojwt-rest-api/internal/service"
+	"gojwt-rest-api/pkg/validator"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SettingsHandler handles organization setting override requests
+type SettingsHandler struct {
+	settingsService service.SettingsService
+	validator       *validator.Validator
+}
+
+// NewSettingsHandler creates a new settings handler
+func NewSettingsHandler(settingsService service.SettingsService, validator *validator.Validator) *SettingsHandler {
+	return &SettingsHandler{
+		settingsService: settingsService,
+		validator:       validator,
+	}
+}
+
+// GetOrganizationSettings returns an organization's setting overrides and the settings in effect
+func (h *SettingsHandler) GetOrganizationSettings(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, domain.ErrorResponse("invalid organization ID", err.Error()))
+		return
+	}
+
+	settings, err := h.settingsService.GetOverrides(uint(id))
+	if err != nil {
+		switch err {
+		case domain.ErrOrganizationNotFound:
+			c.JSON(http.StatusNotFound, domain.ErrorResponse(domain.ErrOrganizationNotFound.Error(), err.Error()))
+		default:
+			c.JSON(http.StatusInternalServerError, domain.ErrorResponse("failed to retrieve organization settings", err.Error()))
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, domain.SuccessResponse("organization settings retrieved", settings.ToResponse(h.settingsService.Defaults())))
+}
+
+// UpdateOrganizationSettings replaces an organization's setting overrides
+func (h *SettingsHandler) UpdateOrganizationSettings(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, domain.ErrorResponse("invalid organization ID", err.Error()))
+		return
+	}
+
+	req, ok := Bind[domain.UpdateOrganizationSettingsRequest](c, h.validator)
+	if !ok {
+		return
+	}
+
+	settings, err := h.settingsService.UpdateOverrides(uint(id), req)
+	if err != nil {
+		switch err {
+		case domain.ErrOrganizationNotFound:
+			c.JSON(http.StatusNotFound, domain.ErrorResponse(domain.ErrOrganizationNotFound.Error(), err.Error()))
+		case domain.ErrInvalidAccessSchedule:
+			c.JSON(http.StatusBadRequest, domain.ErrorResponse(domain.ErrValidationFailed.Error(), err.Error()))
+		default:
+			c.JSON(http.StatusInternalServerError, domain.ErrorResponse("failed to update organization settings", err.Error()))
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, domain.SuccessResponse("organization settings updated", settings.ToResponse(h.settingsService.Defaults())))
+}
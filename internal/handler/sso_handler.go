@@ -0,0 +1,178 @@
+package handler
+
+import (
+	"gojwt-rest-api/internal/domain"
+	"gojwt-rest-api/internal/service"
+	"gojwt-rest-api/pkg/validator"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SSOHandler handles home realm discovery and SSO connection administration requests
+type SSOHandler struct {
+	ssoService  service.SSOService
+	userService service.UserService
+	validator   *validator.Validator
+}
+
+// NewSSOHandler creates a new SSO handler
+func NewSSOHandler(ssoService service.SSOService, userService service.UserService, validator *validator.Validator) *SSOHandler {
+	return &SSOHandler{
+		ssoService:  ssoService,
+		userService: userService,
+		validator:   validator,
+	}
+}
+
+// LoginStart tells the client whether to ask for a password or redirect to the user's identity provider
+func (h *SSOHandler) LoginStart(c *gin.Context) {
+	req, ok := Bind[domain.LoginStartRequest](c, h.validator)
+	if !ok {
+		return
+	}
+
+	response, err := h.ssoService.DiscoverRealm(req.Email)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, domain.ErrorResponse("failed to discover login method", err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, domain.SuccessResponse("login method discovered", response))
+}
+
+// DevLogin signs in with the attributes of an unverified assertion, exercising the
+// connection's provisioning rules without an identity provider.
+// It must never be registered in production.
+func (h *SSOHandler) DevLogin(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, domain.ErrorResponse("invalid sso connection ID", err.Error()))
+		return
+	}
+
+	req, ok := Bind[domain.SSOLoginRequest](c, h.validator)
+	if !ok {
+		return
+	}
+
+	user, err := h.ssoService.ResolveUser(uint(id), req.Attributes)
+	if err != nil {
+		switch err {
+		case domain.ErrSSOConnectionNotFound:
+			c.JSON(http.StatusNotFound, domain.ErrorResponse(domain.ErrSSOConnectionNotFound.Error(), err.Error()))
+		case domain.ErrSSOEmailDomainMismatch, domain.ErrJITProvisioningDisabled, domain.ErrUserDeactivated, domain.ErrUserQuotaExceeded:
+			c.JSON(http.StatusForbidden, domain.ErrorResponse(err.Error(), nil))
+		default:
+			c.JSON(http.StatusInternalServerError, domain.ErrorResponse(domain.ErrLoginFailed.Error(), err.Error()))
+		}
+		return
+	}
+
+	response, err := h.userService.IssueSession(user, req.ClientID)
+	if err != nil {
+		switch err {
+		case domain.ErrUserDeactivated, domain.ErrAccountLocked:
+			c.JSON(http.StatusForbidden, domain.ErrorResponse(err.Error(), nil))
+		case domain.ErrOutsideAccessSchedule:
+			c.JSON(http.StatusForbidden, domain.ErrorResponse(err.Error(), gin.H{"code": domain.ErrorCodeOutsideAccessSchedule}))
+		case domain.ErrSessionQuotaExceeded:
+			c.JSON(http.StatusTooManyRequests, domain.ErrorResponse(domain.ErrSessionQuotaExceeded.Error(), nil))
+		case domain.ErrUnknownClient, domain.ErrClientIDRequired:
+			c.JSON(http.StatusBadRequest, domain.ErrorResponse(err.Error(), nil))
+		default:
+			c.JSON(http.StatusInternalServerError, domain.ErrorResponse(domain.ErrLoginFailed.Error(), err.Error()))
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, domain.SuccessResponse("login successful", response))
+}
+
+// CreateConnection creates a new SSO connection
+func (h *SSOHandler) CreateConnection(c *gin.Context) {
+	req, ok := Bind[domain.CreateSSOConnectionRequest](c, h.validator)
+	if !ok {
+		return
+	}
+
+	conn, err := h.ssoService.CreateConnection(req)
+	if err != nil {
+		switch err {
+		case domain.ErrSSOConnectionAlreadyExists, domain.ErrSSODomainTaken:
+			c.JSON(http.StatusConflict, domain.ErrorResponse(err.Error(), err.Error()))
+		case domain.ErrOrganizationNotFound:
+			c.JSON(http.StatusBadRequest, domain.ErrorResponse(domain.ErrOrganizationNotFound.Error(), err.Error()))
+		default:
+			c.JSON(http.StatusInternalServerError, domain.ErrorResponse("failed to create sso connection", err.Error()))
+		}
+		return
+	}
+
+	c.JSON(http.StatusCreated, domain.SuccessResponse("sso connection created", conn.ToResponse()))
+}
+
+// ListConnections lists all SSO connections
+func (h *SSOHandler) ListConnections(c *gin.Context) {
+	conns, err := h.ssoService.ListConnections()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, domain.ErrorResponse("failed to retrieve sso connections", err.Error()))
+		return
+	}
+
+	responses := make([]*domain.SSOConnectionResponse, len(conns))
+	for i, conn := range conns {
+		responses[i] = conn.ToResponse()
+	}
+
+	c.JSON(http.StatusOK, domain.SuccessResponse("sso connections retrieved", responses))
+}
+
+// UpdateProvisioningRules replaces the just-in-time provisioning rules of an SSO connection
+func (h *SSOHandler) UpdateProvisioningRules(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, domain.ErrorResponse("invalid sso connection ID", err.Error()))
+		return
+	}
+
+	req, ok := Bind[domain.SSOProvisioningRules](c, h.validator)
+	if !ok {
+		return
+	}
+
+	conn, err := h.ssoService.UpdateProvisioningRules(uint(id), req)
+	if err != nil {
+		switch err {
+		case domain.ErrSSOConnectionNotFound:
+			c.JSON(http.StatusNotFound, domain.ErrorResponse(domain.ErrSSOConnectionNotFound.Error(), err.Error()))
+		default:
+			c.JSON(http.StatusInternalServerError, domain.ErrorResponse("failed to update provisioning rules", err.Error()))
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, domain.SuccessResponse("provisioning rules updated", conn.ToResponse()))
+}
+
+// DeleteConnection deletes an SSO connection
+func (h *SSOHandler) DeleteConnection(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, domain.ErrorResponse("invalid sso connection ID", err.Error()))
+		return
+	}
+
+	if err := h.ssoService.DeleteConnection(uint(id)); err != nil {
+		switch err {
+		case domain.ErrSSOConnectionNotFound:
+			c.JSON(http.StatusNotFound, domain.ErrorResponse(domain.ErrSSOConnectionNotFound.Error(), err.Error()))
+		default:
+			c.JSON(http.StatusInternalServerError, domain.ErrorResponse("failed to delete sso connection", err.Error()))
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, domain.SuccessResponse("sso connection deleted", nil))
+}
@@ -0,0 +1,147 @@
+package handler
+
+import (
+	"gojwt-rest-api/internal/domain"
+	"gojwt-rest-api/internal/middleware"
+	"gojwt-rest-api/internal/service"
+	"gojwt-rest-api/pkg/validator"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// TwoFactorHandler handles two-factor enrollment and login verification requests
+type TwoFactorHandler struct {
+	twoFactorService service.TwoFactorService
+	userService      service.UserService
+	validator        *validator.Validator
+}
+
+// NewTwoFactorHandler creates a new two-factor handler
+func NewTwoFactorHandler(twoFactorService service.TwoFactorService, userService service.UserService, validator *validator.Validator) *TwoFactorHandler {
+	return &TwoFactorHandler{
+		twoFactorService: twoFactorService,
+		userService:      userService,
+		validator:        validator,
+	}
+}
+
+// BeginEnrollment generates a TOTP secret for the authenticated user
+func (h *TwoFactorHandler) BeginEnrollment(c *gin.Context) {
+	userID, exists := middleware.GetUserID(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, domain.ErrorResponse("user not authenticated", nil))
+		return
+	}
+
+	enrollment, err := h.twoFactorService.BeginEnrollment(userID)
+	if err != nil {
+		switch err {
+		case domain.ErrUserNotFound:
+			c.JSON(http.StatusNotFound, domain.ErrorResponse(domain.ErrUserNotFound.Error(), nil))
+		case domain.ErrTwoFactorAlreadyEnrolled:
+			c.JSON(http.StatusConflict, domain.ErrorResponse(domain.ErrTwoFactorAlreadyEnrolled.Error(), nil))
+		default:
+			c.JSON(http.StatusInternalServerError, domain.ErrorResponse("failed to start two-factor enrollment", err.Error()))
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, domain.SuccessResponse("two-factor enrollment started", enrollment))
+}
+
+// ConfirmEnrollment activates two-factor authentication with a code of the new
+// secret. Users logging in with an enrollment token receive a session.
+func (h *TwoFactorHandler) ConfirmEnrollment(c *gin.Context) {
+	claims, exists := middleware.GetClaims(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, domain.ErrorResponse("user not authenticated", nil))
+		return
+	}
+
+	req, ok := h.bindCode(c)
+	if !ok {
+		return
+	}
+
+	if err := h.twoFactorService.ConfirmEnrollment(claims.UserID, req.Code); err != nil {
+		twoFactorError(c, err, "failed to confirm two-factor enrollment")
+		return
+	}
+
+	if claims.Scope != domain.ScopeTwoFactorEnroll {
+		c.JSON(http.StatusOK, domain.SuccessResponse("two-factor authentication enabled", nil))
+		return
+	}
+	h.issueSession(c, claims.UserID, claims.ClientID)
+}
+
+// VerifyLogin completes a login requiring a second factor
+func (h *TwoFactorHandler) VerifyLogin(c *gin.Context) {
+	claims, exists := middleware.GetClaims(c)
+	if !exists || claims.Scope != domain.ScopeTwoFactorVerify {
+		c.JSON(http.StatusUnauthorized, domain.ErrorResponse("two-factor login token required", nil))
+		return
+	}
+
+	req, ok := h.bindCode(c)
+	if !ok {
+		return
+	}
+
+	if err := h.twoFactorService.Verify(claims.UserID, req.Code); err != nil {
+		twoFactorError(c, err, domain.ErrLoginFailed.Error())
+		return
+	}
+	h.issueSession(c, claims.UserID, claims.ClientID)
+}
+
+// bindCode binds and validates the TOTP code of a request
+func (h *TwoFactorHandler) bindCode(c *gin.Context) (*domain.TwoFactorCodeRequest, bool) {
+	return Bind[domain.TwoFactorCodeRequest](c, h.validator)
+}
+
+// issueSession issues the session of a user who completed the second factor,
+// for the client application the login was started from
+func (h *TwoFactorHandler) issueSession(c *gin.Context, userID uint, clientID string) {
+	user, err := h.userService.GetUserByID(userID)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, domain.ErrorResponse(domain.ErrUserNotFound.Error(), nil))
+		return
+	}
+
+	response, err := h.userService.IssueSession(user, clientID)
+	if err != nil {
+		switch err {
+		case domain.ErrUserDeactivated, domain.ErrAccountLocked:
+			c.JSON(http.StatusForbidden, domain.ErrorResponse(err.Error(), nil))
+		case domain.ErrOutsideAccessSchedule:
+			c.JSON(http.StatusForbidden, domain.ErrorResponse(err.Error(), gin.H{"code": domain.ErrorCodeOutsideAccessSchedule}))
+		case domain.ErrSessionQuotaExceeded:
+			c.JSON(http.StatusTooManyRequests, domain.ErrorResponse(domain.ErrSessionQuotaExceeded.Error(), nil))
+		case domain.ErrUnknownClient, domain.ErrClientIDRequired:
+			c.JSON(http.StatusBadRequest, domain.ErrorResponse(err.Error(), nil))
+		default:
+			c.JSON(http.StatusInternalServerError, domain.ErrorResponse(domain.ErrLoginFailed.Error(), err.Error()))
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, domain.SuccessResponse("login successful", response))
+}
+
+// twoFactorError maps two-factor errors to responses
+func twoFactorError(c *gin.Context, err error, message string) {
+	switch err {
+	case domain.ErrInvalidTwoFactorCode:
+		c.JSON(http.StatusUnauthorized, domain.ErrorResponse(err.Error(), nil))
+	case domain.ErrTooManyTwoFactorAttempts:
+		c.JSON(http.StatusTooManyRequests, domain.ErrorResponse(err.Error(), nil))
+	case domain.ErrTwoFactorNotEnrolled:
+		c.JSON(http.StatusBadRequest, domain.ErrorResponse(err.Error(), nil))
+	case domain.ErrTwoFactorAlreadyEnrolled:
+		c.JSON(http.StatusConflict, domain.ErrorResponse(err.Error(), nil))
+	default:
+		c.JSON(http.StatusInternalServerError, domain.ErrorResponse(message, err.Error()))
+	}
+}
@@ -0,0 +1,49 @@
+package handler
+
+import (
+	"gojwt-rest-api/internal/domain"
+	"gojwt-rest-api/internal/middleware"
+	"gojwt-rest-api/internal/service"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// UserDetailsHandler handles admin user detail requests
+type UserDetailsHandler struct {
+	detailsService service.UserDetailsService
+	userService    service.UserService
+}
+
+// NewUserDetailsHandler creates a new user details handler
+func NewUserDetailsHandler(detailsService service.UserDetailsService, userService service.UserService) *UserDetailsHandler {
+	return &UserDetailsHandler{detailsService: detailsService, userService: userService}
+}
+
+// GetUserDetails returns a user with sessions, sign-in methods and, to
+// callers allowed to read the audit log, recent audit events
+func (h *UserDetailsHandler) GetUserDetails(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, domain.ErrorResponse("invalid user ID", err.Error()))
+		return
+	}
+	withAudit, ok := middleware.CallerHasAdminScope(c, h.userService, domain.ScopeAdminAuditRead)
+	if !ok {
+		return
+	}
+
+	details, err := h.detailsService.WithContext(c.Request.Context()).GetUserDetails(uint(id), withAudit)
+	if err != nil {
+		switch err {
+		case domain.ErrUserNotFound:
+			c.JSON(http.StatusNotFound, domain.ErrorResponse(domain.ErrUserNotFound.Error(), nil))
+		default:
+			c.JSON(http.StatusInternalServerError, domain.ErrorResponse("failed to get user details", err.Error()))
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, domain.SuccessResponse("user details retrieved", details))
+}
@@ -1,6 +1,7 @@
 package handler
 
 import (
+	"errors"
 	"gojwt-rest-api/internal/domain"
 	"gojwt-rest-api/internal/middleware"
 	"gojwt-rest-api/internal/service"
@@ -8,6 +9,7 @@ import (
 	"math"
 	"net/http"
 	"strconv"
+	"strings"
 
 	"github.com/gin-gonic/gin"
 )
@@ -26,6 +28,12 @@ func NewUserHandler(userService service.UserService, validator *validator.Valida
 	}
 }
 
+// users returns the user service scoped to the organization of the request,
+// so that admins of an organization only see and change its users
+func (h *UserHandler) users(c *gin.Context) service.UserService {
+	return h.userService.WithContext(c.Request.Context())
+}
+
 // GetProfile gets current user profile
 func (h *UserHandler) GetProfile(c *gin.Context) {
 	userID, exists := middleware.GetUserID(c)
@@ -45,7 +53,7 @@ func (h *UserHandler) GetProfile(c *gin.Context) {
 		return
 	}
 
-	c.JSON(http.StatusOK, domain.SuccessResponse("user profile retrieved", user.ToResponse()))
+	c.JSON(http.StatusOK, domain.SuccessResponse("user profile retrieved", user.ToProfileResponse()))
 }
 
 // GetUserByID gets a user by ID
@@ -56,7 +64,7 @@ func (h *UserHandler) GetUserByID(c *gin.Context) {
 		return
 	}
 
-	user, err := h.userService.GetUserByID(uint(id))
+	user, err := h.users(c).GetUserByID(uint(id))
 	if err != nil {
 		switch err {
 		case domain.ErrUserNotFound:
@@ -90,8 +98,13 @@ func (h *UserHandler) GetAllUsers(c *gin.Context) {
 	pagination.Page = page
 	pagination.PageSize = pageSize
 	pagination.Search = search
+	pagination.Sort = c.Query("sort")
 
-	users, total, err := h.userService.GetAllUsers(&pagination)
+	users, total, err := h.users(c).GetAllUsers(&pagination)
+	if errors.Is(err, domain.ErrInvalidSort) {
+		c.JSON(http.StatusBadRequest, domain.ErrorResponse(domain.ErrInvalidSort.Error(), err.Error()))
+		return
+	}
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, domain.ErrorResponse("failed to retrieve users", err.Error()))
 		return
@@ -117,6 +130,27 @@ func (h *UserHandler) GetAllUsers(c *gin.Context) {
 	c.JSON(http.StatusOK, domain.SuccessResponse("users retrieved", response))
 }
 
+// SuggestUsers looks up users by name or email prefix for search-as-you-type
+func (h *UserHandler) SuggestUsers(c *gin.Context) {
+	query := c.Query("q")
+	if strings.TrimSpace(query) == "" {
+		c.JSON(http.StatusBadRequest, domain.ErrorResponse("query parameter q is required", nil))
+		return
+	}
+	if len(query) > 100 {
+		c.JSON(http.StatusBadRequest, domain.ErrorResponse("query parameter q is too long", nil))
+		return
+	}
+
+	suggestions, err := h.users(c).SuggestUsers(query)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, domain.ErrorResponse("failed to suggest users", err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, domain.SuccessResponse("users suggested", suggestions))
+}
+
 // UpdateUser updates a user
 func (h *UserHandler) UpdateUser(c *gin.Context) {
 	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
@@ -125,26 +159,25 @@ func (h *UserHandler) UpdateUser(c *gin.Context) {
 		return
 	}
 
-	var req domain.UpdateUserRequest
-
-	// Bind JSON
-	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, domain.ErrorResponse(domain.ErrInvalidRequest.Error(), err))
+	req, ok := Bind[domain.UpdateUserRequest](c, h.validator)
+	if !ok {
 		return
 	}
 
-	// Validate request
-	if validationErrors := h.validator.Validate(&req); len(validationErrors) > 0 {
-		c.JSON(http.StatusBadRequest, domain.ErrorResponse(domain.ErrValidationFailed.Error(), validationErrors))
-		return
-	}
+	actorID, _ := middleware.GetUserID(c)
 
 	// Update user
-	user, err := h.userService.UpdateUser(uint(id), &req)
+	user, err := h.users(c).UpdateUser(actorID, uint(id), req)
 	if err != nil {
 		switch err {
+		case domain.ErrAdminRoleRequired:
+			c.JSON(http.StatusForbidden, domain.ErrorResponse(domain.ErrAdminRoleRequired.Error(), nil))
+		case domain.ErrLastAdmin:
+			c.JSON(http.StatusConflict, domain.ErrorResponse(domain.ErrLastAdmin.Error(), nil))
 		case domain.ErrEmailAlreadyInUse:
 			c.JSON(http.StatusConflict, domain.ErrorResponse(domain.ErrEmailAlreadyInUse.Error(), err.Error()))
+		case domain.ErrEmailDomainNotAllowed:
+			c.JSON(http.StatusBadRequest, domain.ErrorResponse(domain.ErrEmailDomainNotAllowed.Error(), nil))
 		case domain.ErrUserNotFound:
 			c.JSON(http.StatusNotFound, domain.ErrorResponse(domain.ErrUserNotFound.Error(), err.Error()))
 		default:
@@ -164,8 +197,16 @@ func (h *UserHandler) DeleteUser(c *gin.Context) {
 		return
 	}
 
-	if err := h.userService.DeleteUser(uint(id)); err != nil {
+	actorID, _ := middleware.GetUserID(c)
+
+	if err := h.users(c).DeleteUser(actorID, uint(id)); err != nil {
 		switch err {
+		case domain.ErrCannotDeleteSelf:
+			c.JSON(http.StatusConflict, domain.ErrorResponse(domain.ErrCannotDeleteSelf.Error(), nil))
+		case domain.ErrAdminRoleRequired:
+			c.JSON(http.StatusForbidden, domain.ErrorResponse(domain.ErrAdminRoleRequired.Error(), nil))
+		case domain.ErrLastAdmin:
+			c.JSON(http.StatusConflict, domain.ErrorResponse(domain.ErrLastAdmin.Error(), nil))
 		case domain.ErrUserNotFound:
 			c.JSON(http.StatusNotFound, domain.ErrorResponse(domain.ErrUserNotFound.Error(), err.Error()))
 		default:
@@ -176,3 +217,56 @@ func (h *UserHandler) DeleteUser(c *gin.Context) {
 
 	c.JSON(http.StatusOK, domain.SuccessResponse("user deleted successfully", nil))
 }
+
+// UpdateAdminScopes replaces the delegated admin scopes of a user
+func (h *UserHandler) UpdateAdminScopes(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, domain.ErrorResponse("invalid user ID", err.Error()))
+		return
+	}
+
+	req, ok := Bind[domain.UpdateAdminScopesRequest](c, h.validator)
+	if !ok {
+		return
+	}
+
+	user, err := h.users(c).UpdateAdminScopes(uint(id), req.Scopes)
+	if err != nil {
+		switch err {
+		case domain.ErrUserNotFound:
+			c.JSON(http.StatusNotFound, domain.ErrorResponse(domain.ErrUserNotFound.Error(), nil))
+		default:
+			c.JSON(http.StatusInternalServerError, domain.ErrorResponse(domain.ErrFailedToUpdateUser.Error(), err.Error()))
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, domain.SuccessResponse("admin scopes updated", user.ToResponse()))
+}
+
+// RevokeClientSessions logs out the sessions of a client application, e.g. of
+// a compromised app version, of every user or of the user_id query parameter
+func (h *UserHandler) RevokeClientSessions(c *gin.Context) {
+	clientID := c.Param("client_id")
+	var userID *uint
+	if raw := c.Query("user_id"); raw != "" {
+		id, err := strconv.ParseUint(raw, 10, 32)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, domain.ErrorResponse("invalid user ID", err.Error()))
+			return
+		}
+		uid := uint(id)
+		userID = &uid
+	}
+
+	actorID, _ := middleware.GetUserID(c)
+
+	revocation, err := h.users(c).RevokeClientSessions(actorID, userID, clientID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, domain.ErrorResponse("failed to revoke client sessions", err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, domain.SuccessResponse("sessions of the client have been revoked", revocation))
+}
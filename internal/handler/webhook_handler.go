@@ -0,0 +1,118 @@
+package handler
+
+import (
+	"gojwt-rest-api/internal/domain"
+	"gojwt-rest-api/internal/service"
+	"math"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// WebhookHandler handles webhook delivery requests
+type WebhookHandler struct {
+	webhookService service.WebhookService
+}
+
+// NewWebhookHandler creates a new webhook handler
+func NewWebhookHandler(webhookService service.WebhookService) *WebhookHandler {
+	return &WebhookHandler{webhookService: webhookService}
+}
+
+// ListDeliveries lists webhook deliveries, filtered by event_type and status (failed or succeeded)
+func (h *WebhookHandler) ListDeliveries(c *gin.Context) {
+	var pagination domain.PaginationQuery
+
+	page, err := strconv.Atoi(c.DefaultQuery("page", "1"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, domain.ErrorResponse("invalid page parameter", err.Error()))
+		return
+	}
+	pageSize, err := strconv.Atoi(c.DefaultQuery("page_size", "10"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, domain.ErrorResponse("invalid page_size parameter", err.Error()))
+		return
+	}
+	pagination.Page = page
+	pagination.PageSize = pageSize
+
+	filter := domain.WebhookDeliveryFilter{EventType: c.Query("event_type")}
+	switch status := c.Query("status"); status {
+	case "":
+	case "failed", "succeeded":
+		success := status == "succeeded"
+		filter.Success = &success
+	default:
+		c.JSON(http.StatusBadRequest, domain.ErrorResponse("invalid status parameter", "status must be failed or succeeded"))
+		return
+	}
+
+	deliveries, total, err := h.webhookService.ListDeliveries(&filter, &pagination)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, domain.ErrorResponse("failed to retrieve webhook deliveries", err.Error()))
+		return
+	}
+
+	responses := make([]*domain.WebhookDeliveryResponse, len(deliveries))
+	for i, delivery := range deliveries {
+		responses[i] = delivery.ToResponse()
+	}
+
+	response := domain.PaginatedResponse{
+		Data:       responses,
+		Page:       pagination.Page,
+		PageSize:   pagination.PageSize,
+		TotalItems: total,
+		TotalPages: int(math.Ceil(float64(total) / float64(pagination.PageSize))),
+	}
+
+	c.JSON(http.StatusOK, domain.SuccessResponse("webhook deliveries retrieved", response))
+}
+
+// GetDelivery gets a webhook delivery, including its payload
+func (h *WebhookHandler) GetDelivery(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, domain.ErrorResponse("invalid delivery ID", err.Error()))
+		return
+	}
+
+	delivery, err := h.webhookService.GetDelivery(uint(id))
+	if err != nil {
+		switch err {
+		case domain.ErrWebhookDeliveryNotFound:
+			c.JSON(http.StatusNotFound, domain.ErrorResponse(domain.ErrWebhookDeliveryNotFound.Error(), err.Error()))
+		default:
+			c.JSON(http.StatusInternalServerError, domain.ErrorResponse("failed to retrieve webhook delivery", err.Error()))
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, domain.SuccessResponse("webhook delivery retrieved", delivery.ToResponse()))
+}
+
+// ReplayDelivery resends the payload of a webhook delivery
+func (h *WebhookHandler) ReplayDelivery(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, domain.ErrorResponse("invalid delivery ID", err.Error()))
+		return
+	}
+
+	delivery, err := h.webhookService.Replay(uint(id))
+	if err != nil {
+		switch err {
+		case domain.ErrWebhookDeliveryNotFound:
+			c.JSON(http.StatusNotFound, domain.ErrorResponse(domain.ErrWebhookDeliveryNotFound.Error(), err.Error()))
+		case domain.ErrWebhookEndpointRemoved:
+			c.JSON(http.StatusConflict, domain.ErrorResponse(domain.ErrWebhookEndpointRemoved.Error(), err.Error()))
+		default:
+			c.JSON(http.StatusInternalServerError, domain.ErrorResponse("failed to replay webhook delivery", err.Error()))
+		}
+		return
+	}
+
+	// The replay itself may have failed, which the delivery describes
+	c.JSON(http.StatusOK, domain.SuccessResponse("webhook delivery replayed", delivery.ToResponse()))
+}
@@ -0,0 +1,77 @@
+package kms
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"gojwt-rest-api/internal/cloudauth"
+	"gojwt-rest-api/internal/config"
+	"net/http"
+	"time"
+)
+
+// AWSDecrypter decrypts with AWS KMS through its JSON API, signing requests
+// with Signature Version 4
+type AWSDecrypter struct {
+	endpoint string
+	signer   cloudauth.AWSSigner
+	client   *http.Client
+}
+
+// NewAWSDecrypter creates an AWS KMS decrypter
+func NewAWSDecrypter(cfg config.KMSConfig, client *http.Client) *AWSDecrypter {
+	endpoint := cfg.Endpoint
+	if endpoint == "" {
+		endpoint = "https://kms." + cfg.AWSRegion + ".amazonaws.com/"
+	}
+	return &AWSDecrypter{
+		endpoint: endpoint,
+		signer: cloudauth.AWSSigner{
+			AccessKeyID:     cfg.AWSAccessKeyID,
+			SecretAccessKey: cfg.AWSSecretAccessKey,
+			SessionToken:    cfg.AWSSessionToken,
+			Region:          cfg.AWSRegion,
+			Service:         "kms",
+		},
+		client: client,
+	}
+}
+
+// Decrypt decrypts a ciphertext blob produced by KMS Encrypt. Symmetric
+// ciphertexts identify their key, so no key ID is sent.
+func (d *AWSDecrypter) Decrypt(ctx context.Context, ciphertext []byte) ([]byte, error) {
+	body, err := json.Marshal(map[string]string{
+		"CiphertextBlob": base64.StdEncoding.EncodeToString(ciphertext),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, d.endpoint, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-amz-json-1.1")
+	req.Header.Set("X-Amz-Target", "TrentService.Decrypt")
+	d.signer.Sign(req, body, time.Now())
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("aws kms responded with status %d", resp.StatusCode)
+	}
+
+	var out struct {
+		Plaintext string `json:"Plaintext"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+		return nil, err
+	}
+	return base64.StdEncoding.DecodeString(out.Plaintext)
+}
@@ -0,0 +1,81 @@
+package kms
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"gojwt-rest-api/internal/cloudauth"
+	"gojwt-rest-api/internal/config"
+	"net/http"
+	"strings"
+)
+
+// GCPDecrypter decrypts with Google Cloud KMS through its REST API,
+// authenticating as the service account of the instance
+type GCPDecrypter struct {
+	keyName      string
+	endpoint     string
+	metadataHost string
+	client       *http.Client
+}
+
+// NewGCPDecrypter creates a Google Cloud KMS decrypter
+func NewGCPDecrypter(cfg config.KMSConfig, client *http.Client) *GCPDecrypter {
+	endpoint := cfg.Endpoint
+	if endpoint == "" {
+		endpoint = "https://cloudkms.googleapis.com"
+	}
+	return &GCPDecrypter{
+		keyName:      cfg.KeyName,
+		endpoint:     strings.TrimSuffix(endpoint, "/"),
+		metadataHost: cfg.GCPMetadataHost,
+		client:       client,
+	}
+}
+
+// Decrypt decrypts a ciphertext with the configured crypto key
+func (d *GCPDecrypter) Decrypt(ctx context.Context, ciphertext []byte) ([]byte, error) {
+	token, err := cloudauth.GCPAccessToken(ctx, d.client, d.metadataHost)
+	if err != nil {
+		return nil, err
+	}
+
+	body, err := json.Marshal(map[string]string{
+		"ciphertext": base64.StdEncoding.EncodeToString(ciphertext),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	url := d.endpoint + "/v1/" + d.keyName + ":decrypt"
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	var out struct {
+		Plaintext string `json:"plaintext"`
+	}
+	if err := d.do(req, &out); err != nil {
+		return nil, err
+	}
+	return base64.StdEncoding.DecodeString(out.Plaintext)
+}
+
+// do sends req and decodes the JSON response into out
+func (d *GCPDecrypter) do(req *http.Request, out interface{}) error {
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("gcp kms responded with status %d", resp.StatusCode)
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
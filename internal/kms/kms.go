@@ -0,0 +1,75 @@
+// Package kms decrypts secrets with a cloud key management service, so that
+// signing and encryption keys are only stored encrypted ("envelope encryption").
+package kms
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"gojwt-rest-api/internal/config"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// EncryptedPrefix marks configuration secrets encrypted with the KMS key,
+// e.g. JWT_SECRET=kms:<base64 ciphertext>
+const EncryptedPrefix = "kms:"
+
+// requestTimeout bounds every call to the key management service
+const requestTimeout = 10 * time.Second
+
+// Decrypter decrypts data encrypted with a KMS key
+type Decrypter interface {
+	Decrypt(ctx context.Context, ciphertext []byte) ([]byte, error)
+}
+
+// New creates the decrypter of the configured provider, or nil when no
+// provider is configured
+func New(cfg config.KMSConfig) (Decrypter, error) {
+	client := &http.Client{Timeout: requestTimeout}
+
+	switch cfg.Provider {
+	case "":
+		return nil, nil
+	case config.KMSProviderAWS:
+		return NewAWSDecrypter(cfg, client), nil
+	case config.KMSProviderGCP:
+		return NewGCPDecrypter(cfg, client), nil
+	default:
+		return nil, fmt.Errorf("unsupported KMS provider: %s", cfg.Provider)
+	}
+}
+
+// ResolveSecrets replaces the secrets of cfg prefixed with EncryptedPrefix
+// by their plaintext. decrypter may be nil when no secret is encrypted.
+func ResolveSecrets(ctx context.Context, cfg *config.Config, decrypter Decrypter) error {
+	secrets := []*string{&cfg.JWT.Secret, &cfg.PII.EncryptionKey, &cfg.PII.BlindIndexKey}
+	for i := range cfg.JWT.StandbySecrets {
+		secrets = append(secrets, &cfg.JWT.StandbySecrets[i])
+	}
+	for i := range cfg.PII.PreviousKeys {
+		secrets = append(secrets, &cfg.PII.PreviousKeys[i])
+	}
+
+	for _, secret := range secrets {
+		if !strings.HasPrefix(*secret, EncryptedPrefix) {
+			continue
+		}
+		if decrypter == nil {
+			return errors.New("KMS_PROVIDER is required to decrypt kms: secrets")
+		}
+
+		ciphertext, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(*secret, EncryptedPrefix))
+		if err != nil {
+			return fmt.Errorf("invalid encrypted secret: %w", err)
+		}
+		plaintext, err := decrypter.Decrypt(ctx, ciphertext)
+		if err != nil {
+			return fmt.Errorf("failed to decrypt secret: %w", err)
+		}
+		*secret = string(plaintext)
+	}
+	return nil
+}
@@ -0,0 +1,56 @@
+package lifecycle
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+// Drainer coordinates zero-downtime shutdown.
+// Once draining starts the readiness check fails so load balancers stop
+// routing new traffic, while requests already in flight are allowed to finish.
+type Drainer struct {
+	inFlight atomic.Int64
+	draining atomic.Bool
+	once     sync.Once
+	done     chan struct{}
+}
+
+// NewDrainer creates a new drainer
+func NewDrainer() *Drainer {
+	return &Drainer{
+		done: make(chan struct{}),
+	}
+}
+
+// Start begins draining. It is safe to call multiple times.
+func (d *Drainer) Start() {
+	d.once.Do(func() {
+		d.draining.Store(true)
+		close(d.done)
+	})
+}
+
+// Draining returns a channel that is closed once draining has started
+func (d *Drainer) Draining() <-chan struct{} {
+	return d.done
+}
+
+// IsReady reports whether the server should receive new traffic
+func (d *Drainer) IsReady() bool {
+	return !d.draining.Load()
+}
+
+// Begin records the start of a request
+func (d *Drainer) Begin() {
+	d.inFlight.Add(1)
+}
+
+// End records the completion of a request
+func (d *Drainer) End() {
+	d.inFlight.Add(-1)
+}
+
+// InFlight returns the number of requests currently being served
+func (d *Drainer) InFlight() int64 {
+	return d.inFlight.Load()
+}
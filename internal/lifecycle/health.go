@@ -0,0 +1,181 @@
+package lifecycle
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// defaultHealthCheckTimeout bounds a readiness check when no timeout is configured
+const defaultHealthCheckTimeout = 2 * time.Second
+
+// Health check statuses
+const (
+	HealthStatusUp   = "up"
+	HealthStatusDown = "down"
+)
+
+// HealthChecker is a dependency of the server, such as the database, whose
+// health decides whether the server is ready for traffic
+type HealthChecker interface {
+	CheckHealth(ctx context.Context) error
+}
+
+// HealthCheckFunc adapts a function to HealthChecker
+type HealthCheckFunc func(ctx context.Context) error
+
+// CheckHealth calls f
+func (f HealthCheckFunc) CheckHealth(ctx context.Context) error {
+	return f(ctx)
+}
+
+// HealthObserver is notified of registered dependencies and their checks,
+// e.g. to record metrics
+type HealthObserver interface {
+	DependencyRegistered(name string, critical bool)
+	DependencyChecked(name string, elapsed time.Duration, err error)
+}
+
+// HealthResult is the outcome of checking one dependency
+type HealthResult struct {
+	Name     string `json:"name"`
+	Status   string `json:"status"`
+	Critical bool   `json:"critical"`
+	// LatencyMS is how long the check took, in milliseconds
+	LatencyMS int64  `json:"latency_ms"`
+	Error     string `json:"error,omitempty"`
+}
+
+// HealthReport is the outcome of checking every registered dependency
+type HealthReport struct {
+	// Healthy is false when a critical dependency is down
+	Healthy bool           `json:"healthy"`
+	Checks  []HealthResult `json:"checks"`
+}
+
+// HealthOption configures a HealthRegistry
+type HealthOption func(*HealthRegistry)
+
+// WithHealthObserver notifies observer of every registered dependency and check
+func WithHealthObserver(observer HealthObserver) HealthOption {
+	return func(r *HealthRegistry) {
+		r.observer = observer
+	}
+}
+
+// WithHealthCheckTimeout bounds every check to timeout
+func WithHealthCheckTimeout(timeout time.Duration) HealthOption {
+	return func(r *HealthRegistry) {
+		if timeout > 0 {
+			r.timeout = timeout
+		}
+	}
+}
+
+// healthCheck is a registered dependency
+type healthCheck struct {
+	name     string
+	checker  HealthChecker
+	critical bool
+}
+
+// HealthRegistry holds the dependencies subsystems register at startup and
+// checks them together for the readiness endpoint
+type HealthRegistry struct {
+	mu       sync.RWMutex
+	checks   []healthCheck
+	timeout  time.Duration
+	observer HealthObserver
+}
+
+// NewHealthRegistry creates an empty health registry
+func NewHealthRegistry(opts ...HealthOption) *HealthRegistry {
+	r := &HealthRegistry{timeout: defaultHealthCheckTimeout}
+	for _, opt := range opts {
+		opt(r)
+	}
+	return r
+}
+
+// Register adds a critical dependency: the server is not ready while it is down
+func (r *HealthRegistry) Register(name string, checker HealthChecker) {
+	r.register(healthCheck{name: name, checker: checker, critical: true})
+}
+
+// RegisterOptional adds a dependency whose failure is reported without
+// failing readiness, for subsystems the server degrades without, such as
+// the mailer
+func (r *HealthRegistry) RegisterOptional(name string, checker HealthChecker) {
+	r.register(healthCheck{name: name, checker: checker})
+}
+
+func (r *HealthRegistry) register(check healthCheck) {
+	r.mu.Lock()
+	r.checks = append(r.checks, check)
+	r.mu.Unlock()
+
+	if r.observer != nil {
+		r.observer.DependencyRegistered(check.name, check.critical)
+	}
+}
+
+// Check checks every dependency concurrently, each bounded by the timeout,
+// and reports them in registration order
+func (r *HealthRegistry) Check(ctx context.Context) *HealthReport {
+	r.mu.RLock()
+	checks := append([]healthCheck(nil), r.checks...)
+	r.mu.RUnlock()
+
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	report := &HealthReport{Healthy: true, Checks: make([]HealthResult, len(checks))}
+	var wg sync.WaitGroup
+	for i, check := range checks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			report.Checks[i] = r.run(ctx, check)
+		}()
+	}
+	wg.Wait()
+
+	for _, result := range report.Checks {
+		if result.Critical && result.Status == HealthStatusDown {
+			report.Healthy = false
+		}
+	}
+	return report
+}
+
+// run checks a dependency, failing it when it outlives ctx
+func (r *HealthRegistry) run(ctx context.Context, check healthCheck) HealthResult {
+	start := time.Now()
+	done := make(chan error, 1)
+	go func() {
+		done <- check.checker.CheckHealth(ctx)
+	}()
+
+	var err error
+	select {
+	case err = <-done:
+	case <-ctx.Done():
+		err = ctx.Err()
+	}
+	elapsed := time.Since(start)
+	if r.observer != nil {
+		r.observer.DependencyChecked(check.name, elapsed, err)
+	}
+
+	result := HealthResult{
+		Name:      check.name,
+		Status:    HealthStatusUp,
+		Critical:  check.critical,
+		LatencyMS: elapsed.Milliseconds(),
+	}
+	if err != nil {
+		result.Status = HealthStatusDown
+		result.Error = err.Error()
+	}
+	return result
+}
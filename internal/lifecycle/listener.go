@@ -0,0 +1,53 @@
+package lifecycle
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"strings"
+)
+
+const (
+	unixScheme = "unix://"
+	tcpScheme  = "tcp://"
+)
+
+// Listen creates a listener for address, which is either "unix:///path/to.sock"
+// or a TCP address ("host:port", optionally prefixed with "tcp://").
+// Unix sockets are created with socketMode permissions so a local reverse
+// proxy running as another user can connect.
+func Listen(address string, socketMode os.FileMode) (net.Listener, error) {
+	if path, ok := strings.CutPrefix(address, unixScheme); ok {
+		return listenUnix(path, socketMode)
+	}
+
+	return net.Listen("tcp", strings.TrimPrefix(address, tcpScheme))
+}
+
+// listenUnix listens on a Unix domain socket, removing a stale socket left by a previous run
+func listenUnix(path string, socketMode os.FileMode) (net.Listener, error) {
+	if path == "" {
+		return nil, fmt.Errorf("unix socket path is required")
+	}
+
+	if info, err := os.Stat(path); err == nil {
+		if info.Mode()&os.ModeSocket == 0 {
+			return nil, fmt.Errorf("%s exists and is not a socket", path)
+		}
+		if err := os.Remove(path); err != nil {
+			return nil, fmt.Errorf("failed to remove stale socket: %w", err)
+		}
+	}
+
+	listener, err := net.Listen("unix", path)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := os.Chmod(path, socketMode); err != nil {
+		_ = listener.Close()
+		return nil, fmt.Errorf("failed to set socket permissions: %w", err)
+	}
+
+	return listener, nil
+}
@@ -0,0 +1,202 @@
+// Package loadgen fills the database with synthetic users with known
+// credentials, and optionally active sessions, for performance tests.
+package loadgen
+
+import (
+	"context"
+	"fmt"
+	"gojwt-rest-api/internal/domain"
+	"gojwt-rest-api/internal/repository"
+	"gojwt-rest-api/internal/utils"
+	"runtime"
+	"sync"
+)
+
+// Defaults of Options
+const (
+	DefaultEmailPrefix = "loadtest"
+	DefaultEmailDomain = "loadtest.example.com"
+	DefaultPassword    = "LoadTest-123!"
+	DefaultBatchSize   = 500
+)
+
+// Options describes the users to generate
+type Options struct {
+	// Count is the number of users to create
+	Count int
+	// Start is the sequence number of the first user, so several runs can
+	// add users without email collisions
+	Start int
+	// Emails are <EmailPrefix>-<n>@<EmailDomain>
+	EmailPrefix string
+	EmailDomain string
+	// Password is the password of every user
+	Password string
+	// BatchSize is the number of users per insert statement
+	BatchSize int
+	// Workers is the number of passwords hashed in parallel
+	Workers int
+	// Sessions issues an access and refresh token for each user
+	Sessions bool
+	// ClientID is the client application sessions are issued for
+	ClientID string
+}
+
+// Credential is a generated user with its password and, with sessions, its tokens
+type Credential struct {
+	UserID       uint
+	Email        string
+	Password     string
+	AccessToken  string
+	RefreshToken string
+}
+
+// SessionIssuer issues the session of a user logging in, e.g. service.UserService
+type SessionIssuer interface {
+	IssueSession(user *domain.User, clientID string) (*domain.LoginResponse, error)
+}
+
+// Generator creates synthetic users
+type Generator struct {
+	userRepo repository.UserRepository
+	sessions SessionIssuer
+}
+
+// NewGenerator creates a generator issuing sessions through sessions, so that
+// they carry the same claims as sessions of users logging in
+func NewGenerator(userRepo repository.UserRepository, sessions SessionIssuer) *Generator {
+	return &Generator{
+		userRepo: userRepo,
+		sessions: sessions,
+	}
+}
+
+// Generate creates opts.Count users batch by batch, passing each created
+// user to emit, and returns the number of users created
+func (g *Generator) Generate(ctx context.Context, opts Options, emit func(*Credential) error) (int, error) {
+	opts = withDefaults(opts)
+	if opts.Count <= 0 {
+		return 0, fmt.Errorf("count must be positive")
+	}
+	if opts.Sessions && g.sessions == nil {
+		return 0, fmt.Errorf("sessions require a session issuer")
+	}
+
+	created := 0
+	for created < opts.Count {
+		if err := ctx.Err(); err != nil {
+			return created, err
+		}
+
+		size := opts.BatchSize
+		if remaining := opts.Count - created; remaining < size {
+			size = remaining
+		}
+		credentials, err := g.createBatch(opts, opts.Start+created, size)
+		if err != nil {
+			return created, err
+		}
+		for _, credential := range credentials {
+			if err := emit(credential); err != nil {
+				return created, err
+			}
+		}
+		created += size
+	}
+	return created, nil
+}
+
+// createBatch creates the users numbered first to first+size-1
+func (g *Generator) createBatch(opts Options, first, size int) ([]*Credential, error) {
+	users := make([]*domain.User, size)
+	for i := range users {
+		users[i] = &domain.User{
+			Name:  fmt.Sprintf("Load Test %d", first+i),
+			Email: fmt.Sprintf("%s-%d@%s", opts.EmailPrefix, first+i, opts.EmailDomain),
+		}
+	}
+	if err := hashPasswords(users, opts.Password, opts.Workers); err != nil {
+		return nil, err
+	}
+	if err := g.userRepo.CreateBatch(users, opts.BatchSize); err != nil {
+		return nil, fmt.Errorf("create users: %w", err)
+	}
+
+	credentials := make([]*Credential, size)
+	for i, user := range users {
+		credentials[i] = &Credential{UserID: user.ID, Email: user.Email, Password: opts.Password}
+	}
+	if !opts.Sessions {
+		return credentials, nil
+	}
+
+	for i, user := range users {
+		session, err := g.sessions.IssueSession(user, opts.ClientID)
+		if err != nil {
+			return nil, fmt.Errorf("create session of %s: %w", user.Email, err)
+		}
+		credentials[i].AccessToken = session.AccessToken
+		credentials[i].RefreshToken = session.RefreshToken
+	}
+	return credentials, nil
+}
+
+// hashPasswords hashes password for every user with workers goroutines, as
+// bcrypt dominates the cost of generating users
+func hashPasswords(users []*domain.User, password string, workers int) error {
+	jobs := make(chan *domain.User)
+	errs := make(chan error, workers)
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for user := range jobs {
+				hashed, err := utils.HashPassword(password)
+				if err != nil {
+					select {
+					case errs <- err:
+					default:
+					}
+					continue
+				}
+				user.Password = hashed
+			}
+		}()
+	}
+	for _, user := range users {
+		jobs <- user
+	}
+	close(jobs)
+	wg.Wait()
+
+	select {
+	case <-errs:
+		return domain.ErrFailedToHashPassword
+	default:
+		return nil
+	}
+}
+
+// withDefaults fills in unset options
+func withDefaults(opts Options) Options {
+	if opts.Start <= 0 {
+		opts.Start = 1
+	}
+	if opts.EmailPrefix == "" {
+		opts.EmailPrefix = DefaultEmailPrefix
+	}
+	if opts.EmailDomain == "" {
+		opts.EmailDomain = DefaultEmailDomain
+	}
+	if opts.Password == "" {
+		opts.Password = DefaultPassword
+	}
+	if opts.BatchSize <= 0 {
+		opts.BatchSize = DefaultBatchSize
+	}
+	if opts.Workers <= 0 {
+		opts.Workers = runtime.NumCPU()
+	}
+	return opts
+}
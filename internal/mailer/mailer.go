@@ -0,0 +1,105 @@
+package mailer
+
+import (
+	"context"
+	"fmt"
+	"gojwt-rest-api/internal/config"
+	"gojwt-rest-api/pkg/logger"
+	"net"
+	"net/smtp"
+	"strings"
+)
+
+// Message represents an outgoing email
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// Mailer sends emails
+type Mailer interface {
+	Send(msg *Message) error
+}
+
+// New creates a mailer for the configured driver
+func New(cfg config.MailConfig, appLogger *logger.Logger) (Mailer, error) {
+	switch cfg.Driver {
+	case "", "log":
+		return NewLogMailer(appLogger), nil
+	case "smtp":
+		return NewSMTPMailer(cfg), nil
+	default:
+		return nil, fmt.Errorf("unsupported mail driver: %s", cfg.Driver)
+	}
+}
+
+// LogMailer writes emails to the application log instead of sending them (for development)
+type LogMailer struct {
+	logger *logger.Logger
+}
+
+// NewLogMailer creates a new log mailer
+func NewLogMailer(appLogger *logger.Logger) *LogMailer {
+	return &LogMailer{logger: appLogger}
+}
+
+// Send logs the email
+func (m *LogMailer) Send(msg *Message) error {
+	m.logger.Infof("Email to=%s subject=%q\n%s", msg.To, msg.Subject, msg.Body)
+	return nil
+}
+
+// SMTPMailer sends emails through an SMTP server
+type SMTPMailer struct {
+	host string
+	addr string
+	from string
+	auth smtp.Auth
+}
+
+// NewSMTPMailer creates a new SMTP mailer
+func NewSMTPMailer(cfg config.MailConfig) *SMTPMailer {
+	var auth smtp.Auth
+	if cfg.SMTPUser != "" {
+		auth = smtp.PlainAuth("", cfg.SMTPUser, cfg.SMTPPassword, cfg.SMTPHost)
+	}
+
+	return &SMTPMailer{
+		host: cfg.SMTPHost,
+		addr: fmt.Sprintf("%s:%s", cfg.SMTPHost, cfg.SMTPPort),
+		from: cfg.From,
+		auth: auth,
+	}
+}
+
+// Send sends the email
+func (m *SMTPMailer) Send(msg *Message) error {
+	var body strings.Builder
+	fmt.Fprintf(&body, "From: %s\r\n", m.from)
+	fmt.Fprintf(&body, "To: %s\r\n", msg.To)
+	fmt.Fprintf(&body, "Subject: %s\r\n", msg.Subject)
+	body.WriteString("MIME-Version: 1.0\r\n")
+	body.WriteString("Content-Type: text/plain; charset=\"utf-8\"\r\n\r\n")
+	body.WriteString(msg.Body)
+
+	return smtp.SendMail(m.addr, m.auth, m.from, []string{msg.To}, []byte(body.String()))
+}
+
+// CheckHealth checks that the SMTP server greets new connections
+func (m *SMTPMailer) CheckHealth(ctx context.Context) error {
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", m.addr)
+	if err != nil {
+		return err
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = conn.SetDeadline(deadline)
+	}
+	client, err := smtp.NewClient(conn, m.host)
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	return client.Quit()
+}
@@ -0,0 +1,103 @@
+package metrics
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"sync"
+	"sync/atomic"
+)
+
+// Counter is a monotonically increasing value
+type Counter struct {
+	bits atomic.Uint64
+}
+
+// Inc increments the counter by one
+func (c *Counter) Inc() {
+	c.Add(1)
+}
+
+// Add increments the counter by delta, which must not be negative
+func (c *Counter) Add(delta float64) {
+	for {
+		old := c.bits.Load()
+		updated := math.Float64bits(math.Float64frombits(old) + delta)
+		if c.bits.CompareAndSwap(old, updated) {
+			return
+		}
+	}
+}
+
+// Value returns the current counter value
+func (c *Counter) Value() float64 {
+	return math.Float64frombits(c.bits.Load())
+}
+
+// CounterVec is a family of counters partitioned by label values
+type CounterVec struct {
+	metricName string
+	help       string
+	labels     []string
+
+	mu       sync.RWMutex
+	counters map[string]*Counter
+	values   map[string][]string
+}
+
+// NewCounterVec creates and registers a new counter family
+func (r *Registry) NewCounterVec(name, help string, labels ...string) *CounterVec {
+	v := &CounterVec{
+		metricName: name,
+		help:       help,
+		labels:     labels,
+		counters:   make(map[string]*Counter),
+		values:     make(map[string][]string),
+	}
+	r.register(v)
+	return v
+}
+
+// WithLabelValues returns the counter for the given label values, creating it if needed
+func (v *CounterVec) WithLabelValues(values ...string) *Counter {
+	key := labelKey(values)
+
+	v.mu.RLock()
+	counter, exists := v.counters[key]
+	v.mu.RUnlock()
+	if exists {
+		return counter
+	}
+
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	if counter, exists = v.counters[key]; !exists {
+		counter = &Counter{}
+		v.counters[key] = counter
+		v.values[key] = append([]string(nil), values...)
+	}
+	return counter
+}
+
+func (v *CounterVec) name() string {
+	return v.metricName
+}
+
+func (v *CounterVec) write(w io.Writer) {
+	writeHeader(w, v.metricName, v.help, "counter")
+
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+
+	keys := make([]string, 0, len(v.counters))
+	for key := range v.counters {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		fmt.Fprintf(w, "%s%s %g\n", v.metricName, formatLabels(v.labels, v.values[key]), v.counters[key].Value())
+	}
+}
@@ -0,0 +1,113 @@
+package metrics
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"sync"
+	"sync/atomic"
+)
+
+// Gauge is a value that can go up and down
+type Gauge struct {
+	bits atomic.Uint64
+}
+
+// Set sets the gauge to value
+func (g *Gauge) Set(value float64) {
+	g.bits.Store(math.Float64bits(value))
+}
+
+// Add adds delta to the gauge
+func (g *Gauge) Add(delta float64) {
+	for {
+		old := g.bits.Load()
+		updated := math.Float64bits(math.Float64frombits(old) + delta)
+		if g.bits.CompareAndSwap(old, updated) {
+			return
+		}
+	}
+}
+
+// Inc increments the gauge by one
+func (g *Gauge) Inc() {
+	g.Add(1)
+}
+
+// Dec decrements the gauge by one
+func (g *Gauge) Dec() {
+	g.Add(-1)
+}
+
+// Value returns the current gauge value
+func (g *Gauge) Value() float64 {
+	return math.Float64frombits(g.bits.Load())
+}
+
+// GaugeVec is a family of gauges partitioned by label values
+type GaugeVec struct {
+	metricName string
+	help       string
+	labels     []string
+
+	mu     sync.RWMutex
+	gauges map[string]*Gauge
+	values map[string][]string
+}
+
+// NewGaugeVec creates and registers a new gauge family
+func (r *Registry) NewGaugeVec(name, help string, labels ...string) *GaugeVec {
+	v := &GaugeVec{
+		metricName: name,
+		help:       help,
+		labels:     labels,
+		gauges:     make(map[string]*Gauge),
+		values:     make(map[string][]string),
+	}
+	r.register(v)
+	return v
+}
+
+// WithLabelValues returns the gauge for the given label values, creating it if needed
+func (v *GaugeVec) WithLabelValues(values ...string) *Gauge {
+	key := labelKey(values)
+
+	v.mu.RLock()
+	gauge, exists := v.gauges[key]
+	v.mu.RUnlock()
+	if exists {
+		return gauge
+	}
+
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	if gauge, exists = v.gauges[key]; !exists {
+		gauge = &Gauge{}
+		v.gauges[key] = gauge
+		v.values[key] = append([]string(nil), values...)
+	}
+	return gauge
+}
+
+func (v *GaugeVec) name() string {
+	return v.metricName
+}
+
+func (v *GaugeVec) write(w io.Writer) {
+	writeHeader(w, v.metricName, v.help, "gauge")
+
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+
+	keys := make([]string, 0, len(v.gauges))
+	for key := range v.gauges {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		fmt.Fprintf(w, "%s%s %g\n", v.metricName, formatLabels(v.labels, v.values[key]), v.gauges[key].Value())
+	}
+}
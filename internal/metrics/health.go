@@ -0,0 +1,45 @@
+package metrics
+
+import "time"
+
+// DependencyMetrics holds the metrics recorded for every dependency health check
+type DependencyMetrics struct {
+	up        *GaugeVec
+	critical  *GaugeVec
+	failures  *CounterVec
+	duration  *HistogramVec
+	lastCheck *GaugeVec
+}
+
+// NewDependencyMetrics creates and registers the dependency health metrics
+func NewDependencyMetrics(r *Registry) *DependencyMetrics {
+	return &DependencyMetrics{
+		up:        r.NewGaugeVec("dependency_up", "Whether the last health check of the dependency succeeded.", "dependency"),
+		critical:  r.NewGaugeVec("dependency_critical", "Whether readiness fails while the dependency is down.", "dependency"),
+		failures:  r.NewCounterVec("dependency_check_failures_total", "Total number of failed dependency health checks.", "dependency"),
+		duration:  r.NewHistogramVec("dependency_check_duration_seconds", "Dependency health check duration in seconds.", DefaultLatencyBuckets, "dependency"),
+		lastCheck: r.NewGaugeVec("dependency_last_check_timestamp_seconds", "Unix time of the last health check of the dependency.", "dependency"),
+	}
+}
+
+// DependencyRegistered exposes the metrics of a dependency before its first check
+func (m *DependencyMetrics) DependencyRegistered(name string, critical bool) {
+	value := 0.0
+	if critical {
+		value = 1
+	}
+	m.critical.WithLabelValues(name).Set(value)
+	m.failures.WithLabelValues(name)
+}
+
+// DependencyChecked records the outcome of a health check
+func (m *DependencyMetrics) DependencyChecked(name string, elapsed time.Duration, err error) {
+	m.duration.WithLabelValues(name).Observe(elapsed.Seconds())
+	m.lastCheck.WithLabelValues(name).Set(float64(time.Now().Unix()))
+	if err != nil {
+		m.up.WithLabelValues(name).Set(0)
+		m.failures.WithLabelValues(name).Inc()
+		return
+	}
+	m.up.WithLabelValues(name).Set(1)
+}
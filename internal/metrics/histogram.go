@@ -0,0 +1,172 @@
+package metrics
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"sync"
+)
+
+// DefaultLatencyBuckets are histogram buckets (in seconds) suited to API latencies
+var DefaultLatencyBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+// Histogram counts observations into cumulative buckets
+type Histogram struct {
+	mu      sync.Mutex
+	buckets []float64
+	counts  []uint64
+	count   uint64
+	sum     float64
+}
+
+// newHistogram creates a histogram with the given upper bounds
+func newHistogram(buckets []float64) *Histogram {
+	return &Histogram{
+		buckets: buckets,
+		counts:  make([]uint64, len(buckets)),
+	}
+}
+
+// Observe records a value
+func (h *Histogram) Observe(value float64) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.count++
+	h.sum += value
+	for i, bound := range h.buckets {
+		if value <= bound {
+			h.counts[i]++
+		}
+	}
+}
+
+// Count returns the number of observations
+func (h *Histogram) Count() uint64 {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	return h.count
+}
+
+// Quantile estimates the q-quantile (0 < q < 1) by linear interpolation
+// within buckets, matching Prometheus' histogram_quantile.
+func (h *Histogram) Quantile(q float64) float64 {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.count == 0 {
+		return math.NaN()
+	}
+
+	rank := q * float64(h.count)
+	lowerBound, lowerCount := 0.0, uint64(0)
+	for i, bound := range h.buckets {
+		if float64(h.counts[i]) >= rank {
+			inBucket := h.counts[i] - lowerCount
+			if inBucket == 0 {
+				return bound
+			}
+			return lowerBound + (bound-lowerBound)*(rank-float64(lowerCount))/float64(inBucket)
+		}
+		lowerBound, lowerCount = bound, h.counts[i]
+	}
+
+	// Rank falls into the +Inf bucket, the best estimate is the highest finite bound
+	return lowerBound
+}
+
+// HistogramVec is a family of histograms partitioned by label values
+type HistogramVec struct {
+	metricName string
+	help       string
+	labels     []string
+	buckets    []float64
+
+	mu         sync.RWMutex
+	histograms map[string]*Histogram
+	values     map[string][]string
+}
+
+// NewHistogramVec creates and registers a new histogram family
+func (r *Registry) NewHistogramVec(name, help string, buckets []float64, labels ...string) *HistogramVec {
+	sorted := append([]float64(nil), buckets...)
+	sort.Float64s(sorted)
+
+	v := &HistogramVec{
+		metricName: name,
+		help:       help,
+		labels:     labels,
+		buckets:    sorted,
+		histograms: make(map[string]*Histogram),
+		values:     make(map[string][]string),
+	}
+	r.register(v)
+	return v
+}
+
+// WithLabelValues returns the histogram for the given label values, creating it if needed
+func (v *HistogramVec) WithLabelValues(values ...string) *Histogram {
+	key := labelKey(values)
+
+	v.mu.RLock()
+	histogram, exists := v.histograms[key]
+	v.mu.RUnlock()
+	if exists {
+		return histogram
+	}
+
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	if histogram, exists = v.histograms[key]; !exists {
+		histogram = newHistogram(v.buckets)
+		v.histograms[key] = histogram
+		v.values[key] = append([]string(nil), values...)
+	}
+	return histogram
+}
+
+// Each calls fn for every histogram in the family with its label values
+func (v *HistogramVec) Each(fn func(values []string, h *Histogram)) {
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+
+	for key, histogram := range v.histograms {
+		fn(v.values[key], histogram)
+	}
+}
+
+func (v *HistogramVec) name() string {
+	return v.metricName
+}
+
+func (v *HistogramVec) write(w io.Writer) {
+	writeHeader(w, v.metricName, v.help, "histogram")
+
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+
+	keys := make([]string, 0, len(v.histograms))
+	for key := range v.histograms {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		h := v.histograms[key]
+		values := v.values[key]
+
+		h.mu.Lock()
+		for i, bound := range h.buckets {
+			le := strconv.FormatFloat(bound, 'g', -1, 64)
+			fmt.Fprintf(w, "%s_bucket%s %d\n", v.metricName, formatLabels(v.labels, values, "le", le), h.counts[i])
+		}
+		fmt.Fprintf(w, "%s_bucket%s %d\n", v.metricName, formatLabels(v.labels, values, "le", "+Inf"), h.count)
+		fmt.Fprintf(w, "%s_sum%s %g\n", v.metricName, formatLabels(v.labels, values), h.sum)
+		fmt.Fprintf(w, "%s_count%s %d\n", v.metricName, formatLabels(v.labels, values), h.count)
+		h.mu.Unlock()
+	}
+}
@@ -0,0 +1,92 @@
+package metrics
+
+import (
+	"math"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// unmatchedRoute labels requests that did not match any registered route,
+// keeping label cardinality bounded regardless of the paths clients send
+const unmatchedRoute = "unmatched"
+
+// HTTPMetrics holds the metrics recorded for every HTTP request
+type HTTPMetrics struct {
+	requests *CounterVec
+	errors   *CounterVec
+	duration *HistogramVec
+	inFlight *GaugeVec
+}
+
+// RouteStats summarizes a route for SLO dashboards
+type RouteStats struct {
+	Method     string  `json:"method"`
+	Route      string  `json:"route"`
+	Requests   uint64  `json:"requests"`
+	Errors     uint64  `json:"errors"`
+	ErrorRate  float64 `json:"error_rate"`
+	P95Seconds float64 `json:"p95_seconds"`
+}
+
+// NewHTTPMetrics creates and registers the HTTP request metrics
+func NewHTTPMetrics(r *Registry) *HTTPMetrics {
+	return &HTTPMetrics{
+		requests: r.NewCounterVec("http_requests_total", "Total number of HTTP requests.", "method", "route", "status"),
+		errors:   r.NewCounterVec("http_request_errors_total", "Total number of HTTP requests answered with a 5xx status.", "method", "route"),
+		duration: r.NewHistogramVec("http_request_duration_seconds", "HTTP request latency in seconds.", DefaultLatencyBuckets, "method", "route"),
+		inFlight: r.NewGaugeVec("http_requests_in_flight", "Number of HTTP requests currently being served."),
+	}
+}
+
+// Begin records the start of a request
+func (m *HTTPMetrics) Begin() {
+	m.inFlight.WithLabelValues().Inc()
+}
+
+// Observe records a completed request. route must be the route template
+// (e.g. /api/v1/users/:id), never the raw path.
+func (m *HTTPMetrics) Observe(method, route string, status int, elapsed time.Duration) {
+	if route == "" {
+		route = unmatchedRoute
+	}
+
+	m.inFlight.WithLabelValues().Dec()
+	m.requests.WithLabelValues(method, route, strconv.Itoa(status)).Inc()
+	m.duration.WithLabelValues(method, route).Observe(elapsed.Seconds())
+	if status >= 500 {
+		m.errors.WithLabelValues(method, route).Inc()
+	}
+}
+
+// RouteStats returns per-route request counts, error rate and p95 latency
+func (m *HTTPMetrics) RouteStats() []RouteStats {
+	var stats []RouteStats
+	m.duration.Each(func(values []string, h *Histogram) {
+		method, route := values[0], values[1]
+		requests := h.Count()
+		errors := uint64(m.errors.WithLabelValues(method, route).Value())
+
+		s := RouteStats{
+			Method:   method,
+			Route:    route,
+			Requests: requests,
+			Errors:   errors,
+		}
+		if requests > 0 {
+			s.ErrorRate = float64(errors) / float64(requests)
+		}
+		if p95 := h.Quantile(0.95); !math.IsNaN(p95) {
+			s.P95Seconds = p95
+		}
+		stats = append(stats, s)
+	})
+
+	sort.Slice(stats, func(i, j int) bool {
+		if stats[i].Route != stats[j].Route {
+			return stats[i].Route < stats[j].Route
+		}
+		return stats[i].Method < stats[j].Method
+	})
+	return stats
+}
@@ -0,0 +1,55 @@
+package metrics
+
+import "time"
+
+// JobDurationBuckets are histogram buckets (in seconds) suited to background jobs
+var JobDurationBuckets = []float64{0.01, 0.1, 0.5, 1, 5, 15, 60, 300, 900, 3600}
+
+// JobMetrics holds the metrics recorded for every scheduled job run
+type JobMetrics struct {
+	runs        *CounterVec
+	failures    *CounterVec
+	duration    *HistogramVec
+	running     *GaugeVec
+	lastSuccess *GaugeVec
+	interval    *GaugeVec
+}
+
+// NewJobMetrics creates and registers the scheduled job metrics
+func NewJobMetrics(r *Registry) *JobMetrics {
+	return &JobMetrics{
+		runs:        r.NewCounterVec("scheduled_job_runs_total", "Total number of completed scheduled job runs.", "job"),
+		failures:    r.NewCounterVec("scheduled_job_failures_total", "Total number of scheduled job runs that returned an error.", "job"),
+		duration:    r.NewHistogramVec("scheduled_job_duration_seconds", "Scheduled job run duration in seconds.", JobDurationBuckets, "job"),
+		running:     r.NewGaugeVec("scheduled_job_running", "Whether the scheduled job is currently running.", "job"),
+		lastSuccess: r.NewGaugeVec("scheduled_job_last_success_timestamp_seconds", "Unix time of the last scheduled job run that completed without error.", "job"),
+		interval:    r.NewGaugeVec("scheduled_job_interval_seconds", "Interval the scheduled job runs at.", "job"),
+	}
+}
+
+// JobRegistered records the interval of a job, so that alerts can compare
+// the time since its last success to it
+func (m *JobMetrics) JobRegistered(name string, interval time.Duration) {
+	m.interval.WithLabelValues(name).Set(interval.Seconds())
+	m.running.WithLabelValues(name).Set(0)
+	// Expose the counters before the first run so that rates start at zero
+	m.runs.WithLabelValues(name)
+	m.failures.WithLabelValues(name)
+}
+
+// JobStarted records the start of a job run
+func (m *JobMetrics) JobStarted(name string) {
+	m.running.WithLabelValues(name).Set(1)
+}
+
+// JobFinished records the end of a job run
+func (m *JobMetrics) JobFinished(name string, elapsed time.Duration, err error) {
+	m.running.WithLabelValues(name).Set(0)
+	m.runs.WithLabelValues(name).Inc()
+	m.duration.WithLabelValues(name).Observe(elapsed.Seconds())
+	if err != nil {
+		m.failures.WithLabelValues(name).Inc()
+		return
+	}
+	m.lastSuccess.WithLabelValues(name).Set(float64(time.Now().Unix()))
+}
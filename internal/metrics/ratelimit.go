@@ -0,0 +1,26 @@
+package metrics
+
+// RateLimitMetrics holds the metrics recorded by rate limiters
+type RateLimitMetrics struct {
+	rejections *CounterVec
+	visitors   *GaugeVec
+}
+
+// NewRateLimitMetrics creates and registers the rate limiter metrics
+func NewRateLimitMetrics(r *Registry) *RateLimitMetrics {
+	return &RateLimitMetrics{
+		rejections: r.NewCounterVec("rate_limit_rejections_total", "Total number of requests rejected by the rate limiter.", "limiter", "route"),
+		visitors:   r.NewGaugeVec("rate_limit_tracked_visitors", "Number of clients tracked by the rate limiter.", "limiter"),
+	}
+}
+
+// RequestRejected records a request rejected by limiter. route must be the
+// route template, never the raw path.
+func (m *RateLimitMetrics) RequestRejected(limiter, route string) {
+	m.rejections.WithLabelValues(limiter, route).Inc()
+}
+
+// VisitorsTracked records the number of clients tracked by limiter
+func (m *RateLimitMetrics) VisitorsTracked(limiter string, visitors int) {
+	m.visitors.WithLabelValues(limiter).Set(float64(visitors))
+}
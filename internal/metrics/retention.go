@@ -0,0 +1,20 @@
+package metrics
+
+// RetentionMetrics holds the metrics recorded by the data retention job
+type RetentionMetrics struct {
+	purged *CounterVec
+}
+
+// NewRetentionMetrics creates and registers the data retention metrics
+func NewRetentionMetrics(r *Registry) *RetentionMetrics {
+	return &RetentionMetrics{
+		purged: r.NewCounterVec("retention_purged_rows_total", "Total number of rows deleted by the retention policy.", "dataset"),
+	}
+}
+
+// Observe records the rows deleted per dataset by a retention run
+func (m *RetentionMetrics) Observe(purged map[string]int64) {
+	for dataset, rows := range purged {
+		m.purged.WithLabelValues(dataset).Add(float64(rows))
+	}
+}
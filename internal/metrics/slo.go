@@ -0,0 +1,201 @@
+package metrics
+
+import (
+	"sync"
+	"time"
+)
+
+// SLO names
+const (
+	SLOAvailability = "availability"
+	SLOAuthSuccess  = "auth_success"
+)
+
+// sloBucketWidth and sloRetention define the rolling window resolution and span
+const (
+	sloBucketWidth = time.Minute
+	sloRetention   = 24 * time.Hour
+)
+
+// SLOWindows are the windows summarized for multi-window burn-rate alerting
+var SLOWindows = []time.Duration{5 * time.Minute, 30 * time.Minute, time.Hour, 6 * time.Hour, 24 * time.Hour}
+
+// SLOWindowStats summarizes an SLO over a single window
+type SLOWindowStats struct {
+	Window   string  `json:"window"`
+	Total    uint64  `json:"total"`
+	Good     uint64  `json:"good"`
+	Ratio    float64 `json:"ratio"`
+	BurnRate float64 `json:"burn_rate"`
+}
+
+// SLOStats summarizes an SLO across all windows
+type SLOStats struct {
+	Name                 string           `json:"name"`
+	Target               float64          `json:"target"`
+	Compliant            bool             `json:"compliant"`
+	ErrorBudgetRemaining float64          `json:"error_budget_remaining"`
+	Windows              []SLOWindowStats `json:"windows"`
+}
+
+// sloBucket holds the events of one minute
+type sloBucket struct {
+	start time.Time
+	total uint64
+	good  uint64
+}
+
+// slo tracks good/total events for one objective
+type slo struct {
+	name   string
+	target float64
+
+	mu      sync.Mutex
+	buckets []sloBucket
+}
+
+// record adds an event to the current bucket
+func (s *slo) record(now time.Time, good bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	start := now.Truncate(sloBucketWidth)
+	idx := int(start.Unix()/int64(sloBucketWidth.Seconds())) % len(s.buckets)
+	if !s.buckets[idx].start.Equal(start) {
+		s.buckets[idx] = sloBucket{start: start}
+	}
+	s.buckets[idx].total++
+	if good {
+		s.buckets[idx].good++
+	}
+}
+
+// window sums the events recorded within the given window
+func (s *slo) window(now time.Time, window time.Duration) (total, good uint64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	since := now.Truncate(sloBucketWidth).Add(-window + sloBucketWidth)
+	for _, b := range s.buckets {
+		if !b.start.IsZero() && !b.start.Before(since) && !b.start.After(now) {
+			total += b.total
+			good += b.good
+		}
+	}
+	return total, good
+}
+
+// SLOTracker records service level indicators and exposes them both as
+// Prometheus counters (for burn-rate alert rules) and as a rolling summary.
+type SLOTracker struct {
+	objectives map[string]*slo
+	authRoutes map[string]bool
+	events     *CounterVec
+	good       *CounterVec
+	targets    *GaugeVec
+	now        func() time.Time
+}
+
+// NewSLOTracker creates and registers the SLO metrics.
+// authRoutes are the route templates counted towards the auth success SLO.
+func NewSLOTracker(r *Registry, availabilityTarget, authTarget float64, authRoutes ...string) *SLOTracker {
+	t := &SLOTracker{
+		objectives: make(map[string]*slo),
+		authRoutes: make(map[string]bool),
+		events:     r.NewCounterVec("slo_events_total", "Total events counted towards a service level objective.", "slo"),
+		good:       r.NewCounterVec("slo_good_events_total", "Good events counted towards a service level objective.", "slo"),
+		targets:    r.NewGaugeVec("slo_target_ratio", "Target ratio of good events for a service level objective.", "slo"),
+		now:        time.Now,
+	}
+
+	for name, target := range map[string]float64{SLOAvailability: availabilityTarget, SLOAuthSuccess: authTarget} {
+		t.objectives[name] = &slo{
+			name:    name,
+			target:  target,
+			buckets: make([]sloBucket, int(sloRetention/sloBucketWidth)),
+		}
+		t.targets.WithLabelValues(name).Set(target)
+		// Expose zero-valued series so alert rules have data from the start
+		t.events.WithLabelValues(name)
+		t.good.WithLabelValues(name)
+	}
+	for _, route := range authRoutes {
+		t.authRoutes[route] = true
+	}
+
+	return t
+}
+
+// RecordRequest records a completed request against the SLOs.
+// Server errors count against availability. Auth routes succeed with 2xx and
+// fail with server errors or, when serverFailure is set, with a client error
+// status caused by the server, such as an overloaded password check.
+func (t *SLOTracker) RecordRequest(route string, status int, serverFailure bool) {
+	if route == "" {
+		// Unmatched routes (404s for arbitrary paths) say nothing about availability
+		return
+	}
+
+	t.record(SLOAvailability, status < 500)
+	if t.authRoutes[route] {
+		switch {
+		case status >= 500 || serverFailure:
+			t.record(SLOAuthSuccess, false)
+		case status >= 200 && status < 300:
+			t.record(SLOAuthSuccess, true)
+		}
+		// Other client errors, such as wrong passwords or expired refresh
+		// tokens, say nothing about the service and are not counted
+	}
+}
+
+// record records a single event for the named SLO
+func (t *SLOTracker) record(name string, good bool) {
+	t.objectives[name].record(t.now(), good)
+	t.events.WithLabelValues(name).Inc()
+	if good {
+		t.good.WithLabelValues(name).Inc()
+	}
+}
+
+// Stats summarizes current compliance of every SLO
+func (t *SLOTracker) Stats() []SLOStats {
+	now := t.now()
+	stats := make([]SLOStats, 0, len(t.objectives))
+
+	for _, name := range []string{SLOAvailability, SLOAuthSuccess} {
+		objective := t.objectives[name]
+		s := SLOStats{
+			Name:                 name,
+			Target:               objective.target,
+			Compliant:            true,
+			ErrorBudgetRemaining: 1,
+		}
+
+		for _, window := range SLOWindows {
+			total, good := objective.window(now, window)
+			ws := SLOWindowStats{
+				Window: window.String(),
+				Total:  total,
+				Good:   good,
+				Ratio:  1,
+			}
+			if total > 0 {
+				ws.Ratio = float64(good) / float64(total)
+			}
+			if budget := 1 - objective.target; budget > 0 {
+				ws.BurnRate = (1 - ws.Ratio) / budget
+			}
+			s.Windows = append(s.Windows, ws)
+		}
+
+		// Compliance and remaining budget are judged over the longest window
+		longest := s.Windows[len(s.Windows)-1]
+		s.Compliant = longest.Ratio >= objective.target
+		s.ErrorBudgetRemaining = 1 - longest.BurnRate
+
+		stats = append(stats, s)
+	}
+
+	return stats
+}
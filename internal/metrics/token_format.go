@@ -0,0 +1,25 @@
+package metrics
+
+import "strconv"
+
+// TokenFormatMetrics counts validated access tokens by format, to follow how
+// much traffic still uses an older format during a migration
+type TokenFormatMetrics struct {
+	tokens *CounterVec
+}
+
+// NewTokenFormatMetrics creates and registers the token format metrics
+func NewTokenFormatMetrics(r *Registry) *TokenFormatMetrics {
+	return &TokenFormatMetrics{
+		tokens: r.NewCounterVec("auth_token_format_total", "Total number of validated access tokens by format and outcome.", "format", "outcome"),
+	}
+}
+
+// TokenFormatSeen records a validated access token of format
+func (m *TokenFormatMetrics) TokenFormatSeen(format uint, accepted bool) {
+	outcome := "accepted"
+	if !accepted {
+		outcome = "rejected"
+	}
+	m.tokens.WithLabelValues(strconv.FormatUint(uint64(format), 10), outcome).Inc()
+}
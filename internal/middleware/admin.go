@@ -3,11 +3,17 @@ package middleware
 import (
 	"gojwt-rest-api/internal/domain"
 	"gojwt-rest-api/internal/service"
+	"gojwt-rest-api/internal/utils"
 	"net/http"
+	"strconv"
 
 	"github.com/gin-gonic/gin"
 )
 
+// contextSelfAccessKey marks requests let through by AdminScopeOrSelfMiddleware
+// for the user themselves
+const contextSelfAccessKey = "self_access"
+
 // AdminMiddleware checks if the user is an admin
 func AdminMiddleware(userService service.UserService) gin.HandlerFunc {
 	return func(c *gin.Context) {
@@ -17,13 +23,21 @@ func AdminMiddleware(userService service.UserService) gin.HandlerFunc {
 			return
 		}
 
-		user, err := userService.GetUserByID(userID)
-		if err != nil {
-			c.AbortWithStatusJSON(http.StatusNotFound, domain.ErrorResponse("user not found", nil))
+		claims, ok := currentPermissions(c, userService, userID)
+		if c.IsAborted() {
 			return
 		}
+		isAdmin := ok && claims.HasRole(domain.RoleAdmin)
+		if !ok {
+			user, err := userService.GetUserByID(userID)
+			if err != nil {
+				c.AbortWithStatusJSON(http.StatusNotFound, domain.ErrorResponse("user not found", nil))
+				return
+			}
+			isAdmin = user.IsAdmin
+		}
 
-		if !user.IsAdmin {
+		if !isAdmin {
 			c.AbortWithStatusJSON(http.StatusForbidden, domain.ErrorResponse("admin access required", nil))
 			return
 		}
@@ -31,3 +45,117 @@ func AdminMiddleware(userService service.UserService) gin.HandlerFunc {
 		c.Next()
 	}
 }
+
+// AdminScopeMiddleware checks if the user is an admin or was granted the
+// delegated admin scope
+func AdminScopeMiddleware(userService service.UserService, scope string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, exists := GetUserID(c)
+		if !exists {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, domain.ErrorResponse("user not authenticated", nil))
+			return
+		}
+
+		hasScope, ok := hasAdminScope(c, userService, userID, scope)
+		if !ok {
+			return
+		}
+		if !hasScope {
+			c.AbortWithStatusJSON(http.StatusForbidden, domain.ErrorResponse("admin scope required", scope))
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// AdminScopeOrSelfMiddleware is AdminScopeMiddleware also letting through
+// the user whose ID is the id route parameter, for whom IsSelfAccess
+// reports true, so that handlers show them a restricted view
+func AdminScopeOrSelfMiddleware(userService service.UserService, scope string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, exists := GetUserID(c)
+		if !exists {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, domain.ErrorResponse("user not authenticated", nil))
+			return
+		}
+
+		hasScope, ok := hasAdminScope(c, userService, userID, scope)
+		if !ok {
+			return
+		}
+		if !hasScope {
+			if c.Param("id") != strconv.FormatUint(uint64(userID), 10) {
+				c.AbortWithStatusJSON(http.StatusForbidden, domain.ErrorResponse("admin scope required", scope))
+				return
+			}
+			c.Set(contextSelfAccessKey, true)
+		}
+
+		c.Next()
+	}
+}
+
+// IsSelfAccess reports whether AdminScopeOrSelfMiddleware let the request
+// through only because it is about the user themselves
+func IsSelfAccess(c *gin.Context) bool {
+	return c.GetBool(contextSelfAccessKey)
+}
+
+// CallerHasAdminScope reports whether the authenticated user is an admin or
+// was granted scope, for handlers leaving out the parts of a response that
+// need scope. It returns false for ok after aborting the request when that
+// cannot be told.
+func CallerHasAdminScope(c *gin.Context, userService service.UserService, scope string) (hasScope, ok bool) {
+	userID, exists := GetUserID(c)
+	if !exists {
+		c.AbortWithStatusJSON(http.StatusUnauthorized, domain.ErrorResponse("user not authenticated", nil))
+		return false, false
+	}
+	return hasAdminScope(c, userService, userID, scope)
+}
+
+// hasAdminScope reports whether the user is an admin or was granted scope.
+// It returns false for ok after aborting the request when that cannot be told.
+func hasAdminScope(c *gin.Context, userService service.UserService, userID uint, scope string) (hasScope, ok bool) {
+	claims, fromClaims := currentPermissions(c, userService, userID)
+	if c.IsAborted() {
+		return false, false
+	}
+	if fromClaims {
+		return claims.HasPermission(scope), true
+	}
+
+	user, err := userService.GetUserByID(userID)
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusNotFound, domain.ErrorResponse("user not found", nil))
+		return false, false
+	}
+	return user.HasAdminScope(scope), true
+}
+
+// currentPermissions returns the claims of the access token when they embed
+// the roles of the user at their current permission version, so admin checks
+// need not load the user. Tokens with stale roles are rejected, and the
+// request aborted, so the client refreshes them; requests authenticated
+// otherwise, and older tokens without roles, fall back to the user.
+func currentPermissions(c *gin.Context, userService service.UserService, userID uint) (*utils.JWTClaims, bool) {
+	claims, ok := GetClaims(c)
+	if !ok || !claims.CarriesPermissions() {
+		return nil, false
+	}
+
+	changed, err := userService.PermissionsChanged(userID, claims.PermVersion)
+	switch {
+	case err == domain.ErrUserNotFound:
+		c.AbortWithStatusJSON(http.StatusNotFound, domain.ErrorResponse("user not found", nil))
+	case err != nil:
+		c.AbortWithStatusJSON(http.StatusInternalServerError, domain.ErrorResponse("failed to verify token", nil))
+	case changed:
+		c.Header("WWW-Authenticate", challengeStalePermissions)
+		c.AbortWithStatusJSON(http.StatusUnauthorized, domain.ErrorResponse(domain.ErrPermissionsChanged.Error(), gin.H{"code": domain.ErrorCodePermissionsChanged}))
+	default:
+		return claims, true
+	}
+	return nil, false
+}
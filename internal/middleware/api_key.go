@@ -0,0 +1,65 @@
+package middleware
+
+import (
+	"gojwt-rest-api/internal/domain"
+	"gojwt-rest-api/internal/service"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	headerAPIKey           = "X-API-Key"
+	contextAPIKeyIDKey     = "api_key_id"
+	contextAPIKeyScopesKey = "api_key_scopes"
+)
+
+// APIKeyMiddleware authenticates requests presenting an X-API-Key header as the
+// key's owner and records the key's usage per route. Requests without the header
+// are left to AuthMiddleware, which only lets keys through on the routes of
+// their scopes (see AcceptAPIKeys).
+func APIKeyMiddleware(apiKeyService service.APIKeyService, usage *service.APIKeyUsageTracker) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		plaintext := c.GetHeader(headerAPIKey)
+		if plaintext == "" {
+			c.Next()
+			return
+		}
+
+		key, err := apiKeyService.Authenticate(plaintext)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, domain.ErrorResponse(domain.ErrInvalidAPIKey.Error(), nil))
+			return
+		}
+
+		// Set user information in context
+		c.Set(contextUserIDKey, key.UserID)
+		c.Set(contextUserEmailKey, key.User.Email)
+		c.Set(contextAPIKeyIDKey, key.ID)
+		c.Set(contextAPIKeyScopesKey, key.ScopeList())
+
+		c.Next()
+
+		if route := c.FullPath(); route != "" {
+			usage.Record(key.ID, c.Request.Method, route)
+		}
+	}
+}
+
+// GetAPIKeyScopes retrieves the scopes of the API key that authenticated the request
+func GetAPIKeyScopes(c *gin.Context) []string {
+	scopes, exists := c.Get(contextAPIKeyScopesKey)
+	if !exists {
+		return nil
+	}
+	return scopes.([]string)
+}
+
+// GetAPIKeyID retrieves the ID of the API key that authenticated the request
+func GetAPIKeyID(c *gin.Context) (uint, bool) {
+	id, exists := c.Get(contextAPIKeyIDKey)
+	if !exists {
+		return 0, false
+	}
+	return id.(uint), true
+}
@@ -1,56 +1,314 @@
 package middleware
 
 import (
+	"errors"
 	"gojwt-rest-api/internal/domain"
 	"gojwt-rest-api/internal/utils"
 	"net/http"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
 )
 
 const (
-	contextUserIDKey   = "user_id"
+	contextUserIDKey    = "user_id"
 	contextUserEmailKey = "user_email"
+	contextClaimsKey    = "token_claims"
+	bearerPrefix        = "Bearer "
+	requestedWithHeader = "X-Requested-With"
+	// TokenExpiresInHeader tells clients, in seconds, that their access token
+	// expires soon and should be refreshed
+	TokenExpiresInHeader = "X-Token-Expires-In"
 )
 
-// AuthMiddleware creates JWT authentication middleware
-func AuthMiddleware(jwtSecret string) gin.HandlerFunc {
+// WWW-Authenticate challenges of rejected access tokens (RFC 6750)
+const (
+	challengeMissingToken     = `Bearer`
+	challengeExpiredToken     = `Bearer error="invalid_token", error_description="The access token expired"`
+	challengeInvalidToken     = `Bearer error="invalid_token", error_description="The access token is invalid"`
+	challengeGenericToken     = `Bearer error="invalid_token"`
+	challengeStalePermissions = `Bearer error="invalid_token", error_description="The roles of the user changed"`
+)
+
+// authOptions configures AuthMiddleware
+type authOptions struct {
+	allowedScopes      []string
+	genericTokenErrors bool
+	renewalHintWindow  time.Duration
+	tokenSources       TokenSources
+	tokenFormat        TokenFormatPolicy
+	apiKeyRoutes       APIKeyRoutes
+	keys               *utils.KeySet
+}
+
+// APIKeyRoutes maps the routes accepting API keys, as "METHOD /path/template"
+// like "GET /api/v1/profile", to the scope the key must hold
+type APIKeyRoutes map[string]string
+
+// TokenSources are where AuthMiddleware reads access tokens from. Sources
+// are tried in a fixed order, the Authorization header, then the cookie, then
+// the query parameter, and the first one present is used even if its token
+// is invalid.
+type TokenSources struct {
+	// Header reads "Authorization: Bearer <token>"
+	Header bool
+	// Cookie is the name of the cookie carrying the token, empty to disable
+	// it. Cookie tokens on unsafe methods require the X-Requested-With header,
+	// which cross-site forms cannot send.
+	Cookie string
+	// QueryParam is the name of the query parameter carrying the token, for
+	// downloads and WebSockets, empty to disable it. It is only read on GET
+	// and HEAD requests.
+	QueryParam string
+}
+
+// extract returns the access token of the request
+func (s TokenSources) extract(c *gin.Context) (string, error) {
+	if s.Header {
+		if authHeader := c.GetHeader("Authorization"); authHeader != "" {
+			token, ok := strings.CutPrefix(authHeader, bearerPrefix)
+			if !ok {
+				return "", domain.ErrInvalidAuthHeaderFormat
+			}
+			return token, nil
+		}
+	}
+	if s.Cookie != "" {
+		if token, err := c.Cookie(s.Cookie); err == nil && token != "" {
+			if !safeMethod(c.Request.Method) && c.GetHeader(requestedWithHeader) == "" {
+				return "", domain.ErrCookieTokenHeaderRequired
+			}
+			return token, nil
+		}
+	}
+	if s.QueryParam != "" && (c.Request.Method == http.MethodGet || c.Request.Method == http.MethodHead) {
+		if token := c.Query(s.QueryParam); token != "" {
+			return token, nil
+		}
+	}
+	return "", domain.ErrAuthHeaderRequired
+}
+
+// safeMethod reports whether method is read-only, and so not a CSRF target
+func safeMethod(method string) bool {
+	return method == http.MethodGet || method == http.MethodHead || method == http.MethodOptions
+}
+
+// TokenFormatObserver is notified of the format of every validated access
+// token, and of whether it was accepted
+type TokenFormatObserver interface {
+	TokenFormatSeen(format uint, accepted bool)
+}
+
+// TokenFormatPolicy is the migration window of access tokens issued in a
+// format older than utils.CurrentTokenFormat
+type TokenFormatPolicy struct {
+	// LegacyCutoff is when older formats stop being accepted, zero to keep
+	// accepting them
+	LegacyCutoff time.Time
+	// Observer, if set, follows how much traffic still uses older formats
+	Observer TokenFormatObserver
+}
+
+// AuthOption configures AuthMiddleware
+type AuthOption func(*authOptions)
+
+// AllowScopes accepts restricted tokens (see utils.WithScope) whose scope is
+// one of scopes
+func AllowScopes(scopes ...string) AuthOption {
+	return func(o *authOptions) {
+		o.allowedScopes = append(o.allowedScopes, scopes...)
+	}
+}
+
+// WithGenericTokenErrors rejects expired and invalid tokens with the same
+// response, not telling clients whether to refresh
+func WithGenericTokenErrors() AuthOption {
+	return func(o *authOptions) {
+		o.genericTokenErrors = true
+	}
+}
+
+// WithRenewalHint sets TokenExpiresInHeader on responses to requests whose
+// access token expires within window, so clients refresh it before it fails
+func WithRenewalHint(window time.Duration) AuthOption {
+	return func(o *authOptions) {
+		o.renewalHintWindow = window
+	}
+}
+
+// WithTokenSources reads access tokens from sources instead of only the
+// Authorization header
+func WithTokenSources(sources TokenSources) AuthOption {
+	return func(o *authOptions) {
+		o.tokenSources = sources
+	}
+}
+
+// WithTokenFormatPolicy accepts access tokens of older formats until the
+// cutoff of policy, flagging them with the Deprecation and Sunset headers,
+// and rejects them afterwards with the code domain.ErrorCodeTokenExpired, so
+// that clients refresh them into the current format
+func WithTokenFormatPolicy(policy TokenFormatPolicy) AuthOption {
+	return func(o *authOptions) {
+		o.tokenFormat = policy
+	}
+}
+
+// AcceptAPIKeys lets requests authenticated by APIKeyMiddleware through on
+// routes, when the key holds the scope of the route
+func AcceptAPIKeys(routes APIKeyRoutes) AuthOption {
+	return func(o *authOptions) {
+		o.apiKeyRoutes = routes
+	}
+}
+
+// WithKeySet verifies access tokens with the keys of keys instead of the
+// secret, rejecting tokens signed with a revoked key with the code
+// domain.ErrorCodeTokenExpired, so that clients refresh them onto the next key
+func WithKeySet(keys *utils.KeySet) AuthOption {
+	return func(o *authOptions) {
+		o.keys = keys
+	}
+}
+
+// AuthMiddleware creates JWT authentication middleware.
+// Requests already authenticated by ClientCertMiddleware are let through, and
+// those authenticated by APIKeyMiddleware only on the routes given to
+// AcceptAPIKeys.
+// Restricted tokens (see utils.WithScope) are only accepted when their scope
+// is allowed with AllowScopes.
+// Expired tokens are rejected with the code domain.ErrorCodeTokenExpired, so
+// clients refresh the session, and other invalid tokens with
+// domain.ErrorCodeTokenInvalid, unless WithGenericTokenErrors is given.
+func AuthMiddleware(jwtSecret string, opts ...AuthOption) gin.HandlerFunc {
+	options := &authOptions{tokenSources: TokenSources{Header: true}}
+	for _, opt := range opts {
+		opt(options)
+	}
+	validator := utils.NewTokenValidator(jwtSecret)
+	if options.keys != nil {
+		validator = utils.NewKeySetValidator(options.keys)
+	}
+
 	return func(c *gin.Context) {
-		// Get authorization header
-		authHeader := c.GetHeader("Authorization")
-		if authHeader == "" {
-			c.JSON(http.StatusUnauthorized, domain.ErrorResponse(domain.ErrAuthHeaderRequired.Error(), nil))
-			c.Abort()
+		if _, ok := GetAPIKeyID(c); ok {
+			if options.checkAPIKeyScope(c) {
+				c.Next()
+			}
+			return
+		}
+		if _, ok := GetClientIdentity(c); ok {
+			c.Next()
 			return
 		}
 
-		// Check if it's a Bearer token
-		parts := strings.SplitN(authHeader, " ", 2)
-		if len(parts) != 2 || parts[0] != "Bearer" {
-			c.JSON(http.StatusUnauthorized, domain.ErrorResponse(domain.ErrInvalidAuthHeaderFormat.Error(), nil))
+		// Get the token from the first source present
+		token, err := options.tokenSources.extract(c)
+		switch err {
+		case nil:
+		case domain.ErrCookieTokenHeaderRequired:
+			c.AbortWithStatusJSON(http.StatusForbidden, domain.ErrorResponse(err.Error(), nil))
+			return
+		default:
+			c.Header("WWW-Authenticate", challengeMissingToken)
+			c.JSON(http.StatusUnauthorized, domain.ErrorResponse(err.Error(), nil))
 			c.Abort()
 			return
 		}
 
-		token := parts[1]
-
 		// Validate token
-		claims, err := utils.ValidateToken(token, jwtSecret)
+		claims, err := validator.Validate(token)
 		if err != nil {
-			c.JSON(http.StatusUnauthorized, domain.ErrorResponse(domain.ErrInvalidOrExpiredToken.Error(), err))
+			rejectToken(c, err, options.genericTokenErrors)
+			return
+		}
+		if !options.checkTokenFormat(c, claims) {
+			return
+		}
+
+		if claims.Scope != "" && !containsScope(options.allowedScopes, claims.Scope) {
+			c.JSON(http.StatusForbidden, domain.ErrorResponse(domain.ErrRestrictedToken.Error(), nil))
 			c.Abort()
 			return
 		}
 
+		if options.renewalHintWindow > 0 && claims.ExpiresAt != nil {
+			if expiresIn := time.Until(claims.ExpiresAt.Time); expiresIn <= options.renewalHintWindow {
+				c.Header(TokenExpiresInHeader, strconv.Itoa(int(expiresIn.Seconds())))
+			}
+		}
+
 		// Set user information in context
 		c.Set(contextUserIDKey, claims.UserID)
 		c.Set(contextUserEmailKey, claims.Email)
+		c.Set(contextClaimsKey, claims)
 
 		c.Next()
 	}
 }
 
+// checkAPIKeyScope aborts requests authenticated by an API key unless the route
+// accepts API keys and the key holds its scope
+func (o *authOptions) checkAPIKeyScope(c *gin.Context) bool {
+	scope, accepted := o.apiKeyRoutes[c.Request.Method+" "+c.FullPath()]
+	switch {
+	case !accepted:
+		c.AbortWithStatusJSON(http.StatusForbidden, domain.ErrorResponse(domain.ErrAPIKeyNotAccepted.Error(), nil))
+		return false
+	case !containsScope(GetAPIKeyScopes(c), scope):
+		c.AbortWithStatusJSON(http.StatusForbidden, domain.ErrorResponse(domain.ErrAPIKeyScopeMissing.Error(), gin.H{"required_scope": scope}))
+		return false
+	}
+	return true
+}
+
+// rejectToken responds to a token that failed validation with err
+func rejectToken(c *gin.Context, err error, generic bool) {
+	switch {
+	case generic:
+		c.Header("WWW-Authenticate", challengeGenericToken)
+		c.AbortWithStatusJSON(http.StatusUnauthorized, domain.ErrorResponse(domain.ErrInvalidOrExpiredToken.Error(), nil))
+	case errors.Is(err, jwt.ErrTokenExpired):
+		c.Header("WWW-Authenticate", challengeExpiredToken)
+		c.AbortWithStatusJSON(http.StatusUnauthorized, domain.ErrorResponse(domain.ErrTokenExpired.Error(), gin.H{"code": domain.ErrorCodeTokenExpired}))
+	case errors.Is(err, domain.ErrSigningKeyRotated):
+		c.Header("WWW-Authenticate", challengeExpiredToken)
+		c.AbortWithStatusJSON(http.StatusUnauthorized, domain.ErrorResponse(domain.ErrSigningKeyRotated.Error(), gin.H{"code": domain.ErrorCodeTokenExpired}))
+	default:
+		c.Header("WWW-Authenticate", challengeInvalidToken)
+		c.AbortWithStatusJSON(http.StatusUnauthorized, domain.ErrorResponse(domain.ErrInvalidToken.Error(), gin.H{"code": domain.ErrorCodeTokenInvalid}))
+	}
+}
+
+// checkTokenFormat applies the token format policy to claims, aborting the
+// request when the format is no longer accepted
+func (o *authOptions) checkTokenFormat(c *gin.Context, claims *utils.JWTClaims) bool {
+	format := claims.TokenFormat()
+	policy := o.tokenFormat
+	legacy := format < utils.CurrentTokenFormat
+	accepted := !legacy || policy.LegacyCutoff.IsZero() || time.Now().Before(policy.LegacyCutoff)
+	if policy.Observer != nil {
+		policy.Observer.TokenFormatSeen(format, accepted)
+	}
+
+	switch {
+	case !accepted && o.genericTokenErrors:
+		rejectToken(c, domain.ErrTokenFormatRetired, true)
+	case !accepted:
+		c.Header("WWW-Authenticate", challengeExpiredToken)
+		c.AbortWithStatusJSON(http.StatusUnauthorized, domain.ErrorResponse(domain.ErrTokenFormatRetired.Error(), gin.H{"code": domain.ErrorCodeTokenExpired}))
+	case legacy && !policy.LegacyCutoff.IsZero():
+		// RFC 9745 and RFC 8594
+		c.Header("Deprecation", "true")
+		c.Header("Sunset", policy.LegacyCutoff.UTC().Format(http.TimeFormat))
+	}
+	return accepted
+}
+
 // GetUserID retrieves user ID from context
 func GetUserID(c *gin.Context) (uint, bool) {
 	userID, exists := c.Get(contextUserIDKey)
@@ -68,3 +326,22 @@ func GetUserEmail(c *gin.Context) (string, bool) {
 	}
 	return email.(string), true
 }
+
+// GetClaims retrieves the validated token claims from context
+func GetClaims(c *gin.Context) (*utils.JWTClaims, bool) {
+	claims, exists := c.Get(contextClaimsKey)
+	if !exists {
+		return nil, false
+	}
+	return claims.(*utils.JWTClaims), true
+}
+
+// containsScope reports whether scope is one of scopes
+func containsScope(scopes []string, scope string) bool {
+	for _, s := range scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,121 @@
+package middleware
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"gojwt-rest-api/internal/cache"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	headerCacheStatus  = "X-Cache"
+	cacheStatusHit     = "HIT"
+	cacheStatusMiss    = "MISS"
+	anonymousPrincipal = "anonymous"
+)
+
+// cachedResponse represents a response stored in the cache
+type cachedResponse struct {
+	Status      int    `json:"status"`
+	ContentType string `json:"content_type"`
+	Body        []byte `json:"body"`
+}
+
+// cacheWriter captures the response body while writing it to the client
+type cacheWriter struct {
+	gin.ResponseWriter
+	body *bytes.Buffer
+}
+
+func (w *cacheWriter) Write(b []byte) (int, error) {
+	w.body.Write(b)
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *cacheWriter) WriteString(s string) (int, error) {
+	w.body.WriteString(s)
+	return w.ResponseWriter.WriteString(s)
+}
+
+// ResponseCacheMiddleware caches successful GET responses of a route group.
+// Cache keys are derived from the request URL and the authenticated principal,
+// so users never receive responses cached for somebody else.
+func ResponseCacheMiddleware(store cache.Store, group string, ttl time.Duration) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.Request.Method != http.MethodGet || c.GetHeader("Cache-Control") == "no-cache" {
+			c.Next()
+			return
+		}
+
+		key := responseCacheKey(c, group)
+
+		if data, err := store.Get(c.Request.Context(), key); err == nil {
+			var cached cachedResponse
+			if err := json.Unmarshal(data, &cached); err == nil {
+				c.Header(headerCacheStatus, cacheStatusHit)
+				c.Data(cached.Status, cached.ContentType, cached.Body)
+				c.Abort()
+				return
+			}
+		}
+
+		writer := &cacheWriter{ResponseWriter: c.Writer, body: &bytes.Buffer{}}
+		c.Writer = writer
+		c.Header(headerCacheStatus, cacheStatusMiss)
+
+		c.Next()
+
+		if writer.Status() != http.StatusOK {
+			return
+		}
+
+		data, err := json.Marshal(cachedResponse{
+			Status:      writer.Status(),
+			ContentType: writer.Header().Get("Content-Type"),
+			Body:        writer.body.Bytes(),
+		})
+		if err != nil {
+			return
+		}
+		// Caching is best effort, a failing store must not fail the request
+		_ = store.Set(c.Request.Context(), key, data, ttl)
+	}
+}
+
+// InvalidateCacheMiddleware drops the cached responses of the given groups
+// after a successful write request, so subsequent reads observe the change.
+func InvalidateCacheMiddleware(store cache.Store, groups ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Next()
+
+		if c.Writer.Status() >= http.StatusBadRequest {
+			return
+		}
+
+		for _, group := range groups {
+			_ = InvalidateCacheGroup(c, store, group)
+		}
+	}
+}
+
+// InvalidateCacheGroup removes every cached response of a route group
+func InvalidateCacheGroup(c *gin.Context, store cache.Store, group string) error {
+	return cache.NewGroup(store, group).Invalidate(c.Request.Context())
+}
+
+// responseCacheKey derives the cache key from the request URL and principal
+func responseCacheKey(c *gin.Context, group string) string {
+	principal := anonymousPrincipal
+	if userID, exists := GetUserID(c); exists {
+		principal = strconv.FormatUint(uint64(userID), 10)
+	}
+
+	hash := sha256.Sum256([]byte(c.Request.URL.RequestURI()))
+	return cache.GroupKeyPrefix(group) + principal + ":" + hex.EncodeToString(hash[:])
+}
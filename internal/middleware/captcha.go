@@ -0,0 +1,31 @@
+package middleware
+
+import (
+	"gojwt-rest-api/internal/captcha"
+	"gojwt-rest-api/internal/domain"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// CaptchaHeader carries the response of the CAPTCHA solved by the client
+const CaptchaHeader = "X-Captcha-Token"
+
+// CaptchaMiddleware rejects requests without a valid CAPTCHA response in
+// CaptchaHeader. Place it after rate limiting, so that rejected clients don't
+// reach the provider.
+func CaptchaMiddleware(verifier captcha.Verifier) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		err := verifier.Verify(c.Request.Context(), c.GetHeader(CaptchaHeader), c.ClientIP())
+		if err != nil {
+			if err == domain.ErrCaptchaInvalid {
+				c.JSON(http.StatusBadRequest, domain.ErrorResponse(domain.ErrCaptchaInvalid.Error(), nil))
+			} else {
+				c.JSON(http.StatusServiceUnavailable, domain.ErrorResponse(domain.ErrCaptchaUnavailable.Error(), nil))
+			}
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
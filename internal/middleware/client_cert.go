@@ -0,0 +1,54 @@
+package middleware
+
+import (
+	"crypto/tls"
+
+	"github.com/gin-gonic/gin"
+)
+
+const contextClientIdentityKey = "client_identity"
+
+// ClientCertMiddleware authenticates requests presenting a verified client
+// certificate as the user its identity is mapped to. Requests without a
+// mapped certificate are left to AuthMiddleware.
+func ClientCertMiddleware(principals map[string]uint) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		identity, userID, ok := clientPrincipal(c.Request.TLS, principals)
+		if !ok {
+			c.Next()
+			return
+		}
+
+		c.Set(contextUserIDKey, userID)
+		c.Set(contextClientIdentityKey, identity)
+		c.Next()
+	}
+}
+
+// clientPrincipal finds the principal of the verified client certificate,
+// matching its URI SANs (e.g. SPIFFE IDs) before its subject common name
+func clientPrincipal(state *tls.ConnectionState, principals map[string]uint) (string, uint, bool) {
+	if state == nil || len(state.VerifiedChains) == 0 || len(state.PeerCertificates) == 0 {
+		return "", 0, false
+	}
+	cert := state.PeerCertificates[0]
+
+	for _, uri := range cert.URIs {
+		if userID, ok := principals[uri.String()]; ok {
+			return uri.String(), userID, true
+		}
+	}
+	if userID, ok := principals[cert.Subject.CommonName]; ok && cert.Subject.CommonName != "" {
+		return cert.Subject.CommonName, userID, true
+	}
+	return "", 0, false
+}
+
+// GetClientIdentity retrieves the client certificate identity that authenticated the request
+func GetClientIdentity(c *gin.Context) (string, bool) {
+	identity, exists := c.Get(contextClientIdentityKey)
+	if !exists {
+		return "", false
+	}
+	return identity.(string), true
+}
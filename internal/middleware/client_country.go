@@ -0,0 +1,47 @@
+package middleware
+
+import (
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+const contextClientCountryKey = "client_country"
+
+// ClientCountryMiddleware reads the country of the client from header, as set
+// by a CDN or load balancer in front of the API (e.g. CF-IPCountry). The
+// header must be set by a trusted proxy, clients can send any value.
+// An empty header disables the middleware.
+func ClientCountryMiddleware(header string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if header != "" {
+			if country := strings.ToUpper(strings.TrimSpace(c.GetHeader(header))); isCountryCode(country) {
+				c.Set(contextClientCountryKey, country)
+			}
+		}
+		c.Next()
+	}
+}
+
+// GetClientCountry retrieves the country of the client from context, empty when unknown
+func GetClientCountry(c *gin.Context) string {
+	country, exists := c.Get(contextClientCountryKey)
+	if !exists {
+		return ""
+	}
+	return country.(string)
+}
+
+// isCountryCode reports whether code is an ISO 3166-1 alpha-2 code. The
+// user-assigned codes proxies use for unknown (XX) and Tor (T1) are not.
+func isCountryCode(code string) bool {
+	if len(code) != 2 || code == "XX" {
+		return false
+	}
+	for _, r := range code {
+		if r < 'A' || r > 'Z' {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,49 @@
+package middleware
+
+import (
+	"gojwt-rest-api/internal/domain"
+	"gojwt-rest-api/internal/service"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// ClientIDHeader names the client application a request comes from
+	ClientIDHeader = "X-Client-ID"
+	// ClientVersionHeader carries the app version of the client, e.g. 2.4.1
+	ClientVersionHeader = "X-Client-Version"
+)
+
+// ClientVersionMiddleware refuses requests from app versions older than the
+// minimum version of their client application with 426 Upgrade Required and
+// the code domain.ErrorCodeUpgradeRequired, so that apps can prompt for an
+// update. Requests without ClientIDHeader, such as from browsers or servers,
+// are not checked.
+func ClientVersionMiddleware(versions service.ClientVersionService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		clientID := c.GetHeader(ClientIDHeader)
+		if clientID == "" {
+			c.Next()
+			return
+		}
+
+		version := c.GetHeader(ClientVersionHeader)
+		policy, err := versions.Check(clientID, version)
+		switch err {
+		case nil:
+			c.Next()
+		case domain.ErrClientUpgradeRequired:
+			c.AbortWithStatusJSON(http.StatusUpgradeRequired, domain.ErrorResponse(err.Error(), &domain.ClientUpgradeRequired{
+				Code:           domain.ErrorCodeUpgradeRequired,
+				ClientID:       clientID,
+				ClientVersion:  version,
+				MinimumVersion: policy.MinVersion,
+			}))
+		case domain.ErrInvalidClientVersion:
+			c.AbortWithStatusJSON(http.StatusBadRequest, domain.ErrorResponse(err.Error(), nil))
+		default:
+			c.AbortWithStatusJSON(http.StatusInternalServerError, domain.ErrorResponse("failed to check client version", nil))
+		}
+	}
+}
@@ -12,8 +12,12 @@ const (
 	headerAllowCredentials = "Access-Control-Allow-Credentials"
 	headerAllowHeaders     = "Access-Control-Allow-Headers"
 	headerAllowMethods     = "Access-Control-Allow-Methods"
+	headerExposeHeaders    = "Access-Control-Expose-Headers"
 	allowMethods           = "POST, OPTIONS, GET, PUT, DELETE, PATCH"
-	allowHeaders           = "Content-Type, Content-Length, Accept-Encoding, X-CSRF-Token, Authorization, accept, origin, Cache-Control, X-Requested-With"
+	allowHeaders           = "Content-Type, Content-Length, Accept-Encoding, X-CSRF-Token, Authorization, accept, origin, Cache-Control, X-Requested-With, X-Request-ID, X-Client-ID, X-Client-Version"
+	// exposeHeaders lets browser clients read the request ID to report errors
+	// and the renewal hint of their access token
+	exposeHeaders = RequestIDHeader + ", " + TokenExpiresInHeader
 )
 
 // CORSMiddleware handles CORS
@@ -23,6 +27,7 @@ func CORSMiddleware(cfg config.CORSConfig) gin.HandlerFunc {
 		c.Writer.Header().Set(headerAllowCredentials, "true")
 		c.Writer.Header().Set(headerAllowHeaders, allowHeaders)
 		c.Writer.Header().Set(headerAllowMethods, allowMethods)
+		c.Writer.Header().Set(headerExposeHeaders, exposeHeaders)
 
 		if c.Request.Method == http.MethodOptions {
 			c.AbortWithStatus(http.StatusNoContent)
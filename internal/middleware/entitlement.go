@@ -0,0 +1,22 @@
+package middleware
+
+import (
+	"gojwt-rest-api/internal/domain"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RequireEntitlement allows only tokens whose plan includes the entitlement, e.g. "sso".
+// It must run after AuthMiddleware; requests without token claims are rejected.
+func RequireEntitlement(entitlement string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		claims, exists := GetClaims(c)
+		if !exists || !claims.HasEntitlement(entitlement) {
+			c.AbortWithStatusJSON(http.StatusForbidden, domain.ErrorResponse(domain.ErrEntitlementRequired.Error(), gin.H{"entitlement": entitlement}))
+			return
+		}
+
+		c.Next()
+	}
+}
@@ -0,0 +1,17 @@
+package middleware
+
+import (
+	"gojwt-rest-api/internal/lifecycle"
+
+	"github.com/gin-gonic/gin"
+)
+
+// InFlightMiddleware tracks in-flight requests for graceful draining
+func InFlightMiddleware(drainer *lifecycle.Drainer) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		drainer.Begin()
+		defer drainer.End()
+
+		c.Next()
+	}
+}
@@ -0,0 +1,21 @@
+package middleware
+
+import (
+	"gojwt-rest-api/internal/metrics"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// MetricsMiddleware records request metrics labelled by route template
+func MetricsMiddleware(m *metrics.HTTPMetrics) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		m.Begin()
+
+		c.Next()
+
+		// FullPath returns the route template (/users/:id), keeping label cardinality bounded
+		m.Observe(c.Request.Method, c.FullPath(), c.Writer.Status(), time.Since(start))
+	}
+}
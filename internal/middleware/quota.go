@@ -0,0 +1,37 @@
+package middleware
+
+import (
+	"gojwt-rest-api/internal/domain"
+	"gojwt-rest-api/internal/service"
+	"math"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// OrganizationQuotaMiddleware enforces the request quota of the authenticated
+// user's organization. It must run after authentication. Requests whose quota
+// cannot be checked, e.g. while the database is unavailable, are refused.
+func OrganizationQuotaMiddleware(quota service.QuotaService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, exists := GetUserID(c)
+		if !exists {
+			c.Next()
+			return
+		}
+
+		retryAfter, err := quota.AllowRequest(userID)
+		if err == domain.ErrRequestQuotaExceeded {
+			c.Header("Retry-After", strconv.Itoa(int(math.Ceil(retryAfter.Seconds()))))
+			c.AbortWithStatusJSON(http.StatusTooManyRequests, domain.ErrorResponse(domain.ErrRequestQuotaExceeded.Error(), nil))
+			return
+		}
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusServiceUnavailable, domain.ErrorResponse(domain.ErrQuotaUnavailable.Error(), nil))
+			return
+		}
+
+		c.Next()
+	}
+}
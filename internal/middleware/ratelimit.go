@@ -1,38 +1,139 @@
 package middleware
 
 import (
+	"context"
 	"gojwt-rest-api/internal/config"
 	"gojwt-rest-api/internal/domain"
+	"gojwt-rest-api/pkg/kv"
 	"net/http"
+	"sort"
 	"sync"
 	"time"
 
 	"github.com/gin-gonic/gin"
 )
 
-// RateLimiter represents a simple in-memory rate limiter.
-// NOTE: This implementation is not suitable for a distributed environment
-// with multiple server instances. For production, consider using a
-// distributed rate limiter with a shared data store like Redis.
+// defaultLimiterName names the limiter in metrics and statistics when no name is given
+const defaultLimiterName = "global"
+
+// unmatchedRoute labels rejected requests that did not match any route
+const unmatchedRoute = "unmatched"
+
+// rateLimitKeyPrefix namespaces the request counters of rate limiters
+const rateLimitKeyPrefix = "ratelimit:"
+
+// RateLimiter limits the requests of each client IP per fixed window.
+// Requests are counted in a kv.Store, in memory by default; a Redis store
+// enforces the limit across server instances. Statistics only cover the
+// clients seen by this instance.
 type RateLimiter struct {
+	store    kv.Store
 	visitors map[string]*visitor
 	mu       sync.RWMutex
 	rate     int
 	duration time.Duration
+	name     string
+	observer RateLimitObserver
+	// internalRate replaces rate for internal callers when relaxInternal is
+	// set, 0 not limiting them
+	internalRate  int
+	relaxInternal bool
+	// rejections counts rejected requests by route template
+	rejections map[string]uint64
 }
 
-// visitor represents a client visitor
+// visitor represents a client visitor seen by this instance
 type visitor struct {
+	// count is the number of requests allowed in the current window
 	count      int
 	lastAccess time.Time
+	// lastSeen is the time of the last request, allowed or not
+	lastSeen time.Time
+	// rejected counts the requests rejected since the visitor is tracked
+	rejected int
+}
+
+// RateLimitObserver is notified of rate limiter decisions, e.g. to record metrics
+type RateLimitObserver interface {
+	RequestRejected(limiter, route string)
+	VisitorsTracked(limiter string, visitors int)
+}
+
+// RateLimiterOption configures a RateLimiter
+type RateLimiterOption func(*RateLimiter)
+
+// WithLimiterName names the limiter in metrics and statistics
+func WithLimiterName(name string) RateLimiterOption {
+	return func(rl *RateLimiter) {
+		rl.name = name
+	}
+}
+
+// WithRateLimitObserver notifies observer of rejected requests and of the
+// number of tracked visitors
+func WithRateLimitObserver(observer RateLimitObserver) RateLimiterOption {
+	return func(rl *RateLimiter) {
+		rl.observer = observer
+	}
+}
+
+// WithRateLimitStore counts requests in store instead of process memory,
+// e.g. a Redis store to share the limit between server instances
+func WithRateLimitStore(store kv.Store) RateLimiterOption {
+	return func(rl *RateLimiter) {
+		rl.store = store
+	}
+}
+
+// WithInternalLimit limits requests from inside the trust boundary (see
+// TrustBoundaryMiddleware) to limit per duration instead, not limiting them
+// when limit is 0
+func WithInternalLimit(limit int) RateLimiterOption {
+	return func(rl *RateLimiter) {
+		rl.internalRate = limit
+		rl.relaxInternal = true
+	}
+}
+
+// RateLimitStats is the current state of a rate limiter, to tune its limit
+type RateLimitStats struct {
+	Name  string `json:"name"`
+	Limit int    `json:"limit"`
+	// InternalLimit is the limit of internal callers, 0 when not limited
+	InternalLimit *int   `json:"internal_limit,omitempty"`
+	Window        string `json:"window"`
+	// TrackedVisitors are the clients seen within the window
+	TrackedVisitors int `json:"tracked_visitors"`
+	// ThrottledVisitors are the clients currently at the limit
+	ThrottledVisitors int                 `json:"throttled_visitors"`
+	Rejections        uint64              `json:"rejections"`
+	RejectionsByRoute map[string]uint64   `json:"rejections_by_route"`
+	TopOffenders      []RateLimitOffender `json:"top_offenders"`
+}
+
+// RateLimitOffender is a tracked client with rejected requests
+type RateLimitOffender struct {
+	IP string `json:"ip"`
+	// Requests is the number of requests allowed in the current window
+	Requests   int       `json:"requests"`
+	Rejections int       `json:"rejections"`
+	LastSeen   time.Time `json:"last_seen"`
 }
 
 // NewRateLimiter creates a new rate limiter
-func NewRateLimiter(cfg config.RateLimitConfig) *RateLimiter {
+func NewRateLimiter(cfg config.RateLimitConfig, opts ...RateLimiterOption) *RateLimiter {
 	rl := &RateLimiter{
-		visitors: make(map[string]*visitor),
-		rate:     cfg.RequestsPerDuration,
-		duration: cfg.Duration,
+		visitors:   make(map[string]*visitor),
+		rate:       cfg.RequestsPerDuration,
+		duration:   cfg.Duration,
+		name:       defaultLimiterName,
+		rejections: make(map[string]uint64),
+	}
+	for _, opt := range opts {
+		opt(rl)
+	}
+	if rl.store == nil {
+		rl.store = kv.NewMemoryStore(cfg.CleanupInterval)
 	}
 
 	// Start cleanup goroutine
@@ -41,7 +142,7 @@ func NewRateLimiter(cfg config.RateLimitConfig) *RateLimiter {
 	return rl
 }
 
-// cleanup removes old visitors periodically
+// cleanup removes the statistics of old visitors periodically
 func (rl *RateLimiter) cleanup(interval time.Duration) {
 	ticker := time.NewTicker(interval)
 	defer ticker.Stop()
@@ -49,16 +150,36 @@ func (rl *RateLimiter) cleanup(interval time.Duration) {
 	for range ticker.C {
 		rl.mu.Lock()
 		for ip, v := range rl.visitors {
-			if time.Since(v.lastAccess) > rl.duration {
+			if time.Since(v.lastSeen) > rl.duration {
 				delete(rl.visitors, ip)
 			}
 		}
+		visitors := len(rl.visitors)
 		rl.mu.Unlock()
+
+		if rl.observer != nil {
+			rl.observer.VisitorsTracked(rl.name, visitors)
+		}
 	}
 }
 
-// allow checks if the request is allowed
-func (rl *RateLimiter) allow(ip string) bool {
+// allow checks if the request to route is allowed. Requests are allowed
+// when the store is unavailable, so that an outage of a shared store does not
+// take the API down with it.
+func (rl *RateLimiter) allow(ctx context.Context, ip, route string, internal bool) bool {
+	rate := rl.rate
+	if internal && rl.relaxInternal {
+		if rl.internalRate == 0 {
+			return true
+		}
+		rate = rl.internalRate
+	}
+
+	count, err := rl.store.Incr(ctx, rateLimitKeyPrefix+rl.name+":"+ip, rl.duration)
+	if err != nil {
+		count = 0
+	}
+
 	rl.mu.Lock()
 	defer rl.mu.Unlock()
 
@@ -66,36 +187,99 @@ func (rl *RateLimiter) allow(ip string) bool {
 
 	v, exists := rl.visitors[ip]
 	if !exists {
-		rl.visitors[ip] = &visitor{
-			count:      1,
-			lastAccess: now,
+		v = &visitor{}
+		rl.visitors[ip] = v
+		if rl.observer != nil {
+			rl.observer.VisitorsTracked(rl.name, len(rl.visitors))
 		}
-		return true
-	}
-
-	// Reset count if duration has passed
-	if now.Sub(v.lastAccess) > rl.duration {
-		v.count = 1
-		v.lastAccess = now
-		return true
 	}
+	v.lastSeen = now
 
 	// Check if rate limit exceeded
-	if v.count >= rl.rate {
+	if count > int64(rate) {
+		if route == "" {
+			route = unmatchedRoute
+		}
+		v.count = rate
+		v.rejected++
+		rl.rejections[route]++
+		if rl.observer != nil {
+			rl.observer.RequestRejected(rl.name, route)
+		}
 		return false
 	}
 
-	v.count++
+	v.count = int(count)
 	v.lastAccess = now
 	return true
 }
 
+// Allow checks a request to route counted under key rather than the client
+// IP, e.g. the account the request targets. Keys are listed as offenders in
+// Stats, so they should not carry personal data.
+func (rl *RateLimiter) Allow(ctx context.Context, key, route string) bool {
+	return rl.allow(ctx, key, route, false)
+}
+
+// Stats returns the current state of the limiter, with at most top
+// offenders, the tracked clients with the most rejected requests
+func (rl *RateLimiter) Stats(top int) *RateLimitStats {
+	rl.mu.RLock()
+	defer rl.mu.RUnlock()
+
+	now := time.Now()
+	stats := &RateLimitStats{
+		Name:              rl.name,
+		Limit:             rl.rate,
+		Window:            rl.duration.String(),
+		RejectionsByRoute: make(map[string]uint64, len(rl.rejections)),
+		TopOffenders:      []RateLimitOffender{},
+	}
+	if rl.relaxInternal {
+		internalLimit := rl.internalRate
+		stats.InternalLimit = &internalLimit
+	}
+	for route, count := range rl.rejections {
+		stats.RejectionsByRoute[route] = count
+		stats.Rejections += count
+	}
+	for ip, v := range rl.visitors {
+		if now.Sub(v.lastSeen) > rl.duration {
+			continue
+		}
+		stats.TrackedVisitors++
+		if v.count >= rl.rate && now.Sub(v.lastAccess) <= rl.duration {
+			stats.ThrottledVisitors++
+		}
+		if v.rejected > 0 {
+			stats.TopOffenders = append(stats.TopOffenders, RateLimitOffender{
+				IP:         ip,
+				Requests:   v.count,
+				Rejections: v.rejected,
+				LastSeen:   v.lastSeen,
+			})
+		}
+	}
+
+	sort.Slice(stats.TopOffenders, func(i, j int) bool {
+		a, b := stats.TopOffenders[i], stats.TopOffenders[j]
+		if a.Rejections != b.Rejections {
+			return a.Rejections > b.Rejections
+		}
+		return a.IP < b.IP
+	})
+	if len(stats.TopOffenders) > top {
+		stats.TopOffenders = stats.TopOffenders[:top]
+	}
+	return stats
+}
+
 // RateLimitMiddleware creates rate limiting middleware
 func RateLimitMiddleware(limiter *RateLimiter) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		ip := c.ClientIP()
 
-		if !limiter.allow(ip) {
+		if !limiter.allow(c.Request.Context(), ip, c.FullPath(), IsInternal(c)) {
 			c.JSON(http.StatusTooManyRequests, domain.ErrorResponse(domain.ErrRateLimitExceeded.Error(), nil))
 			c.Abort()
 			return
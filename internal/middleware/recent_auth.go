@@ -0,0 +1,42 @@
+package middleware
+
+import (
+	"fmt"
+	"gojwt-rest-api/internal/domain"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RecentAuthMiddleware guards sensitive actions, allowing only tokens whose
+// auth_time is at most maxAge old. Older sessions get a fresh token from
+// POST /auth/reauthenticate. The rejection carries a step-up challenge
+// (RFC 9470) in the WWW-Authenticate header.
+// It must run after AuthMiddleware. Requests authenticated by
+// ClientCertMiddleware are let through, as they cannot reauthenticate, and
+// those authenticated by APIKeyMiddleware are refused.
+func RecentAuthMiddleware(maxAge time.Duration) gin.HandlerFunc {
+	maxAgeSeconds := int64(maxAge.Seconds())
+	challenge := fmt.Sprintf(`Bearer error="insufficient_user_authentication", error_description="A more recent authentication is required", max_age=%d`, maxAgeSeconds)
+
+	return func(c *gin.Context) {
+		if _, ok := GetAPIKeyID(c); ok {
+			c.AbortWithStatusJSON(http.StatusForbidden, domain.ErrorResponse(domain.ErrAPIKeyNotAccepted.Error(), nil))
+			return
+		}
+		if _, ok := GetClientIdentity(c); ok {
+			c.Next()
+			return
+		}
+
+		claims, exists := GetClaims(c)
+		if !exists || !claims.AuthenticatedWithin(maxAge) {
+			c.Header("WWW-Authenticate", challenge)
+			c.AbortWithStatusJSON(http.StatusUnauthorized, domain.ErrorResponse(domain.ErrReauthenticationRequired.Error(), gin.H{"max_age": maxAgeSeconds}))
+			return
+		}
+
+		c.Next()
+	}
+}
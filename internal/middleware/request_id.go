@@ -0,0 +1,183 @@
+package middleware
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/json"
+	"fmt"
+	"gojwt-rest-api/internal/domain"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// RequestIDHeader carries the ID of a request, in both directions
+	RequestIDHeader = "X-Request-ID"
+	// traceParentHeader is the W3C trace context header set by tracing proxies
+	traceParentHeader = "traceparent"
+	requestIDKey      = "request_id"
+	// requestIDField is the member added to JSON error responses
+	requestIDField = "request_id"
+	// redactedValue replaces redacted query parameters in log lines
+	redactedValue = "[redacted]"
+)
+
+var (
+	// requestIDPattern accepts IDs assigned by proxies and clients, keeping
+	// them safe to echo in headers and log lines
+	requestIDPattern = regexp.MustCompile(`^[A-Za-z0-9._:-]{1,128}$`)
+	// traceParentPattern matches a traceparent header, capturing the trace ID
+	traceParentPattern = regexp.MustCompile(`^[0-9a-f]{2}-([0-9a-f]{32})-[0-9a-f]{16}-[0-9a-f]{2}$`)
+)
+
+// RequestIDMiddleware assigns every request an ID, returned in the
+// X-Request-ID header and in the body of JSON error responses, so that errors
+// reported by users can be found in the logs. The ID is taken from the
+// X-Request-ID header set by a proxy, then from the trace ID of a traceparent
+// header, and generated otherwise. Register it before any middleware that may
+// respond with an error.
+func RequestIDMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id := incomingRequestID(c.Request)
+		if id == "" {
+			id = newRequestID()
+		}
+		c.Set(requestIDKey, id)
+		c.Header(RequestIDHeader, id)
+
+		c.Writer = &requestIDWriter{ResponseWriter: c.Writer, requestID: id}
+		c.Next()
+	}
+}
+
+// GetRequestID returns the ID assigned to the request by RequestIDMiddleware
+func GetRequestID(c *gin.Context) string {
+	return c.GetString(requestIDKey)
+}
+
+// NewRequestLogFormatter formats access log lines like gin's default logger,
+// followed by the request ID. The values of redactedParams, such as access
+// tokens passed in the query, are not logged.
+func NewRequestLogFormatter(redactedParams ...string) gin.LogFormatter {
+	return func(params gin.LogFormatterParams) string {
+		requestID, _ := params.Keys[requestIDKey].(string)
+		if requestID == "" {
+			requestID = "-"
+		}
+		return fmt.Sprintf("[GIN] %v | %3d | %13v | %15s | %-7s %#v | %s\n%s",
+			params.TimeStamp.Format("2006/01/02 - 15:04:05"),
+			params.StatusCode,
+			params.Latency,
+			params.ClientIP,
+			params.Method,
+			redactQuery(params.Path, redactedParams),
+			requestID,
+			params.ErrorMessage,
+		)
+	}
+}
+
+// redactQuery replaces the values of the named parameters in the query of path
+func redactQuery(path string, names []string) string {
+	base, query, ok := strings.Cut(path, "?")
+	if !ok || len(names) == 0 {
+		return path
+	}
+	pairs := strings.Split(query, "&")
+	for i, pair := range pairs {
+		key, _, _ := strings.Cut(pair, "=")
+		if unescaped, err := url.QueryUnescape(key); err == nil {
+			key = unescaped
+		}
+		for _, name := range names {
+			if key == name {
+				pairs[i] = url.QueryEscape(name) + "=" + redactedValue
+			}
+		}
+	}
+	return base + "?" + strings.Join(pairs, "&")
+}
+
+// NotFoundHandler responds to requests matching no route with a JSON error
+func NotFoundHandler(c *gin.Context) {
+	c.JSON(http.StatusNotFound, domain.ErrorResponse("route not found", nil))
+}
+
+// RecoveryHandler responds to a panic with a JSON internal server error,
+// which carries the request ID like other error responses
+func RecoveryHandler(c *gin.Context, recovered any) {
+	c.AbortWithStatusJSON(http.StatusInternalServerError, domain.ErrorResponse("internal server error", nil))
+}
+
+// incomingRequestID returns the request ID set by a proxy or client, if valid
+func incomingRequestID(r *http.Request) string {
+	if id := r.Header.Get(RequestIDHeader); requestIDPattern.MatchString(id) {
+		return id
+	}
+	if match := traceParentPattern.FindStringSubmatch(r.Header.Get(traceParentHeader)); match != nil {
+		return match[1]
+	}
+	return ""
+}
+
+// newRequestID generates a random request ID
+func newRequestID() string {
+	return rand.Text()
+}
+
+// requestIDWriter adds the request ID to JSON error responses. Handlers
+// render a response with a single write, so only the first write is
+// considered.
+type requestIDWriter struct {
+	gin.ResponseWriter
+	requestID string
+	written   bool
+}
+
+func (w *requestIDWriter) Write(b []byte) (int, error) {
+	if w.written {
+		return w.ResponseWriter.Write(b)
+	}
+	w.written = true
+
+	if w.Status() < http.StatusBadRequest || !strings.HasPrefix(w.Header().Get("Content-Type"), "application/json") {
+		return w.ResponseWriter.Write(b)
+	}
+	body, ok := withRequestID(b, w.requestID)
+	if !ok {
+		return w.ResponseWriter.Write(b)
+	}
+	if _, err := w.ResponseWriter.Write(body); err != nil {
+		return 0, err
+	}
+	return len(b), nil
+}
+
+// withRequestID appends the request ID member to a JSON object, keeping the
+// members before it in order. It returns false when body is not an object.
+func withRequestID(body []byte, requestID string) ([]byte, bool) {
+	trimmed := bytes.TrimSpace(body)
+	if len(trimmed) < 2 || trimmed[0] != '{' || trimmed[len(trimmed)-1] != '}' || !json.Valid(trimmed) {
+		return nil, false
+	}
+	member, err := json.Marshal(requestID)
+	if err != nil {
+		return nil, false
+	}
+
+	inner := bytes.TrimSpace(trimmed[1 : len(trimmed)-1])
+	out := make([]byte, 0, len(trimmed)+len(requestIDField)+len(member)+8)
+	out = append(out, '{')
+	if len(inner) > 0 {
+		out = append(out, inner...)
+		out = append(out, ',')
+	}
+	out = append(out, `"`+requestIDField+`":`...)
+	out = append(out, member...)
+	out = append(out, '}')
+	return out, true
+}
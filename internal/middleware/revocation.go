@@ -0,0 +1,46 @@
+package middleware
+
+import (
+	"gojwt-rest-api/internal/domain"
+	"gojwt-rest-api/internal/service"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RevocationMiddleware rejects access tokens of users whose tokens were all
+// revoked, e.g. deleted users whose tokens have not expired yet, tokens
+// issued before the user's session epoch was bumped and tokens of revoked
+// client application sessions. It must run after AuthMiddleware.
+func RevocationMiddleware(userService service.UserService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if _, ok := GetAPIKeyID(c); ok {
+			c.Next()
+			return
+		}
+
+		userID, exists := GetUserID(c)
+		if !exists {
+			c.Next()
+			return
+		}
+
+		revoked, err := userService.AccessRevoked(userID)
+		if claims, ok := GetClaims(c); ok && err == nil && !revoked {
+			revoked, err = userService.SessionRevoked(userID, claims.SessionEpoch)
+			if err == nil && !revoked && claims.ClientID != "" && claims.IssuedAt != nil {
+				revoked, err = userService.ClientSessionRevoked(userID, claims.ClientID, claims.IssuedAt.Time)
+			}
+		}
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusInternalServerError, domain.ErrorResponse("failed to verify token", nil))
+			return
+		}
+		if revoked {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, domain.ErrorResponse(domain.ErrInvalidOrExpiredToken.Error(), nil))
+			return
+		}
+
+		c.Next()
+	}
+}
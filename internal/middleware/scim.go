@@ -0,0 +1,27 @@
+package middleware
+
+import (
+	"crypto/subtle"
+	"gojwt-rest-api/internal/scim"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SCIMAuthMiddleware authenticates identity provider provisioning requests
+// with the configured static bearer token
+func SCIMAuthMiddleware(token string) gin.HandlerFunc {
+	expected := []byte(token)
+
+	return func(c *gin.Context) {
+		presented, ok := strings.CutPrefix(c.GetHeader("Authorization"), bearerPrefix)
+		if !ok || subtle.ConstantTimeCompare([]byte(presented), expected) != 1 {
+			c.Header("Content-Type", scim.ContentType)
+			c.AbortWithStatusJSON(http.StatusUnauthorized, scim.NewError(http.StatusUnauthorized, "", "invalid provisioning token"))
+			return
+		}
+
+		c.Next()
+	}
+}
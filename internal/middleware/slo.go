@@ -0,0 +1,25 @@
+package middleware
+
+import (
+	"gojwt-rest-api/internal/metrics"
+
+	"github.com/gin-gonic/gin"
+)
+
+const contextServerFailureKey = "slo_server_failure"
+
+// MarkServerFailure flags a request answered with a client error status for a
+// server-side reason, e.g. an overloaded password check, so that it counts
+// against the auth success SLO
+func MarkServerFailure(c *gin.Context) {
+	c.Set(contextServerFailureKey, true)
+}
+
+// SLOMiddleware records every completed request against the service level objectives
+func SLOMiddleware(tracker *metrics.SLOTracker) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Next()
+
+		tracker.RecordRequest(c.FullPath(), c.Writer.Status(), c.GetBool(contextServerFailureKey))
+	}
+}
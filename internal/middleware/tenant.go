@@ -0,0 +1,42 @@
+package middleware
+
+import (
+	"gojwt-rest-api/internal/domain"
+	"gojwt-rest-api/internal/service"
+	"gojwt-rest-api/internal/tenant"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// TenantMiddleware scopes the request context to the organization of the
+// authenticated user, so database statements executed with the request
+// context only see records of that organization. Handlers pass the request
+// context on with the WithContext methods of the services, e.g.
+// service.UserService.WithContext. Global admins are not scoped. It must run
+// after authentication.
+func TenantMiddleware(userService service.UserService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, exists := GetUserID(c)
+		if !exists {
+			c.Next()
+			return
+		}
+
+		user, err := userService.GetUserByID(userID)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, domain.ErrorResponse("user not found", nil))
+			return
+		}
+
+		if !user.IsAdmin {
+			var orgID uint
+			if user.OrganizationID != nil {
+				orgID = *user.OrganizationID
+			}
+			c.Request = c.Request.WithContext(tenant.WithOrganization(c.Request.Context(), orgID))
+		}
+
+		c.Next()
+	}
+}
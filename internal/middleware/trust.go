@@ -0,0 +1,128 @@
+package middleware
+
+import (
+	"net/netip"
+	"slices"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	contextTrustZoneKey     = "trust_zone"
+	contextTrustBoundaryKey = "trust_boundary"
+)
+
+// Trust zones of requests
+const (
+	TrustZoneInternal = "internal"
+	TrustZoneExternal = "external"
+)
+
+// TrustBoundary separates internal callers, such as other services of the
+// deployment, from external ones
+type TrustBoundary struct {
+	// Networks are the networks of internal callers. They are matched against
+	// the connection peer, not X-Forwarded-For, which clients can forge.
+	Networks []netip.Prefix
+	// ClientCerts trusts callers presenting a verified client certificate
+	ClientCerts bool
+	// APIKeyIDs trusts callers authenticated by APIKeyMiddleware with one of
+	// these keys. Any user can create API keys, so only dedicated keys, such
+	// as those of service accounts, may be listed. API keys are authenticated
+	// after rate limiting, so these callers are only internal to the
+	// middleware and handlers running after APIKeyMiddleware.
+	APIKeyIDs []uint
+}
+
+// TrustBoundaryMiddleware classifies requests as internal or external to
+// boundary, for GetTrustZone. Register it before RateLimitMiddleware so that
+// internal callers get the internal limit.
+func TrustBoundaryMiddleware(boundary TrustBoundary) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		zone := TrustZoneExternal
+		if boundary.trustsConnection(c) {
+			zone = TrustZoneInternal
+		}
+		c.Set(contextTrustZoneKey, zone)
+		c.Set(contextTrustBoundaryKey, &boundary)
+		c.Next()
+	}
+}
+
+// trustsConnection reports whether the connection of the request is from
+// inside the boundary
+func (b *TrustBoundary) trustsConnection(c *gin.Context) bool {
+	if b.ClientCerts && c.Request.TLS != nil && len(c.Request.TLS.VerifiedChains) > 0 {
+		return true
+	}
+	if len(b.Networks) == 0 {
+		return false
+	}
+	addr, err := netip.ParseAddr(c.RemoteIP())
+	if err != nil {
+		return false
+	}
+	addr = addr.Unmap()
+	for _, network := range b.Networks {
+		if network.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// GetTrustZone returns whether the request is internal or external. Requests
+// not classified by TrustBoundaryMiddleware are external.
+func GetTrustZone(c *gin.Context) string {
+	if c.GetString(contextTrustZoneKey) == TrustZoneInternal {
+		return TrustZoneInternal
+	}
+	if boundary, exists := c.Get(contextTrustBoundaryKey); exists {
+		if keyID, ok := GetAPIKeyID(c); ok && slices.Contains(boundary.(*TrustBoundary).APIKeyIDs, keyID) {
+			return TrustZoneInternal
+		}
+	}
+	return TrustZoneExternal
+}
+
+// IsInternal reports whether the request is from inside the trust boundary
+func IsInternal(c *gin.Context) bool {
+	return GetTrustZone(c) == TrustZoneInternal
+}
+
+// InternalOnlyMiddleware hides routes from external callers, answering them
+// as if the route did not exist
+func InternalOnlyMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !IsInternal(c) {
+			NotFoundHandler(c)
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
+
+// LoopbackOnlyMiddleware hides routes from callers not connected from the
+// local host, over a loopback address or a unix socket. Like TrustBoundary,
+// it checks the connection peer, not X-Forwarded-For.
+func LoopbackOnlyMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !isLoopbackPeer(c) {
+			NotFoundHandler(c)
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
+
+// isLoopbackPeer reports whether the connection of the request is local.
+// Peers of unix sockets have no address.
+func isLoopbackPeer(c *gin.Context) bool {
+	if c.Request.RemoteAddr == "" || c.Request.RemoteAddr == "@" {
+		return true
+	}
+	addr, err := netip.ParseAddr(c.RemoteIP())
+	return err == nil && addr.Unmap().IsLoopback()
+}
@@ -0,0 +1,222 @@
+// Package pii encrypts personal data at rest. Columns tagged with
+// `gorm:"serializer:pii"` are encrypted with AES-GCM when a keyring is in use,
+// and blind indexes keep exact-match lookups on them possible.
+package pii
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync/atomic"
+
+	"gorm.io/gorm/schema"
+)
+
+// encryptedPrefix marks encrypted values, which are stored as
+// "enc:<key id>:<base64 nonce and ciphertext>"
+const encryptedPrefix = "enc:"
+
+var (
+	// ErrUnknownKey is returned when a value was encrypted with a key that is not in the keyring
+	ErrUnknownKey = errors.New("pii: value encrypted with unknown key")
+	// ErrMalformedValue is returned when an encrypted value cannot be decoded
+	ErrMalformedValue = errors.New("pii: malformed encrypted value")
+)
+
+// Keyring holds the current encryption key, retired keys still needed to
+// decrypt existing values, and the blind index key
+type Keyring struct {
+	currentID     string
+	keys          map[string]cipher.AEAD
+	blindIndexKey []byte
+}
+
+// NewKeyring creates a keyring. Keys are "<id>:<base64 32 byte key>"; values
+// are encrypted with current and decrypted with current or any previous key.
+func NewKeyring(current string, previous []string, blindIndexKey string) (*Keyring, error) {
+	k := &Keyring{keys: make(map[string]cipher.AEAD)}
+
+	for i, key := range append([]string{current}, previous...) {
+		id, aead, err := parseKey(key)
+		if err != nil {
+			return nil, err
+		}
+		if _, exists := k.keys[id]; exists {
+			return nil, fmt.Errorf("pii: duplicate key id %q", id)
+		}
+		if i == 0 {
+			k.currentID = id
+		}
+		k.keys[id] = aead
+	}
+
+	indexKey, err := base64.StdEncoding.DecodeString(blindIndexKey)
+	if err != nil || len(indexKey) < 32 {
+		return nil, errors.New("pii: blind index key must be at least 32 base64 encoded bytes")
+	}
+	k.blindIndexKey = indexKey
+
+	return k, nil
+}
+
+// parseKey parses an "<id>:<base64 key>" AES-256 key
+func parseKey(key string) (string, cipher.AEAD, error) {
+	id, encoded, ok := strings.Cut(key, ":")
+	if !ok || id == "" {
+		return "", nil, errors.New("pii: keys must have the form <id>:<base64 key>")
+	}
+	raw, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil || len(raw) != 32 {
+		return "", nil, fmt.Errorf("pii: key %q must be 32 base64 encoded bytes", id)
+	}
+	block, err := aes.NewCipher(raw)
+	if err != nil {
+		return "", nil, err
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return "", nil, err
+	}
+	return id, aead, nil
+}
+
+// Encrypt encrypts plaintext with the current key
+func (k *Keyring) Encrypt(plaintext string) (string, error) {
+	aead := k.keys[k.currentID]
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+	sealed := aead.Seal(nonce, nonce, []byte(plaintext), []byte(k.currentID))
+	return encryptedPrefix + k.currentID + ":" + base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+// Decrypt decrypts a value encrypted with any key of the keyring. Values
+// that are not encrypted, e.g. written before encryption was enabled, are
+// returned unchanged.
+func (k *Keyring) Decrypt(value string) (string, error) {
+	if !IsEncrypted(value) {
+		return value, nil
+	}
+
+	id, encoded, ok := strings.Cut(strings.TrimPrefix(value, encryptedPrefix), ":")
+	if !ok {
+		return "", ErrMalformedValue
+	}
+	aead, exists := k.keys[id]
+	if !exists {
+		return "", ErrUnknownKey
+	}
+	sealed, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil || len(sealed) < aead.NonceSize() {
+		return "", ErrMalformedValue
+	}
+
+	plaintext, err := aead.Open(nil, sealed[:aead.NonceSize()], sealed[aead.NonceSize():], []byte(id))
+	if err != nil {
+		return "", ErrMalformedValue
+	}
+	return string(plaintext), nil
+}
+
+// IsCurrent reports whether value is encrypted with the current key, i.e.
+// does not need to be re-encrypted after a key rotation
+func (k *Keyring) IsCurrent(value string) bool {
+	return strings.HasPrefix(value, encryptedPrefix+k.currentID+":")
+}
+
+// BlindIndex returns a keyed hash of value for exact-match lookups.
+// Values are compared case-insensitively, like the email column collation.
+func (k *Keyring) BlindIndex(value string) string {
+	mac := hmac.New(sha256.New, k.blindIndexKey)
+	mac.Write([]byte(strings.ToLower(strings.TrimSpace(value))))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// IsEncrypted reports whether value was produced by Encrypt
+func IsEncrypted(value string) bool {
+	return strings.HasPrefix(value, encryptedPrefix)
+}
+
+// active is the keyring used by the serializer, nil when encryption is disabled
+var active atomic.Pointer[Keyring]
+
+// Use enables encryption with keyring, or disables it when keyring is nil
+func Use(keyring *Keyring) {
+	active.Store(keyring)
+}
+
+// Enabled reports whether PII encryption is enabled
+func Enabled() bool {
+	return active.Load() != nil
+}
+
+// BlindIndex returns the blind index of value, or nil when encryption is disabled
+func BlindIndex(value string) *string {
+	keyring := active.Load()
+	if keyring == nil {
+		return nil
+	}
+	index := keyring.BlindIndex(value)
+	return &index
+}
+
+func init() {
+	schema.RegisterSerializer("pii", Serializer{})
+}
+
+// Serializer encrypts string columns with the active keyring. Without a
+// keyring values are stored as plaintext.
+type Serializer struct{}
+
+// Scan implements schema.SerializerInterface
+func (Serializer) Scan(ctx context.Context, field *schema.Field, dst reflect.Value, dbValue interface{}) error {
+	var value string
+	switch v := dbValue.(type) {
+	case nil:
+	case []byte:
+		value = string(v)
+	case string:
+		value = v
+	default:
+		return fmt.Errorf("pii: unsupported column value %T", dbValue)
+	}
+
+	if IsEncrypted(value) {
+		keyring := active.Load()
+		if keyring == nil {
+			return ErrUnknownKey
+		}
+		plaintext, err := keyring.Decrypt(value)
+		if err != nil {
+			return err
+		}
+		value = plaintext
+	}
+
+	field.ReflectValueOf(ctx, dst).SetString(value)
+	return nil
+}
+
+// Value implements schema.SerializerValuerInterface
+func (Serializer) Value(ctx context.Context, field *schema.Field, dst reflect.Value, fieldValue interface{}) (interface{}, error) {
+	value, ok := fieldValue.(string)
+	if !ok {
+		return nil, fmt.Errorf("pii: unsupported field type %T", fieldValue)
+	}
+
+	keyring := active.Load()
+	if keyring == nil {
+		return value, nil
+	}
+	return keyring.Encrypt(value)
+}
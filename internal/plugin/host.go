@@ -0,0 +1,164 @@
+package plugin
+
+import (
+	"fmt"
+	"gojwt-rest-api/internal/config"
+	"gojwt-rest-api/internal/events"
+	"gojwt-rest-api/internal/routecheck"
+	"gojwt-rest-api/internal/service"
+	"gojwt-rest-api/pkg/logger"
+	"gojwt-rest-api/pkg/validator"
+	"net/http"
+	"path"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// Host is what the server offers plugins to extend it. The infrastructure is
+// set when plugins register; the services are handed to their routes.
+type Host struct {
+	Config *config.Config
+	// DB is the database of the server. Plugins migrate their own tables.
+	DB     *gorm.DB
+	Logger *logger.Logger
+	// Events publishes events to the subscribers, webhooks included
+	Events events.Publisher
+
+	bus        *events.Bus
+	current    string
+	routes     []pluginRoutes
+	middleware []gin.HandlerFunc
+	enrichers  []service.ClaimsEnricher
+}
+
+// pluginRoutes is the route registration of a plugin
+type pluginRoutes struct {
+	plugin   string
+	register func(r *Router)
+}
+
+// NewHost creates the host plugins register with
+func NewHost(cfg *config.Config, db *gorm.DB, appLogger *logger.Logger, bus *events.Bus) *Host {
+	return &Host{
+		Config: cfg,
+		DB:     db,
+		Logger: appLogger,
+		Events: bus,
+		bus:    bus,
+	}
+}
+
+// Load registers plugins with the host, in order
+func (h *Host) Load(plugins ...Plugin) error {
+	for _, p := range plugins {
+		h.current = p.Name()
+		if err := p.Register(h); err != nil {
+			return fmt.Errorf("plugin %s: %w", p.Name(), err)
+		}
+		h.Logger.Info("Plugin loaded:", p.Name())
+	}
+	h.current = ""
+	return nil
+}
+
+// AddRoutes registers the routes of the plugin, which register receives
+// once the services are available. See Router for where they are mounted.
+func (h *Host) AddRoutes(register func(r *Router)) {
+	h.routes = append(h.routes, pluginRoutes{plugin: h.current, register: register})
+}
+
+// Use adds middleware to every /api/v1 route, core routes included, e.g. to
+// validate requests further. It runs before authentication.
+func (h *Host) Use(middleware ...gin.HandlerFunc) {
+	h.middleware = append(h.middleware, middleware...)
+}
+
+// AddClaimsEnricher adds the claims of enricher to every issued access token
+func (h *Host) AddClaimsEnricher(enricher service.ClaimsEnricher) {
+	h.enrichers = append(h.enrichers, enricher)
+}
+
+// Subscribe calls handler asynchronously for every published event of
+// eventType, or every event for events.AllEvents
+func (h *Host) Subscribe(eventType string, handler events.Handler) {
+	h.bus.Subscribe(eventType, handler)
+}
+
+// Middleware returns the middleware added by plugins
+func (h *Host) Middleware() []gin.HandlerFunc {
+	return h.middleware
+}
+
+// ClaimsEnrichers returns the claims enrichers added by plugins
+func (h *Host) ClaimsEnrichers() []service.ClaimsEnricher {
+	return h.enrichers
+}
+
+// RouteDeps are the middleware chains and services plugin routes are built with
+type RouteDeps struct {
+	// Protected authenticates requests, as on the core protected routes
+	Protected []gin.HandlerFunc
+	// Admin additionally restricts requests to admins
+	Admin     []gin.HandlerFunc
+	Users     service.UserService
+	Audit     service.AuditService
+	Validator *validator.Validator
+}
+
+// MountRoutes registers the routes of the plugins on api, the /api/v1 group,
+// returning those served without authentication
+func (h *Host) MountRoutes(api *gin.RouterGroup, deps RouteDeps) []routecheck.Route {
+	var public []routecheck.Route
+	for _, routes := range h.routes {
+		base := api.Group("/plugins/" + routes.plugin)
+		r := &Router{
+			Protected: base.Group("", deps.Protected...),
+			Admin:     api.Group("/admin/plugins/"+routes.plugin, append(append([]gin.HandlerFunc{}, deps.Protected...), deps.Admin...)...),
+			Users:     deps.Users,
+			Audit:     deps.Audit,
+			Validator: deps.Validator,
+			public:    base,
+		}
+		routes.register(r)
+		public = append(public, r.publicRoutes...)
+	}
+	return public
+}
+
+// Router is where a plugin registers its routes. They are mounted under
+// /api/v1/plugins/<name>, and admin routes under /api/v1/admin/plugins/<name>,
+// so that they never collide with core routes.
+type Router struct {
+	// Protected serves authenticated users
+	Protected *gin.RouterGroup
+	// Admin serves admins only
+	Admin *gin.RouterGroup
+
+	Users     service.UserService
+	Audit     service.AuditService
+	Validator *validator.Validator
+
+	public       *gin.RouterGroup
+	publicRoutes []routecheck.Route
+}
+
+// Public registers a route served without authentication, exempting it from
+// the startup check that every API route is authenticated
+func (r *Router) Public(method, relativePath string, handlers ...gin.HandlerFunc) {
+	r.public.Handle(method, relativePath, handlers...)
+	r.publicRoutes = append(r.publicRoutes, routecheck.Route{
+		Method: method,
+		Path:   path.Join(r.public.BasePath(), relativePath),
+	})
+}
+
+// PublicGET registers a public GET route, see Public
+func (r *Router) PublicGET(relativePath string, handlers ...gin.HandlerFunc) {
+	r.Public(http.MethodGet, relativePath, handlers...)
+}
+
+// PublicPOST registers a public POST route, see Public
+func (r *Router) PublicPOST(relativePath string, handlers ...gin.HandlerFunc) {
+	r.Public(http.MethodPost, relativePath, handlers...)
+}
@@ -0,0 +1,66 @@
+// Package plugin lets modules compiled into the server extend it without
+// patching core files. A module registers its Plugin from an init function,
+// and is enabled by a blank import in cmd/api/plugins.go:
+//
+//	import _ "gojwt-rest-api/plugins/auditexport"
+//
+// At startup every registered plugin is handed a Host, to which it adds
+// routes, middleware, claims enrichers and event subscribers.
+package plugin
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"sync"
+)
+
+// namePattern is the form of plugin names, which appear in route paths
+var namePattern = regexp.MustCompile(`^[a-z0-9][a-z0-9-]{0,49}$`)
+
+// Plugin is a module extending the server
+type Plugin interface {
+	// Name identifies the plugin in logs and in the paths of its routes:
+	// lowercase letters, digits and '-'
+	Name() string
+	// Register adds the extensions of the plugin to host. Errors stop the
+	// server from starting.
+	Register(host *Host) error
+}
+
+var (
+	mu      sync.Mutex
+	plugins = make(map[string]Plugin)
+)
+
+// Register makes a plugin available to the server. It panics on invalid and
+// duplicate names, as both are programming errors.
+func Register(p Plugin) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	name := p.Name()
+	if !namePattern.MatchString(name) {
+		panic(fmt.Sprintf("plugin: invalid name %q", name))
+	}
+	if _, exists := plugins[name]; exists {
+		panic(fmt.Sprintf("plugin: Register called twice for %q", name))
+	}
+	plugins[name] = p
+}
+
+// Registered returns the registered plugins ordered by name, the order in
+// which they are loaded
+func Registered() []Plugin {
+	mu.Lock()
+	defer mu.Unlock()
+
+	registered := make([]Plugin, 0, len(plugins))
+	for _, p := range plugins {
+		registered = append(registered, p)
+	}
+	sort.Slice(registered, func(i, j int) bool {
+		return registered[i].Name() < registered[j].Name()
+	})
+	return registered
+}
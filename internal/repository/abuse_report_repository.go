@@ -0,0 +1,13 @@
+package repository
+
+import "gojwt-rest-api/internal/domain"
+
+// AbuseReportRepository defines the interface for abuse report data access
+type AbuseReportRepository interface {
+	Create(report *domain.AbuseReport) error
+	// FindByID returns domain.ErrAbuseReportNotFound for unknown reports
+	FindByID(id uint) (*domain.AbuseReport, error)
+	// Find lists matching reports, newest first
+	Find(filter *domain.AbuseReportFilter, offset, limit int) ([]*domain.AbuseReport, int64, error)
+	Update(report *domain.AbuseReport) error
+}
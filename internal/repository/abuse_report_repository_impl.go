@@ -0,0 +1,57 @@
+package repository
+
+import (
+	"gojwt-rest-api/internal/domain"
+
+	"gorm.io/gorm"
+)
+
+// abuseReportRepositoryImpl is the implementation of AbuseReportRepository
+type abuseReportRepositoryImpl struct {
+	db *gorm.DB
+}
+
+// NewAbuseReportRepository creates a new abuse report repository
+func NewAbuseReportRepository(db *gorm.DB) AbuseReportRepository {
+	return &abuseReportRepositoryImpl{db: db}
+}
+
+// Create stores a submitted report
+func (r *abuseReportRepositoryImpl) Create(report *domain.AbuseReport) error {
+	return r.db.Create(report).Error
+}
+
+// FindByID finds a report by ID
+func (r *abuseReportRepositoryImpl) FindByID(id uint) (*domain.AbuseReport, error) {
+	var report domain.AbuseReport
+	if err := r.db.First(&report, id).Error; err != nil {
+		return nil, translateError(err, domain.ErrAbuseReportNotFound, nil)
+	}
+	return &report, nil
+}
+
+// Find lists matching reports, newest first
+func (r *abuseReportRepositoryImpl) Find(filter *domain.AbuseReportFilter, offset, limit int) ([]*domain.AbuseReport, int64, error) {
+	var reports []*domain.AbuseReport
+	var total int64
+
+	query := r.db.Model(&domain.AbuseReport{})
+	if filter.Category != "" {
+		query = query.Where("category = ?", filter.Category)
+	}
+	if filter.Status != "" {
+		query = query.Where("status = ?", filter.Status)
+	}
+
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	err := query.Order("id DESC").Offset(offset).Limit(limit).Find(&reports).Error
+	return reports, total, err
+}
+
+// Update saves the triage of a report
+func (r *abuseReportRepositoryImpl) Update(report *domain.AbuseReport) error {
+	return r.db.Save(report).Error
+}
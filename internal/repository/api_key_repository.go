@@ -0,0 +1,25 @@
+package repository
+
+import (
+	"gojwt-rest-api/internal/domain"
+	"time"
+)
+
+// APIKeyRepository defines the interface for API key data operations
+type APIKeyRepository interface {
+	Create(key *domain.APIKey) error
+	FindByID(id uint) (*domain.APIKey, error)
+	FindByHash(keyHash string) (*domain.APIKey, error)
+	FindByUserID(userID uint) ([]*domain.APIKey, error)
+	FindAll() ([]*domain.APIKey, error)
+	// FindActiveCreatedBefore returns keys that are neither revoked nor expired
+	// and were created before cutoff, with their owner preloaded
+	FindActiveCreatedBefore(cutoff time.Time) ([]*domain.APIKey, error)
+	Update(key *domain.APIKey) error
+	// RevokeAllUserAPIKeys revokes the API keys of a user that are not revoked yet
+	RevokeAllUserAPIKeys(userID uint) error
+	// RecordUsage adds count requests to the key's usage of an endpoint and bumps its last-used time
+	RecordUsage(apiKeyID uint, method, route string, count int64, lastUsedAt time.Time) error
+	// FindUsage returns the key's per-endpoint usage, most requested first
+	FindUsage(apiKeyID uint) ([]*domain.APIKeyUsage, error)
+}
@@ -0,0 +1,116 @@
+package repository
+
+import (
+	"gojwt-rest-api/internal/domain"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// apiKeyRepositoryImpl is the implementation of APIKeyRepository
+type apiKeyRepositoryImpl struct {
+	db *gorm.DB
+}
+
+// NewAPIKeyRepository creates a new API key repository
+func NewAPIKeyRepository(db *gorm.DB) APIKeyRepository {
+	return &apiKeyRepositoryImpl{db: db}
+}
+
+// Create creates a new API key
+func (r *apiKeyRepositoryImpl) Create(key *domain.APIKey) error {
+	return r.db.Create(key).Error
+}
+
+// FindByID finds an API key by ID
+func (r *apiKeyRepositoryImpl) FindByID(id uint) (*domain.APIKey, error) {
+	var key domain.APIKey
+	err := r.db.First(&key, id).Error
+	if err != nil {
+		return nil, translateError(err, domain.ErrAPIKeyNotFound, nil)
+	}
+	return &key, nil
+}
+
+// FindByHash finds an API key by the hash of its plaintext value, with its owner preloaded
+func (r *apiKeyRepositoryImpl) FindByHash(keyHash string) (*domain.APIKey, error) {
+	var key domain.APIKey
+	err := r.db.Preload("User").Where("key_hash = ?", keyHash).First(&key).Error
+	if err != nil {
+		return nil, translateError(err, domain.ErrAPIKeyNotFound, nil)
+	}
+	return &key, nil
+}
+
+// FindByUserID finds all API keys owned by a user
+func (r *apiKeyRepositoryImpl) FindByUserID(userID uint) ([]*domain.APIKey, error) {
+	var keys []*domain.APIKey
+	err := r.db.Where("user_id = ?", userID).Order("id").Find(&keys).Error
+	return keys, err
+}
+
+// FindAll finds all API keys
+func (r *apiKeyRepositoryImpl) FindAll() ([]*domain.APIKey, error) {
+	var keys []*domain.APIKey
+	err := r.db.Order("id").Find(&keys).Error
+	return keys, err
+}
+
+// FindActiveCreatedBefore finds usable API keys created before cutoff
+func (r *apiKeyRepositoryImpl) FindActiveCreatedBefore(cutoff time.Time) ([]*domain.APIKey, error) {
+	var keys []*domain.APIKey
+	err := r.db.Preload("User").
+		Where("revoked_at IS NULL AND (expires_at IS NULL OR expires_at > ?) AND created_at < ?", time.Now(), cutoff).
+		Order("id").
+		Find(&keys).Error
+	return keys, err
+}
+
+// Update updates an API key without touching its preloaded owner
+func (r *apiKeyRepositoryImpl) Update(key *domain.APIKey) error {
+	return r.db.Omit(clause.Associations).Save(key).Error
+}
+
+// RevokeAllUserAPIKeys revokes all API keys of a user
+func (r *apiKeyRepositoryImpl) RevokeAllUserAPIKeys(userID uint) error {
+	return r.db.Model(&domain.APIKey{}).
+		Where("user_id = ? AND revoked_at IS NULL", userID).
+		Update("revoked_at", time.Now()).Error
+}
+
+// RecordUsage upserts the endpoint usage row and updates the key's last-used time
+func (r *apiKeyRepositoryImpl) RecordUsage(apiKeyID uint, method, route string, count int64, lastUsedAt time.Time) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		usage := &domain.APIKeyUsage{
+			APIKeyID:     apiKeyID,
+			Method:       method,
+			Route:        route,
+			RequestCount: count,
+			LastUsedAt:   lastUsedAt,
+		}
+		err := tx.Omit(clause.Associations).Clauses(clause.OnConflict{
+			Columns: []clause.Column{{Name: "api_key_id"}, {Name: "method"}, {Name: "route"}},
+			DoUpdates: clause.Assignments(map[string]interface{}{
+				"request_count": gorm.Expr("request_count + ?", count),
+				"last_used_at":  lastUsedAt,
+			}),
+		}).Create(usage).Error
+		if err != nil {
+			return err
+		}
+
+		return tx.Model(&domain.APIKey{}).
+			Where("id = ? AND (last_used_at IS NULL OR last_used_at < ?)", apiKeyID, lastUsedAt).
+			Update("last_used_at", lastUsedAt).Error
+	})
+}
+
+// FindUsage finds the per-endpoint usage of an API key, most requested first
+func (r *apiKeyRepositoryImpl) FindUsage(apiKeyID uint) ([]*domain.APIKeyUsage, error) {
+	var usage []*domain.APIKeyUsage
+	err := r.db.Where("api_key_id = ?", apiKeyID).
+		Order("request_count DESC, route").
+		Find(&usage).Error
+	return usage, err
+}
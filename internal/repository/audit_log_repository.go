@@ -0,0 +1,23 @@
+package repository
+
+import (
+	"context"
+	"gojwt-rest-api/internal/domain"
+	"time"
+)
+
+// AuditLogRepository defines the interface for audit log data access
+type AuditLogRepository interface {
+	// WithContext returns the repository executing its statements with ctx,
+	// which scopes them to the organization of a tenant request
+	WithContext(ctx context.Context) AuditLogRepository
+	Create(log *domain.AuditLog) error
+	// Find lists matching audit logs, newest first
+	Find(filter *domain.AuditLogFilter, offset, limit int) ([]*domain.AuditLog, int64, error)
+	// Purge deletes up to limit audit logs created before before, returning the number deleted
+	Purge(before time.Time, limit int) (int64, error)
+	// FindBefore lists up to limit audit logs created before before, oldest first
+	FindBefore(before time.Time, limit int) ([]*domain.AuditLog, error)
+	// DeleteByIDs deletes the audit logs with the IDs, returning the number deleted
+	DeleteByIDs(ids []uint) (int64, error)
+}
@@ -0,0 +1,75 @@
+package repository
+
+import (
+	"context"
+	"gojwt-rest-api/internal/domain"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// auditLogRepositoryImpl is the implementation of AuditLogRepository
+type auditLogRepositoryImpl struct {
+	db *gorm.DB
+}
+
+// NewAuditLogRepository creates a new audit log repository
+func NewAuditLogRepository(db *gorm.DB) AuditLogRepository {
+	return &auditLogRepositoryImpl{db: db}
+}
+
+// WithContext returns the repository executing its statements with ctx
+func (r *auditLogRepositoryImpl) WithContext(ctx context.Context) AuditLogRepository {
+	return &auditLogRepositoryImpl{db: r.db.WithContext(ctx)}
+}
+
+// Create appends an audit log
+func (r *auditLogRepositoryImpl) Create(log *domain.AuditLog) error {
+	return r.db.Create(log).Error
+}
+
+// Find lists matching audit logs, newest first
+func (r *auditLogRepositoryImpl) Find(filter *domain.AuditLogFilter, offset, limit int) ([]*domain.AuditLog, int64, error) {
+	var logs []*domain.AuditLog
+	var total int64
+
+	query := r.db.Model(&domain.AuditLog{})
+	if filter.Action != "" {
+		query = query.Where("action = ?", filter.Action)
+	}
+	if filter.UserID != 0 {
+		query = query.Where("user_id = ?", filter.UserID)
+	}
+	if filter.OrganizationID != 0 {
+		query = query.Where("organization_id = ?", filter.OrganizationID)
+	}
+
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	err := query.Order("id DESC").Offset(offset).Limit(limit).Find(&logs).Error
+	return logs, total, err
+}
+
+// Purge deletes up to limit audit logs created before before
+func (r *auditLogRepositoryImpl) Purge(before time.Time, limit int) (int64, error) {
+	result := r.db.Where("created_at < ?", before).Limit(limit).Delete(&domain.AuditLog{})
+	return result.RowsAffected, result.Error
+}
+
+// FindBefore lists up to limit audit logs created before before, oldest first
+func (r *auditLogRepositoryImpl) FindBefore(before time.Time, limit int) ([]*domain.AuditLog, error) {
+	var logs []*domain.AuditLog
+	err := r.db.Where("created_at < ?", before).Order("id ASC").Limit(limit).Find(&logs).Error
+	return logs, err
+}
+
+// DeleteByIDs deletes the audit logs with the IDs
+func (r *auditLogRepositoryImpl) DeleteByIDs(ids []uint) (int64, error) {
+	if len(ids) == 0 {
+		return 0, nil
+	}
+	result := r.db.Where("id IN ?", ids).Delete(&domain.AuditLog{})
+	return result.RowsAffected, result.Error
+}
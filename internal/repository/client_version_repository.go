@@ -0,0 +1,14 @@
+package repository
+
+import "gojwt-rest-api/internal/domain"
+
+// ClientVersionRepository defines the interface for client version policy data access
+type ClientVersionRepository interface {
+	// FindAll returns the minimum versions of all client applications having one
+	FindAll() ([]*domain.ClientVersionPolicy, error)
+	// Save creates or replaces the minimum version of a client application
+	Save(policy *domain.ClientVersionPolicy) error
+	// Delete removes the minimum version of a client application, returning
+	// domain.ErrClientMinVersionNotSet when it has none
+	Delete(clientID string) error
+}
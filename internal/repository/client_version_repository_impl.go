@@ -0,0 +1,41 @@
+package repository
+
+import (
+	"gojwt-rest-api/internal/domain"
+
+	"gorm.io/gorm"
+)
+
+// clientVersionRepositoryImpl is the implementation of ClientVersionRepository
+type clientVersionRepositoryImpl struct {
+	db *gorm.DB
+}
+
+// NewClientVersionRepository creates a new client version repository
+func NewClientVersionRepository(db *gorm.DB) ClientVersionRepository {
+	return &clientVersionRepositoryImpl{db: db}
+}
+
+// FindAll returns the client version policies ordered by client ID
+func (r *clientVersionRepositoryImpl) FindAll() ([]*domain.ClientVersionPolicy, error) {
+	var policies []*domain.ClientVersionPolicy
+	err := r.db.Order("client_id").Find(&policies).Error
+	return policies, err
+}
+
+// Save upserts policy by client ID
+func (r *clientVersionRepositoryImpl) Save(policy *domain.ClientVersionPolicy) error {
+	return r.db.Save(policy).Error
+}
+
+// Delete removes the policy of a client application
+func (r *clientVersionRepositoryImpl) Delete(clientID string) error {
+	result := r.db.Where("client_id = ?", clientID).Delete(&domain.ClientVersionPolicy{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return domain.ErrClientMinVersionNotSet
+	}
+	return nil
+}
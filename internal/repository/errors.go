@@ -0,0 +1,39 @@
+package repository
+
+import (
+	"errors"
+
+	"github.com/go-sql-driver/mysql"
+	"gorm.io/gorm"
+)
+
+// mysqlDuplicateEntry is the MySQL error number of unique constraint violations
+const mysqlDuplicateEntry = 1062
+
+// translateError turns gorm and driver errors into domain errors, so services
+// and handlers can match them with errors.Is whatever the driver: missing
+// records become notFound and unique constraint violations become conflict.
+// A nil sentinel leaves its case alone, and other errors are returned as is.
+func translateError(err, notFound, conflict error) error {
+	switch {
+	case err == nil:
+		return nil
+	case notFound != nil && errors.Is(err, gorm.ErrRecordNotFound):
+		return notFound
+	case conflict != nil && isDuplicateKey(err):
+		return conflict
+	}
+	return err
+}
+
+// isDuplicateKey reports whether err is a unique constraint violation, as
+// when two requests insert the same email between a lookup and the insert.
+// It matches MySQL errors and gorm.ErrDuplicatedKey, which dialectors return
+// when gorm.Config.TranslateError is set.
+func isDuplicateKey(err error) bool {
+	var mysqlErr *mysql.MySQLError
+	if errors.As(err, &mysqlErr) {
+		return mysqlErr.Number == mysqlDuplicateEntry
+	}
+	return errors.Is(err, gorm.ErrDuplicatedKey)
+}
@@ -0,0 +1,15 @@
+package repository
+
+import (
+	"gojwt-rest-api/internal/domain"
+	"time"
+)
+
+// LoginLocationRepository defines the interface for login location data access
+type LoginLocationRepository interface {
+	Create(location *domain.LoginLocation) error
+	// FindCountriesSince returns the distinct countries a user logged in from since since
+	FindCountriesSince(userID uint, since time.Time) ([]string, error)
+	// DeleteBefore deletes the locations of a user recorded before before
+	DeleteBefore(userID uint, before time.Time) error
+}
@@ -0,0 +1,38 @@
+package repository
+
+import (
+	"gojwt-rest-api/internal/domain"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// loginLocationRepositoryImpl is the implementation of LoginLocationRepository
+type loginLocationRepositoryImpl struct {
+	db *gorm.DB
+}
+
+// NewLoginLocationRepository creates a new login location repository
+func NewLoginLocationRepository(db *gorm.DB) LoginLocationRepository {
+	return &loginLocationRepositoryImpl{db: db}
+}
+
+// Create records a login location
+func (r *loginLocationRepositoryImpl) Create(location *domain.LoginLocation) error {
+	return r.db.Omit("User").Create(location).Error
+}
+
+// FindCountriesSince returns the distinct countries a user logged in from since since
+func (r *loginLocationRepositoryImpl) FindCountriesSince(userID uint, since time.Time) ([]string, error) {
+	var countries []string
+	err := r.db.Model(&domain.LoginLocation{}).
+		Where("user_id = ? AND created_at >= ?", userID, since).
+		Distinct("country").
+		Pluck("country", &countries).Error
+	return countries, err
+}
+
+// DeleteBefore deletes the locations of a user recorded before before
+func (r *loginLocationRepositoryImpl) DeleteBefore(userID uint, before time.Time) error {
+	return r.db.Where("user_id = ? AND created_at < ?", userID, before).Delete(&domain.LoginLocation{}).Error
+}
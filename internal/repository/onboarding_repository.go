@@ -0,0 +1,17 @@
+package repository
+
+import (
+	"gojwt-rest-api/internal/domain"
+	"time"
+)
+
+// OnboardingRepository defines the interface for onboarding step data access
+type OnboardingRepository interface {
+	// CompleteStep records that a user completed step at at, keeping the
+	// time of an earlier completion
+	CompleteStep(userID uint, step string, at time.Time) error
+	// ResetStep marks a step of a user not completed
+	ResetStep(userID uint, step string) error
+	// FindCompletedSteps returns the steps a user completed
+	FindCompletedSteps(userID uint) ([]*domain.OnboardingStep, error)
+}
@@ -0,0 +1,40 @@
+package repository
+
+import (
+	"gojwt-rest-api/internal/domain"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// onboardingRepositoryImpl is the implementation of OnboardingRepository
+type onboardingRepositoryImpl struct {
+	db *gorm.DB
+}
+
+// NewOnboardingRepository creates a new onboarding repository
+func NewOnboardingRepository(db *gorm.DB) OnboardingRepository {
+	return &onboardingRepositoryImpl{db: db}
+}
+
+// CompleteStep records a completed step, ignoring steps completed before
+func (r *onboardingRepositoryImpl) CompleteStep(userID uint, step string, at time.Time) error {
+	return r.db.Omit("User").Clauses(clause.OnConflict{DoNothing: true}).Create(&domain.OnboardingStep{
+		UserID:      userID,
+		Step:        step,
+		CompletedAt: at,
+	}).Error
+}
+
+// ResetStep deletes the record of a completed step
+func (r *onboardingRepositoryImpl) ResetStep(userID uint, step string) error {
+	return r.db.Where("user_id = ? AND step = ?", userID, step).Delete(&domain.OnboardingStep{}).Error
+}
+
+// FindCompletedSteps returns the steps a user completed
+func (r *onboardingRepositoryImpl) FindCompletedSteps(userID uint) ([]*domain.OnboardingStep, error) {
+	var steps []*domain.OnboardingStep
+	err := r.db.Where("user_id = ?", userID).Find(&steps).Error
+	return steps, err
+}
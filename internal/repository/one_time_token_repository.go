@@ -0,0 +1,20 @@
+package repository
+
+import (
+	"gojwt-rest-api/internal/domain"
+	"time"
+)
+
+// OneTimeTokenRepository defines the interface for one-time token data access
+type OneTimeTokenRepository interface {
+	Create(token *domain.OneTimeToken) error
+	FindByTokenHash(purpose, tokenHash string) (*domain.OneTimeToken, error)
+	// FindActiveBySubject finds the unconsumed, unexpired tokens of the purpose issued to subject
+	FindActiveBySubject(purpose, subject string, now time.Time) ([]*domain.OneTimeToken, error)
+	// InvalidateSubject consumes the unconsumed tokens of the purpose issued to subject
+	InvalidateSubject(purpose, subject string, at time.Time) error
+	// Consume marks a token consumed, locking its row so that only one of
+	// concurrent consumers succeeds. Consumed and expired tokens return
+	// domain.ErrOneTimeTokenInvalid.
+	Consume(purpose, tokenHash string, at time.Time) (*domain.OneTimeToken, error)
+}
@@ -0,0 +1,85 @@
+package repository
+
+import (
+	"gojwt-rest-api/internal/domain"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// oneTimeTokenRepositoryImpl is the implementation of OneTimeTokenRepository
+type oneTimeTokenRepositoryImpl struct {
+	db *gorm.DB
+}
+
+// NewOneTimeTokenRepository creates a new one-time token repository
+func NewOneTimeTokenRepository(db *gorm.DB) OneTimeTokenRepository {
+	return &oneTimeTokenRepositoryImpl{db: db}
+}
+
+// Create creates a new one-time token
+func (r *oneTimeTokenRepositoryImpl) Create(token *domain.OneTimeToken) error {
+	return r.db.Create(token).Error
+}
+
+// FindByTokenHash finds a token of the purpose by the hash of its token
+func (r *oneTimeTokenRepositoryImpl) FindByTokenHash(purpose, tokenHash string) (*domain.OneTimeToken, error) {
+	var token domain.OneTimeToken
+	err := r.db.Where("purpose = ? AND token_hash = ?", purpose, tokenHash).First(&token).Error
+	if err != nil {
+		return nil, translateError(err, domain.ErrOneTimeTokenNotFound, nil)
+	}
+	return &token, nil
+}
+
+// FindActiveBySubject finds the unconsumed, unexpired tokens of the purpose issued to subject
+func (r *oneTimeTokenRepositoryImpl) FindActiveBySubject(purpose, subject string, now time.Time) ([]*domain.OneTimeToken, error) {
+	var tokens []*domain.OneTimeToken
+	err := r.db.Where("purpose = ? AND subject = ? AND consumed_at IS NULL AND expires_at > ?", purpose, subject, now).
+		Order("id").
+		Find(&tokens).Error
+	return tokens, err
+}
+
+// InvalidateSubject consumes the unconsumed tokens of the purpose issued to subject
+func (r *oneTimeTokenRepositoryImpl) InvalidateSubject(purpose, subject string, at time.Time) error {
+	return r.db.Model(&domain.OneTimeToken{}).
+		Where("purpose = ? AND subject = ? AND consumed_at IS NULL", purpose, subject).
+		Update("consumed_at", at).Error
+}
+
+// Consume marks a token consumed in a transaction holding its row lock
+func (r *oneTimeTokenRepositoryImpl) Consume(purpose, tokenHash string, at time.Time) (*domain.OneTimeToken, error) {
+	var token *domain.OneTimeToken
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		var err error
+		token, err = consumeOneTimeToken(tx, purpose, tokenHash, at)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return token, nil
+}
+
+// consumeOneTimeToken locks the row of a token and marks it consumed within
+// tx, so flows can consume a token atomically with their own changes
+func consumeOneTimeToken(tx *gorm.DB, purpose, tokenHash string, at time.Time) (*domain.OneTimeToken, error) {
+	var token domain.OneTimeToken
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("purpose = ? AND token_hash = ?", purpose, tokenHash).
+		First(&token).Error
+	if err != nil {
+		return nil, translateError(err, domain.ErrOneTimeTokenNotFound, nil)
+	}
+	if !token.IsUsable(at) {
+		return nil, domain.ErrOneTimeTokenInvalid
+	}
+
+	if err := tx.Model(&token).Update("consumed_at", at).Error; err != nil {
+		return nil, err
+	}
+	token.ConsumedAt = &at
+	return &token, nil
+}
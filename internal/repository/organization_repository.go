@@ -0,0 +1,48 @@
+package repository
+
+import (
+	"gojwt-rest-api/internal/domain"
+	"time"
+)
+
+// OrganizationRepository defines the interface for organization and plan data access
+type OrganizationRepository interface {
+	Create(org *domain.Organization) error
+	FindByID(id uint) (*domain.Organization, error)
+	FindByName(name string) (*domain.Organization, error)
+	FindAll() ([]*domain.Organization, error)
+	// AddMember assigns the user to the organization with the given role
+	AddMember(orgID uint, userID uint, role string) error
+	FindMembers(orgID uint) ([]*domain.User, error)
+	// RemoveMember detaches the user from the organization
+	RemoveMember(orgID uint, userID uint) error
+	// TransferOwnership makes newOwnerID the owner and demotes the current owners to admin
+	TransferOwnership(orgID uint, newOwnerID uint) error
+	CountMembers(orgID uint) (int64, error)
+	// CountActiveSessions counts unrevoked, unexpired refresh tokens of the organization's members
+	CountActiveSessions(orgID uint, now time.Time) (int64, error)
+	// RevokeSessions revokes the refresh tokens of every member and bumps
+	// their session epoch, invalidating their access tokens, in one transaction
+	RevokeSessions(orgID uint, at time.Time) (*domain.OrganizationSessionRevocation, error)
+
+	// Plan operations
+	CreatePlan(plan *domain.Plan) error
+	FindPlanByID(id uint) (*domain.Plan, error)
+	FindPlanByName(name string) (*domain.Plan, error)
+	FindPlans() ([]*domain.Plan, error)
+
+	// Settings operations
+	// FindSettings returns the organization's setting overrides, empty when none are stored
+	FindSettings(orgID uint) (*domain.OrganizationSettings, error)
+	SaveSettings(settings *domain.OrganizationSettings) error
+
+	// Invitation operations
+	CreateInvitation(invitation *domain.OrganizationInvitation) error
+	FindInvitationByTokenHash(tokenHash string) (*domain.OrganizationInvitation, error)
+	// FindPendingInvitation finds an unaccepted invitation of the email to the organization
+	FindPendingInvitation(orgID uint, email string) (*domain.OrganizationInvitation, error)
+	FindPendingInvitations(orgID uint, now time.Time) ([]*domain.OrganizationInvitation, error)
+	UpdateInvitation(invitation *domain.OrganizationInvitation) error
+	// AcceptInvitation marks the invitation accepted and adds the user to the organization
+	AcceptInvitation(invitation *domain.OrganizationInvitation, userID uint) error
+}
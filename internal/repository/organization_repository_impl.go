@@ -0,0 +1,311 @@
+package repository
+
+import (
+	"errors"
+	"gojwt-rest-api/internal/domain"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// organizationRepositoryImpl is the implementation of OrganizationRepository
+type organizationRepositoryImpl struct {
+	db *gorm.DB
+}
+
+// NewOrganizationRepository creates a new organization repository
+func NewOrganizationRepository(db *gorm.DB) OrganizationRepository {
+	return &organizationRepositoryImpl{db: db}
+}
+
+// Create creates a new organization
+func (r *organizationRepositoryImpl) Create(org *domain.Organization) error {
+	return translateError(r.db.Omit("Plan").Create(org).Error, nil, domain.ErrOrganizationAlreadyExists)
+}
+
+// FindByID finds an organization by ID with its plan
+func (r *organizationRepositoryImpl) FindByID(id uint) (*domain.Organization, error) {
+	var org domain.Organization
+	err := r.db.Preload("Plan").First(&org, id).Error
+	if err != nil {
+		return nil, translateError(err, domain.ErrOrganizationNotFound, nil)
+	}
+	return &org, nil
+}
+
+// FindByName finds an organization by name
+func (r *organizationRepositoryImpl) FindByName(name string) (*domain.Organization, error) {
+	var org domain.Organization
+	err := r.db.Preload("Plan").Where("name = ?", name).First(&org).Error
+	if err != nil {
+		return nil, translateError(err, domain.ErrOrganizationNotFound, nil)
+	}
+	return &org, nil
+}
+
+// FindAll finds all organizations with their plans
+func (r *organizationRepositoryImpl) FindAll() ([]*domain.Organization, error) {
+	var orgs []*domain.Organization
+	err := r.db.Preload("Plan").Order("id").Find(&orgs).Error
+	return orgs, err
+}
+
+// AddMember assigns the user to the organization with the given role
+func (r *organizationRepositoryImpl) AddMember(orgID uint, userID uint, role string) error {
+	return addMember(r.db, orgID, userID, role)
+}
+
+// addMember assigns the user to the organization within the given transaction
+func addMember(tx *gorm.DB, orgID uint, userID uint, role string) error {
+	result := tx.Model(&domain.User{}).Where("id = ?", userID).Updates(map[string]interface{}{
+		"organization_id":   orgID,
+		"organization_role": role,
+	})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return domain.ErrUserNotFound
+	}
+	return nil
+}
+
+// FindMembers finds the users of an organization
+func (r *organizationRepositoryImpl) FindMembers(orgID uint) ([]*domain.User, error) {
+	var users []*domain.User
+	err := r.db.Where("organization_id = ?", orgID).Order("id").Find(&users).Error
+	return users, err
+}
+
+// RemoveMember detaches the user from the organization
+func (r *organizationRepositoryImpl) RemoveMember(orgID uint, userID uint) error {
+	result := r.db.Model(&domain.User{}).Where("id = ? AND organization_id = ?", userID, orgID).Updates(map[string]interface{}{
+		"organization_id":   nil,
+		"organization_role": "",
+	})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return domain.ErrNotOrganizationMember
+	}
+	return nil
+}
+
+// TransferOwnership makes newOwnerID the owner and demotes the current owners to admin
+func (r *organizationRepositoryImpl) TransferOwnership(orgID uint, newOwnerID uint) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Model(&domain.User{}).
+			Where("organization_id = ? AND organization_role = ?", orgID, domain.OrgRoleOwner).
+			Update("organization_role", domain.OrgRoleAdmin).Error
+		if err != nil {
+			return err
+		}
+
+		result := tx.Model(&domain.User{}).
+			Where("id = ? AND organization_id = ?", newOwnerID, orgID).
+			Update("organization_role", domain.OrgRoleOwner)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return domain.ErrNotOrganizationMember
+		}
+		return nil
+	})
+}
+
+// CountMembers counts the active users of an organization; deactivated users
+// do not take a seat
+func (r *organizationRepositoryImpl) CountMembers(orgID uint) (int64, error) {
+	var count int64
+	err := r.db.Model(&domain.User{}).Where("organization_id = ? AND deactivated_at IS NULL", orgID).Count(&count).Error
+	return count, err
+}
+
+// CountActiveSessions counts the active refresh tokens of an organization's members
+func (r *organizationRepositoryImpl) CountActiveSessions(orgID uint, now time.Time) (int64, error) {
+	var count int64
+	err := r.db.Model(&domain.RefreshToken{}).
+		Joins("JOIN users ON users.id = refresh_tokens.user_id").
+		Where("users.organization_id = ? AND refresh_tokens.is_revoked = ? AND refresh_tokens.expires_at > ?", orgID, false, now).
+		Count(&count).Error
+	return count, err
+}
+
+// RevokeSessions revokes the sessions of every member with one statement per
+// table, however many members and token families the organization has
+func (r *organizationRepositoryImpl) RevokeSessions(orgID uint, at time.Time) (*domain.OrganizationSessionRevocation, error) {
+	revocation := &domain.OrganizationSessionRevocation{OrganizationID: orgID, RevokedAt: at}
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		members := tx.Model(&domain.User{}).Where("organization_id = ?", orgID)
+		result := members.Session(&gorm.Session{}).UpdateColumn("session_epoch", gorm.Expr("session_epoch + 1"))
+		if result.Error != nil {
+			return result.Error
+		}
+		revocation.Members = result.RowsAffected
+
+		result = tx.Model(&domain.RefreshToken{}).
+			Where("is_revoked = ? AND user_id IN (?)", false, members.Session(&gorm.Session{}).Select("id")).
+			Updates(map[string]interface{}{
+				"is_revoked": true,
+				"revoked_at": at,
+			})
+		if result.Error != nil {
+			return result.Error
+		}
+		revocation.RefreshTokens = result.RowsAffected
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return revocation, nil
+}
+
+// CreatePlan creates a new plan
+func (r *organizationRepositoryImpl) CreatePlan(plan *domain.Plan) error {
+	return translateError(r.db.Create(plan).Error, nil, domain.ErrPlanAlreadyExists)
+}
+
+// FindPlanByID finds a plan by ID
+func (r *organizationRepositoryImpl) FindPlanByID(id uint) (*domain.Plan, error) {
+	var plan domain.Plan
+	err := r.db.First(&plan, id).Error
+	if err != nil {
+		return nil, translateError(err, domain.ErrPlanNotFound, nil)
+	}
+	return &plan, nil
+}
+
+// FindPlanByName finds a plan by name
+func (r *organizationRepositoryImpl) FindPlanByName(name string) (*domain.Plan, error) {
+	var plan domain.Plan
+	err := r.db.Where("name = ?", name).First(&plan).Error
+	if err != nil {
+		return nil, translateError(err, domain.ErrPlanNotFound, nil)
+	}
+	return &plan, nil
+}
+
+// FindPlans finds all plans
+func (r *organizationRepositoryImpl) FindPlans() ([]*domain.Plan, error) {
+	var plans []*domain.Plan
+	err := r.db.Order("id").Find(&plans).Error
+	return plans, err
+}
+
+// FindSettings returns the organization's setting overrides, empty when none are stored
+func (r *organizationRepositoryImpl) FindSettings(orgID uint) (*domain.OrganizationSettings, error) {
+	var settings domain.OrganizationSettings
+	if err := r.db.Where("organization_id = ?", orgID).First(&settings).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return &domain.OrganizationSettings{OrganizationID: orgID}, nil
+		}
+		return nil, err
+	}
+	return &settings, nil
+}
+
+// SaveSettings creates or replaces the organization's setting overrides
+func (r *organizationRepositoryImpl) SaveSettings(settings *domain.OrganizationSettings) error {
+	return r.db.Save(settings).Error
+}
+
+// Invitations are stored as one-time tokens issued to the invited email
+// address, with the organization as resource
+
+// CreateInvitation creates a new invitation
+func (r *organizationRepositoryImpl) CreateInvitation(invitation *domain.OrganizationInvitation) error {
+	token, err := invitation.ToOneTimeToken()
+	if err != nil {
+		return err
+	}
+	if err := r.db.Create(token).Error; err != nil {
+		return err
+	}
+	invitation.ID = token.ID
+	invitation.CreatedAt = token.CreatedAt
+	invitation.UpdatedAt = token.UpdatedAt
+	return nil
+}
+
+// FindInvitationByTokenHash finds an invitation by the hash of its token, with its organization
+func (r *organizationRepositoryImpl) FindInvitationByTokenHash(tokenHash string) (*domain.OrganizationInvitation, error) {
+	var token domain.OneTimeToken
+	err := r.db.Where("purpose = ? AND token_hash = ?", domain.OneTimeTokenPurposeInvitation, tokenHash).First(&token).Error
+	if err != nil {
+		return nil, translateError(err, domain.ErrInvitationNotFound, nil)
+	}
+	invitation, err := domain.InvitationFromOneTimeToken(&token)
+	if err != nil {
+		return nil, err
+	}
+	if err := r.db.First(&invitation.Organization, invitation.OrganizationID).Error; err != nil {
+		return nil, translateError(err, domain.ErrInvitationNotFound, nil)
+	}
+	return invitation, nil
+}
+
+// FindPendingInvitation finds an unaccepted invitation of the email to the organization
+func (r *organizationRepositoryImpl) FindPendingInvitation(orgID uint, email string) (*domain.OrganizationInvitation, error) {
+	var token domain.OneTimeToken
+	err := r.invitations(orgID).Where("subject = ? AND consumed_at IS NULL", email).First(&token).Error
+	if err != nil {
+		return nil, translateError(err, domain.ErrInvitationNotFound, nil)
+	}
+	return domain.InvitationFromOneTimeToken(&token)
+}
+
+// FindPendingInvitations finds the unaccepted, unexpired invitations of an organization
+func (r *organizationRepositoryImpl) FindPendingInvitations(orgID uint, now time.Time) ([]*domain.OrganizationInvitation, error) {
+	var tokens []*domain.OneTimeToken
+	err := r.invitations(orgID).Where("consumed_at IS NULL AND expires_at > ?", now).
+		Order("id").
+		Find(&tokens).Error
+	if err != nil {
+		return nil, err
+	}
+
+	invitations := make([]*domain.OrganizationInvitation, 0, len(tokens))
+	for _, token := range tokens {
+		invitation, err := domain.InvitationFromOneTimeToken(token)
+		if err != nil {
+			return nil, err
+		}
+		invitations = append(invitations, invitation)
+	}
+	return invitations, nil
+}
+
+// UpdateInvitation updates the token, role, inviter and expiry of an invitation
+func (r *organizationRepositoryImpl) UpdateInvitation(invitation *domain.OrganizationInvitation) error {
+	token, err := invitation.ToOneTimeToken()
+	if err != nil {
+		return err
+	}
+	return r.db.Model(token).Select("token_hash", "payload", "expires_at").Updates(token).Error
+}
+
+// AcceptInvitation marks the invitation accepted and adds the user to the organization
+func (r *organizationRepositoryImpl) AcceptInvitation(invitation *domain.OrganizationInvitation, userID uint) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		// The row lock lets only the first concurrent acceptance win
+		_, err := consumeOneTimeToken(tx, domain.OneTimeTokenPurposeInvitation, invitation.TokenHash, *invitation.AcceptedAt)
+		switch err {
+		case nil:
+		case domain.ErrOneTimeTokenNotFound:
+			return domain.ErrInvitationNotFound
+		case domain.ErrOneTimeTokenInvalid:
+			return domain.ErrInvitationExpired
+		default:
+			return err
+		}
+		return addMember(tx, invitation.OrganizationID, userID, invitation.Role)
+	})
+}
+
+// invitations scopes a query to the invitations of an organization
+func (r *organizationRepositoryImpl) invitations(orgID uint) *gorm.DB {
+	return r.db.Where("purpose = ? AND resource_id = ?", domain.OneTimeTokenPurposeInvitation, orgID)
+}
@@ -0,0 +1,12 @@
+package repository
+
+import "gojwt-rest-api/internal/domain"
+
+// ProfileHistoryRepository defines the interface for profile change history data access
+type ProfileHistoryRepository interface {
+	// Create appends a profile change, numbering it after the latest version
+	// of the user
+	Create(change *domain.ProfileChange) error
+	// FindByUser lists the profile changes of a user, newest first
+	FindByUser(userID uint, offset, limit int) ([]*domain.ProfileChange, int64, error)
+}
@@ -0,0 +1,51 @@
+package repository
+
+import (
+	"gojwt-rest-api/internal/domain"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// profileHistoryRepositoryImpl is the implementation of ProfileHistoryRepository
+type profileHistoryRepositoryImpl struct {
+	db *gorm.DB
+}
+
+// NewProfileHistoryRepository creates a new profile history repository
+func NewProfileHistoryRepository(db *gorm.DB) ProfileHistoryRepository {
+	return &profileHistoryRepositoryImpl{db: db}
+}
+
+// Create numbers the change after the latest version of the user and
+// inserts it in a transaction. Locking the versions of the user keeps
+// concurrent changes from taking the same number.
+func (r *profileHistoryRepositoryImpl) Create(change *domain.ProfileChange) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		var latest uint
+		err := tx.Model(&domain.ProfileChange{}).
+			Clauses(clause.Locking{Strength: "UPDATE"}).
+			Select("COALESCE(MAX(version), 0)").
+			Where("user_id = ?", change.UserID).
+			Scan(&latest).Error
+		if err != nil {
+			return err
+		}
+		change.Version = latest + 1
+		return tx.Create(change).Error
+	})
+}
+
+// FindByUser lists the profile changes of a user, newest first
+func (r *profileHistoryRepositoryImpl) FindByUser(userID uint, offset, limit int) ([]*domain.ProfileChange, int64, error) {
+	var changes []*domain.ProfileChange
+	var total int64
+
+	query := r.db.Model(&domain.ProfileChange{}).Where("user_id = ?", userID)
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	err := query.Order("version DESC").Offset(offset).Limit(limit).Find(&changes).Error
+	return changes, total, err
+}
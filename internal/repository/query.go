@@ -0,0 +1,79 @@
+package repository
+
+import (
+	"fmt"
+	"gojwt-rest-api/internal/domain"
+	"strings"
+
+	"gorm.io/gorm/clause"
+)
+
+// ListSpec whitelists the fields clients can sort and filter a listing by.
+// Client supplied field names are only ever mapped to the columns listed here
+// and values are always bound as parameters, so sort and filter parameters
+// cannot inject SQL.
+type ListSpec struct {
+	// SortColumns maps sort fields to columns
+	SortColumns map[string]string
+	// FilterColumns maps filter fields to columns
+	FilterColumns map[string]string
+	// TieBreaker is appended to every sort so pages are stable, e.g. "id"
+	TieBreaker string
+}
+
+// filterConditions maps filter operators to their condition on a column
+var filterConditions = map[string]func(column clause.Column, value string) clause.Expression{
+	domain.FilterEqual: func(column clause.Column, value string) clause.Expression {
+		return clause.Eq{Column: column, Value: value}
+	},
+	domain.FilterContains: func(column clause.Column, value string) clause.Expression {
+		return clause.Like{Column: column, Value: "%" + likeEscaper.Replace(value) + "%"}
+	},
+	domain.FilterStartsWith: func(column clause.Column, value string) clause.Expression {
+		return clause.Like{Column: column, Value: likeEscaper.Replace(value) + "%"}
+	},
+}
+
+// Order parses sort, a comma-separated list of fields each prefixed with "-"
+// for descending order, e.g. "-created_at,name", into an ORDER BY clause. It
+// returns nil for an empty sort and domain.ErrInvalidSort for unknown fields.
+func (s ListSpec) Order(sort string) (*clause.OrderBy, error) {
+	if sort == "" {
+		return nil, nil
+	}
+
+	order := &clause.OrderBy{}
+	seen := make(map[string]bool)
+	for _, field := range strings.Split(sort, ",") {
+		name, desc := strings.CutPrefix(strings.TrimSpace(field), "-")
+		column, ok := s.SortColumns[name]
+		if !ok {
+			return nil, fmt.Errorf("%w: unknown field %q", domain.ErrInvalidSort, name)
+		}
+		if seen[column] {
+			return nil, fmt.Errorf("%w: field %q is listed twice", domain.ErrInvalidSort, name)
+		}
+		seen[column] = true
+		order.Columns = append(order.Columns, clause.OrderByColumn{Column: clause.Column{Name: column}, Desc: desc})
+	}
+	if s.TieBreaker != "" && !seen[s.TieBreaker] {
+		order.Columns = append(order.Columns, clause.OrderByColumn{Column: clause.Column{Name: s.TieBreaker}})
+	}
+	return order, nil
+}
+
+// Condition returns the condition matching the rows whose field matches value
+// with operator, one of domain.FilterEqual, domain.FilterContains and
+// domain.FilterStartsWith. Unknown fields and operators return
+// domain.ErrInvalidFilter.
+func (s ListSpec) Condition(field, operator, value string) (clause.Expression, error) {
+	column, ok := s.FilterColumns[field]
+	if !ok {
+		return nil, fmt.Errorf("%w: unknown field %q", domain.ErrInvalidFilter, field)
+	}
+	condition, ok := filterConditions[operator]
+	if !ok {
+		return nil, fmt.Errorf("%w: unknown operator %q", domain.ErrInvalidFilter, operator)
+	}
+	return condition(clause.Column{Name: column}, value), nil
+}
@@ -0,0 +1,19 @@
+package repository
+
+import "gojwt-rest-api/internal/domain"
+
+// SessionAnalyticsRepository defines the aggregate queries on the token
+// tables behind the session analytics
+type SessionAnalyticsRepository interface {
+	// CountActiveSessions counts the refresh tokens that were valid at the
+	// start of each bucket. Rotation revokes the previous token of a session,
+	// so each active session holds exactly one.
+	CountActiveSessions(buckets *domain.AnalyticsBuckets) ([]int64, error)
+	// CountRefreshes counts the refresh token rotations of each bucket
+	CountRefreshes(buckets *domain.AnalyticsBuckets) ([]int64, error)
+	// CountCreated counts the rows added to a token table (see
+	// domain.TokenTableRefreshTokens) in each bucket
+	CountCreated(table string, buckets *domain.AnalyticsBuckets) ([]int64, error)
+	// TableStats returns the row count and storage size of a token table
+	TableStats(table string) (*domain.TokenTableStats, error)
+}
@@ -0,0 +1,141 @@
+package repository
+
+import (
+	"fmt"
+	"gojwt-rest-api/internal/domain"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// tokenTableModels are the tables CountCreated and TableStats accept
+var tokenTableModels = map[string]interface{}{
+	domain.TokenTableRefreshTokens: &domain.RefreshToken{},
+	domain.TokenTableBlacklist:     &domain.TokenBlacklist{},
+}
+
+// sessionAnalyticsRepositoryImpl is the implementation of SessionAnalyticsRepository
+type sessionAnalyticsRepositoryImpl struct {
+	db *gorm.DB
+}
+
+// NewSessionAnalyticsRepository creates a new session analytics repository
+func NewSessionAnalyticsRepository(db *gorm.DB) SessionAnalyticsRepository {
+	return &sessionAnalyticsRepositoryImpl{db: db}
+}
+
+// sessionEnd is when a refresh token stopped being valid: its expiry, or its
+// revocation when earlier. Tokens revoked before revoked_at was recorded
+// count as never valid.
+const sessionEnd = "CASE WHEN is_revoked THEN LEAST(COALESCE(revoked_at, created_at), expires_at) ELSE expires_at END"
+
+// CountActiveSessions counts the refresh tokens issued by the start of each
+// bucket that had neither expired nor been revoked by then. A single query
+// groups the tokens by the first and the last bucket start they were valid
+// at, and the counts are summed per bucket from these ranges.
+func (r *sessionAnalyticsRepositoryImpl) CountActiveSessions(buckets *domain.AnalyticsBuckets) ([]int64, error) {
+	var rows []struct {
+		FirstBucket int
+		EndBucket   int
+		Count       int64
+	}
+	interval := int64(buckets.Interval / time.Microsecond)
+	err := r.db.Model(&domain.RefreshToken{}).
+		Select(fmt.Sprintf("GREATEST(CEIL(TIMESTAMPDIFF(MICROSECOND, ?, created_at) / ?), 0) AS first_bucket, "+
+			"LEAST(CEIL(TIMESTAMPDIFF(MICROSECOND, ?, %s) / ?), ?) AS end_bucket, COUNT(*) AS count", sessionEnd),
+			buckets.From, interval, buckets.From, interval, buckets.Count).
+		Where(fmt.Sprintf("created_at <= ? AND %s > ?", sessionEnd), buckets.Start(buckets.Count-1), buckets.From).
+		Group("first_bucket, end_bucket").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	// changes[i] is the number of sessions starting to count at bucket i,
+	// less the number no longer counting from it
+	changes := make([]int64, buckets.Count+1)
+	for _, row := range rows {
+		if row.FirstBucket < row.EndBucket && row.EndBucket <= buckets.Count {
+			changes[row.FirstBucket] += row.Count
+			changes[row.EndBucket] -= row.Count
+		}
+	}
+	counts := make([]int64, buckets.Count)
+	var active int64
+	for i := range counts {
+		active += changes[i]
+		counts[i] = active
+	}
+	return counts, nil
+}
+
+// CountRefreshes counts the tokens revoked by rotation in each bucket
+func (r *sessionAnalyticsRepositoryImpl) CountRefreshes(buckets *domain.AnalyticsBuckets) ([]int64, error) {
+	query := r.db.Model(&domain.RefreshToken{}).Where("replaced_by IS NOT NULL")
+	return countByBucket(query, "revoked_at", buckets)
+}
+
+// CountCreated counts the rows of table created in each bucket
+func (r *sessionAnalyticsRepositoryImpl) CountCreated(table string, buckets *domain.AnalyticsBuckets) ([]int64, error) {
+	model, ok := tokenTableModels[table]
+	if !ok {
+		return nil, fmt.Errorf("unknown token table %q", table)
+	}
+	return countByBucket(r.db.Model(model), "created_at", buckets)
+}
+
+// TableStats counts the rows of table and reads its storage size from the
+// MySQL information schema, whose sizes are estimates
+func (r *sessionAnalyticsRepositoryImpl) TableStats(table string) (*domain.TokenTableStats, error) {
+	model, ok := tokenTableModels[table]
+	if !ok {
+		return nil, fmt.Errorf("unknown token table %q", table)
+	}
+
+	var rows int64
+	if err := r.db.Model(model).Count(&rows).Error; err != nil {
+		return nil, err
+	}
+	var size struct {
+		DataBytes  int64
+		IndexBytes int64
+	}
+	err := r.db.Raw(
+		"SELECT data_length AS data_bytes, index_length AS index_bytes FROM information_schema.tables WHERE table_schema = DATABASE() AND table_name = ?",
+		table,
+	).Scan(&size).Error
+	if err != nil {
+		return nil, err
+	}
+	return &domain.TokenTableStats{
+		Table:      table,
+		Rows:       rows,
+		DataBytes:  size.DataBytes,
+		IndexBytes: size.IndexBytes,
+	}, nil
+}
+
+// countByBucket counts the rows of query whose column falls in each bucket
+func countByBucket(query *gorm.DB, column string, buckets *domain.AnalyticsBuckets) ([]int64, error) {
+	var rows []struct {
+		Bucket int
+		Count  int64
+	}
+	err := query.
+		Select(fmt.Sprintf("FLOOR(TIMESTAMPDIFF(SECOND, ?, %s) / ?) AS bucket, COUNT(*) AS count", column),
+			buckets.From, int64(buckets.Interval/time.Second)).
+		Where(fmt.Sprintf("%s >= ? AND %s < ?", column, column), buckets.From, buckets.To()).
+		Group("bucket").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	counts := make([]int64, buckets.Count)
+	for _, row := range rows {
+		if row.Bucket >= 0 && row.Bucket < buckets.Count {
+			counts[row.Bucket] = row.Count
+		}
+	}
+	return counts, nil
+}
@@ -0,0 +1,14 @@
+package repository
+
+import "gojwt-rest-api/internal/domain"
+
+// SigningKeyRepository defines the interface for signing key incident data access
+type SigningKeyRepository interface {
+	// FindCompromised returns the fingerprints among fingerprints of the keys
+	// reported compromised
+	FindCompromised(fingerprints []string) ([]string, error)
+	// RecordIncident stores incident, counting the sessions outstanding at its
+	// creation and revoking their refresh tokens when revokeRefreshTokens, in
+	// one transaction
+	RecordIncident(incident *domain.SigningKeyIncident, revokeRefreshTokens bool) error
+}
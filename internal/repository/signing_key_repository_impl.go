@@ -0,0 +1,55 @@
+package repository
+
+import (
+	"gojwt-rest-api/internal/domain"
+
+	"gorm.io/gorm"
+)
+
+// signingKeyRepositoryImpl is the implementation of SigningKeyRepository
+type signingKeyRepositoryImpl struct {
+	db *gorm.DB
+}
+
+// NewSigningKeyRepository creates a new signing key repository
+func NewSigningKeyRepository(db *gorm.DB) SigningKeyRepository {
+	return &signingKeyRepositoryImpl{db: db}
+}
+
+// FindCompromised returns the fingerprints among fingerprints that were reported compromised
+func (r *signingKeyRepositoryImpl) FindCompromised(fingerprints []string) ([]string, error) {
+	var compromised []string
+	if len(fingerprints) == 0 {
+		return compromised, nil
+	}
+	err := r.db.Model(&domain.SigningKeyIncident{}).
+		Distinct("key_fingerprint").
+		Where("key_fingerprint IN ?", fingerprints).
+		Pluck("key_fingerprint", &compromised).Error
+	return compromised, err
+}
+
+// RecordIncident stores incident with the number of outstanding sessions, the
+// unrevoked and unexpired refresh tokens, revoking them when revokeRefreshTokens
+func (r *signingKeyRepositoryImpl) RecordIncident(incident *domain.SigningKeyIncident, revokeRefreshTokens bool) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		outstanding := tx.Model(&domain.RefreshToken{}).
+			Where("is_revoked = ? AND expires_at > ?", false, incident.CreatedAt)
+		if err := outstanding.Session(&gorm.Session{}).Count(&incident.OutstandingSessions).Error; err != nil {
+			return err
+		}
+
+		if revokeRefreshTokens {
+			result := outstanding.Session(&gorm.Session{}).Updates(map[string]interface{}{
+				"is_revoked": true,
+				"revoked_at": incident.CreatedAt,
+			})
+			if result.Error != nil {
+				return result.Error
+			}
+			incident.RefreshTokensRevoked = result.RowsAffected
+		}
+
+		return tx.Create(incident).Error
+	})
+}
@@ -0,0 +1,19 @@
+package repository
+
+import "gojwt-rest-api/internal/domain"
+
+// SSOConnectionRepository defines the interface for SSO connection data access
+type SSOConnectionRepository interface {
+	// Create creates the connection together with its domains
+	Create(conn *domain.SSOConnection) error
+	FindByID(id uint) (*domain.SSOConnection, error)
+	FindByName(name string) (*domain.SSOConnection, error)
+	// FindByDomain finds the connection an email domain is mapped to
+	FindByDomain(domain string) (*domain.SSOConnection, error)
+	// FindMappedDomains returns which of the given domains are already mapped to a connection
+	FindMappedDomains(domains []string) ([]string, error)
+	FindAll() ([]*domain.SSOConnection, error)
+	// Update saves the connection's own columns, leaving its domains untouched
+	Update(conn *domain.SSOConnection) error
+	Delete(id uint) error
+}
@@ -0,0 +1,92 @@
+package repository
+
+import (
+	"gojwt-rest-api/internal/domain"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ssoConnectionRepositoryImpl is the implementation of SSOConnectionRepository
+type ssoConnectionRepositoryImpl struct {
+	db *gorm.DB
+}
+
+// NewSSOConnectionRepository creates a new SSO connection repository
+func NewSSOConnectionRepository(db *gorm.DB) SSOConnectionRepository {
+	return &ssoConnectionRepositoryImpl{db: db}
+}
+
+// Create creates the connection together with its domains
+func (r *ssoConnectionRepositoryImpl) Create(conn *domain.SSOConnection) error {
+	return r.db.Create(conn).Error
+}
+
+// FindByID finds a connection by ID with its domains
+func (r *ssoConnectionRepositoryImpl) FindByID(id uint) (*domain.SSOConnection, error) {
+	var conn domain.SSOConnection
+	err := r.db.Preload("Domains").First(&conn, id).Error
+	if err != nil {
+		return nil, translateError(err, domain.ErrSSOConnectionNotFound, nil)
+	}
+	return &conn, nil
+}
+
+// FindByName finds a connection by name
+func (r *ssoConnectionRepositoryImpl) FindByName(name string) (*domain.SSOConnection, error) {
+	var conn domain.SSOConnection
+	err := r.db.Preload("Domains").Where("name = ?", name).First(&conn).Error
+	if err != nil {
+		return nil, translateError(err, domain.ErrSSOConnectionNotFound, nil)
+	}
+	return &conn, nil
+}
+
+// FindByDomain finds the connection an email domain is mapped to
+func (r *ssoConnectionRepositoryImpl) FindByDomain(emailDomain string) (*domain.SSOConnection, error) {
+	var conn domain.SSOConnection
+	err := r.db.Preload("Domains").
+		Joins("JOIN sso_domains ON sso_domains.connection_id = sso_connections.id").
+		Where("sso_domains.domain = ?", emailDomain).
+		First(&conn).Error
+	if err != nil {
+		return nil, translateError(err, domain.ErrSSOConnectionNotFound, nil)
+	}
+	return &conn, nil
+}
+
+// FindMappedDomains returns which of the given domains are already mapped to a connection
+func (r *ssoConnectionRepositoryImpl) FindMappedDomains(domains []string) ([]string, error) {
+	var mapped []string
+	err := r.db.Model(&domain.SSODomain{}).Where("domain IN ?", domains).Pluck("domain", &mapped).Error
+	return mapped, err
+}
+
+// FindAll finds all connections with their domains
+func (r *ssoConnectionRepositoryImpl) FindAll() ([]*domain.SSOConnection, error) {
+	var conns []*domain.SSOConnection
+	err := r.db.Preload("Domains").Order("id").Find(&conns).Error
+	return conns, err
+}
+
+// Update saves the connection's own columns, leaving its domains untouched
+func (r *ssoConnectionRepositoryImpl) Update(conn *domain.SSOConnection) error {
+	return r.db.Omit(clause.Associations).Save(conn).Error
+}
+
+// Delete deletes a connection and its domain mappings
+func (r *ssoConnectionRepositoryImpl) Delete(id uint) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("connection_id = ?", id).Delete(&domain.SSODomain{}).Error; err != nil {
+			return err
+		}
+		result := tx.Delete(&domain.SSOConnection{}, id)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return domain.ErrSSOConnectionNotFound
+		}
+		return nil
+	})
+}
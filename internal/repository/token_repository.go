@@ -2,6 +2,7 @@ package repository
 
 import (
 	"gojwt-rest-api/internal/domain"
+	"time"
 )
 
 // TokenRepository defines the interface for token operations
@@ -11,13 +12,40 @@ type TokenRepository interface {
 	FindRefreshTokenByToken(token string) (*domain.RefreshToken, error)
 	FindRefreshTokensByUserID(userID uint) ([]*domain.RefreshToken, error)
 	UpdateRefreshToken(token *domain.RefreshToken) error
+	// RotateRefreshToken revokes current, recording next as its replacement, and
+	// stores next in one transaction. It returns false without storing next
+	// when current was already revoked, e.g. by a concurrent rotation.
+	RotateRefreshToken(current, next *domain.RefreshToken, at time.Time) (bool, error)
 	RevokeRefreshToken(token string) error
 	RevokeAllUserRefreshTokens(userID uint) error
 	RevokeTokenFamily(tokenFamily string) error
+	// RevokeClientRefreshTokens revokes the refresh tokens of the sessions of
+	// a client application, of userID or of every user when nil, returning
+	// the number revoked
+	RevokeClientRefreshTokens(clientID string, userID *uint, at time.Time) (int64, error)
+	// AddClientSessionCutoff revokes the access tokens of the sessions of a
+	// client application issued up to cutoff.RevokedAt
+	AddClientSessionCutoff(cutoff *domain.ClientSessionCutoff) error
+	// FindClientSessionCutoff returns the latest cutoff of the sessions of a
+	// client application applying to userID, nil if none
+	FindClientSessionCutoff(clientID string, userID uint) (*time.Time, error)
 	DeleteExpiredRefreshTokens() error
+	// CountActiveRefreshTokens counts the unrevoked, unexpired refresh tokens of a user
+	CountActiveRefreshTokens(userID uint, now time.Time) (int64, error)
+	// FindLastSessionStart returns when the user's most recent session (token
+	// family) was started, nil if no session is left
+	FindLastSessionStart(userID uint) (*time.Time, error)
+	// PurgeRevokedRefreshTokens deletes up to limit expired refresh tokens
+	// revoked before revokedBefore, returning the number deleted
+	PurgeRevokedRefreshTokens(revokedBefore time.Time, limit int) (int64, error)
+	// PurgeExpiredRefreshTokens deletes up to limit unrevoked refresh tokens,
+	// i.e. past sessions, that expired before expiredBefore
+	PurgeExpiredRefreshTokens(expiredBefore time.Time, limit int) (int64, error)
 
 	// Token Blacklist operations
 	AddToBlacklist(token *domain.TokenBlacklist) error
 	IsTokenBlacklisted(token string) (bool, error)
 	DeleteExpiredBlacklistTokens() error
+	// PurgeBlacklist deletes up to limit blacklist entries that expired before expiredBefore
+	PurgeBlacklist(expiredBefore time.Time, limit int) (int64, error)
 }
@@ -27,10 +27,7 @@ func (r *tokenRepositoryImpl) FindRefreshTokenByToken(token string) (*domain.Ref
 	var refreshToken domain.RefreshToken
 	err := r.db.Where("token = ?", token).First(&refreshToken).Error
 	if err != nil {
-		if err == gorm.ErrRecordNotFound {
-			return nil, domain.ErrTokenNotFound
-		}
-		return nil, err
+		return nil, translateError(err, domain.ErrTokenNotFound, nil)
 	}
 	return &refreshToken, nil
 }
@@ -42,11 +39,67 @@ func (r *tokenRepositoryImpl) FindRefreshTokensByUserID(userID uint) ([]*domain.
 	return tokens, err
 }
 
+// CountActiveRefreshTokens counts the usable refresh tokens of a user
+func (r *tokenRepositoryImpl) CountActiveRefreshTokens(userID uint, now time.Time) (int64, error) {
+	var count int64
+	err := r.db.Model(&domain.RefreshToken{}).
+		Where("user_id = ? AND is_revoked = ? AND expires_at > ?", userID, false, now).
+		Count(&count).Error
+	return count, err
+}
+
+// FindLastSessionStart returns the creation time of the first token of the
+// user's newest token family. Rotation keeps the family, so this is the last login.
+func (r *tokenRepositoryImpl) FindLastSessionStart(userID uint) (*time.Time, error) {
+	families := r.db.Model(&domain.RefreshToken{}).
+		Select("MIN(created_at) AS started_at").
+		Where("user_id = ?", userID).
+		Group("token_family")
+
+	var result struct {
+		StartedAt *time.Time
+	}
+	err := r.db.Table("(?) AS families", families).Select("MAX(started_at) AS started_at").Scan(&result).Error
+	return result.StartedAt, err
+}
+
 // UpdateRefreshToken updates a refresh token
 func (r *tokenRepositoryImpl) UpdateRefreshToken(token *domain.RefreshToken) error {
 	return r.db.Save(token).Error
 }
 
+// RotateRefreshToken revokes current, recording next as its replacement, and
+// stores next in one transaction. It returns false without storing next
+// when current was already revoked, e.g. by a concurrent rotation.
+func (r *tokenRepositoryImpl) RotateRefreshToken(current, next *domain.RefreshToken, at time.Time) (bool, error) {
+	rotated := false
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		// Only one of concurrent rotations of the same token matches the unrevoked row
+		result := tx.Model(&domain.RefreshToken{}).
+			Where("id = ? AND is_revoked = ?", current.ID, false).
+			Updates(map[string]interface{}{
+				"is_revoked":  true,
+				"revoked_at":  at,
+				"replaced_by": next.Token,
+			})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return nil
+		}
+		if err := tx.Create(next).Error; err != nil {
+			return err
+		}
+		rotated = true
+		return nil
+	})
+	if err != nil {
+		return false, err
+	}
+	return rotated, nil
+}
+
 // RevokeRefreshToken revokes a specific refresh token
 func (r *tokenRepositoryImpl) RevokeRefreshToken(token string) error {
 	now := time.Now()
@@ -69,6 +122,37 @@ func (r *tokenRepositoryImpl) RevokeAllUserRefreshTokens(userID uint) error {
 		}).Error
 }
 
+// RevokeClientRefreshTokens revokes the refresh tokens of a client application's sessions
+func (r *tokenRepositoryImpl) RevokeClientRefreshTokens(clientID string, userID *uint, at time.Time) (int64, error) {
+	query := r.db.Model(&domain.RefreshToken{}).Where("client_id = ? AND is_revoked = ?", clientID, false)
+	if userID != nil {
+		query = query.Where("user_id = ?", *userID)
+	}
+	result := query.Updates(map[string]interface{}{
+		"is_revoked": true,
+		"revoked_at": at,
+	})
+	return result.RowsAffected, result.Error
+}
+
+// AddClientSessionCutoff stores a cutoff of a client application's sessions
+func (r *tokenRepositoryImpl) AddClientSessionCutoff(cutoff *domain.ClientSessionCutoff) error {
+	return r.db.Create(cutoff).Error
+}
+
+// FindClientSessionCutoff returns the latest cutoff of a client application's
+// sessions applying to userID
+func (r *tokenRepositoryImpl) FindClientSessionCutoff(clientID string, userID uint) (*time.Time, error) {
+	var result struct {
+		RevokedAt *time.Time
+	}
+	err := r.db.Model(&domain.ClientSessionCutoff{}).
+		Select("MAX(revoked_at) AS revoked_at").
+		Where("client_id = ? AND (user_id IS NULL OR user_id = ?)", clientID, userID).
+		Scan(&result).Error
+	return result.RevokedAt, err
+}
+
 // RevokeTokenFamily revokes all tokens in a token family (for security breach detection)
 func (r *tokenRepositoryImpl) RevokeTokenFamily(tokenFamily string) error {
 	now := time.Now()
@@ -86,6 +170,25 @@ func (r *tokenRepositoryImpl) DeleteExpiredRefreshTokens() error {
 		Delete(&domain.RefreshToken{}).Error
 }
 
+// PurgeRevokedRefreshTokens deletes up to limit expired refresh tokens revoked
+// before revokedBefore. Unexpired revoked tokens are kept to detect their reuse.
+func (r *tokenRepositoryImpl) PurgeRevokedRefreshTokens(revokedBefore time.Time, limit int) (int64, error) {
+	result := r.db.
+		Where("is_revoked = ? AND COALESCE(revoked_at, created_at) < ? AND expires_at < ?", true, revokedBefore, time.Now()).
+		Limit(limit).
+		Delete(&domain.RefreshToken{})
+	return result.RowsAffected, result.Error
+}
+
+// PurgeExpiredRefreshTokens deletes up to limit unrevoked refresh tokens that expired before expiredBefore
+func (r *tokenRepositoryImpl) PurgeExpiredRefreshTokens(expiredBefore time.Time, limit int) (int64, error) {
+	result := r.db.
+		Where("is_revoked = ? AND expires_at < ?", false, expiredBefore).
+		Limit(limit).
+		Delete(&domain.RefreshToken{})
+	return result.RowsAffected, result.Error
+}
+
 // AddToBlacklist adds a token to the blacklist
 func (r *tokenRepositoryImpl) AddToBlacklist(token *domain.TokenBlacklist) error {
 	return r.db.Create(token).Error
@@ -105,3 +208,12 @@ func (r *tokenRepositoryImpl) DeleteExpiredBlacklistTokens() error {
 	return r.db.Where("expires_at < ?", time.Now()).
 		Delete(&domain.TokenBlacklist{}).Error
 }
+
+// PurgeBlacklist deletes up to limit blacklist entries that expired before expiredBefore
+func (r *tokenRepositoryImpl) PurgeBlacklist(expiredBefore time.Time, limit int) (int64, error) {
+	result := r.db.
+		Where("expires_at < ?", expiredBefore).
+		Limit(limit).
+		Delete(&domain.TokenBlacklist{})
+	return result.RowsAffected, result.Error
+}
@@ -1,15 +1,60 @@
 package repository
 
 import (
+	"context"
 	"gojwt-rest-api/internal/domain"
+	"time"
 )
 
 // UserRepository defines the interface for user data access
 type UserRepository interface {
+	// WithContext returns the repository executing its statements with ctx,
+	// which scopes them to the organization of a tenant request
+	WithContext(ctx context.Context) UserRepository
 	Create(user *domain.User) error
+	// CreateBatch inserts users with one statement per batchSize users
+	CreateBatch(users []*domain.User, batchSize int) error
 	FindByID(id uint) (*domain.User, error)
 	FindByEmail(email string) (*domain.User, error)
+	// FindTokenVersions returns the token versions of a user, the only columns
+	// needed to validate access tokens
+	FindTokenVersions(id uint) (*domain.TokenVersions, error)
 	FindAll(pagination *domain.PaginationQuery) ([]*domain.User, int64, error)
+	// FindByFilter retrieves users matching filter, ordered by ID
+	FindByFilter(filter *domain.UserFilter, offset, limit int) ([]*domain.User, int64, error)
+	// Suggest finds users whose name or email starts with prefix, ordered by name
+	Suggest(prefix string, limit int) ([]*domain.UserSuggestion, error)
 	Update(user *domain.User) error
 	Delete(id uint) error
+	// DeleteWithCleanup deletes a user in a transaction together with their
+	// refresh tokens, API keys, two-factor enrollment and profile history,
+	// blacklists their access tokens and removes their ID from audit logs and
+	// the profile history of others
+	DeleteWithCleanup(deletion *domain.UserDeletion) error
+	// CountActiveAdmins counts admins that have not been deactivated
+	CountActiveAdmins() (int64, error)
+	// MarkFirstLogin records the first login time, returning false if it was already set
+	MarkFirstLogin(id uint, at time.Time) (bool, error)
+	// RecordLogin records the last login time and clears any inactivity warning
+	RecordLogin(id uint, at time.Time) error
+
+	// Inactivity policy operations
+	// FindInactive finds users due for an inactivity policy stage, skipping
+	// admins and exempt users
+	FindInactive(query *domain.InactiveUserQuery) ([]*domain.User, error)
+	// MarkInactivityWarned records the inactivity warning, returning false if the user was already warned
+	MarkInactivityWarned(id uint, at time.Time) (bool, error)
+	// SuspendInactive deactivates the user, returning false if they were already deactivated
+	SuspendInactive(id uint, at time.Time) (bool, error)
+	// Anonymize replaces the user's personal data in a transaction together
+	// with removing their refresh tokens, API keys, two-factor enrollment and
+	// profile history and blacklisting their access tokens
+	Anonymize(anonymization *domain.UserAnonymization) error
+
+	// Two-factor operations
+	FindTwoFactor(userID uint) (*domain.UserTwoFactor, error)
+	SaveTwoFactor(twoFactor *domain.UserTwoFactor) error
+	// UseTwoFactorStep records an accepted TOTP time step, returning false if
+	// the step or a later one was already used
+	UseTwoFactorStep(userID uint, step int64) (bool, error)
 }
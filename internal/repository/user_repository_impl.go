@@ -1,26 +1,63 @@
 package repository
 
 import (
+	"context"
+	"fmt"
 	"gojwt-rest-api/internal/domain"
+	"gojwt-rest-api/internal/pii"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 // userRepositoryImpl is the implementation of UserRepository
 type userRepositoryImpl struct {
-	db *gorm.DB
+	db       *gorm.DB
+	fullText bool
+}
+
+// UserRepositoryOption configures optional behaviour of the user repository
+type UserRepositoryOption func(*userRepositoryImpl)
+
+// WithFullTextSearch makes FindAll search with the FULLTEXT index created by
+// migrations.EnsureUserFullTextIndex instead of LIKE patterns
+func WithFullTextSearch() UserRepositoryOption {
+	return func(r *userRepositoryImpl) {
+		r.fullText = true
+	}
 }
 
 // NewUserRepository creates a new user repository
-func NewUserRepository(db *gorm.DB) UserRepository {
-	return &userRepositoryImpl{
+func NewUserRepository(db *gorm.DB, opts ...UserRepositoryOption) UserRepository {
+	r := &userRepositoryImpl{
 		db: db,
 	}
+	for _, opt := range opts {
+		opt(r)
+	}
+	return r
 }
 
-// Create creates a new user
+// WithContext returns the repository executing its statements with ctx
+func (r *userRepositoryImpl) WithContext(ctx context.Context) UserRepository {
+	scoped := *r
+	scoped.db = r.db.WithContext(ctx)
+	return &scoped
+}
+
+// Create creates a new user. It returns domain.ErrUserAlreadyExists when the
+// email was registered concurrently, after the caller checked it was free.
 func (r *userRepositoryImpl) Create(user *domain.User) error {
-	return r.db.Create(user).Error
+	return translateError(r.db.Create(user).Error, nil, domain.ErrUserAlreadyExists)
+}
+
+// CreateBatch inserts users with one statement per batchSize users
+func (r *userRepositoryImpl) CreateBatch(users []*domain.User, batchSize int) error {
+	return r.db.CreateInBatches(users, batchSize).Error
 }
 
 // FindByID finds a user by ID
@@ -28,38 +65,60 @@ func (r *userRepositoryImpl) FindByID(id uint) (*domain.User, error) {
 	var user domain.User
 	err := r.db.First(&user, id).Error
 	if err != nil {
-		if err == gorm.ErrRecordNotFound {
-			return nil, domain.ErrUserNotFound
-		}
-		return nil, err
+		return nil, translateError(err, domain.ErrUserNotFound, nil)
 	}
 	return &user, nil
 }
 
-// FindByEmail finds a user by email
+// FindTokenVersions returns the session epoch and permission version of a user
+func (r *userRepositoryImpl) FindTokenVersions(id uint) (*domain.TokenVersions, error) {
+	var user domain.User
+	err := r.db.Select("session_epoch", "perm_version").First(&user, id).Error
+	if err != nil {
+		return nil, translateError(err, domain.ErrUserNotFound, nil)
+	}
+	return &domain.TokenVersions{SessionEpoch: user.SessionEpoch, PermVersion: user.PermVersion}, nil
+}
+
+// FindByEmail finds a user by email, through its blind index when PII
+// encryption is enabled
 func (r *userRepositoryImpl) FindByEmail(email string) (*domain.User, error) {
 	var user domain.User
-	err := r.db.Where("email = ?", email).First(&user).Error
+	err := whereEmail(r.db, email).First(&user).Error
 	if err != nil {
-		if err == gorm.ErrRecordNotFound {
-			return nil, domain.ErrUserNotFound
-		}
-		return nil, err
+		return nil, translateError(err, domain.ErrUserNotFound, nil)
 	}
 	return &user, nil
 }
 
+// emailIndexMatch matches an email through its blind index. Users written
+// before PII encryption was enabled have no blind index until cmd/reencrypt
+// runs, and are matched on their plaintext email instead.
+const emailIndexMatch = "(email_hash = ? OR (email_hash IS NULL AND email = ?))"
+
+// whereEmail filters users by exact email
+func whereEmail(query *gorm.DB, email string) *gorm.DB {
+	if index := pii.BlindIndex(email); index != nil {
+		return query.Where(emailIndexMatch, *index, email)
+	}
+	return query.Where("email = ?", email)
+}
+
 // FindAll retrieves all users with pagination and search
 func (r *userRepositoryImpl) FindAll(pagination *domain.PaginationQuery) ([]*domain.User, int64, error) {
 	var users []*domain.User
 	var total int64
 
+	order, err := userSortSpec().Order(pagination.Sort)
+	if err != nil {
+		return nil, 0, err
+	}
+
 	query := r.db.Model(&domain.User{})
 
 	// Apply search filter if provided
 	if pagination.Search != "" {
-		searchPattern := "%" + pagination.Search + "%"
-		query = query.Where("name LIKE ? OR email LIKE ?", searchPattern, searchPattern)
+		query = r.applySearch(query, pagination.Search)
 	}
 
 	// Count total items
@@ -67,7 +126,10 @@ func (r *userRepositoryImpl) FindAll(pagination *domain.PaginationQuery) ([]*dom
 		return nil, 0, err
 	}
 
-	// Apply pagination
+	// Apply sorting and pagination
+	if order != nil {
+		query = query.Order(*order)
+	}
 	offset := (pagination.Page - 1) * pagination.PageSize
 	if err := query.Offset(offset).Limit(pagination.PageSize).Find(&users).Error; err != nil {
 		return nil, 0, err
@@ -76,9 +138,146 @@ func (r *userRepositoryImpl) FindAll(pagination *domain.PaginationQuery) ([]*dom
 	return users, total, nil
 }
 
-// Update updates a user
+// minFullTextTermLength is InnoDB's default innodb_ft_min_token_size; shorter
+// words are not indexed
+const minFullTextTermLength = 3
+
+// applySearch filters users whose name or email matches search. Full-text
+// search falls back to LIKE patterns for terms too short to be indexed.
+// Encrypted emails only match the search exactly.
+func (r *userRepositoryImpl) applySearch(query *gorm.DB, search string) *gorm.DB {
+	if index := pii.BlindIndex(search); index != nil {
+		return query.Where("name LIKE ? OR "+emailIndexMatch, "%"+search+"%", *index, search)
+	}
+	if r.fullText {
+		if against := fullTextQuery(search); against != "" {
+			return query.Where("MATCH(name, email) AGAINST (? IN BOOLEAN MODE)", against)
+		}
+	}
+	searchPattern := "%" + search + "%"
+	return query.Where("name LIKE ? OR email LIKE ?", searchPattern, searchPattern)
+}
+
+// fullTextQuery converts a search into a boolean mode query requiring every
+// word as a prefix, e.g. "john@example" becomes "+john* +example*". It returns
+// "" when a word is too short to be indexed.
+func fullTextQuery(search string) string {
+	words := strings.FieldsFunc(search, func(c rune) bool {
+		return !unicode.IsLetter(c) && !unicode.IsDigit(c)
+	})
+	if len(words) == 0 {
+		return ""
+	}
+	for i, word := range words {
+		if utf8.RuneCountInString(word) < minFullTextTermLength {
+			return ""
+		}
+		words[i] = "+" + word + "*"
+	}
+	return strings.Join(words, " ")
+}
+
+// userListSpec whitelists the fields users are sorted and filtered by
+var userListSpec = ListSpec{
+	SortColumns: map[string]string{
+		"id":            "id",
+		"name":          "name",
+		"email":         "email",
+		"created_at":    "created_at",
+		"last_login_at": "last_login_at",
+	},
+	FilterColumns: map[string]string{
+		"email": "email",
+		"name":  "name",
+	},
+	TieBreaker: "id",
+}
+
+// userSortSpec returns the list spec users are sorted by. Encrypted emails
+// have no meaningful order, so they cannot be sorted by email.
+func userSortSpec() ListSpec {
+	if !pii.Enabled() {
+		return userListSpec
+	}
+	spec := userListSpec
+	spec.SortColumns = make(map[string]string, len(userListSpec.SortColumns))
+	for field, column := range userListSpec.SortColumns {
+		if field != "email" {
+			spec.SortColumns[field] = column
+		}
+	}
+	return spec
+}
+
+// FindByFilter retrieves users matching the filter with offset pagination
+func (r *userRepositoryImpl) FindByFilter(filter *domain.UserFilter, offset, limit int) ([]*domain.User, int64, error) {
+	var users []*domain.User
+	var total int64
+
+	query := r.db.Model(&domain.User{})
+
+	if filter != nil {
+		switch {
+		case filter.Field == "":
+		case filter.Field == "email" && pii.Enabled():
+			// Encrypted emails can only be compared through their blind index
+			if filter.Operator != domain.FilterEqual {
+				return nil, 0, fmt.Errorf("%w: encrypted field %q only supports %q", domain.ErrInvalidFilter, filter.Field, domain.FilterEqual)
+			}
+			query = whereEmail(query, filter.Value)
+		default:
+			condition, err := userListSpec.Condition(filter.Field, filter.Operator, filter.Value)
+			if err != nil {
+				return nil, 0, err
+			}
+			query = query.Where(condition)
+		}
+		if filter.Active != nil {
+			if *filter.Active {
+				query = query.Where("deactivated_at IS NULL")
+			} else {
+				query = query.Where("deactivated_at IS NOT NULL")
+			}
+		}
+	}
+
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	if err := query.Order("id").Offset(offset).Limit(limit).Find(&users).Error; err != nil {
+		return nil, 0, err
+	}
+
+	return users, total, nil
+}
+
+// likeEscaper escapes the wildcards of LIKE patterns
+var likeEscaper = strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`)
+
+// Suggest finds users by name or email prefix. Prefix patterns can use the
+// indexes on both columns, unlike the contains search of FindAll. Encrypted
+// emails only match a complete email.
+func (r *userRepositoryImpl) Suggest(prefix string, limit int) ([]*domain.UserSuggestion, error) {
+	var suggestions []*domain.UserSuggestion
+	pattern := likeEscaper.Replace(prefix) + "%"
+	query := r.db.Model(&domain.User{}).Select("id", "name", "email")
+	if index := pii.BlindIndex(prefix); index != nil {
+		query = query.Where("name LIKE ? OR "+emailIndexMatch, pattern, *index, prefix)
+	} else {
+		query = query.Where("name LIKE ? OR email LIKE ?", pattern, pattern)
+	}
+	err := query.
+		Order("name").
+		Limit(limit).
+		Find(&suggestions).Error
+	return suggestions, err
+}
+
+// Update updates a user. It returns domain.ErrUserAlreadyExists when the new
+// email was taken concurrently.
 func (r *userRepositoryImpl) Update(user *domain.User) error {
-	return r.db.Save(user).Error
+	return translateError(r.db.Save(user).Error, nil, domain.ErrUserAlreadyExists)
 }
 
 // Delete deletes a user by ID
@@ -92,3 +291,229 @@ func (r *userRepositoryImpl) Delete(id uint) error {
 	}
 	return nil
 }
+
+// DeleteWithCleanup deletes a user and everything referencing them in a transaction
+func (r *userRepositoryImpl) DeleteWithCleanup(deletion *domain.UserDeletion) error {
+	id := deletion.UserID
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("user_id = ?", id).Delete(&domain.RefreshToken{}).Error; err != nil {
+			return err
+		}
+		blacklist := &domain.TokenBlacklist{
+			Token:     domain.UserTokensBlacklistKey(id),
+			ExpiresAt: deletion.AccessTokensRevokedUntil,
+		}
+		if err := tx.Clauses(clause.OnConflict{
+			DoUpdates: clause.AssignmentColumns([]string{"expires_at"}),
+		}).Create(blacklist).Error; err != nil {
+			return err
+		}
+
+		keys := tx.Model(&domain.APIKey{}).Select("id").Where("user_id = ?", id)
+		if err := tx.Where("api_key_id IN (?)", keys).Delete(&domain.APIKeyUsage{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("user_id = ?", id).Delete(&domain.APIKey{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("user_id = ?", id).Delete(&domain.UserTwoFactor{}).Error; err != nil {
+			return err
+		}
+
+		// Keep the audit trail but drop the references to the deleted user
+		if err := tx.Model(&domain.AuditLog{}).Where("user_id = ?", id).Update("user_id", nil).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&domain.AuditLog{}).Where("actor_id = ?", id).Update("actor_id", nil).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("user_id = ?", id).Delete(&domain.ProfileChange{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&domain.ProfileChange{}).Where("actor_id = ?", id).Update("actor_id", nil).Error; err != nil {
+			return err
+		}
+
+		result := tx.Delete(&domain.User{}, id)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return domain.ErrUserNotFound
+		}
+
+		if deletion.Audit != nil {
+			return tx.Create(deletion.Audit).Error
+		}
+		return nil
+	})
+}
+
+// CountActiveAdmins counts admins that have not been deactivated
+func (r *userRepositoryImpl) CountActiveAdmins() (int64, error) {
+	var count int64
+	err := r.db.Model(&domain.User{}).
+		Where("is_admin = ? AND deactivated_at IS NULL", true).
+		Count(&count).Error
+	return count, err
+}
+
+// MarkFirstLogin records the first login time, returning false if it was already set.
+// The conditional update makes concurrent first logins record (and report) only once.
+func (r *userRepositoryImpl) MarkFirstLogin(id uint, at time.Time) (bool, error) {
+	result := r.db.Model(&domain.User{}).
+		Where("id = ? AND first_login_at IS NULL", id).
+		Update("first_login_at", at)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
+
+// RecordLogin records the last login time and clears any inactivity warning
+func (r *userRepositoryImpl) RecordLogin(id uint, at time.Time) error {
+	return r.db.Model(&domain.User{}).
+		Where("id = ?", id).
+		UpdateColumns(map[string]interface{}{
+			"last_login_at":        at,
+			"inactivity_warned_at": nil,
+		}).Error
+}
+
+// lastActivityColumn is a user's last login, falling back to their registration
+const lastActivityColumn = "COALESCE(last_login_at, first_login_at, created_at)"
+
+// FindInactive finds users due for an inactivity policy stage, oldest activity first
+func (r *userRepositoryImpl) FindInactive(query *domain.InactiveUserQuery) ([]*domain.User, error) {
+	db := r.db.
+		Where("is_admin = ? AND inactivity_exempt = ? AND anonymized_at IS NULL", false, false).
+		Where(lastActivityColumn+" < ?", query.LastActiveBefore)
+
+	switch query.Stage {
+	case domain.InactivityStageWarn:
+		db = db.Where("inactivity_warned_at IS NULL AND deactivated_at IS NULL")
+	case domain.InactivityStageSuspend:
+		db = db.Where("deactivated_at IS NULL")
+	case domain.InactivityStageAnonymize:
+	default:
+		return nil, fmt.Errorf("unknown inactivity stage %q", query.Stage)
+	}
+	if query.WarnedBefore != nil {
+		db = db.Where("inactivity_warned_at < ?", *query.WarnedBefore)
+	}
+	if query.SuspendedBefore != nil {
+		db = db.Where("deactivated_at < ?", *query.SuspendedBefore)
+	}
+
+	var users []*domain.User
+	err := db.Order(lastActivityColumn).Limit(query.Limit).Find(&users).Error
+	return users, err
+}
+
+// MarkInactivityWarned records the inactivity warning. The conditional update
+// makes overlapping policy runs warn only once.
+func (r *userRepositoryImpl) MarkInactivityWarned(id uint, at time.Time) (bool, error) {
+	result := r.db.Model(&domain.User{}).
+		Where("id = ? AND inactivity_warned_at IS NULL", id).
+		UpdateColumn("inactivity_warned_at", at)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
+
+// SuspendInactive deactivates the user, returning false if they were already deactivated
+func (r *userRepositoryImpl) SuspendInactive(id uint, at time.Time) (bool, error) {
+	result := r.db.Model(&domain.User{}).
+		Where("id = ? AND deactivated_at IS NULL", id).
+		UpdateColumn("deactivated_at", at)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
+
+// Anonymize replaces the user's personal data and removes their credentials in a transaction
+func (r *userRepositoryImpl) Anonymize(anonymization *domain.UserAnonymization) error {
+	id := anonymization.UserID
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("user_id = ?", id).Delete(&domain.RefreshToken{}).Error; err != nil {
+			return err
+		}
+		blacklist := &domain.TokenBlacklist{
+			Token:     domain.UserTokensBlacklistKey(id),
+			ExpiresAt: anonymization.AccessTokensRevokedUntil,
+		}
+		if err := tx.Clauses(clause.OnConflict{
+			DoUpdates: clause.AssignmentColumns([]string{"expires_at"}),
+		}).Create(blacklist).Error; err != nil {
+			return err
+		}
+
+		keys := tx.Model(&domain.APIKey{}).Select("id").Where("user_id = ?", id)
+		if err := tx.Where("api_key_id IN (?)", keys).Delete(&domain.APIKeyUsage{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("user_id = ?", id).Delete(&domain.APIKey{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("user_id = ?", id).Delete(&domain.UserTwoFactor{}).Error; err != nil {
+			return err
+		}
+		// Previous names and emails are personal data as well
+		if err := tx.Where("user_id = ?", id).Delete(&domain.ProfileChange{}).Error; err != nil {
+			return err
+		}
+
+		// The replacement email is not personal data and is stored as plaintext
+		now := time.Now()
+		result := tx.Model(&domain.User{}).Where("id = ?", id).UpdateColumns(map[string]interface{}{
+			"name":           anonymization.Name,
+			"first_name":     anonymization.Name,
+			"last_name":      "",
+			"display_name":   "",
+			"email":          anonymization.Email,
+			"email_hash":     pii.BlindIndex(anonymization.Email),
+			"password":       anonymization.Password,
+			"anonymized_at":  now,
+			"deactivated_at": gorm.Expr("COALESCE(deactivated_at, ?)", now),
+		})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return domain.ErrUserNotFound
+		}
+
+		if anonymization.Audit != nil {
+			return tx.Create(anonymization.Audit).Error
+		}
+		return nil
+	})
+}
+
+// FindTwoFactor finds the two-factor enrollment of a user
+func (r *userRepositoryImpl) FindTwoFactor(userID uint) (*domain.UserTwoFactor, error) {
+	var twoFactor domain.UserTwoFactor
+	if err := r.db.Where("user_id = ?", userID).First(&twoFactor).Error; err != nil {
+		return nil, translateError(err, domain.ErrTwoFactorNotEnrolled, nil)
+	}
+	return &twoFactor, nil
+}
+
+// SaveTwoFactor creates or replaces the two-factor enrollment of a user
+func (r *userRepositoryImpl) SaveTwoFactor(twoFactor *domain.UserTwoFactor) error {
+	return r.db.Save(twoFactor).Error
+}
+
+// UseTwoFactorStep records an accepted TOTP time step. The conditional update
+// makes concurrent logins with the same code succeed only once.
+func (r *userRepositoryImpl) UseTwoFactorStep(userID uint, step int64) (bool, error) {
+	result := r.db.Model(&domain.UserTwoFactor{}).
+		Where("user_id = ? AND last_used_step < ?", userID, step).
+		Update("last_used_step", step)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
@@ -0,0 +1,11 @@
+package repository
+
+import "gojwt-rest-api/internal/domain"
+
+// WebhookDeliveryRepository defines the interface for webhook delivery data access
+type WebhookDeliveryRepository interface {
+	Create(delivery *domain.WebhookDelivery) error
+	FindByID(id uint) (*domain.WebhookDelivery, error)
+	// Find lists matching deliveries, newest first
+	Find(filter *domain.WebhookDeliveryFilter, offset, limit int) ([]*domain.WebhookDelivery, int64, error)
+}
@@ -0,0 +1,53 @@
+package repository
+
+import (
+	"gojwt-rest-api/internal/domain"
+
+	"gorm.io/gorm"
+)
+
+// webhookDeliveryRepositoryImpl is the implementation of WebhookDeliveryRepository
+type webhookDeliveryRepositoryImpl struct {
+	db *gorm.DB
+}
+
+// NewWebhookDeliveryRepository creates a new webhook delivery repository
+func NewWebhookDeliveryRepository(db *gorm.DB) WebhookDeliveryRepository {
+	return &webhookDeliveryRepositoryImpl{db: db}
+}
+
+// Create records a delivery attempt
+func (r *webhookDeliveryRepositoryImpl) Create(delivery *domain.WebhookDelivery) error {
+	return r.db.Create(delivery).Error
+}
+
+// FindByID finds a delivery by ID
+func (r *webhookDeliveryRepositoryImpl) FindByID(id uint) (*domain.WebhookDelivery, error) {
+	var delivery domain.WebhookDelivery
+	if err := r.db.First(&delivery, id).Error; err != nil {
+		return nil, translateError(err, domain.ErrWebhookDeliveryNotFound, nil)
+	}
+	return &delivery, nil
+}
+
+// Find lists matching deliveries, newest first
+func (r *webhookDeliveryRepositoryImpl) Find(filter *domain.WebhookDeliveryFilter, offset, limit int) ([]*domain.WebhookDelivery, int64, error) {
+	var deliveries []*domain.WebhookDelivery
+	var total int64
+
+	query := r.db.Model(&domain.WebhookDelivery{})
+	if filter.EventType != "" {
+		query = query.Where("event_type = ?", filter.EventType)
+	}
+	if filter.Success != nil {
+		query = query.Where("success = ?", *filter.Success)
+	}
+
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	// Payloads are only needed to replay a single delivery
+	err := query.Omit("payload").Order("id DESC").Offset(offset).Limit(limit).Find(&deliveries).Error
+	return deliveries, total, err
+}
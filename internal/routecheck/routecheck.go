@@ -0,0 +1,161 @@
+// Package routecheck verifies the wiring of a gin router at startup, so that
+// a route registered without its authentication middleware, or a missing
+// route, stops the server instead of shipping.
+//
+// Verify sends a probe request to every route of the engine. The probe is
+// recognized by a value of the request context, which clients cannot set, and
+// Middleware captures the handler chain of the route and aborts it before any
+// other handler runs.
+package routecheck
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"runtime"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// probeKey marks probe requests in the request context
+type probeKey struct{}
+
+// probe receives the handler chain of the route a probe request matched
+type probe struct {
+	reached  bool
+	fullPath string
+	handlers []string
+}
+
+// Middleware captures the handler chain of probe requests and aborts them.
+// It must be the first middleware of the engine, so probes have no effect.
+func Middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if p, ok := c.Request.Context().Value(probeKey{}).(*probe); ok {
+			p.reached = true
+			p.fullPath = c.FullPath()
+			p.handlers = c.HandlerNames()
+			c.AbortWithStatus(http.StatusNoContent)
+			return
+		}
+		c.Next()
+	}
+}
+
+// Rule is an invariant of the routes it matches
+type Rule struct {
+	// Description explains the invariant in errors
+	Description string
+	// Match selects the routes the rule applies to, by method and path template
+	Match func(method, path string) bool
+	// When optionally restricts the rule to the matched routes whose chain has
+	// a handler built by one of these middleware constructors
+	When []interface{}
+	// AnyOf lists middleware constructors, such as middleware.AuthMiddleware;
+	// a handler built by one of them must be in the route's chain
+	AnyOf []interface{}
+}
+
+// Route is a method and path template
+type Route struct {
+	Method string
+	Path   string
+}
+
+func (r Route) String() string {
+	return r.Method + " " + r.Path
+}
+
+// Verify checks every route of engine against rules, and that the required
+// routes are registered, returning an error listing every violation
+func Verify(engine *gin.Engine, rules []Rule, required []Route) error {
+	var problems []string
+	registered := make(map[Route]bool)
+
+	for _, info := range engine.Routes() {
+		route := Route{Method: info.Method, Path: info.Path}
+		registered[route] = true
+
+		chain, err := handlerChain(engine, route)
+		if err != nil {
+			problems = append(problems, fmt.Sprintf("%s: %v", route, err))
+			continue
+		}
+		for _, rule := range rules {
+			if !rule.Match(route.Method, route.Path) || (len(rule.When) > 0 && !chainHasAny(chain, rule.When)) {
+				continue
+			}
+			if !chainHasAny(chain, rule.AnyOf) {
+				problems = append(problems, fmt.Sprintf("%s: %s", route, rule.Description))
+			}
+		}
+	}
+	for _, route := range required {
+		if !registered[route] {
+			problems = append(problems, fmt.Sprintf("%s: required route is not registered", route))
+		}
+	}
+
+	if len(problems) > 0 {
+		return fmt.Errorf("%d wiring problems: %s", len(problems), strings.Join(problems, "; "))
+	}
+	return nil
+}
+
+// PathPrefix matches the routes whose path is prefix or below it, except the
+// listed routes
+func PathPrefix(prefix string, except ...Route) func(method, path string) bool {
+	excluded := make(map[Route]bool, len(except))
+	for _, route := range except {
+		excluded[route] = true
+	}
+	return func(method, path string) bool {
+		if excluded[Route{Method: method, Path: path}] {
+			return false
+		}
+		return path == prefix || strings.HasPrefix(path, strings.TrimSuffix(prefix, "/")+"/")
+	}
+}
+
+// handlerChain sends a probe request to route and returns the names of its handlers
+func handlerChain(engine *gin.Engine, route Route) ([]string, error) {
+	p := &probe{}
+	ctx := context.WithValue(context.Background(), probeKey{}, p)
+	req := httptest.NewRequest(route.Method, probePath(route.Path), nil).WithContext(ctx)
+	engine.ServeHTTP(httptest.NewRecorder(), req)
+
+	if !p.reached {
+		return nil, fmt.Errorf("probe did not reach routecheck.Middleware, register it first")
+	}
+	if p.fullPath != route.Path {
+		return nil, fmt.Errorf("probe matched %s instead", p.fullPath)
+	}
+	return p.handlers, nil
+}
+
+// probePath fills the parameters of a path template
+func probePath(path string) string {
+	segments := strings.Split(path, "/")
+	for i, segment := range segments {
+		if strings.HasPrefix(segment, ":") || strings.HasPrefix(segment, "*") {
+			segments[i] = "routecheck-probe"
+		}
+	}
+	return strings.Join(segments, "/")
+}
+
+// chainHasAny reports whether a handler of chain was built by one of constructors
+func chainHasAny(chain []string, constructors []interface{}) bool {
+	for _, constructor := range constructors {
+		name := runtime.FuncForPC(reflect.ValueOf(constructor).Pointer()).Name()
+		for _, handler := range chain {
+			if handler == name || strings.HasPrefix(handler, name+".func") {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,135 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"gojwt-rest-api/pkg/logger"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// ErrNotRunning is reported by CheckHealth when the scheduler is not started
+var ErrNotRunning = errors.New("scheduler is not running")
+
+// Job is a unit of periodic background work
+type Job func(ctx context.Context) error
+
+// entry is a job registered with its interval
+type entry struct {
+	name     string
+	interval time.Duration
+	job      Job
+}
+
+// Observer is notified of registered jobs and their runs, e.g. to record metrics
+type Observer interface {
+	JobRegistered(name string, interval time.Duration)
+	JobStarted(name string)
+	JobFinished(name string, elapsed time.Duration, err error)
+}
+
+// Option configures optional scheduler behavior
+type Option func(*Scheduler)
+
+// WithObserver notifies observer of every registered job and run
+func WithObserver(observer Observer) Option {
+	return func(s *Scheduler) {
+		s.observer = observer
+	}
+}
+
+// Scheduler runs registered jobs at fixed intervals until stopped
+type Scheduler struct {
+	entries  []entry
+	logger   *logger.Logger
+	observer Observer
+	cancel   context.CancelFunc
+	wg       sync.WaitGroup
+	running  atomic.Bool
+}
+
+// New creates a new scheduler
+func New(appLogger *logger.Logger, opts ...Option) *Scheduler {
+	s := &Scheduler{
+		logger: appLogger,
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// Every registers job to run every interval once the scheduler is started.
+// Non-positive intervals disable the job.
+func (s *Scheduler) Every(name string, interval time.Duration, job Job) {
+	if interval <= 0 {
+		return
+	}
+	s.entries = append(s.entries, entry{name: name, interval: interval, job: job})
+	if s.observer != nil {
+		s.observer.JobRegistered(name, interval)
+	}
+}
+
+// Start starts running the registered jobs in the background
+func (s *Scheduler) Start() {
+	ctx, cancel := context.WithCancel(context.Background())
+	s.cancel = cancel
+	s.running.Store(true)
+
+	for _, e := range s.entries {
+		s.wg.Add(1)
+		go s.run(ctx, e)
+	}
+}
+
+// Stop stops the scheduler and waits for running jobs to return
+func (s *Scheduler) Stop() {
+	s.running.Store(false)
+	if s.cancel != nil {
+		s.cancel()
+	}
+	s.wg.Wait()
+}
+
+// CheckHealth reports whether the scheduler runs its jobs
+func (s *Scheduler) CheckHealth(ctx context.Context) error {
+	if !s.running.Load() {
+		return ErrNotRunning
+	}
+	return nil
+}
+
+// run executes a job on every tick until ctx is cancelled
+func (s *Scheduler) run(ctx context.Context, e entry) {
+	defer s.wg.Done()
+
+	ticker := time.NewTicker(e.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			s.runOnce(ctx, e)
+		}
+	}
+}
+
+// runOnce executes a single run of a job
+func (s *Scheduler) runOnce(ctx context.Context, e entry) {
+	if s.observer != nil {
+		s.observer.JobStarted(e.name)
+	}
+	start := time.Now()
+
+	err := e.job(ctx)
+	if s.observer != nil {
+		s.observer.JobFinished(e.name, time.Since(start), err)
+	}
+	if err != nil {
+		s.logger.Errorf("Scheduled job %s failed: %v", e.name, err)
+	}
+}
@@ -0,0 +1,62 @@
+package scim
+
+import (
+	"errors"
+	"gojwt-rest-api/internal/domain"
+	"strconv"
+	"strings"
+)
+
+// ErrInvalidFilter is returned for filters outside the supported subset
+var ErrInvalidFilter = errors.New("unsupported filter, expected `<attribute> <eq|co|sw> <value>`")
+
+// filterAttributes maps SCIM attribute paths to user filter fields
+var filterAttributes = map[string]string{
+	"username":       "email",
+	"emails.value":   "email",
+	"emails":         "email",
+	"displayname":    "name",
+	"name.formatted": "name",
+}
+
+// ParseFilter parses a single attribute expression such as `userName eq "john@example.com"`.
+// userName, emails.value, displayName and name.formatted support eq, co and sw; active supports eq.
+func ParseFilter(filter string) (*domain.UserFilter, error) {
+	filter = strings.TrimSpace(filter)
+	if filter == "" {
+		return nil, nil
+	}
+
+	parts := strings.SplitN(filter, " ", 3)
+	if len(parts) != 3 {
+		return nil, ErrInvalidFilter
+	}
+	attribute := strings.ToLower(parts[0])
+	operator := strings.ToLower(parts[1])
+	value := strings.TrimSpace(parts[2])
+
+	if attribute == "active" {
+		active, err := strconv.ParseBool(value)
+		if err != nil || operator != domain.FilterEqual {
+			return nil, ErrInvalidFilter
+		}
+		return &domain.UserFilter{Active: &active}, nil
+	}
+
+	field, ok := filterAttributes[attribute]
+	if !ok {
+		return nil, ErrInvalidFilter
+	}
+	switch operator {
+	case domain.FilterEqual, domain.FilterContains, domain.FilterStartsWith:
+	default:
+		return nil, ErrInvalidFilter
+	}
+
+	unquoted, err := strconv.Unquote(value)
+	if err != nil {
+		return nil, ErrInvalidFilter
+	}
+
+	return &domain.UserFilter{Field: field, Operator: operator, Value: unquoted}, nil
+}
@@ -0,0 +1,139 @@
+package scim
+
+import (
+	"encoding/json"
+	"errors"
+	"strconv"
+	"strings"
+)
+
+// Patch errors
+var (
+	ErrInvalidPatchOp = errors.New("unsupported patch operation, expected add or replace")
+	ErrInvalidPath    = errors.New("unsupported patch path")
+	ErrInvalidValue   = errors.New("invalid value for patch path")
+)
+
+// PatchRequest is a SCIM PATCH request body
+type PatchRequest struct {
+	Schemas    []string         `json:"schemas"`
+	Operations []PatchOperation `json:"Operations"`
+}
+
+// PatchOperation is a single PATCH operation
+type PatchOperation struct {
+	Op    string          `json:"op"`
+	Path  string          `json:"path,omitempty"`
+	Value json.RawMessage `json:"value"`
+}
+
+// Apply applies the PATCH operations to the user. Only add and replace are supported,
+// which covers what Okta and Azure AD send to update and deactivate users.
+func (r *PatchRequest) Apply(user *User) error {
+	for _, op := range r.Operations {
+		switch strings.ToLower(op.Op) {
+		case "add", "replace":
+		default:
+			return ErrInvalidPatchOp
+		}
+
+		if op.Path != "" {
+			if err := setAttribute(user, op.Path, op.Value); err != nil {
+				return err
+			}
+			continue
+		}
+
+		// Without a path the value is an object of attributes to set
+		var attributes map[string]json.RawMessage
+		if err := json.Unmarshal(op.Value, &attributes); err != nil {
+			return ErrInvalidValue
+		}
+		for path, value := range attributes {
+			if err := setAttribute(user, path, value); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// setAttribute sets a single attribute of the user from its JSON value
+func setAttribute(user *User, path string, value json.RawMessage) error {
+	switch strings.ToLower(path) {
+	case "active":
+		active, err := parseBool(value)
+		if err != nil {
+			return err
+		}
+		user.Active = &active
+	case "username":
+		return unmarshalString(value, &user.UserName)
+	case "displayname":
+		return unmarshalString(value, &user.DisplayName)
+	case "name":
+		var name Name
+		if err := json.Unmarshal(value, &name); err != nil {
+			return ErrInvalidValue
+		}
+		user.Name = &name
+		user.DisplayName = ""
+	case "name.formatted", "name.givenname", "name.familyname":
+		if user.Name == nil {
+			user.Name = &Name{}
+		}
+		// A name component change takes precedence over the previous display name
+		user.DisplayName = ""
+		field := strings.TrimPrefix(strings.ToLower(path), "name.")
+		switch field {
+		case "formatted":
+			return unmarshalString(value, &user.Name.Formatted)
+		case "givenname":
+			user.Name.Formatted = ""
+			return unmarshalString(value, &user.Name.GivenName)
+		default:
+			user.Name.Formatted = ""
+			return unmarshalString(value, &user.Name.FamilyName)
+		}
+	case "emails":
+		var emails []Email
+		if err := json.Unmarshal(value, &emails); err != nil {
+			return ErrInvalidValue
+		}
+		user.Emails = emails
+	case `emails[type eq "work"].value`, `emails[primary eq true].value`:
+		var email string
+		if err := unmarshalString(value, &email); err != nil {
+			return err
+		}
+		user.Emails = []Email{{Value: email, Type: "work", Primary: true}}
+	default:
+		return ErrInvalidPath
+	}
+	return nil
+}
+
+// unmarshalString decodes a JSON string value
+func unmarshalString(value json.RawMessage, dst *string) error {
+	if err := json.Unmarshal(value, dst); err != nil {
+		return ErrInvalidValue
+	}
+	return nil
+}
+
+// parseBool decodes a JSON boolean, also accepting "True"/"False" strings sent by Azure AD
+func parseBool(value json.RawMessage) (bool, error) {
+	var b bool
+	if err := json.Unmarshal(value, &b); err == nil {
+		return b, nil
+	}
+	var s string
+	if err := json.Unmarshal(value, &s); err != nil {
+		return false, ErrInvalidValue
+	}
+	b, err := strconv.ParseBool(s)
+	if err != nil {
+		return false, ErrInvalidValue
+	}
+	return b, nil
+}
@@ -0,0 +1,133 @@
+package service
+
+import (
+	"gojwt-rest-api/internal/domain"
+	"gojwt-rest-api/internal/events"
+	"gojwt-rest-api/internal/repository"
+	"strings"
+)
+
+// AbuseReportService defines the interface for abuse and security reports,
+// submitted publicly and triaged by admins
+type AbuseReportService interface {
+	// Submit stores a report sent from reporterIP as open
+	Submit(req *domain.SubmitAbuseReportRequest, reporterIP string) (*domain.AbuseReport, error)
+	// List lists matching reports, newest first
+	List(filter *domain.AbuseReportFilter, pagination *domain.PaginationQuery) ([]*domain.AbuseReport, int64, error)
+	// Get returns domain.ErrAbuseReportNotFound for unknown reports
+	Get(id uint) (*domain.AbuseReport, error)
+	// Triage sets the status of a report on behalf of actorID
+	Triage(actorID, id uint, req *domain.TriageAbuseReportRequest) (*domain.AbuseReport, error)
+}
+
+// AbuseReportServiceOption configures optional abuse report service behavior
+type AbuseReportServiceOption func(*abuseReportServiceImpl)
+
+// WithAbuseReportEventPublisher publishes submitted reports to publisher, so
+// that webhook subscribers can alert the people triaging them
+func WithAbuseReportEventPublisher(publisher events.Publisher) AbuseReportServiceOption {
+	return func(s *abuseReportServiceImpl) {
+		s.events = publisher
+	}
+}
+
+// abuseReportServiceImpl is the implementation of AbuseReportService
+type abuseReportServiceImpl struct {
+	reportRepo   repository.AbuseReportRepository
+	auditService AuditService
+	events       events.Publisher
+}
+
+// NewAbuseReportService creates a new abuse report service
+func NewAbuseReportService(
+	reportRepo repository.AbuseReportRepository,
+	auditService AuditService,
+	opts ...AbuseReportServiceOption,
+) AbuseReportService {
+	s := &abuseReportServiceImpl{
+		reportRepo:   reportRepo,
+		auditService: auditService,
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// Submit stores the report and announces it
+func (s *abuseReportServiceImpl) Submit(req *domain.SubmitAbuseReportRequest, reporterIP string) (*domain.AbuseReport, error) {
+	report := &domain.AbuseReport{
+		Category:         req.Category,
+		ReportedUserID:   req.ReportedUserID,
+		SessionReference: strings.TrimSpace(req.SessionReference),
+		ContactEmail:     strings.ToLower(strings.TrimSpace(req.ContactEmail)),
+		Message:          strings.TrimSpace(req.Message),
+		ReporterIP:       reporterIP,
+		Status:           domain.AbuseReportStatusOpen,
+	}
+	if err := s.reportRepo.Create(report); err != nil {
+		return nil, err
+	}
+
+	if s.events != nil {
+		s.events.Publish(events.AbuseReportSubmitted, &events.AbuseReportSubmittedData{
+			ReportID:       report.ID,
+			Category:       report.Category,
+			ReportedUserID: report.ReportedUserID,
+			SubmittedAt:    report.CreatedAt,
+		})
+	}
+	return report, nil
+}
+
+// List lists matching reports, newest first
+func (s *abuseReportServiceImpl) List(filter *domain.AbuseReportFilter, pagination *domain.PaginationQuery) ([]*domain.AbuseReport, int64, error) {
+	// Set default pagination values
+	if pagination.Page <= 0 {
+		pagination.Page = 1
+	}
+	if pagination.PageSize <= 0 {
+		pagination.PageSize = 10
+	}
+	if pagination.PageSize > 100 {
+		pagination.PageSize = 100 // Max page size
+	}
+
+	offset := (pagination.Page - 1) * pagination.PageSize
+	return s.reportRepo.Find(filter, offset, pagination.PageSize)
+}
+
+// Get gets a report by ID
+func (s *abuseReportServiceImpl) Get(id uint) (*domain.AbuseReport, error) {
+	return s.reportRepo.FindByID(id)
+}
+
+// Triage updates the status and note of the report and audits the change
+func (s *abuseReportServiceImpl) Triage(actorID, id uint, req *domain.TriageAbuseReportRequest) (*domain.AbuseReport, error) {
+	report, err := s.reportRepo.FindByID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	previous := report.Status
+	report.Status = req.Status
+	report.TriageNote = strings.TrimSpace(req.Note)
+	report.TriagedBy = &actorID
+	if err := s.reportRepo.Update(report); err != nil {
+		return nil, err
+	}
+
+	err = s.auditService.Record(&domain.AuditLog{
+		Action:  domain.AuditAbuseReportTriaged,
+		ActorID: &actorID,
+		UserID:  report.ReportedUserID,
+	}, map[string]interface{}{
+		"report_id":       report.ID,
+		"previous_status": previous,
+		"status":          report.Status,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return report, nil
+}
@@ -0,0 +1,97 @@
+package service
+
+import (
+	"gojwt-rest-api/internal/domain"
+	"time"
+)
+
+// Actions checked against access schedules
+const (
+	AccessActionLogin   = "login"
+	AccessActionRefresh = "refresh"
+)
+
+// AccessScheduleService enforces the hours in which users may log in and
+// refresh their sessions
+type AccessScheduleService interface {
+	// Check returns ErrOutsideAccessSchedule, after recording an audit log,
+	// when a schedule applying to the user does not allow the action now
+	Check(user *domain.User, action string) error
+}
+
+// accessScheduleServiceImpl is the implementation of AccessScheduleService
+type accessScheduleServiceImpl struct {
+	roles        map[string]*domain.AccessSchedule
+	settings     SettingsService
+	auditService AuditService
+}
+
+// NewAccessScheduleService creates a new access schedule service. roles maps
+// global roles (e.g. "user") and prefixed organization roles (e.g.
+// "org:member") to their schedule. With settings, the schedules of
+// organization settings apply to their members as well.
+func NewAccessScheduleService(roles map[string]*domain.AccessSchedule, settings SettingsService, auditService AuditService) AccessScheduleService {
+	return &accessScheduleServiceImpl{
+		roles:        roles,
+		settings:     settings,
+		auditService: auditService,
+	}
+}
+
+// Check checks every schedule applying to the user at the current time in
+// the timezone of the schedule. The user's profile timezone is ignored, users
+// change it themselves.
+func (s *accessScheduleServiceImpl) Check(user *domain.User, action string) error {
+	schedules, err := s.schedulesFor(user)
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	for _, schedule := range schedules {
+		if schedule.Allows(now) {
+			continue
+		}
+		err := s.auditService.Record(&domain.AuditLog{
+			Action:         domain.AuditAccessScheduleDenied,
+			UserID:         &user.ID,
+			OrganizationID: user.OrganizationID,
+		}, map[string]string{
+			"action":   action,
+			"schedule": schedule.String(),
+			"timezone": schedule.Location().String(),
+		})
+		if err != nil {
+			return err
+		}
+		return domain.ErrOutsideAccessSchedule
+	}
+	return nil
+}
+
+// schedulesFor returns the schedules of the user's roles and organization
+func (s *accessScheduleServiceImpl) schedulesFor(user *domain.User) ([]*domain.AccessSchedule, error) {
+	var schedules []*domain.AccessSchedule
+	for _, role := range user.Roles() {
+		if schedule := s.roles[role]; schedule != nil {
+			schedules = append(schedules, schedule)
+		}
+	}
+	if user.OrganizationID == nil {
+		return schedules, nil
+	}
+	if schedule := s.roles[OrganizationRolePrefix+user.OrganizationRole]; schedule != nil {
+		schedules = append(schedules, schedule)
+	}
+
+	if s.settings == nil {
+		return schedules, nil
+	}
+	settings, err := s.settings.ForOrganization(user.OrganizationID)
+	if err != nil {
+		return nil, err
+	}
+	if settings.AccessSchedule != nil {
+		schedules = append(schedules, settings.AccessSchedule)
+	}
+	return schedules, nil
+}
@@ -0,0 +1,304 @@
+package service
+
+import (
+	"gojwt-rest-api/internal/domain"
+	"gojwt-rest-api/internal/events"
+	"gojwt-rest-api/internal/repository"
+	"gojwt-rest-api/internal/utils"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// AccountLockPolicy configures the risk signals locking an account for
+// suspicious activity
+type AccountLockPolicy struct {
+	// LockOnTokenReuse locks the account when a rotated refresh token is presented again
+	LockOnTokenReuse bool
+	// MaxCountries locks the account when it logs in from more countries
+	// within CountryWindow, zero disables the check
+	MaxCountries  int
+	CountryWindow time.Duration
+	// UnlockTokenTTL is how long an emailed unlock link is valid
+	UnlockTokenTTL time.Duration
+}
+
+// AccountLockService defines the interface for locking accounts on risk
+// signals, and the self-service unlock of locked accounts
+type AccountLockService interface {
+	// TokenReused locks the user of a reused refresh token, if the policy says so
+	TokenReused(userID uint) error
+	// CheckLogin records the country of a login by user, and locks the user
+	// when it logged in from too many countries. Unknown countries are empty.
+	CheckLogin(user *domain.User, country string) error
+	// RequestUnlock emails a new unlock link to a locked user. Unknown emails
+	// and users that are not locked are ignored, without error.
+	RequestUnlock(email string) error
+	// Unlock unlocks the user an unlock token was emailed to, replacing their password
+	Unlock(req *domain.UnlockAccountRequest) (*domain.User, error)
+}
+
+// AccountLockServiceOption configures optional account lock service behavior
+type AccountLockServiceOption func(*accountLockServiceImpl)
+
+// WithAccountLockEventPublisher publishes lock and unlock events, which
+// deliver the unlock links, to publisher
+func WithAccountLockEventPublisher(publisher events.Publisher) AccountLockServiceOption {
+	return func(s *accountLockServiceImpl) {
+		s.events = publisher
+	}
+}
+
+// WithAccountLockSettingsService checks new passwords against the password
+// policy of the user's organization
+func WithAccountLockSettingsService(settings SettingsService) AccountLockServiceOption {
+	return func(s *accountLockServiceImpl) {
+		s.settings = settings
+	}
+}
+
+// WithAccountLockOnboardingTracker records unlocks as a verified email, since
+// the unlock link was sent to it
+func WithAccountLockOnboardingTracker(onboarding OnboardingTracker) AccountLockServiceOption {
+	return func(s *accountLockServiceImpl) {
+		s.onboarding = onboarding
+	}
+}
+
+// WithAccountLockUserCache invalidates cache when users change
+func WithAccountLockUserCache(cache UserCache) AccountLockServiceOption {
+	return func(s *accountLockServiceImpl) {
+		s.userCache = cache
+	}
+}
+
+// accountLockServiceImpl is the implementation of AccountLockService
+type accountLockServiceImpl struct {
+	userRepo      repository.UserRepository
+	tokenRepo     repository.TokenRepository
+	locationRepo  repository.LoginLocationRepository
+	oneTimeTokens OneTimeTokenService
+	auditService  AuditService
+	policy        AccountLockPolicy
+	events        events.Publisher
+	settings      SettingsService
+	onboarding    OnboardingTracker
+	userCache     UserCache
+}
+
+// NewAccountLockService creates a new account lock service
+func NewAccountLockService(
+	userRepo repository.UserRepository,
+	tokenRepo repository.TokenRepository,
+	locationRepo repository.LoginLocationRepository,
+	oneTimeTokens OneTimeTokenService,
+	auditService AuditService,
+	policy AccountLockPolicy,
+	opts ...AccountLockServiceOption,
+) AccountLockService {
+	s := &accountLockServiceImpl{
+		userRepo:      userRepo,
+		tokenRepo:     tokenRepo,
+		locationRepo:  locationRepo,
+		oneTimeTokens: oneTimeTokens,
+		auditService:  auditService,
+		policy:        policy,
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// TokenReused locks the user of a reused refresh token
+func (s *accountLockServiceImpl) TokenReused(userID uint) error {
+	if !s.policy.LockOnTokenReuse {
+		return nil
+	}
+	user, err := s.userRepo.FindByID(userID)
+	if err != nil {
+		return err
+	}
+	if user.IsLocked() || !user.IsActive() {
+		return nil
+	}
+	return s.lock(user, domain.LockReasonTokenReuse)
+}
+
+// CheckLogin records the country of a login and counts the countries of the
+// window, dropping older locations of the user on the way
+func (s *accountLockServiceImpl) CheckLogin(user *domain.User, country string) error {
+	if user.IsLocked() {
+		return domain.ErrAccountLocked
+	}
+	if s.policy.MaxCountries <= 0 || country == "" {
+		return nil
+	}
+
+	now := time.Now()
+	since := now.Add(-s.policy.CountryWindow)
+	if err := s.locationRepo.DeleteBefore(user.ID, since); err != nil {
+		return err
+	}
+	if err := s.locationRepo.Create(&domain.LoginLocation{UserID: user.ID, Country: strings.ToUpper(country)}); err != nil {
+		return err
+	}
+	countries, err := s.locationRepo.FindCountriesSince(user.ID, since)
+	if err != nil {
+		return err
+	}
+	if len(countries) <= s.policy.MaxCountries {
+		return nil
+	}
+
+	if err := s.lock(user, domain.LockReasonManyCountries); err != nil {
+		return err
+	}
+	return domain.ErrAccountLocked
+}
+
+// lock locks the account, ends its sessions and emails the user an unlock
+// link. Bumping the session epoch rejects the outstanding access tokens too.
+func (s *accountLockServiceImpl) lock(user *domain.User, reason string) error {
+	now := time.Now()
+	user.LockedAt = &now
+	user.LockReason = reason
+	user.SessionEpoch++
+	if err := s.userRepo.Update(user); err != nil {
+		return domain.ErrFailedToUpdateUser
+	}
+	invalidateUserCache(s.userCache)
+	if err := s.tokenRepo.RevokeAllUserRefreshTokens(user.ID); err != nil {
+		return err
+	}
+
+	err := s.auditService.Record(&domain.AuditLog{
+		Action:         domain.AuditUserLocked,
+		UserID:         &user.ID,
+		OrganizationID: user.OrganizationID,
+	}, map[string]string{"reason": reason})
+	if err != nil {
+		return err
+	}
+	return s.sendUnlockLink(events.UserLocked, user)
+}
+
+// RequestUnlock emails a new unlock link to a locked user
+func (s *accountLockServiceImpl) RequestUnlock(email string) error {
+	user, err := s.userRepo.FindByEmail(email)
+	if err != nil {
+		if err == domain.ErrUserNotFound {
+			return nil
+		}
+		return err
+	}
+	if !user.IsLocked() || !user.IsActive() {
+		return nil
+	}
+	return s.sendUnlockLink(events.UserUnlockRequested, user)
+}
+
+// sendUnlockLink issues an unlock token, replacing earlier ones, and
+// publishes it to be emailed to the user
+func (s *accountLockServiceImpl) sendUnlockLink(eventType string, user *domain.User) error {
+	token, record, err := s.oneTimeTokens.Issue(
+		domain.OneTimeTokenPurposeAccountUnlock,
+		strconv.FormatUint(uint64(user.ID), 10),
+		&domain.AccountUnlock{UserID: user.ID},
+		s.policy.UnlockTokenTTL,
+	)
+	if err != nil {
+		return err
+	}
+
+	if s.events != nil {
+		s.events.Publish(eventType, &events.UserLockedData{
+			UserID:          user.ID,
+			Name:            user.Name,
+			Email:           user.Email,
+			Reason:          user.LockReason,
+			LockedAt:        *user.LockedAt,
+			UnlockExpiresAt: record.ExpiresAt,
+			UnlockToken:     token,
+		})
+	}
+	return nil
+}
+
+// Unlock checks the new password before consuming the token, so that a
+// password rejected by the policy doesn't spend the link
+func (s *accountLockServiceImpl) Unlock(req *domain.UnlockAccountRequest) (*domain.User, error) {
+	var unlock domain.AccountUnlock
+	if _, err := s.oneTimeTokens.Lookup(domain.OneTimeTokenPurposeAccountUnlock, req.Token, &unlock); err != nil {
+		return nil, err
+	}
+	user, err := s.userRepo.FindByID(unlock.UserID)
+	if err != nil {
+		if err == domain.ErrUserNotFound {
+			return nil, domain.ErrOneTimeTokenInvalid
+		}
+		return nil, err
+	}
+	if !user.IsLocked() {
+		return nil, domain.ErrOneTimeTokenInvalid
+	}
+
+	if s.settings != nil {
+		settings, err := s.settings.ForOrganization(user.OrganizationID)
+		if err != nil {
+			return nil, err
+		}
+		if err := settings.PasswordPolicy.Check(req.NewPassword); err != nil {
+			return nil, err
+		}
+	}
+	// Whoever triggered the lock may know the current password
+	if utils.CheckPassword(user.Password, req.NewPassword) == nil {
+		return nil, domain.ErrPasswordUnchanged
+	}
+	hashedPassword, err := utils.HashPassword(req.NewPassword)
+	if err != nil {
+		return nil, domain.ErrFailedToHashPassword
+	}
+
+	if _, err := s.oneTimeTokens.Consume(domain.OneTimeTokenPurposeAccountUnlock, req.Token, nil); err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	user.Password = hashedPassword
+	user.LockedAt = nil
+	user.LockReason = ""
+	// The password is replaced, end any session opened with the old one
+	user.SessionEpoch++
+	if err := s.userRepo.Update(user); err != nil {
+		return nil, domain.ErrFailedToUpdateUser
+	}
+	invalidateUserCache(s.userCache)
+	if err := s.tokenRepo.RevokeAllUserRefreshTokens(user.ID); err != nil {
+		return nil, err
+	}
+	// Start the country check afresh, or the next login would lock again
+	if err := s.locationRepo.DeleteBefore(user.ID, now.Add(time.Second)); err != nil {
+		return nil, err
+	}
+	if s.onboarding != nil {
+		if err := s.onboarding.CompleteStep(user.ID, domain.OnboardingStepEmailVerified); err != nil {
+			return nil, err
+		}
+	}
+
+	err = s.auditService.Record(&domain.AuditLog{
+		Action:         domain.AuditUserUnlocked,
+		ActorID:        &user.ID,
+		UserID:         &user.ID,
+		OrganizationID: user.OrganizationID,
+	}, nil)
+	if err != nil {
+		return nil, err
+	}
+	if s.events != nil {
+		s.events.Publish(events.UserUnlocked, &events.UserUnlockedData{UserID: user.ID, UnlockedAt: now})
+	}
+	return user, nil
+}
@@ -0,0 +1,227 @@
+package service
+
+import (
+	"gojwt-rest-api/internal/domain"
+	"gojwt-rest-api/internal/events"
+	"gojwt-rest-api/internal/repository"
+	"gojwt-rest-api/internal/utils"
+	"strings"
+	"time"
+)
+
+// APIKeyService defines the interface for API key business logic
+type APIKeyService interface {
+	CreateAPIKey(userID uint, req *domain.CreateAPIKeyRequest) (*domain.APIKey, string, error)
+	ListUserAPIKeys(userID uint) ([]*domain.APIKey, error)
+	RevokeAPIKey(userID uint, id uint) error
+	// Authenticate resolves a plaintext key to a valid API key with its
+	// owner, refusing keys of deactivated or locked users
+	Authenticate(plaintext string) (*domain.APIKey, error)
+	// Admin methods
+	ListAPIKeys() ([]*domain.APIKey, error)
+	GetUsage(id uint, limit int) (*domain.APIKeyUsageResponse, error)
+	CheckRotation() (*domain.APIKeyRotationReport, error)
+	RotationMaxAge() time.Duration
+}
+
+// apiKeyServiceImpl is the implementation of APIKeyService
+type apiKeyServiceImpl struct {
+	apiKeyRepo  repository.APIKeyRepository
+	rotationAge time.Duration
+	autoExpire  bool
+	expireGrace time.Duration
+	events      events.Publisher
+}
+
+// APIKeyServiceOption configures optional behaviour of the API key service
+type APIKeyServiceOption func(*apiKeyServiceImpl)
+
+// WithAutoExpire schedules keys due for rotation to expire grace after their owner was notified
+func WithAutoExpire(grace time.Duration) APIKeyServiceOption {
+	return func(s *apiKeyServiceImpl) {
+		s.autoExpire = true
+		s.expireGrace = grace
+	}
+}
+
+// WithAPIKeyEventPublisher publishes rotation reminders to publisher
+func WithAPIKeyEventPublisher(publisher events.Publisher) APIKeyServiceOption {
+	return func(s *apiKeyServiceImpl) {
+		s.events = publisher
+	}
+}
+
+// NewAPIKeyService creates a new API key service.
+// Keys older than rotationAge are flagged for rotation; zero disables the check.
+func NewAPIKeyService(apiKeyRepo repository.APIKeyRepository, rotationAge time.Duration, opts ...APIKeyServiceOption) APIKeyService {
+	s := &apiKeyServiceImpl{
+		apiKeyRepo:  apiKeyRepo,
+		rotationAge: rotationAge,
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// CreateAPIKey issues a new API key, returning the plaintext key alongside the stored entity
+func (s *apiKeyServiceImpl) CreateAPIKey(userID uint, req *domain.CreateAPIKeyRequest) (*domain.APIKey, string, error) {
+	plaintext, prefix, err := utils.GenerateAPIKey()
+	if err != nil {
+		return nil, "", domain.ErrFailedToGenerateToken
+	}
+
+	key := &domain.APIKey{
+		UserID:  userID,
+		Name:    req.Name,
+		Prefix:  prefix,
+		KeyHash: utils.HashAPIKey(plaintext),
+		Scopes:  strings.Join(req.Scopes, ","),
+	}
+	if req.ExpiresInDays > 0 {
+		expiresAt := time.Now().AddDate(0, 0, req.ExpiresInDays)
+		key.ExpiresAt = &expiresAt
+	}
+
+	if err := s.apiKeyRepo.Create(key); err != nil {
+		return nil, "", err
+	}
+
+	return key, plaintext, nil
+}
+
+// ListUserAPIKeys lists the API keys owned by a user
+func (s *apiKeyServiceImpl) ListUserAPIKeys(userID uint) ([]*domain.APIKey, error) {
+	return s.apiKeyRepo.FindByUserID(userID)
+}
+
+// RevokeAPIKey revokes an API key owned by the user
+func (s *apiKeyServiceImpl) RevokeAPIKey(userID uint, id uint) error {
+	key, err := s.apiKeyRepo.FindByID(id)
+	if err != nil {
+		return err
+	}
+	// Don't reveal keys owned by somebody else
+	if key.UserID != userID {
+		return domain.ErrAPIKeyNotFound
+	}
+	if key.RevokedAt != nil {
+		return nil
+	}
+
+	now := time.Now()
+	key.RevokedAt = &now
+	return s.apiKeyRepo.Update(key)
+}
+
+// Authenticate resolves a plaintext key to a valid API key with its owner.
+// Keys act on behalf of their owner, so they stop working while the owner is
+// deactivated or locked.
+func (s *apiKeyServiceImpl) Authenticate(plaintext string) (*domain.APIKey, error) {
+	key, err := s.apiKeyRepo.FindByHash(utils.HashAPIKey(plaintext))
+	if err != nil {
+		if err == domain.ErrAPIKeyNotFound {
+			return nil, domain.ErrInvalidAPIKey
+		}
+		return nil, err
+	}
+	if !key.IsValid(time.Now()) || !key.User.IsActive() || key.User.IsLocked() {
+		return nil, domain.ErrInvalidAPIKey
+	}
+	return key, nil
+}
+
+// ListAPIKeys lists all API keys
+func (s *apiKeyServiceImpl) ListAPIKeys() ([]*domain.APIKey, error) {
+	return s.apiKeyRepo.FindAll()
+}
+
+// GetUsage summarizes an API key's usage with its limit most requested endpoints
+func (s *apiKeyServiceImpl) GetUsage(id uint, limit int) (*domain.APIKeyUsageResponse, error) {
+	key, err := s.apiKeyRepo.FindByID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	usage, err := s.apiKeyRepo.FindUsage(id)
+	if err != nil {
+		return nil, err
+	}
+
+	response := &domain.APIKeyUsageResponse{
+		APIKey:       key.ToResponse(s.rotationAge),
+		LastUsedAt:   key.LastUsedAt,
+		TopEndpoints: make([]*domain.EndpointUsage, 0, min(len(usage), limit)),
+	}
+	for _, endpoint := range usage {
+		response.TotalRequests += endpoint.RequestCount
+		if len(response.TopEndpoints) < limit {
+			response.TopEndpoints = append(response.TopEndpoints, &domain.EndpointUsage{
+				Method:     endpoint.Method,
+				Route:      endpoint.Route,
+				Requests:   endpoint.RequestCount,
+				LastUsedAt: endpoint.LastUsedAt,
+			})
+		}
+	}
+
+	return response, nil
+}
+
+// RotationMaxAge returns the age after which keys are due for rotation
+func (s *apiKeyServiceImpl) RotationMaxAge() time.Duration {
+	return s.rotationAge
+}
+
+// CheckRotation flags keys older than the rotation age, notifies their owners once
+// and, when auto-expiry is enabled, schedules the keys to expire after the grace period
+func (s *apiKeyServiceImpl) CheckRotation() (*domain.APIKeyRotationReport, error) {
+	now := time.Now()
+	report := &domain.APIKeyRotationReport{
+		Checked:    now,
+		MaxAge:     s.rotationAge.String(),
+		AutoExpire: s.autoExpire,
+	}
+	if s.rotationAge <= 0 {
+		return report, nil
+	}
+
+	keys, err := s.apiKeyRepo.FindActiveCreatedBefore(now.Add(-s.rotationAge))
+	if err != nil {
+		return nil, err
+	}
+
+	for _, key := range keys {
+		report.Stale++
+		if key.RotationNotifiedAt != nil {
+			continue
+		}
+
+		if s.autoExpire {
+			expiresAt := now.Add(s.expireGrace)
+			if key.ExpiresAt == nil || key.ExpiresAt.After(expiresAt) {
+				key.ExpiresAt = &expiresAt
+				report.Expiring++
+			}
+		}
+		key.RotationNotifiedAt = &now
+		if err := s.apiKeyRepo.Update(key); err != nil {
+			return nil, err
+		}
+		report.Notified++
+
+		if s.events != nil {
+			s.events.Publish(events.APIKeyRotationDue, &events.APIKeyRotationDueData{
+				APIKeyID:  key.ID,
+				Name:      key.Name,
+				Prefix:    key.Prefix,
+				UserID:    key.UserID,
+				Email:     key.User.Email,
+				CreatedAt: key.CreatedAt,
+				ExpiresAt: key.ExpiresAt,
+			})
+		}
+	}
+
+	return report, nil
+}
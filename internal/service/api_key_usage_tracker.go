@@ -0,0 +1,91 @@
+package service
+
+import (
+	"context"
+	"gojwt-rest-api/internal/repository"
+	"sync"
+	"time"
+)
+
+// usageKey identifies an endpoint called with an API key
+type usageKey struct {
+	apiKeyID uint
+	method   string
+	route    string
+}
+
+// usageCount is the pending usage of an endpoint since the last flush
+type usageCount struct {
+	requests   int64
+	lastUsedAt time.Time
+}
+
+// APIKeyUsageTracker aggregates API key usage in memory and writes it to the
+// database in batches, keeping the database off the request path
+type APIKeyUsageTracker struct {
+	apiKeyRepo repository.APIKeyRepository
+	mu         sync.Mutex
+	pending    map[usageKey]*usageCount
+}
+
+// NewAPIKeyUsageTracker creates a new API key usage tracker
+func NewAPIKeyUsageTracker(apiKeyRepo repository.APIKeyRepository) *APIKeyUsageTracker {
+	return &APIKeyUsageTracker{
+		apiKeyRepo: apiKeyRepo,
+		pending:    make(map[usageKey]*usageCount),
+	}
+}
+
+// Record records a request made with an API key to the given route template
+func (t *APIKeyUsageTracker) Record(apiKeyID uint, method, route string) {
+	key := usageKey{apiKeyID: apiKeyID, method: method, route: route}
+	now := time.Now()
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	count, ok := t.pending[key]
+	if !ok {
+		count = &usageCount{}
+		t.pending[key] = count
+	}
+	count.requests++
+	count.lastUsedAt = now
+}
+
+// Flush writes the usage aggregated since the last flush to the database.
+// Usage that fails to be written is kept for the next flush.
+func (t *APIKeyUsageTracker) Flush(ctx context.Context) error {
+	t.mu.Lock()
+	batch := t.pending
+	t.pending = make(map[usageKey]*usageCount)
+	t.mu.Unlock()
+
+	var firstErr error
+	for key, count := range batch {
+		err := t.apiKeyRepo.RecordUsage(key.apiKeyID, key.method, key.route, count.requests, count.lastUsedAt)
+		if err != nil {
+			t.requeue(key, count)
+			if firstErr == nil {
+				firstErr = err
+			}
+		}
+	}
+	return firstErr
+}
+
+// requeue merges usage that failed to flush back into the pending batch
+func (t *APIKeyUsageTracker) requeue(key usageKey, count *usageCount) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	pending, ok := t.pending[key]
+	if !ok {
+		t.pending[key] = count
+		return
+	}
+	pending.requests += count.requests
+	if count.lastUsedAt.After(pending.lastUsedAt) {
+		pending.lastUsedAt = count.lastUsedAt
+	}
+}
@@ -0,0 +1,62 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"gojwt-rest-api/internal/domain"
+	"gojwt-rest-api/internal/repository"
+)
+
+// AuditService defines the interface for recording and reading audit logs
+type AuditService interface {
+	// WithContext returns the service reading and writing with ctx, scoping
+	// audit logs to the organization of a tenant request
+	WithContext(ctx context.Context) AuditService
+	// Record appends the audit log, storing detail (if any) as JSON
+	Record(log *domain.AuditLog, detail interface{}) error
+	List(filter *domain.AuditLogFilter, pagination *domain.PaginationQuery) ([]*domain.AuditLog, int64, error)
+}
+
+// auditServiceImpl is the implementation of AuditService
+type auditServiceImpl struct {
+	auditRepo repository.AuditLogRepository
+}
+
+// NewAuditService creates a new audit service
+func NewAuditService(auditRepo repository.AuditLogRepository) AuditService {
+	return &auditServiceImpl{auditRepo: auditRepo}
+}
+
+// WithContext returns the service reading and writing with ctx
+func (s *auditServiceImpl) WithContext(ctx context.Context) AuditService {
+	return &auditServiceImpl{auditRepo: s.auditRepo.WithContext(ctx)}
+}
+
+// Record appends the audit log, storing detail (if any) as JSON
+func (s *auditServiceImpl) Record(log *domain.AuditLog, detail interface{}) error {
+	if detail != nil {
+		data, err := json.Marshal(detail)
+		if err != nil {
+			return err
+		}
+		log.Detail = string(data)
+	}
+	return s.auditRepo.Create(log)
+}
+
+// List lists matching audit logs, newest first
+func (s *auditServiceImpl) List(filter *domain.AuditLogFilter, pagination *domain.PaginationQuery) ([]*domain.AuditLog, int64, error) {
+	// Set default pagination values
+	if pagination.Page <= 0 {
+		pagination.Page = 1
+	}
+	if pagination.PageSize <= 0 {
+		pagination.PageSize = 10
+	}
+	if pagination.PageSize > 100 {
+		pagination.PageSize = 100 // Max page size
+	}
+
+	offset := (pagination.Page - 1) * pagination.PageSize
+	return s.auditRepo.Find(filter, offset, pagination.PageSize)
+}
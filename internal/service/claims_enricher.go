@@ -0,0 +1,41 @@
+package service
+
+import (
+	"gojwt-rest-api/internal/domain"
+	"gojwt-rest-api/internal/repository"
+	"gojwt-rest-api/internal/utils"
+)
+
+// ClaimsEnricher contributes extra claims to the access tokens issued for a user
+type ClaimsEnricher interface {
+	EnrichClaims(user *domain.User) (utils.TokenOption, error)
+}
+
+// planClaimsEnricher embeds the user's organization, plan and entitlements into tokens
+type planClaimsEnricher struct {
+	orgRepo repository.OrganizationRepository
+}
+
+// NewPlanClaimsEnricher creates a claims enricher adding organization plan entitlements.
+// Claims reflect the plan at issuance, so plan changes apply on the next token refresh.
+func NewPlanClaimsEnricher(orgRepo repository.OrganizationRepository) ClaimsEnricher {
+	return &planClaimsEnricher{orgRepo: orgRepo}
+}
+
+// EnrichClaims returns a token option setting the user's tenant claims
+func (e *planClaimsEnricher) EnrichClaims(user *domain.User) (utils.TokenOption, error) {
+	if user.OrganizationID == nil {
+		return nil, nil
+	}
+
+	org, err := e.orgRepo.FindByID(*user.OrganizationID)
+	if err != nil {
+		return nil, err
+	}
+
+	return func(claims *utils.JWTClaims) {
+		claims.OrganizationID = org.ID
+		claims.Plan = org.Plan.Name
+		claims.Entitlements = org.Plan.EntitlementList()
+	}, nil
+}
@@ -0,0 +1,204 @@
+package service
+
+import (
+	"gojwt-rest-api/internal/domain"
+	"gojwt-rest-api/internal/repository"
+	"sync"
+	"time"
+)
+
+// ClientVersionService manages the minimum app versions of client
+// applications. The policies are cached in memory for cacheTTL, so changes
+// made on other instances apply within cacheTTL.
+type ClientVersionService interface {
+	// Check returns domain.ErrClientUpgradeRequired, with the policy, when
+	// version is older than the minimum version of clientID, and
+	// domain.ErrInvalidClientVersion when it is malformed. An empty version,
+	// from apps predating the minimum, is older than any. Clients without
+	// minimum version accept any version.
+	Check(clientID, version string) (*domain.ClientVersionPolicy, error)
+	// List returns the minimum versions of the client applications having one
+	List() ([]*domain.ClientVersionPolicy, error)
+	// SetMinVersion sets the minimum version of a client application on behalf of actorID
+	SetMinVersion(actorID uint, clientID, minVersion string) (*domain.ClientVersionPolicy, error)
+	// RemoveMinVersion accepts any version of a client application again
+	RemoveMinVersion(actorID uint, clientID string) error
+}
+
+// ClientVersionServiceOption configures optional client version service behavior
+type ClientVersionServiceOption func(*clientVersionServiceImpl)
+
+// WithClientVersionClients only accepts minimum versions for the configured
+// client applications, by ID
+func WithClientVersionClients(clients map[string]*domain.ClientApplication) ClientVersionServiceOption {
+	return func(s *clientVersionServiceImpl) {
+		s.clients = clients
+	}
+}
+
+// clientVersionServiceImpl is the implementation of ClientVersionService
+type clientVersionServiceImpl struct {
+	repo         repository.ClientVersionRepository
+	auditService AuditService
+	cacheTTL     time.Duration
+	clients      map[string]*domain.ClientApplication
+
+	mu       sync.Mutex
+	policies map[string]cachedClientVersion
+	expires  time.Time
+}
+
+// cachedClientVersion is a policy with its parsed minimum version
+type cachedClientVersion struct {
+	policy *domain.ClientVersionPolicy
+	min    domain.ClientVersion
+}
+
+// NewClientVersionService creates a new client version service
+func NewClientVersionService(
+	repo repository.ClientVersionRepository,
+	auditService AuditService,
+	cacheTTL time.Duration,
+	opts ...ClientVersionServiceOption,
+) ClientVersionService {
+	s := &clientVersionServiceImpl{
+		repo:         repo,
+		auditService: auditService,
+		cacheTTL:     cacheTTL,
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// Check compares version with the cached minimum version of the client
+func (s *clientVersionServiceImpl) Check(clientID, version string) (*domain.ClientVersionPolicy, error) {
+	policies, err := s.cachedPolicies()
+	if err != nil {
+		return nil, err
+	}
+	cached, ok := policies[clientID]
+	if !ok {
+		return nil, nil
+	}
+
+	if version == "" {
+		return cached.policy, domain.ErrClientUpgradeRequired
+	}
+	v, err := domain.ParseClientVersion(version)
+	if err != nil {
+		return cached.policy, domain.ErrInvalidClientVersion
+	}
+	if v.Less(cached.min) {
+		return cached.policy, domain.ErrClientUpgradeRequired
+	}
+	return nil, nil
+}
+
+// List returns the stored policies
+func (s *clientVersionServiceImpl) List() ([]*domain.ClientVersionPolicy, error) {
+	return s.repo.FindAll()
+}
+
+// SetMinVersion validates and stores the minimum version, applying it on this
+// instance immediately
+func (s *clientVersionServiceImpl) SetMinVersion(actorID uint, clientID, minVersion string) (*domain.ClientVersionPolicy, error) {
+	if err := s.checkClient(clientID); err != nil {
+		return nil, err
+	}
+	if _, err := domain.ParseClientVersion(minVersion); err != nil {
+		return nil, domain.ErrInvalidClientVersion
+	}
+
+	policy := &domain.ClientVersionPolicy{
+		ClientID:   clientID,
+		MinVersion: minVersion,
+		UpdatedBy:  &actorID,
+	}
+	if err := s.repo.Save(policy); err != nil {
+		return nil, err
+	}
+	s.invalidate()
+
+	err := s.auditService.Record(&domain.AuditLog{
+		Action:  domain.AuditClientMinVersionSet,
+		ActorID: &actorID,
+	}, map[string]interface{}{
+		"client_id":   clientID,
+		"min_version": minVersion,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return policy, nil
+}
+
+// RemoveMinVersion deletes the policy of the client, applying it on this
+// instance immediately
+func (s *clientVersionServiceImpl) RemoveMinVersion(actorID uint, clientID string) error {
+	if err := s.repo.Delete(clientID); err != nil {
+		return err
+	}
+	s.invalidate()
+
+	return s.auditService.Record(&domain.AuditLog{
+		Action:  domain.AuditClientMinVersionUnset,
+		ActorID: &actorID,
+	}, map[string]interface{}{
+		"client_id": clientID,
+	})
+}
+
+// checkClient refuses malformed client IDs, and unknown ones when client
+// applications are configured
+func (s *clientVersionServiceImpl) checkClient(clientID string) error {
+	if !domain.ValidClientID(clientID) {
+		return domain.ErrInvalidClientApplication
+	}
+	if len(s.clients) > 0 {
+		if _, ok := s.clients[clientID]; !ok {
+			return domain.ErrUnknownClient
+		}
+	}
+	return nil
+}
+
+// cachedPolicies returns the policies by client ID, loading them when the
+// cache expired
+func (s *clientVersionServiceImpl) cachedPolicies() (map[string]cachedClientVersion, error) {
+	now := time.Now()
+	s.mu.Lock()
+	policies, expires := s.policies, s.expires
+	s.mu.Unlock()
+	if policies != nil && now.Before(expires) {
+		return policies, nil
+	}
+
+	stored, err := s.repo.FindAll()
+	if err != nil {
+		return nil, err
+	}
+	policies = make(map[string]cachedClientVersion, len(stored))
+	for _, policy := range stored {
+		// Minimum versions are validated when set, skip any edited by hand
+		// into something unparsable rather than lock the client out
+		min, err := domain.ParseClientVersion(policy.MinVersion)
+		if err != nil {
+			continue
+		}
+		policies[policy.ClientID] = cachedClientVersion{policy: policy, min: min}
+	}
+
+	s.mu.Lock()
+	s.policies, s.expires = policies, now.Add(s.cacheTTL)
+	s.mu.Unlock()
+	return policies, nil
+}
+
+// invalidate reloads the policies on the next check
+func (s *clientVersionServiceImpl) invalidate() {
+	s.mu.Lock()
+	s.policies = nil
+	s.mu.Unlock()
+}
@@ -0,0 +1,311 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"gojwt-rest-api/internal/domain"
+	"gojwt-rest-api/internal/events"
+	"gojwt-rest-api/internal/repository"
+	"gojwt-rest-api/internal/utils"
+	"time"
+)
+
+// inactivityBatchSize is the number of users processed per query
+const inactivityBatchSize = 100
+
+// inactivityAuditSource identifies audit logs recorded by the inactivity policy
+const inactivityAuditSource = "inactivity_policy"
+
+// InactivityPolicy configures the stages applied to users without a login.
+// Each stage applies after the user was inactive for its duration, zero disables it.
+type InactivityPolicy struct {
+	WarnAfter      time.Duration
+	SuspendAfter   time.Duration
+	AnonymizeAfter time.Duration
+	// AccessTokenTTL is how long access tokens of anonymized users stay blacklisted
+	AccessTokenTTL time.Duration
+}
+
+// InactivityService defines the interface for the user inactivity policy
+type InactivityService interface {
+	// Run warns, suspends and anonymizes inactive users as configured by the policy
+	Run(ctx context.Context) (*domain.InactivityReport, error)
+	// SetExempt opts a user out of (or back into) the inactivity policy
+	SetExempt(actorID, id uint, exempt bool) (*domain.User, error)
+}
+
+// InactivityServiceOption configures optional inactivity service behavior
+type InactivityServiceOption func(*inactivityServiceImpl)
+
+// WithInactivityEventPublisher publishes an event for every applied stage to publisher
+func WithInactivityEventPublisher(publisher events.Publisher) InactivityServiceOption {
+	return func(s *inactivityServiceImpl) {
+		s.events = publisher
+	}
+}
+
+// WithInactivityUserCache invalidates cache when users change
+func WithInactivityUserCache(cache UserCache) InactivityServiceOption {
+	return func(s *inactivityServiceImpl) {
+		s.userCache = cache
+	}
+}
+
+// inactivityServiceImpl is the implementation of InactivityService
+type inactivityServiceImpl struct {
+	userRepo     repository.UserRepository
+	tokenRepo    repository.TokenRepository
+	auditService AuditService
+	policy       InactivityPolicy
+	events       events.Publisher
+	userCache    UserCache
+}
+
+// NewInactivityService creates a new inactivity service
+func NewInactivityService(userRepo repository.UserRepository, tokenRepo repository.TokenRepository, auditService AuditService, policy InactivityPolicy, opts ...InactivityServiceOption) InactivityService {
+	s := &inactivityServiceImpl{
+		userRepo:     userRepo,
+		tokenRepo:    tokenRepo,
+		auditService: auditService,
+		policy:       policy,
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// Run applies the stages from last to first, so that a single run never
+// moves a user through more than one stage
+func (s *inactivityServiceImpl) Run(ctx context.Context) (*domain.InactivityReport, error) {
+	now := time.Now()
+	report := &domain.InactivityReport{Checked: now}
+
+	if s.policy.AnonymizeAfter > 0 {
+		query := &domain.InactiveUserQuery{
+			Stage:            domain.InactivityStageAnonymize,
+			LastActiveBefore: now.Add(-s.policy.AnonymizeAfter),
+		}
+		switch {
+		case s.policy.SuspendAfter > 0:
+			query.SuspendedBefore = timePtr(now.Add(-(s.policy.AnonymizeAfter - s.policy.SuspendAfter)))
+		case s.policy.WarnAfter > 0:
+			query.WarnedBefore = timePtr(now.Add(-(s.policy.AnonymizeAfter - s.policy.WarnAfter)))
+		}
+		count, err := s.apply(ctx, query, s.anonymize)
+		report.Anonymized = count
+		if err != nil {
+			return report, err
+		}
+	}
+
+	if s.policy.SuspendAfter > 0 {
+		query := &domain.InactiveUserQuery{
+			Stage:            domain.InactivityStageSuspend,
+			LastActiveBefore: now.Add(-s.policy.SuspendAfter),
+		}
+		if s.policy.WarnAfter > 0 {
+			query.WarnedBefore = timePtr(now.Add(-(s.policy.SuspendAfter - s.policy.WarnAfter)))
+		}
+		count, err := s.apply(ctx, query, s.suspend)
+		report.Suspended = count
+		if err != nil {
+			return report, err
+		}
+	}
+
+	if s.policy.WarnAfter > 0 {
+		query := &domain.InactiveUserQuery{
+			Stage:            domain.InactivityStageWarn,
+			LastActiveBefore: now.Add(-s.policy.WarnAfter),
+		}
+		count, err := s.apply(ctx, query, s.warn)
+		report.Warned = count
+		if err != nil {
+			return report, err
+		}
+	}
+
+	return report, nil
+}
+
+// apply runs stage on every user matching query, returning the number of users it applied to
+func (s *inactivityServiceImpl) apply(ctx context.Context, query *domain.InactiveUserQuery, stage func(user *domain.User, now time.Time) (bool, error)) (int, error) {
+	query.Limit = inactivityBatchSize
+	count := 0
+	for {
+		users, err := s.userRepo.FindInactive(query)
+		if err != nil {
+			return count, err
+		}
+
+		applied := 0
+		for _, user := range users {
+			if err := ctx.Err(); err != nil {
+				return count, err
+			}
+			ok, err := stage(user, time.Now())
+			if ok {
+				applied++
+			}
+			if err != nil {
+				return count + applied, fmt.Errorf("user %d: %w", user.ID, err)
+			}
+		}
+		count += applied
+
+		// Users a stage was applied to no longer match the query
+		if len(users) < inactivityBatchSize || applied == 0 {
+			return count, nil
+		}
+	}
+}
+
+// warn records the inactivity warning and notifies the user
+func (s *inactivityServiceImpl) warn(user *domain.User, now time.Time) (bool, error) {
+	warned, err := s.userRepo.MarkInactivityWarned(user.ID, now)
+	if err != nil || !warned {
+		return false, err
+	}
+
+	data := &events.UserInactivityData{
+		UserID:       user.ID,
+		Email:        user.Email,
+		Name:         user.Name,
+		LastActiveAt: user.LastActiveAt(),
+	}
+	if s.policy.SuspendAfter > 0 {
+		data.SuspendAt = timePtr(now.Add(s.policy.SuspendAfter - s.policy.WarnAfter))
+	}
+	if err := s.record(domain.AuditUserInactivityWarned, user, data); err != nil {
+		return true, err
+	}
+	if s.events != nil {
+		s.events.Publish(events.UserInactivityWarning, data)
+	}
+	return true, nil
+}
+
+// suspend deactivates the user and ends their sessions
+func (s *inactivityServiceImpl) suspend(user *domain.User, now time.Time) (bool, error) {
+	suspended, err := s.userRepo.SuspendInactive(user.ID, now)
+	if err != nil || !suspended {
+		return false, err
+	}
+	invalidateUserCache(s.userCache)
+	if err := s.tokenRepo.RevokeAllUserRefreshTokens(user.ID); err != nil {
+		return true, err
+	}
+
+	data := &events.UserInactivityData{
+		UserID:       user.ID,
+		Email:        user.Email,
+		Name:         user.Name,
+		LastActiveAt: user.LastActiveAt(),
+	}
+	if err := s.record(domain.AuditUserSuspended, user, data); err != nil {
+		return true, err
+	}
+	if s.events != nil {
+		s.events.Publish(events.UserSuspendedInactive, data)
+	}
+	return true, nil
+}
+
+// anonymize erases the personal data of the user, keeping the account row
+// so that audit logs still reference it
+func (s *inactivityServiceImpl) anonymize(user *domain.User, now time.Time) (bool, error) {
+	password, err := utils.GenerateRandomPassword()
+	if err != nil {
+		return false, domain.ErrFailedToHashPassword
+	}
+	hashedPassword, err := utils.HashPassword(password)
+	if err != nil {
+		return false, domain.ErrFailedToHashPassword
+	}
+
+	data := &events.UserInactivityData{
+		UserID:       user.ID,
+		LastActiveAt: user.LastActiveAt(),
+	}
+	detail, err := json.Marshal(inactivityDetail(data))
+	if err != nil {
+		return false, err
+	}
+	err = s.userRepo.Anonymize(&domain.UserAnonymization{
+		UserID:                   user.ID,
+		Name:                     "Anonymized user",
+		Email:                    fmt.Sprintf("anonymized-%d@invalid", user.ID),
+		Password:                 hashedPassword,
+		AccessTokensRevokedUntil: now.Add(max(s.policy.AccessTokenTTL, TwoFactorTokenTTL)),
+		Audit: &domain.AuditLog{
+			Action:         domain.AuditUserAnonymized,
+			UserID:         &user.ID,
+			OrganizationID: user.OrganizationID,
+			Source:         inactivityAuditSource,
+			Detail:         string(detail),
+		},
+	})
+	if err != nil {
+		return false, err
+	}
+	invalidateUserCache(s.userCache)
+
+	if s.events != nil {
+		s.events.Publish(events.UserAnonymized, data)
+	}
+	return true, nil
+}
+
+// record appends an audit log for a stage applied to user
+func (s *inactivityServiceImpl) record(action string, user *domain.User, data *events.UserInactivityData) error {
+	return s.auditService.Record(&domain.AuditLog{
+		Action:         action,
+		UserID:         &user.ID,
+		OrganizationID: user.OrganizationID,
+		Source:         inactivityAuditSource,
+	}, inactivityDetail(data))
+}
+
+// inactivityDetail is the audit log detail of an applied stage, without personal data
+func inactivityDetail(data *events.UserInactivityData) map[string]interface{} {
+	detail := map[string]interface{}{"last_active_at": data.LastActiveAt}
+	if data.SuspendAt != nil {
+		detail["suspend_at"] = data.SuspendAt
+	}
+	return detail
+}
+
+// SetExempt opts a user out of (or back into) the inactivity policy
+func (s *inactivityServiceImpl) SetExempt(actorID, id uint, exempt bool) (*domain.User, error) {
+	user, err := s.userRepo.FindByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if user.InactivityExempt == exempt {
+		return user, nil
+	}
+
+	user.InactivityExempt = exempt
+	if err := s.userRepo.Update(user); err != nil {
+		return nil, domain.ErrFailedToUpdateUser
+	}
+	invalidateUserCache(s.userCache)
+
+	err = s.auditService.Record(&domain.AuditLog{
+		Action:         domain.AuditUserInactivityExempt,
+		ActorID:        &actorID,
+		UserID:         &user.ID,
+		OrganizationID: user.OrganizationID,
+	}, map[string]bool{"exempt": exempt})
+	if err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+// timePtr returns a pointer to t
+func timePtr(t time.Time) *time.Time {
+	return &t
+}
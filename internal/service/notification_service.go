@@ -0,0 +1,257 @@
+package service
+
+import (
+	"fmt"
+	"gojwt-rest-api/internal/domain"
+	"gojwt-rest-api/internal/events"
+	"gojwt-rest-api/internal/mailer"
+	"net/url"
+	"time"
+)
+
+// NotificationService emails users about account events that need their attention
+type NotificationService struct {
+	mailer mailer.Mailer
+	// invitationURL is the frontend page accepting invitations, the token is appended as a query parameter
+	invitationURL string
+	// emailRevertURL is the frontend page reverting email changes, the token is appended as a query parameter
+	emailRevertURL string
+	// unlockURL is the frontend page unlocking accounts, the token is appended as a query parameter
+	unlockURL string
+	// resetURL is the frontend page resetting passwords, the token is appended as a query parameter
+	resetURL string
+}
+
+// NotificationOption configures optional behaviour of the notification service
+type NotificationOption func(*NotificationService)
+
+// WithEmailRevertURL links email change notices to the frontend page reverting the change
+func WithEmailRevertURL(url string) NotificationOption {
+	return func(s *NotificationService) {
+		s.emailRevertURL = url
+	}
+}
+
+// WithAccountUnlockURL links account locked notices to the frontend page unlocking the account
+func WithAccountUnlockURL(url string) NotificationOption {
+	return func(s *NotificationService) {
+		s.unlockURL = url
+	}
+}
+
+// WithPasswordResetURL links password reset emails to the frontend page resetting the password
+func WithPasswordResetURL(url string) NotificationOption {
+	return func(s *NotificationService) {
+		s.resetURL = url
+	}
+}
+
+// NewNotificationService creates a new notification service
+func NewNotificationService(mailer mailer.Mailer, invitationURL string, opts ...NotificationOption) *NotificationService {
+	s := &NotificationService{
+		mailer:        mailer,
+		invitationURL: invitationURL,
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// SendAPIKeyRotationReminder emails the key owner for an APIKeyRotationDue event
+func (s *NotificationService) SendAPIKeyRotationReminder(event events.Event) error {
+	data, ok := event.Data.(*events.APIKeyRotationDueData)
+	if !ok {
+		return fmt.Errorf("unexpected payload for %s event", event.Type)
+	}
+
+	body := fmt.Sprintf(
+		"Your API key %q (%s...) was created on %s and is due for rotation.\n\nPlease create a new key and revoke the old one.\n",
+		data.Name, data.Prefix, data.CreatedAt.Format("2006-01-02"),
+	)
+	if data.ExpiresAt != nil {
+		body += fmt.Sprintf("\nThe key will stop working on %s.\n", data.ExpiresAt.Format(time.RFC1123))
+	}
+
+	return s.mailer.Send(&mailer.Message{
+		To:      data.Email,
+		Subject: "Your API key is due for rotation",
+		Body:    body,
+	})
+}
+
+// SendOrganizationInvitation emails the invitee for an OrganizationInvitationCreated event
+func (s *NotificationService) SendOrganizationInvitation(event events.Event) error {
+	data, ok := event.Data.(*events.OrganizationInvitationData)
+	if !ok {
+		return fmt.Errorf("unexpected payload for %s event", event.Type)
+	}
+
+	body := fmt.Sprintf("You have been invited to join %s as %s.\n\n", data.OrganizationName, data.Role)
+	if s.invitationURL != "" {
+		body += fmt.Sprintf("Accept the invitation: %s?token=%s\n", s.invitationURL, url.QueryEscape(data.Token))
+	} else {
+		body += fmt.Sprintf("Sign in and accept the invitation with this token:\n\n%s\n", data.Token)
+	}
+	body += fmt.Sprintf("\nThe invitation expires on %s.\n", data.ExpiresAt.Format(time.RFC1123))
+
+	return s.mailer.Send(&mailer.Message{
+		To:      data.Email,
+		Subject: fmt.Sprintf("You're invited to join %s", data.OrganizationName),
+		Body:    body,
+	})
+}
+
+// SendOrganizationMemberRemoved emails the user for an OrganizationMemberRemoved event
+func (s *NotificationService) SendOrganizationMemberRemoved(event events.Event) error {
+	data, ok := event.Data.(*events.OrganizationMembershipData)
+	if !ok {
+		return fmt.Errorf("unexpected payload for %s event", event.Type)
+	}
+
+	return s.mailer.Send(&mailer.Message{
+		To:      data.Email,
+		Subject: fmt.Sprintf("You have been removed from %s", data.OrganizationName),
+		Body:    fmt.Sprintf("You are no longer a member of %s.\n", data.OrganizationName),
+	})
+}
+
+// SendOrganizationOwnershipTransferred emails the new owner for an OrganizationOwnershipTransferred event
+func (s *NotificationService) SendOrganizationOwnershipTransferred(event events.Event) error {
+	data, ok := event.Data.(*events.OrganizationMembershipData)
+	if !ok {
+		return fmt.Errorf("unexpected payload for %s event", event.Type)
+	}
+
+	return s.mailer.Send(&mailer.Message{
+		To:      data.Email,
+		Subject: fmt.Sprintf("You are now the owner of %s", data.OrganizationName),
+		Body:    fmt.Sprintf("Ownership of %s has been transferred to you.\n", data.OrganizationName),
+	})
+}
+
+// SendInactivityWarning emails the user for a UserInactivityWarning event
+func (s *NotificationService) SendInactivityWarning(event events.Event) error {
+	data, ok := event.Data.(*events.UserInactivityData)
+	if !ok {
+		return fmt.Errorf("unexpected payload for %s event", event.Type)
+	}
+
+	body := fmt.Sprintf("Hi %s,\n\nYou have not signed in since %s.\n", data.Name, data.LastActiveAt.Format("2006-01-02"))
+	if data.SuspendAt != nil {
+		body += fmt.Sprintf("\nYour account will be suspended on %s unless you sign in before then.\n", data.SuspendAt.Format(time.RFC1123))
+	} else {
+		body += "\nSign in to keep your account active.\n"
+	}
+
+	return s.mailer.Send(&mailer.Message{
+		To:      data.Email,
+		Subject: "Your account is inactive",
+		Body:    body,
+	})
+}
+
+// SendEmailChangeNotice emails the previous address for a UserEmailChanged
+// event, with a link reverting the change when it can still be reverted
+func (s *NotificationService) SendEmailChangeNotice(event events.Event) error {
+	data, ok := event.Data.(*events.UserEmailChangedData)
+	if !ok {
+		return fmt.Errorf("unexpected payload for %s event", event.Type)
+	}
+
+	body := fmt.Sprintf("Hi %s,\n\nThe email address of your account was changed to %s on %s.\n",
+		data.Name, data.NewEmail, data.ChangedAt.Format(time.RFC1123))
+	if data.RevertToken != "" {
+		body += "\nIf this wasn't you, revert the change and sign out all sessions:\n\n"
+		if s.emailRevertURL != "" {
+			body += fmt.Sprintf("%s?token=%s\n", s.emailRevertURL, url.QueryEscape(data.RevertToken))
+		} else {
+			body += fmt.Sprintf("POST /api/v1/auth/email-change/revert with this token:\n\n%s\n", data.RevertToken)
+		}
+		body += fmt.Sprintf("\nThe link expires on %s.\n", data.RevertExpiresAt.Format(time.RFC1123))
+	}
+
+	return s.mailer.Send(&mailer.Message{
+		To:      data.PreviousEmail,
+		Subject: "The email address of your account was changed",
+		Body:    body,
+	})
+}
+
+// lockReasonDescriptions explain the risk signals in account locked notices
+var lockReasonDescriptions = map[string]string{
+	domain.LockReasonTokenReuse:    "a session token of your account was used again after it had been replaced, which happens when it was copied",
+	domain.LockReasonManyCountries: "your account signed in from several countries within a short time",
+}
+
+// SendAccountLockedNotice emails the unlock link of UserLocked and
+// UserUnlockRequested events to the user
+func (s *NotificationService) SendAccountLockedNotice(event events.Event) error {
+	data, ok := event.Data.(*events.UserLockedData)
+	if !ok {
+		return fmt.Errorf("unexpected payload for %s event", event.Type)
+	}
+
+	reason, ok := lockReasonDescriptions[data.Reason]
+	if !ok {
+		reason = "we detected suspicious activity on your account"
+	}
+	body := fmt.Sprintf("Hi %s,\n\nYour account was locked on %s because %s. All sessions have been signed out.\n",
+		data.Name, data.LockedAt.Format(time.RFC1123), reason)
+	body += "\nTo unlock it, verify this email address and choose a new password:\n\n"
+	if s.unlockURL != "" {
+		body += fmt.Sprintf("%s?token=%s\n", s.unlockURL, url.QueryEscape(data.UnlockToken))
+	} else {
+		body += fmt.Sprintf("POST /api/v1/auth/unlock with this token and a new password:\n\n%s\n", data.UnlockToken)
+	}
+	body += fmt.Sprintf("\nThe link expires on %s. You can request a new one from the login page.\n", data.UnlockExpiresAt.Format(time.RFC1123))
+
+	return s.mailer.Send(&mailer.Message{
+		To:      data.Email,
+		Subject: "Your account was locked",
+		Body:    body,
+	})
+}
+
+// SendPasswordResetLink emails the reset link of a UserPasswordResetRequested event to the user
+func (s *NotificationService) SendPasswordResetLink(event events.Event) error {
+	data, ok := event.Data.(*events.UserPasswordResetRequestedData)
+	if !ok {
+		return fmt.Errorf("unexpected payload for %s event", event.Type)
+	}
+
+	body := fmt.Sprintf("Hi %s,\n\nSomeone asked to reset the password of your account on %s.\n", data.Name, data.RequestedAt.Format(time.RFC1123))
+	body += "\nTo choose a new password, use this link:\n\n"
+	if s.resetURL != "" {
+		body += fmt.Sprintf("%s?token=%s\n", s.resetURL, url.QueryEscape(data.ResetToken))
+	} else {
+		body += fmt.Sprintf("POST /api/v1/auth/password/reset with this token and a new password:\n\n%s\n", data.ResetToken)
+	}
+	body += fmt.Sprintf("\nThe link expires on %s. If it wasn't you, you can ignore this email; your password was not changed.\n",
+		data.ExpiresAt.Format(time.RFC1123))
+
+	return s.mailer.Send(&mailer.Message{
+		To:      data.Email,
+		Subject: "Reset your password",
+		Body:    body,
+	})
+}
+
+// SendRegistrationAttemptNotice tells the owner of a registered email that
+// someone tried to register with it, for a UserRegistrationAttempted event.
+// Registrants are not told the email is taken when registration is concealed.
+func (s *NotificationService) SendRegistrationAttemptNotice(event events.Event) error {
+	data, ok := event.Data.(*events.UserRegistrationAttemptedData)
+	if !ok {
+		return fmt.Errorf("unexpected payload for %s event", event.Type)
+	}
+
+	return s.mailer.Send(&mailer.Message{
+		To:      data.Email,
+		Subject: "Someone tried to sign up with your email",
+		Body: fmt.Sprintf("Hi %s,\n\nOn %s someone tried to create an account with this email address, "+
+			"but you already have one. If it was you, log in instead, or reset your password if you forgot it.\n\n"+
+			"If it wasn't you, you can ignore this email; your account was not changed.\n",
+			data.Name, data.AttemptedAt.Format(time.RFC1123)),
+	})
+}
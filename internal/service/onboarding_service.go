@@ -0,0 +1,71 @@
+package service
+
+import (
+	"fmt"
+	"gojwt-rest-api/internal/domain"
+	"gojwt-rest-api/internal/events"
+	"gojwt-rest-api/internal/mailer"
+	"gojwt-rest-api/internal/repository"
+	"time"
+)
+
+// OnboardingTracker records the onboarding steps users complete. Services
+// completing or undoing a step report it here.
+type OnboardingTracker interface {
+	// CompleteStep records that the user completed step, keeping the time of
+	// an earlier completion
+	CompleteStep(userID uint, step string) error
+	// ResetStep marks a step of the user not completed, e.g. email_verified
+	// after an email change
+	ResetStep(userID uint, step string) error
+	// Status summarizes the onboarding of the user
+	Status(userID uint) (*domain.OnboardingStatusResponse, error)
+}
+
+// OnboardingService tracks the onboarding steps of users and reacts to
+// onboarding events such as a user's first login
+type OnboardingService struct {
+	stepRepo repository.OnboardingRepository
+	mailer   mailer.Mailer
+}
+
+// NewOnboardingService creates a new onboarding service
+func NewOnboardingService(stepRepo repository.OnboardingRepository, mailer mailer.Mailer) *OnboardingService {
+	return &OnboardingService{
+		stepRepo: stepRepo,
+		mailer:   mailer,
+	}
+}
+
+// CompleteStep records that the user completed step
+func (s *OnboardingService) CompleteStep(userID uint, step string) error {
+	return s.stepRepo.CompleteStep(userID, step, time.Now())
+}
+
+// ResetStep marks a step of the user not completed
+func (s *OnboardingService) ResetStep(userID uint, step string) error {
+	return s.stepRepo.ResetStep(userID, step)
+}
+
+// Status summarizes the completed steps of the user
+func (s *OnboardingService) Status(userID uint) (*domain.OnboardingStatusResponse, error) {
+	steps, err := s.stepRepo.FindCompletedSteps(userID)
+	if err != nil {
+		return nil, err
+	}
+	return domain.NewOnboardingStatusResponse(steps), nil
+}
+
+// SendWelcomeEmail sends the welcome email for a UserFirstLogin event
+func (s *OnboardingService) SendWelcomeEmail(event events.Event) error {
+	data, ok := event.Data.(*events.UserFirstLoginData)
+	if !ok {
+		return fmt.Errorf("unexpected payload for %s event", event.Type)
+	}
+
+	return s.mailer.Send(&mailer.Message{
+		To:      data.Email,
+		Subject: "Welcome!",
+		Body:    fmt.Sprintf("Hi %s,\n\nWelcome aboard! Your account is ready to use.\n", data.Name),
+	})
+}
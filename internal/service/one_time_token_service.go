@@ -0,0 +1,108 @@
+package service
+
+import (
+	"gojwt-rest-api/internal/domain"
+	"gojwt-rest-api/internal/repository"
+	"gojwt-rest-api/internal/utils"
+	"time"
+)
+
+// OneTimeTokenService issues and consumes single-use tokens sent to users,
+// for flows such as email verification, password reset or magic links. Each
+// flow uses its own purpose and keeps its data in the token's payload.
+type OneTimeTokenService interface {
+	// Issue creates a token of the purpose for subject, invalidating the
+	// unconsumed tokens previously issued to subject for the same purpose. It
+	// returns the token to send to the user; only its hash is stored.
+	Issue(purpose, subject string, payload interface{}, ttl time.Duration) (string, *domain.OneTimeToken, error)
+	// Consume accepts a token of the purpose once, decoding its payload into
+	// payload when not nil. Unknown, expired and used tokens return
+	// domain.ErrOneTimeTokenInvalid.
+	Consume(purpose, token string, payload interface{}) (*domain.OneTimeToken, error)
+	// Lookup decodes the payload of a usable token without consuming it, so a
+	// flow can validate its input before spending the token
+	Lookup(purpose, token string, payload interface{}) (*domain.OneTimeToken, error)
+	// Active returns the tokens of the purpose issued to subject that can still be consumed
+	Active(purpose, subject string) ([]*domain.OneTimeToken, error)
+}
+
+// oneTimeTokenServiceImpl is the implementation of OneTimeTokenService
+type oneTimeTokenServiceImpl struct {
+	tokenRepo repository.OneTimeTokenRepository
+}
+
+// NewOneTimeTokenService creates a new one-time token service
+func NewOneTimeTokenService(tokenRepo repository.OneTimeTokenRepository) OneTimeTokenService {
+	return &oneTimeTokenServiceImpl{tokenRepo: tokenRepo}
+}
+
+// Issue creates a token of the purpose for subject
+func (s *oneTimeTokenServiceImpl) Issue(purpose, subject string, payload interface{}, ttl time.Duration) (string, *domain.OneTimeToken, error) {
+	token, err := utils.GenerateOpaqueToken()
+	if err != nil {
+		return "", nil, err
+	}
+
+	now := time.Now()
+	record := &domain.OneTimeToken{
+		Purpose:   purpose,
+		Subject:   subject,
+		TokenHash: utils.HashToken(token),
+		ExpiresAt: now.Add(ttl),
+	}
+	if err := record.SetPayload(payload); err != nil {
+		return "", nil, err
+	}
+
+	if err := s.tokenRepo.InvalidateSubject(purpose, subject, now); err != nil {
+		return "", nil, err
+	}
+	if err := s.tokenRepo.Create(record); err != nil {
+		return "", nil, err
+	}
+	return token, record, nil
+}
+
+// Consume accepts a token of the purpose once
+func (s *oneTimeTokenServiceImpl) Consume(purpose, token string, payload interface{}) (*domain.OneTimeToken, error) {
+	record, err := s.tokenRepo.Consume(purpose, utils.HashToken(token), time.Now())
+	if err != nil {
+		if err == domain.ErrOneTimeTokenNotFound {
+			return nil, domain.ErrOneTimeTokenInvalid
+		}
+		return nil, err
+	}
+
+	if payload != nil {
+		if err := record.DecodePayload(payload); err != nil {
+			return nil, err
+		}
+	}
+	return record, nil
+}
+
+// Lookup decodes the payload of a usable token without consuming it
+func (s *oneTimeTokenServiceImpl) Lookup(purpose, token string, payload interface{}) (*domain.OneTimeToken, error) {
+	record, err := s.tokenRepo.FindByTokenHash(purpose, utils.HashToken(token))
+	if err != nil {
+		if err == domain.ErrOneTimeTokenNotFound {
+			return nil, domain.ErrOneTimeTokenInvalid
+		}
+		return nil, err
+	}
+	if !record.IsUsable(time.Now()) {
+		return nil, domain.ErrOneTimeTokenInvalid
+	}
+
+	if payload != nil {
+		if err := record.DecodePayload(payload); err != nil {
+			return nil, err
+		}
+	}
+	return record, nil
+}
+
+// Active returns the tokens of the purpose issued to subject that can still be consumed
+func (s *oneTimeTokenServiceImpl) Active(purpose, subject string) ([]*domain.OneTimeToken, error) {
+	return s.tokenRepo.FindActiveBySubject(purpose, subject, time.Now())
+}
@@ -0,0 +1,536 @@
+package service
+
+import (
+	"gojwt-rest-api/internal/domain"
+	"gojwt-rest-api/internal/events"
+	"gojwt-rest-api/internal/repository"
+	"gojwt-rest-api/internal/utils"
+	"strings"
+	"time"
+)
+
+// OrganizationService defines the interface for organization and plan management
+type OrganizationService interface {
+	CreatePlan(req *domain.CreatePlanRequest) (*domain.Plan, error)
+	ListPlans() ([]*domain.Plan, error)
+	CreateOrganization(req *domain.CreateOrganizationRequest) (*domain.Organization, error)
+	GetOrganization(id uint) (*domain.Organization, error)
+	ListOrganizations() ([]*domain.Organization, error)
+	AddMember(orgID uint, userID uint, role string) error
+
+	// Membership methods, authorized by the acting user's organization role
+	ListMembers(actorID uint, orgID uint) ([]*domain.User, error)
+	RemoveMember(actorID uint, orgID uint, userID uint) error
+	TransferOwnership(actorID uint, orgID uint, newOwnerID uint) error
+	InviteMember(actorID uint, orgID uint, req *domain.InviteMemberRequest) (*domain.OrganizationInvitation, error)
+	ListInvitations(actorID uint, orgID uint) ([]*domain.OrganizationInvitation, error)
+	AcceptInvitation(userID uint, token string) (*domain.Organization, error)
+	VerifyInvitation(token, email string) error
+	// RevokeSessions logs out every member of an organization, including the actor
+	RevokeSessions(actorID uint, orgID uint) (*domain.OrganizationSessionRevocation, error)
+}
+
+// DefaultInvitationTTL is how long invitations can be accepted unless configured otherwise
+const DefaultInvitationTTL = 7 * 24 * time.Hour
+
+// organizationServiceImpl is the implementation of OrganizationService
+type organizationServiceImpl struct {
+	orgRepo       repository.OrganizationRepository
+	userRepo      repository.UserRepository
+	quota         QuotaService
+	events        events.Publisher
+	settings      SettingsService
+	audit         AuditService
+	onboarding    OnboardingTracker
+	invitationTTL time.Duration
+	userCache     UserCache
+}
+
+// OrganizationServiceOption configures optional behaviour of the organization service
+type OrganizationServiceOption func(*organizationServiceImpl)
+
+// WithOrganizationEventPublisher publishes invitation and membership events to publisher
+func WithOrganizationEventPublisher(publisher events.Publisher) OrganizationServiceOption {
+	return func(s *organizationServiceImpl) {
+		s.events = publisher
+	}
+}
+
+// WithInvitationTTL sets how long invitations can be accepted
+func WithInvitationTTL(ttl time.Duration) OrganizationServiceOption {
+	return func(s *organizationServiceImpl) {
+		s.invitationTTL = ttl
+	}
+}
+
+// WithOrganizationSettings restricts members to the organization's allowed email domains
+func WithOrganizationSettings(settings SettingsService) OrganizationServiceOption {
+	return func(s *organizationServiceImpl) {
+		s.settings = settings
+	}
+}
+
+// WithOrganizationAuditService records organization wide security actions, such as forced logouts
+func WithOrganizationAuditService(audit AuditService) OrganizationServiceOption {
+	return func(s *organizationServiceImpl) {
+		s.audit = audit
+	}
+}
+
+// WithOrganizationOnboardingTracker records accepted invitations as a verified
+// email, since the invitation token was sent to it
+func WithOrganizationOnboardingTracker(onboarding OnboardingTracker) OrganizationServiceOption {
+	return func(s *organizationServiceImpl) {
+		s.onboarding = onboarding
+	}
+}
+
+// WithOrganizationUserCache invalidates cache when users change
+func WithOrganizationUserCache(cache UserCache) OrganizationServiceOption {
+	return func(s *organizationServiceImpl) {
+		s.userCache = cache
+	}
+}
+
+// NewOrganizationService creates a new organization service
+func NewOrganizationService(orgRepo repository.OrganizationRepository, userRepo repository.UserRepository, quota QuotaService, opts ...OrganizationServiceOption) OrganizationService {
+	s := &organizationServiceImpl{
+		orgRepo:       orgRepo,
+		userRepo:      userRepo,
+		quota:         quota,
+		invitationTTL: DefaultInvitationTTL,
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// CreatePlan creates a new plan
+func (s *organizationServiceImpl) CreatePlan(req *domain.CreatePlanRequest) (*domain.Plan, error) {
+	if _, err := s.orgRepo.FindPlanByName(req.Name); err == nil {
+		return nil, domain.ErrPlanAlreadyExists
+	} else if err != domain.ErrPlanNotFound {
+		return nil, err
+	}
+
+	plan := &domain.Plan{
+		Name:              req.Name,
+		RequestsPerMinute: req.RequestsPerMinute,
+		MaxActiveUsers:    req.MaxActiveUsers,
+		MaxSessions:       req.MaxSessions,
+		Entitlements:      strings.Join(req.Entitlements, ","),
+	}
+	if err := s.orgRepo.CreatePlan(plan); err != nil {
+		return nil, err
+	}
+	return plan, nil
+}
+
+// ListPlans lists all plans
+func (s *organizationServiceImpl) ListPlans() ([]*domain.Plan, error) {
+	return s.orgRepo.FindPlans()
+}
+
+// CreateOrganization creates a new organization on the given plan
+func (s *organizationServiceImpl) CreateOrganization(req *domain.CreateOrganizationRequest) (*domain.Organization, error) {
+	if _, err := s.orgRepo.FindByName(req.Name); err == nil {
+		return nil, domain.ErrOrganizationAlreadyExists
+	} else if err != domain.ErrOrganizationNotFound {
+		return nil, err
+	}
+
+	plan, err := s.orgRepo.FindPlanByID(req.PlanID)
+	if err != nil {
+		return nil, err
+	}
+
+	org := &domain.Organization{
+		Name:   req.Name,
+		PlanID: plan.ID,
+		Plan:   *plan,
+	}
+	if err := s.orgRepo.Create(org); err != nil {
+		return nil, err
+	}
+	return org, nil
+}
+
+// GetOrganization gets an organization by ID
+func (s *organizationServiceImpl) GetOrganization(id uint) (*domain.Organization, error) {
+	return s.orgRepo.FindByID(id)
+}
+
+// ListOrganizations lists all organizations
+func (s *organizationServiceImpl) ListOrganizations() ([]*domain.Organization, error) {
+	return s.orgRepo.FindAll()
+}
+
+// AddMember adds a user to an organization within the plan's active user limit.
+// Adding an owner transfers the ownership to the user.
+func (s *organizationServiceImpl) AddMember(orgID uint, userID uint, role string) error {
+	if _, err := s.orgRepo.FindByID(orgID); err != nil {
+		return err
+	}
+
+	user, err := s.userRepo.FindByID(userID)
+	if err != nil {
+		return err
+	}
+	if role == "" {
+		role = domain.OrgRoleMember
+	}
+
+	switch {
+	case !user.IsOrganizationMember(orgID):
+		if err := s.checkEmailAllowed(orgID, user.Email); err != nil {
+			return err
+		}
+		if err := s.quota.CheckUserQuota(orgID); err != nil {
+			return err
+		}
+		// New owners join as admin and take over the ownership below
+		initialRole := role
+		if role == domain.OrgRoleOwner {
+			initialRole = domain.OrgRoleAdmin
+		}
+		if err := s.orgRepo.AddMember(orgID, userID, initialRole); err != nil {
+			return err
+		}
+	case user.OrganizationRole == role:
+		return nil
+	case user.OrganizationRole == domain.OrgRoleOwner:
+		return domain.ErrCannotRemoveOwner
+	case role != domain.OrgRoleOwner:
+		if err := s.orgRepo.AddMember(orgID, userID, role); err != nil {
+			return err
+		}
+	}
+
+	if role == domain.OrgRoleOwner {
+		if err := s.orgRepo.TransferOwnership(orgID, userID); err != nil {
+			return err
+		}
+	}
+	invalidateUserCache(s.userCache)
+	return nil
+}
+
+// ListMembers lists the members of an organization to its members
+func (s *organizationServiceImpl) ListMembers(actorID uint, orgID uint) ([]*domain.User, error) {
+	actor, err := s.userRepo.FindByID(actorID)
+	if err != nil {
+		return nil, err
+	}
+	if !actor.IsAdmin && !actor.IsOrganizationMember(orgID) {
+		return nil, domain.ErrOrganizationForbidden
+	}
+
+	if _, err := s.orgRepo.FindByID(orgID); err != nil {
+		return nil, err
+	}
+	return s.orgRepo.FindMembers(orgID)
+}
+
+// RemoveMember removes a member from an organization. Owners and admins may remove
+// members, only owners may remove admins, and members may leave on their own.
+// The owner cannot be removed before transferring the ownership.
+func (s *organizationServiceImpl) RemoveMember(actorID uint, orgID uint, userID uint) error {
+	actor, err := s.userRepo.FindByID(actorID)
+	if err != nil {
+		return err
+	}
+	org, err := s.orgRepo.FindByID(orgID)
+	if err != nil {
+		return err
+	}
+	member, err := s.userRepo.FindByID(userID)
+	if err != nil {
+		return err
+	}
+	if !member.IsOrganizationMember(orgID) {
+		return domain.ErrNotOrganizationMember
+	}
+
+	if member.OrganizationRole == domain.OrgRoleOwner {
+		return domain.ErrCannotRemoveOwner
+	}
+	leaving := actorID == userID
+	if !leaving {
+		if !actor.CanManageOrganization(orgID) {
+			return domain.ErrOrganizationForbidden
+		}
+		if member.OrganizationRole == domain.OrgRoleAdmin && !actor.IsAdmin && actor.OrganizationRole != domain.OrgRoleOwner {
+			return domain.ErrOrganizationForbidden
+		}
+	}
+
+	if err := s.orgRepo.RemoveMember(orgID, userID); err != nil {
+		return err
+	}
+	invalidateUserCache(s.userCache)
+	if member.IsActive() {
+		s.quota.ReleaseUserQuota(orgID)
+	}
+
+	s.publish(events.OrganizationMemberRemoved, &events.OrganizationMembershipData{
+		OrganizationID:   org.ID,
+		OrganizationName: org.Name,
+		UserID:           member.ID,
+		Email:            member.Email,
+		ActorID:          actorID,
+	})
+	return nil
+}
+
+// TransferOwnership makes another member the owner of an organization, demoting
+// the current owner to admin. Only the owner or a global admin may transfer it.
+func (s *organizationServiceImpl) TransferOwnership(actorID uint, orgID uint, newOwnerID uint) error {
+	actor, err := s.userRepo.FindByID(actorID)
+	if err != nil {
+		return err
+	}
+	if !actor.IsAdmin && !(actor.IsOrganizationMember(orgID) && actor.OrganizationRole == domain.OrgRoleOwner) {
+		return domain.ErrOrganizationForbidden
+	}
+
+	org, err := s.orgRepo.FindByID(orgID)
+	if err != nil {
+		return err
+	}
+	newOwner, err := s.userRepo.FindByID(newOwnerID)
+	if err != nil {
+		return err
+	}
+	if !newOwner.IsOrganizationMember(orgID) {
+		return domain.ErrNotOrganizationMember
+	}
+	if newOwner.OrganizationRole == domain.OrgRoleOwner {
+		return nil
+	}
+
+	if err := s.orgRepo.TransferOwnership(orgID, newOwnerID); err != nil {
+		return err
+	}
+	invalidateUserCache(s.userCache)
+
+	s.publish(events.OrganizationOwnershipTransferred, &events.OrganizationMembershipData{
+		OrganizationID:   org.ID,
+		OrganizationName: org.Name,
+		UserID:           newOwner.ID,
+		Email:            newOwner.Email,
+		ActorID:          actorID,
+	})
+	return nil
+}
+
+// InviteMember invites an email address to an organization. Inviting an address
+// with a pending invitation renews it, invalidating the previous token.
+func (s *organizationServiceImpl) InviteMember(actorID uint, orgID uint, req *domain.InviteMemberRequest) (*domain.OrganizationInvitation, error) {
+	actor, err := s.userRepo.FindByID(actorID)
+	if err != nil {
+		return nil, err
+	}
+	if !actor.CanManageOrganization(orgID) {
+		return nil, domain.ErrOrganizationForbidden
+	}
+
+	org, err := s.orgRepo.FindByID(orgID)
+	if err != nil {
+		return nil, err
+	}
+
+	email := strings.ToLower(req.Email)
+	if err := s.checkEmailAllowed(orgID, email); err != nil {
+		return nil, err
+	}
+	if existing, err := s.userRepo.FindByEmail(email); err == nil && existing.IsOrganizationMember(orgID) {
+		return nil, domain.ErrAlreadyOrganizationMember
+	}
+
+	role := req.Role
+	if role == "" {
+		role = domain.OrgRoleMember
+	}
+
+	token, err := utils.GenerateOpaqueToken()
+	if err != nil {
+		return nil, err
+	}
+
+	invitation, err := s.orgRepo.FindPendingInvitation(orgID, email)
+	switch err {
+	case nil:
+		invitation.Role = role
+		invitation.TokenHash = utils.HashToken(token)
+		invitation.InvitedByID = actorID
+		invitation.ExpiresAt = time.Now().Add(s.invitationTTL)
+		err = s.orgRepo.UpdateInvitation(invitation)
+	case domain.ErrInvitationNotFound:
+		invitation = &domain.OrganizationInvitation{
+			OrganizationID: orgID,
+			Email:          email,
+			Role:           role,
+			TokenHash:      utils.HashToken(token),
+			InvitedByID:    actorID,
+			ExpiresAt:      time.Now().Add(s.invitationTTL),
+		}
+		err = s.orgRepo.CreateInvitation(invitation)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	s.publish(events.OrganizationInvitationCreated, &events.OrganizationInvitationData{
+		InvitationID:     invitation.ID,
+		OrganizationID:   org.ID,
+		OrganizationName: org.Name,
+		Email:            invitation.Email,
+		Role:             invitation.Role,
+		InvitedByID:      actorID,
+		ExpiresAt:        invitation.ExpiresAt,
+		Token:            token,
+	})
+	return invitation, nil
+}
+
+// ListInvitations lists the pending invitations of an organization
+func (s *organizationServiceImpl) ListInvitations(actorID uint, orgID uint) ([]*domain.OrganizationInvitation, error) {
+	actor, err := s.userRepo.FindByID(actorID)
+	if err != nil {
+		return nil, err
+	}
+	if !actor.CanManageOrganization(orgID) {
+		return nil, domain.ErrOrganizationForbidden
+	}
+	return s.orgRepo.FindPendingInvitations(orgID, time.Now())
+}
+
+// VerifyInvitation checks that token is a pending invitation sent to email
+// and that the organization has a seat left, without accepting it, e.g. to
+// let the invited user register
+func (s *organizationServiceImpl) VerifyInvitation(token, email string) error {
+	invitation, err := s.orgRepo.FindInvitationByTokenHash(utils.HashToken(token))
+	if err != nil {
+		return err
+	}
+	if !invitation.IsPending(time.Now()) {
+		return domain.ErrInvitationExpired
+	}
+	if !strings.EqualFold(email, invitation.Email) {
+		return domain.ErrInvitationEmailMismatch
+	}
+	return s.quota.CheckUserQuotaAvailable(invitation.OrganizationID)
+}
+
+// AcceptInvitation adds the user to the organization of the invitation. The
+// invitation must have been sent to the user's email address.
+func (s *organizationServiceImpl) AcceptInvitation(userID uint, token string) (*domain.Organization, error) {
+	invitation, err := s.orgRepo.FindInvitationByTokenHash(utils.HashToken(token))
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	if !invitation.IsPending(now) {
+		return nil, domain.ErrInvitationExpired
+	}
+
+	user, err := s.userRepo.FindByID(userID)
+	if err != nil {
+		return nil, err
+	}
+	if !strings.EqualFold(user.Email, invitation.Email) {
+		return nil, domain.ErrInvitationEmailMismatch
+	}
+	if user.OrganizationID != nil {
+		return nil, domain.ErrAlreadyOrganizationMember
+	}
+	// The allowed domains may have changed since the invitation was sent
+	if err := s.checkEmailAllowed(invitation.OrganizationID, user.Email); err != nil {
+		return nil, err
+	}
+
+	if err := s.quota.CheckUserQuota(invitation.OrganizationID); err != nil {
+		return nil, err
+	}
+
+	invitation.AcceptedAt = &now
+	if err := s.orgRepo.AcceptInvitation(invitation, user.ID); err != nil {
+		return nil, err
+	}
+	invalidateUserCache(s.userCache)
+	if s.onboarding != nil {
+		if err := s.onboarding.CompleteStep(user.ID, domain.OnboardingStepEmailVerified); err != nil {
+			return nil, err
+		}
+	}
+	return &invitation.Organization, nil
+}
+
+// RevokeSessions logs out every member of an organization, e.g. after a
+// compromise of its identity provider. Owners and admins of the organization
+// and global admins may do so. Refresh tokens are revoked and access tokens
+// invalidated through the members' session epoch, so members have to log in again.
+func (s *organizationServiceImpl) RevokeSessions(actorID uint, orgID uint) (*domain.OrganizationSessionRevocation, error) {
+	actor, err := s.userRepo.FindByID(actorID)
+	if err != nil {
+		return nil, err
+	}
+	if !actor.CanManageOrganization(orgID) {
+		return nil, domain.ErrOrganizationForbidden
+	}
+	org, err := s.orgRepo.FindByID(orgID)
+	if err != nil {
+		return nil, err
+	}
+
+	revocation, err := s.orgRepo.RevokeSessions(orgID, time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	if s.audit != nil {
+		err := s.audit.Record(&domain.AuditLog{
+			Action:         domain.AuditOrgSessionsRevoked,
+			ActorID:        &actorID,
+			OrganizationID: &org.ID,
+		}, map[string]int64{
+			"members":                revocation.Members,
+			"refresh_tokens_revoked": revocation.RefreshTokens,
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	s.publish(events.OrganizationSessionsRevoked, &events.OrganizationSessionsRevokedData{
+		OrganizationID:   org.ID,
+		OrganizationName: org.Name,
+		ActorID:          actorID,
+		Members:          revocation.Members,
+		RevokedAt:        revocation.RevokedAt,
+	})
+	return revocation, nil
+}
+
+// checkEmailAllowed checks an email address against the organization's allowed domains
+func (s *organizationServiceImpl) checkEmailAllowed(orgID uint, email string) error {
+	if s.settings == nil {
+		return nil
+	}
+	settings, err := s.settings.ForOrganization(&orgID)
+	if err != nil {
+		return err
+	}
+	if !settings.AllowsEmail(email) {
+		return domain.ErrEmailDomainNotAllowed
+	}
+	return nil
+}
+
+// publish publishes an event when a publisher is configured
+func (s *organizationServiceImpl) publish(eventType string, data interface{}) {
+	if s.events != nil {
+		s.events.Publish(eventType, data)
+	}
+}
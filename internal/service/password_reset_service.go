@@ -0,0 +1,197 @@
+package service
+
+import (
+	"gojwt-rest-api/internal/domain"
+	"gojwt-rest-api/internal/events"
+	"gojwt-rest-api/internal/repository"
+	"gojwt-rest-api/internal/utils"
+	"strconv"
+	"time"
+)
+
+// PasswordResetService defines the interface for the self-service reset of
+// forgotten passwords
+type PasswordResetService interface {
+	// RequestReset emails a reset link to the active user registered with
+	// email. Unknown emails, inactive and locked users are ignored, without
+	// error; locked users unlock their account with a new password instead.
+	RequestReset(email string) error
+	// Reset replaces the password of the user a reset token was emailed to,
+	// ending their sessions
+	Reset(req *domain.ResetPasswordRequest) (*domain.User, error)
+}
+
+// PasswordResetServiceOption configures optional password reset service behavior
+type PasswordResetServiceOption func(*passwordResetServiceImpl)
+
+// WithPasswordResetEventPublisher publishes reset events, which deliver the
+// reset links, to publisher
+func WithPasswordResetEventPublisher(publisher events.Publisher) PasswordResetServiceOption {
+	return func(s *passwordResetServiceImpl) {
+		s.events = publisher
+	}
+}
+
+// WithPasswordResetSettingsService checks new passwords against the password
+// policy of the user's organization
+func WithPasswordResetSettingsService(settings SettingsService) PasswordResetServiceOption {
+	return func(s *passwordResetServiceImpl) {
+		s.settings = settings
+	}
+}
+
+// WithPasswordResetOnboardingTracker records resets as a verified email,
+// since the reset link was sent to it
+func WithPasswordResetOnboardingTracker(onboarding OnboardingTracker) PasswordResetServiceOption {
+	return func(s *passwordResetServiceImpl) {
+		s.onboarding = onboarding
+	}
+}
+
+// WithPasswordResetUserCache invalidates cache when users change
+func WithPasswordResetUserCache(cache UserCache) PasswordResetServiceOption {
+	return func(s *passwordResetServiceImpl) {
+		s.userCache = cache
+	}
+}
+
+// passwordResetServiceImpl is the implementation of PasswordResetService
+type passwordResetServiceImpl struct {
+	userRepo      repository.UserRepository
+	tokenRepo     repository.TokenRepository
+	oneTimeTokens OneTimeTokenService
+	auditService  AuditService
+	tokenTTL      time.Duration
+	events        events.Publisher
+	settings      SettingsService
+	onboarding    OnboardingTracker
+	userCache     UserCache
+}
+
+// NewPasswordResetService creates a new password reset service issuing reset
+// links valid for tokenTTL
+func NewPasswordResetService(
+	userRepo repository.UserRepository,
+	tokenRepo repository.TokenRepository,
+	oneTimeTokens OneTimeTokenService,
+	auditService AuditService,
+	tokenTTL time.Duration,
+	opts ...PasswordResetServiceOption,
+) PasswordResetService {
+	s := &passwordResetServiceImpl{
+		userRepo:      userRepo,
+		tokenRepo:     tokenRepo,
+		oneTimeTokens: oneTimeTokens,
+		auditService:  auditService,
+		tokenTTL:      tokenTTL,
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// RequestReset issues a reset token, replacing earlier ones, and publishes
+// it to be emailed to the user
+func (s *passwordResetServiceImpl) RequestReset(email string) error {
+	user, err := s.userRepo.FindByEmail(email)
+	if err != nil {
+		if err == domain.ErrUserNotFound {
+			return nil
+		}
+		return err
+	}
+	if !user.IsActive() || user.IsLocked() {
+		return nil
+	}
+
+	token, record, err := s.oneTimeTokens.Issue(
+		domain.OneTimeTokenPurposePasswordReset,
+		strconv.FormatUint(uint64(user.ID), 10),
+		&domain.PasswordReset{UserID: user.ID},
+		s.tokenTTL,
+	)
+	if err != nil {
+		return err
+	}
+
+	if s.events != nil {
+		s.events.Publish(events.UserPasswordResetRequested, &events.UserPasswordResetRequestedData{
+			UserID:      user.ID,
+			Name:        user.Name,
+			Email:       user.Email,
+			RequestedAt: time.Now(),
+			ExpiresAt:   record.ExpiresAt,
+			ResetToken:  token,
+		})
+	}
+	return nil
+}
+
+// Reset checks the new password before consuming the token, so that a
+// password rejected by the policy doesn't spend the link
+func (s *passwordResetServiceImpl) Reset(req *domain.ResetPasswordRequest) (*domain.User, error) {
+	var reset domain.PasswordReset
+	if _, err := s.oneTimeTokens.Lookup(domain.OneTimeTokenPurposePasswordReset, req.Token, &reset); err != nil {
+		return nil, err
+	}
+	user, err := s.userRepo.FindByID(reset.UserID)
+	if err != nil {
+		if err == domain.ErrUserNotFound {
+			return nil, domain.ErrOneTimeTokenInvalid
+		}
+		return nil, err
+	}
+	if !user.IsActive() || user.IsLocked() {
+		return nil, domain.ErrOneTimeTokenInvalid
+	}
+
+	if s.settings != nil {
+		settings, err := s.settings.ForOrganization(user.OrganizationID)
+		if err != nil {
+			return nil, err
+		}
+		if err := settings.PasswordPolicy.Check(req.NewPassword); err != nil {
+			return nil, err
+		}
+	}
+	hashedPassword, err := utils.HashPassword(req.NewPassword)
+	if err != nil {
+		return nil, domain.ErrFailedToHashPassword
+	}
+
+	if _, err := s.oneTimeTokens.Consume(domain.OneTimeTokenPurposePasswordReset, req.Token, nil); err != nil {
+		return nil, err
+	}
+
+	// Whoever knew the old password may still hold a session, end its
+	// access tokens along with the refresh tokens
+	user.Password = hashedPassword
+	user.SessionEpoch++
+	if err := s.userRepo.Update(user); err != nil {
+		return nil, domain.ErrFailedToUpdateUser
+	}
+	invalidateUserCache(s.userCache)
+	if err := s.tokenRepo.RevokeAllUserRefreshTokens(user.ID); err != nil {
+		return nil, err
+	}
+	if s.onboarding != nil {
+		if err := s.onboarding.CompleteStep(user.ID, domain.OnboardingStepEmailVerified); err != nil {
+			return nil, err
+		}
+	}
+
+	err = s.auditService.Record(&domain.AuditLog{
+		Action:         domain.AuditUserPasswordReset,
+		ActorID:        &user.ID,
+		UserID:         &user.ID,
+		OrganizationID: user.OrganizationID,
+	}, nil)
+	if err != nil {
+		return nil, err
+	}
+	if s.events != nil {
+		s.events.Publish(events.UserPasswordReset, &events.UserPasswordResetData{UserID: user.ID, ResetAt: time.Now()})
+	}
+	return user, nil
+}
@@ -0,0 +1,62 @@
+package service
+
+import (
+	"gojwt-rest-api/internal/domain"
+	"gojwt-rest-api/internal/repository"
+)
+
+// ProfileHistoryService defines the interface for recording and reading the
+// change history of user profiles
+type ProfileHistoryService interface {
+	// Record appends a version to the history of user if its profile changed
+	// from before. actorID is nil for changes made by the system, an identity
+	// provider or a revert link.
+	Record(user *domain.User, before domain.ProfileSnapshot, actorID *uint, source string) error
+	// List lists the profile changes of a user, newest first
+	List(userID uint, pagination *domain.PaginationQuery) ([]*domain.ProfileChange, int64, error)
+}
+
+// profileHistoryServiceImpl is the implementation of ProfileHistoryService
+type profileHistoryServiceImpl struct {
+	historyRepo repository.ProfileHistoryRepository
+}
+
+// NewProfileHistoryService creates a new profile history service
+func NewProfileHistoryService(historyRepo repository.ProfileHistoryRepository) ProfileHistoryService {
+	return &profileHistoryServiceImpl{historyRepo: historyRepo}
+}
+
+// Record stores the fields of user changed since before, if any
+func (s *profileHistoryServiceImpl) Record(user *domain.User, before domain.ProfileSnapshot, actorID *uint, source string) error {
+	fields := before.Diff(domain.SnapshotProfile(user))
+	if len(fields) == 0 {
+		return nil
+	}
+
+	change := &domain.ProfileChange{
+		UserID:  user.ID,
+		ActorID: actorID,
+		Source:  source,
+	}
+	if err := change.SetFields(fields); err != nil {
+		return err
+	}
+	return s.historyRepo.Create(change)
+}
+
+// List lists the profile changes of a user, newest first
+func (s *profileHistoryServiceImpl) List(userID uint, pagination *domain.PaginationQuery) ([]*domain.ProfileChange, int64, error) {
+	// Set default pagination values
+	if pagination.Page <= 0 {
+		pagination.Page = 1
+	}
+	if pagination.PageSize <= 0 {
+		pagination.PageSize = 10
+	}
+	if pagination.PageSize > 100 {
+		pagination.PageSize = 100 // Max page size
+	}
+
+	offset := (pagination.Page - 1) * pagination.PageSize
+	return s.historyRepo.FindByUser(userID, offset, pagination.PageSize)
+}
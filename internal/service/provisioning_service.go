@@ -0,0 +1,202 @@
+package service
+
+import (
+	"gojwt-rest-api/internal/domain"
+	"gojwt-rest-api/internal/repository"
+	"gojwt-rest-api/internal/utils"
+	"time"
+)
+
+// ProvisionedUser holds the attributes an identity provider manages for a user
+type ProvisionedUser struct {
+	Name   string
+	Email  string
+	Active bool
+}
+
+// ProvisioningService defines the interface for identity provider user provisioning (SCIM)
+type ProvisioningService interface {
+	ListUsers(filter *domain.UserFilter, offset, limit int) ([]*domain.User, int64, error)
+	GetUser(id uint) (*domain.User, error)
+	CreateUser(attrs *ProvisionedUser) (*domain.User, error)
+	ReplaceUser(id uint, attrs *ProvisionedUser) (*domain.User, error)
+	DeactivateUser(id uint) error
+}
+
+// provisioningServiceImpl is the implementation of ProvisioningService
+type provisioningServiceImpl struct {
+	userRepo  repository.UserRepository
+	tokenRepo repository.TokenRepository
+	history   ProfileHistoryService
+	apiKeys   repository.APIKeyRepository
+	quota     QuotaService
+	userCache UserCache
+}
+
+// ProvisioningServiceOption configures optional behaviour of the provisioning service
+type ProvisioningServiceOption func(*provisioningServiceImpl)
+
+// WithProvisioningProfileHistory records the name and email changes made by
+// the identity provider in the profile history of users
+func WithProvisioningProfileHistory(history ProfileHistoryService) ProvisioningServiceOption {
+	return func(s *provisioningServiceImpl) {
+		s.history = history
+	}
+}
+
+// WithProvisioningAPIKeys revokes the API keys of deprovisioned users, so
+// that they stay unusable when the user is provisioned again
+func WithProvisioningAPIKeys(apiKeyRepo repository.APIKeyRepository) ProvisioningServiceOption {
+	return func(s *provisioningServiceImpl) {
+		s.apiKeys = apiKeyRepo
+	}
+}
+
+// WithProvisioningQuotaService enforces the active user limit of the
+// organization of users being reactivated, and frees the seat of users
+// being deactivated
+func WithProvisioningQuotaService(quota QuotaService) ProvisioningServiceOption {
+	return func(s *provisioningServiceImpl) {
+		s.quota = quota
+	}
+}
+
+// WithProvisioningUserCache invalidates cache when users change
+func WithProvisioningUserCache(cache UserCache) ProvisioningServiceOption {
+	return func(s *provisioningServiceImpl) {
+		s.userCache = cache
+	}
+}
+
+// NewProvisioningService creates a new provisioning service
+func NewProvisioningService(userRepo repository.UserRepository, tokenRepo repository.TokenRepository, opts ...ProvisioningServiceOption) ProvisioningService {
+	s := &provisioningServiceImpl{
+		userRepo:  userRepo,
+		tokenRepo: tokenRepo,
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// ListUsers lists users matching the filter
+func (s *provisioningServiceImpl) ListUsers(filter *domain.UserFilter, offset, limit int) ([]*domain.User, int64, error) {
+	return s.userRepo.FindByFilter(filter, offset, limit)
+}
+
+// GetUser gets a user by ID
+func (s *provisioningServiceImpl) GetUser(id uint) (*domain.User, error) {
+	return s.userRepo.FindByID(id)
+}
+
+// CreateUser provisions a new user. Provisioned users get a random password
+// and sign in through their identity provider or a password reset.
+func (s *provisioningServiceImpl) CreateUser(attrs *ProvisionedUser) (*domain.User, error) {
+	if _, err := s.userRepo.FindByEmail(attrs.Email); err == nil {
+		return nil, domain.ErrUserAlreadyExists
+	} else if err != domain.ErrUserNotFound {
+		return nil, err
+	}
+
+	password, err := utils.GenerateRandomPassword()
+	if err != nil {
+		return nil, domain.ErrFailedToHashPassword
+	}
+	hashedPassword, err := utils.HashPassword(password)
+	if err != nil {
+		return nil, domain.ErrFailedToHashPassword
+	}
+
+	user := &domain.User{
+		Name:     attrs.Name,
+		Email:    attrs.Email,
+		Password: hashedPassword,
+	}
+	if !attrs.Active {
+		now := time.Now()
+		user.DeactivatedAt = &now
+	}
+
+	if err := s.userRepo.Create(user); err != nil {
+		if err == domain.ErrUserAlreadyExists {
+			return nil, err
+		}
+		return nil, domain.ErrFailedToCreateUser
+	}
+	invalidateUserCache(s.userCache)
+	return user, nil
+}
+
+// ReplaceUser replaces the provisioned attributes of a user
+func (s *provisioningServiceImpl) ReplaceUser(id uint, attrs *ProvisionedUser) (*domain.User, error) {
+	user, err := s.userRepo.FindByID(id)
+	if err != nil {
+		return nil, err
+	}
+	before := domain.SnapshotProfile(user)
+
+	if attrs.Email != user.Email {
+		if existing, err := s.userRepo.FindByEmail(attrs.Email); err == nil && existing.ID != user.ID {
+			return nil, domain.ErrUserAlreadyExists
+		}
+		user.Email = attrs.Email
+	}
+	if attrs.Name != "" {
+		user.SetName(attrs.Name)
+	}
+
+	deactivating := !attrs.Active && user.IsActive()
+	reactivating := attrs.Active && !user.IsActive()
+	switch {
+	case deactivating:
+		now := time.Now()
+		user.DeactivatedAt = &now
+	case attrs.Active:
+		user.DeactivatedAt = nil
+	}
+	// Reactivated users take a seat of their organization again
+	seated := s.quota != nil && user.OrganizationID != nil
+	if reactivating && seated {
+		if err := s.quota.CheckUserQuota(*user.OrganizationID); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := s.userRepo.Update(user); err != nil {
+		return nil, domain.ErrFailedToUpdateUser
+	}
+	invalidateUserCache(s.userCache)
+	if deactivating && seated {
+		s.quota.ReleaseUserQuota(*user.OrganizationID)
+	}
+	if s.history != nil {
+		if err := s.history.Record(user, before, nil, domain.ProfileChangeSourceSCIM); err != nil {
+			return nil, err
+		}
+	}
+
+	// End existing sessions of deprovisioned users
+	if deactivating {
+		if err := s.tokenRepo.RevokeAllUserRefreshTokens(user.ID); err != nil {
+			return nil, err
+		}
+		if s.apiKeys != nil {
+			if err := s.apiKeys.RevokeAllUserAPIKeys(user.ID); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return user, nil
+}
+
+// DeactivateUser deactivates a user and revokes their sessions
+func (s *provisioningServiceImpl) DeactivateUser(id uint) error {
+	user, err := s.userRepo.FindByID(id)
+	if err != nil {
+		return err
+	}
+
+	_, err = s.ReplaceUser(id, &ProvisionedUser{Name: user.Name, Email: user.Email, Active: false})
+	return err
+}
@@ -0,0 +1,275 @@
+package service
+
+import (
+	"gojwt-rest-api/internal/domain"
+	"gojwt-rest-api/internal/repository"
+	"sync"
+	"time"
+)
+
+// quotaWindow is the length of the request quota window
+const quotaWindow = time.Minute
+
+// QuotaService enforces organization-level limits defined by the organization's plan.
+// Organizations, memberships and counts are cached in memory for cacheTTL, so limit
+// and membership changes take effect within that delay.
+type QuotaService interface {
+	// AllowRequest counts a request by the user against their organization's quota,
+	// returning how long to wait when the quota is exceeded
+	AllowRequest(userID uint) (time.Duration, error)
+	// CheckUserQuota reserves a seat for a new member of the organization
+	CheckUserQuota(orgID uint) error
+	// CheckUserQuotaAvailable checks that the organization has a seat left,
+	// without reserving it
+	CheckUserQuotaAvailable(orgID uint) error
+	// ReleaseUserQuota frees the seat of a member who left the organization,
+	// was deleted or was deactivated
+	ReleaseUserQuota(orgID uint)
+	// CheckSessionQuota reserves a session for a member of the organization logging in
+	CheckSessionQuota(orgID uint) error
+	GetUsage(orgID uint) (*domain.OrganizationUsage, error)
+}
+
+// userOrgEntry caches the organization a user belongs to
+type userOrgEntry struct {
+	orgID   *uint
+	expires time.Time
+}
+
+// orgEntry caches an organization with its plan
+type orgEntry struct {
+	org     *domain.Organization
+	expires time.Time
+}
+
+// countEntry caches a counter loaded from the database
+type countEntry struct {
+	count   int64
+	expires time.Time
+}
+
+// requestWindow counts an organization's requests in the current window
+type requestWindow struct {
+	start time.Time
+	count int
+}
+
+// quotaServiceImpl is the implementation of QuotaService
+type quotaServiceImpl struct {
+	orgRepo  repository.OrganizationRepository
+	userRepo repository.UserRepository
+	cacheTTL time.Duration
+
+	mu       sync.Mutex
+	userOrgs map[uint]userOrgEntry
+	orgs     map[uint]orgEntry
+	members  map[uint]countEntry
+	sessions map[uint]countEntry
+	windows  map[uint]*requestWindow
+}
+
+// NewQuotaService creates a new quota service
+func NewQuotaService(orgRepo repository.OrganizationRepository, userRepo repository.UserRepository, cacheTTL time.Duration) QuotaService {
+	return &quotaServiceImpl{
+		orgRepo:  orgRepo,
+		userRepo: userRepo,
+		cacheTTL: cacheTTL,
+		userOrgs: make(map[uint]userOrgEntry),
+		orgs:     make(map[uint]orgEntry),
+		members:  make(map[uint]countEntry),
+		sessions: make(map[uint]countEntry),
+		windows:  make(map[uint]*requestWindow),
+	}
+}
+
+// AllowRequest counts a request against the user's organization request quota
+func (s *quotaServiceImpl) AllowRequest(userID uint) (time.Duration, error) {
+	orgID, err := s.organizationOf(userID)
+	if err != nil || orgID == nil {
+		return 0, err
+	}
+	org, err := s.organization(*orgID)
+	if err != nil {
+		return 0, err
+	}
+
+	limit := org.Plan.RequestsPerMinute
+	if limit <= 0 {
+		return 0, nil
+	}
+
+	now := time.Now()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	window, ok := s.windows[org.ID]
+	if !ok || now.Sub(window.start) >= quotaWindow {
+		window = &requestWindow{start: now.Truncate(quotaWindow)}
+		s.windows[org.ID] = window
+	}
+	if window.count >= limit {
+		return window.start.Add(quotaWindow).Sub(now), domain.ErrRequestQuotaExceeded
+	}
+	window.count++
+	return 0, nil
+}
+
+// CheckUserQuota checks the organization's active user limit, reserving a seat when allowed
+func (s *quotaServiceImpl) CheckUserQuota(orgID uint) error {
+	org, err := s.organization(orgID)
+	if err != nil {
+		return err
+	}
+	return s.reserve(s.members, orgID, org.Plan.MaxActiveUsers, domain.ErrUserQuotaExceeded, s.countMembers(orgID), true)
+}
+
+// CheckUserQuotaAvailable checks the organization's active user limit without reserving a seat
+func (s *quotaServiceImpl) CheckUserQuotaAvailable(orgID uint) error {
+	org, err := s.organization(orgID)
+	if err != nil {
+		return err
+	}
+	return s.reserve(s.members, orgID, org.Plan.MaxActiveUsers, domain.ErrUserQuotaExceeded, s.countMembers(orgID), false)
+}
+
+// ReleaseUserQuota frees a seat of the organization's active user limit
+func (s *quotaServiceImpl) ReleaseUserQuota(orgID uint) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if entry, ok := s.members[orgID]; ok && entry.count > 0 {
+		entry.count--
+		s.members[orgID] = entry
+	}
+}
+
+// countMembers loads the number of active members of the organization
+func (s *quotaServiceImpl) countMembers(orgID uint) func() (int64, error) {
+	return func() (int64, error) {
+		return s.orgRepo.CountMembers(orgID)
+	}
+}
+
+// CheckSessionQuota checks the organization's session limit, reserving a session when allowed
+func (s *quotaServiceImpl) CheckSessionQuota(orgID uint) error {
+	org, err := s.organization(orgID)
+	if err != nil {
+		return err
+	}
+	return s.reserve(s.sessions, orgID, org.Plan.MaxSessions, domain.ErrSessionQuotaExceeded, func() (int64, error) {
+		return s.orgRepo.CountActiveSessions(orgID, time.Now())
+	}, true)
+}
+
+// GetUsage reports the organization's current consumption against its plan
+func (s *quotaServiceImpl) GetUsage(orgID uint) (*domain.OrganizationUsage, error) {
+	org, err := s.orgRepo.FindByID(orgID)
+	if err != nil {
+		return nil, err
+	}
+	members, err := s.orgRepo.CountMembers(orgID)
+	if err != nil {
+		return nil, err
+	}
+	sessions, err := s.orgRepo.CountActiveSessions(orgID, time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	usage := &domain.OrganizationUsage{
+		OrganizationID:    org.ID,
+		Plan:              org.Plan.Name,
+		RequestsPerMinute: org.Plan.RequestsPerMinute,
+		ActiveUsers:       members,
+		MaxActiveUsers:    org.Plan.MaxActiveUsers,
+		Sessions:          sessions,
+		MaxSessions:       org.Plan.MaxSessions,
+	}
+
+	s.mu.Lock()
+	if window, ok := s.windows[orgID]; ok && time.Since(window.start) < quotaWindow {
+		usage.RequestsThisMinute = window.count
+	}
+	s.mu.Unlock()
+
+	return usage, nil
+}
+
+// reserve checks a cached counter against limit and, when take is set,
+// increments it when allowed, so concurrent reservations between cache
+// refreshes are accounted for
+func (s *quotaServiceImpl) reserve(counts map[uint]countEntry, orgID uint, limit int, exceeded error, load func() (int64, error), take bool) error {
+	if limit <= 0 {
+		return nil
+	}
+
+	s.mu.Lock()
+	entry, ok := counts[orgID]
+	s.mu.Unlock()
+
+	var loaded *countEntry
+	if !ok || time.Now().After(entry.expires) {
+		count, err := load()
+		if err != nil {
+			return err
+		}
+		loaded = &countEntry{count: count, expires: time.Now().Add(s.cacheTTL)}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// Another request may have refreshed the counter while loading
+	entry, ok = counts[orgID]
+	if loaded != nil && (!ok || time.Now().After(entry.expires)) {
+		entry = *loaded
+	}
+	if entry.count >= int64(limit) {
+		counts[orgID] = entry
+		return exceeded
+	}
+	if take {
+		entry.count++
+	}
+	counts[orgID] = entry
+	return nil
+}
+
+// organizationOf resolves the organization a user belongs to
+func (s *quotaServiceImpl) organizationOf(userID uint) (*uint, error) {
+	s.mu.Lock()
+	entry, ok := s.userOrgs[userID]
+	s.mu.Unlock()
+	if ok && time.Now().Before(entry.expires) {
+		return entry.orgID, nil
+	}
+
+	user, err := s.userRepo.FindByID(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	s.userOrgs[userID] = userOrgEntry{orgID: user.OrganizationID, expires: time.Now().Add(s.cacheTTL)}
+	s.mu.Unlock()
+	return user.OrganizationID, nil
+}
+
+// organization loads an organization with its plan
+func (s *quotaServiceImpl) organization(orgID uint) (*domain.Organization, error) {
+	s.mu.Lock()
+	entry, ok := s.orgs[orgID]
+	s.mu.Unlock()
+	if ok && time.Now().Before(entry.expires) {
+		return entry.org, nil
+	}
+
+	org, err := s.orgRepo.FindByID(orgID)
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	s.orgs[orgID] = orgEntry{org: org, expires: time.Now().Add(s.cacheTTL)}
+	s.mu.Unlock()
+	return org, nil
+}
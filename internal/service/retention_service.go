@@ -0,0 +1,176 @@
+package service
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"gojwt-rest-api/internal/domain"
+	"gojwt-rest-api/internal/repository"
+	"gojwt-rest-api/internal/storage"
+	"strconv"
+	"time"
+)
+
+// retentionBatchSize is the number of rows deleted per query, keeping locks short
+const retentionBatchSize = 1000
+
+// RetentionPolicy configures how long data is kept once it is no longer
+// needed, zero keeps it forever
+type RetentionPolicy struct {
+	// RevokedTokens is measured from revocation; revoked tokens are always
+	// kept until they expire so that their reuse is still detected
+	RevokedTokens time.Duration
+	// LoginHistory is measured from the expiry of a session's refresh token
+	LoginHistory time.Duration
+	// TokenBlacklist is measured from the expiry of the blacklisted token
+	TokenBlacklist time.Duration
+	// AuditLogs is measured from creation
+	AuditLogs time.Duration
+}
+
+// RetentionService defines the interface for purging data past its retention window
+type RetentionService interface {
+	// Purge deletes the data of every dataset with a retention window that has passed it
+	Purge(ctx context.Context) (*domain.RetentionReport, error)
+}
+
+// retentionServiceImpl is the implementation of RetentionService
+type retentionServiceImpl struct {
+	tokenRepo repository.TokenRepository
+	auditRepo repository.AuditLogRepository
+	policy    RetentionPolicy
+	// archive receives audit logs before they are purged, nil to purge them only
+	archive       storage.Store
+	archivePrefix string
+}
+
+// RetentionServiceOption configures optional retention service behavior
+type RetentionServiceOption func(*retentionServiceImpl)
+
+// WithAuditLogArchive exports audit logs to store, under prefix, before they
+// are purged, keeping them for compliance outside the database
+func WithAuditLogArchive(store storage.Store, prefix string) RetentionServiceOption {
+	return func(s *retentionServiceImpl) {
+		s.archive = store
+		s.archivePrefix = prefix
+	}
+}
+
+// NewRetentionService creates a new retention service
+func NewRetentionService(tokenRepo repository.TokenRepository, auditRepo repository.AuditLogRepository, policy RetentionPolicy, opts ...RetentionServiceOption) RetentionService {
+	s := &retentionServiceImpl{
+		tokenRepo: tokenRepo,
+		auditRepo: auditRepo,
+		policy:    policy,
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// Purge deletes the data of every dataset with a retention window that has
+// passed it. The report includes the rows purged before an error occurred.
+func (s *retentionServiceImpl) Purge(ctx context.Context) (*domain.RetentionReport, error) {
+	now := time.Now()
+	report := &domain.RetentionReport{Checked: now, Purged: make(map[string]int64)}
+
+	purgeAuditLogs := s.auditRepo.Purge
+	if s.archive != nil {
+		purgeAuditLogs = func(before time.Time, limit int) (int64, error) {
+			return s.archiveAuditLogs(ctx, before, limit)
+		}
+	}
+
+	datasets := []struct {
+		name   string
+		window time.Duration
+		purge  func(before time.Time, limit int) (int64, error)
+	}{
+		{domain.RetentionRevokedTokens, s.policy.RevokedTokens, s.tokenRepo.PurgeRevokedRefreshTokens},
+		{domain.RetentionLoginHistory, s.policy.LoginHistory, s.tokenRepo.PurgeExpiredRefreshTokens},
+		{domain.RetentionTokenBlacklist, s.policy.TokenBlacklist, s.tokenRepo.PurgeBlacklist},
+		{domain.RetentionAuditLogs, s.policy.AuditLogs, purgeAuditLogs},
+	}
+
+	for _, dataset := range datasets {
+		if dataset.window <= 0 {
+			continue
+		}
+		before := now.Add(-dataset.window)
+		for {
+			if err := ctx.Err(); err != nil {
+				return report, err
+			}
+			purged, err := dataset.purge(before, retentionBatchSize)
+			report.Purged[dataset.name] += purged
+			if err != nil {
+				return report, err
+			}
+			if purged < retentionBatchSize {
+				break
+			}
+		}
+	}
+
+	return report, nil
+}
+
+// archiveAuditLogs archives up to limit audit logs created before before, then
+// deletes them, returning the number deleted. The logs of each organization,
+// and the logs of no organization, are written to their own object as gzip
+// compressed NDJSON; nothing is deleted unless every object was written.
+func (s *retentionServiceImpl) archiveAuditLogs(ctx context.Context, before time.Time, limit int) (int64, error) {
+	logs, err := s.auditRepo.FindBefore(before, limit)
+	if err != nil || len(logs) == 0 {
+		return 0, err
+	}
+
+	var partitions []string
+	byPartition := make(map[string][]*domain.AuditLog)
+	ids := make([]uint, 0, len(logs))
+	for _, log := range logs {
+		partition := "global"
+		if log.OrganizationID != nil {
+			partition = "org-" + strconv.FormatUint(uint64(*log.OrganizationID), 10)
+		}
+		if _, ok := byPartition[partition]; !ok {
+			partitions = append(partitions, partition)
+		}
+		byPartition[partition] = append(byPartition[partition], log)
+		ids = append(ids, log.ID)
+	}
+
+	for _, partition := range partitions {
+		partitionLogs := byPartition[partition]
+		body, err := encodeAuditLogs(partitionLogs)
+		if err != nil {
+			return 0, err
+		}
+		first, last := partitionLogs[0], partitionLogs[len(partitionLogs)-1]
+		key := fmt.Sprintf("%s%s/%s/%d-%d.ndjson.gz", s.archivePrefix, partition, first.CreatedAt.UTC().Format("2006/01/02"), first.ID, last.ID)
+		if err := s.archive.Put(ctx, key, body, "application/gzip"); err != nil {
+			return 0, fmt.Errorf("archive audit logs to %s: %w", key, err)
+		}
+	}
+
+	return s.auditRepo.DeleteByIDs(ids)
+}
+
+// encodeAuditLogs writes logs as gzip compressed NDJSON, one log per line
+func encodeAuditLogs(logs []*domain.AuditLog) ([]byte, error) {
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	encoder := json.NewEncoder(gz)
+	for _, log := range logs {
+		if err := encoder.Encode(log.ToResponse()); err != nil {
+			return nil, err
+		}
+	}
+	if err := gz.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
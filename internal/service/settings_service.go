@@ -0,0 +1,131 @@
+package service
+
+import (
+	"gojwt-rest-api/internal/domain"
+	"gojwt-rest-api/internal/repository"
+	"strings"
+	"sync"
+	"time"
+)
+
+// SettingsService resolves the settings in effect for an organization: the global
+// configuration with the organization's overrides applied. Overrides are cached in
+// memory for cacheTTL; updates made through this service take effect immediately
+// on this instance and within cacheTTL on others.
+type SettingsService interface {
+	// Defaults returns the global settings
+	Defaults() domain.TenantSettings
+	// ForOrganization returns the effective settings of an organization, the
+	// global settings when orgID is nil
+	ForOrganization(orgID *uint) (*domain.TenantSettings, error)
+	GetOverrides(orgID uint) (*domain.OrganizationSettings, error)
+	UpdateOverrides(orgID uint, req *domain.UpdateOrganizationSettingsRequest) (*domain.OrganizationSettings, error)
+}
+
+// settingsEntry caches an organization's effective settings
+type settingsEntry struct {
+	settings *domain.TenantSettings
+	expires  time.Time
+}
+
+// settingsServiceImpl is the implementation of SettingsService
+type settingsServiceImpl struct {
+	orgRepo  repository.OrganizationRepository
+	defaults domain.TenantSettings
+	cacheTTL time.Duration
+
+	mu      sync.Mutex
+	entries map[uint]settingsEntry
+}
+
+// NewSettingsService creates a new settings service
+func NewSettingsService(orgRepo repository.OrganizationRepository, defaults domain.TenantSettings, cacheTTL time.Duration) SettingsService {
+	return &settingsServiceImpl{
+		orgRepo:  orgRepo,
+		defaults: defaults,
+		cacheTTL: cacheTTL,
+		entries:  make(map[uint]settingsEntry),
+	}
+}
+
+// Defaults returns the global settings
+func (s *settingsServiceImpl) Defaults() domain.TenantSettings {
+	return s.defaults
+}
+
+// ForOrganization returns the effective settings of an organization
+func (s *settingsServiceImpl) ForOrganization(orgID *uint) (*domain.TenantSettings, error) {
+	if orgID == nil {
+		settings := s.defaults
+		return &settings, nil
+	}
+
+	now := time.Now()
+	s.mu.Lock()
+	entry, ok := s.entries[*orgID]
+	s.mu.Unlock()
+	if ok && now.Before(entry.expires) {
+		return entry.settings, nil
+	}
+
+	overrides, err := s.orgRepo.FindSettings(*orgID)
+	if err != nil {
+		return nil, err
+	}
+	settings := overrides.Apply(s.defaults)
+
+	s.mu.Lock()
+	s.entries[*orgID] = settingsEntry{settings: settings, expires: now.Add(s.cacheTTL)}
+	s.mu.Unlock()
+	return settings, nil
+}
+
+// GetOverrides returns the setting overrides of an organization
+func (s *settingsServiceImpl) GetOverrides(orgID uint) (*domain.OrganizationSettings, error) {
+	if _, err := s.orgRepo.FindByID(orgID); err != nil {
+		return nil, err
+	}
+	return s.orgRepo.FindSettings(orgID)
+}
+
+// UpdateOverrides replaces the setting overrides of an organization
+func (s *settingsServiceImpl) UpdateOverrides(orgID uint, req *domain.UpdateOrganizationSettingsRequest) (*domain.OrganizationSettings, error) {
+	if _, err := s.orgRepo.FindByID(orgID); err != nil {
+		return nil, err
+	}
+
+	var accessSchedule string
+	if req.AccessSchedule != "" {
+		schedule, err := domain.ParseAccessSchedule(req.AccessSchedule)
+		if err != nil {
+			return nil, domain.ErrInvalidAccessSchedule
+		}
+		accessSchedule = schedule.String()
+	}
+
+	domains := make([]string, 0, len(req.AllowedEmailDomains))
+	for _, d := range req.AllowedEmailDomains {
+		domains = append(domains, strings.ToLower(strings.TrimSpace(d)))
+	}
+
+	settings := &domain.OrganizationSettings{
+		OrganizationID:           orgID,
+		AccessTokenTTLSeconds:    req.AccessTokenTTLSeconds,
+		RefreshTokenTTLSeconds:   req.RefreshTokenTTLSeconds,
+		PasswordMinLength:        req.PasswordMinLength,
+		PasswordRequireUppercase: req.PasswordRequireUppercase,
+		PasswordRequireDigit:     req.PasswordRequireDigit,
+		PasswordRequireSymbol:    req.PasswordRequireSymbol,
+		AllowedEmailDomains:      strings.Join(domains, ","),
+		Require2FA:               req.Require2FA,
+		AccessSchedule:           accessSchedule,
+	}
+	if err := s.orgRepo.SaveSettings(settings); err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	delete(s.entries, orgID)
+	s.mu.Unlock()
+	return settings, nil
+}
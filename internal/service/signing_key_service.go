@@ -0,0 +1,69 @@
+package service
+
+import (
+	"gojwt-rest-api/internal/domain"
+	"gojwt-rest-api/internal/repository"
+	"gojwt-rest-api/internal/utils"
+	"time"
+)
+
+// SigningKeyService handles signing key compromises. Access tokens carry the
+// ID of the key they were signed with, and reporting a compromise drops the
+// key from the key set: tokens signed with it are rejected whatever their
+// claims, so that clients refresh their sessions onto the next key of the
+// set, and the refresh tokens can be revoked as well. Other instances drop
+// the key at their next Refresh.
+type SigningKeyService interface {
+	// Keys returns the key set access tokens are signed and verified with
+	Keys() *utils.KeySet
+	// Refresh drops the keys of the set that were reported compromised, e.g.
+	// by another instance. It returns domain.ErrNoSigningKey when no key is
+	// left to sign tokens with.
+	Refresh() error
+	// ReportCompromise records the compromise of a signing key and drops it
+	// from the key set of this instance at once
+	ReportCompromise(req *domain.SigningKeyCompromise) (*domain.SigningKeyIncident, error)
+}
+
+// signingKeyServiceImpl is the implementation of SigningKeyService
+type signingKeyServiceImpl struct {
+	repo repository.SigningKeyRepository
+	keys *utils.KeySet
+}
+
+// NewSigningKeyService creates a new signing key service for the keys of keys
+func NewSigningKeyService(repo repository.SigningKeyRepository, keys *utils.KeySet) SigningKeyService {
+	return &signingKeyServiceImpl{repo: repo, keys: keys}
+}
+
+// Keys returns the key set of the service
+func (s *signingKeyServiceImpl) Keys() *utils.KeySet {
+	return s.keys
+}
+
+// Refresh revokes the keys of the set reported compromised
+func (s *signingKeyServiceImpl) Refresh() error {
+	compromised, err := s.repo.FindCompromised(s.keys.IDs())
+	if err != nil {
+		return err
+	}
+	s.keys.Revoke(compromised...)
+	_, err = s.keys.SigningKeyID()
+	return err
+}
+
+// ReportCompromise records an incident for the key and revokes it from the
+// key set of this instance
+func (s *signingKeyServiceImpl) ReportCompromise(req *domain.SigningKeyCompromise) (*domain.SigningKeyIncident, error) {
+	incident := &domain.SigningKeyIncident{
+		KeyFingerprint: domain.SigningKeyFingerprint(req.Secret),
+		Reason:         req.Reason,
+		CreatedAt:      time.Now(),
+	}
+	if err := s.repo.RecordIncident(incident, req.RevokeRefreshTokens); err != nil {
+		return nil, err
+	}
+
+	s.keys.Revoke(incident.KeyFingerprint)
+	return incident, nil
+}
@@ -0,0 +1,346 @@
+package service
+
+import (
+	"gojwt-rest-api/internal/domain"
+	"gojwt-rest-api/internal/repository"
+	"gojwt-rest-api/internal/utils"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// SSOService defines the interface for SSO connections and home realm discovery
+type SSOService interface {
+	// DiscoverRealm tells whether the user of the email signs in with a password or an SSO connection
+	DiscoverRealm(email string) (*domain.LoginStartResponse, error)
+	// ResolveUser maps the attributes of a verified assertion to a user, provisioning
+	// or updating it according to the connection's just-in-time provisioning rules
+	ResolveUser(connectionID uint, attributes map[string]string) (*domain.User, error)
+	// Admin methods
+	CreateConnection(req *domain.CreateSSOConnectionRequest) (*domain.SSOConnection, error)
+	ListConnections() ([]*domain.SSOConnection, error)
+	UpdateProvisioningRules(id uint, rules *domain.SSOProvisioningRules) (*domain.SSOConnection, error)
+	DeleteConnection(id uint) error
+}
+
+// ssoServiceImpl is the implementation of SSOService
+type ssoServiceImpl struct {
+	ssoRepo    repository.SSOConnectionRepository
+	orgRepo    repository.OrganizationRepository
+	userRepo   repository.UserRepository
+	audit      AuditService
+	quota      QuotaService
+	onboarding OnboardingTracker
+	history    ProfileHistoryService
+	userCache  UserCache
+}
+
+// SSOServiceOption configures optional behaviour of the SSO service
+type SSOServiceOption func(*ssoServiceImpl)
+
+// WithSSOQuotaService enforces the organization's active user limit on provisioned users
+func WithSSOQuotaService(quota QuotaService) SSOServiceOption {
+	return func(s *ssoServiceImpl) {
+		s.quota = quota
+	}
+}
+
+// WithSSOOnboardingTracker records the emails of users signing in via SSO as
+// verified, the identity provider vouching for them
+func WithSSOOnboardingTracker(onboarding OnboardingTracker) SSOServiceOption {
+	return func(s *ssoServiceImpl) {
+		s.onboarding = onboarding
+	}
+}
+
+// WithSSOProfileHistory records the name changes of users updated on login
+// in their profile history
+func WithSSOProfileHistory(history ProfileHistoryService) SSOServiceOption {
+	return func(s *ssoServiceImpl) {
+		s.history = history
+	}
+}
+
+// WithSSOUserCache invalidates cache when users change
+func WithSSOUserCache(cache UserCache) SSOServiceOption {
+	return func(s *ssoServiceImpl) {
+		s.userCache = cache
+	}
+}
+
+// NewSSOService creates a new SSO service
+func NewSSOService(
+	ssoRepo repository.SSOConnectionRepository,
+	orgRepo repository.OrganizationRepository,
+	userRepo repository.UserRepository,
+	audit AuditService,
+	opts ...SSOServiceOption,
+) SSOService {
+	s := &ssoServiceImpl{
+		ssoRepo:  ssoRepo,
+		orgRepo:  orgRepo,
+		userRepo: userRepo,
+		audit:    audit,
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// DiscoverRealm maps the email domain to an SSO connection. Users of unmapped
+// domains sign in with their password.
+func (s *ssoServiceImpl) DiscoverRealm(email string) (*domain.LoginStartResponse, error) {
+	conn, err := s.ssoRepo.FindByDomain(emailDomain(email))
+	if err == domain.ErrSSOConnectionNotFound {
+		return &domain.LoginStartResponse{Method: domain.LoginMethodPassword}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	redirectURL, err := url.Parse(conn.LoginURL)
+	if err != nil {
+		return nil, err
+	}
+	// Let the identity provider prefill the username
+	query := redirectURL.Query()
+	query.Set("login_hint", email)
+	redirectURL.RawQuery = query.Encode()
+
+	return &domain.LoginStartResponse{
+		Method:       domain.LoginMethodSSO,
+		ConnectionID: conn.ID,
+		Connection:   conn.Name,
+		Protocol:     conn.Protocol,
+		RedirectURL:  redirectURL.String(),
+	}, nil
+}
+
+// ResolveUser maps the attributes of a verified assertion to a user. Unknown users
+// are created when just-in-time provisioning is enabled, known users get their name
+// updated when the connection updates on login. Provisioning decisions are audited.
+func (s *ssoServiceImpl) ResolveUser(connectionID uint, attributes map[string]string) (*domain.User, error) {
+	conn, err := s.ssoRepo.FindByID(connectionID)
+	if err != nil {
+		return nil, err
+	}
+
+	rules := conn.Provisioning.WithDefaults()
+	email, name := rules.MapIdentity(attributes)
+
+	// A connection may only sign in users of its own domains
+	if !strings.Contains(email, "@") || !conn.HasDomain(emailDomain(email)) {
+		if err := s.recordDenied(conn, email, domain.ErrSSOEmailDomainMismatch); err != nil {
+			return nil, err
+		}
+		return nil, domain.ErrSSOEmailDomainMismatch
+	}
+
+	user, err := s.userRepo.FindByEmail(email)
+	if err == domain.ErrUserNotFound {
+		user, err = s.provisionUser(conn, rules, email, name)
+		if err != nil {
+			return nil, err
+		}
+		if err := s.emailVerified(user); err != nil {
+			return nil, err
+		}
+		return user, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if !user.IsActive() {
+		return nil, domain.ErrUserDeactivated
+	}
+
+	if rules.UpdateOnLogin && name != user.Name {
+		previousName := user.Name
+		before := domain.SnapshotProfile(user)
+		user.SetName(name)
+		if err := s.userRepo.Update(user); err != nil {
+			return nil, domain.ErrFailedToUpdateUser
+		}
+		invalidateUserCache(s.userCache)
+		if s.history != nil {
+			if err := s.history.Record(user, before, nil, domain.ProfileChangeSourceSSO); err != nil {
+				return nil, err
+			}
+		}
+		if err := s.audit.Record(s.auditLog(conn, domain.AuditSSOUserUpdated, user), map[string]interface{}{
+			"email":         user.Email,
+			"previous_name": previousName,
+			"name":          user.Name,
+		}); err != nil {
+			return nil, err
+		}
+	}
+	if err := s.emailVerified(user); err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+// emailVerified completes the email verification step of a user signing in
+func (s *ssoServiceImpl) emailVerified(user *domain.User) error {
+	if s.onboarding == nil {
+		return nil
+	}
+	return s.onboarding.CompleteStep(user.ID, domain.OnboardingStepEmailVerified)
+}
+
+// provisionUser creates a user on its first SSO login. Provisioned users get a
+// random password, the connection's default role and organization.
+func (s *ssoServiceImpl) provisionUser(conn *domain.SSOConnection, rules domain.SSOProvisioningRules, email, name string) (*domain.User, error) {
+	if !rules.Enabled {
+		if err := s.recordDenied(conn, email, domain.ErrJITProvisioningDisabled); err != nil {
+			return nil, err
+		}
+		return nil, domain.ErrJITProvisioningDisabled
+	}
+
+	if s.quota != nil && conn.OrganizationID != nil {
+		if err := s.quota.CheckUserQuota(*conn.OrganizationID); err != nil {
+			if recordErr := s.recordDenied(conn, email, err); recordErr != nil {
+				return nil, recordErr
+			}
+			return nil, err
+		}
+	}
+
+	password, err := utils.GenerateRandomPassword()
+	if err != nil {
+		return nil, domain.ErrFailedToHashPassword
+	}
+	hashedPassword, err := utils.HashPassword(password)
+	if err != nil {
+		return nil, domain.ErrFailedToHashPassword
+	}
+
+	user := &domain.User{
+		Name:           name,
+		Email:          email,
+		Password:       hashedPassword,
+		IsAdmin:        rules.DefaultRole == domain.RoleAdmin,
+		OrganizationID: conn.OrganizationID,
+	}
+	if err := s.userRepo.Create(user); err != nil {
+		return nil, domain.ErrFailedToCreateUser
+	}
+	invalidateUserCache(s.userCache)
+
+	if err := s.audit.Record(s.auditLog(conn, domain.AuditSSOUserProvisioned, user), map[string]interface{}{
+		"email": user.Email,
+		"name":  user.Name,
+		"roles": user.Roles(),
+	}); err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+// recordDenied audits a refused SSO login
+func (s *ssoServiceImpl) recordDenied(conn *domain.SSOConnection, email string, reason error) error {
+	return s.audit.Record(s.auditLog(conn, domain.AuditSSOProvisioningDenied, nil), map[string]interface{}{
+		"email":  email,
+		"reason": reason.Error(),
+	})
+}
+
+// auditLog creates an audit log of a provisioning event of the connection
+func (s *ssoServiceImpl) auditLog(conn *domain.SSOConnection, action string, user *domain.User) *domain.AuditLog {
+	log := &domain.AuditLog{
+		Action:         action,
+		OrganizationID: conn.OrganizationID,
+		Source:         "sso_connection:" + strconv.FormatUint(uint64(conn.ID), 10),
+	}
+	if user != nil {
+		log.UserID = &user.ID
+	}
+	return log
+}
+
+// CreateConnection creates an SSO connection for domains not mapped to another connection
+func (s *ssoServiceImpl) CreateConnection(req *domain.CreateSSOConnectionRequest) (*domain.SSOConnection, error) {
+	if _, err := s.ssoRepo.FindByName(req.Name); err == nil {
+		return nil, domain.ErrSSOConnectionAlreadyExists
+	} else if err != domain.ErrSSOConnectionNotFound {
+		return nil, err
+	}
+
+	if req.OrganizationID != nil {
+		if _, err := s.orgRepo.FindByID(*req.OrganizationID); err != nil {
+			return nil, err
+		}
+	}
+
+	domains := normalizeDomains(req.Domains)
+	mapped, err := s.ssoRepo.FindMappedDomains(domains)
+	if err != nil {
+		return nil, err
+	}
+	if len(mapped) > 0 {
+		return nil, domain.ErrSSODomainTaken
+	}
+
+	conn := &domain.SSOConnection{
+		Name:           req.Name,
+		Protocol:       req.Protocol,
+		LoginURL:       req.LoginURL,
+		OrganizationID: req.OrganizationID,
+		Provisioning:   req.Provisioning.WithDefaults(),
+		Domains:        make([]domain.SSODomain, len(domains)),
+	}
+	for i, d := range domains {
+		conn.Domains[i] = domain.SSODomain{Domain: d}
+	}
+	if err := s.ssoRepo.Create(conn); err != nil {
+		return nil, err
+	}
+	return conn, nil
+}
+
+// ListConnections lists all SSO connections
+func (s *ssoServiceImpl) ListConnections() ([]*domain.SSOConnection, error) {
+	return s.ssoRepo.FindAll()
+}
+
+// UpdateProvisioningRules replaces the just-in-time provisioning rules of a connection
+func (s *ssoServiceImpl) UpdateProvisioningRules(id uint, rules *domain.SSOProvisioningRules) (*domain.SSOConnection, error) {
+	conn, err := s.ssoRepo.FindByID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	conn.Provisioning = rules.WithDefaults()
+	if err := s.ssoRepo.Update(conn); err != nil {
+		return nil, err
+	}
+	return conn, nil
+}
+
+// DeleteConnection deletes an SSO connection, its users fall back to password login
+func (s *ssoServiceImpl) DeleteConnection(id uint) error {
+	return s.ssoRepo.Delete(id)
+}
+
+// emailDomain returns the lower-cased domain part of an email address
+func emailDomain(email string) string {
+	return strings.ToLower(email[strings.LastIndex(email, "@")+1:])
+}
+
+// normalizeDomains lower-cases the domains and drops duplicates
+func normalizeDomains(domains []string) []string {
+	seen := make(map[string]bool, len(domains))
+	normalized := make([]string, 0, len(domains))
+	for _, d := range domains {
+		d = strings.ToLower(strings.TrimSpace(d))
+		if !seen[d] {
+			seen[d] = true
+			normalized = append(normalized, d)
+		}
+	}
+	return normalized
+}
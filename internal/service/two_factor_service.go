@@ -0,0 +1,275 @@
+package service
+
+import (
+	"context"
+	"gojwt-rest-api/internal/domain"
+	"gojwt-rest-api/internal/repository"
+	"gojwt-rest-api/internal/utils"
+	"gojwt-rest-api/pkg/kv"
+	"strconv"
+	"time"
+)
+
+// OrganizationRolePrefix prefixes organization roles in the list of roles
+// requiring two-factor authentication, e.g. "org:owner"
+const OrganizationRolePrefix = "org:"
+
+// TwoFactorService manages TOTP enrollment and the policy deciding who must use it
+type TwoFactorService interface {
+	// Required reports whether the policy requires the user to use two-factor
+	// authentication, because of their roles or their organization's settings
+	Required(user *domain.User) (bool, error)
+	IsEnrolled(userID uint) (bool, error)
+	// BeginEnrollment generates a new secret, replacing a pending enrollment
+	BeginEnrollment(userID uint) (*domain.TwoFactorEnrollmentResponse, error)
+	// ConfirmEnrollment activates a pending enrollment with a code of the new
+	// secret. Invalid codes count towards the same lockout as Verify.
+	ConfirmEnrollment(userID uint, code string) error
+	// Verify checks a code of an enrolled user, accepting each code only
+	// once. It returns domain.ErrTooManyTwoFactorAttempts once too many
+	// invalid codes were presented for the user.
+	Verify(userID uint, code string) error
+}
+
+// twoFactorServiceImpl is the implementation of TwoFactorService
+type twoFactorServiceImpl struct {
+	userRepo      repository.UserRepository
+	settings      SettingsService
+	issuer        string
+	requiredRoles map[string]bool
+	onboarding    OnboardingTracker
+
+	attempts    kv.Store
+	maxAttempts int64
+	lockout     time.Duration
+	userCache   UserCache
+}
+
+// TwoFactorServiceOption configures optional two-factor service behavior
+type TwoFactorServiceOption func(*twoFactorServiceImpl)
+
+// WithTwoFactorOnboardingTracker records confirmed enrollments as an onboarding step
+func WithTwoFactorOnboardingTracker(onboarding OnboardingTracker) TwoFactorServiceOption {
+	return func(s *twoFactorServiceImpl) {
+		s.onboarding = onboarding
+	}
+}
+
+// WithTwoFactorAttemptLimit counts the invalid codes presented for a user in
+// store, and refuses to verify codes of the user for lockout after
+// maxAttempts of them, so that codes cannot be guessed
+func WithTwoFactorAttemptLimit(store kv.Store, maxAttempts int, lockout time.Duration) TwoFactorServiceOption {
+	return func(s *twoFactorServiceImpl) {
+		s.attempts = store
+		s.maxAttempts = int64(maxAttempts)
+		s.lockout = lockout
+	}
+}
+
+// WithTwoFactorUserCache invalidates cache when users change
+func WithTwoFactorUserCache(cache UserCache) TwoFactorServiceOption {
+	return func(s *twoFactorServiceImpl) {
+		s.userCache = cache
+	}
+}
+
+// NewTwoFactorService creates a new two-factor service. requiredRoles lists the
+// global roles (e.g. "admin") and prefixed organization roles (e.g. "org:owner")
+// that must use two-factor authentication.
+func NewTwoFactorService(userRepo repository.UserRepository, settings SettingsService, issuer string, requiredRoles []string, opts ...TwoFactorServiceOption) TwoFactorService {
+	roles := make(map[string]bool, len(requiredRoles))
+	for _, role := range requiredRoles {
+		roles[role] = true
+	}
+	s := &twoFactorServiceImpl{
+		userRepo:      userRepo,
+		settings:      settings,
+		issuer:        issuer,
+		requiredRoles: roles,
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// Required reports whether the user must use two-factor authentication
+func (s *twoFactorServiceImpl) Required(user *domain.User) (bool, error) {
+	for _, role := range user.Roles() {
+		if s.requiredRoles[role] {
+			return true, nil
+		}
+	}
+	if user.OrganizationID == nil {
+		return false, nil
+	}
+	if s.requiredRoles[OrganizationRolePrefix+user.OrganizationRole] {
+		return true, nil
+	}
+
+	if s.settings == nil {
+		return false, nil
+	}
+	settings, err := s.settings.ForOrganization(user.OrganizationID)
+	if err != nil {
+		return false, err
+	}
+	return settings.Require2FA, nil
+}
+
+// IsEnrolled reports whether the user has a confirmed enrollment
+func (s *twoFactorServiceImpl) IsEnrolled(userID uint) (bool, error) {
+	twoFactor, err := s.userRepo.FindTwoFactor(userID)
+	if err == domain.ErrTwoFactorNotEnrolled {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return twoFactor.IsConfirmed(), nil
+}
+
+// BeginEnrollment generates a new secret for the user
+func (s *twoFactorServiceImpl) BeginEnrollment(userID uint) (*domain.TwoFactorEnrollmentResponse, error) {
+	user, err := s.userRepo.FindByID(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	existing, err := s.userRepo.FindTwoFactor(userID)
+	if err != nil && err != domain.ErrTwoFactorNotEnrolled {
+		return nil, err
+	}
+	if existing != nil && existing.IsConfirmed() {
+		return nil, domain.ErrTwoFactorAlreadyEnrolled
+	}
+
+	secret, err := utils.GenerateTOTPSecret()
+	if err != nil {
+		return nil, err
+	}
+	if err := s.userRepo.SaveTwoFactor(&domain.UserTwoFactor{UserID: userID, Secret: secret}); err != nil {
+		return nil, err
+	}
+
+	return &domain.TwoFactorEnrollmentResponse{
+		Secret:     secret,
+		OTPAuthURL: utils.TOTPURL(s.issuer, user.Email, secret),
+	}, nil
+}
+
+// ConfirmEnrollment activates the user's pending enrollment, unless too many
+// invalid codes were presented for the user lately
+func (s *twoFactorServiceImpl) ConfirmEnrollment(userID uint, code string) error {
+	if err := s.checkAttempts(userID); err != nil {
+		return err
+	}
+	twoFactor, err := s.userRepo.FindTwoFactor(userID)
+	if err != nil {
+		return err
+	}
+	if twoFactor.IsConfirmed() {
+		return domain.ErrTwoFactorAlreadyEnrolled
+	}
+
+	now := time.Now()
+	step, ok := utils.ValidateTOTP(twoFactor.Secret, code, now)
+	if !ok {
+		return s.failedAttempt(userID)
+	}
+
+	twoFactor.ConfirmedAt = &now
+	twoFactor.LastUsedStep = step
+	if err := s.userRepo.SaveTwoFactor(twoFactor); err != nil {
+		return err
+	}
+	invalidateUserCache(s.userCache)
+	if err := s.resetAttempts(userID); err != nil {
+		return err
+	}
+	if s.onboarding != nil {
+		return s.onboarding.CompleteStep(userID, domain.OnboardingStepTwoFactorEnrolled)
+	}
+	return nil
+}
+
+// Verify checks a code of the user's confirmed enrollment, unless too many
+// invalid codes were presented for the user lately
+func (s *twoFactorServiceImpl) Verify(userID uint, code string) error {
+	if err := s.checkAttempts(userID); err != nil {
+		return err
+	}
+	twoFactor, err := s.userRepo.FindTwoFactor(userID)
+	if err != nil {
+		return err
+	}
+	if !twoFactor.IsConfirmed() {
+		return domain.ErrTwoFactorNotEnrolled
+	}
+
+	step, ok := utils.ValidateTOTP(twoFactor.Secret, code, time.Now())
+	if !ok || step <= twoFactor.LastUsedStep {
+		return s.failedAttempt(userID)
+	}
+	used, err := s.userRepo.UseTwoFactorStep(userID, step)
+	if err != nil {
+		return err
+	}
+	if !used {
+		// A concurrent request accepted the same code
+		return domain.ErrInvalidTwoFactorCode
+	}
+	return s.resetAttempts(userID)
+}
+
+// checkAttempts returns domain.ErrTooManyTwoFactorAttempts when the user
+// reached the maximum of invalid codes
+func (s *twoFactorServiceImpl) checkAttempts(userID uint) error {
+	if s.attempts == nil {
+		return nil
+	}
+	value, err := s.attempts.Get(context.Background(), attemptsKey(userID))
+	if err == kv.ErrNotFound {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	count, err := strconv.ParseInt(string(value), 10, 64)
+	if err != nil {
+		return err
+	}
+	if count >= s.maxAttempts {
+		return domain.ErrTooManyTwoFactorAttempts
+	}
+	return nil
+}
+
+// failedAttempt counts an invalid code of the user, the counter expiring
+// lockout after the first one
+func (s *twoFactorServiceImpl) failedAttempt(userID uint) error {
+	if s.attempts == nil {
+		return domain.ErrInvalidTwoFactorCode
+	}
+	count, err := s.attempts.Incr(context.Background(), attemptsKey(userID), s.lockout)
+	if err != nil {
+		return err
+	}
+	if count >= s.maxAttempts {
+		return domain.ErrTooManyTwoFactorAttempts
+	}
+	return domain.ErrInvalidTwoFactorCode
+}
+
+// resetAttempts clears the invalid code counter of the user
+func (s *twoFactorServiceImpl) resetAttempts(userID uint) error {
+	if s.attempts == nil {
+		return nil
+	}
+	return s.attempts.Delete(context.Background(), attemptsKey(userID))
+}
+
+// attemptsKey is the key of the invalid code counter of a user
+func attemptsKey(userID uint) string {
+	return strconv.FormatUint(uint64(userID), 10)
+}
@@ -0,0 +1,19 @@
+package service
+
+import "context"
+
+// UserCache holds cached responses about users, such as the admin user
+// listings. Services changing users invalidate it, so admins do not read
+// stale users until the cached responses expire.
+type UserCache interface {
+	// Invalidate drops every cached response about users
+	Invalidate(ctx context.Context) error
+}
+
+// invalidateUserCache drops the cached responses about users, when cached.
+// Failures are ignored, the responses still expire after the cache TTL.
+func invalidateUserCache(cache UserCache) {
+	if cache != nil {
+		_ = cache.Invalidate(context.Background())
+	}
+}
@@ -0,0 +1,128 @@
+package service
+
+import (
+	"context"
+	"gojwt-rest-api/internal/domain"
+	"gojwt-rest-api/internal/repository"
+	"time"
+)
+
+// RecentAuditEventsLimit is the number of audit events included in user details
+const RecentAuditEventsLimit = 10
+
+// UserDetailsService aggregates account information of a user for admins
+type UserDetailsService interface {
+	// WithContext returns the service reading users and audit logs with ctx,
+	// scoping them to the organization of a tenant request
+	WithContext(ctx context.Context) UserDetailsService
+	// GetUserDetails returns the details of a user, with their recent audit
+	// events when withAudit is set
+	GetUserDetails(id uint, withAudit bool) (*domain.UserDetailsResponse, error)
+}
+
+// userDetailsServiceImpl is the implementation of UserDetailsService
+type userDetailsServiceImpl struct {
+	userRepo     repository.UserRepository
+	tokenRepo    repository.TokenRepository
+	ssoRepo      repository.SSOConnectionRepository
+	auditService AuditService
+}
+
+// NewUserDetailsService creates a new user details service
+func NewUserDetailsService(
+	userRepo repository.UserRepository,
+	tokenRepo repository.TokenRepository,
+	ssoRepo repository.SSOConnectionRepository,
+	auditService AuditService,
+) UserDetailsService {
+	return &userDetailsServiceImpl{
+		userRepo:     userRepo,
+		tokenRepo:    tokenRepo,
+		ssoRepo:      ssoRepo,
+		auditService: auditService,
+	}
+}
+
+// WithContext returns the service reading users and audit logs with ctx
+func (s *userDetailsServiceImpl) WithContext(ctx context.Context) UserDetailsService {
+	scoped := *s
+	scoped.userRepo = s.userRepo.WithContext(ctx)
+	scoped.auditService = s.auditService.WithContext(ctx)
+	return &scoped
+}
+
+// GetUserDetails returns the user with their sessions, sign-in methods and,
+// when withAudit is set, recent audit events
+func (s *userDetailsServiceImpl) GetUserDetails(id uint, withAudit bool) (*domain.UserDetailsResponse, error) {
+	user, err := s.userRepo.FindByID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	sessions, err := s.tokenRepo.CountActiveRefreshTokens(id, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	lastLogin, err := s.tokenRepo.FindLastSessionStart(id)
+	if err != nil {
+		return nil, err
+	}
+	if lastLogin == nil {
+		// Expired sessions are cleaned up, fall back to the first login
+		lastLogin = user.FirstLoginAt
+	}
+
+	twoFactor, err := s.userRepo.FindTwoFactor(id)
+	if err != nil && err != domain.ErrTwoFactorNotEnrolled {
+		return nil, err
+	}
+
+	providers, err := s.linkedProviders(user)
+	if err != nil {
+		return nil, err
+	}
+
+	details := &domain.UserDetailsResponse{
+		User:             user.ToResponse(),
+		ActiveSessions:   sessions,
+		LastLoginAt:      lastLogin,
+		TwoFactorEnabled: twoFactor != nil && twoFactor.IsConfirmed(),
+		LinkedProviders:  providers,
+	}
+	if !withAudit {
+		return details, nil
+	}
+
+	logs, _, err := s.auditService.List(
+		&domain.AuditLogFilter{UserID: id},
+		&domain.PaginationQuery{Page: 1, PageSize: RecentAuditEventsLimit},
+	)
+	if err != nil {
+		return nil, err
+	}
+	details.RecentAuditEvents = make([]*domain.AuditLogResponse, len(logs))
+	for i, log := range logs {
+		details.RecentAuditEvents[i] = log.ToResponse()
+	}
+	return details, nil
+}
+
+// linkedProviders lists how the user signs in: with their password and,
+// when their email domain is mapped to one, through an SSO connection
+func (s *userDetailsServiceImpl) linkedProviders(user *domain.User) ([]*domain.LinkedProviderResponse, error) {
+	providers := []*domain.LinkedProviderResponse{{Method: domain.LoginMethodPassword}}
+
+	conn, err := s.ssoRepo.FindByDomain(emailDomain(user.Email))
+	if err == domain.ErrSSOConnectionNotFound {
+		return providers, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return append(providers, &domain.LinkedProviderResponse{
+		Method:       domain.LoginMethodSSO,
+		ConnectionID: conn.ID,
+		Connection:   conn.Name,
+		Protocol:     conn.Protocol,
+	}), nil
+}
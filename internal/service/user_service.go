@@ -1,34 +1,266 @@
 package service
 
 import (
+	"context"
 	"gojwt-rest-api/internal/domain"
+	"gojwt-rest-api/internal/events"
 	"gojwt-rest-api/internal/repository"
+	"gojwt-rest-api/internal/tenant"
 	"gojwt-rest-api/internal/utils"
+	"gojwt-rest-api/pkg/logger"
+	"strconv"
+	"strings"
 	"time"
+
+	"golang.org/x/sync/singleflight"
 )
 
 // UserService defines the interface for user business logic
 type UserService interface {
+	// WithContext returns the service executing its statements with ctx,
+	// scoping them to the organization of a tenant request
+	WithContext(ctx context.Context) UserService
+	// Register creates a user. Registering a registered email publishes a
+	// UserRegistrationAttempted event for its owner.
 	Register(req *domain.RegisterRequest) (*domain.User, error)
+	// EmailAvailable reports whether email can be used to register
+	EmailAvailable(email string) (bool, error)
 	Login(req *domain.LoginRequest) (*domain.LoginResponse, error)
+	// IssueSession starts a session of the client application clientID,
+	// empty for none, for a user authenticated by other means than a password
+	IssueSession(user *domain.User, clientID string) (*domain.LoginResponse, error)
 	RefreshToken(req *domain.RefreshTokenRequest) (*domain.RefreshTokenResponse, error)
 	Logout(userID uint, req *domain.LogoutRequest) error
+	// Reauthenticate confirms the identity of a logged-in user with their password
+	// or two-factor code and issues a short-lived access token with a fresh
+	// auth_time, for the session of the client application clientID
+	Reauthenticate(userID uint, clientID string, req *domain.ReauthenticateRequest) (*domain.ReauthenticateResponse, error)
+	// AccessRevoked reports whether all access tokens of the user were revoked,
+	// e.g. because the user was deleted
+	AccessRevoked(userID uint) (bool, error)
+	// SessionRevoked reports whether an access token of the user carrying
+	// epoch was revoked by a later session epoch bump, e.g. an organization
+	// wide forced logout
+	SessionRevoked(userID uint, epoch uint) (bool, error)
+	// PermissionsChanged reports whether the roles or admin scopes of the user
+	// changed since access tokens carrying version were issued
+	PermissionsChanged(userID uint, version uint) (bool, error)
 	GetUserByID(id uint) (*domain.User, error)
 	GetAllUsers(pagination *domain.PaginationQuery) ([]*domain.User, int64, error)
-	UpdateUser(id uint, req *domain.UpdateUserRequest) (*domain.User, error)
-	DeleteUser(id uint) error
+	// SuggestUsers returns up to MaxUserSuggestions users whose name or email starts with query
+	SuggestUsers(query string) ([]*domain.UserSuggestion, error)
+	// UpdateUser updates a user on behalf of actorID. Changing the admin role
+	// requires an admin actor and must leave at least one active admin.
+	UpdateUser(actorID, id uint, req *domain.UpdateUserRequest) (*domain.User, error)
+	// DeleteUser deletes a user on behalf of actorID, revoking their tokens
+	// and credentials. Actors cannot delete themselves, and the last active
+	// admin cannot be deleted.
+	DeleteUser(actorID, id uint) error
+	// UpdateAdminScopes replaces the delegated admin scopes of a user
+	UpdateAdminScopes(id uint, scopes []string) (*domain.User, error)
 	// Self-service methods
 	ChangePassword(userID uint, req *domain.ChangePasswordRequest) error
 	UpdateOwnProfile(userID uint, req *domain.UpdateProfileRequest) (*domain.User, error)
+	// RevertEmailChange restores the previous email address of the user a
+	// revert token was emailed for, ends the user's sessions and replaces
+	// their password, returning a password reset token to set a new one
+	RevertEmailChange(token string) (*domain.User, string, error)
+	// RevokeClientSessions revokes the sessions of a client application on
+	// behalf of actorID, of userID or of every user when nil: their refresh
+	// tokens, and the access tokens already issued through a cutoff checked
+	// by ClientSessionRevoked
+	RevokeClientSessions(actorID uint, userID *uint, clientID string) (*domain.ClientSessionRevocation, error)
+	// ClientSessionRevoked reports whether an access token of the user issued
+	// at issuedAt for a session of clientID was revoked by RevokeClientSessions
+	ClientSessionRevoked(userID uint, clientID string, issuedAt time.Time) (bool, error)
 }
 
 // userServiceImpl is the implementation of UserService
 type userServiceImpl struct {
-	userRepo          repository.UserRepository
-	tokenRepo         repository.TokenRepository
-	jwtSecret         string
-	accessTokenExpiry time.Duration
+	userRepo           repository.UserRepository
+	tokenRepo          repository.TokenRepository
+	keys               *utils.KeySet
+	accessTokenExpiry  time.Duration
 	refreshTokenExpiry time.Duration
+	// userLookups coalesces concurrent lookups of the same user ID, across
+	// the scopes of WithContext; lookupScope keeps the lookups of different
+	// organizations apart
+	userLookups     *singleflight.Group
+	lookupScope     string
+	passwordLimiter *utils.PasswordLimiter
+	events          events.Publisher
+	quota           QuotaService
+	claimsEnrichers []ClaimsEnricher
+	settings        SettingsService
+	twoFactor       TwoFactorService
+	audit           AuditService
+	profileHistory  ProfileHistoryService
+	// oneTimeTokens issues email change revert tokens, valid for emailRevertWindow
+	oneTimeTokens     OneTimeTokenService
+	emailRevertWindow time.Duration
+	accountLock       AccountLockService
+	accessSchedules   AccessScheduleService
+	onboarding        OnboardingTracker
+	// notifyConcurrentLogin publishes UserConcurrentLogin events
+	notifyConcurrentLogin bool
+	// selfRegistrationDisabled only lets users register with an invitation
+	// verified by invitations
+	selfRegistrationDisabled bool
+	invitations              InvitationVerifier
+	// clients are the known client applications, sessions of other clients
+	// are refused when set
+	clients          map[string]*domain.ClientApplication
+	clientIDRequired bool
+	// logger reports failures that do not fail the request
+	logger *logger.Logger
+	// userCache is invalidated when users change
+	userCache UserCache
+}
+
+// InvitationVerifier checks that an invitation token was sent to an email
+type InvitationVerifier interface {
+	VerifyInvitation(token, email string) error
+}
+
+// MaxUserSuggestions is the maximum number of users returned by SuggestUsers
+const MaxUserSuggestions = 10
+
+// TwoFactorTokenTTL is how long restricted tokens issued during login are valid
+const TwoFactorTokenTTL = 10 * time.Minute
+
+// RevertPasswordResetTTL is how long the password reset tokens returned by
+// RevertEmailChange are valid
+const RevertPasswordResetTTL = 15 * time.Minute
+
+// ReauthTokenTTL is how long access tokens issued by Reauthenticate are valid
+const ReauthTokenTTL = 5 * time.Minute
+
+// UserServiceOption configures optional behaviour of the user service
+type UserServiceOption func(*userServiceImpl)
+
+// WithPasswordLimiter bounds concurrent password verification with the given limiter
+func WithPasswordLimiter(limiter *utils.PasswordLimiter) UserServiceOption {
+	return func(s *userServiceImpl) {
+		s.passwordLimiter = limiter
+	}
+}
+
+// WithQuotaService enforces organization session limits on login and frees
+// the seat of deleted members
+func WithQuotaService(quota QuotaService) UserServiceOption {
+	return func(s *userServiceImpl) {
+		s.quota = quota
+	}
+}
+
+// WithClaimsEnricher adds the enricher's claims to every issued access token.
+// Enrichers apply in the order they are given, later ones overriding the
+// claims of earlier ones.
+func WithClaimsEnricher(enricher ClaimsEnricher) UserServiceOption {
+	return func(s *userServiceImpl) {
+		s.claimsEnrichers = append(s.claimsEnrichers, enricher)
+	}
+}
+
+// WithSettingsService applies organization setting overrides (token lifetimes,
+// password policy, allowed email domains) instead of the global configuration only
+func WithSettingsService(settings SettingsService) UserServiceOption {
+	return func(s *userServiceImpl) {
+		s.settings = settings
+	}
+}
+
+// WithTwoFactorService requires a second factor on password login from enrolled
+// users and from users the two-factor policy applies to
+func WithTwoFactorService(twoFactor TwoFactorService) UserServiceOption {
+	return func(s *userServiceImpl) {
+		s.twoFactor = twoFactor
+	}
+}
+
+// WithAuditService records security relevant account changes, such as email changes
+func WithAuditService(audit AuditService) UserServiceOption {
+	return func(s *userServiceImpl) {
+		s.audit = audit
+	}
+}
+
+// WithProfileHistory records a version of the profile of users whenever
+// their name or email changes
+func WithProfileHistory(history ProfileHistoryService) UserServiceOption {
+	return func(s *userServiceImpl) {
+		s.profileHistory = history
+	}
+}
+
+// WithEmailChangeRevert lets the previous address of a changed email revert
+// the change for window. The previous address stays reserved meanwhile.
+func WithEmailChangeRevert(tokens OneTimeTokenService, window time.Duration) UserServiceOption {
+	return func(s *userServiceImpl) {
+		s.oneTimeTokens = tokens
+		s.emailRevertWindow = window
+	}
+}
+
+// WithAccountLock locks accounts on the risk signals of logins and token
+// refreshes, and refuses locked accounts
+func WithAccountLock(accountLock AccountLockService) UserServiceOption {
+	return func(s *userServiceImpl) {
+		s.accountLock = accountLock
+	}
+}
+
+// WithAccessSchedule refuses logins and token refreshes outside the access
+// schedules applying to the user
+func WithAccessSchedule(accessSchedules AccessScheduleService) UserServiceOption {
+	return func(s *userServiceImpl) {
+		s.accessSchedules = accessSchedules
+	}
+}
+
+// WithConcurrentLoginNotification publishes an event whenever a user logs in
+// while holding other sessions, for webhooks and the session event streams
+func WithConcurrentLoginNotification() UserServiceOption {
+	return func(s *userServiceImpl) {
+		s.notifyConcurrentLogin = true
+	}
+}
+
+// WithOnboardingTracker records the onboarding steps completed through the
+// user service: saving the own profile and verifying an email
+func WithOnboardingTracker(onboarding OnboardingTracker) UserServiceOption {
+	return func(s *userServiceImpl) {
+		s.onboarding = onboarding
+	}
+}
+
+// WithSelfRegistrationDisabled rejects registrations without an invitation
+// verified by invitations. Without invitations, which are only available in
+// multi-tenant mode, every registration is rejected.
+func WithSelfRegistrationDisabled(invitations InvitationVerifier) UserServiceOption {
+	return func(s *userServiceImpl) {
+		s.selfRegistrationDisabled = true
+		s.invitations = invitations
+	}
+}
+
+// WithSigningKeys signs access tokens with the signing key of keys instead of
+// the secret, so that they follow the key set after a signing key compromise
+// (see SigningKeyService)
+func WithSigningKeys(keys *utils.KeySet) UserServiceOption {
+	return func(s *userServiceImpl) {
+		s.keys = keys
+	}
+}
+
+// WithClientApplications scopes sessions to the client applications, by ID,
+// refusing logins from other clients and applying the clients' token
+// lifetimes. With required, logins have to name their client.
+func WithClientApplications(clients map[string]*domain.ClientApplication, required bool) UserServiceOption {
+	return func(s *userServiceImpl) {
+		s.clients = clients
+		s.clientIDRequired = required
+	}
 }
 
 // NewUserService creates a new user service
@@ -38,18 +270,89 @@ func NewUserService(
 	jwtSecret string,
 	accessTokenExpiry time.Duration,
 	refreshTokenExpiry time.Duration,
+	opts ...UserServiceOption,
 ) UserService {
-	return &userServiceImpl{
+	s := &userServiceImpl{
 		userRepo:           userRepo,
 		tokenRepo:          tokenRepo,
-		jwtSecret:          jwtSecret,
+		keys:               utils.NewKeySet(jwtSecret),
 		accessTokenExpiry:  accessTokenExpiry,
 		refreshTokenExpiry: refreshTokenExpiry,
+		userLookups:        new(singleflight.Group),
+		logger:             logger.New(),
+	}
+	for _, opt := range opts {
+		opt(s)
 	}
+	return s
+}
+
+// WithContext returns the service reading and writing users and audit logs
+// with ctx, which scopes them to the organization of a tenant request (see
+// middleware.TenantMiddleware)
+func (s *userServiceImpl) WithContext(ctx context.Context) UserService {
+	scoped := *s
+	scoped.userRepo = s.userRepo.WithContext(ctx)
+	// Lookups are shared with the other scopes of the same organization only
+	if orgID, ok := tenant.OrganizationFromContext(ctx); ok {
+		scoped.lookupScope = "org:" + strconv.FormatUint(uint64(orgID), 10) + ":"
+	}
+	if s.audit != nil {
+		scoped.audit = s.audit.WithContext(ctx)
+	}
+	return &scoped
+}
+
+// WithLogger reports failures that do not fail the request, such as the
+// first login bookkeeping, to appLogger
+func WithLogger(appLogger *logger.Logger) UserServiceOption {
+	return func(s *userServiceImpl) {
+		s.logger = appLogger
+	}
+}
+
+// WithEventPublisher publishes domain events (e.g. first login) to publisher
+func WithEventPublisher(publisher events.Publisher) UserServiceOption {
+	return func(s *userServiceImpl) {
+		s.events = publisher
+	}
+}
+
+// WithUserCache invalidates cache when users change
+func WithUserCache(cache UserCache) UserServiceOption {
+	return func(s *userServiceImpl) {
+		s.userCache = cache
+	}
+}
+
+// checkPassword verifies a password, going through the limiter when configured
+func (s *userServiceImpl) checkPassword(hashedPassword, password string) error {
+	if s.passwordLimiter != nil {
+		return s.passwordLimiter.CheckPassword(hashedPassword, password)
+	}
+	return utils.CheckPassword(hashedPassword, password)
 }
 
 // Register registers a new user
 func (s *userServiceImpl) Register(req *domain.RegisterRequest) (*domain.User, error) {
+	if err := s.checkRegistrationAllowed(req); err != nil {
+		return nil, err
+	}
+	settings, err := s.settingsFor(nil)
+	if err != nil {
+		return nil, err
+	}
+	if err := settings.PasswordPolicy.Check(req.Password); err != nil {
+		return nil, err
+	}
+
+	// Hash the password first, so that registering an existing email takes
+	// as long as a registration
+	hashedPassword, err := utils.HashPassword(req.Password)
+	if err != nil {
+		return nil, domain.ErrFailedToHashPassword
+	}
+
 	// Check if user already exists
 	existingUser, err := s.userRepo.FindByEmail(req.Email)
 	if err != nil && err != domain.ErrUserNotFound {
@@ -57,31 +360,79 @@ func (s *userServiceImpl) Register(req *domain.RegisterRequest) (*domain.User, e
 		return nil, err
 	}
 	if existingUser != nil {
+		if s.events != nil {
+			s.events.Publish(events.UserRegistrationAttempted, &events.UserRegistrationAttemptedData{
+				UserID:      existingUser.ID,
+				Name:        existingUser.Name,
+				Email:       existingUser.Email,
+				AttemptedAt: time.Now(),
+			})
+		}
 		return nil, domain.ErrUserAlreadyExists
 	}
-
-	// Hash password
-	hashedPassword, err := utils.HashPassword(req.Password)
-	if err != nil {
-		return nil, domain.ErrFailedToHashPassword
+	if reserved, err := s.emailReserved(req.Email, 0); err != nil {
+		return nil, err
+	} else if reserved {
+		return nil, domain.ErrUserAlreadyExists
 	}
 
 	// Create user
 	user := &domain.User{
-		Name:     req.Name,
 		Email:    req.Email,
 		Password: hashedPassword,
 	}
+	if req.FirstName != "" {
+		user.UpdateName(req.FirstName, &req.LastName, &req.DisplayName)
+	} else {
+		user.SetName(req.Name)
+	}
 
 	if err := s.userRepo.Create(user); err != nil {
+		if err == domain.ErrUserAlreadyExists {
+			return nil, err
+		}
 		return nil, domain.ErrFailedToCreateUser
 	}
+	invalidateUserCache(s.userCache)
 
 	return user, nil
 }
 
+// checkRegistrationAllowed rejects registrations without an invitation when
+// self-registration is disabled
+func (s *userServiceImpl) checkRegistrationAllowed(req *domain.RegisterRequest) error {
+	if !s.selfRegistrationDisabled {
+		return nil
+	}
+	if req.InvitationToken == "" || s.invitations == nil {
+		return domain.ErrSelfRegistrationDisabled
+	}
+	return s.invitations.VerifyInvitation(req.InvitationToken, req.Email)
+}
+
+// EmailAvailable reports whether email is neither registered nor reserved
+// for reverting an email change
+func (s *userServiceImpl) EmailAvailable(email string) (bool, error) {
+	existingUser, err := s.userRepo.FindByEmail(email)
+	if err != nil && err != domain.ErrUserNotFound {
+		return false, err
+	}
+	if existingUser != nil {
+		return false, nil
+	}
+	reserved, err := s.emailReserved(email, 0)
+	if err != nil {
+		return false, err
+	}
+	return !reserved, nil
+}
+
 // Login authenticates a user and returns JWT tokens
 func (s *userServiceImpl) Login(req *domain.LoginRequest) (*domain.LoginResponse, error) {
+	if _, err := s.clientFor(req.ClientID); err != nil {
+		return nil, err
+	}
+
 	// Find user by email
 	user, err := s.userRepo.FindByEmail(req.Email)
 	if err != nil {
@@ -89,17 +440,133 @@ func (s *userServiceImpl) Login(req *domain.LoginRequest) (*domain.LoginResponse
 	}
 
 	// Check password
-	if err := utils.CheckPassword(user.Password, req.Password); err != nil {
+	if err := s.checkPassword(user.Password, req.Password); err != nil {
+		if err == domain.ErrPasswordCheckBusy {
+			return nil, err
+		}
 		return nil, domain.ErrInvalidCredentials
 	}
 
+	if s.accountLock != nil && user.IsActive() {
+		if err := s.accountLock.CheckLogin(user, req.Country); err != nil {
+			return nil, err
+		}
+	}
+	// Refuse before a second factor is asked for
+	if err := s.checkAccessSchedule(user, AccessActionLogin); err != nil {
+		return nil, err
+	}
+
+	if s.twoFactor != nil {
+		return s.startTwoFactorLogin(user, req.ClientID)
+	}
+	return s.IssueSession(user, req.ClientID)
+}
+
+// startTwoFactorLogin issues a restricted token when the user has to provide a
+// second factor, or has to enroll one first, and a session otherwise
+func (s *userServiceImpl) startTwoFactorLogin(user *domain.User, clientID string) (*domain.LoginResponse, error) {
+	if !user.IsActive() {
+		return nil, domain.ErrUserDeactivated
+	}
+	if user.IsLocked() {
+		return nil, domain.ErrAccountLocked
+	}
+
+	enrolled, err := s.twoFactor.IsEnrolled(user.ID)
+	if err != nil {
+		return nil, err
+	}
+	if enrolled {
+		return s.issueRestrictedToken(user, clientID, domain.ScopeTwoFactorVerify, domain.LoginStatusTwoFactorRequired)
+	}
+
+	required, err := s.twoFactor.Required(user)
+	if err != nil {
+		return nil, err
+	}
+	if required {
+		return s.issueRestrictedToken(user, clientID, domain.ScopeTwoFactorEnroll, domain.LoginStatusTwoFactorEnrollmentRequired)
+	}
+	return s.IssueSession(user, clientID)
+}
+
+// issueRestrictedToken issues a short-lived access token limited to the
+// scope, without refresh token. It carries the client application, so that
+// the session is started for it once the second factor is provided.
+func (s *userServiceImpl) issueRestrictedToken(user *domain.User, clientID, scope, status string) (*domain.LoginResponse, error) {
+	token, err := s.keys.GenerateToken(user.ID, user.Email, TwoFactorTokenTTL,
+		utils.WithScope(scope),
+		utils.WithSessionEpoch(user.SessionEpoch),
+		utils.WithClientID(clientID),
+	)
+	if err != nil {
+		return nil, domain.ErrFailedToGenerateToken
+	}
+
+	return &domain.LoginResponse{
+		User:        user.ToResponse(),
+		Status:      status,
+		AccessToken: token,
+		ExpiresIn:   int64(TwoFactorTokenTTL.Seconds()),
+		TokenType:   "Bearer",
+		Scope:       scope,
+	}, nil
+}
+
+// IssueSession logs in a user authenticated by other means than a password, such
+// as a verified SSO assertion, and returns JWT tokens
+func (s *userServiceImpl) IssueSession(user *domain.User, clientID string) (*domain.LoginResponse, error) {
+	client, err := s.clientFor(clientID)
+	if err != nil {
+		return nil, err
+	}
+	if !user.IsActive() {
+		return nil, domain.ErrUserDeactivated
+	}
+	if user.IsLocked() {
+		return nil, domain.ErrAccountLocked
+	}
+	if err := s.checkAccessSchedule(user, AccessActionLogin); err != nil {
+		return nil, err
+	}
+
+	// Enforce the organization's session limit
+	if s.quota != nil && user.OrganizationID != nil {
+		if err := s.quota.CheckSessionQuota(*user.OrganizationID); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := s.concurrentLogin(user); err != nil {
+		return nil, err
+	}
+
+	// Track first login for onboarding flows, without failing the login
+	if user.FirstLoginAt == nil {
+		if err := s.markFirstLogin(user); err != nil {
+			s.logger.Errorf("Failed to record first login of user %d: %v", user.ID, err)
+		}
+	}
+
+	settings, err := s.settingsFor(user.OrganizationID)
+	if err != nil {
+		return nil, err
+	}
+
 	// Generate JWT token pair
-	tokenPair, tokenFamily, err := utils.GenerateTokenPair(
+	tokenOpts, err := s.tokenOptions(user)
+	if err != nil {
+		return nil, domain.ErrFailedToGenerateToken
+	}
+	tokenOpts = append(tokenOpts, utils.WithAuthTime(time.Now()), utils.WithClientID(clientID))
+	accessTTL, refreshTTL := client.TokenLifetimes(settings)
+	tokenPair, tokenFamily, err := s.keys.GenerateTokenPair(
 		user.ID,
 		user.Email,
-		s.jwtSecret,
-		s.accessTokenExpiry,
-		s.refreshTokenExpiry,
+		accessTTL,
+		refreshTTL,
+		tokenOpts...,
 	)
 	if err != nil {
 		return nil, domain.ErrFailedToGenerateToken
@@ -110,13 +577,22 @@ func (s *userServiceImpl) Login(req *domain.LoginRequest) (*domain.LoginResponse
 		UserID:      user.ID,
 		Token:       tokenPair.RefreshToken,
 		TokenFamily: tokenFamily,
-		ExpiresAt:   time.Now().Add(s.refreshTokenExpiry),
+		ClientID:    clientID,
+		ExpiresAt:   time.Now().Add(refreshTTL),
 	}
 
 	if err := s.tokenRepo.CreateRefreshToken(refreshToken); err != nil {
 		return nil, domain.ErrFailedToCreateRefreshToken
 	}
 
+	// Track activity for the inactivity policy
+	now := time.Now()
+	if err := s.userRepo.RecordLogin(user.ID, now); err != nil {
+		return nil, err
+	}
+	user.LastLoginAt = &now
+	user.InactivityWarnedAt = nil
+
 	response := &domain.LoginResponse{
 		User:         user.ToResponse(),
 		AccessToken:  tokenPair.AccessToken,
@@ -128,6 +604,46 @@ func (s *userServiceImpl) Login(req *domain.LoginRequest) (*domain.LoginResponse
 	return response, nil
 }
 
+// concurrentLogin logs out the other sessions of a user logging in, when the
+// user prefers so, and notifies them of the login. It runs before the new
+// session is stored.
+func (s *userServiceImpl) concurrentLogin(user *domain.User) error {
+	notify := s.notifyConcurrentLogin && s.events != nil
+	if !notify && !user.LogoutOtherSessionsOnLogin {
+		return nil
+	}
+	now := time.Now()
+	sessions, err := s.tokenRepo.CountActiveRefreshTokens(user.ID, now)
+	if err != nil {
+		return err
+	}
+	if sessions == 0 {
+		return nil
+	}
+
+	if user.LogoutOtherSessionsOnLogin {
+		if err := s.tokenRepo.RevokeAllUserRefreshTokens(user.ID); err != nil {
+			return err
+		}
+		// Invalidate the access tokens of the other sessions as well; the new
+		// session is issued with the bumped epoch
+		user.SessionEpoch++
+		if err := s.userRepo.Update(user); err != nil {
+			return domain.ErrFailedToUpdateUser
+		}
+	}
+	if notify {
+		s.events.Publish(events.UserConcurrentLogin, &events.UserConcurrentLoginData{
+			UserID:               user.ID,
+			Email:                user.Email,
+			OtherSessions:        sessions,
+			OtherSessionsRevoked: user.LogoutOtherSessionsOnLogin,
+			LoginAt:              now,
+		})
+	}
+	return nil
+}
+
 // RefreshToken generates a new access token using a refresh token
 func (s *userServiceImpl) RefreshToken(req *domain.RefreshTokenRequest) (*domain.RefreshTokenResponse, error) {
 	// Find refresh token in database
@@ -140,7 +656,7 @@ func (s *userServiceImpl) RefreshToken(req *domain.RefreshTokenRequest) (*domain
 	if !storedToken.IsValid() {
 		if storedToken.IsRevoked {
 			// Token reuse detected - revoke entire token family
-			_ = s.tokenRepo.RevokeTokenFamily(storedToken.TokenFamily)
+			s.tokenReused(storedToken)
 			return nil, domain.ErrTokenReused
 		}
 		return nil, domain.ErrTokenExpired
@@ -151,41 +667,60 @@ func (s *userServiceImpl) RefreshToken(req *domain.RefreshTokenRequest) (*domain
 	if err != nil {
 		return nil, domain.ErrUserNotFound
 	}
+	if !user.IsActive() {
+		return nil, domain.ErrUserDeactivated
+	}
+	if user.IsLocked() {
+		return nil, domain.ErrAccountLocked
+	}
+	if err := s.checkAccessSchedule(user, AccessActionRefresh); err != nil {
+		return nil, err
+	}
+
+	settings, err := s.settingsFor(user.OrganizationID)
+	if err != nil {
+		return nil, err
+	}
 
-	// Generate new token pair (token rotation)
-	newTokenPair, _, err := utils.GenerateTokenPair(
+	// Generate new token pair (token rotation). The session keeps its client
+	// application, whose lifetimes apply as long as it is configured.
+	tokenOpts, err := s.tokenOptions(user)
+	if err != nil {
+		return nil, domain.ErrFailedToGenerateToken
+	}
+	tokenOpts = append(tokenOpts, utils.WithClientID(storedToken.ClientID))
+	accessTTL, refreshTTL := s.clients[storedToken.ClientID].TokenLifetimes(settings)
+	newTokenPair, _, err := s.keys.GenerateTokenPair(
 		user.ID,
 		user.Email,
-		s.jwtSecret,
-		s.accessTokenExpiry,
-		s.refreshTokenExpiry,
+		accessTTL,
+		refreshTTL,
+		tokenOpts...,
 	)
 	if err != nil {
 		return nil, domain.ErrFailedToGenerateToken
 	}
 
-	// Revoke old refresh token
+	// Replace the old refresh token with a new one of the same family (for rotation tracking)
 	now := time.Now()
-	storedToken.IsRevoked = true
-	storedToken.RevokedAt = &now
-	replacedBy := newTokenPair.RefreshToken
-	storedToken.ReplacedBy = &replacedBy
-
-	if err := s.tokenRepo.UpdateRefreshToken(storedToken); err != nil {
-		return nil, err
-	}
-
-	// Store new refresh token with same family (for rotation tracking)
 	newRefreshToken := &domain.RefreshToken{
 		UserID:      user.ID,
 		Token:       newTokenPair.RefreshToken,
-		TokenFamily: storedToken.TokenFamily, // Same family for rotation tracking
-		ExpiresAt:   time.Now().Add(s.refreshTokenExpiry),
+		TokenFamily: storedToken.TokenFamily,
+		ClientID:    storedToken.ClientID,
+		ExpiresAt:   now.Add(refreshTTL),
 	}
 
-	if err := s.tokenRepo.CreateRefreshToken(newRefreshToken); err != nil {
+	rotated, err := s.tokenRepo.RotateRefreshToken(storedToken, newRefreshToken, now)
+	if err != nil {
 		return nil, domain.ErrFailedToCreateRefreshToken
 	}
+	if !rotated {
+		// A concurrent request used the same token first: treat it like any
+		// other reuse, so exactly one of them gets new tokens
+		s.tokenReused(storedToken)
+		return nil, domain.ErrTokenReused
+	}
 
 	response := &domain.RefreshTokenResponse{
 		AccessToken:  newTokenPair.AccessToken,
@@ -197,6 +732,80 @@ func (s *userServiceImpl) RefreshToken(req *domain.RefreshTokenRequest) (*domain
 	return response, nil
 }
 
+// clientFor returns the client application a session is started from, nil
+// when it names none. Without configured client applications, any client ID
+// is recorded as is.
+func (s *userServiceImpl) clientFor(clientID string) (*domain.ClientApplication, error) {
+	if clientID == "" {
+		if s.clientIDRequired {
+			return nil, domain.ErrClientIDRequired
+		}
+		return nil, nil
+	}
+	if len(s.clients) == 0 {
+		return nil, nil
+	}
+	client, ok := s.clients[clientID]
+	if !ok {
+		return nil, domain.ErrUnknownClient
+	}
+	return client, nil
+}
+
+// RevokeClientSessions revokes the refresh and access tokens of a client
+// application's sessions
+func (s *userServiceImpl) RevokeClientSessions(actorID uint, userID *uint, clientID string) (*domain.ClientSessionRevocation, error) {
+	// Token issue times have a precision of one second
+	now := time.Now().Truncate(time.Second)
+	err := s.tokenRepo.AddClientSessionCutoff(&domain.ClientSessionCutoff{ClientID: clientID, UserID: userID, RevokedAt: now})
+	if err != nil {
+		return nil, err
+	}
+	revoked, err := s.tokenRepo.RevokeClientRefreshTokens(clientID, userID, now)
+	if err != nil {
+		return nil, err
+	}
+
+	if s.audit != nil {
+		err := s.audit.Record(&domain.AuditLog{
+			Action:  domain.AuditClientSessionsRevoked,
+			ActorID: &actorID,
+			UserID:  userID,
+		}, map[string]interface{}{
+			"client_id":              clientID,
+			"refresh_tokens_revoked": revoked,
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return &domain.ClientSessionRevocation{
+		ClientID:      clientID,
+		UserID:        userID,
+		RefreshTokens: revoked,
+		RevokedAt:     now,
+	}, nil
+}
+
+// checkAccessSchedule refuses the action outside the access schedules of the
+// user, for active users only so that deactivated and locked users keep
+// getting their own errors
+func (s *userServiceImpl) checkAccessSchedule(user *domain.User, action string) error {
+	if s.accessSchedules == nil || !user.IsActive() || user.IsLocked() {
+		return nil
+	}
+	return s.accessSchedules.Check(user, action)
+}
+
+// tokenReused revokes the family of a reused refresh token and reports the
+// reuse as a risk signal. Failures are ignored, the refresh fails either way.
+func (s *userServiceImpl) tokenReused(token *domain.RefreshToken) {
+	_ = s.tokenRepo.RevokeTokenFamily(token.TokenFamily)
+	if s.accountLock != nil {
+		_ = s.accountLock.TokenReused(token.UserID)
+	}
+}
+
 // Logout revokes refresh token and blacklists access token
 func (s *userServiceImpl) Logout(userID uint, req *domain.LogoutRequest) error {
 	// Revoke refresh token if provided
@@ -216,13 +825,179 @@ func (s *userServiceImpl) Logout(userID uint, req *domain.LogoutRequest) error {
 	return nil
 }
 
-// GetUserByID retrieves a user by ID
+// Reauthenticate checks the password, or the two-factor code when given, of a
+// logged-in user. The returned access token has no refresh token: it is only
+// meant for the sensitive actions that require a recent authentication. It
+// carries the client of the presenting token, so revoking the sessions of
+// that client revokes it too.
+func (s *userServiceImpl) Reauthenticate(userID uint, clientID string, req *domain.ReauthenticateRequest) (*domain.ReauthenticateResponse, error) {
+	user, err := s.userRepo.FindByID(userID)
+	if err != nil {
+		return nil, err
+	}
+	if !user.IsActive() {
+		return nil, domain.ErrUserDeactivated
+	}
+	if user.IsLocked() {
+		return nil, domain.ErrAccountLocked
+	}
+
+	if req.Code != "" {
+		if s.twoFactor == nil {
+			return nil, domain.ErrTwoFactorNotEnrolled
+		}
+		if err := s.twoFactor.Verify(user.ID, req.Code); err != nil {
+			return nil, err
+		}
+	} else if err := s.checkPassword(user.Password, req.Password); err != nil {
+		if err == domain.ErrPasswordCheckBusy {
+			return nil, err
+		}
+		return nil, domain.ErrInvalidCredentials
+	}
+
+	tokenOpts, err := s.tokenOptions(user)
+	if err != nil {
+		return nil, domain.ErrFailedToGenerateToken
+	}
+	authTime := time.Now()
+	token, err := s.keys.GenerateToken(user.ID, user.Email, ReauthTokenTTL,
+		append(tokenOpts, utils.WithAuthTime(authTime), utils.WithClientID(clientID))...)
+	if err != nil {
+		return nil, domain.ErrFailedToGenerateToken
+	}
+
+	return &domain.ReauthenticateResponse{
+		AccessToken: token,
+		ExpiresIn:   int64(ReauthTokenTTL.Seconds()),
+		TokenType:   "Bearer",
+		AuthTime:    authTime.Truncate(time.Second),
+	}, nil
+}
+
+// AccessRevoked reports whether all access tokens of the user were revoked
+func (s *userServiceImpl) AccessRevoked(userID uint) (bool, error) {
+	return s.tokenRepo.IsTokenBlacklisted(domain.UserTokensBlacklistKey(userID))
+}
+
+// SessionRevoked reports whether the user's session epoch moved past epoch.
+// Tokens of deleted users are revoked as well.
+func (s *userServiceImpl) SessionRevoked(userID uint, epoch uint) (bool, error) {
+	versions, err := s.userRepo.FindTokenVersions(userID)
+	if err != nil {
+		if err == domain.ErrUserNotFound {
+			return true, nil
+		}
+		return false, err
+	}
+	return epoch < versions.SessionEpoch, nil
+}
+
+// ClientSessionRevoked reports whether the sessions of the client were revoked
+// for the user after an access token was issued at issuedAt
+func (s *userServiceImpl) ClientSessionRevoked(userID uint, clientID string, issuedAt time.Time) (bool, error) {
+	cutoff, err := s.tokenRepo.FindClientSessionCutoff(clientID, userID)
+	if err != nil || cutoff == nil {
+		return false, err
+	}
+	return !issuedAt.After(*cutoff), nil
+}
+
+// PermissionsChanged reports whether the user's permission version moved past
+// version. It returns domain.ErrUserNotFound for deleted users.
+func (s *userServiceImpl) PermissionsChanged(userID uint, version uint) (bool, error) {
+	versions, err := s.userRepo.FindTokenVersions(userID)
+	if err != nil {
+		return false, err
+	}
+	return version < versions.PermVersion, nil
+}
+
+// settingsFor returns the settings in effect for an organization, the global
+// configuration when no settings service is configured
+func (s *userServiceImpl) settingsFor(orgID *uint) (*domain.TenantSettings, error) {
+	if s.settings == nil {
+		return &domain.TenantSettings{
+			AccessTokenTTL:  s.accessTokenExpiry,
+			RefreshTokenTTL: s.refreshTokenExpiry,
+		}, nil
+	}
+	return s.settings.ForOrganization(orgID)
+}
+
+// checkEmailAllowed checks a new email address of the user against the
+// allowed domains of their organization
+func (s *userServiceImpl) checkEmailAllowed(user *domain.User, email string) error {
+	if user.OrganizationID == nil {
+		return nil
+	}
+	settings, err := s.settingsFor(user.OrganizationID)
+	if err != nil {
+		return err
+	}
+	if !settings.AllowsEmail(email) {
+		return domain.ErrEmailDomainNotAllowed
+	}
+	return nil
+}
+
+// tokenOptions returns the extra claims to embed in the user's access tokens
+func (s *userServiceImpl) tokenOptions(user *domain.User) ([]utils.TokenOption, error) {
+	opts := []utils.TokenOption{
+		utils.WithSessionEpoch(user.SessionEpoch),
+		utils.WithPermissions(user.Roles(), user.Scopes(), user.PermVersion),
+	}
+	for _, enricher := range s.claimsEnrichers {
+		opt, err := enricher.EnrichClaims(user)
+		if err != nil {
+			return nil, err
+		}
+		if opt != nil {
+			opts = append(opts, opt)
+		}
+	}
+	return opts, nil
+}
+
+// markFirstLogin records the user's first login and emits the onboarding event
+func (s *userServiceImpl) markFirstLogin(user *domain.User) error {
+	now := time.Now()
+	first, err := s.userRepo.MarkFirstLogin(user.ID, now)
+	if err != nil {
+		return err
+	}
+	if !first {
+		// A concurrent login already recorded it
+		return nil
+	}
+
+	user.FirstLoginAt = &now
+	if s.events != nil {
+		s.events.Publish(events.UserFirstLogin, &events.UserFirstLoginData{
+			UserID:       user.ID,
+			Email:        user.Email,
+			Name:         user.Name,
+			FirstLoginAt: now,
+		})
+	}
+	return nil
+}
+
+// GetUserByID retrieves a user by ID.
+// Concurrent lookups of the same ID (e.g. admin middleware and handlers under
+// burst traffic) share a single database query, also across the scopes of
+// WithContext of the same organization.
 func (s *userServiceImpl) GetUserByID(id uint) (*domain.User, error) {
-	user, err := s.userRepo.FindByID(id)
+	result, err, _ := s.userLookups.Do(s.lookupScope+strconv.FormatUint(uint64(id), 10), func() (interface{}, error) {
+		return s.userRepo.FindByID(id)
+	})
 	if err != nil {
 		return nil, err
 	}
-	return user, nil
+
+	// Return a copy so callers sharing the result cannot affect each other
+	user := *result.(*domain.User)
+	return &user, nil
 }
 
 // GetAllUsers retrieves all users with pagination
@@ -241,43 +1016,170 @@ func (s *userServiceImpl) GetAllUsers(pagination *domain.PaginationQuery) ([]*do
 	return s.userRepo.FindAll(pagination)
 }
 
+// SuggestUsers looks up users by name or email prefix
+func (s *userServiceImpl) SuggestUsers(query string) ([]*domain.UserSuggestion, error) {
+	query = strings.TrimSpace(query)
+	if query == "" {
+		return []*domain.UserSuggestion{}, nil
+	}
+	return s.userRepo.Suggest(query, MaxUserSuggestions)
+}
+
 // UpdateUser updates a user
-func (s *userServiceImpl) UpdateUser(id uint, req *domain.UpdateUserRequest) (*domain.User, error) {
+func (s *userServiceImpl) UpdateUser(actorID, id uint, req *domain.UpdateUserRequest) (*domain.User, error) {
 	// Find existing user
 	user, err := s.userRepo.FindByID(id)
 	if err != nil {
 		return nil, err
 	}
+	// Delegates must not change admins at all, e.g. their email to take the
+	// account over through a password reset
+	if user.IsAdmin {
+		if err := s.requireAdminActor(actorID); err != nil {
+			return nil, err
+		}
+	}
+	before := domain.SnapshotProfile(user)
 
 	// Check if email is being changed and if it's already taken
+	var change *emailChange
 	if req.Email != "" && req.Email != user.Email {
-		existingUser, err := s.userRepo.FindByEmail(req.Email)
-		if err != nil && err != domain.ErrUserNotFound {
-			// Handle potential database errors
+		if change, err = s.changeEmail(user, req.Email); err != nil {
 			return nil, err
 		}
-		if existingUser != nil {
-			return nil, domain.ErrEmailAlreadyInUse
-		}
-		user.Email = req.Email
 	}
 
 	// Update name if provided
-	if req.Name != "" {
-		user.Name = req.Name
+	if req.FirstName != "" || req.LastName != nil || req.DisplayName != nil {
+		user.UpdateName(req.FirstName, req.LastName, req.DisplayName)
+	} else if req.Name != "" {
+		user.SetName(req.Name)
+	}
+
+	// Promote or demote the user
+	if req.IsAdmin != nil && *req.IsAdmin != user.IsAdmin {
+		if err := s.checkAdminChange(actorID, user); err != nil {
+			return nil, err
+		}
+		user.IsAdmin = *req.IsAdmin
+		user.PermVersion++
 	}
 
 	// Save changes
 	if err := s.userRepo.Update(user); err != nil {
 		return nil, domain.ErrFailedToUpdateUser
 	}
+	invalidateUserCache(s.userCache)
+	if err := s.recordProfileChange(user, before, &actorID, domain.ProfileChangeSourceAdmin); err != nil {
+		return nil, err
+	}
+	if change != nil {
+		if err := s.emailChanged(actorID, user, change); err != nil {
+			return nil, err
+		}
+	}
 
 	return user, nil
 }
 
 // DeleteUser deletes a user
-func (s *userServiceImpl) DeleteUser(id uint) error {
-	return s.userRepo.Delete(id)
+func (s *userServiceImpl) DeleteUser(actorID, id uint) error {
+	if actorID == id {
+		return domain.ErrCannotDeleteSelf
+	}
+
+	user, err := s.userRepo.FindByID(id)
+	if err != nil {
+		return err
+	}
+	if user.IsAdmin {
+		if err := s.checkAdminChange(actorID, user); err != nil {
+			return err
+		}
+	}
+
+	// Access tokens are stateless, keep them blacklisted until the last one expired
+	settings, err := s.settingsFor(user.OrganizationID)
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	err = s.userRepo.DeleteWithCleanup(&domain.UserDeletion{
+		UserID:                   id,
+		AccessTokensRevokedUntil: now.Add(max(settings.AccessTokenTTL, TwoFactorTokenTTL)),
+		Audit: &domain.AuditLog{
+			Action:         domain.AuditUserDeleted,
+			ActorID:        &actorID,
+			OrganizationID: user.OrganizationID,
+		},
+	})
+	if err != nil {
+		return err
+	}
+	invalidateUserCache(s.userCache)
+	if s.quota != nil && user.OrganizationID != nil && user.IsActive() {
+		s.quota.ReleaseUserQuota(*user.OrganizationID)
+	}
+
+	if s.events != nil {
+		s.events.Publish(events.UserDeleted, &events.UserDeletedData{
+			UserID:    id,
+			Email:     user.Email,
+			ActorID:   actorID,
+			DeletedAt: now,
+		})
+	}
+	return nil
+}
+
+// checkAdminChange checks that actorID may demote or delete the admin, or
+// promote the user, without leaving zero active admins
+func (s *userServiceImpl) checkAdminChange(actorID uint, user *domain.User) error {
+	if err := s.requireAdminActor(actorID); err != nil {
+		return err
+	}
+
+	// Only removing an active admin can leave zero active admins
+	if !user.IsAdmin || !user.IsActive() {
+		return nil
+	}
+	admins, err := s.userRepo.CountActiveAdmins()
+	if err != nil {
+		return err
+	}
+	if admins <= 1 {
+		return domain.ErrLastAdmin
+	}
+	return nil
+}
+
+// requireAdminActor checks that actorID is a full admin, not a delegate
+func (s *userServiceImpl) requireAdminActor(actorID uint) error {
+	actor, err := s.userRepo.FindByID(actorID)
+	if err != nil {
+		return err
+	}
+	if !actor.IsAdmin {
+		return domain.ErrAdminRoleRequired
+	}
+	return nil
+}
+
+// UpdateAdminScopes replaces the delegated admin scopes of a user
+func (s *userServiceImpl) UpdateAdminScopes(id uint, scopes []string) (*domain.User, error) {
+	user, err := s.userRepo.FindByID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	user.AdminScopes = strings.Join(scopes, ",")
+	user.PermVersion++
+	if err := s.userRepo.Update(user); err != nil {
+		return nil, domain.ErrFailedToUpdateUser
+	}
+	invalidateUserCache(s.userCache)
+
+	return user, nil
 }
 
 // ChangePassword allows a user to change their own password
@@ -289,10 +1191,21 @@ func (s *userServiceImpl) ChangePassword(userID uint, req *domain.ChangePassword
 	}
 
 	// Verify old password
-	if err := utils.CheckPassword(user.Password, req.OldPassword); err != nil {
+	if err := s.checkPassword(user.Password, req.OldPassword); err != nil {
+		if err == domain.ErrPasswordCheckBusy {
+			return err
+		}
 		return domain.ErrInvalidCredentials
 	}
 
+	settings, err := s.settingsFor(user.OrganizationID)
+	if err != nil {
+		return err
+	}
+	if err := settings.PasswordPolicy.Check(req.NewPassword); err != nil {
+		return err
+	}
+
 	// Hash new password
 	hashedPassword, err := utils.HashPassword(req.NewPassword)
 	if err != nil {
@@ -304,6 +1217,7 @@ func (s *userServiceImpl) ChangePassword(userID uint, req *domain.ChangePassword
 	if err := s.userRepo.Update(user); err != nil {
 		return domain.ErrFailedToUpdateUser
 	}
+	invalidateUserCache(s.userCache)
 
 	return nil
 }
@@ -315,29 +1229,253 @@ func (s *userServiceImpl) UpdateOwnProfile(userID uint, req *domain.UpdateProfil
 	if err != nil {
 		return nil, err
 	}
+	before := domain.SnapshotProfile(user)
 
 	// Check if email is being changed and if it's already taken
+	var change *emailChange
 	if req.Email != "" && req.Email != user.Email {
-		existingUser, err := s.userRepo.FindByEmail(req.Email)
-		if err != nil && err != domain.ErrUserNotFound {
-			// Handle potential database errors
+		if change, err = s.changeEmail(user, req.Email); err != nil {
 			return nil, err
 		}
-		if existingUser != nil {
-			return nil, domain.ErrEmailAlreadyInUse
-		}
-		user.Email = req.Email
 	}
 
 	// Update name if provided
-	if req.Name != "" {
-		user.Name = req.Name
+	if req.FirstName != "" || req.LastName != nil || req.DisplayName != nil {
+		user.UpdateName(req.FirstName, req.LastName, req.DisplayName)
+	} else if req.Name != "" {
+		user.SetName(req.Name)
+	}
+	if req.Timezone != nil {
+		user.Timezone = *req.Timezone
+	}
+	if req.LogoutOtherSessionsOnLogin != nil {
+		user.LogoutOtherSessionsOnLogin = *req.LogoutOtherSessionsOnLogin
 	}
 
 	// Save changes
 	if err := s.userRepo.Update(user); err != nil {
 		return nil, domain.ErrFailedToUpdateUser
 	}
+	invalidateUserCache(s.userCache)
+	if err := s.recordProfileChange(user, before, &userID, domain.ProfileChangeSourceSelf); err != nil {
+		return nil, err
+	}
+	if change != nil {
+		if err := s.emailChanged(userID, user, change); err != nil {
+			return nil, err
+		}
+	}
+	if s.onboarding != nil {
+		if err := s.onboarding.CompleteStep(userID, domain.OnboardingStepProfileCompleted); err != nil {
+			return nil, err
+		}
+	}
 
 	return user, nil
 }
+
+// emailChange is an email change being saved
+type emailChange struct {
+	previousEmail string
+	// revertToken is emailed to the previous address, revert is its record.
+	// Both are empty when reverting is disabled.
+	revertToken string
+	revert      *domain.OneTimeToken
+}
+
+// changeEmail checks that the user can switch to email and sets it. The
+// previous address is issued a revert token first, so that no change is saved
+// without one.
+func (s *userServiceImpl) changeEmail(user *domain.User, email string) (*emailChange, error) {
+	existingUser, err := s.userRepo.FindByEmail(email)
+	if err != nil && err != domain.ErrUserNotFound {
+		// Handle potential database errors
+		return nil, err
+	}
+	if existingUser != nil {
+		return nil, domain.ErrEmailAlreadyInUse
+	}
+	if reserved, err := s.emailReserved(email, user.ID); err != nil {
+		return nil, err
+	} else if reserved {
+		return nil, domain.ErrEmailAlreadyInUse
+	}
+	if err := s.checkEmailAllowed(user, email); err != nil {
+		return nil, err
+	}
+
+	change := &emailChange{previousEmail: user.Email}
+	if s.oneTimeTokens != nil {
+		change.revertToken, change.revert, err = s.oneTimeTokens.Issue(
+			domain.OneTimeTokenPurposeEmailChangeRevert,
+			strings.ToLower(user.Email),
+			&domain.EmailChangeRevert{UserID: user.ID, PreviousEmail: user.Email, NewEmail: email},
+			s.emailRevertWindow,
+		)
+		if err != nil {
+			return nil, err
+		}
+	}
+	user.Email = email
+	return change, nil
+}
+
+// emailChanged audits a saved email change and notifies the previous address
+func (s *userServiceImpl) emailChanged(actorID uint, user *domain.User, change *emailChange) error {
+	data := &events.UserEmailChangedData{
+		UserID:        user.ID,
+		Name:          user.Name,
+		PreviousEmail: change.previousEmail,
+		NewEmail:      user.Email,
+		ActorID:       actorID,
+		ChangedAt:     time.Now(),
+	}
+	if change.revert != nil {
+		data.RevertExpiresAt = &change.revert.ExpiresAt
+		data.RevertToken = change.revertToken
+	}
+
+	if s.audit != nil {
+		detail := map[string]interface{}{"revertible": change.revert != nil}
+		if change.revert != nil {
+			detail["revertible_until"] = change.revert.ExpiresAt
+		}
+		err := s.audit.Record(&domain.AuditLog{
+			Action:         domain.AuditUserEmailChanged,
+			ActorID:        &actorID,
+			UserID:         &user.ID,
+			OrganizationID: user.OrganizationID,
+		}, detail)
+		if err != nil {
+			return err
+		}
+	}
+	// The new address is yet to be verified
+	if s.onboarding != nil {
+		if err := s.onboarding.ResetStep(user.ID, domain.OnboardingStepEmailVerified); err != nil {
+			return err
+		}
+	}
+	if s.events != nil {
+		s.events.Publish(events.UserEmailChanged, data)
+	}
+	return nil
+}
+
+// recordProfileChange records the changes of the profile of a saved user
+// since before in the profile history, when enabled
+func (s *userServiceImpl) recordProfileChange(user *domain.User, before domain.ProfileSnapshot, actorID *uint, source string) error {
+	if s.profileHistory == nil {
+		return nil
+	}
+	return s.profileHistory.Record(user, before, actorID, source)
+}
+
+// emailReserved reports whether email is the previous address of another
+// user than userID that can still revert its change
+func (s *userServiceImpl) emailReserved(email string, userID uint) (bool, error) {
+	if s.oneTimeTokens == nil {
+		return false, nil
+	}
+	tokens, err := s.oneTimeTokens.Active(domain.OneTimeTokenPurposeEmailChangeRevert, strings.ToLower(email))
+	if err != nil {
+		return false, err
+	}
+	for _, token := range tokens {
+		var revert domain.EmailChangeRevert
+		if err := token.DecodePayload(&revert); err != nil {
+			return false, err
+		}
+		if revert.UserID != userID {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// RevertEmailChange restores the previous email address of a revert token.
+// Whoever changed the email may hold a session and may have changed the
+// password as well, so the user's sessions end and the password is replaced
+// by a random one, with a password reset token returned to set a new one.
+func (s *userServiceImpl) RevertEmailChange(token string) (*domain.User, string, error) {
+	if s.oneTimeTokens == nil {
+		return nil, "", domain.ErrOneTimeTokenInvalid
+	}
+
+	var revert domain.EmailChangeRevert
+	if _, err := s.oneTimeTokens.Consume(domain.OneTimeTokenPurposeEmailChangeRevert, token, &revert); err != nil {
+		return nil, "", err
+	}
+
+	user, err := s.userRepo.FindByID(revert.UserID)
+	if err != nil {
+		return nil, "", err
+	}
+	existingUser, err := s.userRepo.FindByEmail(revert.PreviousEmail)
+	if err != nil && err != domain.ErrUserNotFound {
+		return nil, "", err
+	}
+	if existingUser != nil && existingUser.ID != user.ID {
+		return nil, "", domain.ErrEmailAlreadyInUse
+	}
+
+	password, err := utils.GenerateRandomPassword()
+	if err != nil {
+		return nil, "", domain.ErrFailedToHashPassword
+	}
+	hashedPassword, err := utils.HashPassword(password)
+	if err != nil {
+		return nil, "", domain.ErrFailedToHashPassword
+	}
+
+	before := domain.SnapshotProfile(user)
+	user.Email = revert.PreviousEmail
+	user.Password = hashedPassword
+	// Reject the access tokens of every session along with the refresh tokens
+	user.SessionEpoch++
+	if err := s.userRepo.Update(user); err != nil {
+		return nil, "", domain.ErrFailedToUpdateUser
+	}
+	invalidateUserCache(s.userCache)
+	if err := s.recordProfileChange(user, before, nil, domain.ProfileChangeSourceEmailRevert); err != nil {
+		return nil, "", err
+	}
+	if err := s.tokenRepo.RevokeAllUserRefreshTokens(user.ID); err != nil {
+		return nil, "", err
+	}
+	// The revert link was received at the restored address
+	if s.onboarding != nil {
+		if err := s.onboarding.CompleteStep(user.ID, domain.OnboardingStepEmailVerified); err != nil {
+			return nil, "", err
+		}
+	}
+	resetToken, _, err := s.oneTimeTokens.Issue(
+		domain.OneTimeTokenPurposePasswordReset,
+		strconv.FormatUint(uint64(user.ID), 10),
+		&domain.PasswordReset{UserID: user.ID},
+		RevertPasswordResetTTL,
+	)
+	if err != nil {
+		return nil, "", err
+	}
+
+	now := time.Now()
+	if s.audit != nil {
+		err := s.audit.Record(&domain.AuditLog{
+			Action:         domain.AuditUserEmailReverted,
+			UserID:         &user.ID,
+			OrganizationID: user.OrganizationID,
+		}, nil)
+		if err != nil {
+			return nil, "", err
+		}
+	}
+	if s.events != nil {
+		s.events.Publish(events.UserEmailChangeReverted, &events.UserEmailChangeRevertedData{
+			UserID:     user.ID,
+			Email:      user.Email,
+			RevertedAt: now,
+		})
+	}
+	return user, resetToken, nil
+}
@@ -0,0 +1,126 @@
+package service
+
+import (
+	"fmt"
+	"gojwt-rest-api/internal/domain"
+	"gojwt-rest-api/internal/events"
+	"gojwt-rest-api/internal/repository"
+	"time"
+)
+
+// maxDeliveryErrorLength is the size of the error column of webhook deliveries
+const maxDeliveryErrorLength = 500
+
+// WebhookService defines the interface for delivering webhooks and inspecting past deliveries
+type WebhookService interface {
+	// Subscribe delivers every event published on bus to the configured endpoints
+	Subscribe(bus *events.Bus)
+	ListDeliveries(filter *domain.WebhookDeliveryFilter, pagination *domain.PaginationQuery) ([]*domain.WebhookDelivery, int64, error)
+	GetDelivery(id uint) (*domain.WebhookDelivery, error)
+	// Replay resends the payload of a delivery and records the new attempt
+	Replay(id uint) (*domain.WebhookDelivery, error)
+}
+
+// webhookServiceImpl is the implementation of WebhookService
+type webhookServiceImpl struct {
+	deliveryRepo repository.WebhookDeliveryRepository
+	subscribers  map[string]*events.WebhookSubscriber
+}
+
+// NewWebhookService creates a new webhook service delivering to urls
+func NewWebhookService(deliveryRepo repository.WebhookDeliveryRepository, urls []string, secret string, timeout time.Duration) WebhookService {
+	subscribers := make(map[string]*events.WebhookSubscriber, len(urls))
+	for _, url := range urls {
+		subscribers[url] = events.NewWebhookSubscriber(url, secret, timeout)
+	}
+	return &webhookServiceImpl{
+		deliveryRepo: deliveryRepo,
+		subscribers:  subscribers,
+	}
+}
+
+// Subscribe delivers every event published on bus to the configured endpoints
+func (s *webhookServiceImpl) Subscribe(bus *events.Bus) {
+	for _, subscriber := range s.subscribers {
+		subscriber := subscriber
+		bus.Subscribe(events.AllEvents, func(event events.Event) error {
+			body, err := events.Envelope(event)
+			if err != nil {
+				return err
+			}
+			delivery, err := s.send(subscriber, event.ID, event.Type, body, nil)
+			if err != nil {
+				return err
+			}
+			if !delivery.Success {
+				return fmt.Errorf("webhook delivery %d to %s failed: %s", delivery.ID, delivery.URL, delivery.Error)
+			}
+			return nil
+		})
+	}
+}
+
+// ListDeliveries lists matching deliveries, newest first
+func (s *webhookServiceImpl) ListDeliveries(filter *domain.WebhookDeliveryFilter, pagination *domain.PaginationQuery) ([]*domain.WebhookDelivery, int64, error) {
+	// Set default pagination values
+	if pagination.Page <= 0 {
+		pagination.Page = 1
+	}
+	if pagination.PageSize <= 0 {
+		pagination.PageSize = 10
+	}
+	if pagination.PageSize > 100 {
+		pagination.PageSize = 100 // Max page size
+	}
+
+	offset := (pagination.Page - 1) * pagination.PageSize
+	return s.deliveryRepo.Find(filter, offset, pagination.PageSize)
+}
+
+// GetDelivery gets a delivery by ID
+func (s *webhookServiceImpl) GetDelivery(id uint) (*domain.WebhookDelivery, error) {
+	return s.deliveryRepo.FindByID(id)
+}
+
+// Replay resends the payload of a delivery and records the new attempt
+func (s *webhookServiceImpl) Replay(id uint) (*domain.WebhookDelivery, error) {
+	original, err := s.deliveryRepo.FindByID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	subscriber, ok := s.subscribers[original.URL]
+	if !ok {
+		return nil, domain.ErrWebhookEndpointRemoved
+	}
+
+	return s.send(subscriber, original.EventID, original.EventType, []byte(original.Payload), &original.ID)
+}
+
+// send delivers body to the subscriber's endpoint and records the attempt
+func (s *webhookServiceImpl) send(subscriber *events.WebhookSubscriber, eventID, eventType string, body []byte, replayOf *uint) (*domain.WebhookDelivery, error) {
+	result := subscriber.Send(eventID, body)
+
+	delivery := &domain.WebhookDelivery{
+		EventID:         eventID,
+		EventType:       eventType,
+		URL:             subscriber.URL(),
+		Payload:         string(body),
+		StatusCode:      result.StatusCode,
+		Success:         result.Err == nil,
+		DurationMs:      result.Duration.Milliseconds(),
+		ResponseSnippet: result.Response,
+		ReplayOf:        replayOf,
+	}
+	if result.Err != nil {
+		delivery.Error = result.Err.Error()
+		if len(delivery.Error) > maxDeliveryErrorLength {
+			delivery.Error = delivery.Error[:maxDeliveryErrorLength]
+		}
+	}
+
+	if err := s.deliveryRepo.Create(delivery); err != nil {
+		return nil, err
+	}
+	return delivery, nil
+}
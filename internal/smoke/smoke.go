@@ -0,0 +1,228 @@
+// Package smoke verifies a deployed instance by running the main authentication
+// flow against it with a pre-provisioned smoke test account. No user is
+// created, so runs leave nothing behind and work with self-registration
+// disabled.
+package smoke
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Step names, in the order they run
+const (
+	StepLogin   = "login"
+	StepRefresh = "refresh"
+	StepProfile = "profile"
+	StepLogout  = "logout"
+)
+
+// Result is the outcome of a single step. Steps after a failed one are skipped.
+type Result struct {
+	Step     string
+	Passed   bool
+	Skipped  bool
+	Status   int
+	Duration time.Duration
+	Err      error
+}
+
+// Runner runs the smoke test sequence against a base URL
+type Runner struct {
+	baseURL  string
+	client   *http.Client
+	email    string
+	password string
+
+	accessToken  string
+	refreshToken string
+}
+
+// Option configures optional runner behavior
+type Option func(*Runner)
+
+// WithHTTPClient sends requests with client instead of a default client
+func WithHTTPClient(client *http.Client) Option {
+	return func(r *Runner) {
+		r.client = client
+	}
+}
+
+// New creates a runner for the API at baseURL, e.g. https://api.example.com,
+// logging in as the account with email and password
+func New(baseURL, email, password string, opts ...Option) *Runner {
+	r := &Runner{
+		baseURL:  strings.TrimRight(baseURL, "/"),
+		client:   &http.Client{Timeout: 10 * time.Second},
+		email:    email,
+		password: password,
+	}
+	for _, opt := range opts {
+		opt(r)
+	}
+	return r
+}
+
+// Run logs in, refreshes the tokens, reads the profile and logs out,
+// returning one result per step
+func (r *Runner) Run(ctx context.Context) []Result {
+	r.accessToken, r.refreshToken = "", ""
+
+	steps := []struct {
+		name string
+		run  func(ctx context.Context) (int, error)
+	}{
+		{StepLogin, r.login},
+		{StepRefresh, r.refresh},
+		{StepProfile, r.profile},
+		{StepLogout, r.logout},
+	}
+
+	results := make([]Result, 0, len(steps))
+	failed := false
+	for _, step := range steps {
+		if failed {
+			results = append(results, Result{Step: step.name, Skipped: true})
+			continue
+		}
+		start := time.Now()
+		status, err := step.run(ctx)
+		results = append(results, Result{
+			Step:     step.name,
+			Passed:   err == nil,
+			Status:   status,
+			Duration: time.Since(start),
+			Err:      err,
+		})
+		failed = err != nil
+	}
+	return results
+}
+
+// Passed reports whether every step passed
+func Passed(results []Result) bool {
+	for _, result := range results {
+		if !result.Passed {
+			return false
+		}
+	}
+	return len(results) > 0
+}
+
+func (r *Runner) login(ctx context.Context) (int, error) {
+	var data struct {
+		Status       string `json:"status"`
+		AccessToken  string `json:"access_token"`
+		RefreshToken string `json:"refresh_token"`
+	}
+	body := map[string]string{"email": r.email, "password": r.password}
+	status, err := r.do(ctx, http.MethodPost, "/api/v1/auth/login", "", body, http.StatusOK, &data)
+	if err != nil {
+		return status, err
+	}
+	if data.Status != "" {
+		return status, fmt.Errorf("login requires %s", data.Status)
+	}
+	if data.AccessToken == "" || data.RefreshToken == "" {
+		return status, fmt.Errorf("login response has no tokens")
+	}
+	r.accessToken, r.refreshToken = data.AccessToken, data.RefreshToken
+	return status, nil
+}
+
+func (r *Runner) refresh(ctx context.Context) (int, error) {
+	var data struct {
+		AccessToken  string `json:"access_token"`
+		RefreshToken string `json:"refresh_token"`
+	}
+	body := map[string]string{"refresh_token": r.refreshToken}
+	status, err := r.do(ctx, http.MethodPost, "/api/v1/auth/refresh", "", body, http.StatusOK, &data)
+	if err != nil {
+		return status, err
+	}
+	if data.AccessToken == "" || data.RefreshToken == "" {
+		return status, fmt.Errorf("refresh response has no tokens")
+	}
+	r.accessToken, r.refreshToken = data.AccessToken, data.RefreshToken
+	return status, nil
+}
+
+func (r *Runner) profile(ctx context.Context) (int, error) {
+	var data struct {
+		Email string `json:"email"`
+	}
+	status, err := r.do(ctx, http.MethodGet, "/api/v1/profile", r.accessToken, nil, http.StatusOK, &data)
+	if err != nil {
+		return status, err
+	}
+	if !strings.EqualFold(data.Email, r.email) {
+		return status, fmt.Errorf("profile email is %q, expected %q", data.Email, r.email)
+	}
+	return status, nil
+}
+
+func (r *Runner) logout(ctx context.Context) (int, error) {
+	body := map[string]string{"refresh_token": r.refreshToken}
+	return r.do(ctx, http.MethodPost, "/api/v1/auth/logout", r.accessToken, body, http.StatusOK, nil)
+}
+
+// do sends a JSON request and decodes the data of the standard response into out
+func (r *Runner) do(ctx context.Context, method, path, token string, body interface{}, want int, out interface{}) (int, error) {
+	var reader io.Reader
+	if body != nil {
+		payload, err := json.Marshal(body)
+		if err != nil {
+			return 0, err
+		}
+		reader = bytes.NewReader(payload)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, r.baseURL+path, reader)
+	if err != nil {
+		return 0, err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	var envelope struct {
+		Message string          `json:"message"`
+		Data    json.RawMessage `json:"data"`
+	}
+	raw, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return resp.StatusCode, err
+	}
+	if resp.StatusCode != want {
+		_ = json.Unmarshal(raw, &envelope)
+		if envelope.Message != "" {
+			return resp.StatusCode, fmt.Errorf("unexpected status %d: %s", resp.StatusCode, envelope.Message)
+		}
+		return resp.StatusCode, fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+	if out == nil {
+		return resp.StatusCode, nil
+	}
+	if err := json.Unmarshal(raw, &envelope); err != nil {
+		return resp.StatusCode, fmt.Errorf("decode response: %w", err)
+	}
+	if err := json.Unmarshal(envelope.Data, out); err != nil {
+		return resp.StatusCode, fmt.Errorf("decode response data: %w", err)
+	}
+	return resp.StatusCode, nil
+}
@@ -0,0 +1,62 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"gojwt-rest-api/internal/cloudauth"
+	"gojwt-rest-api/internal/config"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// GCSStore writes objects to Google Cloud Storage through its JSON API,
+// authenticating as the service account of the instance
+type GCSStore struct {
+	bucket       string
+	endpoint     string
+	metadataHost string
+	client       *http.Client
+}
+
+// NewGCSStore creates a Google Cloud Storage store
+func NewGCSStore(cfg config.StorageConfig, client *http.Client) *GCSStore {
+	endpoint := cfg.Endpoint
+	if endpoint == "" {
+		endpoint = "https://storage.googleapis.com"
+	}
+	return &GCSStore{
+		bucket:       cfg.Bucket,
+		endpoint:     strings.TrimSuffix(endpoint, "/"),
+		metadataHost: cfg.GCPMetadataHost,
+		client:       client,
+	}
+}
+
+// Put uploads body with a single media upload
+func (s *GCSStore) Put(ctx context.Context, key string, body []byte, contentType string) error {
+	token, err := cloudauth.GCPAccessToken(ctx, s.client, s.metadataHost)
+	if err != nil {
+		return err
+	}
+
+	endpoint := s.endpoint + "/upload/storage/v1/b/" + url.PathEscape(s.bucket) + "/o?uploadType=media&name=" + url.QueryEscape(key)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", contentType)
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("gcs responded with status %d", resp.StatusCode)
+	}
+	return nil
+}
@@ -0,0 +1,49 @@
+// Package storage writes objects to cloud object storage, Amazon S3 or Google
+// Cloud Storage, for data kept outside the database such as archived audit
+// logs.
+package storage
+
+import (
+	"context"
+	"fmt"
+	"gojwt-rest-api/internal/config"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// requestTimeout bounds every upload
+const requestTimeout = time.Minute
+
+// Store writes objects to a bucket
+type Store interface {
+	// Put writes body to the object key, replacing any object already there
+	Put(ctx context.Context, key string, body []byte, contentType string) error
+}
+
+// New creates the store of the configured provider, or nil when no provider
+// is configured
+func New(cfg config.StorageConfig) (Store, error) {
+	client := &http.Client{Timeout: requestTimeout}
+
+	switch cfg.Provider {
+	case "":
+		return nil, nil
+	case config.StorageProviderS3:
+		return NewS3Store(cfg, client), nil
+	case config.StorageProviderGCS:
+		return NewGCSStore(cfg, client), nil
+	default:
+		return nil, fmt.Errorf("unsupported storage provider: %s", cfg.Provider)
+	}
+}
+
+// escapeKey escapes the segments of an object key for use in a URL path
+func escapeKey(key string) string {
+	segments := strings.Split(key, "/")
+	for i, segment := range segments {
+		segments[i] = url.PathEscape(segment)
+	}
+	return strings.Join(segments, "/")
+}
@@ -0,0 +1,162 @@
+package tenant
+
+import (
+	"reflect"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/schema"
+)
+
+const callbackName = "tenant:scope"
+
+// Plugin scopes every statement executed with a tenant context (see
+// WithOrganization) to the organization of that context. Models without an
+// organization_id column and statements without a tenant context are left
+// untouched. Raw SQL is never rewritten.
+//
+// The organization filter is combined with the existing conditions as
+// "(conditions) AND organization_id = ?", so neither explicit organization
+// conditions nor OR clauses can widen a query beyond the tenant. Unscoped()
+// only disables soft deletes, it does not lift the tenant scope.
+type Plugin struct{}
+
+// Name returns the name of the plugin
+func (Plugin) Name() string {
+	return "tenant"
+}
+
+// Initialize registers the tenant callbacks
+func (Plugin) Initialize(db *gorm.DB) error {
+	if err := db.Callback().Query().Before("gorm:query").Register(callbackName, scopeQuery); err != nil {
+		return err
+	}
+	if err := db.Callback().Row().Before("gorm:row").Register(callbackName, scopeQuery); err != nil {
+		return err
+	}
+	if err := db.Callback().Update().Before("gorm:update").Register(callbackName, scopeWrite); err != nil {
+		return err
+	}
+	if err := db.Callback().Delete().Before("gorm:delete").Register(callbackName, scopeWrite); err != nil {
+		return err
+	}
+	return db.Callback().Create().Before("gorm:create").Register(callbackName, scopeCreate)
+}
+
+// scopeQuery adds the organization filter to a read statement
+func scopeQuery(db *gorm.DB) {
+	orgID, field, ok := scoped(db)
+	if !ok {
+		return
+	}
+	addCondition(db.Statement, field, orgID)
+}
+
+// scopeWrite adds the organization filter to an update or delete statement.
+// Statements without any condition are left alone so GORM still rejects
+// them as global updates instead of silently touching the whole tenant.
+func scopeWrite(db *gorm.DB) {
+	orgID, field, ok := scoped(db)
+	if !ok {
+		return
+	}
+	if _, hasWhere := db.Statement.Clauses["WHERE"]; !hasWhere && !db.AllowGlobalUpdate && !hasPrimaryKey(db.Statement) {
+		return
+	}
+	addCondition(db.Statement, field, orgID)
+}
+
+// scopeCreate assigns new records to the organization of the context and
+// rejects records explicitly assigned to another organization
+func scopeCreate(db *gorm.DB) {
+	orgID, field, ok := scoped(db)
+	if !ok {
+		return
+	}
+
+	stmt := db.Statement
+	assign := func(rv reflect.Value) {
+		value, isZero := field.ValueOf(stmt.Context, rv)
+		if isZero {
+			if orgID != 0 {
+				if err := field.Set(stmt.Context, rv, orgID); err != nil {
+					db.AddError(err)
+				}
+			}
+			return
+		}
+		if organizationOf(value) != orgID {
+			db.AddError(ErrCrossTenantWrite)
+		}
+	}
+
+	switch stmt.ReflectValue.Kind() {
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < stmt.ReflectValue.Len(); i++ {
+			assign(reflect.Indirect(stmt.ReflectValue.Index(i)))
+		}
+	case reflect.Struct:
+		assign(stmt.ReflectValue)
+	}
+}
+
+// scoped returns the organization of the statement context and the tenant
+// column of its model, if the statement has to be scoped
+func scoped(db *gorm.DB) (uint, *schema.Field, bool) {
+	if db.Error != nil || db.Statement.Schema == nil {
+		return 0, nil, false
+	}
+	orgID, ok := OrganizationFromContext(db.Statement.Context)
+	if !ok {
+		return 0, nil, false
+	}
+	field := db.Statement.Schema.LookUpField(columnName)
+	if field == nil {
+		return 0, nil, false
+	}
+	return orgID, field, true
+}
+
+// addCondition groups the existing conditions before appending the
+// organization filter, so an OR condition cannot escape the scope
+func addCondition(stmt *gorm.Statement, field *schema.Field, orgID uint) {
+	if c, ok := stmt.Clauses["WHERE"]; ok {
+		if where, ok := c.Expression.(clause.Where); ok && len(where.Exprs) > 0 {
+			where.Exprs = []clause.Expression{clause.AndConditions{Exprs: where.Exprs}}
+			c.Expression = where
+			stmt.Clauses["WHERE"] = c
+		}
+	}
+	stmt.AddClause(clause.Where{Exprs: []clause.Expression{condition(field.DBName, orgID)}})
+}
+
+// hasPrimaryKey reports whether the statement targets a record by its primary key
+func hasPrimaryKey(stmt *gorm.Statement) bool {
+	if stmt.Schema.PrioritizedPrimaryField == nil {
+		return false
+	}
+	rv := reflect.Indirect(stmt.ReflectValue)
+	switch rv.Kind() {
+	case reflect.Struct:
+		_, isZero := stmt.Schema.PrioritizedPrimaryField.ValueOf(stmt.Context, rv)
+		return !isZero
+	case reflect.Slice, reflect.Array:
+		return rv.Len() > 0
+	}
+	return false
+}
+
+// organizationOf converts the value of an organization column to an ID
+func organizationOf(value interface{}) uint {
+	rv := reflect.Indirect(reflect.ValueOf(value))
+	if !rv.IsValid() {
+		return 0
+	}
+	switch rv.Kind() {
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return uint(rv.Uint())
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return uint(rv.Int())
+	}
+	return 0
+}
@@ -0,0 +1,52 @@
+package tenant
+
+import (
+	"context"
+	"errors"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ErrCrossTenantWrite is returned when a record of another organization is
+// written through a tenant scoped statement
+var ErrCrossTenantWrite = errors.New("record belongs to another organization")
+
+// columnName is the column holding the owning organization of a record
+const columnName = "organization_id"
+
+type contextKey struct{}
+
+// WithOrganization returns a context scoping database statements to the given
+// organization. An organization ID of 0 scopes statements to records that do
+// not belong to any organization.
+func WithOrganization(ctx context.Context, orgID uint) context.Context {
+	return context.WithValue(ctx, contextKey{}, orgID)
+}
+
+// OrganizationFromContext returns the organization the context is scoped to
+func OrganizationFromContext(ctx context.Context) (uint, bool) {
+	if ctx == nil {
+		return 0, false
+	}
+	orgID, ok := ctx.Value(contextKey{}).(uint)
+	return orgID, ok
+}
+
+// Scope returns a GORM scope filtering a query by the given organization.
+// It can be used explicitly where no tenant context is available.
+func Scope(orgID uint) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		return db.Where(condition(columnName, orgID))
+	}
+}
+
+// condition builds the organization filter of the current table. Records
+// without an organization are matched with IS NULL.
+func condition(column string, orgID uint) clause.Expression {
+	var value interface{}
+	if orgID != 0 {
+		value = orgID
+	}
+	return clause.Eq{Column: clause.Column{Table: clause.CurrentTable, Name: column}, Value: value}
+}
@@ -11,62 +11,151 @@ import (
 
 var signingMethod = jwt.SigningMethodHS256
 
+// Access token formats. A breaking change to the claims bumps
+// CurrentTokenFormat, and tokens of older formats keep validating during a
+// migration window, see middleware.WithTokenFormatPolicy.
+const (
+	// TokenFormatLegacy is the format of tokens issued before formats were
+	// versioned, which carry no fmt claim
+	TokenFormatLegacy uint = 1
+	// CurrentTokenFormat is the format of the tokens issued
+	CurrentTokenFormat uint = 2
+)
+
 // JWTClaims represents JWT claims
 type JWTClaims struct {
 	UserID uint   `json:"user_id"`
 	Email  string `json:"email"`
+	// Tenant claims, set by a claims enricher in multi-tenant mode
+	OrganizationID uint     `json:"org_id,omitempty"`
+	Plan           string   `json:"plan,omitempty"`
+	Entitlements   []string `json:"entitlements,omitempty"`
+	// Scope restricts the token to the endpoints accepting it, empty for full access
+	Scope string `json:"scope,omitempty"`
+	// AuthTime is when the user last proved their identity with a password or
+	// second factor. Tokens issued by a refresh don't carry it.
+	AuthTime *jwt.NumericDate `json:"auth_time,omitempty"`
+	// SessionEpoch is the user's session epoch when the token was issued. The
+	// token is revoked once the user's epoch moves past it.
+	SessionEpoch uint `json:"epoch,omitempty"`
+	// ClientID is the client application of the session the token belongs to
+	ClientID string `json:"cid,omitempty"`
+	// Roles and Permissions are the user's roles and effective scopes when the
+	// token was issued, valid as long as PermVersion is the user's current
+	// permission version
+	Roles       []string `json:"roles,omitempty"`
+	Permissions []string `json:"permissions,omitempty"`
+	PermVersion uint     `json:"perm_version,omitempty"`
+	// Format is the format of the token, see TokenFormat
+	Format uint `json:"fmt,omitempty"`
 	jwt.RegisteredClaims
 }
 
-// TokenPair represents access and refresh token pair
-type TokenPair struct {
-	AccessToken  string
-	RefreshToken string
-	ExpiresIn    int64 // seconds until access token expires
+// TokenOption customizes the claims of a generated access token
+type TokenOption func(*JWTClaims)
+
+// WithScope restricts the token to endpoints accepting the scope
+func WithScope(scope string) TokenOption {
+	return func(c *JWTClaims) {
+		c.Scope = scope
+	}
 }
 
-// GenerateToken generates a new JWT token
-func GenerateToken(userID uint, email string, secret string, expiration time.Duration) (string, error) {
-	claims := JWTClaims{
-		UserID: userID,
-		Email:  email,
-		RegisteredClaims: jwt.RegisteredClaims{
-			ExpiresAt: jwt.NewNumericDate(time.Now().Add(expiration)),
-			IssuedAt:  jwt.NewNumericDate(time.Now()),
-		},
+// WithAuthTime records when the user authenticated
+func WithAuthTime(t time.Time) TokenOption {
+	return func(c *JWTClaims) {
+		c.AuthTime = jwt.NewNumericDate(t)
 	}
+}
 
-	token := jwt.NewWithClaims(signingMethod, claims)
-	return token.SignedString([]byte(secret))
+// WithSessionEpoch records the user's session epoch
+func WithSessionEpoch(epoch uint) TokenOption {
+	return func(c *JWTClaims) {
+		c.SessionEpoch = epoch
+	}
 }
 
-// GenerateTokenPair generates both access and refresh tokens
-func GenerateTokenPair(userID uint, email string, secret string, accessExpiry, refreshExpiry time.Duration) (*TokenPair, string, error) {
-	// Generate access token
-	accessToken, err := GenerateToken(userID, email, secret, accessExpiry)
-	if err != nil {
-		return nil, "", err
+// WithClientID records the client application of the session
+func WithClientID(clientID string) TokenOption {
+	return func(c *JWTClaims) {
+		c.ClientID = clientID
 	}
+}
 
-	// Generate refresh token (cryptographically secure random string)
-	refreshToken, err := generateSecureToken()
-	if err != nil {
-		return nil, "", err
+// WithPermissions embeds the user's roles and scopes at permission version
+func WithPermissions(roles, permissions []string, version uint) TokenOption {
+	return func(c *JWTClaims) {
+		c.Roles = roles
+		c.Permissions = permissions
+		c.PermVersion = version
 	}
+}
 
-	// Generate token family for rotation tracking
-	tokenFamily, err := generateSecureToken()
-	if err != nil {
-		return nil, "", err
+// TokenFormat returns the format the token was issued in
+func (c *JWTClaims) TokenFormat() uint {
+	if c.Format == 0 {
+		return TokenFormatLegacy
+	}
+	return c.Format
+}
+
+// AuthenticatedWithin reports whether the user authenticated less than maxAge ago
+func (c *JWTClaims) AuthenticatedWithin(maxAge time.Duration) bool {
+	return c.AuthTime != nil && time.Since(c.AuthTime.Time) <= maxAge
+}
+
+// HasEntitlement reports whether the token grants the entitlement
+func (c *JWTClaims) HasEntitlement(entitlement string) bool {
+	for _, e := range c.Entitlements {
+		if e == entitlement {
+			return true
+		}
+	}
+	return false
+}
+
+// CarriesPermissions reports whether the token embeds the user's roles.
+// Tokens issued before roles were embedded don't.
+func (c *JWTClaims) CarriesPermissions() bool {
+	return len(c.Roles) > 0
+}
+
+// HasRole reports whether the token grants the role
+func (c *JWTClaims) HasRole(role string) bool {
+	for _, r := range c.Roles {
+		if r == role {
+			return true
+		}
 	}
+	return false
+}
 
-	pair := &TokenPair{
-		AccessToken:  accessToken,
-		RefreshToken: refreshToken,
-		ExpiresIn:    int64(accessExpiry.Seconds()),
+// HasPermission reports whether the token grants the scope
+func (c *JWTClaims) HasPermission(scope string) bool {
+	for _, p := range c.Permissions {
+		if p == scope {
+			return true
+		}
 	}
+	return false
+}
+
+// TokenPair represents access and refresh token pair
+type TokenPair struct {
+	AccessToken  string
+	RefreshToken string
+	ExpiresIn    int64 // seconds until access token expires
+}
 
-	return pair, tokenFamily, nil
+// GenerateToken generates a new JWT token signed with secret
+func GenerateToken(userID uint, email string, secret string, expiration time.Duration, opts ...TokenOption) (string, error) {
+	return NewKeySet(secret).GenerateToken(userID, email, expiration, opts...)
+}
+
+// GenerateTokenPair generates both access and refresh tokens, the access
+// token signed with secret
+func GenerateTokenPair(userID uint, email string, secret string, accessExpiry, refreshExpiry time.Duration, opts ...TokenOption) (*TokenPair, string, error) {
+	return NewKeySet(secret).GenerateTokenPair(userID, email, accessExpiry, refreshExpiry, opts...)
 }
 
 // generateSecureToken generates a cryptographically secure random token
@@ -78,28 +167,60 @@ func generateSecureToken() (string, error) {
 	return base64.URLEncoding.EncodeToString(b), nil
 }
 
-// ValidateToken validates a JWT token and returns the claims
-func ValidateToken(tokenString string, secret string) (*JWTClaims, error) {
-	token, err := jwt.ParseWithClaims(tokenString, &JWTClaims{}, func(token *jwt.Token) (interface{}, error) {
-		// Validate signing method
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, domain.ErrInvalidSigningMethod
-		}
-		return []byte(secret), nil
-	})
+// TokenValidator validates access tokens signed with the keys of a key set.
+// The parser is created once and reused, keeping the per-request cost of the
+// auth middleware hot path to the parse itself.
+type TokenValidator struct {
+	parser *jwt.Parser
+	keys   *KeySet
+}
+
+// NewTokenValidator creates a new token validator for the given secret
+func NewTokenValidator(secret string) *TokenValidator {
+	return NewKeySetValidator(NewKeySet(secret))
+}
+
+// NewKeySetValidator creates a new token validator for the keys of keys,
+// rejecting tokens signed with revoked keys with domain.ErrSigningKeyRotated
+func NewKeySetValidator(keys *KeySet) *TokenValidator {
+	return &TokenValidator{
+		parser: jwt.NewParser(),
+		keys:   keys,
+	}
+}
 
+// keyFunc returns the verification key of the token's kid after checking the
+// signing method
+func (v *TokenValidator) keyFunc(token *jwt.Token) (interface{}, error) {
+	// Validate signing method
+	if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+		return nil, domain.ErrInvalidSigningMethod
+	}
+	id, _ := token.Header["kid"].(string)
+	return v.keys.verificationKey(id)
+}
+
+// Validate validates a JWT token and returns the claims
+func (v *TokenValidator) Validate(tokenString string) (*JWTClaims, error) {
+	claims := &JWTClaims{}
+	token, err := v.parser.ParseWithClaims(tokenString, claims, v.keyFunc)
 	if err != nil {
 		return nil, err
 	}
 
-	claims, ok := token.Claims.(*JWTClaims)
-	if !ok || !token.Valid {
+	if !token.Valid {
 		return nil, domain.ErrInvalidToken
 	}
 
 	return claims, nil
 }
 
+// ValidateToken validates a JWT token and returns the claims.
+// Callers validating many tokens with the same secret should reuse a TokenValidator.
+func ValidateToken(tokenString string, secret string) (*JWTClaims, error) {
+	return NewTokenValidator(secret).Validate(tokenString)
+}
+
 // ExtractTokenExpiry extracts the expiration time from a JWT token
 func ExtractTokenExpiry(tokenString string, secret string) (time.Time, error) {
 	claims, err := ValidateToken(tokenString, secret)
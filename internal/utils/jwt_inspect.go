@@ -0,0 +1,94 @@
+package utils
+
+import (
+	"errors"
+	"gojwt-rest-api/internal/domain"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// Token inspection failure reasons
+const (
+	InspectReasonMalformed         = "malformed"
+	InspectReasonBadSignature      = "bad_signature"
+	InspectReasonInvalidSigningAlg = "invalid_signing_method"
+	InspectReasonExpired           = "expired"
+	InspectReasonNotYetValid       = "not_yet_valid"
+	InspectReasonUsedBeforeIssued  = "used_before_issued"
+	InspectReasonWrongAudience     = "wrong_audience"
+	InspectReasonWrongIssuer       = "wrong_issuer"
+	InspectReasonInvalidClaims     = "invalid_claims"
+)
+
+// TokenInspection describes a decoded token and why it does or does not validate
+type TokenInspection struct {
+	Header    map[string]interface{} `json:"header,omitempty"`
+	Claims    map[string]interface{} `json:"claims,omitempty"`
+	Valid     bool                   `json:"valid"`
+	Reasons   []string               `json:"reasons,omitempty"`
+	Error     string                 `json:"error,omitempty"`
+	ExpiresAt *time.Time             `json:"expires_at,omitempty"`
+	IssuedAt  *time.Time             `json:"issued_at,omitempty"`
+}
+
+// InspectToken decodes a token without requiring it to be valid and explains
+// why validation against secret fails. Intended for development only.
+func InspectToken(tokenString string, secret string) *TokenInspection {
+	inspection := &TokenInspection{}
+
+	claims := jwt.MapClaims{}
+	token, _, err := jwt.NewParser().ParseUnverified(tokenString, claims)
+	if err != nil {
+		inspection.Reasons = []string{InspectReasonMalformed}
+		inspection.Error = err.Error()
+		return inspection
+	}
+
+	inspection.Header = token.Header
+	inspection.Claims = claims
+	if exp, err := claims.GetExpirationTime(); err == nil && exp != nil {
+		inspection.ExpiresAt = &exp.Time
+	}
+	if iat, err := claims.GetIssuedAt(); err == nil && iat != nil {
+		inspection.IssuedAt = &iat.Time
+	}
+
+	if _, err := ValidateToken(tokenString, secret); err != nil {
+		inspection.Reasons = inspectReasons(err)
+		inspection.Error = err.Error()
+		return inspection
+	}
+
+	inspection.Valid = true
+	return inspection
+}
+
+// inspectReasons maps validation errors to inspection reasons
+func inspectReasons(err error) []string {
+	checks := []struct {
+		target error
+		reason string
+	}{
+		{domain.ErrInvalidSigningMethod, InspectReasonInvalidSigningAlg},
+		{jwt.ErrTokenMalformed, InspectReasonMalformed},
+		{jwt.ErrTokenSignatureInvalid, InspectReasonBadSignature},
+		{domain.ErrUnknownSigningKey, InspectReasonBadSignature},
+		{jwt.ErrTokenExpired, InspectReasonExpired},
+		{jwt.ErrTokenNotValidYet, InspectReasonNotYetValid},
+		{jwt.ErrTokenUsedBeforeIssued, InspectReasonUsedBeforeIssued},
+		{jwt.ErrTokenInvalidAudience, InspectReasonWrongAudience},
+		{jwt.ErrTokenInvalidIssuer, InspectReasonWrongIssuer},
+	}
+
+	var reasons []string
+	for _, check := range checks {
+		if errors.Is(err, check.target) {
+			reasons = append(reasons, check.reason)
+		}
+	}
+	if len(reasons) == 0 {
+		reasons = append(reasons, InspectReasonInvalidClaims)
+	}
+	return reasons
+}
@@ -0,0 +1,143 @@
+package utils
+
+import (
+	"gojwt-rest-api/internal/domain"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// KeySet holds the keys access tokens are signed and verified with, in order
+// of preference. Tokens carry the ID of their key (domain.SigningKeyFingerprint)
+// in the kid header. Revoking a key, e.g. one reported compromised, drops it
+// at once: tokens signed with it stop verifying whatever their claims, and
+// new tokens are signed with the next key of the set.
+type KeySet struct {
+	ids  []string
+	keys map[string][]byte
+
+	mu      sync.RWMutex
+	revoked map[string]bool
+}
+
+// NewKeySet creates a key set from secrets, the first one preferred for signing
+func NewKeySet(secrets ...string) *KeySet {
+	k := &KeySet{keys: make(map[string][]byte, len(secrets)), revoked: make(map[string]bool)}
+	for _, secret := range secrets {
+		id := domain.SigningKeyFingerprint(secret)
+		if _, ok := k.keys[id]; ok {
+			continue
+		}
+		k.ids = append(k.ids, id)
+		k.keys[id] = []byte(secret)
+	}
+	return k
+}
+
+// IDs returns the IDs of the keys of the set, in order of preference
+func (k *KeySet) IDs() []string {
+	return append([]string(nil), k.ids...)
+}
+
+// Revoke drops the keys with ids from the set. Unknown IDs are ignored.
+func (k *KeySet) Revoke(ids ...string) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	for _, id := range ids {
+		if _, ok := k.keys[id]; ok {
+			k.revoked[id] = true
+		}
+	}
+}
+
+// Revoked reports whether the key with id was revoked
+func (k *KeySet) Revoked(id string) bool {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+	return k.revoked[id]
+}
+
+// SigningKeyID returns the ID of the key new tokens are signed with, the
+// first key not revoked, or domain.ErrNoSigningKey when every key was revoked
+func (k *KeySet) SigningKeyID() (string, error) {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+	for _, id := range k.ids {
+		if !k.revoked[id] {
+			return id, nil
+		}
+	}
+	return "", domain.ErrNoSigningKey
+}
+
+// verificationKey returns the key with id unless it was revoked. Tokens
+// without kid, issued before key IDs, are verified with the first key.
+func (k *KeySet) verificationKey(id string) ([]byte, error) {
+	if id == "" && len(k.ids) > 0 {
+		id = k.ids[0]
+	}
+	key, ok := k.keys[id]
+	if !ok {
+		return nil, domain.ErrUnknownSigningKey
+	}
+	if k.Revoked(id) {
+		return nil, domain.ErrSigningKeyRotated
+	}
+	return key, nil
+}
+
+// GenerateToken generates a new JWT token signed with the signing key of the set
+func (k *KeySet) GenerateToken(userID uint, email string, expiration time.Duration, opts ...TokenOption) (string, error) {
+	id, err := k.SigningKeyID()
+	if err != nil {
+		return "", err
+	}
+
+	claims := &JWTClaims{
+		UserID: userID,
+		Email:  email,
+		Format: CurrentTokenFormat,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(expiration)),
+			IssuedAt:  jwt.NewNumericDate(time.Now()),
+		},
+	}
+	for _, opt := range opts {
+		opt(claims)
+	}
+
+	token := jwt.NewWithClaims(signingMethod, claims)
+	token.Header["kid"] = id
+	return token.SignedString(k.keys[id])
+}
+
+// GenerateTokenPair generates both access and refresh tokens, the access
+// token signed with the signing key of the set
+func (k *KeySet) GenerateTokenPair(userID uint, email string, accessExpiry, refreshExpiry time.Duration, opts ...TokenOption) (*TokenPair, string, error) {
+	// Generate access token
+	accessToken, err := k.GenerateToken(userID, email, accessExpiry, opts...)
+	if err != nil {
+		return nil, "", err
+	}
+
+	// Generate refresh token (cryptographically secure random string)
+	refreshToken, err := generateSecureToken()
+	if err != nil {
+		return nil, "", err
+	}
+
+	// Generate token family for rotation tracking
+	tokenFamily, err := generateSecureToken()
+	if err != nil {
+		return nil, "", err
+	}
+
+	pair := &TokenPair{
+		AccessToken:  accessToken,
+		RefreshToken: refreshToken,
+		ExpiresIn:    int64(accessExpiry.Seconds()),
+	}
+
+	return pair, tokenFamily, nil
+}
@@ -19,3 +19,9 @@ func HashPassword(password string) (string, error) {
 func CheckPassword(hashedPassword, password string) error {
 	return bcrypt.CompareHashAndPassword([]byte(hashedPassword), []byte(password))
 }
+
+// GenerateRandomPassword generates a random password for accounts that are
+// not meant to sign in with a password until it is reset
+func GenerateRandomPassword() (string, error) {
+	return generateSecureToken()
+}
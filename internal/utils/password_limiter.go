@@ -0,0 +1,61 @@
+package utils
+
+import (
+	"gojwt-rest-api/internal/domain"
+	"time"
+)
+
+// PasswordLimiter bounds the number of concurrent bcrypt operations.
+// Under credential-stuffing attacks unbounded bcrypt calls can starve the
+// server of CPU, so callers wait in a queue for a slot and give up after
+// the configured timeout.
+type PasswordLimiter struct {
+	slots   chan struct{}
+	timeout time.Duration
+}
+
+// NewPasswordLimiter creates a new password limiter
+func NewPasswordLimiter(concurrency int, timeout time.Duration) *PasswordLimiter {
+	if concurrency <= 0 {
+		concurrency = 1
+	}
+	return &PasswordLimiter{
+		slots:   make(chan struct{}, concurrency),
+		timeout: timeout,
+	}
+}
+
+// acquire waits for a free slot, returning false when the queue timeout elapses
+func (l *PasswordLimiter) acquire() bool {
+	select {
+	case l.slots <- struct{}{}:
+		return true
+	default:
+	}
+
+	timer := time.NewTimer(l.timeout)
+	defer timer.Stop()
+
+	select {
+	case l.slots <- struct{}{}:
+		return true
+	case <-timer.C:
+		return false
+	}
+}
+
+// release frees a slot
+func (l *PasswordLimiter) release() {
+	<-l.slots
+}
+
+// CheckPassword compares a hashed password with a plain password once a slot is available.
+// It returns domain.ErrPasswordCheckBusy when no slot frees up in time.
+func (l *PasswordLimiter) CheckPassword(hashedPassword, password string) error {
+	if !l.acquire() {
+		return domain.ErrPasswordCheckBusy
+	}
+	defer l.release()
+
+	return CheckPassword(hashedPassword, password)
+}
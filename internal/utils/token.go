@@ -0,0 +1,17 @@
+package utils
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+)
+
+// GenerateOpaqueToken generates a random URL-safe token for single-use links such as invitations
+func GenerateOpaqueToken() (string, error) {
+	return generateSecureToken()
+}
+
+// HashToken returns the SHA-256 hash of an opaque token as stored in the database
+func HashToken(token string) string {
+	hash := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(hash[:])
+}
@@ -0,0 +1,175 @@
+package migrations
+
+import (
+	"fmt"
+	"gojwt-rest-api/internal/domain"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+// ExpectedIndex is an index that hot queries rely on. An index matches when
+// its leading columns are Columns, in order.
+type ExpectedIndex struct {
+	Table   string
+	Columns []string
+}
+
+func (i ExpectedIndex) String() string {
+	return fmt.Sprintf("%s(%s)", i.Table, strings.Join(i.Columns, ","))
+}
+
+// ExpectedIndexes lists the indexes created by Migrate for the hot queries:
+// refresh token lookup and session counting, blacklist checks and login
+var ExpectedIndexes = []ExpectedIndex{
+	{Table: "refresh_tokens", Columns: []string{"token"}},
+	{Table: "refresh_tokens", Columns: []string{"user_id", "is_revoked"}},
+	{Table: "token_blacklist", Columns: []string{"token", "expires_at"}},
+	{Table: "users", Columns: []string{"email"}},
+}
+
+// supersededIndexes are single-column indexes created by earlier versions
+// whose column leads a composite index of ExpectedIndexes, dropped by Migrate
+var supersededIndexes = []struct {
+	Model interface{}
+	Name  string
+}{
+	{Model: &domain.RefreshToken{}, Name: "idx_refresh_tokens_user_id"},
+	{Model: &domain.TokenBlacklist{}, Name: "idx_token_blacklist_token"},
+}
+
+// caseInsensitiveColumns must use a case-insensitive collation so that
+// lookups match regardless of case, e.g. logging in as John@Example.com
+var caseInsensitiveColumns = []struct{ Table, Column string }{
+	{Table: "users", Column: "email"},
+}
+
+// defaultCaseInsensitiveCollation is the collation EnsureCaseInsensitiveCollations
+// converts columns to when the collation of their table is case-sensitive too
+const defaultCaseInsensitiveCollation = "utf8mb4_unicode_ci"
+
+// dropSupersededIndexes drops the supersededIndexes that still exist
+func dropSupersededIndexes(db *gorm.DB) error {
+	for _, index := range supersededIndexes {
+		if !db.Migrator().HasIndex(index.Model, index.Name) {
+			continue
+		}
+		if err := db.Migrator().DropIndex(index.Model, index.Name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// EnsureCaseInsensitiveCollations converts the caseInsensitiveColumns that
+// use a case-sensitive collation to the collation of their table, or to
+// defaultCaseInsensitiveCollation when that one is case-sensitive too. The
+// conversion fails while values differing only in case break a unique index,
+// e.g. two users registered as john@example.com and John@example.com, which
+// must be merged first. Only MySQL is converted.
+func EnsureCaseInsensitiveCollations(db *gorm.DB) error {
+	if db.Dialector.Name() != "mysql" {
+		return nil
+	}
+
+	for _, col := range caseInsensitiveColumns {
+		var column struct {
+			ColumnType     string
+			IsNullable     string
+			CollationName  *string
+			TableCollation string
+		}
+		err := db.Raw(
+			"SELECT c.column_type AS column_type, c.is_nullable AS is_nullable, c.collation_name AS collation_name, t.table_collation AS table_collation "+
+				"FROM information_schema.columns c JOIN information_schema.tables t ON t.table_schema = c.table_schema AND t.table_name = c.table_name "+
+				"WHERE c.table_schema = DATABASE() AND c.table_name = ? AND c.column_name = ?",
+			col.Table, col.Column,
+		).Scan(&column).Error
+		if err != nil {
+			return err
+		}
+		if column.CollationName == nil || caseInsensitive(*column.CollationName) {
+			continue
+		}
+
+		collation := column.TableCollation
+		if !caseInsensitive(collation) {
+			collation = defaultCaseInsensitiveCollation
+		}
+		charset, _, _ := strings.Cut(collation, "_")
+		null := "NOT NULL"
+		if column.IsNullable == "YES" {
+			null = "NULL"
+		}
+		err = db.Exec(fmt.Sprintf("ALTER TABLE `%s` MODIFY `%s` %s CHARACTER SET %s COLLATE %s %s",
+			col.Table, col.Column, column.ColumnType, charset, collation, null)).Error
+		if err != nil {
+			return fmt.Errorf("failed to convert %s.%s to collation %s, merge values differing only in case first: %w", col.Table, col.Column, collation, err)
+		}
+	}
+	return nil
+}
+
+// caseInsensitive reports whether a MySQL collation compares case-insensitively
+func caseInsensitive(collation string) bool {
+	return strings.HasSuffix(collation, "_ci")
+}
+
+// AuditIndexes checks the database for missing expected indexes and
+// case-sensitive collations, e.g. left by a manual schema change after
+// Migrate, returning a warning for each problem found. Only MySQL is audited.
+func AuditIndexes(db *gorm.DB) ([]string, error) {
+	if db.Dialector.Name() != "mysql" {
+		return nil, nil
+	}
+
+	var warnings []string
+	for _, expected := range ExpectedIndexes {
+		found, err := hasIndexOn(db, expected)
+		if err != nil {
+			return nil, err
+		}
+		if !found {
+			warnings = append(warnings, fmt.Sprintf("missing index on %s", expected))
+		}
+	}
+
+	for _, col := range caseInsensitiveColumns {
+		var collation *string
+		err := db.Raw(
+			"SELECT collation_name FROM information_schema.columns WHERE table_schema = DATABASE() AND table_name = ? AND column_name = ?",
+			col.Table, col.Column,
+		).Scan(&collation).Error
+		if err != nil {
+			return nil, err
+		}
+		if collation != nil && !caseInsensitive(*collation) {
+			warnings = append(warnings, fmt.Sprintf("%s.%s uses case-sensitive collation %s", col.Table, col.Column, *collation))
+		}
+	}
+	return warnings, nil
+}
+
+// hasIndexOn reports whether an index of the table starts with the expected columns
+func hasIndexOn(db *gorm.DB, expected ExpectedIndex) (bool, error) {
+	var indexes []struct {
+		IndexName string
+		Columns   string
+	}
+	err := db.Raw(
+		"SELECT index_name AS index_name, GROUP_CONCAT(column_name ORDER BY seq_in_index) AS columns "+
+			"FROM information_schema.statistics WHERE table_schema = DATABASE() AND table_name = ? GROUP BY index_name",
+		expected.Table,
+	).Scan(&indexes).Error
+	if err != nil {
+		return false, err
+	}
+
+	prefix := strings.Join(expected.Columns, ",")
+	for _, index := range indexes {
+		if index.Columns == prefix || strings.HasPrefix(index.Columns, prefix+",") {
+			return true, nil
+		}
+	}
+	return false, nil
+}
@@ -1,6 +1,7 @@
 package migrations
 
 import (
+	"fmt"
 	"gojwt-rest-api/internal/domain"
 
 	"gorm.io/gorm"
@@ -8,9 +9,65 @@ import (
 
 // Migrate runs database migrations
 func Migrate(db *gorm.DB) error {
-	return db.AutoMigrate(
+	backfillOnboarding := !db.Migrator().HasTable(&domain.OnboardingStep{})
+	backfillNames := db.Migrator().HasTable(&domain.User{}) && !db.Migrator().HasColumn(&domain.User{}, "FirstName")
+	err := db.AutoMigrate(
 		&domain.User{},
+		&domain.UserTwoFactor{},
 		&domain.RefreshToken{},
+		&domain.LoginLocation{},
+		&domain.OnboardingStep{},
 		&domain.TokenBlacklist{},
+		&domain.ClientSessionCutoff{},
+		&domain.SigningKeyIncident{},
+		&domain.ClientVersionPolicy{},
+		&domain.APIKey{},
+		&domain.APIKeyUsage{},
+		&domain.Plan{},
+		&domain.Organization{},
+		&domain.OneTimeToken{},
+		&domain.OrganizationSettings{},
+		&domain.SSOConnection{},
+		&domain.SSODomain{},
+		&domain.AuditLog{},
+		&domain.ProfileChange{},
+		&domain.WebhookDelivery{},
+		&domain.AbuseReport{},
+		&schemaMigration{},
 	)
+	if err != nil {
+		return err
+	}
+	if backfillOnboarding {
+		if err := backfillOnboardingSteps(db); err != nil {
+			return err
+		}
+	}
+	if backfillNames {
+		if err := backfillUserNames(db); err != nil {
+			return err
+		}
+	}
+	if err := dropSupersededIndexes(db); err != nil {
+		return err
+	}
+	if err := EnsureCaseInsensitiveCollations(db); err != nil {
+		return err
+	}
+	return runOnce(db, legacyInvitationsVersion, migrateLegacyInvitations)
+}
+
+// UserFullTextIndex is the FULLTEXT index used by full-text user search
+const UserFullTextIndex = "idx_users_fulltext"
+
+// EnsureUserFullTextIndex creates the FULLTEXT index on the name and email of
+// users if it does not exist yet. Only MySQL is supported.
+func EnsureUserFullTextIndex(db *gorm.DB) error {
+	if name := db.Dialector.Name(); name != "mysql" {
+		return fmt.Errorf("full-text user search is not supported on %s", name)
+	}
+	if db.Migrator().HasIndex(&domain.User{}, UserFullTextIndex) {
+		return nil
+	}
+	return db.Exec("CREATE FULLTEXT INDEX " + UserFullTextIndex + " ON users (name, email)").Error
 }
@@ -0,0 +1,47 @@
+package migrations
+
+import (
+	"gojwt-rest-api/internal/domain"
+
+	"gorm.io/gorm"
+)
+
+// backfillBatchSize is the number of users backfilled per query
+const backfillBatchSize = 500
+
+// backfillUserNames splits the full name of users created before names had
+// parts into first and last name, the way domain.SplitName does for new
+// users. The full name is left as is.
+func backfillUserNames(db *gorm.DB) error {
+	var lastID uint
+	for {
+		var rows []struct {
+			ID   uint
+			Name string
+		}
+		err := db.Table("users").
+			Select("id", "name").
+			Where("id > ?", lastID).
+			Order("id").
+			Limit(backfillBatchSize).
+			Scan(&rows).Error
+		if err != nil {
+			return err
+		}
+		if len(rows) == 0 {
+			return nil
+		}
+
+		for _, row := range rows {
+			lastID = row.ID
+			firstName, lastName := domain.SplitName(row.Name)
+			err := db.Table("users").Where("id = ?", row.ID).UpdateColumns(map[string]interface{}{
+				"first_name": firstName,
+				"last_name":  lastName,
+			}).Error
+			if err != nil {
+				return err
+			}
+		}
+	}
+}
@@ -0,0 +1,18 @@
+package migrations
+
+import (
+	"gojwt-rest-api/internal/domain"
+
+	"gorm.io/gorm"
+)
+
+// backfillOnboardingSteps records the onboarding steps users completed before
+// they were tracked. Only confirmed two-factor enrollments are known; earlier
+// email verifications and profile updates were not recorded.
+func backfillOnboardingSteps(db *gorm.DB) error {
+	return db.Exec(
+		"INSERT INTO user_onboarding_steps (user_id, step, completed_at) "+
+			"SELECT user_id, ?, confirmed_at FROM user_two_factors WHERE confirmed_at IS NOT NULL",
+		domain.OnboardingStepTwoFactorEnrolled,
+	).Error
+}
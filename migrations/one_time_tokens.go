@@ -0,0 +1,75 @@
+package migrations
+
+import (
+	"gojwt-rest-api/internal/domain"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	// legacyInvitationsTable stored organization invitations before they moved to one_time_tokens
+	legacyInvitationsTable = "organization_invitations"
+	// legacyInvitationsArchive is the name the legacy table is kept under
+	// once migrated, with the history of accepted invitations
+	legacyInvitationsArchive = "organization_invitations_legacy"
+	// legacyInvitationsVersion records that the legacy table was migrated
+	legacyInvitationsVersion = "2026-10-one-time-token-invitations"
+)
+
+// migrateLegacyInvitations copies the pending invitations of the legacy table
+// to one_time_tokens, then renames it to legacyInvitationsArchive. Invitation
+// tokens already sent remain valid. Copying is idempotent, so a run stopped
+// before the rename is completed by the next one.
+func migrateLegacyInvitations(db *gorm.DB) error {
+	if !db.Migrator().HasTable(legacyInvitationsTable) {
+		return nil
+	}
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		var rows []struct {
+			OrganizationID uint
+			Email          string
+			Role           string
+			TokenHash      string
+			InvitedByID    uint
+			ExpiresAt      time.Time
+			CreatedAt      time.Time
+		}
+		err := tx.Table(legacyInvitationsTable).
+			Where("accepted_at IS NULL").
+			Order("id").
+			Scan(&rows).Error
+		if err != nil {
+			return err
+		}
+
+		for _, row := range rows {
+			invitation := &domain.OrganizationInvitation{
+				OrganizationID: row.OrganizationID,
+				Email:          row.Email,
+				Role:           row.Role,
+				TokenHash:      row.TokenHash,
+				InvitedByID:    row.InvitedByID,
+				ExpiresAt:      row.ExpiresAt,
+				CreatedAt:      row.CreatedAt,
+			}
+			token, err := invitation.ToOneTimeToken()
+			if err != nil {
+				return err
+			}
+			// A previous run may have been interrupted after copying some rows
+			if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(token).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	// DDL commits implicitly on MySQL, so the rename runs after the copy
+	// is committed rather than inside its transaction
+	return db.Migrator().RenameTable(legacyInvitationsTable, legacyInvitationsArchive)
+}
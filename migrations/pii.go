@@ -0,0 +1,144 @@
+package migrations
+
+import (
+	"gojwt-rest-api/internal/pii"
+
+	"gorm.io/gorm"
+)
+
+// reencryptBatchSize is the number of rows re-encrypted per query
+const reencryptBatchSize = 500
+
+// ReencryptUserPII encrypts user emails that are stored as plaintext or with
+// a previous key using the current key of keyring, and fills in their blind
+// index. It returns the number of users updated. Run it after enabling PII
+// encryption and after every key rotation, before retiring previous keys.
+func ReencryptUserPII(db *gorm.DB, keyring *pii.Keyring) (int, error) {
+	updated := 0
+	var lastID uint
+	for {
+		// Read the raw column values, bypassing the pii serializer
+		var rows []struct {
+			ID    uint
+			Email string
+		}
+		err := db.Table("users").
+			Select("id", "email").
+			Where("id > ?", lastID).
+			Order("id").
+			Limit(reencryptBatchSize).
+			Scan(&rows).Error
+		if err != nil {
+			return updated, err
+		}
+		if len(rows) == 0 {
+			return updated, nil
+		}
+
+		for _, row := range rows {
+			lastID = row.ID
+			if keyring.IsCurrent(row.Email) {
+				continue
+			}
+
+			email, err := keyring.Decrypt(row.Email)
+			if err != nil {
+				return updated, err
+			}
+			encrypted, err := keyring.Encrypt(email)
+			if err != nil {
+				return updated, err
+			}
+			err = db.Table("users").Where("id = ?", row.ID).UpdateColumns(map[string]interface{}{
+				"email":      encrypted,
+				"email_hash": keyring.BlindIndex(email),
+			}).Error
+			if err != nil {
+				return updated, err
+			}
+			updated++
+		}
+	}
+}
+
+// ReencryptProfileHistory encrypts the changes of the profile history, which
+// hold previous emails, like ReencryptUserPII does for users. It returns the
+// number of profile changes updated.
+func ReencryptProfileHistory(db *gorm.DB, keyring *pii.Keyring) (int, error) {
+	return reencryptColumn(db, keyring, "profile_changes", "changes")
+}
+
+// ReencryptAbuseReports encrypts the contact emails of abuse reporters like
+// ReencryptUserPII does for users. It returns the number of reports updated.
+func ReencryptAbuseReports(db *gorm.DB, keyring *pii.Keyring) (int, error) {
+	return reencryptColumn(db, keyring, "abuse_reports", "contact_email")
+}
+
+// reencryptColumn encrypts the values of a pii column of table that are not
+// encrypted with the current key of keyring, in batches by ID
+func reencryptColumn(db *gorm.DB, keyring *pii.Keyring, table, column string) (int, error) {
+	updated := 0
+	var lastID uint
+	for {
+		// Read the raw column values, bypassing the pii serializer
+		rows, err := readColumn(db, table, column, lastID)
+		if err != nil {
+			return updated, err
+		}
+		if len(rows) == 0 {
+			return updated, nil
+		}
+
+		for _, row := range rows {
+			lastID = row.ID
+			if keyring.IsCurrent(row.Value) {
+				continue
+			}
+
+			value, err := keyring.Decrypt(row.Value)
+			if err != nil {
+				return updated, err
+			}
+			encrypted, err := keyring.Encrypt(value)
+			if err != nil {
+				return updated, err
+			}
+			err = db.Table(table).Where("id = ?", row.ID).UpdateColumn(column, encrypted).Error
+			if err != nil {
+				return updated, err
+			}
+			updated++
+		}
+	}
+}
+
+// rawValue is the stored value of a column in the row with ID
+type rawValue struct {
+	ID    uint
+	Value string
+}
+
+// readColumn reads a batch of the values of column, in the rows of table
+// after lastID
+func readColumn(db *gorm.DB, table, column string, lastID uint) ([]rawValue, error) {
+	rows, err := db.Table(table).
+		Select("id", column).
+		Where("id > ?", lastID).
+		Order("id").
+		Limit(reencryptBatchSize).
+		Rows()
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var values []rawValue
+	for rows.Next() {
+		var value rawValue
+		if err := rows.Scan(&value.ID, &value.Value); err != nil {
+			return nil, err
+		}
+		values = append(values, value)
+	}
+	return values, rows.Err()
+}
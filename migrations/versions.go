@@ -0,0 +1,35 @@
+package migrations
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// schemaMigration records a one-off migration that was applied
+type schemaMigration struct {
+	Version   string `gorm:"primaryKey;size:100"`
+	AppliedAt time.Time
+}
+
+// TableName specifies the table name for schemaMigration
+func (schemaMigration) TableName() string {
+	return "schema_migrations"
+}
+
+// runOnce runs migrate unless version was already applied, and records it
+// once migrate succeeds. migrate must be safe to run again after it failed
+// halfway: MySQL commits DDL implicitly, so it cannot be rolled back.
+func runOnce(db *gorm.DB, version string, migrate func(db *gorm.DB) error) error {
+	var count int64
+	if err := db.Model(&schemaMigration{}).Where("version = ?", version).Count(&count).Error; err != nil {
+		return err
+	}
+	if count > 0 {
+		return nil
+	}
+	if err := migrate(db); err != nil {
+		return err
+	}
+	return db.Create(&schemaMigration{Version: version, AppliedAt: time.Now().UTC()}).Error
+}
@@ -0,0 +1,127 @@
+package kv
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// MemoryStore is an in-memory store.
+// NOTE: Keys are local to the process, so multiple server instances will
+// each keep their own copy. Use RedisStore to share them.
+type MemoryStore struct {
+	items map[string]*memoryItem
+	mu    sync.RWMutex
+}
+
+// memoryItem represents a stored value with its expiry
+type memoryItem struct {
+	value     []byte
+	expiresAt time.Time
+}
+
+// NewMemoryStore creates a new in-memory store, removing expired keys every
+// cleanupInterval
+func NewMemoryStore(cleanupInterval time.Duration) *MemoryStore {
+	s := &MemoryStore{
+		items: make(map[string]*memoryItem),
+	}
+
+	// Start cleanup goroutine
+	go s.cleanup(cleanupInterval)
+
+	return s
+}
+
+// cleanup removes expired items periodically
+func (s *MemoryStore) cleanup(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		now := time.Now()
+		s.mu.Lock()
+		for key, item := range s.items {
+			if now.After(item.expiresAt) {
+				delete(s.items, key)
+			}
+		}
+		s.mu.Unlock()
+	}
+}
+
+// Get returns the value stored under key
+func (s *MemoryStore) Get(ctx context.Context, key string) ([]byte, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	item, exists := s.items[key]
+	if !exists || time.Now().After(item.expiresAt) {
+		return nil, ErrNotFound
+	}
+	return item.value, nil
+}
+
+// Set stores value under key for the given TTL
+func (s *MemoryStore) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.items[key] = &memoryItem{
+		value:     value,
+		expiresAt: time.Now().Add(ttl),
+	}
+	return nil
+}
+
+// Incr increments the counter under key, starting a new one expiring after
+// ttl when it is missing or expired
+func (s *MemoryStore) Incr(ctx context.Context, key string, ttl time.Duration) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	item, exists := s.items[key]
+	if !exists || now.After(item.expiresAt) {
+		item = &memoryItem{expiresAt: now.Add(ttl)}
+		s.items[key] = item
+	}
+
+	var count int64
+	if len(item.value) > 0 {
+		parsed, err := strconv.ParseInt(string(item.value), 10, 64)
+		if err != nil {
+			return 0, err
+		}
+		count = parsed
+	}
+	count++
+	item.value = []byte(strconv.FormatInt(count, 10))
+	return count, nil
+}
+
+// Delete removes the given keys
+func (s *MemoryStore) Delete(ctx context.Context, keys ...string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, key := range keys {
+		delete(s.items, key)
+	}
+	return nil
+}
+
+// DeletePrefix removes every key starting with prefix
+func (s *MemoryStore) DeletePrefix(ctx context.Context, prefix string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for key := range s.items {
+		if strings.HasPrefix(key, prefix) {
+			delete(s.items, key)
+		}
+	}
+	return nil
+}
@@ -8,6 +8,7 @@ import (
 // Logger represents application logger
 type Logger struct {
 	info  *log.Logger
+	warn  *log.Logger
 	error *log.Logger
 	fatal *log.Logger
 }
@@ -16,6 +17,7 @@ type Logger struct {
 func New() *Logger {
 	return &Logger{
 		info:  log.New(os.Stdout, "INFO: ", log.Ldate|log.Ltime|log.Lshortfile),
+		warn:  log.New(os.Stderr, "WARN: ", log.Ldate|log.Ltime|log.Lshortfile),
 		error: log.New(os.Stderr, "ERROR: ", log.Ldate|log.Ltime|log.Lshortfile),
 		fatal: log.New(os.Stderr, "FATAL: ", log.Ldate|log.Ltime|log.Lshortfile),
 	}
@@ -31,6 +33,16 @@ func (l *Logger) Infof(format string, v ...interface{}) {
 	l.info.Printf(format, v...)
 }
 
+// Warn logs warning message
+func (l *Logger) Warn(v ...interface{}) {
+	l.warn.Println(v...)
+}
+
+// Warnf logs formatted warning message
+func (l *Logger) Warnf(format string, v ...interface{}) {
+	l.warn.Printf(format, v...)
+}
+
 // Error logs error message
 func (l *Logger) Error(v ...interface{}) {
 	l.error.Println(v...)
@@ -0,0 +1,118 @@
+// Package webhooks defines the payload format of webhook deliveries and
+// helpers for consumers to verify them.
+//
+// Every delivery is a JSON Envelope posted with a signature header of the form
+//
+//	X-Webhook-Signature: t=1700000000,v1=<hex HMAC-SHA256 of "<t>.<body>">
+//
+// Consumers should verify the signature with Parse or Verify before trusting the payload.
+package webhooks
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// SchemaVersion is the version of the envelope format. It changes only for
+// incompatible changes; new fields may be added to the same version.
+const SchemaVersion = 1
+
+// Headers of webhook deliveries
+const (
+	HeaderSignature = "X-Webhook-Signature"
+	HeaderID        = "X-Webhook-ID"
+)
+
+// DefaultTolerance is the recommended maximum age of a delivery, limiting replays
+const DefaultTolerance = 5 * time.Minute
+
+var (
+	// ErrInvalidHeader is returned when the signature header cannot be parsed
+	ErrInvalidHeader = errors.New("webhooks: invalid signature header")
+	// ErrSignatureMismatch is returned when no signature matches the payload
+	ErrSignatureMismatch = errors.New("webhooks: signature mismatch")
+	// ErrTimestampOutsideTolerance is returned for deliveries that are too old or from the future
+	ErrTimestampOutsideTolerance = errors.New("webhooks: timestamp outside tolerance")
+)
+
+// Envelope is the JSON body of every webhook delivery
+type Envelope struct {
+	ID            string          `json:"id"`
+	Type          string          `json:"type"`
+	Created       time.Time       `json:"created"`
+	SchemaVersion int             `json:"schema_version"`
+	Data          json.RawMessage `json:"data"`
+}
+
+// Sign returns the signature header value of payload sent at timestamp
+func Sign(secret []byte, timestamp time.Time, payload []byte) string {
+	t := strconv.FormatInt(timestamp.Unix(), 10)
+	return "t=" + t + ",v1=" + computeSignature(secret, t, payload)
+}
+
+// Verify checks the signature header of payload and that it was signed
+// within tolerance of now. The header may carry several v1 signatures,
+// e.g. while the signing secret is rotated.
+func Verify(secret []byte, header string, payload []byte, tolerance time.Duration, now time.Time) error {
+	var timestamp string
+	var signatures []string
+	for _, part := range strings.Split(header, ",") {
+		key, value, ok := strings.Cut(strings.TrimSpace(part), "=")
+		if !ok {
+			return ErrInvalidHeader
+		}
+		switch key {
+		case "t":
+			timestamp = value
+		case "v1":
+			signatures = append(signatures, value)
+		}
+	}
+	if timestamp == "" || len(signatures) == 0 {
+		return ErrInvalidHeader
+	}
+
+	unix, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return ErrInvalidHeader
+	}
+	if age := now.Sub(time.Unix(unix, 0)); age > tolerance || age < -tolerance {
+		return ErrTimestampOutsideTolerance
+	}
+
+	expected := computeSignature(secret, timestamp, payload)
+	for _, signature := range signatures {
+		if hmac.Equal([]byte(signature), []byte(expected)) {
+			return nil
+		}
+	}
+	return ErrSignatureMismatch
+}
+
+// Parse verifies a delivery with DefaultTolerance and decodes its envelope
+func Parse(secret []byte, header string, payload []byte) (*Envelope, error) {
+	if err := Verify(secret, header, payload, DefaultTolerance, time.Now()); err != nil {
+		return nil, err
+	}
+
+	var envelope Envelope
+	if err := json.Unmarshal(payload, &envelope); err != nil {
+		return nil, err
+	}
+	return &envelope, nil
+}
+
+// computeSignature is the hex HMAC-SHA256 of "<timestamp>.<payload>"
+func computeSignature(secret []byte, timestamp string, payload []byte) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(payload)
+	return hex.EncodeToString(mac.Sum(nil))
+}
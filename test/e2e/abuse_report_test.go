@@ -0,0 +1,177 @@
+package e2e
+
+import (
+	"bytes"
+	"encoding/json"
+	"gojwt-rest-api/internal/config"
+	"gojwt-rest-api/internal/domain"
+	"gojwt-rest-api/internal/handler"
+	"gojwt-rest-api/internal/middleware"
+	"gojwt-rest-api/internal/service"
+	"gojwt-rest-api/pkg/validator"
+	"gojwt-rest-api/test/helpers"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// abuseReportFixture routes the public report endpoint, as configured when
+// enabled, and the admin triage endpoints to mocked reports
+type abuseReportFixture struct {
+	router  *gin.Engine
+	captcha *fakeCaptcha
+	repo    *helpers.MockAbuseReportRepository
+}
+
+func newAbuseReportFixture(t *testing.T, reportsPerWindow int) *abuseReportFixture {
+	t.Helper()
+	f := &abuseReportFixture{
+		router:  setupRouter(),
+		captcha: &fakeCaptcha{},
+		repo:    new(helpers.MockAbuseReportRepository),
+	}
+	auditRepo := new(helpers.MockAuditLogRepository)
+	auditRepo.On("Create", mock.Anything).Return(nil)
+	v, err := validator.New()
+	require.NoError(t, err)
+	reportHandler := handler.NewAbuseReportHandler(service.NewAbuseReportService(f.repo, service.NewAuditService(auditRepo)), v)
+
+	limiter := middleware.NewRateLimiter(config.RateLimitConfig{RequestsPerDuration: reportsPerWindow, Duration: time.Minute, CleanupInterval: time.Minute})
+	f.router.POST("/report", middleware.RateLimitMiddleware(limiter), middleware.CaptchaMiddleware(f.captcha), reportHandler.Submit)
+	f.router.GET("/admin/reports", reportHandler.ListReports)
+	f.router.PUT("/admin/reports/:id", reportHandler.TriageReport)
+	return f
+}
+
+func (f *abuseReportFixture) request(method, path string, body interface{}, captchaToken string) *httptest.ResponseRecorder {
+	payload, _ := json.Marshal(body)
+	req := httptest.NewRequest(method, path, bytes.NewReader(payload))
+	req.Header.Set("Content-Type", "application/json")
+	if captchaToken != "" {
+		req.Header.Set(middleware.CaptchaHeader, captchaToken)
+	}
+	w := httptest.NewRecorder()
+	f.router.ServeHTTP(w, req)
+	return w
+}
+
+func TestSubmitAbuseReport(t *testing.T) {
+	report := map[string]interface{}{
+		"category":          "security",
+		"reported_user_id":  42,
+		"session_reference": "ios app",
+		"contact_email":     "Reporter@Example.com",
+		"message":           "This account is sending phishing links",
+	}
+
+	t.Run("Stores the report and returns a receipt", func(t *testing.T) {
+		f := newAbuseReportFixture(t, 10)
+		f.repo.On("Create", mock.AnythingOfType("*domain.AbuseReport")).Run(func(args mock.Arguments) {
+			args.Get(0).(*domain.AbuseReport).ID = 7
+		}).Return(nil)
+
+		w := f.request(http.MethodPost, "/report", report, "solved")
+
+		require.Equal(t, http.StatusCreated, w.Code)
+		var response struct {
+			Data map[string]interface{} `json:"data"`
+		}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		assert.Equal(t, float64(7), response.Data["id"])
+		assert.NotContains(t, response.Data, "message")
+
+		stored := f.repo.Calls[0].Arguments.Get(0).(*domain.AbuseReport)
+		assert.Equal(t, domain.AbuseReportStatusOpen, stored.Status)
+		assert.Equal(t, uint(42), *stored.ReportedUserID)
+		assert.Equal(t, "reporter@example.com", stored.ContactEmail)
+		assert.NotEmpty(t, stored.ReporterIP)
+	})
+
+	t.Run("Validates the report", func(t *testing.T) {
+		f := newAbuseReportFixture(t, 10)
+
+		assert.Equal(t, http.StatusBadRequest, f.request(http.MethodPost, "/report",
+			map[string]interface{}{"category": "spam", "message": "This account is sending phishing links"}, "solved").Code)
+		assert.Equal(t, http.StatusBadRequest, f.request(http.MethodPost, "/report",
+			map[string]interface{}{"category": "abuse", "message": "short"}, "solved").Code)
+		f.repo.AssertNotCalled(t, "Create", mock.Anything)
+	})
+
+	t.Run("Requires a CAPTCHA", func(t *testing.T) {
+		f := newAbuseReportFixture(t, 10)
+
+		assert.Equal(t, http.StatusBadRequest, f.request(http.MethodPost, "/report", report, "").Code)
+		assert.Equal(t, http.StatusBadRequest, f.request(http.MethodPost, "/report", report, "forged").Code)
+		f.repo.AssertNotCalled(t, "Create", mock.Anything)
+	})
+
+	t.Run("Is rate limited per client", func(t *testing.T) {
+		f := newAbuseReportFixture(t, 2)
+		f.repo.On("Create", mock.Anything).Return(nil)
+
+		for i := 0; i < 2; i++ {
+			require.Equal(t, http.StatusCreated, f.request(http.MethodPost, "/report", report, "solved").Code)
+		}
+		assert.Equal(t, http.StatusTooManyRequests, f.request(http.MethodPost, "/report", report, "solved").Code)
+	})
+}
+
+func TestTriageAbuseReports(t *testing.T) {
+	t.Run("Lists reports filtered by status", func(t *testing.T) {
+		f := newAbuseReportFixture(t, 10)
+		f.repo.On("Find", &domain.AbuseReportFilter{Status: domain.AbuseReportStatusOpen}, 0, 10).
+			Return([]*domain.AbuseReport{{ID: 7, Category: domain.AbuseReportCategoryAbuse, Status: domain.AbuseReportStatusOpen}}, int64(1), nil)
+
+		w := f.request(http.MethodGet, "/admin/reports?status=open", nil, "")
+
+		require.Equal(t, http.StatusOK, w.Code)
+		var response struct {
+			Data struct {
+				Data       []domain.AbuseReportResponse `json:"data"`
+				TotalItems int64                        `json:"total_items"`
+			} `json:"data"`
+		}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		require.Len(t, response.Data.Data, 1)
+		assert.Equal(t, uint(7), response.Data.Data[0].ID)
+		assert.Equal(t, int64(1), response.Data.TotalItems)
+	})
+
+	t.Run("Rejects unknown filters", func(t *testing.T) {
+		f := newAbuseReportFixture(t, 10)
+
+		assert.Equal(t, http.StatusBadRequest, f.request(http.MethodGet, "/admin/reports?status=closed", nil, "").Code)
+		assert.Equal(t, http.StatusBadRequest, f.request(http.MethodGet, "/admin/reports?category=spam", nil, "").Code)
+	})
+
+	t.Run("Sets the status of a report", func(t *testing.T) {
+		f := newAbuseReportFixture(t, 10)
+		f.repo.On("FindByID", uint(7)).Return(&domain.AbuseReport{ID: 7, Status: domain.AbuseReportStatusOpen}, nil)
+		f.repo.On("Update", mock.Anything).Return(nil)
+
+		w := f.request(http.MethodPut, "/admin/reports/7", map[string]string{"status": "resolved", "note": "Account suspended"}, "")
+
+		require.Equal(t, http.StatusOK, w.Code)
+		var response struct {
+			Data domain.AbuseReportResponse `json:"data"`
+		}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		assert.Equal(t, domain.AbuseReportStatusResolved, response.Data.Status)
+		assert.Equal(t, "Account suspended", response.Data.TriageNote)
+	})
+
+	t.Run("Unknown reports are not found", func(t *testing.T) {
+		f := newAbuseReportFixture(t, 10)
+		f.repo.On("FindByID", uint(8)).Return(nil, domain.ErrAbuseReportNotFound)
+
+		w := f.request(http.MethodPut, "/admin/reports/8", map[string]string{"status": "dismissed"}, "")
+
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+}
@@ -0,0 +1,94 @@
+package e2e
+
+import (
+	"bytes"
+	"gojwt-rest-api/internal/domain"
+	"gojwt-rest-api/internal/handler"
+	"gojwt-rest-api/internal/middleware"
+	"gojwt-rest-api/internal/service"
+	"gojwt-rest-api/internal/utils"
+	"gojwt-rest-api/pkg/validator"
+	"gojwt-rest-api/test/helpers"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestAdminScopeMiddleware(t *testing.T) {
+	jwtSecret := "test-secret"
+
+	mockRepo := new(helpers.MockUserRepository)
+	mockTokenRepo := new(helpers.MockTokenRepository)
+	userService := service.NewUserService(mockRepo, mockTokenRepo, jwtSecret, 15*time.Minute, 7*24*time.Hour)
+	v, _ := validator.New()
+	userHandler := handler.NewUserHandler(userService, v)
+
+	router := setupRouter()
+	router.Use(middleware.AuthMiddleware(jwtSecret))
+	router.GET("/users/:id", middleware.AdminScopeMiddleware(userService, domain.ScopeAdminUserRead), userHandler.GetUserByID)
+	router.DELETE("/users/:id", middleware.AdminScopeMiddleware(userService, domain.ScopeAdminUserWrite), userHandler.DeleteUser)
+	router.PUT("/users/:id/admin-scopes", middleware.AdminMiddleware(userService), userHandler.UpdateAdminScopes)
+
+	admin := helpers.CreateTestUser(1, "admin@example.com")
+	admin.IsAdmin = true
+	support := helpers.CreateTestUser(2, "support@example.com")
+	support.AdminScopes = domain.ScopeAdminUserRead
+	target := helpers.CreateTestUser(3, "john@example.com")
+
+	mockRepo.On("FindByID", uint(1)).Return(admin, nil)
+	mockRepo.On("FindByID", uint(2)).Return(support, nil)
+	mockRepo.On("FindByID", uint(3)).Return(target, nil)
+
+	doRequest := func(method, path string, user *domain.User, body string) *httptest.ResponseRecorder {
+		token, _ := utils.GenerateToken(user.ID, user.Email, jwtSecret, time.Hour)
+		req, _ := http.NewRequest(method, path, bytes.NewBufferString(body))
+		req.Header.Set("Authorization", "Bearer "+token)
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("Read scope allows viewing users", func(t *testing.T) {
+		assert.Equal(t, http.StatusOK, doRequest(http.MethodGet, "/users/3", support, "").Code)
+	})
+
+	t.Run("Read scope does not allow deleting users", func(t *testing.T) {
+		w := doRequest(http.MethodDelete, "/users/3", support, "")
+
+		assert.Equal(t, http.StatusForbidden, w.Code)
+		assert.Contains(t, w.Body.String(), domain.ScopeAdminUserWrite)
+		mockRepo.AssertNotCalled(t, "DeleteWithCleanup", mock.Anything)
+	})
+
+	t.Run("Users without scopes are rejected", func(t *testing.T) {
+		assert.Equal(t, http.StatusForbidden, doRequest(http.MethodGet, "/users/1", target, "").Code)
+	})
+
+	t.Run("Admins hold every scope", func(t *testing.T) {
+		mockRepo.On("DeleteWithCleanup", mock.AnythingOfType("*domain.UserDeletion")).Return(nil).Once()
+
+		assert.Equal(t, http.StatusOK, doRequest(http.MethodDelete, "/users/3", admin, "").Code)
+	})
+
+	t.Run("Only admins grant scopes", func(t *testing.T) {
+		body := `{"scopes":["admin:user-read","admin:user-write"]}`
+		assert.Equal(t, http.StatusForbidden, doRequest(http.MethodPut, "/users/2/admin-scopes", support, body).Code)
+
+		mockRepo.On("Update", mock.AnythingOfType("*domain.User")).Return(nil).Once()
+		w := doRequest(http.MethodPut, "/users/3/admin-scopes", admin, body)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "admin:user-read,admin:user-write", target.AdminScopes)
+	})
+
+	t.Run("Unknown scopes are rejected", func(t *testing.T) {
+		w := doRequest(http.MethodPut, "/users/3/admin-scopes", admin, `{"scopes":["admin:everything"]}`)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+}
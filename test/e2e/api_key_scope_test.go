@@ -0,0 +1,77 @@
+package e2e
+
+import (
+	"gojwt-rest-api/internal/domain"
+	"gojwt-rest-api/internal/middleware"
+	"gojwt-rest-api/internal/service"
+	"gojwt-rest-api/internal/utils"
+	"gojwt-rest-api/test/helpers"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAuthMiddleware_APIKeyScopes(t *testing.T) {
+	jwtSecret := "test-secret"
+
+	apiKeyRepo := new(helpers.MockAPIKeyRepository)
+	owner := *helpers.CreateTestUser(1, "owner@example.com")
+	owner.IsAdmin = true
+	apiKeyRepo.On("FindByHash", utils.HashAPIKey("read-key")).
+		Return(&domain.APIKey{ID: 1, UserID: 1, Scopes: domain.ScopeProfileRead, User: owner}, nil)
+	apiKeyRepo.On("FindByHash", utils.HashAPIKey("legacy-key")).
+		Return(&domain.APIKey{ID: 2, UserID: 1, User: owner}, nil)
+	apiKeyService := service.NewAPIKeyService(apiKeyRepo, 0)
+
+	router := setupRouter()
+	router.Use(middleware.APIKeyMiddleware(apiKeyService, service.NewAPIKeyUsageTracker(apiKeyRepo)))
+	router.Use(middleware.AuthMiddleware(jwtSecret, middleware.AcceptAPIKeys(middleware.APIKeyRoutes{
+		"GET /profile": domain.ScopeProfileRead,
+		"PUT /profile": domain.ScopeProfileWrite,
+	})))
+	ok := func(c *gin.Context) { c.Status(http.StatusOK) }
+	router.GET("/profile", ok)
+	router.PUT("/profile", ok)
+	router.POST("/api-keys", ok)
+	router.GET("/admin/users", ok)
+
+	doRequest := func(method, path, key string) int {
+		req := httptest.NewRequest(method, path, nil)
+		req.Header.Set("X-API-Key", key)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	t.Run("Accepts keys holding the scope of the route", func(t *testing.T) {
+		assert.Equal(t, http.StatusOK, doRequest(http.MethodGet, "/profile", "read-key"))
+	})
+
+	t.Run("Refuses keys missing the scope of the route", func(t *testing.T) {
+		assert.Equal(t, http.StatusForbidden, doRequest(http.MethodPut, "/profile", "read-key"))
+		assert.Equal(t, http.StatusForbidden, doRequest(http.MethodGet, "/profile", "legacy-key"), "keys without scopes")
+	})
+
+	t.Run("Refuses keys on routes not accepting them, whatever the owner's role", func(t *testing.T) {
+		assert.Equal(t, http.StatusForbidden, doRequest(http.MethodPost, "/api-keys", "read-key"))
+		assert.Equal(t, http.StatusForbidden, doRequest(http.MethodGet, "/admin/users", "read-key"))
+	})
+
+	t.Run("Refuses keys on routes requiring a recent authentication", func(t *testing.T) {
+		recent := setupRouter()
+		recent.Use(middleware.APIKeyMiddleware(apiKeyService, service.NewAPIKeyUsageTracker(apiKeyRepo)))
+		recent.GET("/profile", middleware.AuthMiddleware(jwtSecret, middleware.AcceptAPIKeys(middleware.APIKeyRoutes{
+			"GET /profile": domain.ScopeProfileRead,
+		})), middleware.RecentAuthMiddleware(time.Minute), ok)
+
+		req := httptest.NewRequest(http.MethodGet, "/profile", nil)
+		req.Header.Set("X-API-Key", "read-key")
+		w := httptest.NewRecorder()
+		recent.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusForbidden, w.Code)
+	})
+}
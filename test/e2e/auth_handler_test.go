@@ -5,7 +5,9 @@ import (
 	"encoding/json"
 	"gojwt-rest-api/internal/domain"
 	"gojwt-rest-api/internal/handler"
+	"gojwt-rest-api/internal/middleware"
 	"gojwt-rest-api/internal/service"
+	"gojwt-rest-api/internal/utils"
 	"gojwt-rest-api/pkg/validator"
 	"gojwt-rest-api/test/helpers"
 	"net/http"
@@ -64,6 +66,67 @@ func TestAuthHandler_Register(t *testing.T) {
 		mockRepo.AssertExpectations(t)
 	})
 
+	t.Run("Register with name parts instead of a name", func(t *testing.T) {
+		mockRepo := new(helpers.MockUserRepository)
+		userService := service.NewUserService(mockRepo, new(helpers.MockTokenRepository), "test-secret", 15*time.Minute, 7*24*time.Hour)
+		v, _ := validator.New()
+		authHandler := handler.NewAuthHandler(userService, v)
+
+		router := setupRouter()
+		router.POST("/register", authHandler.Register)
+
+		mockRepo.On("FindByEmail", "john@example.com").Return(nil, domain.ErrUserNotFound)
+		mockRepo.On("Create", mock.AnythingOfType("*domain.User")).Return(nil)
+
+		for body, status := range map[string]int{
+			`{"first_name":"John","last_name":"Doe","email":"john@example.com","password":"password123"}`: http.StatusCreated,
+			`{"last_name":"Doe","email":"john@example.com","password":"password123"}`:                     http.StatusBadRequest,
+		} {
+			req, _ := http.NewRequest(http.MethodPost, "/register", bytes.NewBufferString(body))
+			req.Header.Set("Content-Type", "application/json")
+			w := httptest.NewRecorder()
+
+			router.ServeHTTP(w, req)
+
+			require.Equal(t, status, w.Code, body)
+			if status == http.StatusCreated {
+				var response map[string]interface{}
+				require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+				data := response["data"].(map[string]interface{})
+				assert.Equal(t, "John Doe", data["name"])
+				assert.Equal(t, "John", data["first_name"])
+			}
+		}
+	})
+
+	t.Run("Register when self-registration is disabled", func(t *testing.T) {
+		mockRepo := new(helpers.MockUserRepository)
+		userService := service.NewUserService(mockRepo, new(helpers.MockTokenRepository), "test-secret", 15*time.Minute, 7*24*time.Hour,
+			service.WithSelfRegistrationDisabled(nil))
+		v, _ := validator.New()
+		authHandler := handler.NewAuthHandler(userService, v, handler.WithConcealedRegistration())
+
+		router := setupRouter()
+		router.POST("/register", authHandler.Register)
+
+		jsonBody, _ := json.Marshal(map[string]string{
+			"name":     "John Doe",
+			"email":    "john@example.com",
+			"password": "password123",
+		})
+		req, _ := http.NewRequest(http.MethodPost, "/register", bytes.NewBuffer(jsonBody))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusForbidden, w.Code)
+		var response domain.Response
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		assert.Equal(t, domain.ErrSelfRegistrationDisabled.Error(), response.Message)
+		mockRepo.AssertNotCalled(t, "Create", mock.Anything)
+	})
+
 	t.Run("Register with invalid email format", func(t *testing.T) {
 		mockRepo := new(helpers.MockUserRepository)
 		mockTokenRepo := new(helpers.MockTokenRepository)
@@ -216,8 +279,11 @@ func TestAuthHandler_Login(t *testing.T) {
 
 		// Mock: user found
 		mockRepo.On("FindByEmail", "john@example.com").Return(user, nil)
+		// Mock: first login recorded
+		mockRepo.On("MarkFirstLogin", user.ID, mock.AnythingOfType("time.Time")).Return(true, nil)
 		// Mock: token creation
 		mockTokenRepo.On("CreateRefreshToken", mock.AnythingOfType("*domain.RefreshToken")).Return(nil)
+		mockRepo.On("RecordLogin", user.ID, mock.AnythingOfType("time.Time")).Return(nil)
 
 		req, _ := http.NewRequest(http.MethodPost, "/login", bytes.NewBuffer(jsonBody))
 		req.Header.Set("Content-Type", "application/json")
@@ -368,3 +434,65 @@ func TestAuthHandler_Login(t *testing.T) {
 		assert.False(t, response.Success)
 	})
 }
+
+func TestAuthHandler_Me(t *testing.T) {
+	t.Run("Returns claims, user, roles and scopes", func(t *testing.T) {
+		mockRepo := new(helpers.MockUserRepository)
+		mockTokenRepo := new(helpers.MockTokenRepository)
+		jwtSecret := "test-secret"
+		userService := service.NewUserService(mockRepo, mockTokenRepo, jwtSecret, 15*time.Minute, 7*24*time.Hour)
+		v, _ := validator.New()
+		authHandler := handler.NewAuthHandler(userService, v)
+
+		router := setupRouter()
+		router.Use(middleware.AuthMiddleware(jwtSecret))
+		router.GET("/me", authHandler.Me)
+
+		user := helpers.CreateAdminUser(1, "admin@example.com")
+		mockRepo.On("FindByID", uint(1)).Return(user, nil)
+
+		token, _ := utils.GenerateToken(user.ID, user.Email, jwtSecret, 15*time.Minute)
+
+		req, _ := http.NewRequest(http.MethodGet, "/me", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		var response struct {
+			Data domain.MeResponse `json:"data"`
+		}
+		err := json.Unmarshal(w.Body.Bytes(), &response)
+		require.NoError(t, err)
+		assert.Equal(t, user.ID, response.Data.Claims.UserID)
+		assert.Equal(t, user.Email, response.Data.Claims.Email)
+		assert.InDelta(t, (15 * time.Minute).Seconds(), response.Data.Claims.ExpiresIn, 5)
+		assert.Equal(t, user.Email, response.Data.User.Email)
+		assert.Contains(t, response.Data.Roles, domain.RoleAdmin)
+		assert.Contains(t, response.Data.Scopes, domain.ScopeAdmin)
+
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("Without token", func(t *testing.T) {
+		mockRepo := new(helpers.MockUserRepository)
+		mockTokenRepo := new(helpers.MockTokenRepository)
+		jwtSecret := "test-secret"
+		userService := service.NewUserService(mockRepo, mockTokenRepo, jwtSecret, 15*time.Minute, 7*24*time.Hour)
+		v, _ := validator.New()
+		authHandler := handler.NewAuthHandler(userService, v)
+
+		router := setupRouter()
+		router.Use(middleware.AuthMiddleware(jwtSecret))
+		router.GET("/me", authHandler.Me)
+
+		req, _ := http.NewRequest(http.MethodGet, "/me", nil)
+		w := httptest.NewRecorder()
+
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+	})
+}
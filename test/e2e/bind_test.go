@@ -0,0 +1,60 @@
+package e2e
+
+import (
+	"bytes"
+	"encoding/json"
+	"gojwt-rest-api/internal/domain"
+	"gojwt-rest-api/internal/handler"
+	"gojwt-rest-api/pkg/validator"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBind(t *testing.T) {
+	v, err := validator.New()
+	require.NoError(t, err)
+
+	router := setupRouter()
+	router.POST("/login", func(c *gin.Context) {
+		req, ok := handler.Bind[domain.LoginRequest](c, v)
+		if !ok {
+			return
+		}
+		c.JSON(http.StatusOK, domain.SuccessResponse("bound", req.Email))
+	})
+	post := func(body string) (int, domain.Response) {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodPost, "/login", bytes.NewBufferString(body))
+		req.Header.Set("Content-Type", "application/json")
+		router.ServeHTTP(w, req)
+
+		var resp domain.Response
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		return w.Code, resp
+	}
+
+	t.Run("Valid bodies are bound", func(t *testing.T) {
+		code, resp := post(`{"email":"jane@example.com","password":"secret"}`)
+		assert.Equal(t, http.StatusOK, code)
+		assert.Equal(t, "jane@example.com", resp.Data)
+	})
+
+	t.Run("Malformed bodies are rejected with the decoding error", func(t *testing.T) {
+		code, resp := post(`{"email":`)
+		assert.Equal(t, http.StatusBadRequest, code)
+		assert.Equal(t, domain.ErrInvalidRequest.Error(), resp.Message)
+		assert.IsType(t, "", resp.Error)
+	})
+
+	t.Run("Invalid bodies are rejected with field errors", func(t *testing.T) {
+		code, resp := post(`{"email":"not-an-email"}`)
+		assert.Equal(t, http.StatusBadRequest, code)
+		assert.Equal(t, domain.ErrValidationFailed.Error(), resp.Message)
+		assert.Len(t, resp.Error, 2)
+	})
+}
@@ -0,0 +1,157 @@
+package e2e
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"gojwt-rest-api/internal/config"
+	"gojwt-rest-api/internal/domain"
+	"gojwt-rest-api/internal/events"
+	"gojwt-rest-api/internal/handler"
+	"gojwt-rest-api/internal/middleware"
+	"gojwt-rest-api/internal/service"
+	"gojwt-rest-api/pkg/validator"
+	"gojwt-rest-api/test/factory"
+	"gojwt-rest-api/test/helpers"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeCaptcha accepts the response "solved" and fails with err when set
+type fakeCaptcha struct {
+	err error
+}
+
+func (f *fakeCaptcha) Verify(ctx context.Context, response, remoteIP string) error {
+	if f.err != nil {
+		return f.err
+	}
+	if response != "solved" {
+		return domain.ErrCaptchaInvalid
+	}
+	return nil
+}
+
+// signupFixture routes registration and email checks, as configured in
+// privacy mode, to a user service on in-memory repositories
+type signupFixture struct {
+	router    *gin.Engine
+	captcha   *fakeCaptcha
+	publisher *helpers.MockEventPublisher
+}
+
+func newSignupFixture(t *testing.T, checksPerWindow int) *signupFixture {
+	t.Helper()
+	f := &signupFixture{
+		router:    setupRouter(),
+		captcha:   &fakeCaptcha{},
+		publisher: new(helpers.MockEventPublisher),
+	}
+	f.publisher.On("Publish", mock.Anything, mock.Anything).Return()
+
+	userRepo := helpers.NewMemoryUserRepository()
+	require.NoError(t, userRepo.Create(factory.New().User(factory.WithEmail("taken@example.com"))))
+	userService := service.NewUserService(userRepo, helpers.NewMemoryTokenRepository(), factory.DefaultSecret, time.Minute, time.Hour,
+		service.WithEventPublisher(f.publisher))
+	v, err := validator.New()
+	require.NoError(t, err)
+	authHandler := handler.NewAuthHandler(userService, v, handler.WithConcealedRegistration())
+
+	// As the server without SERVER_TRUSTED_PROXIES, client IPs are connection peers
+	require.NoError(t, f.router.SetTrustedProxies(nil))
+	limiter := middleware.NewRateLimiter(config.RateLimitConfig{RequestsPerDuration: checksPerWindow, Duration: time.Minute, CleanupInterval: time.Minute})
+	f.router.POST("/register", authHandler.Register)
+	f.router.POST("/check-email", middleware.RateLimitMiddleware(limiter), middleware.CaptchaMiddleware(f.captcha), authHandler.CheckEmail)
+	return f
+}
+
+func (f *signupFixture) post(path string, body interface{}, captchaToken string) *httptest.ResponseRecorder {
+	payload, _ := json.Marshal(body)
+	req := httptest.NewRequest(http.MethodPost, path, bytes.NewReader(payload))
+	req.Header.Set("Content-Type", "application/json")
+	if captchaToken != "" {
+		req.Header.Set(middleware.CaptchaHeader, captchaToken)
+	}
+	w := httptest.NewRecorder()
+	f.router.ServeHTTP(w, req)
+	return w
+}
+
+func TestCheckEmail(t *testing.T) {
+	t.Run("Tells whether an email is available", func(t *testing.T) {
+		f := newSignupFixture(t, 10)
+
+		for email, available := range map[string]bool{"taken@example.com": false, "Taken@Example.com": false, "free@example.com": true} {
+			w := f.post("/check-email", map[string]string{"email": email}, "solved")
+
+			require.Equal(t, http.StatusOK, w.Code, email)
+			var response struct {
+				Data domain.CheckEmailResponse `json:"data"`
+			}
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+			assert.Equal(t, available, response.Data.Available, email)
+		}
+	})
+
+	t.Run("Requires a CAPTCHA", func(t *testing.T) {
+		f := newSignupFixture(t, 10)
+
+		assert.Equal(t, http.StatusBadRequest, f.post("/check-email", map[string]string{"email": "taken@example.com"}, "").Code)
+		assert.Equal(t, http.StatusBadRequest, f.post("/check-email", map[string]string{"email": "taken@example.com"}, "forged").Code)
+
+		f.captcha.err = errors.New("provider down")
+		assert.Equal(t, http.StatusServiceUnavailable, f.post("/check-email", map[string]string{"email": "taken@example.com"}, "solved").Code)
+	})
+
+	t.Run("Is rate limited per client", func(t *testing.T) {
+		f := newSignupFixture(t, 2)
+
+		for i := 0; i < 2; i++ {
+			require.Equal(t, http.StatusOK, f.post("/check-email", map[string]string{"email": "free@example.com"}, "solved").Code)
+		}
+		assert.Equal(t, http.StatusTooManyRequests, f.post("/check-email", map[string]string{"email": "free@example.com"}, "solved").Code)
+	})
+
+	t.Run("Ignores forged X-Forwarded-For headers", func(t *testing.T) {
+		f := newSignupFixture(t, 1)
+
+		codes := make([]int, 0, 2)
+		for _, forwardedFor := range []string{"203.0.113.1", "203.0.113.2"} {
+			req := httptest.NewRequest(http.MethodPost, "/check-email", bytes.NewReader([]byte(`{"email":"free@example.com"}`)))
+			req.Header.Set("Content-Type", "application/json")
+			req.Header.Set(middleware.CaptchaHeader, "solved")
+			req.Header.Set("X-Forwarded-For", forwardedFor)
+			w := httptest.NewRecorder()
+			f.router.ServeHTTP(w, req)
+			codes = append(codes, w.Code)
+		}
+
+		assert.Equal(t, []int{http.StatusOK, http.StatusTooManyRequests}, codes)
+	})
+}
+
+func TestRegister_ConcealsExistingEmail(t *testing.T) {
+	f := newSignupFixture(t, 10)
+
+	created := f.post("/register", map[string]string{"name": "New User", "email": "new@example.com", "password": "password123"}, "")
+	existing := f.post("/register", map[string]string{"name": "Prober", "email": "taken@example.com", "password": "password123"}, "")
+
+	assert.Equal(t, http.StatusAccepted, created.Code)
+	assert.Equal(t, created.Code, existing.Code)
+	assert.JSONEq(t, created.Body.String(), existing.Body.String())
+	f.publisher.AssertCalled(t, "Publish", events.UserRegistrationAttempted, mock.MatchedBy(func(d *events.UserRegistrationAttemptedData) bool {
+		return d.Email == "taken@example.com"
+	}))
+
+	// Other failures are still reported
+	invalid := f.post("/register", map[string]string{"name": "New User", "email": "not-an-email", "password": "password123"}, "")
+	assert.Equal(t, http.StatusBadRequest, invalid.Code)
+}
@@ -0,0 +1,144 @@
+package e2e
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"gojwt-rest-api/internal/config"
+	"gojwt-rest-api/internal/middleware"
+	"gojwt-rest-api/internal/service"
+	"gojwt-rest-api/test/helpers"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testCertificate issues a certificate signed by parent, or a self-signed CA when parent is nil
+func testCertificate(t *testing.T, template *x509.Certificate, parent *x509.Certificate, parentKey *ecdsa.PrivateKey) (*x509.Certificate, *ecdsa.PrivateKey) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	template.SerialNumber = big.NewInt(time.Now().UnixNano())
+	template.NotBefore = time.Now().Add(-time.Minute)
+	template.NotAfter = time.Now().Add(time.Hour)
+	if parent == nil {
+		parent, parentKey = template, key
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, parent, &key.PublicKey, parentKey)
+	require.NoError(t, err)
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+	return cert, key
+}
+
+// writePEM writes cert and optionally key as PEM files, returning their paths
+func writePEM(t *testing.T, dir, name string, cert *x509.Certificate, key *ecdsa.PrivateKey) (string, string) {
+	certPath := filepath.Join(dir, name+".crt")
+	require.NoError(t, os.WriteFile(certPath, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: cert.Raw}), 0o600))
+
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+	keyPath := filepath.Join(dir, name+".key")
+	require.NoError(t, os.WriteFile(keyPath, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0o600))
+	return certPath, keyPath
+}
+
+func TestClientCertMiddleware(t *testing.T) {
+	jwtSecret := "test-secret"
+	dir := t.TempDir()
+
+	ca, caKey := testCertificate(t, &x509.Certificate{
+		Subject:               pkix.Name{CommonName: "test-ca"},
+		IsCA:                  true,
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+	}, nil, nil)
+	server, serverKey := testCertificate(t, &x509.Certificate{
+		Subject:     pkix.Name{CommonName: "localhost"},
+		IPAddresses: []net.IP{net.ParseIP("127.0.0.1")},
+		ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}, ca, caKey)
+	deployerURI, _ := url.Parse("spiffe://prod/deployer")
+	deployer, deployerKey := testCertificate(t, &x509.Certificate{
+		Subject:     pkix.Name{CommonName: "deployer"},
+		URIs:        []*url.URL{deployerURI},
+		ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}, ca, caKey)
+	unknown, unknownKey := testCertificate(t, &x509.Certificate{
+		Subject:     pkix.Name{CommonName: "unknown"},
+		ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}, ca, caKey)
+
+	caPath, _ := writePEM(t, dir, "ca", ca, caKey)
+	serverCert, serverKeyPath := writePEM(t, dir, "server", server, serverKey)
+	tlsConfig, err := config.NewAdminTLSConfig(config.ServerConfig{
+		AdminTLSCert:  serverCert,
+		AdminTLSKey:   serverKeyPath,
+		AdminClientCA: caPath,
+	})
+	require.NoError(t, err)
+
+	mockRepo := new(helpers.MockUserRepository)
+	mockTokenRepo := new(helpers.MockTokenRepository)
+	userService := service.NewUserService(mockRepo, mockTokenRepo, jwtSecret, 15*time.Minute, 7*24*time.Hour)
+	serviceAccount := helpers.CreateTestUser(7, "deployer@example.com")
+	serviceAccount.IsAdmin = true
+	mockRepo.On("FindByID", uint(7)).Return(serviceAccount, nil)
+
+	router := setupRouter()
+	admin := router.Group("/admin")
+	admin.Use(middleware.ClientCertMiddleware(map[string]uint{"spiffe://prod/deployer": 7}))
+	admin.Use(middleware.AuthMiddleware(jwtSecret))
+	admin.Use(middleware.AdminMiddleware(userService))
+	admin.GET("/slo", func(c *gin.Context) {
+		identity, _ := middleware.GetClientIdentity(c)
+		userID, _ := middleware.GetUserID(c)
+		c.JSON(http.StatusOK, gin.H{"identity": identity, "user_id": userID})
+	})
+
+	srv := httptest.NewUnstartedServer(router)
+	srv.TLS = tlsConfig
+	srv.StartTLS()
+	defer srv.Close()
+
+	roots := x509.NewCertPool()
+	roots.AddCert(ca)
+	doRequest := func(cert *x509.Certificate, key *ecdsa.PrivateKey) *http.Response {
+		clientTLS := &tls.Config{RootCAs: roots}
+		if cert != nil {
+			clientTLS.Certificates = []tls.Certificate{{Certificate: [][]byte{cert.Raw}, PrivateKey: key}}
+		}
+		client := &http.Client{Transport: &http.Transport{TLSClientConfig: clientTLS}}
+		resp, err := client.Get(srv.URL + "/admin/slo")
+		require.NoError(t, err)
+		resp.Body.Close()
+		return resp
+	}
+
+	t.Run("Mapped client certificate authenticates as its principal", func(t *testing.T) {
+		assert.Equal(t, http.StatusOK, doRequest(deployer, deployerKey).StatusCode)
+	})
+
+	t.Run("Unmapped client certificate still needs a bearer token", func(t *testing.T) {
+		assert.Equal(t, http.StatusUnauthorized, doRequest(unknown, unknownKey).StatusCode)
+	})
+
+	t.Run("Requests without certificate need a bearer token", func(t *testing.T) {
+		assert.Equal(t, http.StatusUnauthorized, doRequest(nil, nil).StatusCode)
+	})
+}
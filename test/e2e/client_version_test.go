@@ -0,0 +1,71 @@
+package e2e
+
+import (
+	"encoding/json"
+	"gojwt-rest-api/internal/domain"
+	"gojwt-rest-api/internal/middleware"
+	"gojwt-rest-api/internal/service"
+	"gojwt-rest-api/test/helpers"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClientVersionMiddleware(t *testing.T) {
+	repo := new(helpers.MockClientVersionRepository)
+	repo.On("FindAll").Return([]*domain.ClientVersionPolicy{{ClientID: "ios", MinVersion: "2.4.0"}}, nil)
+	auditRepo := new(helpers.MockAuditLogRepository)
+	auditRepo.On("Create", mock.Anything).Return(nil)
+	versions := service.NewClientVersionService(repo, service.NewAuditService(auditRepo), time.Hour)
+
+	router := setupRouter()
+	router.Use(middleware.ClientVersionMiddleware(versions))
+	router.GET("/ping", func(c *gin.Context) { c.Status(http.StatusNoContent) })
+	doRequest := func(clientID, version string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest(http.MethodGet, "/ping", nil)
+		if clientID != "" {
+			req.Header.Set(middleware.ClientIDHeader, clientID)
+		}
+		if version != "" {
+			req.Header.Set(middleware.ClientVersionHeader, version)
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("Outdated versions must upgrade", func(t *testing.T) {
+		w := doRequest("ios", "2.3.1")
+
+		require.Equal(t, http.StatusUpgradeRequired, w.Code)
+		var response struct {
+			Error domain.ClientUpgradeRequired `json:"error"`
+		}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		assert.Equal(t, domain.ClientUpgradeRequired{
+			Code:           domain.ErrorCodeUpgradeRequired,
+			ClientID:       "ios",
+			ClientVersion:  "2.3.1",
+			MinimumVersion: "2.4.0",
+		}, response.Error)
+	})
+
+	t.Run("Supported versions pass", func(t *testing.T) {
+		assert.Equal(t, http.StatusNoContent, doRequest("ios", "2.4.0").Code)
+	})
+
+	t.Run("Malformed versions are rejected", func(t *testing.T) {
+		assert.Equal(t, http.StatusBadRequest, doRequest("ios", "beta").Code)
+	})
+
+	t.Run("Requests without client are not checked", func(t *testing.T) {
+		assert.Equal(t, http.StatusNoContent, doRequest("", "1.0").Code)
+		assert.Equal(t, http.StatusNoContent, doRequest("android", "1.0").Code)
+	})
+}
@@ -0,0 +1,203 @@
+package e2e
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"gojwt-rest-api/internal/handler"
+	"gojwt-rest-api/internal/middleware"
+	"gojwt-rest-api/internal/service"
+	"gojwt-rest-api/pkg/validator"
+	"gojwt-rest-api/test/helpers"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// updateContracts rewrites the golden files from the current responses:
+//
+//	go test ./test/e2e/ -run TestContract -update
+var updateContracts = flag.Bool("update", false, "rewrite contract golden files")
+
+const contractDir = "testdata/contract"
+
+// contractState carries the tokens issued by earlier requests to later ones
+type contractState struct {
+	accessToken  string
+	refreshToken string
+}
+
+// contractCase is a recorded request whose response shape is pinned by the
+// golden file testdata/contract/<name>.json
+type contractCase struct {
+	name   string
+	method string
+	path   string
+	body   func(s *contractState) interface{}
+	// auth sends the current access token
+	auth bool
+}
+
+// contractResponse is the content of a golden file
+type contractResponse struct {
+	Status int         `json:"status"`
+	Body   interface{} `json:"body"`
+}
+
+// setupContractRouter wires the real handlers to in-memory repositories
+func setupContractRouter(t *testing.T) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	jwtSecret := "contract-secret"
+
+	userService := service.NewUserService(helpers.NewMemoryUserRepository(), helpers.NewMemoryTokenRepository(),
+		jwtSecret, 15*time.Minute, 7*24*time.Hour)
+	v, err := validator.New()
+	require.NoError(t, err)
+	authHandler := handler.NewAuthHandler(userService, v)
+	profileHandler := handler.NewProfileHandler(userService, v)
+
+	router := gin.New()
+	v1 := router.Group("/api/v1")
+	v1.POST("/auth/register", authHandler.Register)
+	v1.POST("/auth/login", authHandler.Login)
+	v1.POST("/auth/refresh", authHandler.RefreshToken)
+
+	protected := v1.Group("")
+	protected.Use(middleware.AuthMiddleware(jwtSecret), middleware.RevocationMiddleware(userService))
+	protected.POST("/auth/logout", authHandler.Logout)
+	protected.GET("/auth/me", authHandler.Me)
+	protected.POST("/auth/reauthenticate", authHandler.Reauthenticate)
+	protected.GET("/profile", profileHandler.GetOwnProfile)
+	protected.PUT("/profile", profileHandler.UpdateOwnProfile)
+	protected.PUT("/profile/password", profileHandler.ChangePassword)
+	return router
+}
+
+func jsonBody(body interface{}) func(*contractState) interface{} {
+	return func(*contractState) interface{} { return body }
+}
+
+// contractCases run in order, sharing tokens
+var contractCases = []contractCase{
+	{name: "register_created", method: http.MethodPost, path: "/api/v1/auth/register",
+		body: jsonBody(map[string]string{"name": "Contract User", "email": "contract@example.com", "password": "password123"})},
+	{name: "register_validation_failed", method: http.MethodPost, path: "/api/v1/auth/register",
+		body: jsonBody(map[string]string{"name": "C", "email": "not-an-email", "password": "123"})},
+	{name: "register_conflict", method: http.MethodPost, path: "/api/v1/auth/register",
+		body: jsonBody(map[string]string{"name": "Contract User", "email": "contract@example.com", "password": "password123"})},
+	{name: "login_invalid_credentials", method: http.MethodPost, path: "/api/v1/auth/login",
+		body: jsonBody(map[string]string{"email": "contract@example.com", "password": "wrong-password"})},
+	{name: "login_ok", method: http.MethodPost, path: "/api/v1/auth/login",
+		body: jsonBody(map[string]string{"email": "contract@example.com", "password": "password123"})},
+	{name: "refresh_ok", method: http.MethodPost, path: "/api/v1/auth/refresh",
+		body: func(s *contractState) interface{} { return map[string]string{"refresh_token": s.refreshToken} }},
+	{name: "refresh_invalid", method: http.MethodPost, path: "/api/v1/auth/refresh",
+		body: jsonBody(map[string]string{"refresh_token": "unknown"})},
+	{name: "me_ok", method: http.MethodGet, path: "/api/v1/auth/me", auth: true},
+	{name: "reauthenticate_ok", method: http.MethodPost, path: "/api/v1/auth/reauthenticate", auth: true,
+		body: jsonBody(map[string]string{"password": "password123"})},
+	{name: "profile_ok", method: http.MethodGet, path: "/api/v1/profile", auth: true},
+	{name: "profile_unauthorized", method: http.MethodGet, path: "/api/v1/profile"},
+	{name: "profile_update_ok", method: http.MethodPut, path: "/api/v1/profile", auth: true,
+		body: jsonBody(map[string]string{"name": "Contract User Renamed"})},
+	{name: "password_change_ok", method: http.MethodPut, path: "/api/v1/profile/password", auth: true,
+		body: jsonBody(map[string]string{"old_password": "password123", "new_password": "password456"})},
+	{name: "logout_ok", method: http.MethodPost, path: "/api/v1/auth/logout", auth: true,
+		body: func(s *contractState) interface{} { return map[string]string{"refresh_token": s.refreshToken} }},
+}
+
+// TestContract replays the recorded requests and compares the status and shape
+// (envelope, field names and JSON types, not values) of each response with its
+// golden file, so response changes that would break clients fail here
+func TestContract(t *testing.T) {
+	router := setupContractRouter(t)
+	state := &contractState{}
+
+	for _, tc := range contractCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var payload []byte
+			if tc.body != nil {
+				var err error
+				payload, err = json.Marshal(tc.body(state))
+				require.NoError(t, err)
+			}
+			req := httptest.NewRequest(tc.method, tc.path, bytes.NewReader(payload))
+			req.Header.Set("Content-Type", "application/json")
+			if tc.auth {
+				req.Header.Set("Authorization", "Bearer "+state.accessToken)
+			}
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			var body interface{}
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body), w.Body.String())
+			state.capture(body)
+			got := contractResponse{Status: w.Code, Body: responseShape(body)}
+
+			path := filepath.Join(contractDir, tc.name+".json")
+			if *updateContracts {
+				writeContract(t, path, got)
+				return
+			}
+
+			raw, err := os.ReadFile(path)
+			require.NoError(t, err, "missing golden file, run with -update to record it")
+			var want contractResponse
+			require.NoError(t, json.Unmarshal(raw, &want))
+			assert.Equal(t, want.Status, got.Status, "status")
+			assert.Equal(t, want.Body, got.Body, "response shape differs from %s, run with -update if the change is intended", path)
+		})
+	}
+}
+
+// capture keeps the tokens of a response for later requests
+func (s *contractState) capture(body interface{}) {
+	envelope, _ := body.(map[string]interface{})
+	data, _ := envelope["data"].(map[string]interface{})
+	if token, ok := data["access_token"].(string); ok {
+		s.accessToken = token
+	}
+	if token, ok := data["refresh_token"].(string); ok {
+		s.refreshToken = token
+	}
+}
+
+// responseShape replaces every value with the name of its JSON type, keeping
+// object keys. Arrays are described by the shape of their first element.
+func responseShape(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		shape := make(map[string]interface{}, len(v))
+		for key, field := range v {
+			shape[key] = responseShape(field)
+		}
+		return shape
+	case []interface{}:
+		if len(v) == 0 {
+			return []interface{}{}
+		}
+		return []interface{}{responseShape(v[0])}
+	case string:
+		return "string"
+	case float64:
+		return "number"
+	case bool:
+		return "boolean"
+	default:
+		return "null"
+	}
+}
+
+func writeContract(t *testing.T, path string, response contractResponse) {
+	raw, err := json.MarshalIndent(response, "", "  ")
+	require.NoError(t, err)
+	require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+	require.NoError(t, os.WriteFile(path, append(raw, '\n'), 0o644))
+}
@@ -0,0 +1,52 @@
+package e2e
+
+import (
+	"gojwt-rest-api/internal/middleware"
+	"gojwt-rest-api/internal/utils"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRequireEntitlement(t *testing.T) {
+	jwtSecret := "test-secret"
+
+	router := setupRouter()
+	router.GET("/sso", middleware.AuthMiddleware(jwtSecret), middleware.RequireEntitlement("sso"), func(c *gin.Context) {
+		c.Status(http.StatusNoContent)
+	})
+
+	doRequest := func(token string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest(http.MethodGet, "/sso", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("Token with entitlement is allowed", func(t *testing.T) {
+		token, _ := utils.GenerateToken(1, "john@example.com", jwtSecret, time.Hour, func(claims *utils.JWTClaims) {
+			claims.Plan = "enterprise"
+			claims.Entitlements = []string{"audit_log", "sso"}
+		})
+
+		w := doRequest(token)
+
+		assert.Equal(t, http.StatusNoContent, w.Code)
+	})
+
+	t.Run("Token without entitlement is forbidden", func(t *testing.T) {
+		token, _ := utils.GenerateToken(1, "john@example.com", jwtSecret, time.Hour, func(claims *utils.JWTClaims) {
+			claims.Plan = "free"
+		})
+
+		w := doRequest(token)
+
+		assert.Equal(t, http.StatusForbidden, w.Code)
+		assert.Contains(t, w.Body.String(), `"entitlement":"sso"`)
+	})
+}
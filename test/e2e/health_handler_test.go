@@ -0,0 +1,70 @@
+package e2e
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"gojwt-rest-api/internal/handler"
+	"gojwt-rest-api/internal/lifecycle"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHealthHandler_Ready(t *testing.T) {
+	var databaseErr error
+	dependencies := lifecycle.NewHealthRegistry()
+	dependencies.Register("database", lifecycle.HealthCheckFunc(func(ctx context.Context) error {
+		return databaseErr
+	}))
+	dependencies.RegisterOptional("mailer", lifecycle.HealthCheckFunc(func(ctx context.Context) error {
+		return errors.New("dial tcp: connection refused")
+	}))
+	drainer := lifecycle.NewDrainer()
+
+	router := setupRouter()
+	router.GET("/health/ready", handler.NewHealthHandler(drainer, dependencies).Ready)
+
+	ready := func() (int, map[string]interface{}) {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/health/ready", nil))
+		var body map[string]interface{}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+		return w.Code, body
+	}
+
+	t.Run("Ready with a degraded optional dependency", func(t *testing.T) {
+		code, body := ready()
+
+		assert.Equal(t, http.StatusOK, code)
+		assert.Equal(t, "ready", body["status"])
+		checks := body["checks"].([]interface{})
+		require.Len(t, checks, 2)
+		assert.Equal(t, "down", checks[1].(map[string]interface{})["status"])
+	})
+
+	t.Run("Unavailable while a critical dependency is down", func(t *testing.T) {
+		databaseErr = errors.New("driver: bad connection")
+		defer func() { databaseErr = nil }()
+
+		code, body := ready()
+
+		assert.Equal(t, http.StatusServiceUnavailable, code)
+		assert.Equal(t, "unavailable", body["status"])
+		database := body["checks"].([]interface{})[0].(map[string]interface{})
+		assert.Equal(t, "database", database["name"])
+		assert.Equal(t, "driver: bad connection", database["error"])
+	})
+
+	t.Run("Draining takes precedence", func(t *testing.T) {
+		drainer.Start()
+
+		code, body := ready()
+
+		assert.Equal(t, http.StatusServiceUnavailable, code)
+		assert.Equal(t, "draining", body["status"])
+	})
+}
@@ -0,0 +1,79 @@
+package e2e
+
+import (
+	"gojwt-rest-api/internal/domain"
+	"gojwt-rest-api/migrations"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMigrate_LegacyInvitations(t *testing.T) {
+	db := setupTestDatabase(t)
+
+	// Recreate the state of a database migrated before invitations moved
+	require.NoError(t, db.Exec("DELETE FROM schema_migrations").Error)
+	require.NoError(t, db.Exec(`CREATE TABLE organization_invitations (
+		id BIGINT UNSIGNED AUTO_INCREMENT PRIMARY KEY,
+		organization_id BIGINT UNSIGNED NOT NULL,
+		email VARCHAR(255) NOT NULL,
+		role VARCHAR(20) NOT NULL,
+		token_hash VARCHAR(64) NOT NULL,
+		invited_by_id BIGINT UNSIGNED NOT NULL,
+		expires_at DATETIME(3) NOT NULL,
+		accepted_at DATETIME(3) NULL,
+		created_at DATETIME(3) NOT NULL
+	)`).Error)
+	expiresAt := time.Now().Add(24 * time.Hour)
+	require.NoError(t, db.Exec(
+		"INSERT INTO organization_invitations (organization_id, email, role, token_hash, invited_by_id, expires_at, accepted_at, created_at) VALUES "+
+			"(1, 'jane@acme.com', 'member', 'pending-hash', 1, ?, NULL, ?), (1, 'john@acme.com', 'member', 'accepted-hash', 1, ?, ?, ?)",
+		expiresAt, time.Now(), expiresAt, time.Now(), time.Now(),
+	).Error)
+
+	require.NoError(t, migrations.Migrate(db))
+
+	var tokens []domain.OneTimeToken
+	require.NoError(t, db.Where("purpose = ?", domain.OneTimeTokenPurposeInvitation).Find(&tokens).Error)
+	require.Len(t, tokens, 1)
+	assert.Equal(t, "pending-hash", tokens[0].TokenHash)
+
+	// The legacy table is kept, accepted invitations included
+	assert.False(t, db.Migrator().HasTable("organization_invitations"))
+	var archived int64
+	require.NoError(t, db.Table("organization_invitations_legacy").Count(&archived).Error)
+	assert.Equal(t, int64(2), archived)
+
+	t.Run("Runs only once", func(t *testing.T) {
+		require.NoError(t, db.Exec("CREATE TABLE organization_invitations (id BIGINT UNSIGNED PRIMARY KEY)").Error)
+
+		require.NoError(t, migrations.Migrate(db))
+
+		assert.True(t, db.Migrator().HasTable("organization_invitations"))
+	})
+}
+
+func TestMigrate_Indexes(t *testing.T) {
+	db := setupTestDatabase(t)
+
+	// Recreate the state of a database migrated before the composite indexes
+	require.NoError(t, db.Exec("CREATE INDEX idx_refresh_tokens_user_id ON refresh_tokens (user_id)").Error)
+	require.NoError(t, db.Exec("CREATE INDEX idx_token_blacklist_token ON token_blacklist (token)").Error)
+	require.NoError(t, db.Exec("ALTER TABLE users MODIFY email VARCHAR(512) CHARACTER SET utf8mb4 COLLATE utf8mb4_bin NOT NULL").Error)
+
+	require.NoError(t, migrations.Migrate(db))
+
+	assert.False(t, db.Migrator().HasIndex(&domain.RefreshToken{}, "idx_refresh_tokens_user_id"))
+	assert.False(t, db.Migrator().HasIndex(&domain.TokenBlacklist{}, "idx_token_blacklist_token"))
+	var collation string
+	require.NoError(t, db.Raw(
+		"SELECT collation_name FROM information_schema.columns WHERE table_schema = DATABASE() AND table_name = 'users' AND column_name = 'email'",
+	).Scan(&collation).Error)
+	assert.Regexp(t, "_ci$", collation)
+
+	warnings, err := migrations.AuditIndexes(db)
+	require.NoError(t, err)
+	assert.Empty(t, warnings)
+}
@@ -0,0 +1,59 @@
+package e2e
+
+import (
+	"errors"
+	"gojwt-rest-api/internal/domain"
+	"gojwt-rest-api/internal/middleware"
+	"gojwt-rest-api/internal/service"
+	"gojwt-rest-api/internal/utils"
+	"gojwt-rest-api/test/helpers"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOrganizationQuotaMiddleware(t *testing.T) {
+	jwtSecret := "test-secret"
+	orgID := uint(5)
+
+	orgRepo := new(helpers.MockOrganizationRepository)
+	userRepo := new(helpers.MockUserRepository)
+	userRepo.On("FindByID", uint(1)).Return(&domain.User{ID: 1, OrganizationID: &orgID}, nil)
+	userRepo.On("FindByID", uint(2)).Return(nil, errors.New("database unavailable"))
+	orgRepo.On("FindByID", orgID).Return(helpers.CreateTestOrganization(orgID, domain.Plan{ID: 1, RequestsPerMinute: 1}), nil)
+
+	router := setupRouter()
+	router.GET("/resource", middleware.AuthMiddleware(jwtSecret),
+		middleware.OrganizationQuotaMiddleware(service.NewQuotaService(orgRepo, userRepo, time.Minute)),
+		func(c *gin.Context) {
+			c.Status(http.StatusNoContent)
+		})
+
+	doRequest := func(userID uint) *httptest.ResponseRecorder {
+		token, _ := utils.GenerateToken(userID, "john@example.com", jwtSecret, time.Hour)
+		req, _ := http.NewRequest(http.MethodGet, "/resource", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("Rejects requests beyond the quota", func(t *testing.T) {
+		assert.Equal(t, http.StatusNoContent, doRequest(1).Code)
+
+		w := doRequest(1)
+
+		assert.Equal(t, http.StatusTooManyRequests, w.Code)
+		assert.NotEmpty(t, w.Header().Get("Retry-After"))
+	})
+
+	t.Run("Refuses requests whose quota cannot be checked", func(t *testing.T) {
+		w := doRequest(2)
+
+		assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+	})
+}
@@ -0,0 +1,217 @@
+package e2e
+
+import (
+	"bytes"
+	"encoding/json"
+	"gojwt-rest-api/internal/config"
+	"gojwt-rest-api/internal/domain"
+	"gojwt-rest-api/internal/events"
+	"gojwt-rest-api/internal/handler"
+	"gojwt-rest-api/internal/middleware"
+	"gojwt-rest-api/internal/service"
+	"gojwt-rest-api/pkg/logger"
+	"gojwt-rest-api/pkg/validator"
+	"gojwt-rest-api/test/factory"
+	"gojwt-rest-api/test/helpers"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// resetWork is how long a reset request for a registered email takes in
+// slowPasswordReset, standing in for issuing the token and sending the email
+const resetWork = 50 * time.Millisecond
+
+// slowPasswordReset makes reset requests for registered emails measurably
+// slower than for unknown ones
+type slowPasswordReset struct {
+	service.PasswordResetService
+	userRepo *helpers.MemoryUserRepository
+}
+
+func (s *slowPasswordReset) RequestReset(email string) error {
+	if _, err := s.userRepo.FindByEmail(email); err == nil {
+		time.Sleep(resetWork)
+	}
+	return s.PasswordResetService.RequestReset(email)
+}
+
+// passwordResetFixture routes the forgot password and reset endpoints to a
+// password reset service on in-memory repositories with a registered user
+type passwordResetFixture struct {
+	router    *gin.Engine
+	handler   *handler.PasswordResetHandler
+	publisher *helpers.MockEventPublisher
+	user      *domain.User
+}
+
+func newPasswordResetFixture(t *testing.T, opts ...handler.PasswordResetHandlerOption) *passwordResetFixture {
+	t.Helper()
+	f := &passwordResetFixture{
+		router:    setupRouter(),
+		publisher: new(helpers.MockEventPublisher),
+		user:      factory.New().User(factory.WithEmail("taken@example.com")),
+	}
+	f.publisher.On("Publish", mock.Anything, mock.Anything).Return()
+
+	userRepo := helpers.NewMemoryUserRepository()
+	require.NoError(t, userRepo.Create(f.user))
+	auditRepo := new(helpers.MockAuditLogRepository)
+	auditRepo.On("Create", mock.AnythingOfType("*domain.AuditLog")).Return(nil)
+	passwordReset := service.NewPasswordResetService(userRepo, helpers.NewMemoryTokenRepository(),
+		service.NewOneTimeTokenService(helpers.NewMemoryOneTimeTokenRepository()), service.NewAuditService(auditRepo), time.Hour,
+		service.WithPasswordResetEventPublisher(f.publisher),
+	)
+	v, err := validator.New()
+	require.NoError(t, err)
+	f.handler = handler.NewPasswordResetHandler(&slowPasswordReset{PasswordResetService: passwordReset, userRepo: userRepo}, v, logger.New(), opts...)
+	t.Cleanup(f.handler.Wait)
+
+	f.router.POST("/password/forgot", f.handler.ForgotPassword)
+	f.router.POST("/password/reset", f.handler.ResetPassword)
+	return f
+}
+
+func (f *passwordResetFixture) post(path string, body interface{}) *httptest.ResponseRecorder {
+	payload, _ := json.Marshal(body)
+	req := httptest.NewRequest(http.MethodPost, path, bytes.NewReader(payload))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	f.router.ServeHTTP(w, req)
+	return w
+}
+
+// medianForgotPassword returns the median response time of n forgot
+// password requests for email
+func (f *passwordResetFixture) medianForgotPassword(t *testing.T, email string, n int) time.Duration {
+	t.Helper()
+	durations := make([]time.Duration, n)
+	for i := range durations {
+		start := time.Now()
+		w := f.post("/password/forgot", &domain.ForgotPasswordRequest{Email: email})
+		durations[i] = time.Since(start)
+		require.Equal(t, http.StatusAccepted, w.Code)
+	}
+	sort.Slice(durations, func(i, j int) bool { return durations[i] < durations[j] })
+	return durations[n/2]
+}
+
+func TestForgotPassword_SameResponseForAnyEmail(t *testing.T) {
+	f := newPasswordResetFixture(t)
+
+	registered := f.post("/password/forgot", &domain.ForgotPasswordRequest{Email: f.user.Email})
+	unknown := f.post("/password/forgot", &domain.ForgotPasswordRequest{Email: "nobody@example.com"})
+
+	assert.Equal(t, http.StatusAccepted, registered.Code)
+	assert.Equal(t, registered.Code, unknown.Code)
+	assert.Equal(t, registered.Body.String(), unknown.Body.String())
+	assert.Equal(t, registered.Header(), unknown.Header())
+
+	// Only the registered email gets a link, after the response
+	f.handler.Wait()
+	f.publisher.AssertNumberOfCalls(t, "Publish", 1)
+	f.publisher.AssertCalled(t, "Publish", events.UserPasswordResetRequested, mock.MatchedBy(func(data *events.UserPasswordResetRequestedData) bool {
+		return data.Email == f.user.Email
+	}))
+}
+
+func TestForgotPassword_ResponseTimeDoesNotRevealEmail(t *testing.T) {
+	f := newPasswordResetFixture(t)
+	const requests = 15
+
+	registered := f.medianForgotPassword(t, f.user.Email, requests)
+	unknown := f.medianForgotPassword(t, "nobody@example.com", requests)
+
+	// Requests for the registered email take resetWork longer to process;
+	// none of that may show in the response time
+	assert.Less(t, registered, resetWork/2, "registered email answered after the request was processed")
+	diff := registered - unknown
+	if diff < 0 {
+		diff = -diff
+	}
+	assert.Less(t, diff, resetWork/5, "median response times differ by %s", diff)
+}
+
+func TestForgotPassword_InvalidRequest(t *testing.T) {
+	f := newPasswordResetFixture(t)
+
+	w := f.post("/password/forgot", map[string]string{"email": "not-an-email"})
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestForgotPassword_LimitsRequestsPerEmail(t *testing.T) {
+	limiter := middleware.NewRateLimiter(config.RateLimitConfig{RequestsPerDuration: 1, Duration: time.Minute, CleanupInterval: time.Minute})
+	f := newPasswordResetFixture(t, handler.WithResetEmailLimiter(limiter))
+
+	w := f.post("/password/forgot", &domain.ForgotPasswordRequest{Email: f.user.Email})
+	assert.Equal(t, http.StatusAccepted, w.Code)
+
+	// The same email, whatever its case, is limited
+	w = f.post("/password/forgot", &domain.ForgotPasswordRequest{Email: "TAKEN@example.com"})
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+
+	// Other emails are not
+	w = f.post("/password/forgot", &domain.ForgotPasswordRequest{Email: "nobody@example.com"})
+	assert.Equal(t, http.StatusAccepted, w.Code)
+
+	f.handler.Wait()
+	f.publisher.AssertNumberOfCalls(t, "Publish", 1)
+}
+
+func TestForgotPassword_DropsRequestsWhenQueueIsFull(t *testing.T) {
+	f := newPasswordResetFixture(t, handler.WithResetQueue(1, 1))
+
+	// The worker is busy for resetWork with the first request and the queue
+	// holds a second one; the rest are dropped but answered the same
+	for i := 0; i < 5; i++ {
+		w := f.post("/password/forgot", &domain.ForgotPasswordRequest{Email: f.user.Email})
+		require.Equal(t, http.StatusAccepted, w.Code)
+	}
+
+	f.handler.Wait()
+	processed := 0
+	for _, call := range f.publisher.Calls {
+		if call.Method == "Publish" && call.Arguments.Get(0) == events.UserPasswordResetRequested {
+			processed++
+		}
+	}
+	assert.GreaterOrEqual(t, processed, 1)
+	assert.LessOrEqual(t, processed, 2)
+}
+
+func TestResetPassword(t *testing.T) {
+	t.Run("Resets with the emailed token", func(t *testing.T) {
+		f := newPasswordResetFixture(t)
+		f.post("/password/forgot", &domain.ForgotPasswordRequest{Email: f.user.Email})
+		f.handler.Wait()
+		var token string
+		for _, call := range f.publisher.Calls {
+			if data, ok := call.Arguments.Get(1).(*events.UserPasswordResetRequestedData); ok {
+				token = data.ResetToken
+			}
+		}
+		require.NotEmpty(t, token)
+
+		w := f.post("/password/reset", &domain.ResetPasswordRequest{Token: token, NewPassword: "n3w-passw0rd"})
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		w = f.post("/password/reset", &domain.ResetPasswordRequest{Token: token, NewPassword: "n3w-passw0rd"})
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("Rejects unknown tokens", func(t *testing.T) {
+		f := newPasswordResetFixture(t)
+
+		w := f.post("/password/reset", &domain.ResetPasswordRequest{Token: "unknown", NewPassword: "n3w-passw0rd"})
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+}
@@ -7,31 +7,40 @@ import (
 	"gojwt-rest-api/internal/handler"
 	"gojwt-rest-api/internal/middleware"
 	"gojwt-rest-api/internal/service"
-	"gojwt-rest-api/internal/utils"
 	"gojwt-rest-api/pkg/validator"
+	"gojwt-rest-api/test/factory"
 	"gojwt-rest-api/test/helpers"
 	"net/http"
 	"net/http/httptest"
 	"testing"
 	"time"
 
+	"github.com/gin-gonic/gin"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
 )
 
+// setupProfileTest routes the profile endpoints behind the auth middleware,
+// with a factory signing access tokens the middleware accepts
+func setupProfileTest() (*helpers.MockUserRepository, *gin.Engine, *factory.Factory) {
+	f := factory.New()
+	mockRepo := new(helpers.MockUserRepository)
+	userService := service.NewUserService(mockRepo, new(helpers.MockTokenRepository), f.Secret(), 15*time.Minute, 7*24*time.Hour)
+	v, _ := validator.New()
+	profileHandler := handler.NewProfileHandler(userService, v)
+
+	router := setupRouter()
+	router.Use(middleware.AuthMiddleware(f.Secret()))
+	router.GET("/profile", profileHandler.GetOwnProfile)
+	router.PUT("/profile", profileHandler.UpdateOwnProfile)
+	router.PUT("/profile/password", profileHandler.ChangePassword)
+	return mockRepo, router, f
+}
+
 func TestProfileHandler_GetOwnProfile(t *testing.T) {
 	t.Run("Successfully get own profile", func(t *testing.T) {
-		mockRepo := new(helpers.MockUserRepository)
-		mockTokenRepo := new(helpers.MockTokenRepository)
-		jwtSecret := "test-secret"
-		userService := service.NewUserService(mockRepo, mockTokenRepo, jwtSecret, 15*time.Minute, 7*24*time.Hour)
-		v, _ := validator.New()
-		profileHandler := handler.NewProfileHandler(userService, v)
-
-		router := setupRouter()
-		router.Use(middleware.AuthMiddleware(jwtSecret))
-		router.GET("/profile", profileHandler.GetOwnProfile)
+		mockRepo, router, f := setupProfileTest()
 
 		user := helpers.CreateTestUser(1, "john@example.com")
 
@@ -39,7 +48,7 @@ func TestProfileHandler_GetOwnProfile(t *testing.T) {
 		mockRepo.On("FindByID", uint(1)).Return(user, nil)
 
 		// Generate valid token
-		token, _ := utils.GenerateToken(user.ID, user.Email, jwtSecret, 24*time.Hour)
+		token := f.AccessToken(user)
 
 		req, _ := http.NewRequest(http.MethodGet, "/profile", nil)
 		req.Header.Set("Authorization", "Bearer "+token)
@@ -62,16 +71,7 @@ func TestProfileHandler_GetOwnProfile(t *testing.T) {
 	})
 
 	t.Run("Get profile without authentication", func(t *testing.T) {
-		mockRepo := new(helpers.MockUserRepository)
-		jwtSecret := "test-secret"
-		mockTokenRepo := new(helpers.MockTokenRepository)
-		userService := service.NewUserService(mockRepo, mockTokenRepo, jwtSecret, 15*time.Minute, 7*24*time.Hour)
-		v, _ := validator.New()
-		profileHandler := handler.NewProfileHandler(userService, v)
-
-		router := setupRouter()
-		router.Use(middleware.AuthMiddleware(jwtSecret))
-		router.GET("/profile", profileHandler.GetOwnProfile)
+		_, router, _ := setupProfileTest()
 
 		req, _ := http.NewRequest(http.MethodGet, "/profile", nil)
 		w := httptest.NewRecorder()
@@ -84,16 +84,7 @@ func TestProfileHandler_GetOwnProfile(t *testing.T) {
 
 func TestProfileHandler_UpdateOwnProfile(t *testing.T) {
 	t.Run("Successfully update own profile", func(t *testing.T) {
-		mockRepo := new(helpers.MockUserRepository)
-		jwtSecret := "test-secret"
-		mockTokenRepo := new(helpers.MockTokenRepository)
-		userService := service.NewUserService(mockRepo, mockTokenRepo, jwtSecret, 15*time.Minute, 7*24*time.Hour)
-		v, _ := validator.New()
-		profileHandler := handler.NewProfileHandler(userService, v)
-
-		router := setupRouter()
-		router.Use(middleware.AuthMiddleware(jwtSecret))
-		router.PUT("/profile", profileHandler.UpdateOwnProfile)
+		mockRepo, router, f := setupProfileTest()
 
 		user := helpers.CreateTestUser(1, "john@example.com")
 		reqBody := map[string]string{
@@ -110,7 +101,7 @@ func TestProfileHandler_UpdateOwnProfile(t *testing.T) {
 		mockRepo.On("Update", mock.AnythingOfType("*domain.User")).Return(nil)
 
 		// Generate valid token
-		token, _ := utils.GenerateToken(user.ID, user.Email, jwtSecret, 24*time.Hour)
+		token := f.AccessToken(user)
 
 		req, _ := http.NewRequest(http.MethodPut, "/profile", bytes.NewBuffer(jsonBody))
 		req.Header.Set("Content-Type", "application/json")
@@ -130,17 +121,74 @@ func TestProfileHandler_UpdateOwnProfile(t *testing.T) {
 		mockRepo.AssertExpectations(t)
 	})
 
-	t.Run("Update profile with invalid email format", func(t *testing.T) {
-		mockRepo := new(helpers.MockUserRepository)
-		jwtSecret := "test-secret"
-		mockTokenRepo := new(helpers.MockTokenRepository)
-		userService := service.NewUserService(mockRepo, mockTokenRepo, jwtSecret, 15*time.Minute, 7*24*time.Hour)
-		v, _ := validator.New()
-		profileHandler := handler.NewProfileHandler(userService, v)
+	t.Run("Update profile name parts", func(t *testing.T) {
+		mockRepo, router, f := setupProfileTest()
+
+		user := helpers.CreateTestUser(1, "john@example.com")
+		jsonBody, _ := json.Marshal(map[string]string{
+			"first_name":   "Johnny",
+			"display_name": "JD",
+		})
+
+		mockRepo.On("FindByID", uint(1)).Return(user, nil)
+		mockRepo.On("Update", mock.AnythingOfType("*domain.User")).Return(nil)
+
+		req, _ := http.NewRequest(http.MethodPut, "/profile", bytes.NewBuffer(jsonBody))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Authorization", "Bearer "+f.AccessToken(user))
+		w := httptest.NewRecorder()
+
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		var response map[string]interface{}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		data := response["data"].(map[string]interface{})
+		assert.Equal(t, "Johnny", data["first_name"])
+		assert.Equal(t, "User", data["last_name"])
+		assert.Equal(t, "JD", data["display_name"])
+		assert.Equal(t, "JD", data["name"], "name is computed for older clients")
+	})
+
+	t.Run("Update profile timezone", func(t *testing.T) {
+		mockRepo, router, f := setupProfileTest()
+
+		user := helpers.CreateTestUser(1, "john@example.com")
+		user.CreatedAt = time.Date(2026, 3, 1, 2, 0, 0, 0, time.UTC)
+
+		mockRepo.On("FindByID", uint(1)).Return(user, nil)
+		mockRepo.On("Update", mock.AnythingOfType("*domain.User")).Return(nil)
 
-		router := setupRouter()
-		router.Use(middleware.AuthMiddleware(jwtSecret))
-		router.PUT("/profile", profileHandler.UpdateOwnProfile)
+		for body, status := range map[string]int{
+			`{"timezone":"Mars/Olympus_Mons"}`: http.StatusBadRequest,
+			`{"timezone":"Asia/Jakarta"}`:      http.StatusOK,
+		} {
+			req, _ := http.NewRequest(http.MethodPut, "/profile", bytes.NewBufferString(body))
+			req.Header.Set("Content-Type", "application/json")
+			req.Header.Set("Authorization", "Bearer "+f.AccessToken(user))
+			w := httptest.NewRecorder()
+
+			router.ServeHTTP(w, req)
+
+			require.Equal(t, status, w.Code, body)
+		}
+
+		req, _ := http.NewRequest(http.MethodGet, "/profile", nil)
+		req.Header.Set("Authorization", "Bearer "+f.AccessToken(user))
+		w := httptest.NewRecorder()
+
+		router.ServeHTTP(w, req)
+
+		var response map[string]interface{}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		data := response["data"].(map[string]interface{})
+		assert.Equal(t, "Asia/Jakarta", data["timezone"])
+		assert.Equal(t, "2026-03-01T09:00:00+07:00", data["created_at"])
+	})
+
+	t.Run("Update profile with invalid email format", func(t *testing.T) {
+		_, router, f := setupProfileTest()
 
 		user := helpers.CreateTestUser(1, "john@example.com")
 		reqBody := map[string]string{
@@ -150,7 +198,7 @@ func TestProfileHandler_UpdateOwnProfile(t *testing.T) {
 		jsonBody, _ := json.Marshal(reqBody)
 
 		// Generate valid token
-		token, _ := utils.GenerateToken(user.ID, user.Email, jwtSecret, 24*time.Hour)
+		token := f.AccessToken(user)
 
 		req, _ := http.NewRequest(http.MethodPut, "/profile", bytes.NewBuffer(jsonBody))
 		req.Header.Set("Content-Type", "application/json")
@@ -168,16 +216,7 @@ func TestProfileHandler_UpdateOwnProfile(t *testing.T) {
 	})
 
 	t.Run("Update profile with duplicate email", func(t *testing.T) {
-		mockRepo := new(helpers.MockUserRepository)
-		jwtSecret := "test-secret"
-		mockTokenRepo := new(helpers.MockTokenRepository)
-		userService := service.NewUserService(mockRepo, mockTokenRepo, jwtSecret, 15*time.Minute, 7*24*time.Hour)
-		v, _ := validator.New()
-		profileHandler := handler.NewProfileHandler(userService, v)
-
-		router := setupRouter()
-		router.Use(middleware.AuthMiddleware(jwtSecret))
-		router.PUT("/profile", profileHandler.UpdateOwnProfile)
+		mockRepo, router, f := setupProfileTest()
 
 		user := helpers.CreateTestUser(1, "john@example.com")
 		existingUser := helpers.CreateTestUser(2, "existing@example.com")
@@ -193,7 +232,7 @@ func TestProfileHandler_UpdateOwnProfile(t *testing.T) {
 		mockRepo.On("FindByEmail", "existing@example.com").Return(existingUser, nil)
 
 		// Generate valid token
-		token, _ := utils.GenerateToken(user.ID, user.Email, jwtSecret, 24*time.Hour)
+		token := f.AccessToken(user)
 
 		req, _ := http.NewRequest(http.MethodPut, "/profile", bytes.NewBuffer(jsonBody))
 		req.Header.Set("Content-Type", "application/json")
@@ -214,16 +253,7 @@ func TestProfileHandler_UpdateOwnProfile(t *testing.T) {
 	})
 
 	t.Run("Update profile without authentication", func(t *testing.T) {
-		mockRepo := new(helpers.MockUserRepository)
-		jwtSecret := "test-secret"
-		mockTokenRepo := new(helpers.MockTokenRepository)
-		userService := service.NewUserService(mockRepo, mockTokenRepo, jwtSecret, 15*time.Minute, 7*24*time.Hour)
-		v, _ := validator.New()
-		profileHandler := handler.NewProfileHandler(userService, v)
-
-		router := setupRouter()
-		router.Use(middleware.AuthMiddleware(jwtSecret))
-		router.PUT("/profile", profileHandler.UpdateOwnProfile)
+		_, router, _ := setupProfileTest()
 
 		reqBody := map[string]string{
 			"name": "John Updated",
@@ -242,16 +272,7 @@ func TestProfileHandler_UpdateOwnProfile(t *testing.T) {
 
 func TestProfileHandler_ChangePassword(t *testing.T) {
 	t.Run("Successfully change password", func(t *testing.T) {
-		mockRepo := new(helpers.MockUserRepository)
-		jwtSecret := "test-secret"
-		mockTokenRepo := new(helpers.MockTokenRepository)
-		userService := service.NewUserService(mockRepo, mockTokenRepo, jwtSecret, 15*time.Minute, 7*24*time.Hour)
-		v, _ := validator.New()
-		profileHandler := handler.NewProfileHandler(userService, v)
-
-		router := setupRouter()
-		router.Use(middleware.AuthMiddleware(jwtSecret))
-		router.PUT("/profile/password", profileHandler.ChangePassword)
+		mockRepo, router, f := setupProfileTest()
 
 		user := helpers.CreateTestUser(1, "john@example.com")
 		reqBody := map[string]string{
@@ -266,7 +287,7 @@ func TestProfileHandler_ChangePassword(t *testing.T) {
 		mockRepo.On("Update", mock.AnythingOfType("*domain.User")).Return(nil)
 
 		// Generate valid token
-		token, _ := utils.GenerateToken(user.ID, user.Email, jwtSecret, 24*time.Hour)
+		token := f.AccessToken(user)
 
 		req, _ := http.NewRequest(http.MethodPut, "/profile/password", bytes.NewBuffer(jsonBody))
 		req.Header.Set("Content-Type", "application/json")
@@ -287,16 +308,7 @@ func TestProfileHandler_ChangePassword(t *testing.T) {
 	})
 
 	t.Run("Change password with wrong old password", func(t *testing.T) {
-		mockRepo := new(helpers.MockUserRepository)
-		jwtSecret := "test-secret"
-		mockTokenRepo := new(helpers.MockTokenRepository)
-		userService := service.NewUserService(mockRepo, mockTokenRepo, jwtSecret, 15*time.Minute, 7*24*time.Hour)
-		v, _ := validator.New()
-		profileHandler := handler.NewProfileHandler(userService, v)
-
-		router := setupRouter()
-		router.Use(middleware.AuthMiddleware(jwtSecret))
-		router.PUT("/profile/password", profileHandler.ChangePassword)
+		mockRepo, router, f := setupProfileTest()
 
 		user := helpers.CreateTestUser(1, "john@example.com")
 		reqBody := map[string]string{
@@ -309,7 +321,7 @@ func TestProfileHandler_ChangePassword(t *testing.T) {
 		mockRepo.On("FindByID", uint(1)).Return(user, nil)
 
 		// Generate valid token
-		token, _ := utils.GenerateToken(user.ID, user.Email, jwtSecret, 24*time.Hour)
+		token := f.AccessToken(user)
 
 		req, _ := http.NewRequest(http.MethodPut, "/profile/password", bytes.NewBuffer(jsonBody))
 		req.Header.Set("Content-Type", "application/json")
@@ -330,16 +342,7 @@ func TestProfileHandler_ChangePassword(t *testing.T) {
 	})
 
 	t.Run("Change password with short new password", func(t *testing.T) {
-		mockRepo := new(helpers.MockUserRepository)
-		jwtSecret := "test-secret"
-		mockTokenRepo := new(helpers.MockTokenRepository)
-		userService := service.NewUserService(mockRepo, mockTokenRepo, jwtSecret, 15*time.Minute, 7*24*time.Hour)
-		v, _ := validator.New()
-		profileHandler := handler.NewProfileHandler(userService, v)
-
-		router := setupRouter()
-		router.Use(middleware.AuthMiddleware(jwtSecret))
-		router.PUT("/profile/password", profileHandler.ChangePassword)
+		_, router, f := setupProfileTest()
 
 		user := helpers.CreateTestUser(1, "john@example.com")
 		reqBody := map[string]string{
@@ -349,7 +352,7 @@ func TestProfileHandler_ChangePassword(t *testing.T) {
 		jsonBody, _ := json.Marshal(reqBody)
 
 		// Generate valid token
-		token, _ := utils.GenerateToken(user.ID, user.Email, jwtSecret, 24*time.Hour)
+		token := f.AccessToken(user)
 
 		req, _ := http.NewRequest(http.MethodPut, "/profile/password", bytes.NewBuffer(jsonBody))
 		req.Header.Set("Content-Type", "application/json")
@@ -367,16 +370,7 @@ func TestProfileHandler_ChangePassword(t *testing.T) {
 	})
 
 	t.Run("Change password without authentication", func(t *testing.T) {
-		mockRepo := new(helpers.MockUserRepository)
-		jwtSecret := "test-secret"
-		mockTokenRepo := new(helpers.MockTokenRepository)
-		userService := service.NewUserService(mockRepo, mockTokenRepo, jwtSecret, 15*time.Minute, 7*24*time.Hour)
-		v, _ := validator.New()
-		profileHandler := handler.NewProfileHandler(userService, v)
-
-		router := setupRouter()
-		router.Use(middleware.AuthMiddleware(jwtSecret))
-		router.PUT("/profile/password", profileHandler.ChangePassword)
+		_, router, _ := setupProfileTest()
 
 		reqBody := map[string]string{
 			"old_password": "password123",
@@ -394,16 +388,7 @@ func TestProfileHandler_ChangePassword(t *testing.T) {
 	})
 
 	t.Run("Change password with missing fields", func(t *testing.T) {
-		mockRepo := new(helpers.MockUserRepository)
-		jwtSecret := "test-secret"
-		mockTokenRepo := new(helpers.MockTokenRepository)
-		userService := service.NewUserService(mockRepo, mockTokenRepo, jwtSecret, 15*time.Minute, 7*24*time.Hour)
-		v, _ := validator.New()
-		profileHandler := handler.NewProfileHandler(userService, v)
-
-		router := setupRouter()
-		router.Use(middleware.AuthMiddleware(jwtSecret))
-		router.PUT("/profile/password", profileHandler.ChangePassword)
+		_, router, f := setupProfileTest()
 
 		user := helpers.CreateTestUser(1, "john@example.com")
 		reqBody := map[string]string{
@@ -413,7 +398,7 @@ func TestProfileHandler_ChangePassword(t *testing.T) {
 		jsonBody, _ := json.Marshal(reqBody)
 
 		// Generate valid token
-		token, _ := utils.GenerateToken(user.ID, user.Email, jwtSecret, 24*time.Hour)
+		token := f.AccessToken(user)
 
 		req, _ := http.NewRequest(http.MethodPut, "/profile/password", bytes.NewBuffer(jsonBody))
 		req.Header.Set("Content-Type", "application/json")
@@ -0,0 +1,90 @@
+package e2e
+
+import (
+	"encoding/json"
+	"gojwt-rest-api/internal/domain"
+	"gojwt-rest-api/internal/handler"
+	"gojwt-rest-api/internal/middleware"
+	"gojwt-rest-api/internal/service"
+	"gojwt-rest-api/internal/utils"
+	"gojwt-rest-api/test/helpers"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProfileHistoryEndpoint(t *testing.T) {
+	jwtSecret := "test-secret"
+
+	userRepo := new(helpers.MockUserRepository)
+	historyRepo := new(helpers.MockProfileHistoryRepository)
+	userService := service.NewUserService(userRepo, new(helpers.MockTokenRepository), jwtSecret, 15*time.Minute, 7*24*time.Hour)
+	historyHandler := handler.NewProfileHistoryHandler(service.NewProfileHistoryService(historyRepo), userService)
+
+	router := setupRouter()
+	router.Use(middleware.AuthMiddleware(jwtSecret))
+	router.GET("/users/:id/history", middleware.AdminScopeOrSelfMiddleware(userService, domain.ScopeAdminUserRead), historyHandler.GetHistory)
+
+	admin := helpers.CreateAdminUser(1, "admin@example.com")
+	john := helpers.CreateTestUser(3, "john@example.com")
+	jane := helpers.CreateTestUser(4, "jane@example.com")
+	userRepo.On("FindByID", uint(1)).Return(admin, nil)
+	userRepo.On("FindByID", uint(3)).Return(john, nil)
+	userRepo.On("FindByID", uint(4)).Return(jane, nil)
+
+	change := &domain.ProfileChange{UserID: 3, Version: 1, ActorID: &admin.ID, Source: domain.ProfileChangeSourceAdmin, CreatedAt: time.Now()}
+	require.NoError(t, change.SetFields([]domain.ProfileFieldChange{
+		{Field: domain.ProfileFieldEmail, OldValue: "jdoe@example.com", NewValue: "john@example.com"},
+	}))
+	historyRepo.On("FindByUser", uint(3), 0, 10).Return([]*domain.ProfileChange{change}, int64(1), nil)
+
+	getHistory := func(user *domain.User, path string) *httptest.ResponseRecorder {
+		token, _ := utils.GenerateToken(user.ID, user.Email, jwtSecret, time.Hour)
+		req, _ := http.NewRequest(http.MethodGet, path, nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+	type historyResponse struct {
+		Data struct {
+			Data       []map[string]interface{} `json:"data"`
+			TotalItems int64                    `json:"total_items"`
+		} `json:"data"`
+	}
+
+	t.Run("Admins see who changed what", func(t *testing.T) {
+		w := getHistory(admin, "/users/3/history")
+
+		require.Equal(t, http.StatusOK, w.Code)
+		var response historyResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		require.Len(t, response.Data.Data, 1)
+		entry := response.Data.Data[0]
+		assert.Equal(t, float64(1), entry["actor_id"])
+		assert.Equal(t, domain.ProfileChangeSourceAdmin, entry["source"])
+		assert.Contains(t, w.Body.String(), "jdoe@example.com")
+	})
+
+	t.Run("Users see their own history redacted", func(t *testing.T) {
+		w := getHistory(john, "/users/3/history")
+
+		require.Equal(t, http.StatusOK, w.Code)
+		var response historyResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		require.Len(t, response.Data.Data, 1)
+		entry := response.Data.Data[0]
+		assert.Equal(t, domain.ProfileChangedByAdmin, entry["changed_by"])
+		assert.NotContains(t, entry, "actor_id")
+		assert.NotContains(t, w.Body.String(), "jdoe@example.com")
+		assert.Contains(t, w.Body.String(), "j***@example.com")
+	})
+
+	t.Run("Users cannot see the history of others", func(t *testing.T) {
+		assert.Equal(t, http.StatusForbidden, getHistory(jane, "/users/3/history").Code)
+	})
+}
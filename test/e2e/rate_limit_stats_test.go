@@ -0,0 +1,88 @@
+package e2e
+
+import (
+	"bytes"
+	"encoding/json"
+	"gojwt-rest-api/internal/config"
+	"gojwt-rest-api/internal/handler"
+	"gojwt-rest-api/internal/metrics"
+	"gojwt-rest-api/internal/middleware"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRateLimitHandler_Stats(t *testing.T) {
+	registry := metrics.NewRegistry()
+	limiter := middleware.NewRateLimiter(
+		config.RateLimitConfig{RequestsPerDuration: 2, Duration: time.Minute, CleanupInterval: time.Minute},
+		middleware.WithLimiterName("login"),
+		middleware.WithRateLimitObserver(metrics.NewRateLimitMetrics(registry)),
+	)
+
+	router := setupRouter()
+	ok := func(c *gin.Context) { c.Status(http.StatusNoContent) }
+	router.POST("/login", middleware.RateLimitMiddleware(limiter), ok)
+	router.GET("/users/:id", middleware.RateLimitMiddleware(limiter), ok)
+	router.GET("/admin/rate-limits", handler.NewRateLimitHandler(limiter).Stats)
+
+	request := func(method, path, ip string) int {
+		req := httptest.NewRequest(method, path, nil)
+		req.RemoteAddr = ip + ":1234"
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w.Code
+	}
+	for i := 0; i < 5; i++ {
+		request(http.MethodPost, "/login", "203.0.113.7")
+	}
+	for i := 0; i < 3; i++ {
+		request(http.MethodGet, "/users/"+strconv.Itoa(i+1), "198.51.100.2")
+	}
+	require.Equal(t, http.StatusNoContent, request(http.MethodGet, "/users/1", "192.0.2.1"))
+
+	t.Run("Reports visitors, rejections by route and top offenders", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/admin/rate-limits?top=1", nil))
+		require.Equal(t, http.StatusOK, w.Code)
+
+		var body struct {
+			Data []middleware.RateLimitStats `json:"data"`
+		}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+		require.Len(t, body.Data, 1)
+		stats := body.Data[0]
+
+		assert.Equal(t, "login", stats.Name)
+		assert.Equal(t, 2, stats.Limit)
+		assert.Equal(t, "1m0s", stats.Window)
+		assert.Equal(t, 3, stats.TrackedVisitors)
+		assert.Equal(t, 2, stats.ThrottledVisitors)
+		assert.Equal(t, uint64(4), stats.Rejections)
+		assert.Equal(t, map[string]uint64{"/login": 3, "/users/:id": 1}, stats.RejectionsByRoute)
+		require.Len(t, stats.TopOffenders, 1)
+		assert.Equal(t, "203.0.113.7", stats.TopOffenders[0].IP)
+		assert.Equal(t, 3, stats.TopOffenders[0].Rejections)
+	})
+
+	t.Run("Rejections are exported as metrics by route template", func(t *testing.T) {
+		var buf bytes.Buffer
+		registry.Write(&buf)
+
+		assert.Contains(t, buf.String(), `rate_limit_rejections_total{limiter="login",route="/login"} 3`)
+		assert.Contains(t, buf.String(), `rate_limit_rejections_total{limiter="login",route="/users/:id"} 1`)
+		assert.Contains(t, buf.String(), `rate_limit_tracked_visitors{limiter="login"} 3`)
+	})
+
+	t.Run("Rejects an invalid top parameter", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/admin/rate-limits?top=0", nil))
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+}
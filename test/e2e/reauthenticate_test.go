@@ -0,0 +1,133 @@
+package e2e
+
+import (
+	"bytes"
+	"encoding/json"
+	"gojwt-rest-api/internal/domain"
+	"gojwt-rest-api/internal/handler"
+	"gojwt-rest-api/internal/middleware"
+	"gojwt-rest-api/internal/service"
+	"gojwt-rest-api/internal/utils"
+	"gojwt-rest-api/pkg/validator"
+	"gojwt-rest-api/test/factory"
+	"gojwt-rest-api/test/helpers"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRecentAuthMiddleware(t *testing.T) {
+	jwtSecret := "test-secret"
+
+	router := setupRouter()
+	router.POST("/api-keys", middleware.AuthMiddleware(jwtSecret), middleware.RecentAuthMiddleware(5*time.Minute),
+		func(c *gin.Context) { c.Status(http.StatusNoContent) })
+
+	doRequest := func(token string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest(http.MethodPost, "/api-keys", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("Recent authentication is accepted", func(t *testing.T) {
+		token, _ := utils.GenerateToken(1, "john@example.com", jwtSecret, time.Hour, utils.WithAuthTime(time.Now().Add(-time.Minute)))
+
+		assert.Equal(t, http.StatusNoContent, doRequest(token).Code)
+	})
+
+	t.Run("Stale authentication gets a step-up challenge", func(t *testing.T) {
+		token, _ := utils.GenerateToken(1, "john@example.com", jwtSecret, time.Hour, utils.WithAuthTime(time.Now().Add(-10*time.Minute)))
+
+		w := doRequest(token)
+
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+		assert.Contains(t, w.Body.String(), domain.ErrReauthenticationRequired.Error())
+		assert.Contains(t, w.Header().Get("WWW-Authenticate"), `error="insufficient_user_authentication"`)
+		assert.Contains(t, w.Header().Get("WWW-Authenticate"), "max_age=300")
+	})
+
+	t.Run("Token without auth_time is rejected", func(t *testing.T) {
+		token, _ := utils.GenerateToken(1, "john@example.com", jwtSecret, time.Hour)
+
+		assert.Equal(t, http.StatusUnauthorized, doRequest(token).Code)
+	})
+}
+
+func TestReauthenticate(t *testing.T) {
+	f := factory.New()
+	userRepo := helpers.NewMemoryUserRepository()
+	user := f.User()
+	require.NoError(t, userRepo.Create(user))
+	userService := service.NewUserService(userRepo, helpers.NewMemoryTokenRepository(), f.Secret(), 15*time.Minute, time.Hour)
+	v, err := validator.New()
+	require.NoError(t, err)
+	authHandler := handler.NewAuthHandler(userService, v)
+
+	router := setupRouter()
+	protected := router.Group("")
+	protected.Use(middleware.AuthMiddleware(f.Secret()), middleware.RevocationMiddleware(userService))
+	protected.POST("/auth/reauthenticate", authHandler.Reauthenticate)
+	protected.POST("/api-keys", middleware.RecentAuthMiddleware(5*time.Minute), func(c *gin.Context) { c.Status(http.StatusNoContent) })
+
+	doRequest := func(path, token string, body interface{}) *httptest.ResponseRecorder {
+		payload, _ := json.Marshal(body)
+		req, _ := http.NewRequest(http.MethodPost, path, bytes.NewReader(payload))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	// A session whose auth_time is too old for sensitive actions
+	staleToken := f.AccessToken(user, utils.WithAuthTime(time.Now().Add(-time.Hour)))
+	require.Equal(t, http.StatusUnauthorized, doRequest("/api-keys", staleToken, nil).Code)
+
+	t.Run("Password yields a token accepted for sensitive actions", func(t *testing.T) {
+		w := doRequest("/auth/reauthenticate", staleToken, map[string]string{"password": factory.DefaultPassword})
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+		var response struct {
+			Data domain.ReauthenticateResponse `json:"data"`
+		}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		assert.Equal(t, "Bearer", response.Data.TokenType)
+		assert.Equal(t, http.StatusNoContent, doRequest("/api-keys", response.Data.AccessToken, nil).Code)
+	})
+
+	t.Run("Token is revoked with the sessions of the presenting client", func(t *testing.T) {
+		iosToken := f.AccessToken(user, utils.WithClientID("ios"))
+		w := doRequest("/auth/reauthenticate", iosToken, map[string]string{"password": factory.DefaultPassword})
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		var response struct {
+			Data domain.ReauthenticateResponse `json:"data"`
+		}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		require.Equal(t, http.StatusNoContent, doRequest("/api-keys", response.Data.AccessToken, nil).Code)
+
+		_, err := userService.RevokeClientSessions(user.ID, &user.ID, "ios")
+		require.NoError(t, err)
+
+		assert.Equal(t, http.StatusUnauthorized, doRequest("/api-keys", response.Data.AccessToken, nil).Code)
+	})
+
+	t.Run("Wrong password is rejected", func(t *testing.T) {
+		w := doRequest("/auth/reauthenticate", staleToken, map[string]string{"password": "wrong-password"})
+
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+	})
+
+	t.Run("Password or code is required", func(t *testing.T) {
+		w := doRequest("/auth/reauthenticate", staleToken, map[string]string{})
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Contains(t, w.Body.String(), domain.ErrValidationFailed.Error())
+	})
+}
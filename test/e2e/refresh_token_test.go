@@ -14,6 +14,7 @@ import (
 )
 
 func TestRefreshTokenEndpoint(t *testing.T) {
+	t.Parallel()
 	router, _ := setupTestServer(t)
 
 	// Register and login first
@@ -141,6 +142,7 @@ func TestRefreshTokenEndpoint(t *testing.T) {
 }
 
 func TestLogoutEndpoint(t *testing.T) {
+	t.Parallel()
 	router, _ := setupTestServer(t)
 
 	// Register and login
@@ -232,6 +234,7 @@ func TestLogoutEndpoint(t *testing.T) {
 }
 
 func TestTokenReuseDetection(t *testing.T) {
+	t.Parallel()
 	router, _ := setupTestServer(t)
 
 	// Register and login
@@ -333,6 +336,7 @@ func TestTokenExpiration(t *testing.T) {
 }
 
 func TestMultipleRefreshChain(t *testing.T) {
+	t.Parallel()
 	router, _ := setupTestServer(t)
 
 	// Register and login
@@ -419,6 +423,7 @@ func TestMultipleRefreshChain(t *testing.T) {
 }
 
 func TestAccessTokenUsageWithRefresh(t *testing.T) {
+	t.Parallel()
 	router, _ := setupTestServer(t)
 
 	// Register and login
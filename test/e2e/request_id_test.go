@@ -0,0 +1,108 @@
+package e2e
+
+import (
+	"encoding/json"
+	"gojwt-rest-api/internal/domain"
+	"gojwt-rest-api/internal/middleware"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func setupRequestIDRouter() *gin.Engine {
+	router := setupRouter()
+	router.NoRoute(middleware.NotFoundHandler)
+	router.Use(middleware.RequestIDMiddleware())
+	router.Use(gin.CustomRecovery(middleware.RecoveryHandler))
+	router.GET("/ok", func(c *gin.Context) {
+		c.JSON(http.StatusOK, domain.SuccessResponse("ok", gin.H{"id": 1}))
+	})
+	router.GET("/fail", func(c *gin.Context) {
+		c.JSON(http.StatusConflict, domain.ErrorResponse("email already in use", "details"))
+	})
+	router.GET("/panic", func(c *gin.Context) {
+		panic("boom")
+	})
+	return router
+}
+
+func getWithHeaders(router *gin.Engine, path string, headers map[string]string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, path, nil)
+	for name, value := range headers {
+		req.Header.Set(name, value)
+	}
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+// errorBody decodes an error response including its request ID
+func errorBody(t *testing.T, w *httptest.ResponseRecorder) map[string]interface{} {
+	t.Helper()
+	var body map[string]interface{}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+	return body
+}
+
+func TestRequestID(t *testing.T) {
+	router := setupRequestIDRouter()
+
+	t.Run("Error responses carry the generated request ID", func(t *testing.T) {
+		w := getWithHeaders(router, "/fail", nil)
+
+		require.Equal(t, http.StatusConflict, w.Code)
+		id := w.Header().Get(middleware.RequestIDHeader)
+		require.NotEmpty(t, id)
+		body := errorBody(t, w)
+		assert.Equal(t, id, body["request_id"])
+		assert.Equal(t, "email already in use", body["message"])
+		assert.Equal(t, "details", body["error"])
+		assert.NotEqual(t, id, getWithHeaders(router, "/fail", nil).Header().Get(middleware.RequestIDHeader), "IDs are unique")
+	})
+
+	t.Run("Success responses only carry the header", func(t *testing.T) {
+		w := getWithHeaders(router, "/ok", nil)
+
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.NotEmpty(t, w.Header().Get(middleware.RequestIDHeader))
+		assert.NotContains(t, w.Body.String(), "request_id")
+	})
+
+	t.Run("Keeps the ID assigned by a proxy", func(t *testing.T) {
+		w := getWithHeaders(router, "/fail", map[string]string{middleware.RequestIDHeader: "edge-7f3a.42"})
+
+		assert.Equal(t, "edge-7f3a.42", w.Header().Get(middleware.RequestIDHeader))
+		assert.Equal(t, "edge-7f3a.42", errorBody(t, w)["request_id"])
+	})
+
+	t.Run("Uses the trace ID of a traceparent header", func(t *testing.T) {
+		w := getWithHeaders(router, "/fail", map[string]string{
+			"traceparent": "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+		})
+
+		assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", w.Header().Get(middleware.RequestIDHeader))
+	})
+
+	t.Run("Replaces invalid IDs", func(t *testing.T) {
+		w := getWithHeaders(router, "/fail", map[string]string{middleware.RequestIDHeader: "bad id\"<script>"})
+
+		id := w.Header().Get(middleware.RequestIDHeader)
+		assert.NotEmpty(t, id)
+		assert.NotEqual(t, "bad id\"<script>", id)
+	})
+
+	t.Run("Covers panics and unknown routes", func(t *testing.T) {
+		for path, status := range map[string]int{"/panic": http.StatusInternalServerError, "/missing": http.StatusNotFound} {
+			w := getWithHeaders(router, path, nil)
+
+			require.Equal(t, status, w.Code, path)
+			body := errorBody(t, w)
+			assert.Equal(t, false, body["success"], path)
+			assert.Equal(t, w.Header().Get(middleware.RequestIDHeader), body["request_id"], path)
+		}
+	})
+}
@@ -0,0 +1,157 @@
+package e2e
+
+import (
+	"context"
+	"gojwt-rest-api/internal/cache"
+	"gojwt-rest-api/internal/middleware"
+	"gojwt-rest-api/internal/utils"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func setupCachedRouter(store cache.Store, jwtSecret string, hits *int) *gin.Engine {
+	router := setupRouter()
+	router.Use(middleware.AuthMiddleware(jwtSecret))
+	router.GET("/stats", middleware.ResponseCacheMiddleware(store, "stats", time.Minute), func(c *gin.Context) {
+		*hits++
+		userID, _ := middleware.GetUserID(c)
+		c.JSON(http.StatusOK, gin.H{"user_id": userID, "hits": *hits})
+	})
+	router.POST("/stats", middleware.InvalidateCacheMiddleware(store, "stats"), func(c *gin.Context) {
+		c.Status(http.StatusNoContent)
+	})
+	return router
+}
+
+// localBroadcaster delivers invalidations between stores of the same process
+type localBroadcaster struct {
+	mu       sync.Mutex
+	handlers []func(cache.Invalidation)
+}
+
+func (b *localBroadcaster) Publish(_ context.Context, inv cache.Invalidation) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for _, handle := range b.handlers {
+		handle(inv)
+	}
+	return nil
+}
+
+func (b *localBroadcaster) Subscribe(ctx context.Context, handle func(cache.Invalidation)) error {
+	b.mu.Lock()
+	b.handlers = append(b.handlers, handle)
+	b.mu.Unlock()
+	<-ctx.Done()
+	return nil
+}
+
+func (b *localBroadcaster) subscribers() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return len(b.handlers)
+}
+
+func TestResponseCacheMiddleware(t *testing.T) {
+	jwtSecret := "test-secret"
+
+	doRequest := func(router *gin.Engine, method, token string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest(method, "/stats", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("Second request is served from cache", func(t *testing.T) {
+		hits := 0
+		router := setupCachedRouter(cache.NewMemoryStore(time.Minute), jwtSecret, &hits)
+		token, _ := utils.GenerateToken(1, "john@example.com", jwtSecret, time.Hour)
+
+		first := doRequest(router, http.MethodGet, token)
+		second := doRequest(router, http.MethodGet, token)
+
+		assert.Equal(t, http.StatusOK, second.Code)
+		assert.Equal(t, "MISS", first.Header().Get("X-Cache"))
+		assert.Equal(t, "HIT", second.Header().Get("X-Cache"))
+		assert.Equal(t, first.Body.String(), second.Body.String())
+		assert.Equal(t, 1, hits)
+	})
+
+	t.Run("Cache entries are not shared between principals", func(t *testing.T) {
+		hits := 0
+		router := setupCachedRouter(cache.NewMemoryStore(time.Minute), jwtSecret, &hits)
+		tokenA, _ := utils.GenerateToken(1, "a@example.com", jwtSecret, time.Hour)
+		tokenB, _ := utils.GenerateToken(2, "b@example.com", jwtSecret, time.Hour)
+
+		doRequest(router, http.MethodGet, tokenA)
+		w := doRequest(router, http.MethodGet, tokenB)
+
+		assert.Equal(t, "MISS", w.Header().Get("X-Cache"))
+		assert.Contains(t, w.Body.String(), `"user_id":2`)
+		assert.Equal(t, 2, hits)
+	})
+
+	t.Run("Successful write invalidates the group", func(t *testing.T) {
+		hits := 0
+		router := setupCachedRouter(cache.NewMemoryStore(time.Minute), jwtSecret, &hits)
+		token, _ := utils.GenerateToken(1, "john@example.com", jwtSecret, time.Hour)
+
+		doRequest(router, http.MethodGet, token)
+		doRequest(router, http.MethodPost, token)
+		w := doRequest(router, http.MethodGet, token)
+
+		assert.Equal(t, "MISS", w.Header().Get("X-Cache"))
+		assert.Equal(t, 2, hits)
+	})
+
+	t.Run("Services invalidate the group outside of the routes", func(t *testing.T) {
+		hits := 0
+		store := cache.NewMemoryStore(time.Minute)
+		router := setupCachedRouter(store, jwtSecret, &hits)
+		token, _ := utils.GenerateToken(1, "john@example.com", jwtSecret, time.Hour)
+
+		doRequest(router, http.MethodGet, token)
+		require.NoError(t, cache.NewGroup(store, "other").Invalidate(context.Background()))
+		assert.Equal(t, "HIT", doRequest(router, http.MethodGet, token).Header().Get("X-Cache"))
+
+		require.NoError(t, cache.NewGroup(store, "stats").Invalidate(context.Background()))
+		w := doRequest(router, http.MethodGet, token)
+
+		assert.Equal(t, "MISS", w.Header().Get("X-Cache"))
+		assert.Equal(t, 2, hits)
+	})
+
+	t.Run("Successful write invalidates the group on other instances", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		broadcaster := &localBroadcaster{}
+		storeA := cache.NewBroadcastStore(cache.NewMemoryStore(time.Minute), broadcaster)
+		storeB := cache.NewBroadcastStore(cache.NewMemoryStore(time.Minute), broadcaster)
+		go func() { _ = storeA.Listen(ctx) }()
+		go func() { _ = storeB.Listen(ctx) }()
+		assert.Eventually(t, func() bool { return broadcaster.subscribers() == 2 }, time.Second, time.Millisecond)
+
+		hitsA, hitsB := 0, 0
+		routerA := setupCachedRouter(storeA, jwtSecret, &hitsA)
+		routerB := setupCachedRouter(storeB, jwtSecret, &hitsB)
+		token, _ := utils.GenerateToken(1, "john@example.com", jwtSecret, time.Hour)
+
+		doRequest(routerB, http.MethodGet, token)
+		assert.Equal(t, "HIT", doRequest(routerB, http.MethodGet, token).Header().Get("X-Cache"))
+
+		doRequest(routerA, http.MethodPost, token)
+		w := doRequest(routerB, http.MethodGet, token)
+
+		assert.Equal(t, "MISS", w.Header().Get("X-Cache"))
+		assert.Equal(t, 2, hitsB)
+	})
+}
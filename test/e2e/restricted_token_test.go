@@ -0,0 +1,55 @@
+package e2e
+
+import (
+	"gojwt-rest-api/internal/domain"
+	"gojwt-rest-api/internal/middleware"
+	"gojwt-rest-api/internal/utils"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAuthMiddleware_RestrictedTokens(t *testing.T) {
+	jwtSecret := "test-secret"
+
+	router := setupRouter()
+	ok := func(c *gin.Context) { c.Status(http.StatusNoContent) }
+	router.GET("/profile", middleware.AuthMiddleware(jwtSecret), ok)
+	router.POST("/2fa/enroll", middleware.AuthMiddleware(jwtSecret, middleware.AllowScopes(domain.ScopeTwoFactorEnroll)), ok)
+
+	doRequest := func(method, path, token string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest(method, path, nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	enrollToken, _ := utils.GenerateToken(1, "john@example.com", jwtSecret, time.Hour, utils.WithScope(domain.ScopeTwoFactorEnroll))
+	verifyToken, _ := utils.GenerateToken(1, "john@example.com", jwtSecret, time.Hour, utils.WithScope(domain.ScopeTwoFactorVerify))
+	fullToken, _ := utils.GenerateToken(1, "john@example.com", jwtSecret, time.Hour)
+
+	t.Run("Restricted token is rejected on regular routes", func(t *testing.T) {
+		w := doRequest(http.MethodGet, "/profile", enrollToken)
+
+		assert.Equal(t, http.StatusForbidden, w.Code)
+		assert.Contains(t, w.Body.String(), domain.ErrRestrictedToken.Error())
+	})
+
+	t.Run("Restricted token is accepted on routes allowing its scope", func(t *testing.T) {
+		assert.Equal(t, http.StatusNoContent, doRequest(http.MethodPost, "/2fa/enroll", enrollToken).Code)
+	})
+
+	t.Run("Token with another scope is rejected", func(t *testing.T) {
+		assert.Equal(t, http.StatusForbidden, doRequest(http.MethodPost, "/2fa/enroll", verifyToken).Code)
+	})
+
+	t.Run("Full token is accepted everywhere", func(t *testing.T) {
+		assert.Equal(t, http.StatusNoContent, doRequest(http.MethodGet, "/profile", fullToken).Code)
+		assert.Equal(t, http.StatusNoContent, doRequest(http.MethodPost, "/2fa/enroll", fullToken).Code)
+	})
+}
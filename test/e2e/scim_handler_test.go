@@ -0,0 +1,184 @@
+package e2e
+
+import (
+	"bytes"
+	"encoding/json"
+	"gojwt-rest-api/internal/domain"
+	"gojwt-rest-api/internal/handler"
+	"gojwt-rest-api/internal/middleware"
+	"gojwt-rest-api/internal/scim"
+	"gojwt-rest-api/internal/service"
+	"gojwt-rest-api/pkg/validator"
+	"gojwt-rest-api/test/helpers"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+const scimToken = "scim-test-token"
+
+func setupSCIMRouter(userRepo *helpers.MockUserRepository, tokenRepo *helpers.MockTokenRepository, opts ...service.ProvisioningServiceOption) *gin.Engine {
+	v, _ := validator.New()
+	scimHandler := handler.NewSCIMHandler(service.NewProvisioningService(userRepo, tokenRepo, opts...), v)
+
+	router := setupRouter()
+	users := router.Group("/scim/v2/Users")
+	users.Use(middleware.SCIMAuthMiddleware(scimToken))
+	{
+		users.GET("", scimHandler.ListUsers)
+		users.POST("", scimHandler.CreateUser)
+		users.GET("/:id", scimHandler.GetUser)
+		users.PATCH("/:id", scimHandler.PatchUser)
+		users.DELETE("/:id", scimHandler.DeleteUser)
+	}
+	return router
+}
+
+func doSCIMRequest(router *gin.Engine, method, path, token string, body interface{}) *httptest.ResponseRecorder {
+	var reader *bytes.Reader
+	if body != nil {
+		data, _ := json.Marshal(body)
+		reader = bytes.NewReader(data)
+	} else {
+		reader = bytes.NewReader(nil)
+	}
+	req, _ := http.NewRequest(method, path, reader)
+	req.Header.Set("Content-Type", scim.ContentType)
+	req.Header.Set("Authorization", "Bearer "+token)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func TestSCIMHandler(t *testing.T) {
+	t.Run("Rejects invalid provisioning token", func(t *testing.T) {
+		router := setupSCIMRouter(new(helpers.MockUserRepository), new(helpers.MockTokenRepository))
+
+		w := doSCIMRequest(router, http.MethodGet, "/scim/v2/Users", "wrong", nil)
+
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+		assert.Contains(t, w.Body.String(), scim.SchemaError)
+	})
+
+	t.Run("Create user", func(t *testing.T) {
+		userRepo := new(helpers.MockUserRepository)
+		router := setupSCIMRouter(userRepo, new(helpers.MockTokenRepository))
+
+		userRepo.On("FindByEmail", "jane@example.com").Return(nil, domain.ErrUserNotFound)
+		userRepo.On("Create", mock.AnythingOfType("*domain.User")).Run(func(args mock.Arguments) {
+			args.Get(0).(*domain.User).ID = 9
+		}).Return(nil)
+
+		w := doSCIMRequest(router, http.MethodPost, "/scim/v2/Users", scimToken, map[string]interface{}{
+			"schemas":  []string{scim.SchemaUser},
+			"userName": "jane@example.com",
+			"name":     map[string]string{"givenName": "Jane", "familyName": "Roe"},
+		})
+
+		require.Equal(t, http.StatusCreated, w.Code)
+		assert.Equal(t, scim.ContentType, w.Header().Get("Content-Type"))
+
+		var resource scim.User
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resource))
+		assert.Equal(t, "9", resource.ID)
+		assert.Equal(t, "Jane Roe", resource.DisplayName)
+		assert.True(t, resource.IsActive())
+	})
+
+	t.Run("Create duplicate user returns uniqueness error", func(t *testing.T) {
+		userRepo := new(helpers.MockUserRepository)
+		router := setupSCIMRouter(userRepo, new(helpers.MockTokenRepository))
+
+		userRepo.On("FindByEmail", "john@example.com").Return(helpers.CreateTestUser(1, "john@example.com"), nil)
+
+		w := doSCIMRequest(router, http.MethodPost, "/scim/v2/Users", scimToken, map[string]interface{}{
+			"userName":    "john@example.com",
+			"displayName": "John Doe",
+		})
+
+		assert.Equal(t, http.StatusConflict, w.Code)
+		assert.Contains(t, w.Body.String(), scim.ErrorTypeUniqueness)
+	})
+
+	t.Run("List users with filter", func(t *testing.T) {
+		userRepo := new(helpers.MockUserRepository)
+		router := setupSCIMRouter(userRepo, new(helpers.MockTokenRepository))
+
+		expected := &domain.UserFilter{Field: "email", Operator: "eq", Value: "john@example.com"}
+		userRepo.On("FindByFilter", expected, 0, 100).Return([]*domain.User{helpers.CreateTestUser(1, "john@example.com")}, int64(1), nil)
+
+		w := doSCIMRequest(router, http.MethodGet, `/scim/v2/Users?filter=userName+eq+%22john%40example.com%22`, scimToken, nil)
+
+		require.Equal(t, http.StatusOK, w.Code)
+		var list scim.ListResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &list))
+		assert.Equal(t, int64(1), list.TotalResults)
+		assert.Equal(t, "john@example.com", list.Resources[0].UserName)
+	})
+
+	t.Run("Invalid filter", func(t *testing.T) {
+		router := setupSCIMRouter(new(helpers.MockUserRepository), new(helpers.MockTokenRepository))
+
+		w := doSCIMRequest(router, http.MethodGet, `/scim/v2/Users?filter=password+eq+%22x%22`, scimToken, nil)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Contains(t, w.Body.String(), scim.ErrorTypeInvalidFilter)
+	})
+
+	t.Run("PATCH deactivation revokes sessions", func(t *testing.T) {
+		userRepo := new(helpers.MockUserRepository)
+		tokenRepo := new(helpers.MockTokenRepository)
+		router := setupSCIMRouter(userRepo, tokenRepo)
+
+		user := helpers.CreateTestUser(1, "john@example.com")
+		userRepo.On("FindByID", uint(1)).Return(user, nil)
+		userRepo.On("Update", user).Return(nil)
+		tokenRepo.On("RevokeAllUserRefreshTokens", uint(1)).Return(nil)
+
+		w := doSCIMRequest(router, http.MethodPatch, "/scim/v2/Users/1", scimToken, map[string]interface{}{
+			"schemas":    []string{scim.SchemaPatchOp},
+			"Operations": []map[string]interface{}{{"op": "replace", "path": "active", "value": false}},
+		})
+
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.Contains(t, w.Body.String(), `"active":false`)
+		assert.False(t, user.IsActive())
+		tokenRepo.AssertExpectations(t)
+	})
+
+	t.Run("DELETE deactivates the user", func(t *testing.T) {
+		userRepo := new(helpers.MockUserRepository)
+		tokenRepo := new(helpers.MockTokenRepository)
+		apiKeyRepo := new(helpers.MockAPIKeyRepository)
+		router := setupSCIMRouter(userRepo, tokenRepo, service.WithProvisioningAPIKeys(apiKeyRepo))
+
+		user := helpers.CreateTestUser(1, "john@example.com")
+		userRepo.On("FindByID", uint(1)).Return(user, nil)
+		userRepo.On("Update", user).Return(nil)
+		tokenRepo.On("RevokeAllUserRefreshTokens", uint(1)).Return(nil)
+		apiKeyRepo.On("RevokeAllUserAPIKeys", uint(1)).Return(nil)
+
+		w := doSCIMRequest(router, http.MethodDelete, "/scim/v2/Users/1", scimToken, nil)
+
+		assert.Equal(t, http.StatusNoContent, w.Code)
+		assert.NotNil(t, user.DeactivatedAt)
+		userRepo.AssertNotCalled(t, "Delete", mock.Anything)
+		apiKeyRepo.AssertExpectations(t)
+	})
+
+	t.Run("Unknown user", func(t *testing.T) {
+		userRepo := new(helpers.MockUserRepository)
+		router := setupSCIMRouter(userRepo, new(helpers.MockTokenRepository))
+
+		userRepo.On("FindByID", uint(42)).Return(nil, domain.ErrUserNotFound)
+
+		w := doSCIMRequest(router, http.MethodGet, "/scim/v2/Users/42", scimToken, nil)
+
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+}
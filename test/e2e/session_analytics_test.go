@@ -0,0 +1,55 @@
+package e2e
+
+import (
+	"encoding/json"
+	"gojwt-rest-api/internal/domain"
+	"gojwt-rest-api/internal/handler"
+	"gojwt-rest-api/internal/service"
+	"gojwt-rest-api/test/helpers"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSessionAnalyticsEndpoint(t *testing.T) {
+	repo := new(helpers.MockSessionAnalyticsRepository)
+	repo.On("CountRefreshes", mock.Anything).Return(make([]int64, 24), nil)
+	repo.On("CountCreated", mock.Anything, mock.Anything).Return(make([]int64, 24), nil)
+	active := make([]int64, 24)
+	active[0] = 3
+	repo.On("CountActiveSessions", mock.Anything).Return(active, nil)
+	repo.On("TableStats", mock.Anything).Return(&domain.TokenTableStats{}, nil)
+	analyticsHandler := handler.NewSessionAnalyticsHandler(service.NewSessionAnalyticsService(repo))
+
+	router := setupRouter()
+	router.GET("/admin/session-analytics", analyticsHandler.GetSessionAnalytics)
+	get := func(query string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest(http.MethodGet, "/admin/session-analytics"+query, nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("Reports the last 24 intervals by default", func(t *testing.T) {
+		w := get("")
+
+		require.Equal(t, http.StatusOK, w.Code)
+		var response struct {
+			Data domain.SessionAnalyticsReport `json:"data"`
+		}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		assert.Equal(t, "1h0m0s", response.Data.Interval)
+		assert.Len(t, response.Data.Points, 24)
+		assert.Equal(t, int64(3), response.Data.Points[0].ActiveSessions)
+	})
+
+	t.Run("Rejects invalid ranges", func(t *testing.T) {
+		assert.Equal(t, http.StatusBadRequest, get("?interval=soon").Code)
+		assert.Equal(t, http.StatusBadRequest, get("?from=2026-10-02T00:00:00Z&to=2026-10-01T00:00:00Z").Code)
+		assert.Equal(t, http.StatusBadRequest, get("?from=2026-01-01T00:00:00Z&to=2026-10-01T00:00:00Z").Code)
+	})
+}
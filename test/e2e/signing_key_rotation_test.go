@@ -0,0 +1,101 @@
+package e2e
+
+import (
+	"encoding/json"
+	"gojwt-rest-api/internal/domain"
+	"gojwt-rest-api/internal/middleware"
+	"gojwt-rest-api/internal/service"
+	"gojwt-rest-api/internal/utils"
+	"gojwt-rest-api/test/factory"
+	"gojwt-rest-api/test/helpers"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSigningKeyRotation(t *testing.T) {
+	leaked, standby := factory.DefaultSecret, "standby-signing-key-of-at-least-32-bytes"
+	keys := utils.NewKeySet(leaked, standby)
+	signingKeyRepo := new(helpers.MockSigningKeyRepository)
+	signingKeyRepo.On("RecordIncident", mock.Anything, false).Run(func(args mock.Arguments) {
+		args.Get(0).(*domain.SigningKeyIncident).ID = 1
+	}).Return(nil)
+	signingKeys := service.NewSigningKeyService(signingKeyRepo, keys)
+
+	userRepo := helpers.NewMemoryUserRepository()
+	user := factory.New().User()
+	require.NoError(t, userRepo.Create(user))
+	userService := service.NewUserService(userRepo, helpers.NewMemoryTokenRepository(), leaked, 15*time.Minute, time.Hour,
+		service.WithSigningKeys(keys))
+
+	router := setupRouter()
+	router.GET("/profile",
+		middleware.AuthMiddleware(leaked, middleware.WithKeySet(keys)),
+		func(c *gin.Context) { c.Status(http.StatusNoContent) },
+	)
+	doRequest := func(token string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest(http.MethodGet, "/profile", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+	// forge signs admin claims with the leaked key, naming the key kid
+	forge := func(kid string) string {
+		token := jwt.NewWithClaims(jwt.SigningMethodHS256, &utils.JWTClaims{
+			UserID: user.ID,
+			Roles:  []string{domain.RoleAdmin},
+			RegisteredClaims: jwt.RegisteredClaims{
+				ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour)),
+				IssuedAt:  jwt.NewNumericDate(time.Now()),
+			},
+		})
+		if kid != "" {
+			token.Header["kid"] = kid
+		}
+		signed, err := token.SignedString([]byte(leaked))
+		require.NoError(t, err)
+		return signed
+	}
+
+	login, err := userService.Login(&domain.LoginRequest{Email: user.Email, Password: factory.DefaultPassword})
+	require.NoError(t, err)
+	require.Equal(t, http.StatusNoContent, doRequest(login.AccessToken).Code)
+	require.Equal(t, http.StatusNoContent, doRequest(forge(domain.SigningKeyFingerprint(leaked))).Code)
+
+	_, err = signingKeys.ReportCompromise(&domain.SigningKeyCompromise{Secret: leaked, Reason: "leak"})
+	require.NoError(t, err)
+
+	t.Run("Tokens signed with the compromised key must be refreshed", func(t *testing.T) {
+		w := doRequest(login.AccessToken)
+		require.Equal(t, http.StatusUnauthorized, w.Code)
+
+		var body domain.Response
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+		assert.Equal(t, domain.ErrSigningKeyRotated.Error(), body.Message)
+		assert.Equal(t, map[string]interface{}{"code": domain.ErrorCodeTokenExpired}, body.Error)
+	})
+
+	t.Run("Tokens forged with the compromised key are rejected", func(t *testing.T) {
+		for _, kid := range []string{domain.SigningKeyFingerprint(leaked), domain.SigningKeyFingerprint(standby), ""} {
+			assert.Equal(t, http.StatusUnauthorized, doRequest(forge(kid)).Code, "kid %q", kid)
+		}
+	})
+
+	t.Run("Refreshed sessions are signed with the standby key", func(t *testing.T) {
+		refreshed, err := userService.RefreshToken(&domain.RefreshTokenRequest{RefreshToken: login.RefreshToken})
+		require.NoError(t, err)
+
+		token, _, err := jwt.NewParser().ParseUnverified(refreshed.AccessToken, &utils.JWTClaims{})
+		require.NoError(t, err)
+		assert.Equal(t, domain.SigningKeyFingerprint(standby), token.Header["kid"])
+		assert.Equal(t, http.StatusNoContent, doRequest(refreshed.AccessToken).Code)
+	})
+}
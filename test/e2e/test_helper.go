@@ -1,6 +1,8 @@
 package e2e
 
 import (
+	"crypto/rand"
+	"encoding/hex"
 	"fmt"
 	"gojwt-rest-api/internal/handler"
 	"gojwt-rest-api/internal/middleware"
@@ -8,6 +10,7 @@ import (
 	"gojwt-rest-api/internal/service"
 	"gojwt-rest-api/migrations"
 	"gojwt-rest-api/pkg/validator"
+	"os"
 	"testing"
 	"time"
 
@@ -17,33 +20,88 @@ import (
 	"gorm.io/gorm/logger"
 )
 
-func setupTestServer(t *testing.T) (*gin.Engine, *gorm.DB) {
-	gin.SetMode(gin.TestMode)
+// testDatabasePrefix prefixes the databases created for each test, so ones
+// left behind by an interrupted run are easy to find and drop
+const testDatabasePrefix = "gojwt_e2e_"
 
-	// Setup test database
-	dsn := fmt.Sprintf("%s:%s@tcp(%s:%s)/%s?charset=utf8mb4&parseTime=True&loc=Local",
-		"root",
-		"",
-		"localhost",
-		"3306",
-		"gojwt_db_test",
+// getTestEnv returns the environment variable, or fallback when unset
+func getTestEnv(key, fallback string) string {
+	if value, ok := os.LookupEnv(key); ok {
+		return value
+	}
+	return fallback
+}
+
+// testDSN returns the DSN of database on the test MySQL server, configured
+// with TEST_DB_* variables. An empty database connects to the server only.
+func testDSN(database string) string {
+	return fmt.Sprintf("%s:%s@tcp(%s:%s)/%s?charset=utf8mb4&parseTime=True&loc=UTC",
+		getTestEnv("TEST_DB_USER", "root"),
+		getTestEnv("TEST_DB_PASSWORD", ""),
+		getTestEnv("TEST_DB_HOST", "localhost"),
+		getTestEnv("TEST_DB_PORT", "3306"),
+		database,
 	)
+}
 
-	db, err := gorm.Open(mysql.Open(dsn), &gorm.Config{
-		Logger: logger.Default.LogMode(logger.Silent),
-	})
+// setupTestDatabase creates a migrated database used only by t and dropped
+// when t finishes, so tests using it can run in parallel. The test is skipped
+// when no MySQL server is reachable.
+func setupTestDatabase(t *testing.T) *gorm.DB {
+	t.Helper()
+	config := &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)}
+
+	server, err := gorm.Open(mysql.Open(testDSN("")), config)
+	if err != nil {
+		t.Skipf("Skipping e2e test: database connection failed: %v", err)
+		return nil
+	}
+	serverDB, err := server.DB()
 	if err != nil {
 		t.Skipf("Skipping e2e test: database connection failed: %v", err)
-		return nil, nil
+		return nil
+	}
+	if err := serverDB.Ping(); err != nil {
+		serverDB.Close()
+		t.Skipf("Skipping e2e test: database connection failed: %v", err)
+		return nil
 	}
 
-	// Run migrations
-	migrations.Migrate(db)
+	suffix := make([]byte, 8)
+	if _, err := rand.Read(suffix); err != nil {
+		t.Fatal(err)
+	}
+	name := testDatabasePrefix + hex.EncodeToString(suffix)
+	if err := server.Exec("CREATE DATABASE `" + name + "` CHARACTER SET utf8mb4").Error; err != nil {
+		serverDB.Close()
+		t.Fatalf("Failed to create test database: %v", err)
+	}
+
+	db, err := gorm.Open(mysql.Open(testDSN(name)), config)
+	if err != nil {
+		t.Fatalf("Failed to connect to test database: %v", err)
+	}
+	t.Cleanup(func() {
+		if sqlDB, err := db.DB(); err == nil {
+			sqlDB.Close()
+		}
+		if err := server.Exec("DROP DATABASE IF EXISTS `" + name + "`").Error; err != nil {
+			t.Logf("Failed to drop test database %s: %v", name, err)
+		}
+		serverDB.Close()
+	})
+
+	if err := migrations.Migrate(db); err != nil {
+		t.Fatalf("Failed to run migrations: %v", err)
+	}
+	return db
+}
+
+func setupTestServer(t *testing.T) (*gin.Engine, *gorm.DB) {
+	gin.SetMode(gin.TestMode)
 
-	// Clean up tables before each test
-	db.Exec("DELETE FROM token_blacklist")
-	db.Exec("DELETE FROM refresh_tokens")
-	db.Exec("DELETE FROM users")
+	// Each test gets its own database, nothing to clean up between tests
+	db := setupTestDatabase(t)
 
 	// Setup repositories
 	userRepo := repository.NewUserRepository(db)
@@ -0,0 +1,92 @@
+package e2e
+
+import (
+	"encoding/json"
+	"gojwt-rest-api/internal/domain"
+	"gojwt-rest-api/internal/middleware"
+	"gojwt-rest-api/internal/utils"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// tokenRejection is the body of a rejected access token
+type tokenRejection struct {
+	Message string `json:"message"`
+	Error   struct {
+		Code string `json:"code"`
+	} `json:"error"`
+}
+
+func TestAuthMiddleware_TokenErrors(t *testing.T) {
+	jwtSecret := "test-secret"
+
+	router := setupRouter()
+	ok := func(c *gin.Context) { c.Status(http.StatusNoContent) }
+	router.GET("/profile", middleware.AuthMiddleware(jwtSecret), ok)
+	router.GET("/legacy", middleware.AuthMiddleware(jwtSecret, middleware.WithGenericTokenErrors()), ok)
+
+	doRequest := func(path, authorization string) (*httptest.ResponseRecorder, tokenRejection) {
+		req, _ := http.NewRequest(http.MethodGet, path, nil)
+		if authorization != "" {
+			req.Header.Set("Authorization", authorization)
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		var body tokenRejection
+		if w.Code != http.StatusNoContent {
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+		}
+		return w, body
+	}
+
+	expiredToken, _ := utils.GenerateToken(1, "john@example.com", jwtSecret, -time.Minute)
+	forgedExpiredToken, _ := utils.GenerateToken(1, "john@example.com", "another-secret", -time.Minute)
+	forgedToken, _ := utils.GenerateToken(1, "john@example.com", "another-secret", time.Hour)
+
+	t.Run("Expired token asks for a refresh", func(t *testing.T) {
+		w, body := doRequest("/profile", "Bearer "+expiredToken)
+
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+		assert.Equal(t, domain.ErrorCodeTokenExpired, body.Error.Code)
+		assert.Equal(t, domain.ErrTokenExpired.Error(), body.Message)
+		assert.Equal(t, `Bearer error="invalid_token", error_description="The access token expired"`, w.Header().Get("WWW-Authenticate"))
+	})
+
+	t.Run("Invalid tokens require a new login", func(t *testing.T) {
+		for name, token := range map[string]string{"forged": forgedToken, "forged and expired": forgedExpiredToken, "malformed": "not-a-jwt"} {
+			w, body := doRequest("/profile", "Bearer "+token)
+
+			assert.Equal(t, http.StatusUnauthorized, w.Code, name)
+			assert.Equal(t, domain.ErrorCodeTokenInvalid, body.Error.Code, name)
+			assert.Contains(t, w.Header().Get("WWW-Authenticate"), `error="invalid_token"`, name)
+			assert.NotContains(t, w.Header().Get("WWW-Authenticate"), "expired", name)
+		}
+	})
+
+	t.Run("Missing token gets a bare challenge", func(t *testing.T) {
+		w, body := doRequest("/profile", "")
+
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+		assert.Empty(t, body.Error.Code)
+		assert.Equal(t, "Bearer", w.Header().Get("WWW-Authenticate"))
+	})
+
+	t.Run("Generic errors do not tell expired tokens apart", func(t *testing.T) {
+		expired, expiredBody := doRequest("/legacy", "Bearer "+expiredToken)
+		forged, forgedBody := doRequest("/legacy", "Bearer "+forgedToken)
+
+		assert.Equal(t, http.StatusUnauthorized, expired.Code)
+		assert.Equal(t, domain.ErrInvalidOrExpiredToken.Error(), expiredBody.Message)
+		assert.Empty(t, expiredBody.Error.Code)
+		assert.Equal(t, expired.Body.String(), forged.Body.String())
+		assert.Equal(t, expiredBody, forgedBody)
+		assert.Equal(t, expired.Header().Get("WWW-Authenticate"), forged.Header().Get("WWW-Authenticate"))
+	})
+}
@@ -0,0 +1,110 @@
+package e2e
+
+import (
+	"encoding/json"
+	"gojwt-rest-api/internal/domain"
+	"gojwt-rest-api/internal/metrics"
+	"gojwt-rest-api/internal/middleware"
+	"gojwt-rest-api/internal/utils"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// legacyToken signs an access token in the format issued before formats
+// were versioned, without fmt claim
+func legacyToken(t *testing.T, secret string) string {
+	t.Helper()
+	claims := &utils.JWTClaims{
+		UserID: 1,
+		Email:  "john@example.com",
+		RegisteredClaims: jwt.RegisteredClaims{
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(15 * time.Minute)),
+			IssuedAt:  jwt.NewNumericDate(time.Now()),
+		},
+	}
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte(secret))
+	require.NoError(t, err)
+	return token
+}
+
+func TestAuthMiddleware_TokenFormatPolicy(t *testing.T) {
+	jwtSecret := "test-secret"
+	registry := metrics.NewRegistry()
+	observer := metrics.NewTokenFormatMetrics(registry)
+	cutoff := time.Now().Add(24 * time.Hour).Truncate(time.Second)
+
+	router := setupRouter()
+	ok := func(c *gin.Context) { c.Status(http.StatusNoContent) }
+	router.GET("/window", middleware.AuthMiddleware(jwtSecret, middleware.WithTokenFormatPolicy(middleware.TokenFormatPolicy{
+		LegacyCutoff: cutoff,
+		Observer:     observer,
+	})), ok)
+	router.GET("/retired", middleware.AuthMiddleware(jwtSecret, middleware.WithTokenFormatPolicy(middleware.TokenFormatPolicy{
+		LegacyCutoff: time.Now().Add(-time.Hour),
+		Observer:     observer,
+	})), ok)
+
+	doRequest := func(path, token string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest(http.MethodGet, path, nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+	current, err := utils.GenerateToken(1, "john@example.com", jwtSecret, 15*time.Minute)
+	require.NoError(t, err)
+	legacy := legacyToken(t, jwtSecret)
+
+	t.Run("Issues tokens in the current format", func(t *testing.T) {
+		claims, err := utils.ValidateToken(current, jwtSecret)
+		require.NoError(t, err)
+		assert.Equal(t, utils.CurrentTokenFormat, claims.TokenFormat())
+	})
+
+	t.Run("Accepts legacy tokens before the cutoff, flagged as deprecated", func(t *testing.T) {
+		w := doRequest("/window", legacy)
+
+		require.Equal(t, http.StatusNoContent, w.Code)
+		assert.Equal(t, "true", w.Header().Get("Deprecation"))
+		assert.Equal(t, cutoff.UTC().Format(http.TimeFormat), w.Header().Get("Sunset"))
+	})
+
+	t.Run("Does not flag current tokens", func(t *testing.T) {
+		w := doRequest("/window", current)
+
+		require.Equal(t, http.StatusNoContent, w.Code)
+		assert.Empty(t, w.Header().Get("Deprecation"))
+	})
+
+	t.Run("Rejects legacy tokens after the cutoff so clients refresh", func(t *testing.T) {
+		w := doRequest("/retired", legacy)
+
+		require.Equal(t, http.StatusUnauthorized, w.Code)
+		var response struct {
+			Message string            `json:"message"`
+			Error   map[string]string `json:"error"`
+		}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		assert.Equal(t, domain.ErrTokenFormatRetired.Error(), response.Message)
+		assert.Equal(t, domain.ErrorCodeTokenExpired, response.Error["code"])
+
+		assert.Equal(t, http.StatusNoContent, doRequest("/retired", current).Code)
+	})
+
+	t.Run("Counts tokens by format", func(t *testing.T) {
+		var out strings.Builder
+		registry.Write(&out)
+
+		assert.Contains(t, out.String(), `auth_token_format_total{format="1",outcome="accepted"} 1`)
+		assert.Contains(t, out.String(), `auth_token_format_total{format="1",outcome="rejected"} 1`)
+		assert.Contains(t, out.String(), `auth_token_format_total{format="2",outcome="accepted"} 2`)
+	})
+}
@@ -0,0 +1,97 @@
+package e2e
+
+import (
+	"encoding/json"
+	"gojwt-rest-api/internal/domain"
+	"gojwt-rest-api/internal/middleware"
+	"gojwt-rest-api/internal/service"
+	"gojwt-rest-api/internal/utils"
+	"gojwt-rest-api/test/factory"
+	"gojwt-rest-api/test/helpers"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAdminMiddleware_PermissionClaims(t *testing.T) {
+	jwtSecret := factory.DefaultSecret
+	userRepo := helpers.NewMemoryUserRepository()
+	userService := service.NewUserService(userRepo, helpers.NewMemoryTokenRepository(), jwtSecret, 15*time.Minute, time.Hour)
+
+	f := factory.New()
+	admin := f.User(factory.Admin())
+	other := f.User(factory.Admin())
+	support := f.User(factory.WithAdminScopes(domain.ScopeAdminUserRead))
+	for _, user := range []*domain.User{admin, other, support} {
+		require.NoError(t, userRepo.Create(user))
+	}
+
+	router := setupRouter()
+	ok := func(c *gin.Context) { c.Status(http.StatusNoContent) }
+	router.Use(middleware.AuthMiddleware(jwtSecret))
+	router.GET("/admin", middleware.AdminMiddleware(userService), ok)
+	router.GET("/users", middleware.AdminScopeMiddleware(userService, domain.ScopeAdminUserRead), ok)
+
+	login := func(user *domain.User) string {
+		resp, err := userService.Login(&domain.LoginRequest{Email: user.Email, Password: factory.DefaultPassword})
+		require.NoError(t, err)
+		return resp.AccessToken
+	}
+	doRequest := func(path, token string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest(http.MethodGet, path, nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("Access tokens embed roles and permissions", func(t *testing.T) {
+		claims, err := utils.NewTokenValidator(jwtSecret).Validate(login(support))
+		require.NoError(t, err)
+
+		assert.Equal(t, []string{domain.RoleUser}, claims.Roles)
+		assert.Contains(t, claims.Permissions, domain.ScopeAdminUserRead)
+		assert.Equal(t, uint(0), claims.PermVersion)
+	})
+
+	t.Run("Changed scopes reject outstanding tokens", func(t *testing.T) {
+		token := login(support)
+		require.Equal(t, http.StatusNoContent, doRequest("/users", token).Code)
+
+		_, err := userService.UpdateAdminScopes(support.ID, nil)
+		require.NoError(t, err)
+
+		w := doRequest("/users", token)
+		require.Equal(t, http.StatusUnauthorized, w.Code)
+		var body domain.Response
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+		assert.Equal(t, map[string]interface{}{"code": domain.ErrorCodePermissionsChanged}, body.Error)
+
+		assert.Equal(t, http.StatusForbidden, doRequest("/users", login(support)).Code, "new tokens carry the current scopes")
+	})
+
+	t.Run("Demoted admins are rejected", func(t *testing.T) {
+		token := login(other)
+		require.Equal(t, http.StatusNoContent, doRequest("/admin", token).Code)
+
+		demote := false
+		_, err := userService.UpdateUser(admin.ID, other.ID, &domain.UpdateUserRequest{IsAdmin: &demote})
+		require.NoError(t, err)
+
+		assert.Equal(t, http.StatusUnauthorized, doRequest("/admin", token).Code)
+		assert.Equal(t, http.StatusForbidden, doRequest("/admin", login(other)).Code)
+	})
+
+	t.Run("Tokens without roles fall back to the user", func(t *testing.T) {
+		token, err := utils.GenerateToken(admin.ID, admin.Email, jwtSecret, time.Minute)
+		require.NoError(t, err)
+
+		assert.Equal(t, http.StatusNoContent, doRequest("/admin", token).Code)
+		assert.Equal(t, http.StatusNoContent, doRequest("/users", token).Code)
+	})
+}
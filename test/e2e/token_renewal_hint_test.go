@@ -0,0 +1,57 @@
+package e2e
+
+import (
+	"gojwt-rest-api/internal/middleware"
+	"gojwt-rest-api/internal/utils"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAuthMiddleware_RenewalHint(t *testing.T) {
+	jwtSecret := "test-secret"
+
+	router := setupRouter()
+	ok := func(c *gin.Context) { c.Status(http.StatusNoContent) }
+	router.GET("/profile", middleware.AuthMiddleware(jwtSecret, middleware.WithRenewalHint(2*time.Minute)), ok)
+	router.GET("/no-hint", middleware.AuthMiddleware(jwtSecret), ok)
+
+	doRequest := func(path string, expiresIn time.Duration) *httptest.ResponseRecorder {
+		token, err := utils.GenerateToken(1, "john@example.com", jwtSecret, expiresIn)
+		require.NoError(t, err)
+		req, _ := http.NewRequest(http.MethodGet, path, nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("Hints tokens about to expire", func(t *testing.T) {
+		w := doRequest("/profile", 90*time.Second)
+
+		require.Equal(t, http.StatusNoContent, w.Code)
+		seconds, err := strconv.Atoi(w.Header().Get(middleware.TokenExpiresInHeader))
+		require.NoError(t, err)
+		assert.InDelta(t, 90, seconds, 2)
+	})
+
+	t.Run("Does not hint fresh tokens", func(t *testing.T) {
+		w := doRequest("/profile", 15*time.Minute)
+
+		require.Equal(t, http.StatusNoContent, w.Code)
+		assert.Empty(t, w.Header().Get(middleware.TokenExpiresInHeader))
+	})
+
+	t.Run("Is disabled without a window", func(t *testing.T) {
+		w := doRequest("/no-hint", 30*time.Second)
+
+		require.Equal(t, http.StatusNoContent, w.Code)
+		assert.Empty(t, w.Header().Get(middleware.TokenExpiresInHeader))
+	})
+}
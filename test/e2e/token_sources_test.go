@@ -0,0 +1,122 @@
+package e2e
+
+import (
+	"bytes"
+	"gojwt-rest-api/internal/middleware"
+	"gojwt-rest-api/internal/utils"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAuthMiddleware_TokenSources(t *testing.T) {
+	jwtSecret := "test-secret"
+	sources := middleware.TokenSources{Header: true, Cookie: "access_token", QueryParam: "access_token"}
+
+	router := setupRouter()
+	whoami := func(c *gin.Context) {
+		email, _ := middleware.GetUserEmail(c)
+		c.String(http.StatusOK, email)
+	}
+	auth := middleware.AuthMiddleware(jwtSecret, middleware.WithTokenSources(sources))
+	router.GET("/whoami", auth, whoami)
+	router.POST("/whoami", auth, whoami)
+	router.GET("/header-only", middleware.AuthMiddleware(jwtSecret), whoami)
+
+	tokenFor := func(email string) string {
+		token, err := utils.GenerateToken(1, email, jwtSecret, 15*time.Minute)
+		require.NoError(t, err)
+		return token
+	}
+	headerToken := tokenFor("header@example.com")
+	cookieToken := tokenFor("cookie@example.com")
+	queryToken := tokenFor("query@example.com")
+
+	doRequest := func(method, path string, prepare func(*http.Request)) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, nil)
+		if prepare != nil {
+			prepare(req)
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+	withCookie := func(req *http.Request) {
+		req.AddCookie(&http.Cookie{Name: "access_token", Value: cookieToken})
+	}
+
+	t.Run("Prefers the header, then the cookie, then the query", func(t *testing.T) {
+		w := doRequest(http.MethodGet, "/whoami?access_token="+queryToken, func(req *http.Request) {
+			req.Header.Set("Authorization", "Bearer "+headerToken)
+			withCookie(req)
+		})
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "header@example.com", w.Body.String())
+
+		w = doRequest(http.MethodGet, "/whoami?access_token="+queryToken, withCookie)
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "cookie@example.com", w.Body.String())
+
+		w = doRequest(http.MethodGet, "/whoami?access_token="+queryToken, nil)
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "query@example.com", w.Body.String())
+	})
+
+	t.Run("Does not fall back when the first source is invalid", func(t *testing.T) {
+		w := doRequest(http.MethodGet, "/whoami", func(req *http.Request) {
+			req.Header.Set("Authorization", "Bearer invalid")
+			withCookie(req)
+		})
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+
+		w = doRequest(http.MethodGet, "/whoami", func(req *http.Request) {
+			req.Header.Set("Authorization", "Basic dXNlcjpwYXNz")
+			withCookie(req)
+		})
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+	})
+
+	t.Run("Cookie tokens on unsafe methods require X-Requested-With", func(t *testing.T) {
+		w := doRequest(http.MethodPost, "/whoami", withCookie)
+		assert.Equal(t, http.StatusForbidden, w.Code)
+
+		w = doRequest(http.MethodPost, "/whoami", func(req *http.Request) {
+			withCookie(req)
+			req.Header.Set("X-Requested-With", "XMLHttpRequest")
+		})
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "cookie@example.com", w.Body.String())
+	})
+
+	t.Run("Query tokens are only read on GET", func(t *testing.T) {
+		w := doRequest(http.MethodPost, "/whoami?access_token="+queryToken, nil)
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+	})
+
+	t.Run("Disabled sources are ignored", func(t *testing.T) {
+		w := doRequest(http.MethodGet, "/header-only?access_token="+queryToken, withCookie)
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+		assert.Equal(t, "Bearer", w.Header().Get("WWW-Authenticate"))
+	})
+}
+
+func TestRequestLogFormatter_RedactsQueryTokens(t *testing.T) {
+	var logs bytes.Buffer
+	router := setupRouter()
+	router.Use(gin.LoggerWithConfig(gin.LoggerConfig{
+		Formatter: middleware.NewRequestLogFormatter("access_token"),
+		Output:    &logs,
+	}))
+	router.GET("/download", func(c *gin.Context) { c.Status(http.StatusNoContent) })
+
+	req := httptest.NewRequest(http.MethodGet, "/download?file=report.csv&access_token=secret-token", nil)
+	router.ServeHTTP(httptest.NewRecorder(), req)
+
+	assert.NotContains(t, logs.String(), "secret-token")
+	assert.Contains(t, logs.String(), "/download?file=report.csv&access_token=[redacted]")
+}
@@ -0,0 +1,144 @@
+package e2e
+
+import (
+	"gojwt-rest-api/internal/config"
+	"gojwt-rest-api/internal/domain"
+	"gojwt-rest-api/internal/middleware"
+	"gojwt-rest-api/internal/service"
+	"gojwt-rest-api/internal/utils"
+	"gojwt-rest-api/test/helpers"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTrustBoundaryMiddleware(t *testing.T) {
+	boundary := middleware.TrustBoundary{
+		Networks:  []netip.Prefix{netip.MustParsePrefix("10.0.0.0/8"), netip.MustParsePrefix("fd00::/8")},
+		APIKeyIDs: []uint{1},
+	}
+
+	apiKeyRepo := new(helpers.MockAPIKeyRepository)
+	apiKeyRepo.On("FindByHash", utils.HashAPIKey("internal-key")).
+		Return(&domain.APIKey{ID: 1, UserID: 1, User: domain.User{Email: "svc@example.com"}}, nil)
+	apiKeyRepo.On("FindByHash", utils.HashAPIKey("user-key")).
+		Return(&domain.APIKey{ID: 2, UserID: 2, User: domain.User{Email: "john@example.com"}}, nil)
+	apiKeyService := service.NewAPIKeyService(apiKeyRepo, 0)
+
+	router := setupRouter()
+	router.Use(middleware.TrustBoundaryMiddleware(boundary))
+	zone := func(c *gin.Context) { c.String(http.StatusOK, middleware.GetTrustZone(c)) }
+	router.GET("/zone", zone)
+	router.GET("/keyed/zone", middleware.APIKeyMiddleware(apiKeyService, service.NewAPIKeyUsageTracker(apiKeyRepo)), zone)
+	router.GET("/internal/ping", middleware.InternalOnlyMiddleware(), zone)
+
+	doRequest := func(path, remoteAddr string, prepare func(*http.Request)) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		req.RemoteAddr = remoteAddr
+		if prepare != nil {
+			prepare(req)
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("Classifies callers by the network of the connection", func(t *testing.T) {
+		assert.Equal(t, middleware.TrustZoneInternal, doRequest("/zone", "10.1.2.3:4000", nil).Body.String())
+		assert.Equal(t, middleware.TrustZoneInternal, doRequest("/zone", "[fd00::1]:4000", nil).Body.String())
+		assert.Equal(t, middleware.TrustZoneExternal, doRequest("/zone", "203.0.113.9:4000", nil).Body.String())
+	})
+
+	t.Run("Ignores forwarded addresses", func(t *testing.T) {
+		w := doRequest("/zone", "203.0.113.9:4000", func(req *http.Request) {
+			req.Header.Set("X-Forwarded-For", "10.1.2.3")
+		})
+		assert.Equal(t, middleware.TrustZoneExternal, w.Body.String())
+	})
+
+	t.Run("Trusts callers authenticated with a listed API key", func(t *testing.T) {
+		w := doRequest("/keyed/zone", "203.0.113.9:4000", func(req *http.Request) {
+			req.Header.Set("X-API-Key", "internal-key")
+		})
+		assert.Equal(t, middleware.TrustZoneInternal, w.Body.String())
+	})
+
+	t.Run("Does not trust other API keys", func(t *testing.T) {
+		w := doRequest("/keyed/zone", "203.0.113.9:4000", func(req *http.Request) {
+			req.Header.Set("X-API-Key", "user-key")
+		})
+		assert.Equal(t, middleware.TrustZoneExternal, w.Body.String())
+	})
+
+	t.Run("Hides internal routes from external callers", func(t *testing.T) {
+		assert.Equal(t, http.StatusOK, doRequest("/internal/ping", "10.1.2.3:4000", nil).Code)
+		assert.Equal(t, http.StatusNotFound, doRequest("/internal/ping", "203.0.113.9:4000", nil).Code)
+	})
+}
+
+func TestLoopbackOnlyMiddleware(t *testing.T) {
+	router := setupRouter()
+	router.POST("/_dev/ping", middleware.LoopbackOnlyMiddleware(), func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	doRequest := func(remoteAddr string, forwardedFor string) int {
+		req := httptest.NewRequest(http.MethodPost, "/_dev/ping", nil)
+		req.RemoteAddr = remoteAddr
+		if forwardedFor != "" {
+			req.Header.Set("X-Forwarded-For", forwardedFor)
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	assert.Equal(t, http.StatusOK, doRequest("127.0.0.1:4000", ""))
+	assert.Equal(t, http.StatusOK, doRequest("[::1]:4000", ""))
+	assert.Equal(t, http.StatusOK, doRequest("@", ""), "unix socket peer")
+	assert.Equal(t, http.StatusNotFound, doRequest("203.0.113.9:4000", ""))
+	assert.Equal(t, http.StatusNotFound, doRequest("203.0.113.9:4000", "127.0.0.1"), "forwarded address is ignored")
+}
+
+func TestRateLimitMiddleware_InternalLimit(t *testing.T) {
+	newRouter := func(internalLimit int) *gin.Engine {
+		limiter := middleware.NewRateLimiter(
+			config.RateLimitConfig{RequestsPerDuration: 1, Duration: time.Minute, CleanupInterval: time.Minute},
+			middleware.WithInternalLimit(internalLimit),
+		)
+		router := setupRouter()
+		router.Use(middleware.TrustBoundaryMiddleware(middleware.TrustBoundary{
+			Networks: []netip.Prefix{netip.MustParsePrefix("10.0.0.0/8")},
+		}))
+		router.Use(middleware.RateLimitMiddleware(limiter))
+		router.GET("/ping", func(c *gin.Context) { c.Status(http.StatusNoContent) })
+		return router
+	}
+	statuses := func(router *gin.Engine, remoteAddr string, n int) []int {
+		codes := make([]int, n)
+		for i := range codes {
+			req := httptest.NewRequest(http.MethodGet, "/ping", nil)
+			req.RemoteAddr = remoteAddr
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+			codes[i] = w.Code
+		}
+		return codes
+	}
+
+	t.Run("Internal callers get the internal limit", func(t *testing.T) {
+		router := newRouter(3)
+
+		assert.Equal(t, []int{204, 429}, statuses(router, "203.0.113.9:4000", 2))
+		assert.Equal(t, []int{204, 204, 204, 429}, statuses(router, "10.1.2.3:4000", 4))
+	})
+
+	t.Run("An internal limit of zero does not limit internal callers", func(t *testing.T) {
+		router := newRouter(0)
+
+		assert.Equal(t, []int{204, 204, 204, 204, 204}, statuses(router, "10.1.2.3:4000", 5))
+	})
+}
@@ -0,0 +1,109 @@
+package factory
+
+import (
+	"fmt"
+	"gojwt-rest-api/internal/domain"
+	"gojwt-rest-api/internal/utils"
+	"time"
+)
+
+// tokenConfig is a refresh token being built
+type tokenConfig struct {
+	token *domain.RefreshToken
+	now   time.Time
+}
+
+// RefreshTokenOption customizes a built refresh token
+type RefreshTokenOption func(*tokenConfig)
+
+// WithToken sets the token string instead of a random one
+func WithToken(token string) RefreshTokenOption {
+	return func(c *tokenConfig) {
+		c.token.Token = token
+	}
+}
+
+// InFamily puts the token in an existing token family, as after a rotation
+func InFamily(family string) RefreshTokenOption {
+	return func(c *tokenConfig) {
+		c.token.TokenFamily = family
+	}
+}
+
+// ExpiresIn sets the expiry relative to the current time of the clock
+func ExpiresIn(d time.Duration) RefreshTokenOption {
+	return func(c *tokenConfig) {
+		c.token.ExpiresAt = c.now.Add(d)
+	}
+}
+
+// Expired makes the token expire an hour before the current time of the clock
+func Expired() RefreshTokenOption {
+	return ExpiresIn(-time.Hour)
+}
+
+// Revoked revokes the token at the current time of the clock
+func Revoked() RefreshTokenOption {
+	return func(c *tokenConfig) {
+		now := c.now
+		c.token.IsRevoked = true
+		c.token.RevokedAt = &now
+	}
+}
+
+// RefreshToken builds a refresh token of user with random token and family
+// strings, valid for the factory's refresh token lifetime
+func (f *Factory) RefreshToken(user *domain.User, opts ...RefreshTokenOption) *domain.RefreshToken {
+	now := f.Clock.Now()
+	c := &tokenConfig{
+		token: &domain.RefreshToken{
+			ID:        f.tokenID(),
+			UserID:    user.ID,
+			ExpiresAt: now.Add(f.refreshTTL),
+			CreatedAt: now,
+		},
+		now: now,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	if c.token.Token == "" {
+		c.token.Token = f.RandomHex(32)
+	}
+	if c.token.TokenFamily == "" {
+		c.token.TokenFamily = f.RandomHex(16)
+	}
+	return c.token
+}
+
+// AccessToken signs an access token for user with the factory's secret.
+// JWT timestamps come from the real clock, as the validator checks them against it.
+func (f *Factory) AccessToken(user *domain.User, opts ...utils.TokenOption) string {
+	token, err := utils.GenerateToken(user.ID, user.Email, f.secret, f.accessTTL, opts...)
+	if err != nil {
+		panic(fmt.Sprintf("factory: generate access token: %v", err))
+	}
+	return token
+}
+
+// Session is a logged-in user's access token and stored refresh token
+type Session struct {
+	User         *domain.User
+	AccessToken  string
+	RefreshToken *domain.RefreshToken
+}
+
+// Session builds an access token and refresh token for user
+func (f *Factory) Session(user *domain.User, opts ...RefreshTokenOption) *Session {
+	return &Session{
+		User:         user,
+		AccessToken:  f.AccessToken(user),
+		RefreshToken: f.RefreshToken(user, opts...),
+	}
+}
+
+// AuthorizationHeader returns the Authorization header value of the session
+func (s *Session) AuthorizationHeader() string {
+	return "Bearer " + s.AccessToken
+}
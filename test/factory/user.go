@@ -0,0 +1,142 @@
+package factory
+
+import (
+	"fmt"
+	"gojwt-rest-api/internal/domain"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// passwordHashes caches bcrypt hashes by password across factories, as
+// hashing dominates the cost of building users
+var passwordHashes sync.Map
+
+// userConfig is a user being built
+type userConfig struct {
+	user     *domain.User
+	password string
+	now      time.Time
+}
+
+// UserOption customizes a built user
+type UserOption func(*userConfig)
+
+// WithUserID sets the ID instead of the next one in sequence
+func WithUserID(id uint) UserOption {
+	return func(c *userConfig) {
+		c.user.ID = id
+	}
+}
+
+// WithName sets the name
+func WithName(name string) UserOption {
+	return func(c *userConfig) {
+		c.user.Name = name
+	}
+}
+
+// WithEmail sets the email
+func WithEmail(email string) UserOption {
+	return func(c *userConfig) {
+		c.user.Email = email
+	}
+}
+
+// WithPassword sets the password the user can log in with
+func WithPassword(password string) UserOption {
+	return func(c *userConfig) {
+		c.password = password
+	}
+}
+
+// Admin makes the user an administrator
+func Admin() UserOption {
+	return func(c *userConfig) {
+		c.user.IsAdmin = true
+	}
+}
+
+// WithAdminScopes grants delegated admin scopes
+func WithAdminScopes(scopes ...string) UserOption {
+	return func(c *userConfig) {
+		c.user.AdminScopes = strings.Join(scopes, ",")
+	}
+}
+
+// InOrganization makes the user a member of an organization with role
+func InOrganization(orgID uint, role string) UserOption {
+	return func(c *userConfig) {
+		c.user.OrganizationID = &orgID
+		c.user.OrganizationRole = role
+	}
+}
+
+// LoggedInAt records a first and last login at the time
+func LoggedInAt(at time.Time) UserOption {
+	return func(c *userConfig) {
+		c.user.FirstLoginAt = &at
+		c.user.LastLoginAt = &at
+	}
+}
+
+// Deactivated deactivates the user at the current time of the clock
+func Deactivated() UserOption {
+	return func(c *userConfig) {
+		now := c.now
+		c.user.DeactivatedAt = &now
+	}
+}
+
+// User builds a user with the next ID, a name and email derived from it and
+// DefaultPassword, created at the current time of the clock
+func (f *Factory) User(opts ...UserOption) *domain.User {
+	now := f.Clock.Now()
+	c := &userConfig{
+		user:     &domain.User{CreatedAt: now, UpdatedAt: now},
+		password: DefaultPassword,
+		now:      now,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	user := c.user
+	if user.ID == 0 {
+		user.ID = f.userID()
+	}
+	if user.Name == "" {
+		user.Name = fmt.Sprintf("User %d", user.ID)
+	}
+	user.SetName(user.Name)
+	if user.Email == "" {
+		user.Email = fmt.Sprintf("user%d@example.com", user.ID)
+	}
+	user.Password = HashPassword(c.password)
+	return user
+}
+
+// Users builds count users
+func (f *Factory) Users(count int, opts ...UserOption) []*domain.User {
+	users := make([]*domain.User, count)
+	for i := range users {
+		users[i] = f.User(opts...)
+	}
+	return users
+}
+
+// HashPassword returns a bcrypt hash of password with the minimum cost,
+// which utils.CheckPassword accepts like any other hash
+func HashPassword(password string) string {
+	if hash, ok := passwordHashes.Load(password); ok {
+		return hash.(string)
+	}
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.MinCost)
+	if err != nil {
+		panic(fmt.Sprintf("factory: hash password: %v", err))
+	}
+	passwordHashes.Store(password, string(hash))
+	return string(hash)
+}
@@ -0,0 +1,532 @@
+package helpers
+
+import (
+	"context"
+	"gojwt-rest-api/internal/domain"
+	"gojwt-rest-api/internal/repository"
+	"strings"
+	"sync"
+	"time"
+)
+
+// MemoryUserRepository is an in-memory repository.UserRepository covering the
+// operations of the authentication flows. Other operations panic through the
+// embedded nil interface.
+type MemoryUserRepository struct {
+	repository.UserRepository
+
+	mu      sync.RWMutex
+	nextID  uint
+	byID    map[uint]*domain.User
+	byEmail map[string]uint
+}
+
+// NewMemoryUserRepository creates an empty in-memory user repository
+func NewMemoryUserRepository() *MemoryUserRepository {
+	return &MemoryUserRepository{
+		byID:    make(map[uint]*domain.User),
+		byEmail: make(map[string]uint),
+	}
+}
+
+// WithContext returns the repository itself, users are not scoped to tenants
+func (r *MemoryUserRepository) WithContext(ctx context.Context) repository.UserRepository {
+	return r
+}
+
+// Create stores a copy of user, assigning its ID
+func (r *MemoryUserRepository) Create(user *domain.User) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	email := strings.ToLower(user.Email)
+	if _, exists := r.byEmail[email]; exists {
+		return domain.ErrUserAlreadyExists
+	}
+	r.nextID++
+	user.ID = r.nextID
+	user.CreatedAt = time.Now()
+	user.UpdatedAt = user.CreatedAt
+	stored := *user
+	r.byID[user.ID] = &stored
+	r.byEmail[email] = user.ID
+	return nil
+}
+
+// FindByID returns a copy of the user, as a database would
+func (r *MemoryUserRepository) FindByID(id uint) (*domain.User, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	user, ok := r.byID[id]
+	if !ok {
+		return nil, domain.ErrUserNotFound
+	}
+	found := *user
+	return &found, nil
+}
+
+// FindTokenVersions returns the session epoch and permission version of the user
+func (r *MemoryUserRepository) FindTokenVersions(id uint) (*domain.TokenVersions, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	user, ok := r.byID[id]
+	if !ok {
+		return nil, domain.ErrUserNotFound
+	}
+	return &domain.TokenVersions{SessionEpoch: user.SessionEpoch, PermVersion: user.PermVersion}, nil
+}
+
+// FindByEmail returns a copy of the user with email
+func (r *MemoryUserRepository) FindByEmail(email string) (*domain.User, error) {
+	r.mu.RLock()
+	id, ok := r.byEmail[strings.ToLower(email)]
+	r.mu.RUnlock()
+	if !ok {
+		return nil, domain.ErrUserNotFound
+	}
+	return r.FindByID(id)
+}
+
+// Update replaces the stored user, reindexing a changed email
+func (r *MemoryUserRepository) Update(user *domain.User) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	previous, ok := r.byID[user.ID]
+	if !ok {
+		return domain.ErrUserNotFound
+	}
+	email := strings.ToLower(user.Email)
+	if id, exists := r.byEmail[email]; exists && id != user.ID {
+		return domain.ErrEmailAlreadyInUse
+	}
+	delete(r.byEmail, strings.ToLower(previous.Email))
+	r.byEmail[email] = user.ID
+
+	user.UpdatedAt = time.Now()
+	stored := *user
+	r.byID[user.ID] = &stored
+	return nil
+}
+
+// FindByFilter returns copies of the users, ordered by ID, honoring only the
+// Active criterion of filter
+func (r *MemoryUserRepository) FindByFilter(filter *domain.UserFilter, offset, limit int) ([]*domain.User, int64, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var matched []*domain.User
+	for id := uint(1); id <= r.nextID; id++ {
+		user, ok := r.byID[id]
+		if !ok {
+			continue
+		}
+		if filter != nil && filter.Active != nil && *filter.Active != (user.DeactivatedAt == nil) {
+			continue
+		}
+		found := *user
+		matched = append(matched, &found)
+	}
+
+	total := int64(len(matched))
+	if offset >= len(matched) {
+		return nil, total, nil
+	}
+	matched = matched[offset:]
+	if len(matched) > limit {
+		matched = matched[:limit]
+	}
+	return matched, total, nil
+}
+
+// CountActiveAdmins counts admins that have not been deactivated
+func (r *MemoryUserRepository) CountActiveAdmins() (int64, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var count int64
+	for _, user := range r.byID {
+		if user.IsAdmin && user.IsActive() {
+			count++
+		}
+	}
+	return count, nil
+}
+
+// MarkFirstLogin records the first login time, returning false if it was already set
+func (r *MemoryUserRepository) MarkFirstLogin(id uint, at time.Time) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	user, ok := r.byID[id]
+	if !ok || user.FirstLoginAt != nil {
+		return false, nil
+	}
+	user.FirstLoginAt = &at
+	return true, nil
+}
+
+// RecordLogin records the last login time and clears any inactivity warning
+func (r *MemoryUserRepository) RecordLogin(id uint, at time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if user, ok := r.byID[id]; ok {
+		user.LastLoginAt = &at
+		user.InactivityWarnedAt = nil
+	}
+	return nil
+}
+
+// MemoryTokenRepository is an in-memory repository.TokenRepository covering
+// the operations of the authentication flows. Other operations panic through
+// the embedded nil interface.
+type MemoryTokenRepository struct {
+	repository.TokenRepository
+
+	mu        sync.RWMutex
+	nextID    uint
+	refresh   map[string]*domain.RefreshToken
+	blacklist map[string]time.Time
+	cutoffs   []domain.ClientSessionCutoff
+}
+
+// NewMemoryTokenRepository creates an empty in-memory token repository
+func NewMemoryTokenRepository() *MemoryTokenRepository {
+	return &MemoryTokenRepository{
+		refresh:   make(map[string]*domain.RefreshToken),
+		blacklist: make(map[string]time.Time),
+	}
+}
+
+// CreateRefreshToken stores a copy of token, assigning its ID
+func (r *MemoryTokenRepository) CreateRefreshToken(token *domain.RefreshToken) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, exists := r.refresh[token.Token]; exists {
+		return domain.ErrFailedToCreateRefreshToken
+	}
+	r.nextID++
+	token.ID = r.nextID
+	token.CreatedAt = time.Now()
+	stored := *token
+	r.refresh[token.Token] = &stored
+	return nil
+}
+
+// FindRefreshTokenByToken returns a copy of the refresh token
+func (r *MemoryTokenRepository) FindRefreshTokenByToken(token string) (*domain.RefreshToken, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	stored, ok := r.refresh[token]
+	if !ok {
+		return nil, domain.ErrTokenNotFound
+	}
+	found := *stored
+	return &found, nil
+}
+
+// UpdateRefreshToken replaces the stored refresh token
+func (r *MemoryTokenRepository) UpdateRefreshToken(token *domain.RefreshToken) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.refresh[token.Token]; !ok {
+		return domain.ErrTokenNotFound
+	}
+	stored := *token
+	r.refresh[token.Token] = &stored
+	return nil
+}
+
+// RotateRefreshToken revokes current and stores next atomically, returning
+// false when current was already revoked
+func (r *MemoryTokenRepository) RotateRefreshToken(current, next *domain.RefreshToken, at time.Time) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stored, ok := r.refresh[current.Token]
+	if !ok {
+		return false, domain.ErrTokenNotFound
+	}
+	if stored.IsRevoked {
+		return false, nil
+	}
+	if _, exists := r.refresh[next.Token]; exists {
+		return false, domain.ErrFailedToCreateRefreshToken
+	}
+	replacedBy := next.Token
+	stored.IsRevoked = true
+	stored.RevokedAt = &at
+	stored.ReplacedBy = &replacedBy
+
+	r.nextID++
+	next.ID = r.nextID
+	next.CreatedAt = at
+	created := *next
+	r.refresh[next.Token] = &created
+	return true, nil
+}
+
+// RevokeRefreshToken revokes a refresh token
+func (r *MemoryTokenRepository) RevokeRefreshToken(token string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stored, ok := r.refresh[token]
+	if !ok {
+		return domain.ErrTokenNotFound
+	}
+	now := time.Now()
+	stored.IsRevoked = true
+	stored.RevokedAt = &now
+	return nil
+}
+
+// RevokeAllUserRefreshTokens revokes every refresh token of a user
+func (r *MemoryTokenRepository) RevokeAllUserRefreshTokens(userID uint) error {
+	return r.revokeWhere(func(token *domain.RefreshToken) bool { return token.UserID == userID })
+}
+
+// RevokeTokenFamily revokes every refresh token of a token family
+func (r *MemoryTokenRepository) RevokeTokenFamily(tokenFamily string) error {
+	return r.revokeWhere(func(token *domain.RefreshToken) bool { return token.TokenFamily == tokenFamily })
+}
+
+// RevokeClientRefreshTokens revokes the refresh tokens of a client
+// application's sessions, of userID or of every user when nil
+func (r *MemoryTokenRepository) RevokeClientRefreshTokens(clientID string, userID *uint, at time.Time) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var revoked int64
+	for _, token := range r.refresh {
+		if token.ClientID == clientID && (userID == nil || token.UserID == *userID) && !token.IsRevoked {
+			token.IsRevoked = true
+			token.RevokedAt = &at
+			revoked++
+		}
+	}
+	return revoked, nil
+}
+
+// AddClientSessionCutoff stores a cutoff of a client application's sessions
+func (r *MemoryTokenRepository) AddClientSessionCutoff(cutoff *domain.ClientSessionCutoff) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.cutoffs = append(r.cutoffs, *cutoff)
+	return nil
+}
+
+// FindClientSessionCutoff returns the latest cutoff of a client application's
+// sessions applying to userID
+func (r *MemoryTokenRepository) FindClientSessionCutoff(clientID string, userID uint) (*time.Time, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var latest *time.Time
+	for _, cutoff := range r.cutoffs {
+		if cutoff.ClientID != clientID || (cutoff.UserID != nil && *cutoff.UserID != userID) {
+			continue
+		}
+		if latest == nil || cutoff.RevokedAt.After(*latest) {
+			revokedAt := cutoff.RevokedAt
+			latest = &revokedAt
+		}
+	}
+	return latest, nil
+}
+
+func (r *MemoryTokenRepository) revokeWhere(match func(*domain.RefreshToken) bool) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now()
+	for _, token := range r.refresh {
+		if match(token) && !token.IsRevoked {
+			token.IsRevoked = true
+			token.RevokedAt = &now
+		}
+	}
+	return nil
+}
+
+// CountActiveRefreshTokens counts the unrevoked, unexpired refresh tokens of a user
+func (r *MemoryTokenRepository) CountActiveRefreshTokens(userID uint, now time.Time) (int64, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var count int64
+	for _, token := range r.refresh {
+		if token.UserID == userID && !token.IsRevoked && token.ExpiresAt.After(now) {
+			count++
+		}
+	}
+	return count, nil
+}
+
+// AddToBlacklist blacklists a token until it expires
+func (r *MemoryTokenRepository) AddToBlacklist(token *domain.TokenBlacklist) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.blacklist[token.Token] = token.ExpiresAt
+	return nil
+}
+
+// IsTokenBlacklisted reports whether a token is blacklisted and not yet expired
+func (r *MemoryTokenRepository) IsTokenBlacklisted(token string) (bool, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	expiresAt, ok := r.blacklist[token]
+	return ok && expiresAt.After(time.Now()), nil
+}
+
+// MemoryOneTimeTokenRepository is an in-memory repository.OneTimeTokenRepository
+type MemoryOneTimeTokenRepository struct {
+	mu     sync.Mutex
+	nextID uint
+	tokens []*domain.OneTimeToken
+}
+
+// NewMemoryOneTimeTokenRepository creates an empty in-memory one-time token repository
+func NewMemoryOneTimeTokenRepository() *MemoryOneTimeTokenRepository {
+	return &MemoryOneTimeTokenRepository{}
+}
+
+// Create stores a copy of token, assigning its ID
+func (r *MemoryOneTimeTokenRepository) Create(token *domain.OneTimeToken) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, stored := range r.tokens {
+		if stored.TokenHash == token.TokenHash {
+			return domain.ErrOneTimeTokenInvalid
+		}
+	}
+	r.nextID++
+	token.ID = r.nextID
+	token.CreatedAt = time.Now()
+	stored := *token
+	r.tokens = append(r.tokens, &stored)
+	return nil
+}
+
+// FindByTokenHash returns a copy of the token of the purpose with tokenHash
+func (r *MemoryOneTimeTokenRepository) FindByTokenHash(purpose, tokenHash string) (*domain.OneTimeToken, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stored := r.find(purpose, tokenHash)
+	if stored == nil {
+		return nil, domain.ErrOneTimeTokenNotFound
+	}
+	found := *stored
+	return &found, nil
+}
+
+// FindActiveBySubject returns copies of the usable tokens of the purpose issued to subject
+func (r *MemoryOneTimeTokenRepository) FindActiveBySubject(purpose, subject string, now time.Time) ([]*domain.OneTimeToken, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var tokens []*domain.OneTimeToken
+	for _, stored := range r.tokens {
+		if stored.Purpose == purpose && stored.Subject == subject && stored.IsUsable(now) {
+			found := *stored
+			tokens = append(tokens, &found)
+		}
+	}
+	return tokens, nil
+}
+
+// InvalidateSubject consumes the unconsumed tokens of the purpose issued to subject
+func (r *MemoryOneTimeTokenRepository) InvalidateSubject(purpose, subject string, at time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, stored := range r.tokens {
+		if stored.Purpose == purpose && stored.Subject == subject && stored.ConsumedAt == nil {
+			stored.ConsumedAt = &at
+		}
+	}
+	return nil
+}
+
+// Consume marks a usable token consumed
+func (r *MemoryOneTimeTokenRepository) Consume(purpose, tokenHash string, at time.Time) (*domain.OneTimeToken, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stored := r.find(purpose, tokenHash)
+	if stored == nil {
+		return nil, domain.ErrOneTimeTokenNotFound
+	}
+	if !stored.IsUsable(at) {
+		return nil, domain.ErrOneTimeTokenInvalid
+	}
+	stored.ConsumedAt = &at
+	found := *stored
+	return &found, nil
+}
+
+func (r *MemoryOneTimeTokenRepository) find(purpose, tokenHash string) *domain.OneTimeToken {
+	for _, stored := range r.tokens {
+		if stored.Purpose == purpose && stored.TokenHash == tokenHash {
+			return stored
+		}
+	}
+	return nil
+}
+
+// MemoryOnboardingRepository is an in-memory repository.OnboardingRepository
+type MemoryOnboardingRepository struct {
+	mu    sync.Mutex
+	steps map[uint]map[string]time.Time
+}
+
+// NewMemoryOnboardingRepository creates an empty in-memory onboarding repository
+func NewMemoryOnboardingRepository() *MemoryOnboardingRepository {
+	return &MemoryOnboardingRepository{steps: make(map[uint]map[string]time.Time)}
+}
+
+// CompleteStep records a completed step, keeping an earlier completion
+func (r *MemoryOnboardingRepository) CompleteStep(userID uint, step string, at time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.steps[userID] == nil {
+		r.steps[userID] = make(map[string]time.Time)
+	}
+	if _, ok := r.steps[userID][step]; !ok {
+		r.steps[userID][step] = at
+	}
+	return nil
+}
+
+// ResetStep forgets a completed step
+func (r *MemoryOnboardingRepository) ResetStep(userID uint, step string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	delete(r.steps[userID], step)
+	return nil
+}
+
+// FindCompletedSteps returns the steps a user completed
+func (r *MemoryOnboardingRepository) FindCompletedSteps(userID uint) ([]*domain.OnboardingStep, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var steps []*domain.OnboardingStep
+	for step, at := range r.steps[userID] {
+		steps = append(steps, &domain.OnboardingStep{UserID: userID, Step: step, CompletedAt: at})
+	}
+	return steps, nil
+}
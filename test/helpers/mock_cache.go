@@ -0,0 +1,17 @@
+package helpers
+
+import (
+	"context"
+
+	"github.com/stretchr/testify/mock"
+)
+
+// MockUserCache is a mock implementation of service.UserCache
+type MockUserCache struct {
+	mock.Mock
+}
+
+func (m *MockUserCache) Invalidate(ctx context.Context) error {
+	args := m.Called(ctx)
+	return args.Error(0)
+}
@@ -0,0 +1,14 @@
+package helpers
+
+import (
+	"github.com/stretchr/testify/mock"
+)
+
+// MockEventPublisher is a mock implementation of events.Publisher
+type MockEventPublisher struct {
+	mock.Mock
+}
+
+func (m *MockEventPublisher) Publish(eventType string, data interface{}) {
+	m.Called(eventType, data)
+}
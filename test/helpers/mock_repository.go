@@ -1,7 +1,10 @@
 package helpers
 
 import (
+	"context"
 	"gojwt-rest-api/internal/domain"
+	"gojwt-rest-api/internal/repository"
+	"time"
 
 	"github.com/stretchr/testify/mock"
 )
@@ -16,11 +19,21 @@ type MockTokenRepository struct {
 	mock.Mock
 }
 
+// WithContext returns the mock itself, expectations are shared by every context
+func (m *MockUserRepository) WithContext(ctx context.Context) repository.UserRepository {
+	return m
+}
+
 func (m *MockUserRepository) Create(user *domain.User) error {
 	args := m.Called(user)
 	return args.Error(0)
 }
 
+func (m *MockUserRepository) CreateBatch(users []*domain.User, batchSize int) error {
+	args := m.Called(users, batchSize)
+	return args.Error(0)
+}
+
 func (m *MockUserRepository) FindByID(id uint) (*domain.User, error) {
 	args := m.Called(id)
 	if args.Get(0) == nil {
@@ -37,6 +50,14 @@ func (m *MockUserRepository) FindByEmail(email string) (*domain.User, error) {
 	return args.Get(0).(*domain.User), args.Error(1)
 }
 
+func (m *MockUserRepository) FindTokenVersions(id uint) (*domain.TokenVersions, error) {
+	args := m.Called(id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.TokenVersions), args.Error(1)
+}
+
 func (m *MockUserRepository) FindAll(pagination *domain.PaginationQuery) ([]*domain.User, int64, error) {
 	args := m.Called(pagination)
 	if args.Get(0) == nil {
@@ -45,6 +66,22 @@ func (m *MockUserRepository) FindAll(pagination *domain.PaginationQuery) ([]*dom
 	return args.Get(0).([]*domain.User), args.Get(1).(int64), args.Error(2)
 }
 
+func (m *MockUserRepository) FindByFilter(filter *domain.UserFilter, offset, limit int) ([]*domain.User, int64, error) {
+	args := m.Called(filter, offset, limit)
+	if args.Get(0) == nil {
+		return nil, args.Get(1).(int64), args.Error(2)
+	}
+	return args.Get(0).([]*domain.User), args.Get(1).(int64), args.Error(2)
+}
+
+func (m *MockUserRepository) Suggest(prefix string, limit int) ([]*domain.UserSuggestion, error) {
+	args := m.Called(prefix, limit)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*domain.UserSuggestion), args.Error(1)
+}
+
 func (m *MockUserRepository) Update(user *domain.User) error {
 	args := m.Called(user)
 	return args.Error(0)
@@ -55,12 +92,78 @@ func (m *MockUserRepository) Delete(id uint) error {
 	return args.Error(0)
 }
 
+func (m *MockUserRepository) DeleteWithCleanup(deletion *domain.UserDeletion) error {
+	args := m.Called(deletion)
+	return args.Error(0)
+}
+
+func (m *MockUserRepository) CountActiveAdmins() (int64, error) {
+	args := m.Called()
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockUserRepository) MarkFirstLogin(id uint, at time.Time) (bool, error) {
+	args := m.Called(id, at)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockUserRepository) RecordLogin(id uint, at time.Time) error {
+	args := m.Called(id, at)
+	return args.Error(0)
+}
+
+func (m *MockUserRepository) FindInactive(query *domain.InactiveUserQuery) ([]*domain.User, error) {
+	args := m.Called(query)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*domain.User), args.Error(1)
+}
+
+func (m *MockUserRepository) MarkInactivityWarned(id uint, at time.Time) (bool, error) {
+	args := m.Called(id, at)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockUserRepository) SuspendInactive(id uint, at time.Time) (bool, error) {
+	args := m.Called(id, at)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockUserRepository) Anonymize(anonymization *domain.UserAnonymization) error {
+	args := m.Called(anonymization)
+	return args.Error(0)
+}
+
+func (m *MockUserRepository) FindTwoFactor(userID uint) (*domain.UserTwoFactor, error) {
+	args := m.Called(userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.UserTwoFactor), args.Error(1)
+}
+
+func (m *MockUserRepository) SaveTwoFactor(twoFactor *domain.UserTwoFactor) error {
+	args := m.Called(twoFactor)
+	return args.Error(0)
+}
+
+func (m *MockUserRepository) UseTwoFactorStep(userID uint, step int64) (bool, error) {
+	args := m.Called(userID, step)
+	return args.Bool(0), args.Error(1)
+}
+
 // MockTokenRepository methods
 func (m *MockTokenRepository) CreateRefreshToken(token *domain.RefreshToken) error {
 	args := m.Called(token)
 	return args.Error(0)
 }
 
+func (m *MockTokenRepository) RotateRefreshToken(current, next *domain.RefreshToken, at time.Time) (bool, error) {
+	args := m.Called(current, next, at)
+	return args.Bool(0), args.Error(1)
+}
+
 func (m *MockTokenRepository) FindRefreshTokenByToken(token string) (*domain.RefreshToken, error) {
 	args := m.Called(token)
 	if args.Get(0) == nil {
@@ -92,6 +195,24 @@ func (m *MockTokenRepository) RevokeAllUserRefreshTokens(userID uint) error {
 	return args.Error(0)
 }
 
+func (m *MockTokenRepository) RevokeClientRefreshTokens(clientID string, userID *uint, at time.Time) (int64, error) {
+	args := m.Called(clientID, userID, at)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockTokenRepository) AddClientSessionCutoff(cutoff *domain.ClientSessionCutoff) error {
+	args := m.Called(cutoff)
+	return args.Error(0)
+}
+
+func (m *MockTokenRepository) FindClientSessionCutoff(clientID string, userID uint) (*time.Time, error) {
+	args := m.Called(clientID, userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*time.Time), args.Error(1)
+}
+
 func (m *MockTokenRepository) RevokeTokenFamily(tokenFamily string) error {
 	args := m.Called(tokenFamily)
 	return args.Error(0)
@@ -116,3 +237,612 @@ func (m *MockTokenRepository) DeleteExpiredBlacklistTokens() error {
 	args := m.Called()
 	return args.Error(0)
 }
+
+func (m *MockTokenRepository) PurgeRevokedRefreshTokens(revokedBefore time.Time, limit int) (int64, error) {
+	args := m.Called(revokedBefore, limit)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockTokenRepository) PurgeExpiredRefreshTokens(expiredBefore time.Time, limit int) (int64, error) {
+	args := m.Called(expiredBefore, limit)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockTokenRepository) PurgeBlacklist(expiredBefore time.Time, limit int) (int64, error) {
+	args := m.Called(expiredBefore, limit)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockTokenRepository) CountActiveRefreshTokens(userID uint, now time.Time) (int64, error) {
+	args := m.Called(userID, now)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockTokenRepository) FindLastSessionStart(userID uint) (*time.Time, error) {
+	args := m.Called(userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*time.Time), args.Error(1)
+}
+
+// MockAPIKeyRepository is a mock implementation of repository.APIKeyRepository
+type MockAPIKeyRepository struct {
+	mock.Mock
+}
+
+// MockAPIKeyRepository methods
+func (m *MockAPIKeyRepository) Create(key *domain.APIKey) error {
+	args := m.Called(key)
+	return args.Error(0)
+}
+
+func (m *MockAPIKeyRepository) FindByID(id uint) (*domain.APIKey, error) {
+	args := m.Called(id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.APIKey), args.Error(1)
+}
+
+func (m *MockAPIKeyRepository) FindByHash(keyHash string) (*domain.APIKey, error) {
+	args := m.Called(keyHash)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.APIKey), args.Error(1)
+}
+
+func (m *MockAPIKeyRepository) FindByUserID(userID uint) ([]*domain.APIKey, error) {
+	args := m.Called(userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*domain.APIKey), args.Error(1)
+}
+
+func (m *MockAPIKeyRepository) FindAll() ([]*domain.APIKey, error) {
+	args := m.Called()
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*domain.APIKey), args.Error(1)
+}
+
+func (m *MockAPIKeyRepository) FindActiveCreatedBefore(cutoff time.Time) ([]*domain.APIKey, error) {
+	args := m.Called(cutoff)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*domain.APIKey), args.Error(1)
+}
+
+func (m *MockAPIKeyRepository) Update(key *domain.APIKey) error {
+	args := m.Called(key)
+	return args.Error(0)
+}
+
+func (m *MockAPIKeyRepository) RevokeAllUserAPIKeys(userID uint) error {
+	args := m.Called(userID)
+	return args.Error(0)
+}
+
+func (m *MockAPIKeyRepository) RecordUsage(apiKeyID uint, method, route string, count int64, lastUsedAt time.Time) error {
+	args := m.Called(apiKeyID, method, route, count, lastUsedAt)
+	return args.Error(0)
+}
+
+func (m *MockAPIKeyRepository) FindUsage(apiKeyID uint) ([]*domain.APIKeyUsage, error) {
+	args := m.Called(apiKeyID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*domain.APIKeyUsage), args.Error(1)
+}
+
+// MockOrganizationRepository is a mock implementation of repository.OrganizationRepository
+type MockOrganizationRepository struct {
+	mock.Mock
+}
+
+// MockOrganizationRepository methods
+func (m *MockOrganizationRepository) Create(org *domain.Organization) error {
+	args := m.Called(org)
+	return args.Error(0)
+}
+
+func (m *MockOrganizationRepository) FindByID(id uint) (*domain.Organization, error) {
+	args := m.Called(id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.Organization), args.Error(1)
+}
+
+func (m *MockOrganizationRepository) FindByName(name string) (*domain.Organization, error) {
+	args := m.Called(name)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.Organization), args.Error(1)
+}
+
+func (m *MockOrganizationRepository) FindAll() ([]*domain.Organization, error) {
+	args := m.Called()
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*domain.Organization), args.Error(1)
+}
+
+func (m *MockOrganizationRepository) AddMember(orgID uint, userID uint, role string) error {
+	args := m.Called(orgID, userID, role)
+	return args.Error(0)
+}
+
+func (m *MockOrganizationRepository) FindMembers(orgID uint) ([]*domain.User, error) {
+	args := m.Called(orgID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*domain.User), args.Error(1)
+}
+
+func (m *MockOrganizationRepository) RemoveMember(orgID uint, userID uint) error {
+	args := m.Called(orgID, userID)
+	return args.Error(0)
+}
+
+func (m *MockOrganizationRepository) TransferOwnership(orgID uint, newOwnerID uint) error {
+	args := m.Called(orgID, newOwnerID)
+	return args.Error(0)
+}
+
+func (m *MockOrganizationRepository) CountMembers(orgID uint) (int64, error) {
+	args := m.Called(orgID)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockOrganizationRepository) CountActiveSessions(orgID uint, now time.Time) (int64, error) {
+	args := m.Called(orgID, now)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockOrganizationRepository) RevokeSessions(orgID uint, at time.Time) (*domain.OrganizationSessionRevocation, error) {
+	args := m.Called(orgID, at)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.OrganizationSessionRevocation), args.Error(1)
+}
+
+func (m *MockOrganizationRepository) CreatePlan(plan *domain.Plan) error {
+	args := m.Called(plan)
+	return args.Error(0)
+}
+
+func (m *MockOrganizationRepository) FindPlanByID(id uint) (*domain.Plan, error) {
+	args := m.Called(id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.Plan), args.Error(1)
+}
+
+func (m *MockOrganizationRepository) FindPlanByName(name string) (*domain.Plan, error) {
+	args := m.Called(name)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.Plan), args.Error(1)
+}
+
+func (m *MockOrganizationRepository) FindPlans() ([]*domain.Plan, error) {
+	args := m.Called()
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*domain.Plan), args.Error(1)
+}
+
+func (m *MockOrganizationRepository) CreateInvitation(invitation *domain.OrganizationInvitation) error {
+	args := m.Called(invitation)
+	return args.Error(0)
+}
+
+func (m *MockOrganizationRepository) FindInvitationByTokenHash(tokenHash string) (*domain.OrganizationInvitation, error) {
+	args := m.Called(tokenHash)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.OrganizationInvitation), args.Error(1)
+}
+
+func (m *MockOrganizationRepository) FindPendingInvitation(orgID uint, email string) (*domain.OrganizationInvitation, error) {
+	args := m.Called(orgID, email)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.OrganizationInvitation), args.Error(1)
+}
+
+func (m *MockOrganizationRepository) FindPendingInvitations(orgID uint, now time.Time) ([]*domain.OrganizationInvitation, error) {
+	args := m.Called(orgID, now)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*domain.OrganizationInvitation), args.Error(1)
+}
+
+func (m *MockOrganizationRepository) UpdateInvitation(invitation *domain.OrganizationInvitation) error {
+	args := m.Called(invitation)
+	return args.Error(0)
+}
+
+func (m *MockOrganizationRepository) FindSettings(orgID uint) (*domain.OrganizationSettings, error) {
+	args := m.Called(orgID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.OrganizationSettings), args.Error(1)
+}
+
+func (m *MockOrganizationRepository) SaveSettings(settings *domain.OrganizationSettings) error {
+	args := m.Called(settings)
+	return args.Error(0)
+}
+
+func (m *MockOrganizationRepository) AcceptInvitation(invitation *domain.OrganizationInvitation, userID uint) error {
+	args := m.Called(invitation, userID)
+	return args.Error(0)
+}
+
+// MockSSOConnectionRepository is a mock implementation of repository.SSOConnectionRepository
+type MockSSOConnectionRepository struct {
+	mock.Mock
+}
+
+// MockSSOConnectionRepository methods
+func (m *MockSSOConnectionRepository) Create(conn *domain.SSOConnection) error {
+	args := m.Called(conn)
+	return args.Error(0)
+}
+
+func (m *MockSSOConnectionRepository) FindByID(id uint) (*domain.SSOConnection, error) {
+	args := m.Called(id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.SSOConnection), args.Error(1)
+}
+
+func (m *MockSSOConnectionRepository) FindByName(name string) (*domain.SSOConnection, error) {
+	args := m.Called(name)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.SSOConnection), args.Error(1)
+}
+
+func (m *MockSSOConnectionRepository) FindByDomain(emailDomain string) (*domain.SSOConnection, error) {
+	args := m.Called(emailDomain)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.SSOConnection), args.Error(1)
+}
+
+func (m *MockSSOConnectionRepository) FindMappedDomains(domains []string) ([]string, error) {
+	args := m.Called(domains)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]string), args.Error(1)
+}
+
+func (m *MockSSOConnectionRepository) FindAll() ([]*domain.SSOConnection, error) {
+	args := m.Called()
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*domain.SSOConnection), args.Error(1)
+}
+
+func (m *MockSSOConnectionRepository) Update(conn *domain.SSOConnection) error {
+	args := m.Called(conn)
+	return args.Error(0)
+}
+
+func (m *MockSSOConnectionRepository) Delete(id uint) error {
+	args := m.Called(id)
+	return args.Error(0)
+}
+
+// MockAuditLogRepository is a mock implementation of repository.AuditLogRepository
+type MockAuditLogRepository struct {
+	mock.Mock
+}
+
+// WithContext returns the mock itself, expectations are shared by every context
+func (m *MockAuditLogRepository) WithContext(ctx context.Context) repository.AuditLogRepository {
+	return m
+}
+
+// MockAuditLogRepository methods
+func (m *MockAuditLogRepository) Create(log *domain.AuditLog) error {
+	args := m.Called(log)
+	return args.Error(0)
+}
+
+func (m *MockAuditLogRepository) Find(filter *domain.AuditLogFilter, offset, limit int) ([]*domain.AuditLog, int64, error) {
+	args := m.Called(filter, offset, limit)
+	if args.Get(0) == nil {
+		return nil, 0, args.Error(2)
+	}
+	return args.Get(0).([]*domain.AuditLog), args.Get(1).(int64), args.Error(2)
+}
+
+func (m *MockAuditLogRepository) Purge(before time.Time, limit int) (int64, error) {
+	args := m.Called(before, limit)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockAuditLogRepository) FindBefore(before time.Time, limit int) ([]*domain.AuditLog, error) {
+	args := m.Called(before, limit)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*domain.AuditLog), args.Error(1)
+}
+
+func (m *MockAuditLogRepository) DeleteByIDs(ids []uint) (int64, error) {
+	args := m.Called(ids)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+// MockWebhookDeliveryRepository is a mock implementation of repository.WebhookDeliveryRepository
+type MockWebhookDeliveryRepository struct {
+	mock.Mock
+}
+
+// MockWebhookDeliveryRepository methods
+func (m *MockWebhookDeliveryRepository) Create(delivery *domain.WebhookDelivery) error {
+	args := m.Called(delivery)
+	return args.Error(0)
+}
+
+func (m *MockWebhookDeliveryRepository) FindByID(id uint) (*domain.WebhookDelivery, error) {
+	args := m.Called(id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.WebhookDelivery), args.Error(1)
+}
+
+func (m *MockWebhookDeliveryRepository) Find(filter *domain.WebhookDeliveryFilter, offset, limit int) ([]*domain.WebhookDelivery, int64, error) {
+	args := m.Called(filter, offset, limit)
+	if args.Get(0) == nil {
+		return nil, 0, args.Error(2)
+	}
+	return args.Get(0).([]*domain.WebhookDelivery), args.Get(1).(int64), args.Error(2)
+}
+
+// MockOneTimeTokenRepository is a mock implementation of repository.OneTimeTokenRepository
+type MockOneTimeTokenRepository struct {
+	mock.Mock
+}
+
+// MockOneTimeTokenRepository methods
+func (m *MockOneTimeTokenRepository) Create(token *domain.OneTimeToken) error {
+	args := m.Called(token)
+	return args.Error(0)
+}
+
+func (m *MockOneTimeTokenRepository) FindByTokenHash(purpose, tokenHash string) (*domain.OneTimeToken, error) {
+	args := m.Called(purpose, tokenHash)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.OneTimeToken), args.Error(1)
+}
+
+func (m *MockOneTimeTokenRepository) FindActiveBySubject(purpose, subject string, now time.Time) ([]*domain.OneTimeToken, error) {
+	args := m.Called(purpose, subject, now)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*domain.OneTimeToken), args.Error(1)
+}
+
+func (m *MockOneTimeTokenRepository) InvalidateSubject(purpose, subject string, at time.Time) error {
+	args := m.Called(purpose, subject, at)
+	return args.Error(0)
+}
+
+func (m *MockOneTimeTokenRepository) Consume(purpose, tokenHash string, at time.Time) (*domain.OneTimeToken, error) {
+	args := m.Called(purpose, tokenHash, at)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.OneTimeToken), args.Error(1)
+}
+
+// MockLoginLocationRepository is a mock implementation of repository.LoginLocationRepository
+type MockLoginLocationRepository struct {
+	mock.Mock
+}
+
+// MockLoginLocationRepository methods
+func (m *MockLoginLocationRepository) Create(location *domain.LoginLocation) error {
+	args := m.Called(location)
+	return args.Error(0)
+}
+
+func (m *MockLoginLocationRepository) FindCountriesSince(userID uint, since time.Time) ([]string, error) {
+	args := m.Called(userID, since)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]string), args.Error(1)
+}
+
+func (m *MockLoginLocationRepository) DeleteBefore(userID uint, before time.Time) error {
+	args := m.Called(userID, before)
+	return args.Error(0)
+}
+
+// MockOnboardingRepository is a mock implementation of repository.OnboardingRepository
+type MockOnboardingRepository struct {
+	mock.Mock
+}
+
+// MockOnboardingRepository methods
+func (m *MockOnboardingRepository) CompleteStep(userID uint, step string, at time.Time) error {
+	args := m.Called(userID, step, at)
+	return args.Error(0)
+}
+
+func (m *MockOnboardingRepository) ResetStep(userID uint, step string) error {
+	args := m.Called(userID, step)
+	return args.Error(0)
+}
+
+func (m *MockOnboardingRepository) FindCompletedSteps(userID uint) ([]*domain.OnboardingStep, error) {
+	args := m.Called(userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*domain.OnboardingStep), args.Error(1)
+}
+
+// MockSigningKeyRepository is a mock implementation of repository.SigningKeyRepository
+type MockSigningKeyRepository struct {
+	mock.Mock
+}
+
+// MockSigningKeyRepository methods
+func (m *MockSigningKeyRepository) FindCompromised(fingerprints []string) ([]string, error) {
+	args := m.Called(fingerprints)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]string), args.Error(1)
+}
+
+func (m *MockSigningKeyRepository) RecordIncident(incident *domain.SigningKeyIncident, revokeRefreshTokens bool) error {
+	args := m.Called(incident, revokeRefreshTokens)
+	return args.Error(0)
+}
+
+// MockClientVersionRepository is a mock implementation of repository.ClientVersionRepository
+type MockClientVersionRepository struct {
+	mock.Mock
+}
+
+// MockClientVersionRepository methods
+func (m *MockClientVersionRepository) FindAll() ([]*domain.ClientVersionPolicy, error) {
+	args := m.Called()
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*domain.ClientVersionPolicy), args.Error(1)
+}
+
+func (m *MockClientVersionRepository) Save(policy *domain.ClientVersionPolicy) error {
+	args := m.Called(policy)
+	return args.Error(0)
+}
+
+func (m *MockClientVersionRepository) Delete(clientID string) error {
+	args := m.Called(clientID)
+	return args.Error(0)
+}
+
+// MockProfileHistoryRepository is a mock implementation of repository.ProfileHistoryRepository
+type MockProfileHistoryRepository struct {
+	mock.Mock
+}
+
+// MockProfileHistoryRepository methods
+func (m *MockProfileHistoryRepository) Create(change *domain.ProfileChange) error {
+	args := m.Called(change)
+	return args.Error(0)
+}
+
+func (m *MockProfileHistoryRepository) FindByUser(userID uint, offset, limit int) ([]*domain.ProfileChange, int64, error) {
+	args := m.Called(userID, offset, limit)
+	if args.Get(0) == nil {
+		return nil, args.Get(1).(int64), args.Error(2)
+	}
+	return args.Get(0).([]*domain.ProfileChange), args.Get(1).(int64), args.Error(2)
+}
+
+// MockSessionAnalyticsRepository is a mock implementation of repository.SessionAnalyticsRepository
+type MockSessionAnalyticsRepository struct {
+	mock.Mock
+}
+
+// MockSessionAnalyticsRepository methods
+func (m *MockSessionAnalyticsRepository) CountActiveSessions(buckets *domain.AnalyticsBuckets) ([]int64, error) {
+	args := m.Called(buckets)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]int64), args.Error(1)
+}
+
+func (m *MockSessionAnalyticsRepository) CountRefreshes(buckets *domain.AnalyticsBuckets) ([]int64, error) {
+	args := m.Called(buckets)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]int64), args.Error(1)
+}
+
+func (m *MockSessionAnalyticsRepository) CountCreated(table string, buckets *domain.AnalyticsBuckets) ([]int64, error) {
+	args := m.Called(table, buckets)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]int64), args.Error(1)
+}
+
+func (m *MockSessionAnalyticsRepository) TableStats(table string) (*domain.TokenTableStats, error) {
+	args := m.Called(table)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.TokenTableStats), args.Error(1)
+}
+
+// MockAbuseReportRepository is a mock implementation of repository.AbuseReportRepository
+type MockAbuseReportRepository struct {
+	mock.Mock
+}
+
+// MockAbuseReportRepository methods
+func (m *MockAbuseReportRepository) Create(report *domain.AbuseReport) error {
+	args := m.Called(report)
+	return args.Error(0)
+}
+
+func (m *MockAbuseReportRepository) FindByID(id uint) (*domain.AbuseReport, error) {
+	args := m.Called(id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.AbuseReport), args.Error(1)
+}
+
+func (m *MockAbuseReportRepository) Find(filter *domain.AbuseReportFilter, offset, limit int) ([]*domain.AbuseReport, int64, error) {
+	args := m.Called(filter, offset, limit)
+	if args.Get(0) == nil {
+		return nil, 0, args.Error(2)
+	}
+	return args.Get(0).([]*domain.AbuseReport), args.Get(1).(int64), args.Error(2)
+}
+
+func (m *MockAbuseReportRepository) Update(report *domain.AbuseReport) error {
+	args := m.Called(report)
+	return args.Error(0)
+}
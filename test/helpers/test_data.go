@@ -2,29 +2,20 @@ package helpers
 
 import (
 	"gojwt-rest-api/internal/domain"
-	"gojwt-rest-api/internal/utils"
+	"gojwt-rest-api/test/factory"
 	"time"
 )
 
-// CreateTestUser creates a test user with default values
+// CreateTestUser creates a test user with password factory.DefaultPassword
 func CreateTestUser(id uint, email string) *domain.User {
-	hashedPassword, _ := utils.HashPassword("password123")
-	return &domain.User{
-		ID:        id,
-		Name:      "Test User",
-		Email:     email,
-		Password:  hashedPassword,
-		IsAdmin:   false,
-		CreatedAt: time.Now(),
-		UpdatedAt: time.Now(),
-	}
+	return factory.New().User(factory.WithUserID(id), factory.WithName("Test User"), factory.WithEmail(email))
 }
 
 // CreateAdminUser creates a test admin user
 func CreateAdminUser(id uint, email string) *domain.User {
 	user := CreateTestUser(id, email)
 	user.IsAdmin = true
-	user.Name = "Admin User"
+	user.SetName("Admin User")
 	return user
 }
 
@@ -70,3 +61,15 @@ func CreatePaginationQuery(page, pageSize int, search string) *domain.Pagination
 		Search:   search,
 	}
 }
+
+// CreateTestOrganization creates a test organization on the given plan
+func CreateTestOrganization(id uint, plan domain.Plan) *domain.Organization {
+	return &domain.Organization{
+		ID:        id,
+		Name:      "Test Org",
+		PlanID:    plan.ID,
+		Plan:      plan,
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+	}
+}
@@ -0,0 +1,119 @@
+package integration
+
+import (
+	"errors"
+	"gojwt-rest-api/migrations"
+	"regexp"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAuditIndexes(t *testing.T) {
+	statistics := regexp.QuoteMeta("FROM information_schema.statistics")
+	collation := regexp.QuoteMeta("SELECT collation_name FROM information_schema.columns")
+	indexRows := func(indexes ...string) *sqlmock.Rows {
+		rows := sqlmock.NewRows([]string{"index_name", "columns"})
+		for i := 0; i < len(indexes); i += 2 {
+			rows.AddRow(indexes[i], indexes[i+1])
+		}
+		return rows
+	}
+
+	t.Run("No warnings when all indexes exist", func(t *testing.T) {
+		db, mock, cleanup := setupMockDB(t)
+		defer cleanup()
+
+		mock.ExpectQuery(statistics).WithArgs("refresh_tokens").
+			WillReturnRows(indexRows("PRIMARY", "id", "uni_refresh_tokens_token", "token", "idx_refresh_tokens_user_revoked", "user_id,is_revoked"))
+		mock.ExpectQuery(statistics).WithArgs("refresh_tokens").
+			WillReturnRows(indexRows("PRIMARY", "id", "uni_refresh_tokens_token", "token", "idx_refresh_tokens_user_revoked", "user_id,is_revoked"))
+		mock.ExpectQuery(statistics).WithArgs("token_blacklist").
+			WillReturnRows(indexRows("idx_token_blacklist_token_expires", "token,expires_at"))
+		mock.ExpectQuery(statistics).WithArgs("users").
+			WillReturnRows(indexRows("email", "email"))
+		mock.ExpectQuery(collation).WithArgs("users", "email").
+			WillReturnRows(sqlmock.NewRows([]string{"collation_name"}).AddRow("utf8mb4_0900_ai_ci"))
+
+		warnings, err := migrations.AuditIndexes(db)
+
+		require.NoError(t, err)
+		assert.Empty(t, warnings)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Warns about missing indexes and case-sensitive collations", func(t *testing.T) {
+		db, mock, cleanup := setupMockDB(t)
+		defer cleanup()
+
+		mock.ExpectQuery(statistics).WithArgs("refresh_tokens").
+			WillReturnRows(indexRows("uni_refresh_tokens_token", "token", "idx_refresh_tokens_user_id", "user_id"))
+		mock.ExpectQuery(statistics).WithArgs("refresh_tokens").
+			WillReturnRows(indexRows("uni_refresh_tokens_token", "token", "idx_refresh_tokens_user_id", "user_id"))
+		mock.ExpectQuery(statistics).WithArgs("token_blacklist").
+			WillReturnRows(indexRows("idx_token_blacklist_token_expires", "token,expires_at"))
+		mock.ExpectQuery(statistics).WithArgs("users").
+			WillReturnRows(indexRows("email", "email"))
+		mock.ExpectQuery(collation).WithArgs("users", "email").
+			WillReturnRows(sqlmock.NewRows([]string{"collation_name"}).AddRow("utf8mb4_bin"))
+
+		warnings, err := migrations.AuditIndexes(db)
+
+		require.NoError(t, err)
+		assert.Equal(t, []string{
+			"missing index on refresh_tokens(user_id,is_revoked)",
+			"users.email uses case-sensitive collation utf8mb4_bin",
+		}, warnings)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestEnsureCaseInsensitiveCollations(t *testing.T) {
+	column := regexp.QuoteMeta("FROM information_schema.columns c JOIN information_schema.tables t")
+	columnRows := func(collation, tableCollation string) *sqlmock.Rows {
+		return sqlmock.NewRows([]string{"column_type", "is_nullable", "collation_name", "table_collation"}).
+			AddRow("varchar(512)", "NO", collation, tableCollation)
+	}
+
+	t.Run("Leaves case-insensitive columns alone", func(t *testing.T) {
+		db, mock, cleanup := setupMockDB(t)
+		defer cleanup()
+
+		mock.ExpectQuery(column).WithArgs("users", "email").
+			WillReturnRows(columnRows("utf8mb4_0900_ai_ci", "utf8mb4_0900_ai_ci"))
+
+		require.NoError(t, migrations.EnsureCaseInsensitiveCollations(db))
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Converts case-sensitive columns to the table collation", func(t *testing.T) {
+		db, mock, cleanup := setupMockDB(t)
+		defer cleanup()
+
+		mock.ExpectQuery(column).WithArgs("users", "email").
+			WillReturnRows(columnRows("utf8mb4_bin", "utf8mb4_0900_ai_ci"))
+		mock.ExpectExec(regexp.QuoteMeta("ALTER TABLE `users` MODIFY `email` varchar(512) CHARACTER SET utf8mb4 COLLATE utf8mb4_0900_ai_ci NOT NULL")).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+
+		require.NoError(t, migrations.EnsureCaseInsensitiveCollations(db))
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Falls back to the default collation for case-sensitive tables", func(t *testing.T) {
+		db, mock, cleanup := setupMockDB(t)
+		defer cleanup()
+
+		mock.ExpectQuery(column).WithArgs("users", "email").
+			WillReturnRows(columnRows("utf8mb4_bin", "utf8mb4_bin"))
+		mock.ExpectExec(regexp.QuoteMeta("COLLATE utf8mb4_unicode_ci NOT NULL")).
+			WillReturnError(errors.New("Error 1062: Duplicate entry 'john@example.com' for key 'users.email'"))
+
+		err := migrations.EnsureCaseInsensitiveCollations(db)
+
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "merge values differing only in case first")
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
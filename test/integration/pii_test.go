@@ -0,0 +1,172 @@
+package integration
+
+import (
+	"encoding/base64"
+	"gojwt-rest-api/internal/domain"
+	"gojwt-rest-api/internal/pii"
+	"gojwt-rest-api/internal/repository"
+	"gojwt-rest-api/migrations"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestKeyring(t *testing.T, id string, b byte, previous ...string) *pii.Keyring {
+	key := id + ":" + base64.StdEncoding.EncodeToString([]byte(strings.Repeat(string(b), 32)))
+	keyring, err := pii.NewKeyring(key, previous, base64.StdEncoding.EncodeToString([]byte(strings.Repeat("i", 32))))
+	require.NoError(t, err)
+	return keyring
+}
+
+func TestUserRepository_EncryptedEmail(t *testing.T) {
+	keyring := newTestKeyring(t, "v1", 'a')
+	pii.Use(keyring)
+	defer pii.Use(nil)
+
+	t.Run("FindByEmail looks up the blind index and decrypts the email", func(t *testing.T) {
+		db, mock, cleanup := setupMockDB(t)
+		defer cleanup()
+
+		repo := repository.NewUserRepository(db)
+
+		encrypted, err := keyring.Encrypt("john@example.com")
+		require.NoError(t, err)
+		now := time.Now()
+		rows := sqlmock.NewRows([]string{"id", "name", "email", "password", "is_admin", "created_at", "updated_at"}).
+			AddRow(1, "John Doe", encrypted, "hashedpassword", false, now, now)
+
+		mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `users` WHERE (email_hash = ? OR (email_hash IS NULL AND email = ?)) ORDER BY `users`.`id` LIMIT ?")).
+			WithArgs(keyring.BlindIndex("john@example.com"), "John@Example.com", 1).
+			WillReturnRows(rows)
+
+		user, err := repo.FindByEmail("John@Example.com")
+
+		require.NoError(t, err)
+		assert.Equal(t, "john@example.com", user.Email)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("FindByEmail finds users written before encryption was enabled", func(t *testing.T) {
+		db, mock, cleanup := setupMockDB(t)
+		defer cleanup()
+
+		repo := repository.NewUserRepository(db)
+
+		now := time.Now()
+		rows := sqlmock.NewRows([]string{"id", "name", "email", "email_hash", "password", "is_admin", "created_at", "updated_at"}).
+			AddRow(2, "Jane Doe", "jane@example.com", nil, "hashedpassword", false, now, now)
+		mock.ExpectQuery(regexp.QuoteMeta("(email_hash = ? OR (email_hash IS NULL AND email = ?))")).
+			WithArgs(keyring.BlindIndex("jane@example.com"), "jane@example.com", 1).
+			WillReturnRows(rows)
+
+		user, err := repo.FindByEmail("jane@example.com")
+
+		require.NoError(t, err)
+		assert.Equal(t, uint(2), user.ID)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Encrypted emails are neither sorted nor partially matched", func(t *testing.T) {
+		db, _, cleanup := setupMockDB(t)
+		defer cleanup()
+
+		repo := repository.NewUserRepository(db)
+
+		_, _, err := repo.FindAll(&domain.PaginationQuery{Page: 1, PageSize: 10, Sort: "email"})
+		assert.ErrorIs(t, err, domain.ErrInvalidSort)
+
+		_, _, err = repo.FindByFilter(&domain.UserFilter{Field: "email", Operator: domain.FilterContains, Value: "example"}, 0, 10)
+		assert.ErrorIs(t, err, domain.ErrInvalidFilter)
+	})
+
+	t.Run("ReencryptUserPII rewrites plaintext and old-key emails only", func(t *testing.T) {
+		db, mock, cleanup := setupMockDB(t)
+		defer cleanup()
+
+		old := newTestKeyring(t, "v0", 'o')
+		rotated := newTestKeyring(t, "v1", 'a', "v0:"+base64.StdEncoding.EncodeToString([]byte(strings.Repeat("o", 32))))
+		oldValue, _ := old.Encrypt("jane@example.com")
+		currentValue, _ := rotated.Encrypt("bob@example.com")
+
+		mock.ExpectQuery(regexp.QuoteMeta("SELECT id,email FROM `users` WHERE id > ? ORDER BY id LIMIT ?")).
+			WithArgs(0, 500).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "email"}).
+				AddRow(1, "john@example.com").
+				AddRow(2, oldValue).
+				AddRow(3, currentValue))
+		for _, email := range []string{"john@example.com", "jane@example.com"} {
+			mock.ExpectBegin()
+			mock.ExpectExec(regexp.QuoteMeta("UPDATE `users` SET `email`=?,`email_hash`=? WHERE id = ?")).
+				WithArgs(sqlmock.AnyArg(), rotated.BlindIndex(email), sqlmock.AnyArg()).
+				WillReturnResult(sqlmock.NewResult(0, 1))
+			mock.ExpectCommit()
+		}
+		mock.ExpectQuery(regexp.QuoteMeta("SELECT id,email FROM `users` WHERE id > ? ORDER BY id LIMIT ?")).
+			WithArgs(3, 500).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "email"}))
+
+		updated, err := migrations.ReencryptUserPII(db, rotated)
+
+		require.NoError(t, err)
+		assert.Equal(t, 2, updated)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+	t.Run("ReencryptProfileHistory rewrites plaintext changes", func(t *testing.T) {
+		db, mock, cleanup := setupMockDB(t)
+		defer cleanup()
+
+		keyring := newTestKeyring(t, "v1", 'a')
+		currentValue, _ := keyring.Encrypt(`[{"field":"email"}]`)
+
+		mock.ExpectQuery(regexp.QuoteMeta("SELECT id,changes FROM `profile_changes` WHERE id > ? ORDER BY id LIMIT ?")).
+			WithArgs(0, 500).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "changes"}).
+				AddRow(1, `[{"field":"email"}]`).
+				AddRow(2, currentValue))
+		mock.ExpectBegin()
+		mock.ExpectExec(regexp.QuoteMeta("UPDATE `profile_changes` SET `changes`=? WHERE id = ?")).
+			WithArgs(sqlmock.AnyArg(), 1).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+		mock.ExpectQuery(regexp.QuoteMeta("SELECT id,changes FROM `profile_changes` WHERE id > ? ORDER BY id LIMIT ?")).
+			WithArgs(2, 500).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "changes"}))
+
+		updated, err := migrations.ReencryptProfileHistory(db, keyring)
+
+		require.NoError(t, err)
+		assert.Equal(t, 1, updated)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+	t.Run("ReencryptAbuseReports rewrites old-key contact emails", func(t *testing.T) {
+		db, mock, cleanup := setupMockDB(t)
+		defer cleanup()
+
+		previous := newTestKeyring(t, "v1", 'a')
+		oldValue, _ := previous.Encrypt("reporter@example.com")
+		keyring := newTestKeyring(t, "v2", 'b', "v1:"+base64.StdEncoding.EncodeToString([]byte(strings.Repeat("a", 32))))
+
+		mock.ExpectQuery(regexp.QuoteMeta("SELECT id,contact_email FROM `abuse_reports` WHERE id > ? ORDER BY id LIMIT ?")).
+			WithArgs(0, 500).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "contact_email"}).AddRow(3, oldValue))
+		mock.ExpectBegin()
+		mock.ExpectExec(regexp.QuoteMeta("UPDATE `abuse_reports` SET `contact_email`=? WHERE id = ?")).
+			WithArgs(sqlmock.AnyArg(), 3).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+		mock.ExpectQuery(regexp.QuoteMeta("SELECT id,contact_email FROM `abuse_reports` WHERE id > ? ORDER BY id LIMIT ?")).
+			WithArgs(3, 500).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "contact_email"}))
+
+		updated, err := migrations.ReencryptAbuseReports(db, keyring)
+
+		require.NoError(t, err)
+		assert.Equal(t, 1, updated)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
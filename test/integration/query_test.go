@@ -0,0 +1,114 @@
+package integration
+
+import (
+	"gojwt-rest-api/internal/domain"
+	"gojwt-rest-api/internal/repository"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUserRepository_Sort(t *testing.T) {
+	t.Run("Sorts by whitelisted columns with the ID as tie-breaker", func(t *testing.T) {
+		db, mock, cleanup := setupMockDB(t)
+		defer cleanup()
+		repo := repository.NewUserRepository(db)
+
+		mock.ExpectQuery(regexp.QuoteMeta("SELECT count(*) FROM `users`")).
+			WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+		mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `users` ORDER BY `created_at` DESC,`name`,`id` LIMIT ?")).
+			WithArgs(10).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "name", "created_at"}).AddRow(1, "John", time.Now()))
+
+		users, _, err := repo.FindAll(&domain.PaginationQuery{Page: 1, PageSize: 10, Sort: "-created_at,name"})
+
+		require.NoError(t, err)
+		assert.Len(t, users, 1)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Rejects injection attempts before querying", func(t *testing.T) {
+		attempts := []string{
+			"name; DROP TABLE users",
+			"name desc",
+			"(SELECT password FROM users LIMIT 1)",
+			"id--",
+			"-id,`password`",
+			"password",
+			"name,name",
+			"IF(1=1,name,email)",
+		}
+		for _, sort := range attempts {
+			db, mock, cleanup := setupMockDB(t)
+			repo := repository.NewUserRepository(db)
+
+			_, _, err := repo.FindAll(&domain.PaginationQuery{Page: 1, PageSize: 10, Sort: sort})
+
+			assert.ErrorIs(t, err, domain.ErrInvalidSort, sort)
+			assert.NoError(t, mock.ExpectationsWereMet(), "no query runs for %q", sort)
+			cleanup()
+		}
+	})
+}
+
+func TestUserRepository_FilterInjection(t *testing.T) {
+	t.Run("Values are bound as parameters", func(t *testing.T) {
+		db, mock, cleanup := setupMockDB(t)
+		defer cleanup()
+		repo := repository.NewUserRepository(db)
+
+		payload := "x' OR '1'='1"
+		mock.ExpectQuery(regexp.QuoteMeta("SELECT count(*) FROM `users` WHERE `name` = ?")).
+			WithArgs(payload).
+			WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+		mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `users` WHERE `name` = ? ORDER BY id LIMIT ?")).
+			WithArgs(payload, 10).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}))
+
+		_, _, err := repo.FindByFilter(&domain.UserFilter{Field: "name", Operator: domain.FilterEqual, Value: payload}, 0, 10)
+
+		require.NoError(t, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("LIKE wildcards in values are escaped", func(t *testing.T) {
+		db, mock, cleanup := setupMockDB(t)
+		defer cleanup()
+		repo := repository.NewUserRepository(db)
+
+		mock.ExpectQuery(regexp.QuoteMeta("SELECT count(*) FROM `users` WHERE `name` LIKE ?")).
+			WithArgs(`%50\%\_off%`).
+			WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+		mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `users` WHERE `name` LIKE ? ORDER BY id LIMIT ?")).
+			WithArgs(`%50\%\_off%`, 10).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}))
+
+		_, _, err := repo.FindByFilter(&domain.UserFilter{Field: "name", Operator: domain.FilterContains, Value: "50%_off"}, 0, 10)
+
+		require.NoError(t, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Rejects unknown fields and operators before querying", func(t *testing.T) {
+		filters := []*domain.UserFilter{
+			{Field: "name = name OR 1=1 --", Operator: domain.FilterEqual, Value: "x"},
+			{Field: "password", Operator: domain.FilterStartsWith, Value: "$2a$"},
+			{Field: "name", Operator: "= 'x' OR 1=1 --", Value: "x"},
+			{Field: "name", Operator: "gt", Value: "x"},
+		}
+		for _, filter := range filters {
+			db, mock, cleanup := setupMockDB(t)
+			repo := repository.NewUserRepository(db)
+
+			_, _, err := repo.FindByFilter(filter, 0, 10)
+
+			assert.ErrorIs(t, err, domain.ErrInvalidFilter, "%+v", filter)
+			assert.NoError(t, mock.ExpectationsWereMet())
+			cleanup()
+		}
+	})
+}
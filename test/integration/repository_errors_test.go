@@ -0,0 +1,155 @@
+package integration
+
+import (
+	"database/sql"
+	"gojwt-rest-api/internal/domain"
+	"gojwt-rest-api/internal/repository"
+	"regexp"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	sqldriver "github.com/go-sql-driver/mysql"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
+)
+
+// setupTranslatingMockDB is setupMockDB with gorm translating driver errors
+// into its own, as other dialectors do
+func setupTranslatingMockDB(t *testing.T) (*gorm.DB, sqlmock.Sqlmock, func()) {
+	sqlDB, mock, err := sqlmock.New()
+	require.NoError(t, err)
+
+	gormDB, err := gorm.Open(mysql.New(mysql.Config{
+		Conn:                      sqlDB,
+		SkipInitializeWithVersion: true,
+	}), &gorm.Config{TranslateError: true})
+	require.NoError(t, err)
+
+	return gormDB, mock, func() { sqlDB.Close() }
+}
+
+// errorDrivers open mock databases returning driver errors either raw or
+// translated by gorm
+var errorDrivers = []struct {
+	name  string
+	setup func(*testing.T) (*gorm.DB, sqlmock.Sqlmock, func())
+}{
+	{"mysql", setupMockDB},
+	{"translated", setupTranslatingMockDB},
+}
+
+func TestRepositoryErrors_NotFound(t *testing.T) {
+	lookups := []struct {
+		name     string
+		table    string
+		find     func(db *gorm.DB) error
+		expected error
+	}{
+		{"user", "users", func(db *gorm.DB) error {
+			_, err := repository.NewUserRepository(db).FindByID(1)
+			return err
+		}, domain.ErrUserNotFound},
+		{"organization", "organizations", func(db *gorm.DB) error {
+			_, err := repository.NewOrganizationRepository(db).FindByID(1)
+			return err
+		}, domain.ErrOrganizationNotFound},
+		{"plan", "plans", func(db *gorm.DB) error {
+			_, err := repository.NewOrganizationRepository(db).FindPlanByName("pro")
+			return err
+		}, domain.ErrPlanNotFound},
+		{"api key", "api_keys", func(db *gorm.DB) error {
+			_, err := repository.NewAPIKeyRepository(db).FindByID(1)
+			return err
+		}, domain.ErrAPIKeyNotFound},
+		{"sso connection", "sso_connections", func(db *gorm.DB) error {
+			_, err := repository.NewSSOConnectionRepository(db).FindByName("okta")
+			return err
+		}, domain.ErrSSOConnectionNotFound},
+	}
+
+	for _, driver := range errorDrivers {
+		for _, lookup := range lookups {
+			t.Run(driver.name+"/"+lookup.name, func(t *testing.T) {
+				db, mock, cleanup := driver.setup(t)
+				defer cleanup()
+
+				mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `" + lookup.table + "`")).
+					WillReturnRows(sqlmock.NewRows([]string{"id"}))
+
+				err := lookup.find(db)
+
+				assert.ErrorIs(t, err, lookup.expected)
+			})
+		}
+	}
+
+	t.Run("Other errors are returned as is", func(t *testing.T) {
+		db, mock, cleanup := setupMockDB(t)
+		defer cleanup()
+
+		mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `users`")).
+			WillReturnError(sql.ErrConnDone)
+
+		_, err := repository.NewUserRepository(db).FindByID(1)
+
+		assert.ErrorIs(t, err, sql.ErrConnDone)
+		assert.NotErrorIs(t, err, domain.ErrUserNotFound)
+	})
+}
+
+func TestRepositoryErrors_Conflict(t *testing.T) {
+	inserts := []struct {
+		name     string
+		table    string
+		create   func(db *gorm.DB) error
+		expected error
+	}{
+		{"user", "users", func(db *gorm.DB) error {
+			return repository.NewUserRepository(db).Create(&domain.User{Name: "John Doe", Email: "john@example.com"})
+		}, domain.ErrUserAlreadyExists},
+		{"organization", "organizations", func(db *gorm.DB) error {
+			return repository.NewOrganizationRepository(db).Create(&domain.Organization{Name: "acme", PlanID: 1})
+		}, domain.ErrOrganizationAlreadyExists},
+		{"plan", "plans", func(db *gorm.DB) error {
+			return repository.NewOrganizationRepository(db).CreatePlan(&domain.Plan{Name: "pro"})
+		}, domain.ErrPlanAlreadyExists},
+	}
+	duplicate := &sqldriver.MySQLError{Number: 1062, Message: "Duplicate entry"}
+
+	for _, driver := range errorDrivers {
+		for _, insert := range inserts {
+			t.Run(driver.name+"/"+insert.name, func(t *testing.T) {
+				db, mock, cleanup := driver.setup(t)
+				defer cleanup()
+
+				mock.ExpectBegin()
+				mock.ExpectExec(regexp.QuoteMeta("INSERT INTO `" + insert.table + "`")).
+					WillReturnError(duplicate)
+				mock.ExpectRollback()
+
+				err := insert.create(db)
+
+				assert.ErrorIs(t, err, insert.expected)
+				assert.NoError(t, mock.ExpectationsWereMet())
+			})
+		}
+	}
+
+	t.Run("Other constraint violations are returned as is", func(t *testing.T) {
+		db, mock, cleanup := setupMockDB(t)
+		defer cleanup()
+
+		foreignKey := &sqldriver.MySQLError{Number: 1452, Message: "Cannot add or update a child row"}
+		mock.ExpectBegin()
+		mock.ExpectExec(regexp.QuoteMeta("INSERT INTO `organizations`")).
+			WillReturnError(foreignKey)
+		mock.ExpectRollback()
+
+		err := repository.NewOrganizationRepository(db).Create(&domain.Organization{Name: "acme", PlanID: 99})
+
+		assert.ErrorIs(t, err, foreignKey)
+		assert.NotErrorIs(t, err, domain.ErrOrganizationAlreadyExists)
+	})
+}
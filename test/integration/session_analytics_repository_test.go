@@ -0,0 +1,90 @@
+package integration
+
+import (
+	"gojwt-rest-api/internal/domain"
+	"gojwt-rest-api/internal/repository"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSessionAnalyticsRepository(t *testing.T) {
+	from := time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)
+	buckets := &domain.AnalyticsBuckets{From: from, Interval: time.Hour, Count: 3}
+
+	t.Run("Counts the sessions valid at each bucket start in one query", func(t *testing.T) {
+		db, mock, cleanup := setupMockDB(t)
+		defer cleanup()
+
+		mock.ExpectQuery(regexp.QuoteMeta("AS end_bucket, COUNT(*) AS count FROM `refresh_tokens` WHERE created_at <= ? AND CASE WHEN is_revoked THEN LEAST(COALESCE(revoked_at, created_at), expires_at) ELSE expires_at END > ? GROUP BY first_bucket, end_bucket")).
+			WithArgs(from, int64(time.Hour/time.Microsecond), from, int64(time.Hour/time.Microsecond), 3, from.Add(2*time.Hour), from).
+			WillReturnRows(sqlmock.NewRows([]string{"first_bucket", "end_bucket", "count"}).
+				AddRow(0, 3, 5). // valid over the whole range
+				AddRow(1, 2, 2). // valid at the second bucket start only
+				AddRow(2, 2, 9)) // issued and gone between two bucket starts
+
+		counts, err := repository.NewSessionAnalyticsRepository(db).CountActiveSessions(buckets)
+
+		require.NoError(t, err)
+		assert.Equal(t, []int64{5, 7, 5}, counts)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Counts rotations by bucket, filling empty buckets", func(t *testing.T) {
+		db, mock, cleanup := setupMockDB(t)
+		defer cleanup()
+
+		mock.ExpectQuery(regexp.QuoteMeta("SELECT FLOOR(TIMESTAMPDIFF(SECOND, ?, revoked_at) / ?) AS bucket, COUNT(*) AS count FROM `refresh_tokens` WHERE replaced_by IS NOT NULL AND (revoked_at >= ? AND revoked_at < ?) GROUP BY `bucket`")).
+			WithArgs(from, 3600, from, from.Add(3*time.Hour)).
+			WillReturnRows(sqlmock.NewRows([]string{"bucket", "count"}).AddRow(0, 10).AddRow(2, 4))
+
+		counts, err := repository.NewSessionAnalyticsRepository(db).CountRefreshes(buckets)
+
+		require.NoError(t, err)
+		assert.Equal(t, []int64{10, 0, 4}, counts)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Counts created rows of token tables only", func(t *testing.T) {
+		db, mock, cleanup := setupMockDB(t)
+		defer cleanup()
+		repo := repository.NewSessionAnalyticsRepository(db)
+
+		mock.ExpectQuery(regexp.QuoteMeta("FROM `token_blacklist` WHERE created_at >= ? AND created_at < ? GROUP BY `bucket`")).
+			WillReturnRows(sqlmock.NewRows([]string{"bucket", "count"}).AddRow(1, 7))
+
+		counts, err := repo.CountCreated(domain.TokenTableBlacklist, buckets)
+		require.NoError(t, err)
+		assert.Equal(t, []int64{0, 7, 0}, counts)
+
+		_, err = repo.CountCreated("users", buckets)
+		assert.Error(t, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Reports table rows and size", func(t *testing.T) {
+		db, mock, cleanup := setupMockDB(t)
+		defer cleanup()
+
+		mock.ExpectQuery(regexp.QuoteMeta("SELECT count(*) FROM `refresh_tokens`")).
+			WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1000))
+		mock.ExpectQuery(regexp.QuoteMeta("FROM information_schema.tables WHERE table_schema = DATABASE() AND table_name = ?")).
+			WithArgs(domain.TokenTableRefreshTokens).
+			WillReturnRows(sqlmock.NewRows([]string{"data_bytes", "index_bytes"}).AddRow(1<<20, 1<<19))
+
+		stats, err := repository.NewSessionAnalyticsRepository(db).TableStats(domain.TokenTableRefreshTokens)
+
+		require.NoError(t, err)
+		assert.Equal(t, &domain.TokenTableStats{
+			Table:      domain.TokenTableRefreshTokens,
+			Rows:       1000,
+			DataBytes:  1 << 20,
+			IndexBytes: 1 << 19,
+		}, stats)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
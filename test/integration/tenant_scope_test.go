@@ -0,0 +1,287 @@
+package integration
+
+import (
+	"context"
+	"gojwt-rest-api/internal/domain"
+	"gojwt-rest-api/internal/handler"
+	"gojwt-rest-api/internal/middleware"
+	"gojwt-rest-api/internal/repository"
+	"gojwt-rest-api/internal/service"
+	"gojwt-rest-api/internal/tenant"
+	"gojwt-rest-api/internal/utils"
+	"gojwt-rest-api/pkg/validator"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+func setupTenantDB(t *testing.T, orgID uint) (*gorm.DB, sqlmock.Sqlmock, func()) {
+	db, mock, cleanup := setupMockDB(t)
+	require.NoError(t, db.Use(tenant.Plugin{}))
+	return db.WithContext(tenant.WithOrganization(context.Background(), orgID)), mock, cleanup
+}
+
+func TestTenantScope_Query(t *testing.T) {
+	t.Run("Queries are filtered by organization", func(t *testing.T) {
+		db, mock, cleanup := setupTenantDB(t, 1)
+		defer cleanup()
+
+		mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `users` WHERE `users`.`organization_id` = ?")).
+			WithArgs(1).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}))
+
+		var users []domain.User
+		require.NoError(t, db.Find(&users).Error)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Explicit organization condition cannot select another tenant", func(t *testing.T) {
+		db, mock, cleanup := setupTenantDB(t, 1)
+		defer cleanup()
+
+		mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `users` WHERE organization_id = ? AND `users`.`organization_id` = ?")).
+			WithArgs(2, 1).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}))
+
+		var users []domain.User
+		require.NoError(t, db.Where("organization_id = ?", 2).Find(&users).Error)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("OR conditions are grouped inside the scope", func(t *testing.T) {
+		db, mock, cleanup := setupTenantDB(t, 1)
+		defer cleanup()
+
+		mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `users` WHERE (email = ? OR 1 = 1) AND `users`.`organization_id` = ?")).
+			WithArgs("john@example.com", 1).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}))
+
+		var users []domain.User
+		require.NoError(t, db.Where("email = ?", "john@example.com").Or("1 = 1").Find(&users).Error)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Unscoped does not lift the tenant scope", func(t *testing.T) {
+		db, mock, cleanup := setupTenantDB(t, 1)
+		defer cleanup()
+
+		mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `users` WHERE `users`.`id` = ? AND `users`.`organization_id` = ? ORDER BY `users`.`id` LIMIT ?")).
+			WithArgs(5, 1, 1).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}))
+
+		var user domain.User
+		err := db.Unscoped().First(&user, 5).Error
+		assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Users without organization only see unassigned records", func(t *testing.T) {
+		db, mock, cleanup := setupTenantDB(t, 0)
+		defer cleanup()
+
+		mock.ExpectQuery(regexp.QuoteMeta("SELECT count(*) FROM `users` WHERE `users`.`organization_id` IS NULL")).
+			WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+
+		var count int64
+		require.NoError(t, db.Model(&domain.User{}).Count(&count).Error)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Statements without tenant context are not scoped", func(t *testing.T) {
+		db, mock, cleanup := setupMockDB(t)
+		defer cleanup()
+		require.NoError(t, db.Use(tenant.Plugin{}))
+
+		mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `users`")).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}))
+
+		var users []domain.User
+		require.NoError(t, db.Find(&users).Error)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Models without organization column are not scoped", func(t *testing.T) {
+		db, mock, cleanup := setupTenantDB(t, 1)
+		defer cleanup()
+
+		mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `organizations`")).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}))
+
+		var orgs []domain.Organization
+		require.NoError(t, db.Find(&orgs).Error)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestTenantScope_Write(t *testing.T) {
+	t.Run("Delete by primary key is scoped", func(t *testing.T) {
+		db, mock, cleanup := setupTenantDB(t, 1)
+		defer cleanup()
+
+		mock.ExpectBegin()
+		mock.ExpectExec(regexp.QuoteMeta("DELETE FROM `users` WHERE `users`.`organization_id` = ? AND `users`.`id` = ?")).
+			WithArgs(1, 7).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectCommit()
+
+		result := db.Delete(&domain.User{ID: 7})
+		require.NoError(t, result.Error)
+		assert.Equal(t, int64(0), result.RowsAffected)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Update with OR condition is scoped", func(t *testing.T) {
+		db, mock, cleanup := setupTenantDB(t, 1)
+		defer cleanup()
+
+		mock.ExpectBegin()
+		mock.ExpectExec(regexp.QuoteMeta("UPDATE `users` SET `is_admin`=?,`updated_at`=? WHERE (id = ? OR 1 = 1) AND `users`.`organization_id` = ?")).
+			WithArgs(true, sqlmock.AnyArg(), 7, 1).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+
+		err := db.Model(&domain.User{}).Where("id = ?", 7).Or("1 = 1").Update("is_admin", true).Error
+		require.NoError(t, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Global update is still rejected", func(t *testing.T) {
+		db, mock, cleanup := setupTenantDB(t, 1)
+		defer cleanup()
+
+		mock.ExpectBegin()
+		mock.ExpectRollback()
+
+		err := db.Model(&domain.User{}).Update("is_admin", true).Error
+		assert.ErrorIs(t, err, gorm.ErrMissingWhereClause)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Create assigns the tenant organization", func(t *testing.T) {
+		db, mock, cleanup := setupTenantDB(t, 1)
+		defer cleanup()
+
+		mock.ExpectBegin()
+		mock.ExpectExec(regexp.QuoteMeta("INSERT INTO `audit_logs`")).
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectCommit()
+
+		log := &domain.AuditLog{Action: domain.AuditSSOUserProvisioned}
+		require.NoError(t, db.Create(log).Error)
+		require.NotNil(t, log.OrganizationID)
+		assert.Equal(t, uint(1), *log.OrganizationID)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Create into another organization is rejected", func(t *testing.T) {
+		db, mock, cleanup := setupTenantDB(t, 1)
+		defer cleanup()
+
+		other := uint(2)
+		user := &domain.User{Name: "Eve", Email: "eve@example.com", OrganizationID: &other}
+
+		mock.ExpectBegin()
+		mock.ExpectRollback()
+
+		err := db.Create(user).Error
+		assert.ErrorIs(t, err, tenant.ErrCrossTenantWrite)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestTenantScope_HTTP(t *testing.T) {
+	const secret = "tenant-scope-secret"
+	gin.SetMode(gin.TestMode)
+
+	// setup routes GET /users/:id and GET /users/:id/history like the API: an
+	// organization admin of organization 1 reads users through the tenant
+	// middleware
+	setup := func(t *testing.T) (*gin.Engine, sqlmock.Sqlmock, string, func()) {
+		db, mock, cleanup := setupMockDB(t)
+		require.NoError(t, db.Use(tenant.Plugin{}))
+		userService := service.NewUserService(repository.NewUserRepository(db), nil, secret, time.Minute, time.Hour)
+
+		v, err := validator.New()
+		require.NoError(t, err)
+		router := gin.New()
+		router.GET("/users/:id",
+			middleware.AuthMiddleware(secret),
+			middleware.TenantMiddleware(userService),
+			handler.NewUserHandler(userService, v).GetUserByID,
+		)
+		router.GET("/users/:id/history",
+			middleware.AuthMiddleware(secret),
+			middleware.TenantMiddleware(userService),
+			handler.NewProfileHistoryHandler(service.NewProfileHistoryService(repository.NewProfileHistoryRepository(db)), userService).GetHistory,
+		)
+
+		token, err := utils.GenerateToken(1, "admin@example.com", secret, time.Minute)
+		require.NoError(t, err)
+
+		// The tenant middleware loads the actor without scope
+		mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `users` WHERE `users`.`id` = ? ORDER BY `users`.`id` LIMIT ?")).
+			WithArgs(1, 1).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "email", "organization_id"}).AddRow(1, "admin@example.com", 1))
+		return router, mock, token, cleanup
+	}
+
+	get := func(router *gin.Engine, token, path string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("Users of another organization are not found", func(t *testing.T) {
+		router, mock, token, cleanup := setup(t)
+		defer cleanup()
+
+		mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `users` WHERE `users`.`id` = ? AND `users`.`organization_id` = ? ORDER BY `users`.`id` LIMIT ?")).
+			WithArgs(2, 1, 1).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}))
+
+		w := get(router, token, "/users/2")
+
+		assert.Equal(t, http.StatusNotFound, w.Code)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Users of the organization are returned", func(t *testing.T) {
+		router, mock, token, cleanup := setup(t)
+		defer cleanup()
+
+		mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `users` WHERE `users`.`id` = ? AND `users`.`organization_id` = ? ORDER BY `users`.`id` LIMIT ?")).
+			WithArgs(3, 1, 1).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "email", "organization_id"}).AddRow(3, "member@example.com", 1))
+
+		w := get(router, token, "/users/3")
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Contains(t, w.Body.String(), "member@example.com")
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Profile history of users of another organization is not found", func(t *testing.T) {
+		router, mock, token, cleanup := setup(t)
+		defer cleanup()
+
+		mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `users` WHERE `users`.`id` = ? AND `users`.`organization_id` = ? ORDER BY `users`.`id` LIMIT ?")).
+			WithArgs(2, 1, 1).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}))
+
+		w := get(router, token, "/users/2/history")
+
+		assert.Equal(t, http.StatusNotFound, w.Code)
+		assert.NotContains(t, w.Body.String(), "@")
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
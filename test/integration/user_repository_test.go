@@ -9,6 +9,7 @@ import (
 	"time"
 
 	"github.com/DATA-DOG/go-sqlmock"
+	sqldriver "github.com/go-sql-driver/mysql"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"gorm.io/driver/mysql"
@@ -51,10 +52,29 @@ func TestUserRepository_Create(t *testing.T) {
 		mock.ExpectBegin()
 		mock.ExpectExec(regexp.QuoteMeta("INSERT INTO `users`")).
 			WithArgs(
-				sqlmock.AnyArg(), // name
+				"John Doe",       // name
+				"John",           // first_name
+				"Doe",            // last_name
+				"",               // display_name
 				sqlmock.AnyArg(), // email
 				sqlmock.AnyArg(), // password
 				sqlmock.AnyArg(), // is_admin
+				sqlmock.AnyArg(), // first_login_at
+				sqlmock.AnyArg(), // organization_id
+				sqlmock.AnyArg(), // organization_role
+				sqlmock.AnyArg(), // admin_scopes
+				sqlmock.AnyArg(), // email_hash
+				sqlmock.AnyArg(), // last_login_at
+				sqlmock.AnyArg(), // inactivity_exempt
+				sqlmock.AnyArg(), // inactivity_warned_at
+				sqlmock.AnyArg(), // anonymized_at
+				sqlmock.AnyArg(), // deactivated_at
+				sqlmock.AnyArg(), // timezone
+				sqlmock.AnyArg(), // logout_other_sessions_on_login
+				sqlmock.AnyArg(), // locked_at
+				sqlmock.AnyArg(), // lock_reason
+				sqlmock.AnyArg(), // session_epoch
+				sqlmock.AnyArg(), // perm_version
 				sqlmock.AnyArg(), // created_at
 				sqlmock.AnyArg(), // updated_at
 			).
@@ -89,6 +109,29 @@ func TestUserRepository_Create(t *testing.T) {
 		assert.Error(t, err)
 		assert.NoError(t, mock.ExpectationsWereMet())
 	})
+
+	t.Run("Create with duplicate email", func(t *testing.T) {
+		db, mock, cleanup := setupMockDB(t)
+		defer cleanup()
+
+		repo := repository.NewUserRepository(db)
+
+		user := &domain.User{
+			Name:     "John Doe",
+			Email:    "john@example.com",
+			Password: "hashedpassword",
+		}
+
+		mock.ExpectBegin()
+		mock.ExpectExec(regexp.QuoteMeta("INSERT INTO `users`")).
+			WillReturnError(&sqldriver.MySQLError{Number: 1062, Message: "Duplicate entry 'john@example.com' for key 'users.idx_users_email'"})
+		mock.ExpectRollback()
+
+		err := repo.Create(user)
+
+		assert.ErrorIs(t, err, domain.ErrUserAlreadyExists)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
 }
 
 func TestUserRepository_FindByID(t *testing.T) {
@@ -302,9 +345,28 @@ func TestUserRepository_Update(t *testing.T) {
 		mock.ExpectExec(regexp.QuoteMeta("UPDATE `users`")).
 			WithArgs(
 				sqlmock.AnyArg(), // name
+				sqlmock.AnyArg(), // first_name
+				sqlmock.AnyArg(), // last_name
+				sqlmock.AnyArg(), // display_name
 				sqlmock.AnyArg(), // email
 				sqlmock.AnyArg(), // password
 				sqlmock.AnyArg(), // is_admin
+				sqlmock.AnyArg(), // first_login_at
+				sqlmock.AnyArg(), // organization_id
+				sqlmock.AnyArg(), // organization_role
+				sqlmock.AnyArg(), // admin_scopes
+				sqlmock.AnyArg(), // email_hash
+				sqlmock.AnyArg(), // last_login_at
+				sqlmock.AnyArg(), // inactivity_exempt
+				sqlmock.AnyArg(), // inactivity_warned_at
+				sqlmock.AnyArg(), // anonymized_at
+				sqlmock.AnyArg(), // deactivated_at
+				sqlmock.AnyArg(), // timezone
+				sqlmock.AnyArg(), // logout_other_sessions_on_login
+				sqlmock.AnyArg(), // locked_at
+				sqlmock.AnyArg(), // lock_reason
+				sqlmock.AnyArg(), // session_epoch
+				sqlmock.AnyArg(), // perm_version
 				sqlmock.AnyArg(), // created_at
 				sqlmock.AnyArg(), // updated_at
 				sqlmock.AnyArg(), // id
@@ -398,3 +460,226 @@ func TestUserRepository_Delete(t *testing.T) {
 		assert.NoError(t, mock.ExpectationsWereMet())
 	})
 }
+
+func TestUserRepository_DeleteWithCleanup(t *testing.T) {
+	deletion := func() *domain.UserDeletion {
+		actorID := uint(2)
+		return &domain.UserDeletion{
+			UserID:                   1,
+			AccessTokensRevokedUntil: time.Now().Add(15 * time.Minute),
+			Audit:                    &domain.AuditLog{Action: domain.AuditUserDeleted, ActorID: &actorID},
+		}
+	}
+
+	expectCleanup := func(mock sqlmock.Sqlmock) {
+		mock.ExpectExec(regexp.QuoteMeta("DELETE FROM `refresh_tokens` WHERE user_id = ?")).
+			WithArgs(1).
+			WillReturnResult(sqlmock.NewResult(0, 2))
+		mock.ExpectExec(regexp.QuoteMeta("INSERT INTO `token_blacklist`")).
+			WithArgs("user:1", sqlmock.AnyArg(), sqlmock.AnyArg()).
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectExec(regexp.QuoteMeta("DELETE FROM `api_key_usage` WHERE api_key_id IN (SELECT `id` FROM `api_keys` WHERE user_id = ?)")).
+			WithArgs(1).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectExec(regexp.QuoteMeta("DELETE FROM `api_keys` WHERE user_id = ?")).
+			WithArgs(1).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectExec(regexp.QuoteMeta("DELETE FROM `user_two_factors` WHERE user_id = ?")).
+			WithArgs(1).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectExec(regexp.QuoteMeta("UPDATE `audit_logs` SET `user_id`=? WHERE user_id = ?")).
+			WithArgs(nil, 1).
+			WillReturnResult(sqlmock.NewResult(0, 3))
+		mock.ExpectExec(regexp.QuoteMeta("UPDATE `audit_logs` SET `actor_id`=? WHERE actor_id = ?")).
+			WithArgs(nil, 1).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectExec(regexp.QuoteMeta("DELETE FROM `profile_changes` WHERE user_id = ?")).
+			WithArgs(1).
+			WillReturnResult(sqlmock.NewResult(0, 2))
+		mock.ExpectExec(regexp.QuoteMeta("UPDATE `profile_changes` SET `actor_id`=? WHERE actor_id = ?")).
+			WithArgs(nil, 1).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+	}
+
+	t.Run("Deletes the user with their tokens and credentials in a transaction", func(t *testing.T) {
+		db, mock, cleanup := setupMockDB(t)
+		defer cleanup()
+
+		repo := repository.NewUserRepository(db)
+
+		mock.ExpectBegin()
+		expectCleanup(mock)
+		mock.ExpectExec(regexp.QuoteMeta("DELETE FROM `users` WHERE `users`.`id` = ?")).
+			WithArgs(1).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec(regexp.QuoteMeta("INSERT INTO `audit_logs`")).
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectCommit()
+
+		err := repo.DeleteWithCleanup(deletion())
+
+		require.NoError(t, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Rolls back the cleanup when the user does not exist", func(t *testing.T) {
+		db, mock, cleanup := setupMockDB(t)
+		defer cleanup()
+
+		repo := repository.NewUserRepository(db)
+
+		mock.ExpectBegin()
+		expectCleanup(mock)
+		mock.ExpectExec(regexp.QuoteMeta("DELETE FROM `users` WHERE `users`.`id` = ?")).
+			WithArgs(1).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectRollback()
+
+		err := repo.DeleteWithCleanup(deletion())
+
+		assert.ErrorIs(t, err, domain.ErrUserNotFound)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestUserRepository_Suggest(t *testing.T) {
+	t.Run("Matches name or email prefix", func(t *testing.T) {
+		db, mock, cleanup := setupMockDB(t)
+		defer cleanup()
+
+		repo := repository.NewUserRepository(db)
+
+		rows := sqlmock.NewRows([]string{"id", "name", "email"}).
+			AddRow(1, "John Doe", "john@example.com")
+		mock.ExpectQuery(regexp.QuoteMeta("SELECT `id`,`name`,`email` FROM `users` WHERE name LIKE ? OR email LIKE ? ORDER BY name LIMIT ?")).
+			WithArgs("jo%", "jo%", 10).
+			WillReturnRows(rows)
+
+		suggestions, err := repo.Suggest("jo", 10)
+
+		require.NoError(t, err)
+		require.Len(t, suggestions, 1)
+		assert.Equal(t, "john@example.com", suggestions[0].Email)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Escapes LIKE wildcards", func(t *testing.T) {
+		db, mock, cleanup := setupMockDB(t)
+		defer cleanup()
+
+		repo := repository.NewUserRepository(db)
+
+		mock.ExpectQuery(regexp.QuoteMeta("SELECT `id`,`name`,`email` FROM `users`")).
+			WithArgs(`50\%\_%`, `50\%\_%`, 10).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "name", "email"}))
+
+		suggestions, err := repo.Suggest("50%_", 10)
+
+		require.NoError(t, err)
+		assert.Empty(t, suggestions)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestUserRepository_FindAllFullText(t *testing.T) {
+	t.Run("Searches with the full-text index", func(t *testing.T) {
+		db, mock, cleanup := setupMockDB(t)
+		defer cleanup()
+
+		repo := repository.NewUserRepository(db, repository.WithFullTextSearch())
+
+		pagination := &domain.PaginationQuery{Page: 1, PageSize: 10, Search: "john@example"}
+		now := time.Now()
+
+		mock.ExpectQuery(regexp.QuoteMeta("SELECT count(*) FROM `users` WHERE MATCH(name, email) AGAINST (? IN BOOLEAN MODE)")).
+			WithArgs("+john* +example*").
+			WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+		mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `users` WHERE MATCH(name, email) AGAINST (? IN BOOLEAN MODE) LIMIT ?")).
+			WithArgs("+john* +example*", 10).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "name", "email", "password", "is_admin", "created_at", "updated_at"}).
+				AddRow(1, "John Doe", "john@example.com", "hash1", false, now, now))
+
+		users, total, err := repo.FindAll(pagination)
+
+		require.NoError(t, err)
+		assert.Len(t, users, 1)
+		assert.Equal(t, int64(1), total)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Falls back to LIKE for words too short to be indexed", func(t *testing.T) {
+		db, mock, cleanup := setupMockDB(t)
+		defer cleanup()
+
+		repo := repository.NewUserRepository(db, repository.WithFullTextSearch())
+
+		pagination := &domain.PaginationQuery{Page: 1, PageSize: 10, Search: "jo"}
+
+		mock.ExpectQuery(regexp.QuoteMeta("SELECT count(*) FROM `users` WHERE name LIKE ? OR email LIKE ?")).
+			WithArgs("%jo%", "%jo%").
+			WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+		mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `users` WHERE name LIKE ? OR email LIKE ? LIMIT ?")).
+			WithArgs("%jo%", "%jo%", 10).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}))
+
+		_, total, err := repo.FindAll(pagination)
+
+		require.NoError(t, err)
+		assert.Zero(t, total)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestUserRepository_FindInactive(t *testing.T) {
+	t.Run("Suspension requires an earlier warning", func(t *testing.T) {
+		db, mock, cleanup := setupMockDB(t)
+		defer cleanup()
+
+		repo := repository.NewUserRepository(db)
+
+		lastActive := time.Now().Add(-60 * 24 * time.Hour)
+		warned := time.Now().Add(-30 * 24 * time.Hour)
+		mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `users` WHERE (is_admin = ? AND inactivity_exempt = ? AND anonymized_at IS NULL) AND COALESCE(last_login_at, first_login_at, created_at) < ? AND deactivated_at IS NULL AND inactivity_warned_at < ? ORDER BY COALESCE(last_login_at, first_login_at, created_at) LIMIT ?")).
+			WithArgs(false, false, lastActive, warned, 100).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "name", "email"}).AddRow(2, "Idle", "idle@example.com"))
+
+		users, err := repo.FindInactive(&domain.InactiveUserQuery{
+			Stage:            domain.InactivityStageSuspend,
+			LastActiveBefore: lastActive,
+			WarnedBefore:     &warned,
+			Limit:            100,
+		})
+
+		require.NoError(t, err)
+		require.Len(t, users, 1)
+		assert.Equal(t, uint(2), users[0].ID)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Rejects unknown stages", func(t *testing.T) {
+		db, _, cleanup := setupMockDB(t)
+		defer cleanup()
+
+		repo := repository.NewUserRepository(db)
+
+		_, err := repo.FindInactive(&domain.InactiveUserQuery{Stage: "delete"})
+		assert.Error(t, err)
+	})
+}
+
+func TestUserRepository_RecordLogin(t *testing.T) {
+	db, mock, cleanup := setupMockDB(t)
+	defer cleanup()
+
+	repo := repository.NewUserRepository(db)
+
+	now := time.Now()
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta("UPDATE `users` SET `inactivity_warned_at`=?,`last_login_at`=? WHERE id = ?")).
+		WithArgs(nil, now, 1).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	require.NoError(t, repo.RecordLogin(1, now))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
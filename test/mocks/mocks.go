@@ -0,0 +1,17 @@
+// Package mocks holds testify mocks of the service and repository interfaces,
+// generated by mockery from .mockery.yaml. Regenerate them with `make mocks`
+// after changing one of the interfaces; do not edit the generated files.
+package mocks
+
+import (
+	"gojwt-rest-api/internal/repository"
+	"gojwt-rest-api/internal/service"
+)
+
+//go:generate go run github.com/vektra/mockery/v2@v2.53.3 --config .mockery.yaml
+
+// Fail the build, not a downstream test, when a mock falls behind its interface.
+var (
+	_ service.UserService        = (*UserService)(nil)
+	_ repository.TokenRepository = (*TokenRepository)(nil)
+)
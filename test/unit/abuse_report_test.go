@@ -0,0 +1,71 @@
+package unit
+
+import (
+	"gojwt-rest-api/internal/domain"
+	"gojwt-rest-api/internal/events"
+	"gojwt-rest-api/internal/service"
+	"gojwt-rest-api/test/helpers"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAbuseReportService_Submit(t *testing.T) {
+	repo := new(helpers.MockAbuseReportRepository)
+	repo.On("Create", mock.Anything).Run(func(args mock.Arguments) {
+		args.Get(0).(*domain.AbuseReport).ID = 7
+	}).Return(nil)
+	publisher := new(helpers.MockEventPublisher)
+	publisher.On("Publish", mock.Anything, mock.Anything).Return()
+	reportService := service.NewAbuseReportService(repo, service.NewAuditService(new(helpers.MockAuditLogRepository)),
+		service.WithAbuseReportEventPublisher(publisher))
+	reportedID := uint(42)
+
+	report, err := reportService.Submit(&domain.SubmitAbuseReportRequest{
+		Category:       domain.AbuseReportCategoryAbuse,
+		ReportedUserID: &reportedID,
+		ContactEmail:   " reporter@example.com ",
+		Message:        "  Harassment in comments  ",
+	}, "203.0.113.7")
+
+	require.NoError(t, err)
+	assert.Equal(t, domain.AbuseReportStatusOpen, report.Status)
+	assert.Equal(t, "reporter@example.com", report.ContactEmail)
+	assert.Equal(t, "Harassment in comments", report.Message)
+	assert.Equal(t, "203.0.113.7", report.ReporterIP)
+
+	t.Run("Announces the report without its content", func(t *testing.T) {
+		publisher.AssertCalled(t, "Publish", events.AbuseReportSubmitted, mock.MatchedBy(func(data *events.AbuseReportSubmittedData) bool {
+			return data.ReportID == 7 && data.Category == domain.AbuseReportCategoryAbuse && *data.ReportedUserID == reportedID
+		}))
+	})
+}
+
+func TestAbuseReportService_Triage(t *testing.T) {
+	reportedID, adminID := uint(42), uint(1)
+	repo := new(helpers.MockAbuseReportRepository)
+	repo.On("FindByID", uint(7)).Return(&domain.AbuseReport{ID: 7, ReportedUserID: &reportedID, Status: domain.AbuseReportStatusOpen}, nil)
+	repo.On("Update", mock.Anything).Return(nil)
+	auditRepo := new(helpers.MockAuditLogRepository)
+	auditRepo.On("Create", mock.Anything).Return(nil)
+	reportService := service.NewAbuseReportService(repo, service.NewAuditService(auditRepo))
+
+	report, err := reportService.Triage(adminID, 7, &domain.TriageAbuseReportRequest{
+		Status: domain.AbuseReportStatusDismissed,
+		Note:   " Not a violation ",
+	})
+
+	require.NoError(t, err)
+	assert.Equal(t, domain.AbuseReportStatusDismissed, report.Status)
+	assert.Equal(t, "Not a violation", report.TriageNote)
+	assert.Equal(t, adminID, *report.TriagedBy)
+
+	t.Run("Audits the change against the reported user", func(t *testing.T) {
+		auditRepo.AssertCalled(t, "Create", mock.MatchedBy(func(log *domain.AuditLog) bool {
+			return log.Action == domain.AuditAbuseReportTriaged && *log.ActorID == adminID && *log.UserID == reportedID &&
+				log.Detail == `{"previous_status":"open","report_id":7,"status":"dismissed"}`
+		}))
+	})
+}
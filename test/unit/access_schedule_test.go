@@ -0,0 +1,222 @@
+package unit
+
+import (
+	"gojwt-rest-api/internal/domain"
+	"gojwt-rest-api/internal/service"
+	"gojwt-rest-api/test/factory"
+	"gojwt-rest-api/test/helpers"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseAccessSchedule(t *testing.T) {
+	t.Run("Accepts day ranges, lists and windows up to midnight", func(t *testing.T) {
+		for _, spec := range []string{"mon-fri 08:00-20:00", "sat+sun 10:00-14:00", "fri-mon 00:00-24:00", "wed 22:00-06:00"} {
+			schedule, err := domain.ParseAccessSchedule(spec)
+			require.NoError(t, err, spec)
+			assert.Equal(t, spec, schedule.String())
+		}
+	})
+
+	t.Run("Normalizes case and spacing", func(t *testing.T) {
+		schedule, err := domain.ParseAccessSchedule("  Mon-Fri   08:00-20:00 ")
+		require.NoError(t, err)
+		assert.Equal(t, "mon-fri 08:00-20:00", schedule.String())
+	})
+
+	t.Run("Accepts a timezone, UTC by default", func(t *testing.T) {
+		schedule, err := domain.ParseAccessSchedule("Mon-Fri 08:00-20:00 Asia/Jakarta")
+		require.NoError(t, err)
+		assert.Equal(t, "mon-fri 08:00-20:00 Asia/Jakarta", schedule.String())
+		assert.Equal(t, "Asia/Jakarta", schedule.Location().String())
+
+		schedule, err = domain.ParseAccessSchedule("mon-fri 08:00-20:00")
+		require.NoError(t, err)
+		assert.Equal(t, time.UTC, schedule.Location())
+	})
+
+	t.Run("Rejects malformed schedules", func(t *testing.T) {
+		for _, spec := range []string{"", "mon-fri", "weekdays 08:00-20:00", "mon-fri 8-20", "mon-fri 08:00-25:00", "mon 09:00-09:00", "mon 24:00-06:00", "mon-fri 08:00 20:00", "mon-fri 08:00-20:00 Mars/Olympus", "mon-fri 08:00-20:00 Local"} {
+			_, err := domain.ParseAccessSchedule(spec)
+			assert.ErrorIs(t, err, domain.ErrInvalidAccessSchedule, spec)
+		}
+	})
+}
+
+func TestAccessSchedule_Allows(t *testing.T) {
+	// 2026-10-12 is a Monday
+	monday := func(hour, minute int, loc *time.Location) time.Time {
+		return time.Date(2026, time.October, 12, hour, minute, 0, 0, loc)
+	}
+	require.Equal(t, time.Monday, monday(0, 0, time.UTC).Weekday())
+
+	t.Run("Allows the window on the scheduled days only", func(t *testing.T) {
+		schedule, err := domain.ParseAccessSchedule("mon-fri 08:00-20:00")
+		require.NoError(t, err)
+
+		assert.True(t, schedule.Allows(monday(8, 0, time.UTC)))
+		assert.True(t, schedule.Allows(monday(19, 59, time.UTC)))
+		assert.False(t, schedule.Allows(monday(7, 59, time.UTC)))
+		assert.False(t, schedule.Allows(monday(20, 0, time.UTC)))
+		assert.False(t, schedule.Allows(monday(12, 0, time.UTC).AddDate(0, 0, 5)), "saturday")
+	})
+
+	t.Run("Overnight windows continue into the next day", func(t *testing.T) {
+		schedule, err := domain.ParseAccessSchedule("sun 22:00-06:00")
+		require.NoError(t, err)
+
+		assert.True(t, schedule.Allows(monday(5, 59, time.UTC)))
+		assert.False(t, schedule.Allows(monday(6, 0, time.UTC)))
+		assert.False(t, schedule.Allows(monday(23, 0, time.UTC)))
+		assert.True(t, schedule.Allows(monday(23, 0, time.UTC).AddDate(0, 0, -1)))
+	})
+
+	t.Run("Evaluates in the timezone of the schedule", func(t *testing.T) {
+		jakarta, err := time.LoadLocation("Asia/Jakarta")
+		require.NoError(t, err)
+		utc, err := domain.ParseAccessSchedule("mon-fri 08:00-20:00")
+		require.NoError(t, err)
+		local, err := domain.ParseAccessSchedule("mon-fri 08:00-20:00 Asia/Jakarta")
+		require.NoError(t, err)
+
+		// 03:00 UTC is 10:00 in Jakarta, whatever the location of the time
+		at := monday(3, 0, time.UTC)
+		assert.False(t, utc.Allows(at))
+		assert.False(t, utc.Allows(at.In(jakarta)))
+		assert.True(t, local.Allows(at))
+	})
+}
+
+// accessScheduleFixture is a logged-in user on in-memory repositories,
+// recording audit logs
+type accessScheduleFixture struct {
+	userRepo  *helpers.MemoryUserRepository
+	tokenRepo *helpers.MemoryTokenRepository
+	auditRepo *helpers.MockAuditLogRepository
+	user      *domain.User
+}
+
+func newAccessScheduleFixture(t *testing.T) *accessScheduleFixture {
+	t.Helper()
+	f := &accessScheduleFixture{
+		userRepo:  helpers.NewMemoryUserRepository(),
+		tokenRepo: helpers.NewMemoryTokenRepository(),
+		auditRepo: new(helpers.MockAuditLogRepository),
+		user:      factory.New().User(factory.WithEmail("contractor@example.com")),
+	}
+	require.NoError(t, f.userRepo.Create(f.user))
+	f.auditRepo.On("Create", mock.AnythingOfType("*domain.AuditLog")).Return(nil)
+	return f
+}
+
+// userService returns a user service enforcing the role schedules
+func (f *accessScheduleFixture) userService(t *testing.T, roles map[string]string) service.UserService {
+	t.Helper()
+	schedules := make(map[string]*domain.AccessSchedule, len(roles))
+	for role, spec := range roles {
+		schedule, err := domain.ParseAccessSchedule(spec)
+		require.NoError(t, err)
+		schedules[role] = schedule
+	}
+	accessSchedules := service.NewAccessScheduleService(schedules, nil, service.NewAuditService(f.auditRepo))
+	return service.NewUserService(f.userRepo, f.tokenRepo, factory.DefaultSecret, time.Minute, time.Hour,
+		service.WithAccessSchedule(accessSchedules),
+	)
+}
+
+// deniedLogs returns the recorded access schedule denials
+func (f *accessScheduleFixture) deniedLogs() []*domain.AuditLog {
+	var logs []*domain.AuditLog
+	for _, call := range f.auditRepo.Calls {
+		if log := call.Arguments.Get(0).(*domain.AuditLog); log.Action == domain.AuditAccessScheduleDenied {
+			logs = append(logs, log)
+		}
+	}
+	return logs
+}
+
+func TestUserService_AccessSchedule(t *testing.T) {
+	// Schedules allowing and refusing access at the current time, whatever it is
+	const anyTime = "mon-sun 00:00-24:00"
+	tomorrow := strings.ToLower(time.Now().UTC().AddDate(0, 0, 1).Weekday().String()[:3])
+	tomorrowOnly := tomorrow + " 00:00-24:00"
+
+	t.Run("Login outside the schedule is refused and audited", func(t *testing.T) {
+		f := newAccessScheduleFixture(t)
+		userService := f.userService(t, map[string]string{"user": tomorrowOnly})
+
+		_, err := userService.Login(&domain.LoginRequest{Email: f.user.Email, Password: factory.DefaultPassword})
+
+		assert.Equal(t, domain.ErrOutsideAccessSchedule, err)
+		logs := f.deniedLogs()
+		require.Len(t, logs, 1)
+		assert.Equal(t, f.user.ID, *logs[0].UserID)
+		assert.Contains(t, logs[0].Detail, `"action":"login"`)
+		assert.Contains(t, logs[0].Detail, tomorrowOnly)
+	})
+
+	t.Run("Login within every schedule succeeds", func(t *testing.T) {
+		f := newAccessScheduleFixture(t)
+		userService := f.userService(t, map[string]string{"user": anyTime, "admin": tomorrowOnly})
+
+		response, err := userService.Login(&domain.LoginRequest{Email: f.user.Email, Password: factory.DefaultPassword})
+
+		require.NoError(t, err)
+		assert.NotEmpty(t, response.RefreshToken)
+		assert.Empty(t, f.deniedLogs())
+	})
+
+	t.Run("Refresh outside the schedule is refused", func(t *testing.T) {
+		f := newAccessScheduleFixture(t)
+		login, err := f.userService(t, nil).Login(&domain.LoginRequest{Email: f.user.Email, Password: factory.DefaultPassword})
+		require.NoError(t, err)
+
+		_, err = f.userService(t, map[string]string{"user": tomorrowOnly}).
+			RefreshToken(&domain.RefreshTokenRequest{RefreshToken: login.RefreshToken})
+
+		assert.Equal(t, domain.ErrOutsideAccessSchedule, err)
+		logs := f.deniedLogs()
+		require.Len(t, logs, 1)
+		assert.Contains(t, logs[0].Detail, `"action":"refresh"`)
+	})
+
+	t.Run("Changing the profile timezone does not move the schedule", func(t *testing.T) {
+		// At any time, one of these timezones is on another day than UTC
+		today := strings.ToLower(time.Now().UTC().Weekday().String()[:3]) + " 00:00-24:00"
+		for _, timezone := range []string{"Pacific/Kiritimati", "Etc/GMT+12"} {
+			f := newAccessScheduleFixture(t)
+			f.user.Timezone = timezone
+			require.NoError(t, f.userRepo.Update(f.user))
+
+			_, err := f.userService(t, map[string]string{"user": today}).
+				Login(&domain.LoginRequest{Email: f.user.Email, Password: factory.DefaultPassword})
+			assert.NoError(t, err, timezone)
+
+			_, err = f.userService(t, map[string]string{"user": tomorrowOnly}).
+				Login(&domain.LoginRequest{Email: f.user.Email, Password: factory.DefaultPassword})
+			assert.Equal(t, domain.ErrOutsideAccessSchedule, err, timezone)
+		}
+	})
+
+	t.Run("Organization schedules apply to their members", func(t *testing.T) {
+		orgID := uint(3)
+		f := newAccessScheduleFixture(t)
+		f.user.OrganizationID = &orgID
+		f.user.OrganizationRole = domain.OrgRoleMember
+		orgRepo := new(helpers.MockOrganizationRepository)
+		orgRepo.On("FindSettings", orgID).Return(&domain.OrganizationSettings{OrganizationID: orgID, AccessSchedule: tomorrowOnly}, nil)
+		settings := service.NewSettingsService(orgRepo, testDefaultSettings, time.Minute)
+		accessSchedules := service.NewAccessScheduleService(nil, settings, service.NewAuditService(f.auditRepo))
+
+		err := accessSchedules.Check(f.user, service.AccessActionLogin)
+
+		assert.Equal(t, domain.ErrOutsideAccessSchedule, err)
+		require.Len(t, f.deniedLogs(), 1)
+		assert.Equal(t, orgID, *f.deniedLogs()[0].OrganizationID)
+	})
+}
@@ -0,0 +1,223 @@
+package unit
+
+import (
+	"gojwt-rest-api/internal/domain"
+	"gojwt-rest-api/internal/events"
+	"gojwt-rest-api/internal/service"
+	"gojwt-rest-api/internal/utils"
+	"gojwt-rest-api/test/factory"
+	"gojwt-rest-api/test/helpers"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+const newPassword = "n3w-passw0rd"
+
+// accountLockFixture is a user service locking accounts, on in-memory
+// repositories with a logged-in user
+type accountLockFixture struct {
+	userService  service.UserService
+	accountLock  service.AccountLockService
+	userRepo     *helpers.MemoryUserRepository
+	locationRepo *helpers.MockLoginLocationRepository
+	publisher    *helpers.MockEventPublisher
+	userCache    *helpers.MockUserCache
+	user         *domain.User
+	login        *domain.LoginResponse
+}
+
+func newAccountLockFixture(t *testing.T, policy service.AccountLockPolicy) *accountLockFixture {
+	t.Helper()
+	f := &accountLockFixture{
+		userRepo:     helpers.NewMemoryUserRepository(),
+		locationRepo: new(helpers.MockLoginLocationRepository),
+		publisher:    new(helpers.MockEventPublisher),
+		userCache:    new(helpers.MockUserCache),
+		user:         factory.New().User(factory.WithEmail("jane@example.com")),
+	}
+	require.NoError(t, f.userRepo.Create(f.user))
+	f.publisher.On("Publish", mock.Anything, mock.Anything).Return()
+	f.userCache.On("Invalidate", mock.Anything).Return(nil)
+	auditRepo := new(helpers.MockAuditLogRepository)
+	auditRepo.On("Create", mock.AnythingOfType("*domain.AuditLog")).Return(nil)
+	tokenRepo := helpers.NewMemoryTokenRepository()
+
+	f.accountLock = service.NewAccountLockService(f.userRepo, tokenRepo, f.locationRepo,
+		service.NewOneTimeTokenService(helpers.NewMemoryOneTimeTokenRepository()), service.NewAuditService(auditRepo), policy,
+		service.WithAccountLockEventPublisher(f.publisher),
+		service.WithAccountLockUserCache(f.userCache),
+	)
+	f.userService = service.NewUserService(f.userRepo, tokenRepo, factory.DefaultSecret, time.Minute, time.Hour,
+		service.WithAccountLock(f.accountLock),
+	)
+	var err error
+	f.login, err = f.userService.Login(&domain.LoginRequest{Email: f.user.Email, Password: factory.DefaultPassword})
+	require.NoError(t, err)
+	return f
+}
+
+// unlockToken returns the token of the last published unlock link
+func (f *accountLockFixture) unlockToken(t *testing.T) string {
+	t.Helper()
+	var token string
+	for _, call := range f.publisher.Calls {
+		if data, ok := call.Arguments.Get(1).(*events.UserLockedData); ok {
+			token = data.UnlockToken
+		}
+	}
+	require.NotEmpty(t, token, "no unlock link published")
+	return token
+}
+
+// accessRevoked reports whether the access token of the fixture's login was revoked
+func (f *accountLockFixture) accessRevoked(t *testing.T) bool {
+	t.Helper()
+	claims, err := utils.ValidateToken(f.login.AccessToken, factory.DefaultSecret)
+	require.NoError(t, err)
+	revoked, err := f.userService.SessionRevoked(claims.UserID, claims.SessionEpoch)
+	require.NoError(t, err)
+	return revoked
+}
+
+// reuseRefreshToken rotates the fixture's refresh token and presents the old one again
+func (f *accountLockFixture) reuseRefreshToken(t *testing.T) {
+	t.Helper()
+	_, err := f.userService.RefreshToken(&domain.RefreshTokenRequest{RefreshToken: f.login.RefreshToken})
+	require.NoError(t, err)
+	_, err = f.userService.RefreshToken(&domain.RefreshTokenRequest{RefreshToken: f.login.RefreshToken})
+	require.Equal(t, domain.ErrTokenReused, err)
+}
+
+func TestAccountLock_TokenReuse(t *testing.T) {
+	t.Run("Locks the account and emails an unlock link", func(t *testing.T) {
+		f := newAccountLockFixture(t, service.AccountLockPolicy{LockOnTokenReuse: true, UnlockTokenTTL: time.Hour})
+
+		f.reuseRefreshToken(t)
+
+		user, err := f.userRepo.FindByID(f.user.ID)
+		require.NoError(t, err)
+		assert.True(t, user.IsLocked())
+		assert.Equal(t, domain.LockReasonTokenReuse, user.LockReason)
+		assert.True(t, f.accessRevoked(t), "outstanding access tokens must be rejected")
+		f.publisher.AssertCalled(t, "Publish", events.UserLocked, mock.AnythingOfType("*events.UserLockedData"))
+
+		_, err = f.userService.Login(&domain.LoginRequest{Email: f.user.Email, Password: factory.DefaultPassword})
+		assert.Equal(t, domain.ErrAccountLocked, err)
+	})
+
+	t.Run("Only revokes the family when disabled", func(t *testing.T) {
+		f := newAccountLockFixture(t, service.AccountLockPolicy{})
+
+		f.reuseRefreshToken(t)
+
+		_, err := f.userService.Login(&domain.LoginRequest{Email: f.user.Email, Password: factory.DefaultPassword})
+		assert.NoError(t, err)
+		assert.False(t, f.accessRevoked(t))
+		f.publisher.AssertNotCalled(t, "Publish", events.UserLocked, mock.Anything)
+	})
+}
+
+func TestAccountLock_ManyCountries(t *testing.T) {
+	policy := service.AccountLockPolicy{MaxCountries: 2, CountryWindow: 24 * time.Hour, UnlockTokenTTL: time.Hour}
+
+	t.Run("Locks on a login from one country too many", func(t *testing.T) {
+		f := newAccountLockFixture(t, policy)
+		f.locationRepo.On("DeleteBefore", f.user.ID, mock.AnythingOfType("time.Time")).Return(nil)
+		f.locationRepo.On("Create", mock.MatchedBy(func(l *domain.LoginLocation) bool { return l.Country == "BR" })).Return(nil)
+		f.locationRepo.On("FindCountriesSince", f.user.ID, mock.AnythingOfType("time.Time")).Return([]string{"ID", "SG", "BR"}, nil)
+
+		_, err := f.userService.Login(&domain.LoginRequest{Email: f.user.Email, Password: factory.DefaultPassword, Country: "br"})
+
+		assert.Equal(t, domain.ErrAccountLocked, err)
+		user, err := f.userRepo.FindByID(f.user.ID)
+		require.NoError(t, err)
+		assert.Equal(t, domain.LockReasonManyCountries, user.LockReason)
+
+		// The session from before the lock is gone
+		_, err = f.userService.RefreshToken(&domain.RefreshTokenRequest{RefreshToken: f.login.RefreshToken})
+		assert.Error(t, err)
+	})
+
+	t.Run("Allows logins within the limit", func(t *testing.T) {
+		f := newAccountLockFixture(t, policy)
+		f.locationRepo.On("DeleteBefore", f.user.ID, mock.AnythingOfType("time.Time")).Return(nil)
+		f.locationRepo.On("Create", mock.AnythingOfType("*domain.LoginLocation")).Return(nil)
+		f.locationRepo.On("FindCountriesSince", f.user.ID, mock.AnythingOfType("time.Time")).Return([]string{"ID", "SG"}, nil)
+
+		_, err := f.userService.Login(&domain.LoginRequest{Email: f.user.Email, Password: factory.DefaultPassword, Country: "SG"})
+
+		assert.NoError(t, err)
+	})
+
+	t.Run("Ignores logins from an unknown country", func(t *testing.T) {
+		f := newAccountLockFixture(t, policy)
+
+		_, err := f.userService.Login(&domain.LoginRequest{Email: f.user.Email, Password: factory.DefaultPassword})
+
+		assert.NoError(t, err)
+		f.locationRepo.AssertNotCalled(t, "Create", mock.Anything)
+	})
+}
+
+func TestAccountLock_Unlock(t *testing.T) {
+	policy := service.AccountLockPolicy{LockOnTokenReuse: true, UnlockTokenTTL: time.Hour}
+
+	t.Run("Replaces the password and unlocks", func(t *testing.T) {
+		f := newAccountLockFixture(t, policy)
+		f.reuseRefreshToken(t)
+		f.locationRepo.On("DeleteBefore", f.user.ID, mock.AnythingOfType("time.Time")).Return(nil)
+
+		user, err := f.accountLock.Unlock(&domain.UnlockAccountRequest{Token: f.unlockToken(t), NewPassword: newPassword})
+
+		require.NoError(t, err)
+		assert.False(t, user.IsLocked())
+		_, err = f.userService.Login(&domain.LoginRequest{Email: f.user.Email, Password: factory.DefaultPassword})
+		assert.Equal(t, domain.ErrInvalidCredentials, err)
+		_, err = f.userService.Login(&domain.LoginRequest{Email: f.user.Email, Password: newPassword})
+		assert.NoError(t, err)
+		f.publisher.AssertCalled(t, "Publish", events.UserUnlocked, mock.AnythingOfType("*events.UserUnlockedData"))
+		// Once on lock, once on unlock
+		f.userCache.AssertNumberOfCalls(t, "Invalidate", 2)
+	})
+
+	t.Run("Requires a new password without spending the token", func(t *testing.T) {
+		f := newAccountLockFixture(t, policy)
+		f.reuseRefreshToken(t)
+		f.locationRepo.On("DeleteBefore", f.user.ID, mock.AnythingOfType("time.Time")).Return(nil)
+		token := f.unlockToken(t)
+
+		_, err := f.accountLock.Unlock(&domain.UnlockAccountRequest{Token: token, NewPassword: factory.DefaultPassword})
+		assert.Equal(t, domain.ErrPasswordUnchanged, err)
+
+		_, err = f.accountLock.Unlock(&domain.UnlockAccountRequest{Token: token, NewPassword: newPassword})
+		require.NoError(t, err)
+
+		_, err = f.accountLock.Unlock(&domain.UnlockAccountRequest{Token: token, NewPassword: "an0ther-passw0rd"})
+		assert.Equal(t, domain.ErrOneTimeTokenInvalid, err)
+	})
+
+	t.Run("Requesting a new link replaces the previous one", func(t *testing.T) {
+		f := newAccountLockFixture(t, policy)
+		f.reuseRefreshToken(t)
+		first := f.unlockToken(t)
+
+		require.NoError(t, f.accountLock.RequestUnlock("jane@example.com"))
+
+		assert.NotEqual(t, first, f.unlockToken(t))
+		f.publisher.AssertCalled(t, "Publish", events.UserUnlockRequested, mock.AnythingOfType("*events.UserLockedData"))
+		_, err := f.accountLock.Unlock(&domain.UnlockAccountRequest{Token: first, NewPassword: newPassword})
+		assert.Equal(t, domain.ErrOneTimeTokenInvalid, err)
+	})
+
+	t.Run("Requests for unknown or unlocked accounts send nothing", func(t *testing.T) {
+		f := newAccountLockFixture(t, policy)
+
+		assert.NoError(t, f.accountLock.RequestUnlock("nobody@example.com"))
+		assert.NoError(t, f.accountLock.RequestUnlock("jane@example.com"))
+		f.publisher.AssertNotCalled(t, "Publish", events.UserUnlockRequested, mock.Anything)
+	})
+}
@@ -0,0 +1,162 @@
+package unit
+
+import (
+	"context"
+	"errors"
+	"gojwt-rest-api/internal/accounts"
+	"gojwt-rest-api/internal/domain"
+	"gojwt-rest-api/internal/utils"
+	"gojwt-rest-api/test/factory"
+	"gojwt-rest-api/test/helpers"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// seedAccounts stores users built by the factory in a new memory repository
+func seedAccounts(t *testing.T, users ...*domain.User) *helpers.MemoryUserRepository {
+	t.Helper()
+	repo := helpers.NewMemoryUserRepository()
+	for _, user := range users {
+		user.ID = 0
+		require.NoError(t, repo.Create(user))
+	}
+	return repo
+}
+
+func TestAccounts_Export(t *testing.T) {
+	f := factory.New()
+	source := seedAccounts(t,
+		f.User(factory.WithEmail("jane@example.com"), factory.WithAdminScopes(domain.ScopeAdminUserRead)),
+		f.User(factory.WithEmail("john@example.com"), factory.Deactivated()),
+		f.User(factory.WithEmail("ann@example.com")),
+	)
+	exporter := accounts.NewExporter(source)
+
+	t.Run("Exports selected accounts with password hashes", func(t *testing.T) {
+		archive, err := exporter.Export(context.Background(), accounts.ExportOptions{Emails: []string{"jane@example.com"}})
+
+		require.NoError(t, err)
+		assert.Equal(t, accounts.FormatVersion, archive.Version)
+		require.Len(t, archive.Accounts, 1)
+		assert.Equal(t, "jane@example.com", archive.Accounts[0].Email)
+		assert.Equal(t, domain.ScopeAdminUserRead, archive.Accounts[0].AdminScopes)
+		assert.NoError(t, utils.CheckPassword(archive.Accounts[0].PasswordHash, factory.DefaultPassword))
+	})
+
+	t.Run("Exports every active account without passwords", func(t *testing.T) {
+		archive, err := exporter.Export(context.Background(), accounts.ExportOptions{All: true, ActiveOnly: true, WithoutPasswords: true})
+
+		require.NoError(t, err)
+		require.Len(t, archive.Accounts, 2)
+		assert.Equal(t, "jane@example.com", archive.Accounts[0].Email)
+		assert.Equal(t, "ann@example.com", archive.Accounts[1].Email)
+		for _, account := range archive.Accounts {
+			assert.Empty(t, account.PasswordHash)
+		}
+	})
+
+	t.Run("Rejects unknown emails and ambiguous selections", func(t *testing.T) {
+		_, err := exporter.Export(context.Background(), accounts.ExportOptions{Emails: []string{"nobody@example.com"}})
+		assert.True(t, errors.Is(err, domain.ErrUserNotFound))
+
+		_, err = exporter.Export(context.Background(), accounts.ExportOptions{})
+		assert.Error(t, err)
+	})
+}
+
+func TestAccounts_Import(t *testing.T) {
+	f := factory.New()
+	newArchive := func() *accounts.Archive {
+		return &accounts.Archive{Version: accounts.FormatVersion, Accounts: []*accounts.Account{
+			{Email: "jane@example.com", Name: "Jane Imported", PasswordHash: factory.HashPassword("imported-password"), IsAdmin: true},
+			{Email: "new@example.com", Name: "New User"},
+		}}
+	}
+	setup := func(t *testing.T) (*helpers.MemoryUserRepository, *helpers.MemoryTokenRepository, *accounts.Importer, *domain.User) {
+		users := seedAccounts(t, f.User(factory.WithEmail("jane@example.com"), factory.WithName("Jane Target")))
+		tokens := helpers.NewMemoryTokenRepository()
+		jane, err := users.FindByEmail("jane@example.com")
+		require.NoError(t, err)
+		require.NoError(t, tokens.CreateRefreshToken(f.RefreshToken(jane)))
+		return users, tokens, accounts.NewImporter(users, tokens), jane
+	}
+
+	t.Run("Skips existing accounts", func(t *testing.T) {
+		users, _, importer, _ := setup(t)
+
+		report, err := importer.Import(context.Background(), newArchive(), accounts.ImportOptions{OnConflict: accounts.ConflictSkip})
+
+		require.NoError(t, err)
+		assert.Equal(t, 1, report.Created)
+		assert.Equal(t, 1, report.Skipped)
+		assert.Equal(t, []string{"jane@example.com"}, report.Conflicts)
+		jane, _ := users.FindByEmail("jane@example.com")
+		assert.Equal(t, "Jane Target", jane.Name)
+
+		// Accounts exported without passwords cannot be logged into
+		created, err := users.FindByEmail("new@example.com")
+		require.NoError(t, err)
+		assert.NotEmpty(t, created.Password)
+		assert.Error(t, utils.CheckPassword(created.Password, ""))
+	})
+
+	t.Run("Overwrites existing accounts and ends their sessions", func(t *testing.T) {
+		users, tokens, importer, jane := setup(t)
+
+		report, err := importer.Import(context.Background(), newArchive(), accounts.ImportOptions{OnConflict: accounts.ConflictOverwrite})
+
+		require.NoError(t, err)
+		assert.Equal(t, 1, report.Created)
+		assert.Equal(t, 1, report.Updated)
+		updated, _ := users.FindByEmail("jane@example.com")
+		assert.Equal(t, jane.ID, updated.ID)
+		assert.Equal(t, "Jane Imported", updated.Name)
+		assert.True(t, updated.IsAdmin)
+		assert.NoError(t, utils.CheckPassword(updated.Password, "imported-password"))
+		assert.Equal(t, jane.SessionEpoch+1, updated.SessionEpoch)
+		active, err := tokens.CountActiveRefreshTokens(jane.ID, time.Now())
+		require.NoError(t, err)
+		assert.Zero(t, active)
+	})
+
+	t.Run("Fails on conflicts without writing", func(t *testing.T) {
+		users, _, importer, _ := setup(t)
+
+		_, err := importer.Import(context.Background(), newArchive(), accounts.ImportOptions{OnConflict: accounts.ConflictFail})
+
+		assert.True(t, errors.Is(err, accounts.ErrConflict))
+		_, err = users.FindByEmail("new@example.com")
+		assert.Equal(t, domain.ErrUserNotFound, err)
+	})
+
+	t.Run("Dry run writes nothing", func(t *testing.T) {
+		users, _, importer, _ := setup(t)
+
+		report, err := importer.Import(context.Background(), newArchive(), accounts.ImportOptions{OnConflict: accounts.ConflictOverwrite, DryRun: true})
+
+		require.NoError(t, err)
+		assert.Equal(t, 1, report.Created)
+		assert.Equal(t, 1, report.Updated)
+		_, err = users.FindByEmail("new@example.com")
+		assert.Equal(t, domain.ErrUserNotFound, err)
+	})
+
+	t.Run("Rejects invalid archives", func(t *testing.T) {
+		_, _, importer, _ := setup(t)
+
+		for name, archive := range map[string]*accounts.Archive{
+			"unknown version":  {Version: 99},
+			"missing email":    {Version: accounts.FormatVersion, Accounts: []*accounts.Account{{Name: "No Email"}}},
+			"duplicate email":  {Version: accounts.FormatVersion, Accounts: []*accounts.Account{{Email: "a@example.com"}, {Email: "A@example.com"}}},
+			"plaintext secret": {Version: accounts.FormatVersion, Accounts: []*accounts.Account{{Email: "a@example.com", PasswordHash: "password123"}}},
+		} {
+			_, err := importer.Import(context.Background(), archive, accounts.ImportOptions{})
+			assert.Error(t, err, name)
+		}
+		_, err := importer.Import(context.Background(), newArchive(), accounts.ImportOptions{OnConflict: "merge"})
+		assert.Error(t, err)
+	})
+}
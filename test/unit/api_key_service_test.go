@@ -0,0 +1,197 @@
+package unit
+
+import (
+	"context"
+	"errors"
+	"gojwt-rest-api/internal/domain"
+	"gojwt-rest-api/internal/events"
+	"gojwt-rest-api/internal/service"
+	"gojwt-rest-api/internal/utils"
+	"gojwt-rest-api/test/helpers"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAPIKeyService_CreateAPIKey(t *testing.T) {
+	mockRepo := new(helpers.MockAPIKeyRepository)
+	apiKeyService := service.NewAPIKeyService(mockRepo, 90*24*time.Hour)
+
+	mockRepo.On("Create", mock.AnythingOfType("*domain.APIKey")).Return(nil)
+
+	key, plaintext, err := apiKeyService.CreateAPIKey(1, &domain.CreateAPIKeyRequest{Name: "ci", ExpiresInDays: 30,
+		Scopes: []string{domain.ScopeProfileRead, domain.ScopeProfileWrite}})
+
+	require.NoError(t, err)
+	assert.Equal(t, utils.HashAPIKey(plaintext), key.KeyHash, "Only the hash should be stored")
+	assert.True(t, len(plaintext) > len(key.Prefix))
+	assert.Equal(t, plaintext[:len(key.Prefix)], key.Prefix)
+	require.NotNil(t, key.ExpiresAt)
+	assert.WithinDuration(t, time.Now().AddDate(0, 0, 30), *key.ExpiresAt, time.Minute)
+	assert.Equal(t, []string{domain.ScopeProfileRead, domain.ScopeProfileWrite}, key.ScopeList())
+	mockRepo.AssertExpectations(t)
+}
+
+func TestAPIKeyService_Authenticate(t *testing.T) {
+	now := time.Now()
+	tests := []struct {
+		name  string
+		owner domain.User
+		valid bool
+	}{
+		{name: "Active owner", owner: domain.User{ID: 1}, valid: true},
+		{name: "Deactivated owner", owner: domain.User{ID: 1, DeactivatedAt: &now}},
+		{name: "Locked owner", owner: domain.User{ID: 1, LockedAt: &now}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockRepo := new(helpers.MockAPIKeyRepository)
+			apiKeyService := service.NewAPIKeyService(mockRepo, 0)
+			mockRepo.On("FindByHash", utils.HashAPIKey("gjk_secret")).
+				Return(&domain.APIKey{ID: 7, UserID: 1, User: tt.owner}, nil)
+
+			key, err := apiKeyService.Authenticate("gjk_secret")
+
+			if tt.valid {
+				require.NoError(t, err)
+				assert.Equal(t, uint(7), key.ID)
+			} else {
+				assert.ErrorIs(t, err, domain.ErrInvalidAPIKey)
+			}
+		})
+	}
+}
+
+func TestAPIKeyService_CheckRotation(t *testing.T) {
+	maxAge := 90 * 24 * time.Hour
+
+	staleKey := func(id uint, notified bool) *domain.APIKey {
+		key := &domain.APIKey{
+			ID:        id,
+			UserID:    7,
+			Name:      "deploy",
+			Prefix:    "gjk_0123abcd",
+			CreatedAt: time.Now().Add(-maxAge - time.Hour),
+			User:      *helpers.CreateTestUser(7, "owner@example.com"),
+		}
+		if notified {
+			at := time.Now().Add(-time.Hour)
+			key.RotationNotifiedAt = &at
+		}
+		return key
+	}
+
+	t.Run("Notifies owners of stale keys once", func(t *testing.T) {
+		mockRepo := new(helpers.MockAPIKeyRepository)
+		mockEvents := new(helpers.MockEventPublisher)
+		apiKeyService := service.NewAPIKeyService(mockRepo, maxAge, service.WithAPIKeyEventPublisher(mockEvents))
+
+		fresh, seen := staleKey(1, false), staleKey(2, true)
+		mockRepo.On("FindActiveCreatedBefore", mock.AnythingOfType("time.Time")).Return([]*domain.APIKey{fresh, seen}, nil)
+		mockRepo.On("Update", fresh).Return(nil)
+		mockEvents.On("Publish", events.APIKeyRotationDue, mock.MatchedBy(func(data *events.APIKeyRotationDueData) bool {
+			return data.APIKeyID == 1 && data.Email == "owner@example.com" && data.ExpiresAt == nil
+		})).Return()
+
+		report, err := apiKeyService.CheckRotation()
+
+		require.NoError(t, err)
+		assert.Equal(t, 2, report.Stale)
+		assert.Equal(t, 1, report.Notified)
+		assert.Equal(t, 0, report.Expiring)
+		assert.NotNil(t, fresh.RotationNotifiedAt)
+		assert.Nil(t, fresh.ExpiresAt)
+		mockRepo.AssertExpectations(t)
+		mockEvents.AssertExpectations(t)
+	})
+
+	t.Run("Auto-expire schedules expiry after grace period", func(t *testing.T) {
+		mockRepo := new(helpers.MockAPIKeyRepository)
+		grace := 14 * 24 * time.Hour
+		apiKeyService := service.NewAPIKeyService(mockRepo, maxAge, service.WithAutoExpire(grace))
+
+		key := staleKey(1, false)
+		mockRepo.On("FindActiveCreatedBefore", mock.AnythingOfType("time.Time")).Return([]*domain.APIKey{key}, nil)
+		mockRepo.On("Update", key).Return(nil)
+
+		report, err := apiKeyService.CheckRotation()
+
+		require.NoError(t, err)
+		assert.Equal(t, 1, report.Expiring)
+		require.NotNil(t, key.ExpiresAt)
+		assert.WithinDuration(t, time.Now().Add(grace), *key.ExpiresAt, time.Minute)
+		assert.Equal(t, domain.APIKeyStatusRotationDue, key.RotationStatus(maxAge, time.Now()))
+		assert.Equal(t, domain.APIKeyStatusExpired, key.RotationStatus(maxAge, key.ExpiresAt.Add(time.Second)))
+	})
+
+	t.Run("Zero max age disables the check", func(t *testing.T) {
+		mockRepo := new(helpers.MockAPIKeyRepository)
+		apiKeyService := service.NewAPIKeyService(mockRepo, 0)
+
+		report, err := apiKeyService.CheckRotation()
+
+		require.NoError(t, err)
+		assert.Equal(t, 0, report.Stale)
+		mockRepo.AssertNotCalled(t, "FindActiveCreatedBefore", mock.Anything)
+	})
+}
+
+func TestAPIKeyService_GetUsage(t *testing.T) {
+	mockRepo := new(helpers.MockAPIKeyRepository)
+	apiKeyService := service.NewAPIKeyService(mockRepo, 90*24*time.Hour)
+
+	lastUsed := time.Now()
+	mockRepo.On("FindByID", uint(3)).Return(&domain.APIKey{ID: 3, Name: "ci", LastUsedAt: &lastUsed, CreatedAt: time.Now()}, nil)
+	mockRepo.On("FindUsage", uint(3)).Return([]*domain.APIKeyUsage{
+		{APIKeyID: 3, Method: "GET", Route: "/api/v1/users", RequestCount: 40},
+		{APIKeyID: 3, Method: "GET", Route: "/api/v1/users/:id", RequestCount: 15},
+		{APIKeyID: 3, Method: "PUT", Route: "/api/v1/profile", RequestCount: 5},
+	}, nil)
+
+	usage, err := apiKeyService.GetUsage(3, 2)
+
+	require.NoError(t, err)
+	assert.Equal(t, int64(60), usage.TotalRequests, "Total should include endpoints beyond the limit")
+	assert.Equal(t, &lastUsed, usage.LastUsedAt)
+	require.Len(t, usage.TopEndpoints, 2)
+	assert.Equal(t, "/api/v1/users", usage.TopEndpoints[0].Route)
+	assert.Equal(t, domain.APIKeyStatusActive, usage.APIKey.Status)
+}
+
+func TestAPIKeyUsageTracker(t *testing.T) {
+	t.Run("Aggregates requests per endpoint before flushing", func(t *testing.T) {
+		mockRepo := new(helpers.MockAPIKeyRepository)
+		tracker := service.NewAPIKeyUsageTracker(mockRepo)
+
+		for i := 0; i < 3; i++ {
+			tracker.Record(1, "GET", "/api/v1/users")
+		}
+		tracker.Record(1, "GET", "/api/v1/profile")
+
+		mockRepo.On("RecordUsage", uint(1), "GET", "/api/v1/users", int64(3), mock.AnythingOfType("time.Time")).Return(nil).Once()
+		mockRepo.On("RecordUsage", uint(1), "GET", "/api/v1/profile", int64(1), mock.AnythingOfType("time.Time")).Return(nil).Once()
+
+		require.NoError(t, tracker.Flush(context.Background()))
+		// Nothing is pending after a successful flush
+		require.NoError(t, tracker.Flush(context.Background()))
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("Failed writes are retried on the next flush", func(t *testing.T) {
+		mockRepo := new(helpers.MockAPIKeyRepository)
+		tracker := service.NewAPIKeyUsageTracker(mockRepo)
+
+		tracker.Record(1, "GET", "/api/v1/users")
+		mockRepo.On("RecordUsage", uint(1), "GET", "/api/v1/users", int64(1), mock.AnythingOfType("time.Time")).Return(errors.New("db down")).Once()
+		require.Error(t, tracker.Flush(context.Background()))
+
+		tracker.Record(1, "GET", "/api/v1/users")
+		mockRepo.On("RecordUsage", uint(1), "GET", "/api/v1/users", int64(2), mock.AnythingOfType("time.Time")).Return(nil).Once()
+		require.NoError(t, tracker.Flush(context.Background()))
+		mockRepo.AssertExpectations(t)
+	})
+}
@@ -0,0 +1,232 @@
+package unit
+
+import (
+	"fmt"
+	"gojwt-rest-api/internal/domain"
+	"gojwt-rest-api/internal/handler"
+	"gojwt-rest-api/internal/middleware"
+	"gojwt-rest-api/internal/service"
+	"gojwt-rest-api/internal/utils"
+	"gojwt-rest-api/test/factory"
+	"gojwt-rest-api/test/helpers"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	authFlowSecret   = "benchmark-secret"
+	authFlowEmail    = "bench@example.com"
+	authFlowPassword = "password123"
+)
+
+// authFlow wires the user service, auth middleware and profile handler to
+// in-memory repositories, so benchmarks measure the service layer rather than
+// a database
+type authFlow struct {
+	service service.UserService
+	router  *gin.Engine
+}
+
+func newAuthFlow(tb testing.TB) *authFlow {
+	tb.Helper()
+	gin.SetMode(gin.TestMode)
+
+	userRepo := helpers.NewMemoryUserRepository()
+	tokenRepo := helpers.NewMemoryTokenRepository()
+
+	// The factory hashes with the minimum cost so bcrypt does not dwarf the rest of the flow
+	user := factory.New().User(factory.WithEmail(authFlowEmail), factory.WithPassword(authFlowPassword))
+	require.NoError(tb, userRepo.Create(user))
+
+	userService := service.NewUserService(userRepo, tokenRepo, authFlowSecret, 15*time.Minute, 7*24*time.Hour,
+		service.WithPasswordLimiter(utils.NewPasswordLimiter(4, time.Second)),
+	)
+
+	router := gin.New()
+	router.GET("/api/v1/profile",
+		middleware.AuthMiddleware(authFlowSecret),
+		middleware.RevocationMiddleware(userService),
+		handler.NewProfileHandler(userService, nil).GetOwnProfile,
+	)
+	return &authFlow{service: userService, router: router}
+}
+
+func (f *authFlow) login() (*domain.LoginResponse, error) {
+	return f.service.Login(&domain.LoginRequest{Email: authFlowEmail, Password: authFlowPassword})
+}
+
+func (f *authFlow) refresh(refreshToken string) (*domain.RefreshTokenResponse, error) {
+	return f.service.RefreshToken(&domain.RefreshTokenRequest{RefreshToken: refreshToken})
+}
+
+func (f *authFlow) validate(accessToken string) error {
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/profile", nil)
+	req.Header.Set("Authorization", "Bearer "+accessToken)
+	w := httptest.NewRecorder()
+	f.router.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		return fmt.Errorf("profile returned %d: %s", w.Code, w.Body.String())
+	}
+	return nil
+}
+
+// run performs login, refresh and validation in sequence
+func (f *authFlow) run() error {
+	login, err := f.login()
+	if err != nil {
+		return err
+	}
+	refreshed, err := f.refresh(login.RefreshToken)
+	if err != nil {
+		return err
+	}
+	return f.validate(refreshed.AccessToken)
+}
+
+func TestAuthFlow_InMemory(t *testing.T) {
+	flow := newAuthFlow(t)
+
+	login, err := flow.login()
+	require.NoError(t, err)
+	refreshed, err := flow.refresh(login.RefreshToken)
+	require.NoError(t, err)
+	require.NoError(t, flow.validate(refreshed.AccessToken))
+
+	// The rotated refresh token is revoked, presenting it again is reuse
+	_, err = flow.refresh(login.RefreshToken)
+	assert.Equal(t, domain.ErrTokenReused, err)
+	_, err = flow.refresh(refreshed.RefreshToken)
+	assert.Equal(t, domain.ErrTokenReused, err)
+}
+
+func BenchmarkAuthFlow_Login(b *testing.B) {
+	flow := newAuthFlow(b)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := flow.login(); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkAuthFlow_LoginParallel(b *testing.B) {
+	flow := newAuthFlow(b)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			if _, err := flow.login(); err != nil {
+				b.Error(err)
+				return
+			}
+		}
+	})
+}
+
+func BenchmarkAuthFlow_Refresh(b *testing.B) {
+	flow := newAuthFlow(b)
+	login, err := flow.login()
+	require.NoError(b, err)
+	refreshToken := login.RefreshToken
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		refreshed, err := flow.refresh(refreshToken)
+		if err != nil {
+			b.Fatal(err)
+		}
+		refreshToken = refreshed.RefreshToken
+	}
+}
+
+func BenchmarkAuthFlow_RefreshParallel(b *testing.B) {
+	flow := newAuthFlow(b)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		// Each goroutine rotates its own session
+		login, err := flow.login()
+		if err != nil {
+			b.Error(err)
+			return
+		}
+		refreshToken := login.RefreshToken
+		for pb.Next() {
+			refreshed, err := flow.refresh(refreshToken)
+			if err != nil {
+				b.Error(err)
+				return
+			}
+			refreshToken = refreshed.RefreshToken
+		}
+	})
+}
+
+func BenchmarkAuthFlow_Validate(b *testing.B) {
+	flow := newAuthFlow(b)
+	login, err := flow.login()
+	require.NoError(b, err)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if err := flow.validate(login.AccessToken); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkAuthFlow_ValidateParallel(b *testing.B) {
+	flow := newAuthFlow(b)
+	login, err := flow.login()
+	require.NoError(b, err)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			if err := flow.validate(login.AccessToken); err != nil {
+				b.Error(err)
+				return
+			}
+		}
+	})
+}
+
+func BenchmarkAuthFlow_LoginRefreshValidate(b *testing.B) {
+	flow := newAuthFlow(b)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if err := flow.run(); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkAuthFlow_LoginRefreshValidateParallel(b *testing.B) {
+	flow := newAuthFlow(b)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			if err := flow.run(); err != nil {
+				b.Error(err)
+				return
+			}
+		}
+	})
+}
@@ -0,0 +1,53 @@
+package unit
+
+import (
+	"context"
+	"gojwt-rest-api/internal/captcha"
+	"gojwt-rest-api/internal/config"
+	"gojwt-rest-api/internal/domain"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCaptcha_SiteVerifier(t *testing.T) {
+	// siteverify accepts the response "solved" once
+	used := false
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.NoError(t, r.ParseForm())
+		assert.Equal(t, "site-secret", r.PostForm.Get("secret"))
+		assert.Equal(t, "203.0.113.7", r.PostForm.Get("remoteip"))
+		success := r.PostForm.Get("response") == "solved" && !used
+		used = used || success
+		w.Header().Set("Content-Type", "application/json")
+		if success {
+			_, _ = w.Write([]byte(`{"success":true}`))
+		} else {
+			_, _ = w.Write([]byte(`{"success":false,"error-codes":["invalid-input-response"]}`))
+		}
+	}))
+	defer server.Close()
+	verifier := captcha.New(config.CaptchaConfig{Secret: "site-secret", VerifyURL: server.URL})
+
+	assert.NoError(t, verifier.Verify(context.Background(), "solved", "203.0.113.7"))
+	assert.Equal(t, domain.ErrCaptchaInvalid, verifier.Verify(context.Background(), "solved", "203.0.113.7"), "responses are single use")
+	assert.Equal(t, domain.ErrCaptchaInvalid, verifier.Verify(context.Background(), "forged", "203.0.113.7"))
+	assert.Equal(t, domain.ErrCaptchaInvalid, verifier.Verify(context.Background(), "", "203.0.113.7"))
+}
+
+func TestCaptcha_ProviderErrors(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer server.Close()
+	verifier := captcha.New(config.CaptchaConfig{Secret: "site-secret", VerifyURL: server.URL})
+
+	err := verifier.Verify(context.Background(), "solved", "")
+	require.Error(t, err)
+	assert.NotEqual(t, domain.ErrCaptchaInvalid, err, "outages are not failed CAPTCHAs")
+
+	assert.Nil(t, captcha.New(config.CaptchaConfig{}), "no secret disables verification")
+}
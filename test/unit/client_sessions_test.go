@@ -0,0 +1,228 @@
+package unit
+
+import (
+	"errors"
+	"gojwt-rest-api/internal/domain"
+	"gojwt-rest-api/internal/service"
+	"gojwt-rest-api/internal/utils"
+	"gojwt-rest-api/test/factory"
+	"gojwt-rest-api/test/helpers"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// clientSessionsFixture is a user service on in-memory repositories scoping
+// sessions to the web and ios client applications
+type clientSessionsFixture struct {
+	userService service.UserService
+	userRepo    *helpers.MemoryUserRepository
+	auditRepo   *helpers.MockAuditLogRepository
+	users       []*domain.User
+}
+
+func newClientSessionsFixture(t *testing.T, required bool) *clientSessionsFixture {
+	t.Helper()
+	f := &clientSessionsFixture{
+		userRepo:  helpers.NewMemoryUserRepository(),
+		auditRepo: new(helpers.MockAuditLogRepository),
+	}
+	fac := factory.New()
+	for _, email := range []string{"jane@example.com", "john@example.com"} {
+		user := fac.User(factory.WithEmail(email))
+		require.NoError(t, f.userRepo.Create(user))
+		f.users = append(f.users, user)
+	}
+	f.auditRepo.On("Create", mock.AnythingOfType("*domain.AuditLog")).Return(nil)
+
+	clients := map[string]*domain.ClientApplication{
+		"web": {ID: "web"},
+		"ios": {ID: "ios", AccessTokenTTL: 5 * time.Minute, RefreshTokenTTL: 30 * 24 * time.Hour},
+	}
+	f.userService = service.NewUserService(f.userRepo, helpers.NewMemoryTokenRepository(), factory.DefaultSecret, time.Minute, time.Hour,
+		service.WithAuditService(service.NewAuditService(f.auditRepo)),
+		service.WithClientApplications(clients, required),
+	)
+	return f
+}
+
+func (f *clientSessionsFixture) login(t *testing.T, user *domain.User, clientID string) *domain.LoginResponse {
+	t.Helper()
+	response, err := f.userService.Login(&domain.LoginRequest{Email: user.Email, Password: factory.DefaultPassword, ClientID: clientID})
+	require.NoError(t, err)
+	return response
+}
+
+func (f *clientSessionsFixture) refresh(refreshToken string) error {
+	_, err := f.userService.RefreshToken(&domain.RefreshTokenRequest{RefreshToken: refreshToken})
+	return err
+}
+
+func TestClientSessions_Login(t *testing.T) {
+	t.Run("Scopes the session to the client", func(t *testing.T) {
+		f := newClientSessionsFixture(t, false)
+
+		response := f.login(t, f.users[0], "ios")
+
+		claims, err := utils.ValidateToken(response.AccessToken, factory.DefaultSecret)
+		require.NoError(t, err)
+		assert.Equal(t, "ios", claims.ClientID)
+		assert.Equal(t, int64((5 * time.Minute).Seconds()), response.ExpiresIn)
+	})
+
+	t.Run("Keeps the organization lifetimes without client overrides", func(t *testing.T) {
+		f := newClientSessionsFixture(t, false)
+
+		response := f.login(t, f.users[0], "web")
+
+		assert.Equal(t, int64(time.Minute.Seconds()), response.ExpiresIn)
+	})
+
+	t.Run("Accepts logins without client unless required", func(t *testing.T) {
+		f := newClientSessionsFixture(t, false)
+
+		response := f.login(t, f.users[0], "")
+
+		claims, err := utils.ValidateToken(response.AccessToken, factory.DefaultSecret)
+		require.NoError(t, err)
+		assert.Empty(t, claims.ClientID)
+	})
+
+	t.Run("Refuses unknown clients", func(t *testing.T) {
+		f := newClientSessionsFixture(t, false)
+
+		_, err := f.userService.Login(&domain.LoginRequest{Email: f.users[0].Email, Password: factory.DefaultPassword, ClientID: "android"})
+
+		assert.Equal(t, domain.ErrUnknownClient, err)
+	})
+
+	t.Run("Refuses logins without client when required", func(t *testing.T) {
+		f := newClientSessionsFixture(t, true)
+
+		_, err := f.userService.Login(&domain.LoginRequest{Email: f.users[0].Email, Password: factory.DefaultPassword})
+
+		assert.Equal(t, domain.ErrClientIDRequired, err)
+	})
+}
+
+func TestClientSessions_RefreshKeepsClient(t *testing.T) {
+	f := newClientSessionsFixture(t, false)
+	login := f.login(t, f.users[0], "ios")
+
+	response, err := f.userService.RefreshToken(&domain.RefreshTokenRequest{RefreshToken: login.RefreshToken})
+
+	require.NoError(t, err)
+	claims, err := utils.ValidateToken(response.AccessToken, factory.DefaultSecret)
+	require.NoError(t, err)
+	assert.Equal(t, "ios", claims.ClientID)
+	assert.Equal(t, int64((5 * time.Minute).Seconds()), response.ExpiresIn)
+}
+
+func TestClientSessions_Revoke(t *testing.T) {
+	t.Run("Revokes the client sessions of one user", func(t *testing.T) {
+		f := newClientSessionsFixture(t, false)
+		ios := f.login(t, f.users[0], "ios")
+		web := f.login(t, f.users[0], "web")
+		other := f.login(t, f.users[1], "ios")
+
+		revocation, err := f.userService.RevokeClientSessions(f.users[0].ID, &f.users[0].ID, "ios")
+
+		require.NoError(t, err)
+		assert.Equal(t, int64(1), revocation.RefreshTokens)
+		assert.Error(t, f.refresh(ios.RefreshToken))
+		assert.NoError(t, f.refresh(web.RefreshToken))
+		assert.NoError(t, f.refresh(other.RefreshToken))
+		f.auditRepo.AssertCalled(t, "Create", mock.MatchedBy(func(entry *domain.AuditLog) bool {
+			return entry.Action == domain.AuditClientSessionsRevoked && entry.UserID != nil && *entry.UserID == f.users[0].ID
+		}))
+	})
+
+	t.Run("Revokes the client sessions of every user", func(t *testing.T) {
+		f := newClientSessionsFixture(t, false)
+		first := f.login(t, f.users[0], "ios")
+		second := f.login(t, f.users[1], "ios")
+		web := f.login(t, f.users[1], "web")
+
+		revocation, err := f.userService.RevokeClientSessions(f.users[0].ID, nil, "ios")
+
+		require.NoError(t, err)
+		assert.Equal(t, int64(2), revocation.RefreshTokens)
+		assert.Nil(t, revocation.UserID)
+		assert.Error(t, f.refresh(first.RefreshToken))
+		assert.Error(t, f.refresh(second.RefreshToken))
+		assert.NoError(t, f.refresh(web.RefreshToken))
+	})
+
+	t.Run("Revokes the access tokens already issued to the client", func(t *testing.T) {
+		f := newClientSessionsFixture(t, false)
+		ios := f.login(t, f.users[0], "ios")
+		web := f.login(t, f.users[0], "web")
+		other := f.login(t, f.users[1], "ios")
+
+		_, err := f.userService.RevokeClientSessions(f.users[0].ID, &f.users[0].ID, "ios")
+		require.NoError(t, err)
+
+		revoked := func(accessToken string) bool {
+			claims, err := utils.ValidateToken(accessToken, factory.DefaultSecret)
+			require.NoError(t, err)
+			revoked, err := f.userService.ClientSessionRevoked(claims.UserID, claims.ClientID, claims.IssuedAt.Time)
+			require.NoError(t, err)
+			return revoked
+		}
+		assert.True(t, revoked(ios.AccessToken))
+		assert.False(t, revoked(web.AccessToken))
+		assert.False(t, revoked(other.AccessToken))
+
+		later, err := f.userService.ClientSessionRevoked(f.users[0].ID, "ios", time.Now().Add(2*time.Second))
+		require.NoError(t, err)
+		assert.False(t, later, "sessions started after the revocation stay valid")
+	})
+
+	t.Run("Revokes the step-up tokens of the client", func(t *testing.T) {
+		f := newClientSessionsFixture(t, false)
+		f.login(t, f.users[0], "ios")
+		reauth, err := f.userService.Reauthenticate(f.users[0].ID, "ios", &domain.ReauthenticateRequest{Password: factory.DefaultPassword})
+		require.NoError(t, err)
+
+		_, err = f.userService.RevokeClientSessions(f.users[0].ID, &f.users[0].ID, "ios")
+		require.NoError(t, err)
+
+		claims, err := utils.ValidateToken(reauth.AccessToken, factory.DefaultSecret)
+		require.NoError(t, err)
+		assert.Equal(t, "ios", claims.ClientID)
+		revoked, err := f.userService.ClientSessionRevoked(claims.UserID, claims.ClientID, claims.IssuedAt.Time)
+		require.NoError(t, err)
+		assert.True(t, revoked)
+	})
+}
+
+func TestParseClientApplication(t *testing.T) {
+	tests := []struct {
+		spec    string
+		want    *domain.ClientApplication
+		wantErr bool
+	}{
+		{spec: "web", want: &domain.ClientApplication{ID: "web"}},
+		{spec: "ios=15m/720h", want: &domain.ClientApplication{ID: "ios", AccessTokenTTL: 15 * time.Minute, RefreshTokenTTL: 720 * time.Hour}},
+		{spec: "android=/720h", want: &domain.ClientApplication{ID: "android", RefreshTokenTTL: 720 * time.Hour}},
+		{spec: "Web", wantErr: true},
+		{spec: "ios=15m", wantErr: true},
+		{spec: "ios=-1m/720h", wantErr: true},
+		{spec: "ios=soon/720h", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.spec, func(t *testing.T) {
+			client, err := domain.ParseClientApplication(tt.spec)
+			if tt.wantErr {
+				assert.True(t, errors.Is(err, domain.ErrInvalidClientApplication), "got %v", err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, client)
+		})
+	}
+}
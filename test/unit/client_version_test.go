@@ -0,0 +1,138 @@
+package unit
+
+import (
+	"errors"
+	"gojwt-rest-api/internal/domain"
+	"gojwt-rest-api/internal/service"
+	"gojwt-rest-api/test/helpers"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseClientVersion(t *testing.T) {
+	tests := []struct {
+		version string
+		want    domain.ClientVersion
+		wantErr bool
+	}{
+		{version: "2.4.1", want: domain.ClientVersion{2, 4, 1}},
+		{version: "v2.4", want: domain.ClientVersion{2, 4}},
+		{version: "2.4.1-beta.2", want: domain.ClientVersion{2, 4, 1}},
+		{version: "2.4.1+42", want: domain.ClientVersion{2, 4, 1}},
+		{version: "", wantErr: true},
+		{version: "2.x", wantErr: true},
+		{version: "1.2.3.4.5", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.version, func(t *testing.T) {
+			version, err := domain.ParseClientVersion(tt.version)
+			if tt.wantErr {
+				assert.True(t, errors.Is(err, domain.ErrInvalidClientVersion), "got %v", err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, version)
+		})
+	}
+}
+
+func TestClientVersionLess(t *testing.T) {
+	parse := func(s string) domain.ClientVersion {
+		v, err := domain.ParseClientVersion(s)
+		require.NoError(t, err)
+		return v
+	}
+
+	assert.True(t, parse("2.3.9").Less(parse("2.4")))
+	assert.True(t, parse("2.4").Less(parse("2.10")))
+	assert.False(t, parse("2.4").Less(parse("2.4.0")))
+	assert.False(t, parse("2.4.0").Less(parse("2.4")))
+	assert.False(t, parse("3").Less(parse("2.99.99")))
+}
+
+func newClientVersionService(repo *helpers.MockClientVersionRepository, opts ...service.ClientVersionServiceOption) service.ClientVersionService {
+	auditRepo := new(helpers.MockAuditLogRepository)
+	auditRepo.On("Create", mock.AnythingOfType("*domain.AuditLog")).Return(nil)
+	return service.NewClientVersionService(repo, service.NewAuditService(auditRepo), time.Hour, opts...)
+}
+
+func TestClientVersionService_Check(t *testing.T) {
+	repo := new(helpers.MockClientVersionRepository)
+	repo.On("FindAll").Return([]*domain.ClientVersionPolicy{{ClientID: "ios", MinVersion: "2.4.0"}}, nil)
+	versions := newClientVersionService(repo)
+
+	t.Run("Refuses older versions", func(t *testing.T) {
+		policy, err := versions.Check("ios", "2.3.9")
+		assert.Equal(t, domain.ErrClientUpgradeRequired, err)
+		require.NotNil(t, policy)
+		assert.Equal(t, "2.4.0", policy.MinVersion)
+	})
+
+	t.Run("Refuses requests without version", func(t *testing.T) {
+		_, err := versions.Check("ios", "")
+		assert.Equal(t, domain.ErrClientUpgradeRequired, err)
+	})
+
+	t.Run("Refuses malformed versions", func(t *testing.T) {
+		_, err := versions.Check("ios", "latest")
+		assert.Equal(t, domain.ErrInvalidClientVersion, err)
+	})
+
+	t.Run("Accepts the minimum version and newer", func(t *testing.T) {
+		_, err := versions.Check("ios", "2.4")
+		assert.NoError(t, err)
+		_, err = versions.Check("ios", "2.10.0")
+		assert.NoError(t, err)
+	})
+
+	t.Run("Accepts any version of clients without minimum", func(t *testing.T) {
+		_, err := versions.Check("android", "0.1")
+		assert.NoError(t, err)
+	})
+
+	// The policies are loaded once per cache period
+	repo.AssertNumberOfCalls(t, "FindAll", 1)
+}
+
+func TestClientVersionService_SetMinVersion(t *testing.T) {
+	t.Run("Applies the new minimum on this instance immediately", func(t *testing.T) {
+		repo := new(helpers.MockClientVersionRepository)
+		repo.On("FindAll").Return([]*domain.ClientVersionPolicy{}, nil).Once()
+		repo.On("Save", mock.MatchedBy(func(p *domain.ClientVersionPolicy) bool {
+			return p.ClientID == "ios" && p.MinVersion == "2.4.0" && *p.UpdatedBy == 1
+		})).Return(nil)
+		repo.On("FindAll").Return([]*domain.ClientVersionPolicy{{ClientID: "ios", MinVersion: "2.4.0"}}, nil).Once()
+		versions := newClientVersionService(repo)
+
+		_, err := versions.Check("ios", "2.3.0")
+		require.NoError(t, err)
+
+		_, err = versions.SetMinVersion(1, "ios", "2.4.0")
+		require.NoError(t, err)
+
+		_, err = versions.Check("ios", "2.3.0")
+		assert.Equal(t, domain.ErrClientUpgradeRequired, err)
+	})
+
+	t.Run("Refuses malformed versions", func(t *testing.T) {
+		versions := newClientVersionService(new(helpers.MockClientVersionRepository))
+
+		_, err := versions.SetMinVersion(1, "ios", "soon")
+
+		assert.Equal(t, domain.ErrInvalidClientVersion, err)
+	})
+
+	t.Run("Refuses clients that are not configured", func(t *testing.T) {
+		versions := newClientVersionService(new(helpers.MockClientVersionRepository),
+			service.WithClientVersionClients(map[string]*domain.ClientApplication{"ios": {ID: "ios"}}))
+
+		_, err := versions.SetMinVersion(1, "android", "2.0")
+
+		assert.Equal(t, domain.ErrUnknownClient, err)
+	})
+}
@@ -0,0 +1,178 @@
+package unit
+
+import (
+	"gojwt-rest-api/internal/domain"
+	"gojwt-rest-api/internal/events"
+	"gojwt-rest-api/internal/service"
+	"gojwt-rest-api/internal/utils"
+	"gojwt-rest-api/test/factory"
+	"gojwt-rest-api/test/helpers"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// concurrentLoginFixture is a user service on in-memory repositories whose
+// user is logged in once already
+type concurrentLoginFixture struct {
+	userService service.UserService
+	userRepo    *helpers.MemoryUserRepository
+	publisher   *helpers.MockEventPublisher
+	user        *domain.User
+	first       *domain.LoginResponse
+}
+
+func newConcurrentLoginFixture(t *testing.T, opts ...service.UserServiceOption) *concurrentLoginFixture {
+	t.Helper()
+	f := &concurrentLoginFixture{
+		userRepo:  helpers.NewMemoryUserRepository(),
+		publisher: new(helpers.MockEventPublisher),
+		user:      factory.New().User(factory.WithEmail("jane@example.com")),
+	}
+	require.NoError(t, f.userRepo.Create(f.user))
+	f.publisher.On("Publish", mock.Anything, mock.Anything).Return()
+	opts = append(opts, service.WithEventPublisher(f.publisher))
+	f.userService = service.NewUserService(f.userRepo, helpers.NewMemoryTokenRepository(), factory.DefaultSecret, time.Minute, time.Hour, opts...)
+	f.first = f.login(t)
+	return f
+}
+
+func (f *concurrentLoginFixture) login(t *testing.T) *domain.LoginResponse {
+	t.Helper()
+	response, err := f.userService.Login(&domain.LoginRequest{Email: f.user.Email, Password: factory.DefaultPassword})
+	require.NoError(t, err)
+	return response
+}
+
+// concurrentLogins returns the published concurrent login events
+func (f *concurrentLoginFixture) concurrentLogins() []*events.UserConcurrentLoginData {
+	var published []*events.UserConcurrentLoginData
+	for _, call := range f.publisher.Calls {
+		if call.Arguments.String(0) == events.UserConcurrentLogin {
+			published = append(published, call.Arguments.Get(1).(*events.UserConcurrentLoginData))
+		}
+	}
+	return published
+}
+
+func TestUserService_ConcurrentLogin(t *testing.T) {
+	t.Run("Logging in again notifies the other sessions", func(t *testing.T) {
+		f := newConcurrentLoginFixture(t, service.WithConcurrentLoginNotification())
+		assert.Empty(t, f.concurrentLogins(), "the first login has no other session")
+
+		f.login(t)
+
+		published := f.concurrentLogins()
+		require.Len(t, published, 1)
+		assert.Equal(t, f.user.ID, published[0].UserID)
+		assert.Equal(t, int64(1), published[0].OtherSessions)
+		assert.False(t, published[0].OtherSessionsRevoked)
+		_, err := f.userService.RefreshToken(&domain.RefreshTokenRequest{RefreshToken: f.first.RefreshToken})
+		assert.NoError(t, err, "the other session is kept")
+	})
+
+	t.Run("Nothing is published unless enabled", func(t *testing.T) {
+		f := newConcurrentLoginFixture(t)
+
+		f.login(t)
+
+		assert.Empty(t, f.concurrentLogins())
+	})
+
+	t.Run("The preference logs out the other sessions", func(t *testing.T) {
+		f := newConcurrentLoginFixture(t, service.WithConcurrentLoginNotification())
+		f.user.LogoutOtherSessionsOnLogin = true
+		require.NoError(t, f.userRepo.Update(f.user))
+
+		second := f.login(t)
+
+		_, err := f.userService.RefreshToken(&domain.RefreshTokenRequest{RefreshToken: f.first.RefreshToken})
+		assert.Equal(t, domain.ErrTokenReused, err)
+		first, err := utils.ValidateToken(f.first.AccessToken, factory.DefaultSecret)
+		require.NoError(t, err)
+		revoked, err := f.userService.SessionRevoked(f.user.ID, first.SessionEpoch)
+		require.NoError(t, err)
+		assert.True(t, revoked, "access tokens of the other sessions are invalidated")
+
+		current, err := utils.ValidateToken(second.AccessToken, factory.DefaultSecret)
+		require.NoError(t, err)
+		revoked, err = f.userService.SessionRevoked(f.user.ID, current.SessionEpoch)
+		require.NoError(t, err)
+		assert.False(t, revoked, "the new session stays valid")
+		_, err = f.userService.RefreshToken(&domain.RefreshTokenRequest{RefreshToken: second.RefreshToken})
+		assert.NoError(t, err)
+
+		published := f.concurrentLogins()
+		require.Len(t, published, 1)
+		assert.True(t, published[0].OtherSessionsRevoked)
+	})
+
+	t.Run("The preference is saved with the profile", func(t *testing.T) {
+		f := newConcurrentLoginFixture(t)
+		enabled := true
+
+		updated, err := f.userService.UpdateOwnProfile(f.user.ID, &domain.UpdateProfileRequest{LogoutOtherSessionsOnLogin: &enabled})
+
+		require.NoError(t, err)
+		assert.True(t, updated.ToProfileResponse().LogoutOtherSessionsOnLogin)
+		stored, err := f.userRepo.FindByID(f.user.ID)
+		require.NoError(t, err)
+		assert.True(t, stored.LogoutOtherSessionsOnLogin)
+	})
+}
+
+func TestStream(t *testing.T) {
+	login := func(userID uint) events.Event {
+		return events.NewEvent(events.UserConcurrentLogin, &events.UserConcurrentLoginData{UserID: userID})
+	}
+
+	t.Run("Delivers events to the subscribers of their user only", func(t *testing.T) {
+		stream := events.NewStream()
+		jane, cancelJane := stream.Subscribe(1)
+		defer cancelJane()
+		john, cancelJohn := stream.Subscribe(2)
+		defer cancelJohn()
+
+		require.NoError(t, stream.Handle(login(1)))
+		require.NoError(t, stream.Handle(events.NewEvent(events.UserDeleted, &events.UserDeletedData{UserID: 2})))
+
+		select {
+		case event := <-jane:
+			assert.Equal(t, events.UserConcurrentLogin, event.Type)
+		default:
+			t.Fatal("no event delivered")
+		}
+		assert.Len(t, john, 0)
+	})
+
+	t.Run("Does not block on subscribers falling behind", func(t *testing.T) {
+		stream := events.NewStream()
+		_, cancel := stream.Subscribe(1)
+		defer cancel()
+
+		for i := 0; i < 100; i++ {
+			require.NoError(t, stream.Handle(login(1)))
+		}
+	})
+
+	t.Run("Cancelling and closing end subscriptions", func(t *testing.T) {
+		stream := events.NewStream()
+		cancelled, cancel := stream.Subscribe(1)
+		open, _ := stream.Subscribe(1)
+
+		cancel()
+		cancel()
+		_, ok := <-cancelled
+		assert.False(t, ok)
+
+		stream.Close()
+		_, ok = <-open
+		assert.False(t, ok)
+		late, _ := stream.Subscribe(1)
+		_, ok = <-late
+		assert.False(t, ok)
+	})
+}
@@ -0,0 +1,237 @@
+package unit
+
+import (
+	"gojwt-rest-api/internal/config"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// bannerSetting finds the setting of key in the banner
+func bannerSetting(t *testing.T, banner *config.Banner, key string) config.Setting {
+	t.Helper()
+	for _, setting := range banner.Settings {
+		if setting.Key == key {
+			return setting
+		}
+	}
+	t.Fatalf("setting %s not in banner", key)
+	return config.Setting{}
+}
+
+func TestConfigBanner(t *testing.T) {
+	t.Setenv("JWT_SECRET", "super-secret-value")
+	t.Setenv("DB_PASSWORD", "db-password")
+	t.Setenv("JWT_ACCESS_EXPIRATION", "15")
+	t.Setenv("RATE_LIMIT_REQUESTS", "lots")
+	t.Setenv("MULTI_TENANT_ENABLED", "true")
+	t.Setenv("DB_HOST", "")
+
+	cfg, err := config.Load()
+	require.NoError(t, err)
+	banner := cfg.Banner()
+
+	t.Run("Redacts secrets", func(t *testing.T) {
+		secret := bannerSetting(t, banner, "JWT_SECRET")
+		assert.Equal(t, "[redacted]", secret.Value)
+		assert.Equal(t, config.SourceEnv, secret.Source)
+		assert.Equal(t, "[redacted]", bannerSetting(t, banner, "DB_PASSWORD").Value)
+		assert.Equal(t, "", bannerSetting(t, banner, "SMTP_PASSWORD").Value, "unset secrets show as empty")
+	})
+
+	t.Run("Records sources and effective values", func(t *testing.T) {
+		host := bannerSetting(t, banner, "DB_HOST")
+		assert.Equal(t, config.SourceDefault, host.Source, "empty variables are unset")
+		assert.Equal(t, "localhost", host.Value)
+		assert.Equal(t, "168h0m0s", bannerSetting(t, banner, "JWT_REFRESH_EXPIRATION").Value)
+		assert.Contains(t, banner.Overridden, "MULTI_TENANT_ENABLED")
+		assert.NotContains(t, banner.Overridden, "DB_HOST")
+		assert.Positive(t, banner.Sources[config.SourceDefault])
+	})
+
+	t.Run("Warns about replaced values", func(t *testing.T) {
+		expiry := bannerSetting(t, banner, "JWT_ACCESS_EXPIRATION")
+		assert.Equal(t, "1m0s", expiry.Value)
+		assert.Contains(t, expiry.Warning, "need a unit such as 15s, 15m or 15h")
+		assert.Equal(t, "100", bannerSetting(t, banner, "RATE_LIMIT_REQUESTS").Value)
+		assert.Len(t, banner.Warnings, 2)
+	})
+
+	t.Run("Lists enabled subsystems", func(t *testing.T) {
+		enabled := map[string]bool{}
+		for _, subsystem := range banner.Subsystems {
+			enabled[subsystem.Name] = subsystem.Enabled
+		}
+		assert.True(t, enabled["multi_tenancy"])
+		assert.False(t, enabled["pii_encryption"])
+	})
+
+	t.Run("Never logs secret values", func(t *testing.T) {
+		for _, setting := range banner.Settings {
+			assert.NotContains(t, setting.Value, "super-secret-value", setting.Key)
+			assert.NotContains(t, setting.Value, "db-password", setting.Key)
+		}
+	})
+}
+
+func TestConfig_TrustNetworks(t *testing.T) {
+	t.Setenv("JWT_SECRET", "secret")
+
+	t.Run("Parses CIDRs and single addresses", func(t *testing.T) {
+		t.Setenv("TRUST_INTERNAL_NETWORKS", "10.0.0.0/8, 192.168.1.7,fd00::1/8")
+
+		cfg, err := config.Load()
+		require.NoError(t, err)
+
+		networks := make([]string, 0, len(cfg.Trust.InternalNetworks))
+		for _, network := range cfg.Trust.InternalNetworks {
+			networks = append(networks, network.String())
+		}
+		assert.Equal(t, []string{"10.0.0.0/8", "192.168.1.7/32", "fd00::/8"}, networks)
+		assert.True(t, cfg.Trust.Enabled())
+	})
+
+	t.Run("Rejects invalid networks", func(t *testing.T) {
+		t.Setenv("TRUST_INTERNAL_NETWORKS", "10.0.0.0/33")
+
+		_, err := config.Load()
+		assert.ErrorContains(t, err, "TRUST_INTERNAL_NETWORKS")
+	})
+
+	t.Run("Trusts listed API keys only", func(t *testing.T) {
+		t.Setenv("TRUST_INTERNAL_API_KEY_IDS", "3, 7")
+
+		cfg, err := config.Load()
+		require.NoError(t, err)
+		assert.Equal(t, []uint{3, 7}, cfg.Trust.APIKeyIDs)
+		assert.True(t, cfg.Trust.Enabled())
+
+		t.Setenv("TRUST_INTERNAL_API_KEY_IDS", "all")
+		_, err = config.Load()
+		assert.ErrorContains(t, err, "TRUST_INTERNAL_API_KEY_IDS")
+	})
+}
+
+func TestConfig_TrustedProxies(t *testing.T) {
+	t.Setenv("JWT_SECRET", "secret")
+
+	t.Run("Trusts no proxy by default", func(t *testing.T) {
+		cfg, err := config.Load()
+		require.NoError(t, err)
+
+		assert.Empty(t, cfg.Server.TrustedProxies)
+	})
+
+	t.Run("Rejects invalid networks", func(t *testing.T) {
+		t.Setenv("SERVER_TRUSTED_PROXIES", "10.0.0.0/8,proxy.internal")
+
+		_, err := config.Load()
+		assert.ErrorContains(t, err, "SERVER_TRUSTED_PROXIES")
+	})
+}
+
+func TestConfig_LegacyFormatCutoff(t *testing.T) {
+	t.Setenv("JWT_SECRET", "secret")
+
+	t.Run("Parses dates and RFC 3339 times", func(t *testing.T) {
+		t.Setenv("JWT_LEGACY_FORMAT_CUTOFF", "2026-12-01")
+		cfg, err := config.Load()
+		require.NoError(t, err)
+		assert.Equal(t, time.Date(2026, 12, 1, 0, 0, 0, 0, time.UTC), cfg.JWT.LegacyFormatCutoff)
+
+		t.Setenv("JWT_LEGACY_FORMAT_CUTOFF", "2026-12-01T09:30:00+07:00")
+		cfg, err = config.Load()
+		require.NoError(t, err)
+		assert.True(t, cfg.JWT.LegacyFormatCutoff.Equal(time.Date(2026, 12, 1, 2, 30, 0, 0, time.UTC)))
+	})
+
+	t.Run("Keeps accepting legacy tokens when unset", func(t *testing.T) {
+		cfg, err := config.Load()
+		require.NoError(t, err)
+		assert.True(t, cfg.JWT.LegacyFormatCutoff.IsZero())
+	})
+
+	t.Run("Rejects invalid dates", func(t *testing.T) {
+		t.Setenv("JWT_LEGACY_FORMAT_CUTOFF", "next month")
+
+		_, err := config.Load()
+		assert.ErrorContains(t, err, "JWT_LEGACY_FORMAT_CUTOFF")
+	})
+}
+
+func TestConfig_DevRoutes(t *testing.T) {
+	t.Setenv("JWT_SECRET", "secret")
+
+	t.Run("Disabled by default", func(t *testing.T) {
+		cfg, err := config.Load()
+		require.NoError(t, err)
+		assert.False(t, cfg.Server.DevRoutes)
+	})
+
+	t.Run("Allowed on a loopback or unix socket listener", func(t *testing.T) {
+		t.Setenv("DEV_ROUTES_ENABLED", "true")
+		t.Setenv("SERVER_HOST", "127.0.0.1")
+		cfg, err := config.Load()
+		require.NoError(t, err)
+		assert.True(t, cfg.Server.DevRoutes)
+
+		t.Setenv("SERVER_LISTEN", "unix:///tmp/gojwt.sock")
+		_, err = config.Load()
+		assert.NoError(t, err)
+	})
+
+	t.Run("Refused on a public listener", func(t *testing.T) {
+		t.Setenv("DEV_ROUTES_ENABLED", "true")
+		t.Setenv("SERVER_HOST", "0.0.0.0")
+
+		_, err := config.Load()
+		assert.ErrorContains(t, err, "DEV_ROUTES_ENABLED")
+	})
+
+	t.Run("Refused in production", func(t *testing.T) {
+		t.Setenv("DEV_ROUTES_ENABLED", "true")
+		t.Setenv("APP_ENV", "production")
+
+		_, err := config.Load()
+		assert.ErrorContains(t, err, "APP_ENV=production")
+	})
+}
+
+func TestConfigBanner_RedactsSecretLookingKeys(t *testing.T) {
+	secretLooking := regexp.MustCompile(`_(SECRETS?|KEYS?|TOKEN|PASSWORD)$`)
+	t.Setenv("JWT_SECRET", "secret")
+
+	cfg, err := config.Load()
+	require.NoError(t, err)
+	var keys []string
+	for _, setting := range cfg.Banner().Settings {
+		if secretLooking.MatchString(setting.Key) {
+			keys = append(keys, setting.Key)
+		}
+	}
+	require.NotEmpty(t, keys)
+
+	// Settings only valid together with another one
+	companions := map[string]map[string]string{
+		"SERVER_ADMIN_TLS_KEY": {"SERVER_ADMIN_TLS_CERT": "cert.pem", "SERVER_ADMIN_PORT": "9090"},
+		"PII_ENCRYPTION_KEY":   {"PII_BLIND_INDEX_KEY": "leaked-value"},
+	}
+	for _, key := range keys {
+		t.Run(key, func(t *testing.T) {
+			t.Setenv(key, "leaked-value")
+			for companion, value := range companions[key] {
+				t.Setenv(companion, value)
+			}
+
+			cfg, err := config.Load()
+			require.NoError(t, err)
+
+			setting := bannerSetting(t, cfg.Banner(), key)
+			assert.Equal(t, "[redacted]", setting.Value)
+			assert.NotContains(t, setting.Warning, "leaked-value")
+		})
+	}
+}
@@ -0,0 +1,142 @@
+package unit
+
+import (
+	"context"
+	"encoding/json"
+	"gojwt-rest-api/internal/handler"
+	"gojwt-rest-api/internal/lifecycle"
+	"gojwt-rest-api/internal/middleware"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDrainer(t *testing.T) {
+	t.Run("Ready until draining starts", func(t *testing.T) {
+		drainer := lifecycle.NewDrainer()
+		assert.True(t, drainer.IsReady())
+		select {
+		case <-drainer.Draining():
+			t.Fatal("draining before Start")
+		default:
+		}
+
+		drainer.Start()
+		drainer.Start() // safe to call again
+
+		assert.False(t, drainer.IsReady())
+		select {
+		case <-drainer.Draining():
+		default:
+			t.Fatal("Draining not closed by Start")
+		}
+	})
+
+	t.Run("Counts requests in flight", func(t *testing.T) {
+		drainer := lifecycle.NewDrainer()
+
+		drainer.Begin()
+		drainer.Begin()
+		drainer.End()
+
+		assert.Equal(t, int64(1), drainer.InFlight())
+	})
+}
+
+func TestHealthHandler_Drain(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	drainer := lifecycle.NewDrainer()
+	health := handler.NewHealthHandler(drainer, lifecycle.NewHealthRegistry())
+	router := gin.New()
+	router.GET("/health/ready", health.Ready)
+	router.POST("/admin/drain", health.Drain)
+
+	serve := func(method, path string) (int, map[string]interface{}) {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(method, path, nil))
+		var body map[string]interface{}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+		return w.Code, body
+	}
+
+	code, body := serve(http.MethodGet, "/health/ready")
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, "ready", body["status"])
+
+	code, _ = serve(http.MethodPost, "/admin/drain")
+	assert.Equal(t, http.StatusAccepted, code)
+
+	code, body = serve(http.MethodGet, "/health/ready")
+	assert.Equal(t, http.StatusServiceUnavailable, code)
+	assert.Equal(t, "draining", body["status"])
+	select {
+	case <-drainer.Draining():
+	default:
+		t.Fatal("drain request did not start draining")
+	}
+
+	// Draining again keeps the server unready
+	code, _ = serve(http.MethodPost, "/admin/drain")
+	assert.Equal(t, http.StatusAccepted, code)
+	assert.False(t, drainer.IsReady())
+}
+
+func TestInFlightMiddleware_ShutdownWaitsForInFlightRequests(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	drainer := lifecycle.NewDrainer()
+	health := handler.NewHealthHandler(drainer, lifecycle.NewHealthRegistry())
+	started, release := make(chan struct{}), make(chan struct{})
+
+	router := gin.New()
+	router.GET("/health/ready", health.Ready)
+	router.Use(middleware.InFlightMiddleware(drainer))
+	router.GET("/slow", func(c *gin.Context) {
+		close(started)
+		<-release
+		c.Status(http.StatusOK)
+	})
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	srv := &http.Server{Handler: router}
+	go func() { _ = srv.Serve(listener) }()
+
+	responses := make(chan int, 1)
+	go func() {
+		resp, err := http.Get("http://" + listener.Addr().String() + "/slow")
+		if err != nil {
+			responses <- 0
+			return
+		}
+		_ = resp.Body.Close()
+		responses <- resp.StatusCode
+	}()
+	<-started
+	assert.Equal(t, int64(1), drainer.InFlight())
+
+	// Readiness fails at once and reports the request still in flight
+	drainer.Start()
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/health/ready", nil))
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+	assert.JSONEq(t, `{"status":"draining","in_flight":1}`, w.Body.String())
+
+	shutdown := make(chan error, 1)
+	go func() { shutdown <- srv.Shutdown(context.Background()) }()
+	select {
+	case err := <-shutdown:
+		t.Fatalf("shutdown returned before the in-flight request finished: %v", err)
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	close(release)
+	require.NoError(t, <-shutdown)
+	assert.Equal(t, http.StatusOK, <-responses)
+	assert.Equal(t, int64(0), drainer.InFlight())
+}
@@ -0,0 +1,177 @@
+package unit
+
+import (
+	"gojwt-rest-api/internal/domain"
+	"gojwt-rest-api/internal/events"
+	"gojwt-rest-api/internal/service"
+	"gojwt-rest-api/internal/utils"
+	"gojwt-rest-api/test/factory"
+	"gojwt-rest-api/test/helpers"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// emailChangeFixture is a user service with email change reverting enabled,
+// on in-memory repositories with a logged-in user
+type emailChangeFixture struct {
+	userService   service.UserService
+	oneTimeTokens service.OneTimeTokenService
+	userRepo      *helpers.MemoryUserRepository
+	tokenRepo     *helpers.MemoryTokenRepository
+	publisher     *helpers.MockEventPublisher
+	auditRepo     *helpers.MockAuditLogRepository
+	user          *domain.User
+	login         *domain.LoginResponse
+}
+
+func newEmailChangeFixture(t *testing.T) *emailChangeFixture {
+	t.Helper()
+	f := &emailChangeFixture{
+		userRepo:  helpers.NewMemoryUserRepository(),
+		tokenRepo: helpers.NewMemoryTokenRepository(),
+		publisher: new(helpers.MockEventPublisher),
+		auditRepo: new(helpers.MockAuditLogRepository),
+		user:      factory.New().User(factory.WithEmail("jane@example.com")),
+
+		oneTimeTokens: service.NewOneTimeTokenService(helpers.NewMemoryOneTimeTokenRepository()),
+	}
+	require.NoError(t, f.userRepo.Create(f.user))
+	f.publisher.On("Publish", mock.Anything, mock.Anything).Return()
+	f.auditRepo.On("Create", mock.AnythingOfType("*domain.AuditLog")).Return(nil)
+
+	f.userService = service.NewUserService(f.userRepo, f.tokenRepo, factory.DefaultSecret, time.Minute, time.Hour,
+		service.WithEventPublisher(f.publisher),
+		service.WithAuditService(service.NewAuditService(f.auditRepo)),
+		service.WithEmailChangeRevert(f.oneTimeTokens, 72*time.Hour),
+	)
+	var err error
+	f.login, err = f.userService.Login(&domain.LoginRequest{Email: f.user.Email, Password: factory.DefaultPassword})
+	require.NoError(t, err)
+	return f
+}
+
+// changeEmail changes the user's email and returns the published event
+func (f *emailChangeFixture) changeEmail(t *testing.T, email string) *events.UserEmailChangedData {
+	t.Helper()
+	_, err := f.userService.UpdateOwnProfile(f.user.ID, &domain.UpdateProfileRequest{Email: email})
+	require.NoError(t, err)
+
+	var changed *events.UserEmailChangedData
+	for _, call := range f.publisher.Calls {
+		if call.Arguments.Get(0) == events.UserEmailChanged {
+			changed = call.Arguments.Get(1).(*events.UserEmailChangedData)
+		}
+	}
+	require.NotNil(t, changed, "no %s event published", events.UserEmailChanged)
+	return changed
+}
+
+// auditActions returns the actions of the recorded audit logs in order
+func (f *emailChangeFixture) auditActions() []string {
+	var actions []string
+	for _, call := range f.auditRepo.Calls {
+		actions = append(actions, call.Arguments.Get(0).(*domain.AuditLog).Action)
+	}
+	return actions
+}
+
+func TestEmailChange_NotifiesPreviousAddress(t *testing.T) {
+	f := newEmailChangeFixture(t)
+
+	changed := f.changeEmail(t, "attacker@example.com")
+
+	assert.Equal(t, "jane@example.com", changed.PreviousEmail)
+	assert.Equal(t, "attacker@example.com", changed.NewEmail)
+	assert.Equal(t, f.user.ID, changed.ActorID)
+	assert.NotEmpty(t, changed.RevertToken)
+	require.NotNil(t, changed.RevertExpiresAt)
+	assert.WithinDuration(t, time.Now().Add(72*time.Hour), *changed.RevertExpiresAt, time.Minute)
+	assert.Equal(t, []string{domain.AuditUserEmailChanged}, f.auditActions())
+}
+
+func TestEmailChange_RevertRestoresEmailAndRevokesSessions(t *testing.T) {
+	f := newEmailChangeFixture(t)
+	changed := f.changeEmail(t, "attacker@example.com")
+
+	user, resetToken, err := f.userService.RevertEmailChange(changed.RevertToken)
+
+	require.NoError(t, err)
+	assert.Equal(t, "jane@example.com", user.Email)
+	assert.NotEmpty(t, resetToken)
+	stored, err := f.userRepo.FindByEmail("jane@example.com")
+	require.NoError(t, err)
+	assert.Equal(t, f.user.ID, stored.ID)
+
+	_, err = f.userService.RefreshToken(&domain.RefreshTokenRequest{RefreshToken: f.login.RefreshToken})
+	assert.Error(t, err, "sessions from before the revert must be revoked")
+	claims, err := utils.ValidateToken(f.login.AccessToken, factory.DefaultSecret)
+	require.NoError(t, err)
+	revoked, err := f.userService.SessionRevoked(claims.UserID, claims.SessionEpoch)
+	require.NoError(t, err)
+	assert.True(t, revoked, "access tokens from before the revert must be rejected")
+	// Whoever changed the email may have set the password
+	_, err = f.userService.Login(&domain.LoginRequest{Email: "jane@example.com", Password: factory.DefaultPassword})
+	assert.Equal(t, domain.ErrInvalidCredentials, err)
+	assert.Equal(t, []string{domain.AuditUserEmailChanged, domain.AuditUserEmailReverted}, f.auditActions())
+	f.publisher.AssertCalled(t, "Publish", events.UserEmailChangeReverted, mock.AnythingOfType("*events.UserEmailChangeRevertedData"))
+
+	// The token works once
+	_, _, err = f.userService.RevertEmailChange(changed.RevertToken)
+	assert.Equal(t, domain.ErrOneTimeTokenInvalid, err)
+}
+
+func TestEmailChange_RevertReturnsPasswordResetToken(t *testing.T) {
+	f := newEmailChangeFixture(t)
+	changed := f.changeEmail(t, "attacker@example.com")
+	_, resetToken, err := f.userService.RevertEmailChange(changed.RevertToken)
+	require.NoError(t, err)
+
+	auditRepo := new(helpers.MockAuditLogRepository)
+	auditRepo.On("Create", mock.AnythingOfType("*domain.AuditLog")).Return(nil)
+	passwordReset := service.NewPasswordResetService(f.userRepo, f.tokenRepo, f.oneTimeTokens, service.NewAuditService(auditRepo), time.Hour)
+	_, err = passwordReset.Reset(&domain.ResetPasswordRequest{Token: resetToken, NewPassword: newPassword})
+	require.NoError(t, err)
+
+	_, err = f.userService.Login(&domain.LoginRequest{Email: "jane@example.com", Password: newPassword})
+	assert.NoError(t, err)
+}
+
+func TestEmailChange_RevertSurvivesFurtherChanges(t *testing.T) {
+	f := newEmailChangeFixture(t)
+	first := f.changeEmail(t, "attacker@example.com")
+	f.changeEmail(t, "attacker2@example.com")
+
+	// Changing the email again doesn't invalidate the original owner's link
+	user, _, err := f.userService.RevertEmailChange(first.RevertToken)
+
+	require.NoError(t, err)
+	assert.Equal(t, "jane@example.com", user.Email)
+}
+
+func TestEmailChange_PreviousAddressIsReserved(t *testing.T) {
+	f := newEmailChangeFixture(t)
+	f.changeEmail(t, "attacker@example.com")
+
+	_, err := f.userService.Register(&domain.RegisterRequest{Name: "Squatter", Email: "Jane@example.com", Password: factory.DefaultPassword})
+	assert.Equal(t, domain.ErrUserAlreadyExists, err)
+
+	// The user can return to their own previous address
+	_, err = f.userService.UpdateOwnProfile(f.user.ID, &domain.UpdateProfileRequest{Email: "jane@example.com"})
+	assert.NoError(t, err)
+}
+
+func TestEmailChange_InvalidRevertToken(t *testing.T) {
+	f := newEmailChangeFixture(t)
+
+	_, _, err := f.userService.RevertEmailChange("unknown")
+	assert.Equal(t, domain.ErrOneTimeTokenInvalid, err)
+
+	// Without reverting configured, every token is invalid
+	plain := service.NewUserService(f.userRepo, f.tokenRepo, factory.DefaultSecret, time.Minute, time.Hour)
+	_, _, err = plain.RevertEmailChange("unknown")
+	assert.Equal(t, domain.ErrOneTimeTokenInvalid, err)
+}
@@ -0,0 +1,74 @@
+package unit
+
+import (
+	"gojwt-rest-api/internal/domain"
+	"gojwt-rest-api/internal/utils"
+	"gojwt-rest-api/test/factory"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFactory_User(t *testing.T) {
+	f := factory.New(factory.WithClock(factory.NewClock(factory.Epoch)))
+
+	t.Run("Defaults", func(t *testing.T) {
+		first, second := f.User(), f.User()
+
+		assert.Equal(t, uint(1), first.ID)
+		assert.Equal(t, uint(2), second.ID)
+		assert.Equal(t, "user2@example.com", second.Email)
+		assert.Equal(t, factory.Epoch, first.CreatedAt)
+		assert.NoError(t, utils.CheckPassword(first.Password, factory.DefaultPassword))
+		assert.True(t, first.IsActive())
+	})
+
+	t.Run("Options", func(t *testing.T) {
+		f.Clock.Advance(time.Hour)
+		user := f.User(
+			factory.WithUserID(42),
+			factory.WithEmail("jane@example.com"),
+			factory.WithPassword("s3cret-pass"),
+			factory.WithAdminScopes(domain.ScopeAdminUserRead, domain.ScopeAdminAuditRead),
+			factory.InOrganization(7, domain.OrgRoleAdmin),
+			factory.Deactivated(),
+		)
+
+		assert.Equal(t, uint(42), user.ID)
+		assert.Equal(t, "jane@example.com", user.Email)
+		assert.NoError(t, utils.CheckPassword(user.Password, "s3cret-pass"))
+		assert.True(t, user.HasAdminScope(domain.ScopeAdminAuditRead))
+		assert.Equal(t, uint(7), *user.OrganizationID)
+		assert.False(t, user.IsActive())
+		assert.Equal(t, factory.Epoch.Add(time.Hour), *user.DeactivatedAt)
+	})
+}
+
+func TestFactory_SeededValuesAreStable(t *testing.T) {
+	a, b := factory.New(), factory.New()
+	assert.Equal(t, a.RefreshToken(a.User()).Token, b.RefreshToken(b.User()).Token)
+
+	c := factory.New(factory.WithSeed(99))
+	assert.NotEqual(t, a.RandomHex(16), c.RandomHex(16))
+}
+
+func TestFactory_Session(t *testing.T) {
+	f := factory.New()
+	user := f.User()
+
+	session := f.Session(user)
+
+	claims, err := utils.ValidateToken(session.AccessToken, f.Secret())
+	require.NoError(t, err)
+	assert.Equal(t, user.ID, claims.UserID)
+	assert.Equal(t, "Bearer "+session.AccessToken, session.AuthorizationHeader())
+	assert.Equal(t, user.ID, session.RefreshToken.UserID)
+	assert.True(t, session.RefreshToken.IsValid())
+
+	rotated := f.RefreshToken(user, factory.InFamily(session.RefreshToken.TokenFamily), factory.Revoked())
+	assert.Equal(t, session.RefreshToken.TokenFamily, rotated.TokenFamily)
+	assert.False(t, rotated.IsValid())
+	assert.NotEqual(t, session.RefreshToken.ID, rotated.ID)
+}
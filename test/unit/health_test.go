@@ -0,0 +1,101 @@
+package unit
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"gojwt-rest-api/internal/lifecycle"
+	"gojwt-rest-api/internal/metrics"
+	"gojwt-rest-api/internal/scheduler"
+	"gojwt-rest-api/pkg/logger"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHealthRegistry_Check(t *testing.T) {
+	up := lifecycle.HealthCheckFunc(func(ctx context.Context) error { return nil })
+	down := lifecycle.HealthCheckFunc(func(ctx context.Context) error { return errors.New("connection refused") })
+
+	t.Run("Healthy when every critical dependency is up", func(t *testing.T) {
+		health := lifecycle.NewHealthRegistry()
+		health.Register("database", up)
+		health.RegisterOptional("mailer", down)
+
+		report := health.Check(context.Background())
+
+		assert.True(t, report.Healthy)
+		require.Len(t, report.Checks, 2)
+		assert.Equal(t, "database", report.Checks[0].Name)
+		assert.Equal(t, lifecycle.HealthStatusUp, report.Checks[0].Status)
+		assert.True(t, report.Checks[0].Critical)
+		assert.Equal(t, "mailer", report.Checks[1].Name)
+		assert.Equal(t, lifecycle.HealthStatusDown, report.Checks[1].Status)
+		assert.Equal(t, "connection refused", report.Checks[1].Error)
+	})
+
+	t.Run("Unhealthy when a critical dependency is down", func(t *testing.T) {
+		health := lifecycle.NewHealthRegistry()
+		health.Register("database", down)
+		health.Register("redis", up)
+
+		report := health.Check(context.Background())
+
+		assert.False(t, report.Healthy)
+		assert.Equal(t, lifecycle.HealthStatusDown, report.Checks[0].Status)
+		assert.Equal(t, lifecycle.HealthStatusUp, report.Checks[1].Status)
+	})
+
+	t.Run("Checks outliving the timeout fail", func(t *testing.T) {
+		release := make(chan struct{})
+		defer close(release)
+		hung := lifecycle.HealthCheckFunc(func(ctx context.Context) error {
+			<-release
+			return nil
+		})
+		health := lifecycle.NewHealthRegistry(lifecycle.WithHealthCheckTimeout(20 * time.Millisecond))
+		health.Register("database", hung)
+
+		start := time.Now()
+		report := health.Check(context.Background())
+
+		assert.Less(t, time.Since(start), time.Second)
+		assert.False(t, report.Healthy)
+		assert.Equal(t, context.DeadlineExceeded.Error(), report.Checks[0].Error)
+	})
+
+	t.Run("Observed checks are surfaced in metrics", func(t *testing.T) {
+		registry := metrics.NewRegistry()
+		health := lifecycle.NewHealthRegistry(lifecycle.WithHealthObserver(metrics.NewDependencyMetrics(registry)))
+		health.Register("database", up)
+		health.RegisterOptional("mailer", down)
+
+		var before bytes.Buffer
+		registry.Write(&before)
+		assert.Contains(t, before.String(), `dependency_critical{dependency="database"} 1`)
+		assert.Contains(t, before.String(), `dependency_critical{dependency="mailer"} 0`)
+		assert.Contains(t, before.String(), `dependency_check_failures_total{dependency="mailer"} 0`)
+
+		health.Check(context.Background())
+		health.Check(context.Background())
+
+		var after bytes.Buffer
+		registry.Write(&after)
+		assert.Contains(t, after.String(), `dependency_up{dependency="database"} 1`)
+		assert.Contains(t, after.String(), `dependency_up{dependency="mailer"} 0`)
+		assert.Contains(t, after.String(), `dependency_check_failures_total{dependency="mailer"} 2`)
+		assert.Contains(t, after.String(), `dependency_check_duration_seconds_count{dependency="database"} 2`)
+	})
+}
+
+func TestScheduler_CheckHealth(t *testing.T) {
+	jobs := scheduler.New(logger.New())
+
+	assert.ErrorIs(t, jobs.CheckHealth(context.Background()), scheduler.ErrNotRunning)
+	jobs.Start()
+	assert.NoError(t, jobs.CheckHealth(context.Background()))
+	jobs.Stop()
+	assert.ErrorIs(t, jobs.CheckHealth(context.Background()), scheduler.ErrNotRunning)
+}
@@ -0,0 +1,157 @@
+package unit
+
+import (
+	"context"
+	"gojwt-rest-api/internal/domain"
+	"gojwt-rest-api/internal/events"
+	"gojwt-rest-api/internal/service"
+	"gojwt-rest-api/test/helpers"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInactivityService_Run(t *testing.T) {
+	day := 24 * time.Hour
+	policy := service.InactivityPolicy{
+		WarnAfter:      30 * day,
+		SuspendAfter:   60 * day,
+		AnonymizeAfter: 90 * day,
+		AccessTokenTTL: 15 * time.Minute,
+	}
+	stage := func(name string) interface{} {
+		return mock.MatchedBy(func(q *domain.InactiveUserQuery) bool { return q.Stage == name })
+	}
+
+	t.Run("Applies every stage to the users due for it", func(t *testing.T) {
+		userRepo := new(helpers.MockUserRepository)
+		tokenRepo := new(helpers.MockTokenRepository)
+		auditRepo := new(helpers.MockAuditLogRepository)
+		publisher := new(helpers.MockEventPublisher)
+		userCache := new(helpers.MockUserCache)
+		inactivityService := service.NewInactivityService(userRepo, tokenRepo, service.NewAuditService(auditRepo), policy,
+			service.WithInactivityEventPublisher(publisher), service.WithInactivityUserCache(userCache))
+
+		lastLogin := time.Now().Add(-100 * day)
+		stale := helpers.CreateTestUser(1, "stale@example.com")
+		stale.LastLoginAt = &lastLogin
+		idle := helpers.CreateTestUser(2, "idle@example.com")
+		quiet := helpers.CreateTestUser(3, "quiet@example.com")
+
+		var queries []*domain.InactiveUserQuery
+		record := func(args mock.Arguments) { queries = append(queries, args.Get(0).(*domain.InactiveUserQuery)) }
+		userRepo.On("FindInactive", stage(domain.InactivityStageAnonymize)).Run(record).Return([]*domain.User{stale}, nil)
+		userRepo.On("FindInactive", stage(domain.InactivityStageSuspend)).Run(record).Return([]*domain.User{idle}, nil)
+		userRepo.On("FindInactive", stage(domain.InactivityStageWarn)).Run(record).Return([]*domain.User{quiet}, nil)
+
+		var anonymization *domain.UserAnonymization
+		userRepo.On("Anonymize", mock.AnythingOfType("*domain.UserAnonymization")).
+			Run(func(args mock.Arguments) { anonymization = args.Get(0).(*domain.UserAnonymization) }).
+			Return(nil)
+		userRepo.On("SuspendInactive", idle.ID, mock.AnythingOfType("time.Time")).Return(true, nil)
+		tokenRepo.On("RevokeAllUserRefreshTokens", idle.ID).Return(nil)
+		userRepo.On("MarkInactivityWarned", quiet.ID, mock.AnythingOfType("time.Time")).Return(true, nil)
+
+		var actions []string
+		auditRepo.On("Create", mock.AnythingOfType("*domain.AuditLog")).
+			Run(func(args mock.Arguments) { actions = append(actions, args.Get(0).(*domain.AuditLog).Action) }).
+			Return(nil)
+
+		var warning *events.UserInactivityData
+		publisher.On("Publish", events.UserInactivityWarning, mock.Anything).
+			Run(func(args mock.Arguments) { warning = args.Get(1).(*events.UserInactivityData) })
+		publisher.On("Publish", events.UserSuspendedInactive, mock.Anything)
+		publisher.On("Publish", events.UserAnonymized, mock.Anything)
+		userCache.On("Invalidate", mock.Anything).Return(nil)
+
+		report, err := inactivityService.Run(context.Background())
+		require.NoError(t, err)
+		assert.Equal(t, 1, report.Warned)
+		assert.Equal(t, 1, report.Suspended)
+		assert.Equal(t, 1, report.Anonymized)
+		// Suspended and anonymized users are no longer served from cache
+		userCache.AssertNumberOfCalls(t, "Invalidate", 2)
+
+		// Earlier stages must precede later ones by the configured gap
+		require.Len(t, queries, 3)
+		require.NotNil(t, queries[0].SuspendedBefore)
+		assert.WithinDuration(t, time.Now().Add(-30*day), *queries[0].SuspendedBefore, time.Minute)
+		require.NotNil(t, queries[1].WarnedBefore)
+		assert.WithinDuration(t, time.Now().Add(-30*day), *queries[1].WarnedBefore, time.Minute)
+		assert.WithinDuration(t, time.Now().Add(-30*day), queries[2].LastActiveBefore, time.Minute)
+
+		require.NotNil(t, anonymization)
+		assert.Equal(t, stale.ID, anonymization.UserID)
+		assert.Equal(t, "anonymized-1@invalid", anonymization.Email)
+		assert.NotEqual(t, stale.Password, anonymization.Password)
+		assert.Equal(t, domain.AuditUserAnonymized, anonymization.Audit.Action)
+		assert.NotContains(t, anonymization.Audit.Detail, stale.Email)
+
+		assert.Equal(t, []string{domain.AuditUserSuspended, domain.AuditUserInactivityWarned}, actions)
+		require.NotNil(t, warning)
+		assert.Equal(t, quiet.Email, warning.Email)
+		require.NotNil(t, warning.SuspendAt)
+		assert.WithinDuration(t, time.Now().Add(30*day), *warning.SuspendAt, time.Minute)
+		publisher.AssertExpectations(t)
+	})
+
+	t.Run("Disabled stages are skipped", func(t *testing.T) {
+		userRepo := new(helpers.MockUserRepository)
+		inactivityService := service.NewInactivityService(userRepo, new(helpers.MockTokenRepository),
+			service.NewAuditService(new(helpers.MockAuditLogRepository)), service.InactivityPolicy{SuspendAfter: 60 * day})
+
+		var query *domain.InactiveUserQuery
+		userRepo.On("FindInactive", stage(domain.InactivityStageSuspend)).
+			Run(func(args mock.Arguments) { query = args.Get(0).(*domain.InactiveUserQuery) }).
+			Return([]*domain.User{}, nil)
+
+		report, err := inactivityService.Run(context.Background())
+		require.NoError(t, err)
+		assert.Zero(t, report.Suspended)
+		require.NotNil(t, query)
+		assert.Nil(t, query.WarnedBefore)
+		userRepo.AssertNumberOfCalls(t, "FindInactive", 1)
+	})
+
+	t.Run("Users already handled by a concurrent run are not counted", func(t *testing.T) {
+		userRepo := new(helpers.MockUserRepository)
+		publisher := new(helpers.MockEventPublisher)
+		inactivityService := service.NewInactivityService(userRepo, new(helpers.MockTokenRepository),
+			service.NewAuditService(new(helpers.MockAuditLogRepository)), service.InactivityPolicy{WarnAfter: 30 * day},
+			service.WithInactivityEventPublisher(publisher))
+
+		user := helpers.CreateTestUser(4, "quiet@example.com")
+		userRepo.On("FindInactive", stage(domain.InactivityStageWarn)).Return([]*domain.User{user}, nil)
+		userRepo.On("MarkInactivityWarned", user.ID, mock.AnythingOfType("time.Time")).Return(false, nil)
+
+		report, err := inactivityService.Run(context.Background())
+		require.NoError(t, err)
+		assert.Zero(t, report.Warned)
+		publisher.AssertNotCalled(t, "Publish", mock.Anything, mock.Anything)
+	})
+}
+
+func TestInactivityService_SetExempt(t *testing.T) {
+	userRepo := new(helpers.MockUserRepository)
+	auditRepo := new(helpers.MockAuditLogRepository)
+	userCache := new(helpers.MockUserCache)
+	inactivityService := service.NewInactivityService(userRepo, new(helpers.MockTokenRepository),
+		service.NewAuditService(auditRepo), service.InactivityPolicy{}, service.WithInactivityUserCache(userCache))
+
+	user := helpers.CreateTestUser(5, "service@example.com")
+	userRepo.On("FindByID", user.ID).Return(user, nil)
+	userRepo.On("Update", mock.MatchedBy(func(u *domain.User) bool { return u.InactivityExempt })).Return(nil)
+	auditRepo.On("Create", mock.MatchedBy(func(l *domain.AuditLog) bool {
+		return l.Action == domain.AuditUserInactivityExempt && *l.ActorID == 1 && l.Detail == `{"exempt":true}`
+	})).Return(nil)
+	userCache.On("Invalidate", mock.Anything).Return(nil)
+
+	updated, err := inactivityService.SetExempt(1, user.ID, true)
+	require.NoError(t, err)
+	assert.True(t, updated.InactivityExempt)
+	auditRepo.AssertExpectations(t)
+	userCache.AssertExpectations(t)
+}
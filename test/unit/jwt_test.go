@@ -299,6 +299,32 @@ func BenchmarkValidateToken(b *testing.B) {
 	}
 }
 
+func BenchmarkTokenValidator_Validate(b *testing.B) {
+	secret := "benchmark-secret"
+	token, _ := utils.GenerateToken(1, "bench@example.com", secret, 24*time.Hour)
+	validator := utils.NewTokenValidator(secret)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_, _ = validator.Validate(token)
+	}
+}
+
+func BenchmarkTokenValidator_ValidateParallel(b *testing.B) {
+	secret := "benchmark-secret"
+	token, _ := utils.GenerateToken(1, "bench@example.com", secret, 24*time.Hour)
+	validator := utils.NewTokenValidator(secret)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			_, _ = validator.Validate(token)
+		}
+	})
+}
+
 func BenchmarkGenerateTokenParallel(b *testing.B) {
 	secret := "benchmark-secret"
 	userID := uint(1)
@@ -464,3 +490,49 @@ func BenchmarkGenerateTokenPair(b *testing.B) {
 		_, _, _ = utils.GenerateTokenPair(userID, email, secret, accessExpiry, refreshExpiry)
 	}
 }
+
+func TestInspectToken(t *testing.T) {
+	secret := "test-secret-key"
+
+	t.Run("Valid token", func(t *testing.T) {
+		token, err := utils.GenerateToken(1, "test@example.com", secret, time.Hour)
+		require.NoError(t, err)
+
+		inspection := utils.InspectToken(token, secret)
+
+		assert.True(t, inspection.Valid)
+		assert.Empty(t, inspection.Reasons)
+		assert.Equal(t, "HS256", inspection.Header["alg"])
+		assert.Equal(t, "test@example.com", inspection.Claims["email"])
+		assert.NotNil(t, inspection.ExpiresAt)
+	})
+
+	t.Run("Expired token still decodes", func(t *testing.T) {
+		token, err := utils.GenerateToken(1, "test@example.com", secret, -time.Hour)
+		require.NoError(t, err)
+
+		inspection := utils.InspectToken(token, secret)
+
+		assert.False(t, inspection.Valid)
+		assert.Contains(t, inspection.Reasons, utils.InspectReasonExpired)
+		assert.Equal(t, "test@example.com", inspection.Claims["email"])
+	})
+
+	t.Run("Bad signature", func(t *testing.T) {
+		token, err := utils.GenerateToken(1, "test@example.com", "other-secret", time.Hour)
+		require.NoError(t, err)
+
+		inspection := utils.InspectToken(token, secret)
+
+		assert.False(t, inspection.Valid)
+		assert.Contains(t, inspection.Reasons, utils.InspectReasonBadSignature)
+	})
+
+	t.Run("Malformed token", func(t *testing.T) {
+		inspection := utils.InspectToken("not-a-jwt", secret)
+
+		assert.False(t, inspection.Valid)
+		assert.Equal(t, []string{utils.InspectReasonMalformed}, inspection.Reasons)
+		assert.Nil(t, inspection.Claims)
+	})
+}
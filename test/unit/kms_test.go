@@ -0,0 +1,109 @@
+package unit
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"gojwt-rest-api/internal/config"
+	"gojwt-rest-api/internal/kms"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// reverseDecrypter "decrypts" by reversing the ciphertext
+type reverseDecrypter struct{}
+
+func (reverseDecrypter) Decrypt(_ context.Context, ciphertext []byte) ([]byte, error) {
+	plaintext := make([]byte, len(ciphertext))
+	for i, b := range ciphertext {
+		plaintext[len(ciphertext)-1-i] = b
+	}
+	return plaintext, nil
+}
+
+func TestKMS_ResolveSecrets(t *testing.T) {
+	encrypted := kms.EncryptedPrefix + base64.StdEncoding.EncodeToString([]byte("terces"))
+
+	t.Run("Decrypts prefixed secrets only", func(t *testing.T) {
+		cfg := &config.Config{}
+		cfg.JWT.Secret = encrypted
+		cfg.PII.BlindIndexKey = "plain"
+		cfg.PII.PreviousKeys = []string{encrypted}
+
+		require.NoError(t, kms.ResolveSecrets(context.Background(), cfg, reverseDecrypter{}))
+
+		assert.Equal(t, "secret", cfg.JWT.Secret)
+		assert.Equal(t, "plain", cfg.PII.BlindIndexKey)
+		assert.Equal(t, []string{"secret"}, cfg.PII.PreviousKeys)
+	})
+
+	t.Run("Encrypted secrets require a provider", func(t *testing.T) {
+		cfg := &config.Config{}
+		cfg.JWT.Secret = encrypted
+
+		assert.Error(t, kms.ResolveSecrets(context.Background(), cfg, nil))
+	})
+}
+
+func TestKMS_Providers(t *testing.T) {
+	ciphertext := []byte("ciphertext")
+
+	t.Run("AWS requests are signed and decrypted", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			var body map[string]string
+			_ = json.NewDecoder(r.Body).Decode(&body)
+
+			assert.Equal(t, "TrentService.Decrypt", r.Header.Get("X-Amz-Target"))
+			assert.True(t, strings.HasPrefix(r.Header.Get("Authorization"), "AWS4-HMAC-SHA256 Credential=AKID/"))
+			assert.Contains(t, r.Header.Get("Authorization"), "/eu-west-1/kms/aws4_request")
+			assert.Equal(t, base64.StdEncoding.EncodeToString(ciphertext), body["CiphertextBlob"])
+			_ = json.NewEncoder(w).Encode(map[string]string{"Plaintext": base64.StdEncoding.EncodeToString([]byte("secret"))})
+		}))
+		defer server.Close()
+
+		decrypter, err := kms.New(config.KMSConfig{
+			Provider:           config.KMSProviderAWS,
+			Endpoint:           server.URL,
+			AWSRegion:          "eu-west-1",
+			AWSAccessKeyID:     "AKID",
+			AWSSecretAccessKey: "secret-key",
+		})
+		require.NoError(t, err)
+
+		plaintext, err := decrypter.Decrypt(context.Background(), ciphertext)
+		require.NoError(t, err)
+		assert.Equal(t, "secret", string(plaintext))
+	})
+
+	t.Run("GCP requests use the instance access token", func(t *testing.T) {
+		keyName := "projects/p/locations/global/keyRings/r/cryptoKeys/k"
+		mux := http.NewServeMux()
+		mux.HandleFunc("/computeMetadata/v1/instance/service-accounts/default/token", func(w http.ResponseWriter, r *http.Request) {
+			assert.Equal(t, "Google", r.Header.Get("Metadata-Flavor"))
+			_ = json.NewEncoder(w).Encode(map[string]string{"access_token": "token"})
+		})
+		mux.HandleFunc("/v1/"+keyName+":decrypt", func(w http.ResponseWriter, r *http.Request) {
+			assert.Equal(t, "Bearer token", r.Header.Get("Authorization"))
+			_ = json.NewEncoder(w).Encode(map[string]string{"plaintext": base64.StdEncoding.EncodeToString([]byte("secret"))})
+		})
+		server := httptest.NewServer(mux)
+		defer server.Close()
+
+		decrypter, err := kms.New(config.KMSConfig{
+			Provider:        config.KMSProviderGCP,
+			KeyName:         keyName,
+			Endpoint:        server.URL,
+			GCPMetadataHost: strings.TrimPrefix(server.URL, "http://"),
+		})
+		require.NoError(t, err)
+
+		plaintext, err := decrypter.Decrypt(context.Background(), ciphertext)
+		require.NoError(t, err)
+		assert.Equal(t, "secret", string(plaintext))
+	})
+}
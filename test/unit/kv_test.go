@@ -0,0 +1,74 @@
+package unit
+
+import (
+	"context"
+	"gojwt-rest-api/pkg/kv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestKV_MemoryStore(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("Incr counts within a fixed window and restarts once it expires", func(t *testing.T) {
+		store := kv.NewMemoryStore(time.Minute)
+
+		for want := int64(1); want <= 3; want++ {
+			count, err := store.Incr(ctx, "attempts", 50*time.Millisecond)
+			require.NoError(t, err)
+			assert.Equal(t, want, count)
+		}
+
+		time.Sleep(60 * time.Millisecond)
+		count, err := store.Incr(ctx, "attempts", 50*time.Millisecond)
+		require.NoError(t, err)
+		assert.Equal(t, int64(1), count)
+	})
+
+	t.Run("Get misses expired and deleted keys", func(t *testing.T) {
+		store := kv.NewMemoryStore(time.Minute)
+		require.NoError(t, store.Set(ctx, "short", []byte("a"), 10*time.Millisecond))
+		require.NoError(t, store.Set(ctx, "long", []byte("b"), time.Minute))
+
+		time.Sleep(20 * time.Millisecond)
+		_, err := store.Get(ctx, "short")
+		assert.ErrorIs(t, err, kv.ErrNotFound)
+
+		value, err := store.Get(ctx, "long")
+		require.NoError(t, err)
+		assert.Equal(t, []byte("b"), value)
+
+		require.NoError(t, store.Delete(ctx, "long"))
+		_, err = store.Get(ctx, "long")
+		assert.ErrorIs(t, err, kv.ErrNotFound)
+	})
+
+	t.Run("Prefixed views do not collide", func(t *testing.T) {
+		store := kv.NewMemoryStore(time.Minute)
+		limits := kv.WithPrefix(store, "ratelimit:")
+		otp := kv.WithPrefix(store, "otp:")
+
+		_, err := limits.Incr(ctx, "user-1", time.Minute)
+		require.NoError(t, err)
+		count, err := otp.Incr(ctx, "user-1", time.Minute)
+		require.NoError(t, err)
+		assert.Equal(t, int64(1), count)
+
+		require.NoError(t, limits.DeletePrefix(ctx, ""))
+		_, err = store.Get(ctx, "ratelimit:user-1")
+		assert.ErrorIs(t, err, kv.ErrNotFound)
+		value, err := store.Get(ctx, "otp:user-1")
+		require.NoError(t, err)
+		assert.Equal(t, []byte("1"), value)
+	})
+
+	t.Run("NewStore rejects unknown drivers and redis without a client", func(t *testing.T) {
+		_, err := kv.NewStore("memcached", time.Minute, nil)
+		assert.Error(t, err)
+		_, err = kv.NewStore(kv.DriverRedis, time.Minute, nil)
+		assert.Error(t, err)
+	})
+}
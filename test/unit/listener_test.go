@@ -0,0 +1,59 @@
+package unit
+
+import (
+	"gojwt-rest-api/internal/lifecycle"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestListen(t *testing.T) {
+	t.Run("Listen on unix socket with permissions", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "api.sock")
+
+		listener, err := lifecycle.Listen("unix://"+path, 0600)
+		require.NoError(t, err)
+		defer listener.Close()
+
+		info, err := os.Stat(path)
+		require.NoError(t, err)
+		assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+
+		conn, err := net.Dial("unix", path)
+		require.NoError(t, err)
+		conn.Close()
+	})
+
+	t.Run("Replace stale socket", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "api.sock")
+
+		stale, err := net.Listen("unix", path)
+		require.NoError(t, err)
+		stale.(*net.UnixListener).SetUnlinkOnClose(false)
+		stale.Close()
+
+		listener, err := lifecycle.Listen("unix://"+path, 0660)
+		require.NoError(t, err)
+		listener.Close()
+	})
+
+	t.Run("Refuse to replace a regular file", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "not-a-socket")
+		require.NoError(t, os.WriteFile(path, []byte("data"), 0600))
+
+		_, err := lifecycle.Listen("unix://"+path, 0660)
+		assert.Error(t, err)
+	})
+
+	t.Run("Listen on tcp address", func(t *testing.T) {
+		listener, err := lifecycle.Listen("tcp://127.0.0.1:0", 0660)
+		require.NoError(t, err)
+		defer listener.Close()
+
+		assert.Equal(t, "tcp", listener.Addr().Network())
+	})
+}
@@ -0,0 +1,128 @@
+package unit
+
+import (
+	"context"
+	"errors"
+	"gojwt-rest-api/internal/domain"
+	"gojwt-rest-api/internal/loadgen"
+	"gojwt-rest-api/internal/service"
+	"gojwt-rest-api/internal/utils"
+	"gojwt-rest-api/test/helpers"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// assignUserIDs mimics the database assigning IDs to a created batch
+func assignUserIDs(next *uint) func(args mock.Arguments) {
+	return func(args mock.Arguments) {
+		for _, user := range args.Get(0).([]*domain.User) {
+			*next++
+			user.ID = *next
+		}
+	}
+}
+
+func TestLoadgen_CreatesUsersInBatches(t *testing.T) {
+	userRepo := new(helpers.MockUserRepository)
+	var nextID uint
+	userRepo.On("CreateBatch", mock.AnythingOfType("[]*domain.User"), 2).Run(assignUserIDs(&nextID)).Return(nil)
+
+	var credentials []*loadgen.Credential
+	created, err := loadgen.NewGenerator(userRepo, nil).Generate(context.Background(), loadgen.Options{
+		Count:     5,
+		Start:     10,
+		Password:  "Known-Pass-1!",
+		BatchSize: 2,
+		Workers:   2,
+	}, func(credential *loadgen.Credential) error {
+		credentials = append(credentials, credential)
+		return nil
+	})
+
+	require.NoError(t, err)
+	assert.Equal(t, 5, created)
+	require.Len(t, credentials, 5)
+	assert.Equal(t, "loadtest-10@loadtest.example.com", credentials[0].Email)
+	assert.Equal(t, "loadtest-14@loadtest.example.com", credentials[4].Email)
+	assert.Equal(t, uint(5), credentials[4].UserID)
+	assert.Equal(t, "Known-Pass-1!", credentials[0].Password)
+	assert.Empty(t, credentials[0].AccessToken)
+	userRepo.AssertNumberOfCalls(t, "CreateBatch", 3)
+
+	batch := userRepo.Calls[0].Arguments.Get(0).([]*domain.User)
+	require.Len(t, batch, 2)
+	assert.NoError(t, utils.CheckPassword(batch[0].Password, "Known-Pass-1!"))
+	assert.NotEqual(t, batch[0].Password, batch[1].Password)
+}
+
+func TestLoadgen_IssuesSessions(t *testing.T) {
+	userRepo := new(helpers.MockUserRepository)
+	tokenRepo := new(helpers.MockTokenRepository)
+	var nextID uint
+	userRepo.On("CreateBatch", mock.AnythingOfType("[]*domain.User"), loadgen.DefaultBatchSize).Run(assignUserIDs(&nextID)).Return(nil)
+	userRepo.On("MarkFirstLogin", mock.Anything, mock.AnythingOfType("time.Time")).Return(true, nil)
+	userRepo.On("RecordLogin", mock.Anything, mock.AnythingOfType("time.Time")).Return(nil)
+	tokenRepo.On("CreateRefreshToken", mock.MatchedBy(func(token *domain.RefreshToken) bool {
+		return token.TokenFamily != "" && token.ClientID == "load-test"
+	})).Return(nil).Twice()
+	userService := service.NewUserService(userRepo, tokenRepo, "secret", time.Minute, time.Hour)
+
+	var credentials []*loadgen.Credential
+	_, err := loadgen.NewGenerator(userRepo, userService).Generate(context.Background(), loadgen.Options{
+		Count:    2,
+		Workers:  1,
+		Sessions: true,
+		ClientID: "load-test",
+	}, func(credential *loadgen.Credential) error {
+		credentials = append(credentials, credential)
+		return nil
+	})
+
+	require.NoError(t, err)
+	require.Len(t, credentials, 2)
+	claims, err := utils.ValidateToken(credentials[1].AccessToken, "secret")
+	require.NoError(t, err)
+	assert.Equal(t, uint(2), claims.UserID)
+	assert.Equal(t, credentials[1].Email, claims.Email)
+	// Sessions carry the claims of regular logins
+	assert.Equal(t, "load-test", claims.ClientID)
+	assert.NotNil(t, claims.AuthTime)
+	assert.NotEmpty(t, credentials[1].RefreshToken)
+	tokenRepo.AssertExpectations(t)
+}
+
+func TestLoadgen_StopsOnInsertError(t *testing.T) {
+	userRepo := new(helpers.MockUserRepository)
+	userRepo.On("CreateBatch", mock.Anything, 1).Return(errors.New("duplicate entry"))
+
+	emitted := 0
+	created, err := loadgen.NewGenerator(userRepo, nil).Generate(context.Background(), loadgen.Options{
+		Count:     3,
+		BatchSize: 1,
+		Workers:   1,
+	}, func(*loadgen.Credential) error {
+		emitted++
+		return nil
+	})
+
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "duplicate entry")
+	assert.Equal(t, 0, created)
+	assert.Equal(t, 0, emitted)
+	userRepo.AssertNumberOfCalls(t, "CreateBatch", 1)
+}
+
+func TestLoadgen_RejectsInvalidOptions(t *testing.T) {
+	generator := loadgen.NewGenerator(new(helpers.MockUserRepository), nil)
+	emit := func(*loadgen.Credential) error { return nil }
+
+	_, err := generator.Generate(context.Background(), loadgen.Options{}, emit)
+	assert.Error(t, err)
+
+	_, err = generator.Generate(context.Background(), loadgen.Options{Count: 1, Sessions: true}, emit)
+	assert.Error(t, err)
+}
@@ -0,0 +1,111 @@
+package unit
+
+import (
+	"bytes"
+	"gojwt-rest-api/internal/metrics"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRegistry_Write(t *testing.T) {
+	registry := metrics.NewRegistry()
+	requests := registry.NewCounterVec("requests_total", "Total requests.", "route")
+	inFlight := registry.NewGaugeVec("in_flight", "In-flight requests.")
+
+	requests.WithLabelValues("/users/:id").Inc()
+	requests.WithLabelValues("/users/:id").Add(2)
+	requests.WithLabelValues("/health").Inc()
+	inFlight.WithLabelValues().Set(4)
+
+	var buf bytes.Buffer
+	registry.Write(&buf)
+	output := buf.String()
+
+	assert.Contains(t, output, "# TYPE requests_total counter")
+	assert.Contains(t, output, `requests_total{route="/users/:id"} 3`)
+	assert.Contains(t, output, `requests_total{route="/health"} 1`)
+	assert.Contains(t, output, "# TYPE in_flight gauge")
+	assert.Contains(t, output, "in_flight 4")
+	assert.Less(t, bytes.Index(buf.Bytes(), []byte("in_flight")), bytes.Index(buf.Bytes(), []byte("requests_total")),
+		"Metric families should be ordered by name")
+}
+
+func TestRegistry_DuplicateMetricPanics(t *testing.T) {
+	registry := metrics.NewRegistry()
+	registry.NewCounterVec("duplicate_total", "First.")
+
+	assert.Panics(t, func() {
+		registry.NewCounterVec("duplicate_total", "Second.")
+	})
+}
+
+func TestHTTPMetrics_RouteStats(t *testing.T) {
+	registry := metrics.NewRegistry()
+	httpMetrics := metrics.NewHTTPMetrics(registry)
+
+	for i := 0; i < 19; i++ {
+		httpMetrics.Begin()
+		httpMetrics.Observe("GET", "/api/v1/users/:id", 200, 20*time.Millisecond)
+	}
+	httpMetrics.Begin()
+	httpMetrics.Observe("GET", "/api/v1/users/:id", 500, 2*time.Second)
+	httpMetrics.Begin()
+	httpMetrics.Observe("GET", "", 404, time.Millisecond)
+
+	stats := httpMetrics.RouteStats()
+	require.Len(t, stats, 2)
+
+	users := stats[0]
+	assert.Equal(t, "/api/v1/users/:id", users.Route)
+	assert.Equal(t, uint64(20), users.Requests)
+	assert.Equal(t, uint64(1), users.Errors)
+	assert.InDelta(t, 0.05, users.ErrorRate, 0.0001)
+	assert.LessOrEqual(t, users.P95Seconds, 0.025, "p95 should fall in the 25ms bucket")
+
+	assert.Equal(t, "unmatched", stats[1].Route, "Unmatched paths should share a single label")
+
+	var buf bytes.Buffer
+	registry.Write(&buf)
+	assert.Contains(t, buf.String(), `http_request_duration_seconds_bucket{method="GET",route="/api/v1/users/:id",le="+Inf"} 20`)
+	assert.Contains(t, buf.String(), `http_request_errors_total{method="GET",route="/api/v1/users/:id"} 1`)
+}
+
+func TestSLOTracker(t *testing.T) {
+	registry := metrics.NewRegistry()
+	tracker := metrics.NewSLOTracker(registry, 0.99, 0.9, "/api/v1/auth/login")
+
+	for i := 0; i < 96; i++ {
+		tracker.RecordRequest("/api/v1/users/:id", 200, false)
+	}
+	tracker.RecordRequest("/api/v1/users/:id", 503, false)
+	tracker.RecordRequest("/api/v1/auth/login", 200, false)
+	tracker.RecordRequest("/api/v1/auth/login", 401, false)
+	tracker.RecordRequest("/api/v1/auth/login", 429, true)
+	tracker.RecordRequest("", 404, false)
+
+	stats := tracker.Stats()
+	require.Len(t, stats, 2)
+
+	availability := stats[0]
+	assert.Equal(t, metrics.SLOAvailability, availability.Name)
+	shortest := availability.Windows[0]
+	assert.Equal(t, uint64(100), shortest.Total, "Unmatched routes should not be counted")
+	assert.Equal(t, uint64(99), shortest.Good)
+	assert.InDelta(t, 1.0, shortest.BurnRate, 0.0001, "1% errors against a 99% target burns budget at 1x")
+	assert.True(t, availability.Compliant)
+
+	auth := stats[1]
+	assert.Equal(t, metrics.SLOAuthSuccess, auth.Name)
+	assert.Equal(t, uint64(2), auth.Windows[0].Total, "Client errors should not be counted")
+	assert.Equal(t, uint64(1), auth.Windows[0].Good, "Server-side failures should count as bad")
+	assert.False(t, auth.Compliant)
+
+	var buf bytes.Buffer
+	registry.Write(&buf)
+	assert.Contains(t, buf.String(), `slo_events_total{slo="availability"} 100`)
+	assert.Contains(t, buf.String(), `slo_good_events_total{slo="availability"} 99`)
+	assert.Contains(t, buf.String(), `slo_target_ratio{slo="auth_success"} 0.9`)
+}
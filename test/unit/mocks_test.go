@@ -0,0 +1,57 @@
+package unit
+
+import (
+	"encoding/json"
+	"gojwt-rest-api/internal/domain"
+	"gojwt-rest-api/internal/handler"
+	"gojwt-rest-api/internal/service"
+	"gojwt-rest-api/pkg/validator"
+	"gojwt-rest-api/test/helpers"
+	"gojwt-rest-api/test/mocks"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMocks_TokenRepository(t *testing.T) {
+	tokenRepo := mocks.NewTokenRepository(t)
+	userService := service.NewUserService(new(helpers.MockUserRepository), tokenRepo, "test-secret", 15*time.Minute, 7*24*time.Hour)
+
+	tokenRepo.On("RevokeRefreshToken", "refresh-token").Return(nil).Once()
+
+	require.NoError(t, userService.Logout(1, &domain.LogoutRequest{RefreshToken: "refresh-token"}))
+}
+
+func TestMocks_UserService(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	users := mocks.NewUserService(t)
+	// Handlers scope the service to the request context
+	users.On("WithContext", mock.Anything).Return(users)
+	users.On("GetUserByID", uint(7)).Return(helpers.CreateTestUser(7, "jane@example.com"), nil).Once()
+	users.On("GetUserByID", uint(8)).Return(nil, domain.ErrUserNotFound).Once()
+
+	v, err := validator.New()
+	require.NoError(t, err)
+	router := gin.New()
+	router.GET("/users/:id", handler.NewUserHandler(users, v).GetUserByID)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/users/7", nil))
+	require.Equal(t, http.StatusOK, w.Code)
+	var body struct {
+		Data domain.UserResponse `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+	assert.Equal(t, "jane@example.com", body.Data.Email)
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/users/8", nil))
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
@@ -0,0 +1,132 @@
+package unit
+
+import (
+	"gojwt-rest-api/internal/domain"
+	"gojwt-rest-api/internal/events"
+	"gojwt-rest-api/internal/service"
+	"gojwt-rest-api/internal/utils"
+	"gojwt-rest-api/test/factory"
+	"gojwt-rest-api/test/helpers"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// completedSteps returns the onboarding steps of the status that are completed, in order
+func completedSteps(t *testing.T, onboarding service.OnboardingTracker, userID uint) []string {
+	t.Helper()
+	status, err := onboarding.Status(userID)
+	require.NoError(t, err)
+
+	var steps []string
+	for _, step := range status.Steps {
+		if step.Completed {
+			steps = append(steps, step.Step)
+		}
+	}
+	return steps
+}
+
+func TestOnboardingStatusResponse(t *testing.T) {
+	now := time.Now()
+
+	status := domain.NewOnboardingStatusResponse([]*domain.OnboardingStep{
+		{UserID: 1, Step: domain.OnboardingStepTwoFactorEnrolled, CompletedAt: now},
+		{UserID: 1, Step: domain.OnboardingStepEmailVerified, CompletedAt: now},
+		{UserID: 1, Step: "retired_step", CompletedAt: now},
+	})
+
+	require.Len(t, status.Steps, 3)
+	assert.Equal(t, domain.OnboardingSteps[0], status.Steps[0].Step, "steps are listed in order")
+	assert.True(t, status.Steps[0].Completed)
+	assert.False(t, status.Steps[1].Completed)
+	assert.Nil(t, status.Steps[1].CompletedAt)
+	assert.Equal(t, 2, status.CompletedSteps)
+	assert.Equal(t, 3, status.TotalSteps)
+	assert.Equal(t, 66, status.Percent)
+	assert.False(t, status.Completed)
+
+	empty := domain.NewOnboardingStatusResponse(nil)
+	assert.Equal(t, 0, empty.Percent)
+}
+
+func TestOnboarding_UserService(t *testing.T) {
+	userRepo := helpers.NewMemoryUserRepository()
+	user := factory.New().User(factory.WithEmail("jane@example.com"))
+	require.NoError(t, userRepo.Create(user))
+	publisher := new(helpers.MockEventPublisher)
+	publisher.On("Publish", mock.Anything, mock.Anything).Return()
+	onboarding := service.NewOnboardingService(helpers.NewMemoryOnboardingRepository(), nil)
+	userService := service.NewUserService(userRepo, helpers.NewMemoryTokenRepository(), factory.DefaultSecret, time.Minute, time.Hour,
+		service.WithEventPublisher(publisher),
+		service.WithEmailChangeRevert(service.NewOneTimeTokenService(helpers.NewMemoryOneTimeTokenRepository()), time.Hour),
+		service.WithOnboardingTracker(onboarding),
+	)
+	require.NoError(t, onboarding.CompleteStep(user.ID, domain.OnboardingStepEmailVerified))
+
+	_, err := userService.UpdateOwnProfile(user.ID, &domain.UpdateProfileRequest{Name: "Jane Doe"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{domain.OnboardingStepEmailVerified, domain.OnboardingStepProfileCompleted},
+		completedSteps(t, onboarding, user.ID))
+
+	// The new address is not verified
+	_, err = userService.UpdateOwnProfile(user.ID, &domain.UpdateProfileRequest{Email: "jane@example.org"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{domain.OnboardingStepProfileCompleted}, completedSteps(t, onboarding, user.ID))
+
+	// Following the revert link proves the previous address
+	var revertToken string
+	for _, call := range publisher.Calls {
+		if data, ok := call.Arguments.Get(1).(*events.UserEmailChangedData); ok {
+			revertToken = data.RevertToken
+		}
+	}
+	_, _, err = userService.RevertEmailChange(revertToken)
+	require.NoError(t, err)
+	assert.Equal(t, []string{domain.OnboardingStepEmailVerified, domain.OnboardingStepProfileCompleted},
+		completedSteps(t, onboarding, user.ID))
+}
+
+func TestOnboarding_TwoFactorEnrollment(t *testing.T) {
+	userRepo := new(helpers.MockUserRepository)
+	onboarding := service.NewOnboardingService(helpers.NewMemoryOnboardingRepository(), nil)
+	twoFactor := service.NewTwoFactorService(userRepo, nil, "GoJWT", nil, service.WithTwoFactorOnboardingTracker(onboarding))
+
+	secret, err := utils.GenerateTOTPSecret()
+	require.NoError(t, err)
+	userRepo.On("FindTwoFactor", uint(1)).Return(&domain.UserTwoFactor{UserID: 1, Secret: secret}, nil)
+	userRepo.On("SaveTwoFactor", mock.AnythingOfType("*domain.UserTwoFactor")).Return(nil)
+
+	assert.Equal(t, domain.ErrInvalidTwoFactorCode, twoFactor.ConfirmEnrollment(1, "000000x"))
+	assert.Empty(t, completedSteps(t, onboarding, 1))
+
+	code, _ := utils.TOTPCode(secret, time.Now())
+	require.NoError(t, twoFactor.ConfirmEnrollment(1, code))
+	assert.Equal(t, []string{domain.OnboardingStepTwoFactorEnrolled}, completedSteps(t, onboarding, 1))
+}
+
+func TestOnboarding_AcceptInvitationVerifiesEmail(t *testing.T) {
+	orgID := uint(5)
+	orgRepo := new(helpers.MockOrganizationRepository)
+	userRepo := new(helpers.MockUserRepository)
+	onboardingRepo := new(helpers.MockOnboardingRepository)
+	orgService := service.NewOrganizationService(orgRepo, userRepo, service.NewQuotaService(orgRepo, userRepo, time.Minute),
+		service.WithOrganizationOnboardingTracker(service.NewOnboardingService(onboardingRepo, nil)))
+
+	invitation := &domain.OrganizationInvitation{ID: 4, OrganizationID: orgID, Email: "jane@acme.com", Role: domain.OrgRoleMember,
+		ExpiresAt: time.Now().Add(time.Hour)}
+	orgRepo.On("FindInvitationByTokenHash", utils.HashToken("token")).Return(invitation, nil)
+	userRepo.On("FindByID", uint(3)).Return(helpers.CreateTestUser(3, "jane@acme.com"), nil)
+	orgRepo.On("FindByID", orgID).Return(helpers.CreateTestOrganization(orgID, domain.Plan{ID: 1}), nil)
+	userRepo.On("CountActiveByOrganization", orgID).Return(int64(1), nil)
+	orgRepo.On("AcceptInvitation", invitation, uint(3)).Return(nil)
+	onboardingRepo.On("CompleteStep", uint(3), domain.OnboardingStepEmailVerified, mock.AnythingOfType("time.Time")).Return(nil)
+
+	_, err := orgService.AcceptInvitation(3, "token")
+
+	require.NoError(t, err)
+	onboardingRepo.AssertExpectations(t)
+}
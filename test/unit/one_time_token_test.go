@@ -0,0 +1,182 @@
+package unit
+
+import (
+	"gojwt-rest-api/internal/domain"
+	"gojwt-rest-api/internal/repository"
+	"gojwt-rest-api/internal/service"
+	"gojwt-rest-api/internal/utils"
+	"gojwt-rest-api/test/helpers"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// resetPayload is the payload of a hypothetical password reset flow
+type resetPayload struct {
+	UserID uint `json:"user_id"`
+}
+
+func TestOneTimeTokenService_Issue(t *testing.T) {
+	tokenRepo := new(helpers.MockOneTimeTokenRepository)
+	tokenService := service.NewOneTimeTokenService(tokenRepo)
+
+	tokenRepo.On("InvalidateSubject", "password_reset", "jane@example.com", mock.AnythingOfType("time.Time")).Return(nil)
+	tokenRepo.On("Create", mock.AnythingOfType("*domain.OneTimeToken")).Return(nil)
+
+	token, record, err := tokenService.Issue("password_reset", "jane@example.com", &resetPayload{UserID: 7}, time.Hour)
+
+	require.NoError(t, err)
+	assert.NotEmpty(t, token)
+	assert.Equal(t, utils.HashToken(token), record.TokenHash)
+	assert.NotEqual(t, token, record.TokenHash)
+	assert.Equal(t, `{"user_id":7}`, record.Payload)
+	assert.WithinDuration(t, time.Now().Add(time.Hour), record.ExpiresAt, time.Second)
+	assert.True(t, record.IsUsable(time.Now()))
+	tokenRepo.AssertExpectations(t)
+}
+
+func TestOneTimeTokenService_Consume(t *testing.T) {
+	t.Run("Decodes the payload of a consumed token", func(t *testing.T) {
+		tokenRepo := new(helpers.MockOneTimeTokenRepository)
+		tokenService := service.NewOneTimeTokenService(tokenRepo)
+		tokenRepo.On("Consume", "password_reset", utils.HashToken("token"), mock.AnythingOfType("time.Time")).
+			Return(&domain.OneTimeToken{ID: 1, Purpose: "password_reset", Payload: `{"user_id":7}`}, nil)
+
+		var payload resetPayload
+		record, err := tokenService.Consume("password_reset", "token", &payload)
+
+		require.NoError(t, err)
+		assert.Equal(t, uint(1), record.ID)
+		assert.Equal(t, uint(7), payload.UserID)
+	})
+
+	t.Run("Unknown tokens are invalid", func(t *testing.T) {
+		tokenRepo := new(helpers.MockOneTimeTokenRepository)
+		tokenService := service.NewOneTimeTokenService(tokenRepo)
+		tokenRepo.On("Consume", "password_reset", mock.Anything, mock.Anything).Return(nil, domain.ErrOneTimeTokenNotFound)
+
+		_, err := tokenService.Consume("password_reset", "unknown", nil)
+
+		assert.Equal(t, domain.ErrOneTimeTokenInvalid, err)
+	})
+}
+
+func TestOneTimeTokenRepository_Consume(t *testing.T) {
+	columns := []string{"id", "purpose", "subject", "token_hash", "payload", "expires_at", "consumed_at"}
+	selectForUpdate := "SELECT \\* FROM `one_time_tokens` WHERE purpose = \\? AND token_hash = \\? .*FOR UPDATE"
+	now := time.Now()
+
+	t.Run("Locks the row and marks the token consumed", func(t *testing.T) {
+		db, sqlMock := setupTokenMockDB(t)
+		repo := repository.NewOneTimeTokenRepository(db)
+
+		sqlMock.ExpectBegin()
+		sqlMock.ExpectQuery(selectForUpdate).
+			WithArgs("password_reset", "hash", 1).
+			WillReturnRows(sqlmock.NewRows(columns).AddRow(1, "password_reset", "jane@example.com", "hash", "", now.Add(time.Hour), nil))
+		sqlMock.ExpectExec("UPDATE `one_time_tokens` SET `consumed_at`=\\?").
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		sqlMock.ExpectCommit()
+
+		token, err := repo.Consume("password_reset", "hash", now)
+
+		require.NoError(t, err)
+		assert.Equal(t, now, *token.ConsumedAt)
+		assert.NoError(t, sqlMock.ExpectationsWereMet())
+	})
+
+	t.Run("Consumed tokens are rejected", func(t *testing.T) {
+		db, sqlMock := setupTokenMockDB(t)
+		repo := repository.NewOneTimeTokenRepository(db)
+
+		sqlMock.ExpectBegin()
+		sqlMock.ExpectQuery(selectForUpdate).
+			WillReturnRows(sqlmock.NewRows(columns).AddRow(1, "password_reset", "jane@example.com", "hash", "", now.Add(time.Hour), now.Add(-time.Minute)))
+		sqlMock.ExpectRollback()
+
+		_, err := repo.Consume("password_reset", "hash", now)
+
+		assert.Equal(t, domain.ErrOneTimeTokenInvalid, err)
+		assert.NoError(t, sqlMock.ExpectationsWereMet())
+	})
+
+	t.Run("Expired tokens are rejected", func(t *testing.T) {
+		db, sqlMock := setupTokenMockDB(t)
+		repo := repository.NewOneTimeTokenRepository(db)
+
+		sqlMock.ExpectBegin()
+		sqlMock.ExpectQuery(selectForUpdate).
+			WillReturnRows(sqlmock.NewRows(columns).AddRow(1, "password_reset", "jane@example.com", "hash", "", now.Add(-time.Minute), nil))
+		sqlMock.ExpectRollback()
+
+		_, err := repo.Consume("password_reset", "hash", now)
+
+		assert.Equal(t, domain.ErrOneTimeTokenInvalid, err)
+		assert.NoError(t, sqlMock.ExpectationsWereMet())
+	})
+}
+
+func TestOrganizationRepository_AcceptInvitation(t *testing.T) {
+	columns := []string{"id", "purpose", "subject", "resource_id", "token_hash", "payload", "expires_at", "consumed_at"}
+	selectForUpdate := "SELECT \\* FROM `one_time_tokens` WHERE purpose = \\? AND token_hash = \\? .*FOR UPDATE"
+	now := time.Now()
+	invitation := &domain.OrganizationInvitation{ID: 4, OrganizationID: 5, Email: "jane@acme.com", Role: domain.OrgRoleMember,
+		TokenHash: "hash", ExpiresAt: now.Add(time.Hour), AcceptedAt: &now}
+
+	t.Run("Consumes the token and adds the member", func(t *testing.T) {
+		db, sqlMock := setupTokenMockDB(t)
+		repo := repository.NewOrganizationRepository(db)
+
+		sqlMock.ExpectBegin()
+		sqlMock.ExpectQuery(selectForUpdate).
+			WithArgs(domain.OneTimeTokenPurposeInvitation, "hash", 1).
+			WillReturnRows(sqlmock.NewRows(columns).AddRow(4, domain.OneTimeTokenPurposeInvitation, "jane@acme.com", 5, "hash", `{"role":"member"}`, now.Add(time.Hour), nil))
+		sqlMock.ExpectExec("UPDATE `one_time_tokens` SET `consumed_at`=\\?").
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		sqlMock.ExpectExec("UPDATE `users` SET").
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		sqlMock.ExpectCommit()
+
+		require.NoError(t, repo.AcceptInvitation(invitation, 3))
+		assert.NoError(t, sqlMock.ExpectationsWereMet())
+	})
+
+	t.Run("Accepted invitations are rejected", func(t *testing.T) {
+		db, sqlMock := setupTokenMockDB(t)
+		repo := repository.NewOrganizationRepository(db)
+
+		sqlMock.ExpectBegin()
+		sqlMock.ExpectQuery(selectForUpdate).
+			WillReturnRows(sqlmock.NewRows(columns).AddRow(4, domain.OneTimeTokenPurposeInvitation, "jane@acme.com", 5, "hash", `{"role":"member"}`, now.Add(time.Hour), now.Add(-time.Minute)))
+		sqlMock.ExpectRollback()
+
+		assert.Equal(t, domain.ErrInvitationExpired, repo.AcceptInvitation(invitation, 3))
+		assert.NoError(t, sqlMock.ExpectationsWereMet())
+	})
+}
+
+func TestOrganizationInvitation_OneTimeToken(t *testing.T) {
+	invitation := &domain.OrganizationInvitation{
+		ID:             4,
+		OrganizationID: 5,
+		Email:          "jane@acme.com",
+		Role:           domain.OrgRoleAdmin,
+		TokenHash:      utils.HashToken("token"),
+		InvitedByID:    1,
+		ExpiresAt:      time.Now().Add(time.Hour),
+	}
+
+	token, err := invitation.ToOneTimeToken()
+	require.NoError(t, err)
+	assert.Equal(t, domain.OneTimeTokenPurposeInvitation, token.Purpose)
+	assert.Equal(t, "jane@acme.com", token.Subject)
+	assert.Equal(t, uint(5), *token.ResourceID)
+
+	restored, err := domain.InvitationFromOneTimeToken(token)
+	require.NoError(t, err)
+	assert.Equal(t, invitation, restored)
+}
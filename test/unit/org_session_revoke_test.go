@@ -0,0 +1,110 @@
+package unit
+
+import (
+	"gojwt-rest-api/internal/domain"
+	"gojwt-rest-api/internal/events"
+	"gojwt-rest-api/internal/repository"
+	"gojwt-rest-api/internal/service"
+	"gojwt-rest-api/test/factory"
+	"gojwt-rest-api/test/helpers"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOrganizationService_RevokeSessions(t *testing.T) {
+	orgID := uint(5)
+	org := helpers.CreateTestOrganization(orgID, domain.Plan{ID: 1})
+
+	t.Run("Admins log out every member", func(t *testing.T) {
+		orgRepo := new(helpers.MockOrganizationRepository)
+		userRepo := new(helpers.MockUserRepository)
+		auditRepo := new(helpers.MockAuditLogRepository)
+		publisher := new(helpers.MockEventPublisher)
+		orgService := service.NewOrganizationService(orgRepo, userRepo, service.NewQuotaService(orgRepo, userRepo, time.Minute),
+			service.WithOrganizationEventPublisher(publisher),
+			service.WithOrganizationAuditService(service.NewAuditService(auditRepo)))
+
+		userRepo.On("FindByID", uint(1)).Return(createTestMember(1, "admin@acme.com", orgID, domain.OrgRoleAdmin), nil)
+		orgRepo.On("FindByID", orgID).Return(org, nil)
+		orgRepo.On("RevokeSessions", orgID, mock.AnythingOfType("time.Time")).
+			Return(&domain.OrganizationSessionRevocation{OrganizationID: orgID, Members: 3, RefreshTokens: 7, RevokedAt: time.Now()}, nil)
+		auditRepo.On("Create", mock.MatchedBy(func(l *domain.AuditLog) bool {
+			return l.Action == domain.AuditOrgSessionsRevoked && *l.OrganizationID == orgID && *l.ActorID == 1
+		})).Return(nil)
+		publisher.On("Publish", events.OrganizationSessionsRevoked, mock.MatchedBy(func(d *events.OrganizationSessionsRevokedData) bool {
+			return d.OrganizationID == orgID && d.ActorID == 1 && d.Members == 3
+		})).Return()
+
+		revocation, err := orgService.RevokeSessions(1, orgID)
+
+		require.NoError(t, err)
+		assert.Equal(t, int64(3), revocation.Members)
+		assert.Equal(t, int64(7), revocation.RefreshTokens)
+		auditRepo.AssertExpectations(t)
+		publisher.AssertExpectations(t)
+	})
+
+	t.Run("Members cannot log out the organization", func(t *testing.T) {
+		orgRepo := new(helpers.MockOrganizationRepository)
+		userRepo := new(helpers.MockUserRepository)
+		orgService := service.NewOrganizationService(orgRepo, userRepo, service.NewQuotaService(orgRepo, userRepo, time.Minute))
+
+		userRepo.On("FindByID", uint(2)).Return(createTestMember(2, "member@acme.com", orgID, domain.OrgRoleMember), nil)
+
+		_, err := orgService.RevokeSessions(2, orgID)
+
+		assert.Equal(t, domain.ErrOrganizationForbidden, err)
+		orgRepo.AssertNotCalled(t, "RevokeSessions", mock.Anything, mock.Anything)
+	})
+}
+
+func TestOrganizationRepository_RevokeSessions(t *testing.T) {
+	db, sqlMock := setupTokenMockDB(t)
+	repo := repository.NewOrganizationRepository(db)
+	now := time.Now()
+
+	sqlMock.ExpectBegin()
+	sqlMock.ExpectExec("UPDATE `users` SET `session_epoch`=session_epoch \\+ 1 WHERE organization_id = \\?").
+		WillReturnResult(sqlmock.NewResult(0, 3))
+	sqlMock.ExpectExec("UPDATE `refresh_tokens` SET .* WHERE is_revoked = \\? AND user_id IN \\(SELECT `id` FROM `users` WHERE organization_id = \\?").
+		WillReturnResult(sqlmock.NewResult(0, 7))
+	sqlMock.ExpectCommit()
+
+	revocation, err := repo.RevokeSessions(5, now)
+
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), revocation.Members)
+	assert.Equal(t, int64(7), revocation.RefreshTokens)
+	assert.NoError(t, sqlMock.ExpectationsWereMet())
+}
+
+func TestUserService_SessionRevoked(t *testing.T) {
+	userRepo := helpers.NewMemoryUserRepository()
+	user := factory.New().User()
+	require.NoError(t, userRepo.Create(user))
+	userService := service.NewUserService(userRepo, helpers.NewMemoryTokenRepository(), factory.DefaultSecret, time.Minute, time.Hour)
+
+	revoked, err := userService.SessionRevoked(user.ID, 0)
+	require.NoError(t, err)
+	assert.False(t, revoked)
+
+	// A forced logout bumps the epoch past the tokens issued so far
+	user.SessionEpoch = 1
+	require.NoError(t, userRepo.Update(user))
+
+	revoked, err = userService.SessionRevoked(user.ID, 0)
+	require.NoError(t, err)
+	assert.True(t, revoked)
+	revoked, err = userService.SessionRevoked(user.ID, 1)
+	require.NoError(t, err)
+	assert.False(t, revoked)
+
+	revoked, err = userService.SessionRevoked(user.ID+1, 0)
+	require.NoError(t, err)
+	assert.True(t, revoked, "tokens of deleted users are revoked")
+}
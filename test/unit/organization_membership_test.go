@@ -0,0 +1,239 @@
+package unit
+
+import (
+	"gojwt-rest-api/internal/domain"
+	"gojwt-rest-api/internal/events"
+	"gojwt-rest-api/internal/service"
+	"gojwt-rest-api/internal/utils"
+	"gojwt-rest-api/test/helpers"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// createTestMember creates a user belonging to the organization with the given role
+func createTestMember(id uint, email string, orgID uint, role string) *domain.User {
+	user := helpers.CreateTestUser(id, email)
+	user.OrganizationID = &orgID
+	user.OrganizationRole = role
+	return user
+}
+
+func TestOrganizationService_InviteMember(t *testing.T) {
+	orgID := uint(5)
+	org := helpers.CreateTestOrganization(orgID, domain.Plan{ID: 1})
+
+	t.Run("Admins invite and the invitee is emailed the token", func(t *testing.T) {
+		orgRepo := new(helpers.MockOrganizationRepository)
+		userRepo := new(helpers.MockUserRepository)
+		publisher := new(helpers.MockEventPublisher)
+		orgService := service.NewOrganizationService(orgRepo, userRepo, service.NewQuotaService(orgRepo, userRepo, time.Minute),
+			service.WithOrganizationEventPublisher(publisher))
+
+		userRepo.On("FindByID", uint(1)).Return(createTestMember(1, "owner@acme.com", orgID, domain.OrgRoleAdmin), nil)
+		orgRepo.On("FindByID", orgID).Return(org, nil)
+		userRepo.On("FindByEmail", "jane@acme.com").Return(nil, domain.ErrUserNotFound)
+		orgRepo.On("FindPendingInvitation", orgID, "jane@acme.com").Return(nil, domain.ErrInvitationNotFound)
+		orgRepo.On("CreateInvitation", mock.AnythingOfType("*domain.OrganizationInvitation")).Return(nil)
+
+		var token string
+		publisher.On("Publish", events.OrganizationInvitationCreated, mock.Anything).Run(func(args mock.Arguments) {
+			token = args.Get(1).(*events.OrganizationInvitationData).Token
+		}).Return()
+
+		invitation, err := orgService.InviteMember(1, orgID, &domain.InviteMemberRequest{Email: "Jane@acme.com"})
+
+		require.NoError(t, err)
+		assert.Equal(t, "jane@acme.com", invitation.Email)
+		assert.Equal(t, domain.OrgRoleMember, invitation.Role)
+		assert.NotEmpty(t, token)
+		assert.Equal(t, utils.HashToken(token), invitation.TokenHash)
+	})
+
+	t.Run("Members cannot invite", func(t *testing.T) {
+		orgRepo := new(helpers.MockOrganizationRepository)
+		userRepo := new(helpers.MockUserRepository)
+		orgService := service.NewOrganizationService(orgRepo, userRepo, service.NewQuotaService(orgRepo, userRepo, time.Minute))
+
+		userRepo.On("FindByID", uint(2)).Return(createTestMember(2, "member@acme.com", orgID, domain.OrgRoleMember), nil)
+
+		_, err := orgService.InviteMember(2, orgID, &domain.InviteMemberRequest{Email: "jane@acme.com"})
+
+		assert.Equal(t, domain.ErrOrganizationForbidden, err)
+		orgRepo.AssertNotCalled(t, "CreateInvitation", mock.Anything)
+	})
+}
+
+func TestOrganizationService_AcceptInvitation(t *testing.T) {
+	orgID := uint(5)
+	token := "invitation-token"
+	newInvitation := func(expiresAt time.Time) *domain.OrganizationInvitation {
+		return &domain.OrganizationInvitation{
+			ID:             9,
+			OrganizationID: orgID,
+			Email:          "jane@acme.com",
+			Role:           domain.OrgRoleMember,
+			TokenHash:      utils.HashToken(token),
+			ExpiresAt:      expiresAt,
+			Organization:   *helpers.CreateTestOrganization(orgID, domain.Plan{ID: 1}),
+		}
+	}
+
+	t.Run("Invitee joins the organization", func(t *testing.T) {
+		orgRepo := new(helpers.MockOrganizationRepository)
+		userRepo := new(helpers.MockUserRepository)
+		orgService := service.NewOrganizationService(orgRepo, userRepo, service.NewQuotaService(orgRepo, userRepo, time.Minute))
+
+		invitation := newInvitation(time.Now().Add(time.Hour))
+		orgRepo.On("FindInvitationByTokenHash", utils.HashToken(token)).Return(invitation, nil)
+		orgRepo.On("FindByID", orgID).Return(&invitation.Organization, nil)
+		userRepo.On("FindByID", uint(3)).Return(helpers.CreateTestUser(3, "Jane@acme.com"), nil)
+		orgRepo.On("AcceptInvitation", invitation, uint(3)).Return(nil)
+
+		org, err := orgService.AcceptInvitation(3, token)
+
+		require.NoError(t, err)
+		assert.Equal(t, orgID, org.ID)
+		assert.NotNil(t, invitation.AcceptedAt)
+	})
+
+	t.Run("Rejects invitations sent to another address", func(t *testing.T) {
+		orgRepo := new(helpers.MockOrganizationRepository)
+		userRepo := new(helpers.MockUserRepository)
+		orgService := service.NewOrganizationService(orgRepo, userRepo, service.NewQuotaService(orgRepo, userRepo, time.Minute))
+
+		orgRepo.On("FindInvitationByTokenHash", utils.HashToken(token)).Return(newInvitation(time.Now().Add(time.Hour)), nil)
+		userRepo.On("FindByID", uint(4)).Return(helpers.CreateTestUser(4, "mallory@example.com"), nil)
+
+		_, err := orgService.AcceptInvitation(4, token)
+
+		assert.Equal(t, domain.ErrInvitationEmailMismatch, err)
+		orgRepo.AssertNotCalled(t, "AcceptInvitation", mock.Anything, mock.Anything)
+	})
+
+	t.Run("Rejects expired invitations", func(t *testing.T) {
+		orgRepo := new(helpers.MockOrganizationRepository)
+		userRepo := new(helpers.MockUserRepository)
+		orgService := service.NewOrganizationService(orgRepo, userRepo, service.NewQuotaService(orgRepo, userRepo, time.Minute))
+
+		orgRepo.On("FindInvitationByTokenHash", utils.HashToken(token)).Return(newInvitation(time.Now().Add(-time.Minute)), nil)
+
+		_, err := orgService.AcceptInvitation(3, token)
+
+		assert.Equal(t, domain.ErrInvitationExpired, err)
+	})
+}
+
+func TestUserService_RegisterWithoutSelfRegistration(t *testing.T) {
+	token := "invitation-token"
+	invitation := &domain.OrganizationInvitation{
+		OrganizationID: 5,
+		Email:          "jane@acme.com",
+		TokenHash:      utils.HashToken(token),
+		ExpiresAt:      time.Now().Add(time.Hour),
+	}
+	newService := func(members int64) (service.UserService, *helpers.MockUserRepository) {
+		orgRepo := new(helpers.MockOrganizationRepository)
+		userRepo := new(helpers.MockUserRepository)
+		orgRepo.On("FindInvitationByTokenHash", utils.HashToken(token)).Return(invitation, nil)
+		orgRepo.On("FindInvitationByTokenHash", mock.Anything).Return(nil, domain.ErrInvitationNotFound)
+		orgRepo.On("FindByID", uint(5)).Return(helpers.CreateTestOrganization(5, domain.Plan{ID: 1, MaxActiveUsers: 2}), nil)
+		orgRepo.On("CountMembers", uint(5)).Return(members, nil)
+		orgService := service.NewOrganizationService(orgRepo, userRepo, service.NewQuotaService(orgRepo, userRepo, time.Minute))
+		userService := service.NewUserService(userRepo, new(helpers.MockTokenRepository), "test-secret", 15*time.Minute, 7*24*time.Hour,
+			service.WithSelfRegistrationDisabled(orgService))
+		return userService, userRepo
+	}
+
+	t.Run("Invited users register", func(t *testing.T) {
+		userService, userRepo := newService(1)
+		userRepo.On("FindByEmail", "Jane@acme.com").Return(nil, domain.ErrUserNotFound)
+		userRepo.On("Create", mock.AnythingOfType("*domain.User")).Return(nil)
+
+		user, err := userService.Register(&domain.RegisterRequest{Name: "Jane Roe", Email: "Jane@acme.com", Password: "password123", InvitationToken: token})
+
+		require.NoError(t, err)
+		assert.Nil(t, user.OrganizationID, "the invitation is accepted separately")
+	})
+
+	t.Run("Rejects invited users when the organization is full", func(t *testing.T) {
+		userService, userRepo := newService(2)
+
+		_, err := userService.Register(&domain.RegisterRequest{Name: "Jane Roe", Email: "jane@acme.com", Password: "password123", InvitationToken: token})
+
+		assert.Equal(t, domain.ErrUserQuotaExceeded, err)
+		userRepo.AssertNotCalled(t, "Create", mock.Anything)
+	})
+
+	t.Run("Rejects registrations without a valid invitation", func(t *testing.T) {
+		userService, userRepo := newService(1)
+
+		_, err := userService.Register(&domain.RegisterRequest{Name: "Jane Roe", Email: "jane@acme.com", Password: "password123"})
+		assert.Equal(t, domain.ErrSelfRegistrationDisabled, err)
+
+		_, err = userService.Register(&domain.RegisterRequest{Name: "Jane Roe", Email: "jane@acme.com", Password: "password123", InvitationToken: "guessed"})
+		assert.Equal(t, domain.ErrInvitationNotFound, err)
+
+		_, err = userService.Register(&domain.RegisterRequest{Name: "Mallory", Email: "mallory@example.com", Password: "password123", InvitationToken: token})
+		assert.Equal(t, domain.ErrInvitationEmailMismatch, err)
+
+		userRepo.AssertNotCalled(t, "Create", mock.Anything)
+	})
+
+	t.Run("Rejects every registration without invitations", func(t *testing.T) {
+		userRepo := new(helpers.MockUserRepository)
+		userService := service.NewUserService(userRepo, new(helpers.MockTokenRepository), "test-secret", 15*time.Minute, 7*24*time.Hour,
+			service.WithSelfRegistrationDisabled(nil))
+
+		_, err := userService.Register(&domain.RegisterRequest{Name: "Jane Roe", Email: "jane@acme.com", Password: "password123", InvitationToken: token})
+
+		assert.Equal(t, domain.ErrSelfRegistrationDisabled, err)
+		userRepo.AssertNotCalled(t, "FindByEmail", mock.Anything)
+	})
+}
+
+func TestOrganizationService_RemoveMember(t *testing.T) {
+	orgID := uint(5)
+	org := helpers.CreateTestOrganization(orgID, domain.Plan{ID: 1})
+	owner := createTestMember(1, "owner@acme.com", orgID, domain.OrgRoleOwner)
+	admin := createTestMember(2, "admin@acme.com", orgID, domain.OrgRoleAdmin)
+	otherAdmin := createTestMember(3, "admin2@acme.com", orgID, domain.OrgRoleAdmin)
+
+	newService := func() (service.OrganizationService, *helpers.MockOrganizationRepository) {
+		orgRepo := new(helpers.MockOrganizationRepository)
+		userRepo := new(helpers.MockUserRepository)
+		for _, user := range []*domain.User{owner, admin, otherAdmin} {
+			userRepo.On("FindByID", user.ID).Return(user, nil)
+		}
+		orgRepo.On("FindByID", orgID).Return(org, nil)
+		return service.NewOrganizationService(orgRepo, userRepo, service.NewQuotaService(orgRepo, userRepo, time.Minute)), orgRepo
+	}
+
+	t.Run("Owner cannot be removed", func(t *testing.T) {
+		orgService, _ := newService()
+		assert.Equal(t, domain.ErrCannotRemoveOwner, orgService.RemoveMember(2, orgID, 1))
+	})
+
+	t.Run("Admins cannot remove other admins", func(t *testing.T) {
+		orgService, _ := newService()
+		assert.Equal(t, domain.ErrOrganizationForbidden, orgService.RemoveMember(2, orgID, 3))
+	})
+
+	t.Run("Owner removes admins", func(t *testing.T) {
+		orgService, orgRepo := newService()
+		orgRepo.On("RemoveMember", orgID, uint(3)).Return(nil)
+
+		assert.NoError(t, orgService.RemoveMember(1, orgID, 3))
+	})
+
+	t.Run("Only the owner transfers ownership", func(t *testing.T) {
+		orgService, orgRepo := newService()
+		orgRepo.On("TransferOwnership", orgID, uint(2)).Return(nil)
+
+		assert.Equal(t, domain.ErrOrganizationForbidden, orgService.TransferOwnership(3, orgID, 2))
+		assert.NoError(t, orgService.TransferOwnership(1, orgID, 2))
+	})
+}
@@ -0,0 +1,151 @@
+package unit
+
+import (
+	"gojwt-rest-api/internal/domain"
+	"gojwt-rest-api/internal/events"
+	"gojwt-rest-api/internal/service"
+	"gojwt-rest-api/internal/utils"
+	"gojwt-rest-api/test/factory"
+	"gojwt-rest-api/test/helpers"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// passwordResetFixture is a password reset service on in-memory
+// repositories with a logged-in user
+type passwordResetFixture struct {
+	userService   service.UserService
+	passwordReset service.PasswordResetService
+	userRepo      *helpers.MemoryUserRepository
+	publisher     *helpers.MockEventPublisher
+	user          *domain.User
+	login         *domain.LoginResponse
+}
+
+func newPasswordResetFixture(t *testing.T) *passwordResetFixture {
+	t.Helper()
+	f := &passwordResetFixture{
+		userRepo:  helpers.NewMemoryUserRepository(),
+		publisher: new(helpers.MockEventPublisher),
+		user:      factory.New().User(factory.WithEmail("jane@example.com")),
+	}
+	require.NoError(t, f.userRepo.Create(f.user))
+	f.publisher.On("Publish", mock.Anything, mock.Anything).Return()
+	auditRepo := new(helpers.MockAuditLogRepository)
+	auditRepo.On("Create", mock.AnythingOfType("*domain.AuditLog")).Return(nil)
+	tokenRepo := helpers.NewMemoryTokenRepository()
+
+	f.passwordReset = service.NewPasswordResetService(f.userRepo, tokenRepo,
+		service.NewOneTimeTokenService(helpers.NewMemoryOneTimeTokenRepository()), service.NewAuditService(auditRepo), time.Hour,
+		service.WithPasswordResetEventPublisher(f.publisher),
+	)
+	f.userService = service.NewUserService(f.userRepo, tokenRepo, factory.DefaultSecret, time.Minute, time.Hour)
+	var err error
+	f.login, err = f.userService.Login(&domain.LoginRequest{Email: f.user.Email, Password: factory.DefaultPassword})
+	require.NoError(t, err)
+	return f
+}
+
+// resetTokens returns the tokens of the published reset links, oldest first
+func (f *passwordResetFixture) resetTokens() []string {
+	var tokens []string
+	for _, call := range f.publisher.Calls {
+		if data, ok := call.Arguments.Get(1).(*events.UserPasswordResetRequestedData); ok {
+			tokens = append(tokens, data.ResetToken)
+		}
+	}
+	return tokens
+}
+
+func TestPasswordReset_RequestReset(t *testing.T) {
+	t.Run("Publishes a reset link for a registered email", func(t *testing.T) {
+		f := newPasswordResetFixture(t)
+
+		require.NoError(t, f.passwordReset.RequestReset(f.user.Email))
+
+		require.Len(t, f.resetTokens(), 1)
+		f.publisher.AssertCalled(t, "Publish", events.UserPasswordResetRequested, mock.MatchedBy(func(data *events.UserPasswordResetRequestedData) bool {
+			return data.UserID == f.user.ID && data.Email == f.user.Email && data.ExpiresAt.After(time.Now())
+		}))
+	})
+
+	t.Run("Ignores unknown emails without error", func(t *testing.T) {
+		f := newPasswordResetFixture(t)
+
+		require.NoError(t, f.passwordReset.RequestReset("nobody@example.com"))
+
+		assert.Empty(t, f.resetTokens())
+	})
+
+	t.Run("Ignores locked users", func(t *testing.T) {
+		f := newPasswordResetFixture(t)
+		now := time.Now()
+		f.user.LockedAt = &now
+		require.NoError(t, f.userRepo.Update(f.user))
+
+		require.NoError(t, f.passwordReset.RequestReset(f.user.Email))
+
+		assert.Empty(t, f.resetTokens())
+	})
+}
+
+func TestPasswordReset_Reset(t *testing.T) {
+	t.Run("Replaces the password and ends the sessions", func(t *testing.T) {
+		f := newPasswordResetFixture(t)
+		require.NoError(t, f.passwordReset.RequestReset(f.user.Email))
+
+		user, err := f.passwordReset.Reset(&domain.ResetPasswordRequest{Token: f.resetTokens()[0], NewPassword: newPassword})
+
+		require.NoError(t, err)
+		assert.Equal(t, f.user.ID, user.ID)
+		_, err = f.userService.Login(&domain.LoginRequest{Email: f.user.Email, Password: factory.DefaultPassword})
+		assert.Equal(t, domain.ErrInvalidCredentials, err)
+		_, err = f.userService.Login(&domain.LoginRequest{Email: f.user.Email, Password: newPassword})
+		assert.NoError(t, err)
+		_, err = f.userService.RefreshToken(&domain.RefreshTokenRequest{RefreshToken: f.login.RefreshToken})
+		assert.Error(t, err)
+		claims, err := utils.ValidateToken(f.login.AccessToken, factory.DefaultSecret)
+		require.NoError(t, err)
+		revoked, err := f.userService.SessionRevoked(claims.UserID, claims.SessionEpoch)
+		require.NoError(t, err)
+		assert.True(t, revoked, "access tokens of the old password must be rejected")
+		f.publisher.AssertCalled(t, "Publish", events.UserPasswordReset, mock.AnythingOfType("*events.UserPasswordResetData"))
+	})
+
+	t.Run("Accepts a token once", func(t *testing.T) {
+		f := newPasswordResetFixture(t)
+		require.NoError(t, f.passwordReset.RequestReset(f.user.Email))
+		token := f.resetTokens()[0]
+
+		_, err := f.passwordReset.Reset(&domain.ResetPasswordRequest{Token: token, NewPassword: newPassword})
+		require.NoError(t, err)
+		_, err = f.passwordReset.Reset(&domain.ResetPasswordRequest{Token: token, NewPassword: "an0ther-passw0rd"})
+
+		assert.Equal(t, domain.ErrOneTimeTokenInvalid, err)
+	})
+
+	t.Run("Rejects links replaced by a newer request", func(t *testing.T) {
+		f := newPasswordResetFixture(t)
+		require.NoError(t, f.passwordReset.RequestReset(f.user.Email))
+		require.NoError(t, f.passwordReset.RequestReset(f.user.Email))
+		tokens := f.resetTokens()
+		require.Len(t, tokens, 2)
+
+		_, err := f.passwordReset.Reset(&domain.ResetPasswordRequest{Token: tokens[0], NewPassword: newPassword})
+		assert.Equal(t, domain.ErrOneTimeTokenInvalid, err)
+		_, err = f.passwordReset.Reset(&domain.ResetPasswordRequest{Token: tokens[1], NewPassword: newPassword})
+		assert.NoError(t, err)
+	})
+
+	t.Run("Rejects tokens of other flows", func(t *testing.T) {
+		f := newPasswordResetFixture(t)
+
+		_, err := f.passwordReset.Reset(&domain.ResetPasswordRequest{Token: "not-a-reset-token", NewPassword: newPassword})
+
+		assert.Equal(t, domain.ErrOneTimeTokenInvalid, err)
+	})
+}
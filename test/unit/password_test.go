@@ -1,9 +1,12 @@
 package unit
 
 import (
+	"gojwt-rest-api/internal/domain"
 	"gojwt-rest-api/internal/utils"
 	"strings"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -305,6 +308,45 @@ func TestMultiplePasswordHashing(t *testing.T) {
 	assert.Equal(t, iterations, len(hashes), "Should have generated unique hashes")
 }
 
+func TestPasswordLimiter(t *testing.T) {
+	password := "limited-password"
+	hash, err := utils.HashPassword(password)
+	require.NoError(t, err)
+
+	t.Run("Verifies password when a slot is free", func(t *testing.T) {
+		limiter := utils.NewPasswordLimiter(1, time.Second)
+
+		assert.NoError(t, limiter.CheckPassword(hash, password))
+		assert.Error(t, limiter.CheckPassword(hash, "wrong-password"))
+	})
+
+	t.Run("Rejects callers when the queue timeout elapses", func(t *testing.T) {
+		limiter := utils.NewPasswordLimiter(1, time.Millisecond)
+
+		const callers = 4
+		var wg sync.WaitGroup
+		errs := make([]error, callers)
+		for i := 0; i < callers; i++ {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				errs[i] = limiter.CheckPassword(hash, password)
+			}(i)
+		}
+		wg.Wait()
+
+		busy := 0
+		for _, err := range errs {
+			if err != nil {
+				assert.ErrorIs(t, err, domain.ErrPasswordCheckBusy)
+				busy++
+			}
+		}
+		assert.Greater(t, busy, 0, "Concurrent checks beyond the limit should be rejected")
+		assert.Less(t, busy, callers, "At least one check should succeed")
+	})
+}
+
 func TestPasswordValidationFailures(t *testing.T) {
 	correctPassword := "MySecretPassword123"
 	hash, err := utils.HashPassword(correctPassword)
@@ -0,0 +1,80 @@
+package unit
+
+import (
+	"encoding/base64"
+	"gojwt-rest-api/internal/pii"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testPIIKey(id string, b byte) string {
+	return id + ":" + base64.StdEncoding.EncodeToString([]byte(strings.Repeat(string(b), 32)))
+}
+
+var testBlindIndexKey = base64.StdEncoding.EncodeToString([]byte(strings.Repeat("i", 32)))
+
+func TestPIIKeyring(t *testing.T) {
+	t.Run("Encrypted values decrypt to the plaintext", func(t *testing.T) {
+		keyring, err := pii.NewKeyring(testPIIKey("v1", 'a'), nil, testBlindIndexKey)
+		require.NoError(t, err)
+
+		encrypted, err := keyring.Encrypt("john@example.com")
+		require.NoError(t, err)
+		again, _ := keyring.Encrypt("john@example.com")
+
+		assert.True(t, pii.IsEncrypted(encrypted))
+		assert.NotContains(t, encrypted, "john")
+		assert.NotEqual(t, encrypted, again)
+		decrypted, err := keyring.Decrypt(encrypted)
+		require.NoError(t, err)
+		assert.Equal(t, "john@example.com", decrypted)
+	})
+
+	t.Run("Plaintext values are returned unchanged", func(t *testing.T) {
+		keyring, _ := pii.NewKeyring(testPIIKey("v1", 'a'), nil, testBlindIndexKey)
+
+		decrypted, err := keyring.Decrypt("john@example.com")
+		require.NoError(t, err)
+		assert.Equal(t, "john@example.com", decrypted)
+	})
+
+	t.Run("Previous keys decrypt values until they are re-encrypted", func(t *testing.T) {
+		old, _ := pii.NewKeyring(testPIIKey("v1", 'a'), nil, testBlindIndexKey)
+		rotated, err := pii.NewKeyring(testPIIKey("v2", 'b'), []string{testPIIKey("v1", 'a')}, testBlindIndexKey)
+		require.NoError(t, err)
+		withoutOld, _ := pii.NewKeyring(testPIIKey("v2", 'b'), nil, testBlindIndexKey)
+
+		encrypted, _ := old.Encrypt("john@example.com")
+
+		decrypted, err := rotated.Decrypt(encrypted)
+		require.NoError(t, err)
+		assert.Equal(t, "john@example.com", decrypted)
+		assert.False(t, rotated.IsCurrent(encrypted))
+		_, err = withoutOld.Decrypt(encrypted)
+		assert.ErrorIs(t, err, pii.ErrUnknownKey)
+
+		reencrypted, _ := rotated.Encrypt(decrypted)
+		assert.True(t, rotated.IsCurrent(reencrypted))
+	})
+
+	t.Run("Blind index ignores case and does not change with the key", func(t *testing.T) {
+		keyring, _ := pii.NewKeyring(testPIIKey("v1", 'a'), nil, testBlindIndexKey)
+		rotated, _ := pii.NewKeyring(testPIIKey("v2", 'b'), nil, testBlindIndexKey)
+
+		assert.Equal(t, keyring.BlindIndex("john@example.com"), keyring.BlindIndex("John@Example.com"))
+		assert.Equal(t, keyring.BlindIndex("john@example.com"), rotated.BlindIndex("john@example.com"))
+		assert.NotEqual(t, keyring.BlindIndex("john@example.com"), keyring.BlindIndex("jane@example.com"))
+	})
+
+	t.Run("Invalid keys are rejected", func(t *testing.T) {
+		_, err := pii.NewKeyring("v1:short", nil, testBlindIndexKey)
+		assert.Error(t, err)
+		_, err = pii.NewKeyring(testPIIKey("v1", 'a'), []string{testPIIKey("v1", 'b')}, testBlindIndexKey)
+		assert.Error(t, err)
+		_, err = pii.NewKeyring(testPIIKey("v1", 'a'), nil, "")
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,163 @@
+package unit
+
+import (
+	"errors"
+	"gojwt-rest-api/internal/config"
+	"gojwt-rest-api/internal/domain"
+	"gojwt-rest-api/internal/events"
+	"gojwt-rest-api/internal/middleware"
+	"gojwt-rest-api/internal/plugin"
+	"gojwt-rest-api/internal/routecheck"
+	"gojwt-rest-api/internal/utils"
+	"gojwt-rest-api/pkg/logger"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testPlugin is a plugin registering with a function
+type testPlugin struct {
+	name     string
+	register func(host *plugin.Host) error
+}
+
+func (p *testPlugin) Name() string { return p.name }
+
+func (p *testPlugin) Register(host *plugin.Host) error {
+	if p.register == nil {
+		return nil
+	}
+	return p.register(host)
+}
+
+// claimsFunc is a claims enricher calling a function
+type claimsFunc func(user *domain.User) (utils.TokenOption, error)
+
+func (f claimsFunc) EnrichClaims(user *domain.User) (utils.TokenOption, error) { return f(user) }
+
+func newTestPluginHost() *plugin.Host {
+	appLogger := logger.New()
+	return plugin.NewHost(&config.Config{}, nil, appLogger, events.NewBus(appLogger))
+}
+
+func TestPlugin_Register(t *testing.T) {
+	t.Run("Lists plugins ordered by name", func(t *testing.T) {
+		plugin.Register(&testPlugin{name: "test-registry-b"})
+		plugin.Register(&testPlugin{name: "test-registry-a"})
+
+		var names []string
+		for _, p := range plugin.Registered() {
+			names = append(names, p.Name())
+		}
+		assert.Subset(t, names, []string{"test-registry-a", "test-registry-b"})
+		assert.IsIncreasing(t, names)
+	})
+
+	t.Run("Panics on duplicate and invalid names", func(t *testing.T) {
+		plugin.Register(&testPlugin{name: "test-registry-dup"})
+
+		assert.Panics(t, func() { plugin.Register(&testPlugin{name: "test-registry-dup"}) })
+		assert.Panics(t, func() { plugin.Register(&testPlugin{name: "Test Plugin"}) })
+		assert.Panics(t, func() { plugin.Register(&testPlugin{name: ""}) })
+	})
+}
+
+func TestPluginHost_Load(t *testing.T) {
+	t.Run("Collects the extensions of every plugin", func(t *testing.T) {
+		host := newTestPluginHost()
+		enricher := claimsFunc(func(user *domain.User) (utils.TokenOption, error) { return nil, nil })
+
+		err := host.Load(
+			&testPlugin{name: "first", register: func(h *plugin.Host) error {
+				h.Use(func(c *gin.Context) { c.Next() })
+				h.AddClaimsEnricher(enricher)
+				return nil
+			}},
+			&testPlugin{name: "second", register: func(h *plugin.Host) error {
+				h.Use(func(c *gin.Context) { c.Next() })
+				return nil
+			}},
+		)
+
+		require.NoError(t, err)
+		assert.Len(t, host.Middleware(), 2)
+		assert.Len(t, host.ClaimsEnrichers(), 1)
+	})
+
+	t.Run("Names the plugin that failed", func(t *testing.T) {
+		failure := errors.New("missing settings")
+
+		err := newTestPluginHost().Load(&testPlugin{name: "broken", register: func(h *plugin.Host) error {
+			return failure
+		}})
+
+		assert.ErrorIs(t, err, failure)
+		assert.EqualError(t, err, "plugin broken: missing settings")
+	})
+}
+
+func TestPluginHost_MountRoutes(t *testing.T) {
+	ok := func(c *gin.Context) { c.Status(http.StatusOK) }
+	host := newTestPluginHost()
+	require.NoError(t, host.Load(&testPlugin{name: "reports", register: func(h *plugin.Host) error {
+		h.AddRoutes(func(r *plugin.Router) {
+			r.Protected.GET("/summary", ok)
+			r.Admin.DELETE("/summary", ok)
+			r.PublicGET("/status", ok)
+		})
+		return nil
+	}}))
+
+	router := gin.New()
+	router.Use(routecheck.Middleware())
+	v1 := router.Group("/api/v1")
+	// Probes never reach the admin middleware, which needs no user service
+	public := host.MountRoutes(v1, plugin.RouteDeps{
+		Protected: []gin.HandlerFunc{middleware.AuthMiddleware("secret")},
+		Admin:     []gin.HandlerFunc{middleware.AdminMiddleware(nil)},
+	})
+
+	t.Run("Mounts routes under the plugin name", func(t *testing.T) {
+		var routes []string
+		for _, info := range router.Routes() {
+			routes = append(routes, info.Method+" "+info.Path)
+		}
+		assert.ElementsMatch(t, []string{
+			"GET /api/v1/plugins/reports/summary",
+			"DELETE /api/v1/admin/plugins/reports/summary",
+			"GET /api/v1/plugins/reports/status",
+		}, routes)
+		assert.Equal(t, []routecheck.Route{{Method: http.MethodGet, Path: "/api/v1/plugins/reports/status"}}, public)
+	})
+
+	t.Run("Passes the wiring check with public routes exempted", func(t *testing.T) {
+		rules := []routecheck.Rule{
+			{
+				Description: "authentication middleware is missing",
+				Match:       routecheck.PathPrefix("/api/v1", public...),
+				AnyOf:       []interface{}{middleware.AuthMiddleware},
+			},
+			{
+				Description: "admin middleware is missing",
+				Match:       routecheck.PathPrefix("/api/v1/admin"),
+				AnyOf:       []interface{}{middleware.AdminMiddleware},
+			},
+		}
+
+		assert.NoError(t, routecheck.Verify(router, rules, nil))
+	})
+
+	t.Run("Authenticates protected routes only", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/v1/plugins/reports/summary", nil))
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+
+		w = httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/v1/plugins/reports/status", nil))
+		assert.Equal(t, http.StatusOK, w.Code)
+	})
+}
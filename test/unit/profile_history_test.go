@@ -0,0 +1,139 @@
+package unit
+
+import (
+	"gojwt-rest-api/internal/domain"
+	"gojwt-rest-api/internal/service"
+	"gojwt-rest-api/test/helpers"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProfileSnapshot_Diff(t *testing.T) {
+	user := helpers.CreateTestUser(1, "john@example.com")
+	user.SetName("John Doe")
+	before := domain.SnapshotProfile(user)
+
+	t.Run("Lists changed fields only", func(t *testing.T) {
+		after := before
+		after.LastName = "Smith"
+		after.Email = "john.smith@example.com"
+
+		assert.Equal(t, []domain.ProfileFieldChange{
+			{Field: domain.ProfileFieldLastName, OldValue: "Doe", NewValue: "Smith"},
+			{Field: domain.ProfileFieldEmail, OldValue: "john@example.com", NewValue: "john.smith@example.com"},
+		}, before.Diff(after))
+	})
+
+	t.Run("Unchanged profiles have no changes", func(t *testing.T) {
+		assert.Empty(t, before.Diff(domain.SnapshotProfile(user)))
+	})
+}
+
+func TestProfileChange_ToOwnResponse(t *testing.T) {
+	userID, adminID := uint(1), uint(2)
+	change := &domain.ProfileChange{UserID: userID, Version: 3, Source: domain.ProfileChangeSourceAdmin, ActorID: &adminID}
+	require.NoError(t, change.SetFields([]domain.ProfileFieldChange{
+		{Field: domain.ProfileFieldFirstName, OldValue: "John", NewValue: "Johnny"},
+		{Field: domain.ProfileFieldEmail, OldValue: "john@example.com", NewValue: "johnny@example.org"},
+	}))
+
+	t.Run("Masks emails and does not identify admins", func(t *testing.T) {
+		response, err := change.ToOwnResponse()
+
+		require.NoError(t, err)
+		assert.Equal(t, uint(3), response.Version)
+		assert.Equal(t, domain.ProfileChangedByAdmin, response.ChangedBy)
+		assert.Equal(t, []domain.ProfileFieldChange{
+			{Field: domain.ProfileFieldFirstName, OldValue: "John", NewValue: "Johnny"},
+			{Field: domain.ProfileFieldEmail, OldValue: "j***@example.com", NewValue: "j***@example.org"},
+		}, response.Changes)
+	})
+
+	t.Run("Tells own and system changes apart", func(t *testing.T) {
+		own := &domain.ProfileChange{UserID: userID, Source: domain.ProfileChangeSourceSelf, ActorID: &userID, Changes: "[]"}
+		reverted := &domain.ProfileChange{UserID: userID, Source: domain.ProfileChangeSourceEmailRevert, Changes: "[]"}
+
+		ownResponse, err := own.ToOwnResponse()
+		require.NoError(t, err)
+		revertedResponse, err := reverted.ToOwnResponse()
+		require.NoError(t, err)
+
+		assert.Equal(t, domain.ProfileChangedBySelf, ownResponse.ChangedBy)
+		assert.Equal(t, domain.ProfileChangedBySystem, revertedResponse.ChangedBy)
+	})
+
+	t.Run("Admins see the full change", func(t *testing.T) {
+		response, err := change.ToResponse()
+
+		require.NoError(t, err)
+		assert.Equal(t, &adminID, response.ActorID)
+		assert.Equal(t, "john@example.com", response.Changes[1].OldValue)
+	})
+}
+
+func TestUserService_ProfileHistory(t *testing.T) {
+	newService := func() (*helpers.MockUserRepository, *helpers.MockProfileHistoryRepository, service.UserService) {
+		userRepo := new(helpers.MockUserRepository)
+		historyRepo := new(helpers.MockProfileHistoryRepository)
+		userService := service.NewUserService(userRepo, new(helpers.MockTokenRepository), "test-secret", 15*time.Minute, 7*24*time.Hour,
+			service.WithProfileHistory(service.NewProfileHistoryService(historyRepo)))
+		return userRepo, historyRepo, userService
+	}
+
+	t.Run("Records own profile changes", func(t *testing.T) {
+		userRepo, historyRepo, userService := newService()
+		user := helpers.CreateTestUser(1, "john@example.com")
+		user.SetName("John Doe")
+		userRepo.On("FindByID", uint(1)).Return(user, nil)
+		userRepo.On("FindByEmail", "johnny@example.com").Return(nil, domain.ErrUserNotFound)
+		userRepo.On("Update", mock.AnythingOfType("*domain.User")).Return(nil)
+		var recorded *domain.ProfileChange
+		historyRepo.On("Create", mock.AnythingOfType("*domain.ProfileChange")).
+			Run(func(args mock.Arguments) { recorded = args.Get(0).(*domain.ProfileChange) }).
+			Return(nil)
+
+		_, err := userService.UpdateOwnProfile(1, &domain.UpdateProfileRequest{FirstName: "Johnny", Email: "johnny@example.com"})
+
+		require.NoError(t, err)
+		require.NotNil(t, recorded)
+		assert.Equal(t, uint(1), recorded.UserID)
+		assert.Equal(t, uint(1), *recorded.ActorID)
+		assert.Equal(t, domain.ProfileChangeSourceSelf, recorded.Source)
+		fields, err := recorded.Fields()
+		require.NoError(t, err)
+		assert.Equal(t, []domain.ProfileFieldChange{
+			{Field: domain.ProfileFieldFirstName, OldValue: "John", NewValue: "Johnny"},
+			{Field: domain.ProfileFieldEmail, OldValue: "john@example.com", NewValue: "johnny@example.com"},
+		}, fields)
+	})
+
+	t.Run("Records the admin making changes", func(t *testing.T) {
+		userRepo, historyRepo, userService := newService()
+		userRepo.On("FindByID", uint(3)).Return(helpers.CreateTestUser(3, "jane@example.com"), nil)
+		userRepo.On("Update", mock.AnythingOfType("*domain.User")).Return(nil)
+		historyRepo.On("Create", mock.MatchedBy(func(change *domain.ProfileChange) bool {
+			return change.UserID == 3 && *change.ActorID == 1 && change.Source == domain.ProfileChangeSourceAdmin
+		})).Return(nil).Once()
+
+		_, err := userService.UpdateUser(1, 3, &domain.UpdateUserRequest{Name: "Jane Roe"})
+
+		require.NoError(t, err)
+		historyRepo.AssertExpectations(t)
+	})
+
+	t.Run("Saves without changes leave no version", func(t *testing.T) {
+		userRepo, historyRepo, userService := newService()
+		userRepo.On("FindByID", uint(1)).Return(helpers.CreateTestUser(1, "john@example.com"), nil)
+		userRepo.On("Update", mock.AnythingOfType("*domain.User")).Return(nil)
+		timezone := "Asia/Jakarta"
+
+		_, err := userService.UpdateOwnProfile(1, &domain.UpdateProfileRequest{Timezone: &timezone})
+
+		require.NoError(t, err)
+		historyRepo.AssertNotCalled(t, "Create", mock.Anything)
+	})
+}
@@ -0,0 +1,136 @@
+package unit
+
+import (
+	"gojwt-rest-api/internal/domain"
+	"gojwt-rest-api/internal/service"
+	"gojwt-rest-api/internal/utils"
+	"gojwt-rest-api/test/helpers"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestQuotaService_AllowRequest(t *testing.T) {
+	orgID := uint(5)
+	plan := domain.Plan{ID: 1, Name: "free", RequestsPerMinute: 3}
+
+	t.Run("Rejects requests beyond the plan limit", func(t *testing.T) {
+		orgRepo := new(helpers.MockOrganizationRepository)
+		userRepo := new(helpers.MockUserRepository)
+		quota := service.NewQuotaService(orgRepo, userRepo, time.Minute)
+
+		user := &domain.User{ID: 1, OrganizationID: &orgID}
+		userRepo.On("FindByID", uint(1)).Return(user, nil).Once()
+		orgRepo.On("FindByID", orgID).Return(helpers.CreateTestOrganization(orgID, plan), nil).Once()
+
+		for i := 0; i < plan.RequestsPerMinute; i++ {
+			_, err := quota.AllowRequest(1)
+			require.NoError(t, err)
+		}
+		retryAfter, err := quota.AllowRequest(1)
+
+		assert.Equal(t, domain.ErrRequestQuotaExceeded, err)
+		assert.True(t, retryAfter > 0 && retryAfter <= time.Minute)
+		// Organization and membership are served from cache after the first request
+		userRepo.AssertExpectations(t)
+		orgRepo.AssertExpectations(t)
+	})
+
+	t.Run("Users without an organization are not limited", func(t *testing.T) {
+		orgRepo := new(helpers.MockOrganizationRepository)
+		userRepo := new(helpers.MockUserRepository)
+		quota := service.NewQuotaService(orgRepo, userRepo, time.Minute)
+
+		userRepo.On("FindByID", uint(2)).Return(&domain.User{ID: 2}, nil)
+
+		_, err := quota.AllowRequest(2)
+
+		assert.NoError(t, err)
+		orgRepo.AssertNotCalled(t, "FindByID", mock.Anything)
+	})
+}
+
+func TestQuotaService_CheckSessionQuota(t *testing.T) {
+	orgID := uint(5)
+	orgRepo := new(helpers.MockOrganizationRepository)
+	userRepo := new(helpers.MockUserRepository)
+	quota := service.NewQuotaService(orgRepo, userRepo, time.Minute)
+
+	orgRepo.On("FindByID", orgID).Return(helpers.CreateTestOrganization(orgID, domain.Plan{ID: 1, MaxSessions: 2}), nil)
+	orgRepo.On("CountActiveSessions", orgID, mock.AnythingOfType("time.Time")).Return(int64(1), nil).Once()
+
+	// One session exists, so one more is allowed before the cached counter hits the limit
+	assert.NoError(t, quota.CheckSessionQuota(orgID))
+	assert.Equal(t, domain.ErrSessionQuotaExceeded, quota.CheckSessionQuota(orgID))
+	orgRepo.AssertExpectations(t)
+}
+
+func TestOrganizationService_AddMember(t *testing.T) {
+	orgID := uint(5)
+	orgRepo := new(helpers.MockOrganizationRepository)
+	userRepo := new(helpers.MockUserRepository)
+	quota := service.NewQuotaService(orgRepo, userRepo, time.Minute)
+	orgService := service.NewOrganizationService(orgRepo, userRepo, quota)
+
+	orgRepo.On("FindByID", orgID).Return(helpers.CreateTestOrganization(orgID, domain.Plan{ID: 1, MaxActiveUsers: 1}), nil)
+	orgRepo.On("CountMembers", orgID).Return(int64(0), nil).Once()
+	userRepo.On("FindByID", uint(1)).Return(&domain.User{ID: 1}, nil)
+	userRepo.On("FindByID", uint(2)).Return(&domain.User{ID: 2}, nil)
+	orgRepo.On("AddMember", orgID, uint(1), domain.OrgRoleMember).Return(nil).Once()
+
+	require.NoError(t, orgService.AddMember(orgID, 1, ""))
+	assert.Equal(t, domain.ErrUserQuotaExceeded, orgService.AddMember(orgID, 2, ""))
+	orgRepo.AssertExpectations(t)
+}
+
+func TestQuotaService_ReleaseUserQuota(t *testing.T) {
+	orgID := uint(5)
+	orgRepo := new(helpers.MockOrganizationRepository)
+	userRepo := new(helpers.MockUserRepository)
+	quota := service.NewQuotaService(orgRepo, userRepo, time.Minute)
+
+	orgRepo.On("FindByID", orgID).Return(helpers.CreateTestOrganization(orgID, domain.Plan{ID: 1, MaxActiveUsers: 1}), nil)
+	orgRepo.On("CountMembers", orgID).Return(int64(1), nil).Once()
+
+	assert.Equal(t, domain.ErrUserQuotaExceeded, quota.CheckUserQuotaAvailable(orgID))
+	quota.ReleaseUserQuota(orgID)
+	// The freed seat is available before the cached counter is reloaded
+	assert.NoError(t, quota.CheckUserQuotaAvailable(orgID))
+	assert.NoError(t, quota.CheckUserQuota(orgID))
+	assert.Equal(t, domain.ErrUserQuotaExceeded, quota.CheckUserQuota(orgID))
+	orgRepo.AssertExpectations(t)
+}
+
+func TestPlanClaimsEnricher(t *testing.T) {
+	orgID := uint(5)
+	orgRepo := new(helpers.MockOrganizationRepository)
+	enricher := service.NewPlanClaimsEnricher(orgRepo)
+
+	plan := domain.Plan{ID: 1, Name: "enterprise", Entitlements: "sso, audit_log"}
+	orgRepo.On("FindByID", orgID).Return(helpers.CreateTestOrganization(orgID, plan), nil)
+
+	t.Run("Embeds organization plan entitlements", func(t *testing.T) {
+		opt, err := enricher.EnrichClaims(&domain.User{ID: 1, OrganizationID: &orgID})
+		require.NoError(t, err)
+
+		token, err := utils.GenerateToken(1, "john@example.com", "test-secret", time.Hour, opt)
+		require.NoError(t, err)
+		claims, err := utils.ValidateToken(token, "test-secret")
+		require.NoError(t, err)
+
+		assert.Equal(t, orgID, claims.OrganizationID)
+		assert.Equal(t, "enterprise", claims.Plan)
+		assert.Equal(t, []string{"sso", "audit_log"}, claims.Entitlements)
+		assert.True(t, claims.HasEntitlement("sso"))
+	})
+
+	t.Run("Users without an organization get no extra claims", func(t *testing.T) {
+		opt, err := enricher.EnrichClaims(&domain.User{ID: 2})
+
+		assert.NoError(t, err)
+		assert.Nil(t, opt)
+	})
+}
@@ -0,0 +1,118 @@
+package unit
+
+import (
+	"gojwt-rest-api/internal/domain"
+	"gojwt-rest-api/internal/service"
+	"gojwt-rest-api/internal/utils"
+	"gojwt-rest-api/test/factory"
+	"gojwt-rest-api/test/helpers"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUserService_AuthTime(t *testing.T) {
+	userRepo := helpers.NewMemoryUserRepository()
+	user := factory.New().User()
+	require.NoError(t, userRepo.Create(user))
+	userService := service.NewUserService(userRepo, helpers.NewMemoryTokenRepository(), factory.DefaultSecret, time.Minute, time.Hour)
+
+	login, err := userService.Login(&domain.LoginRequest{Email: user.Email, Password: factory.DefaultPassword})
+	require.NoError(t, err)
+
+	t.Run("Login sets auth_time", func(t *testing.T) {
+		claims, err := utils.ValidateToken(login.AccessToken, factory.DefaultSecret)
+		require.NoError(t, err)
+		require.NotNil(t, claims.AuthTime)
+		assert.True(t, claims.AuthenticatedWithin(time.Minute))
+	})
+
+	t.Run("Refreshed tokens carry no auth_time", func(t *testing.T) {
+		refreshed, err := userService.RefreshToken(&domain.RefreshTokenRequest{RefreshToken: login.RefreshToken})
+		require.NoError(t, err)
+
+		claims, err := utils.ValidateToken(refreshed.AccessToken, factory.DefaultSecret)
+		require.NoError(t, err)
+		assert.Nil(t, claims.AuthTime)
+		assert.False(t, claims.AuthenticatedWithin(time.Hour))
+	})
+}
+
+func TestUserService_Reauthenticate(t *testing.T) {
+	f := factory.New()
+
+	newService := func(t *testing.T, users ...*domain.User) service.UserService {
+		userRepo := helpers.NewMemoryUserRepository()
+		for _, user := range users {
+			require.NoError(t, userRepo.Create(user))
+		}
+		return service.NewUserService(userRepo, helpers.NewMemoryTokenRepository(), factory.DefaultSecret, time.Minute, time.Hour)
+	}
+
+	t.Run("Password issues a short-lived token with a fresh auth_time", func(t *testing.T) {
+		user := f.User()
+		userService := newService(t, user)
+
+		response, err := userService.Reauthenticate(user.ID, "", &domain.ReauthenticateRequest{Password: factory.DefaultPassword})
+
+		require.NoError(t, err)
+		assert.Equal(t, int64(service.ReauthTokenTTL.Seconds()), response.ExpiresIn)
+		assert.WithinDuration(t, time.Now(), response.AuthTime, 2*time.Second)
+		claims, err := utils.ValidateToken(response.AccessToken, factory.DefaultSecret)
+		require.NoError(t, err)
+		assert.Equal(t, user.ID, claims.UserID)
+		assert.Equal(t, response.AuthTime.Unix(), claims.AuthTime.Unix())
+		assert.WithinDuration(t, time.Now().Add(service.ReauthTokenTTL), claims.ExpiresAt.Time, 2*time.Second)
+	})
+
+	t.Run("Wrong password is rejected", func(t *testing.T) {
+		user := f.User()
+		userService := newService(t, user)
+
+		_, err := userService.Reauthenticate(user.ID, "", &domain.ReauthenticateRequest{Password: "wrong-password"})
+
+		assert.Equal(t, domain.ErrInvalidCredentials, err)
+	})
+
+	t.Run("Deactivated users cannot reauthenticate", func(t *testing.T) {
+		user := f.User(factory.Deactivated())
+		userService := newService(t, user)
+
+		_, err := userService.Reauthenticate(user.ID, "", &domain.ReauthenticateRequest{Password: factory.DefaultPassword})
+
+		assert.Equal(t, domain.ErrUserDeactivated, err)
+	})
+
+	t.Run("Code is rejected without two-factor authentication", func(t *testing.T) {
+		user := f.User()
+		userService := newService(t, user)
+
+		_, err := userService.Reauthenticate(user.ID, "", &domain.ReauthenticateRequest{Code: "123456"})
+
+		assert.Equal(t, domain.ErrTwoFactorNotEnrolled, err)
+	})
+
+	t.Run("Two-factor code replaces the password", func(t *testing.T) {
+		userRepo := new(helpers.MockUserRepository)
+		twoFactor := service.NewTwoFactorService(userRepo, nil, "GoJWT", nil)
+		userService := service.NewUserService(userRepo, new(helpers.MockTokenRepository), factory.DefaultSecret, time.Minute, time.Hour,
+			service.WithTwoFactorService(twoFactor))
+
+		user := f.User()
+		now := time.Now()
+		code, err := utils.TOTPCode(rfc6238Secret, now)
+		require.NoError(t, err)
+		userRepo.On("FindByID", user.ID).Return(user, nil)
+		userRepo.On("FindTwoFactor", user.ID).Return(&domain.UserTwoFactor{UserID: user.ID, Secret: rfc6238Secret, ConfirmedAt: &now}, nil)
+		userRepo.On("UseTwoFactorStep", user.ID, mock.Anything).Return(true, nil)
+
+		response, err := userService.Reauthenticate(user.ID, "", &domain.ReauthenticateRequest{Code: code})
+
+		require.NoError(t, err)
+		assert.NotEmpty(t, response.AccessToken)
+		userRepo.AssertExpectations(t)
+	})
+}
@@ -0,0 +1,123 @@
+package unit
+
+import (
+	"gojwt-rest-api/internal/domain"
+	"gojwt-rest-api/internal/service"
+	"gojwt-rest-api/test/factory"
+	"gojwt-rest-api/test/helpers"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// These tests are meant to run with -race (make test-race)
+
+const rotationGoroutines = 32
+
+// newRotationService returns a user service on in-memory repositories with a
+// logged-in user
+func newRotationService(t *testing.T) (service.UserService, *domain.LoginResponse) {
+	t.Helper()
+	userRepo := helpers.NewMemoryUserRepository()
+	user := factory.New().User()
+	require.NoError(t, userRepo.Create(user))
+
+	userService := service.NewUserService(userRepo, helpers.NewMemoryTokenRepository(), factory.DefaultSecret, time.Minute, time.Hour)
+	login, err := userService.Login(&domain.LoginRequest{Email: user.Email, Password: factory.DefaultPassword})
+	require.NoError(t, err)
+	return userService, login
+}
+
+// refreshConcurrently presents each refresh token from its own goroutine,
+// released at the same time, and returns the results in order
+func refreshConcurrently(userService service.UserService, tokens []string) ([]*domain.RefreshTokenResponse, []error) {
+	responses := make([]*domain.RefreshTokenResponse, len(tokens))
+	errs := make([]error, len(tokens))
+	start := make(chan struct{})
+	var wg sync.WaitGroup
+	for i, token := range tokens {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			<-start
+			responses[i], errs[i] = userService.RefreshToken(&domain.RefreshTokenRequest{RefreshToken: token})
+		}()
+	}
+	close(start)
+	wg.Wait()
+	return responses, errs
+}
+
+func TestRefreshRotation_SameTokenConcurrently(t *testing.T) {
+	// Repeat to vary the interleavings
+	for round := 0; round < 10; round++ {
+		userService, login := newRotationService(t)
+		tokens := make([]string, rotationGoroutines)
+		for i := range tokens {
+			tokens[i] = login.RefreshToken
+		}
+
+		responses, errs := refreshConcurrently(userService, tokens)
+
+		var winner *domain.RefreshTokenResponse
+		for i, err := range errs {
+			if err == nil {
+				require.Nil(t, winner, "round %d: more than one refresh succeeded", round)
+				winner = responses[i]
+				continue
+			}
+			assert.Equal(t, domain.ErrTokenReused, err, "round %d", round)
+		}
+		require.NotNil(t, winner, "round %d: no refresh succeeded", round)
+
+		// The losers count as reuse, which revokes the family including the winner's token
+		_, err := userService.RefreshToken(&domain.RefreshTokenRequest{RefreshToken: winner.RefreshToken})
+		assert.Equal(t, domain.ErrTokenReused, err, "round %d", round)
+	}
+}
+
+func TestRefreshRotation_IndependentSessionsConcurrently(t *testing.T) {
+	userRepo := helpers.NewMemoryUserRepository()
+	user := factory.New().User()
+	require.NoError(t, userRepo.Create(user))
+	userService := service.NewUserService(userRepo, helpers.NewMemoryTokenRepository(), factory.DefaultSecret, time.Minute, time.Hour)
+
+	tokens := make([]string, rotationGoroutines)
+	for i := range tokens {
+		login, err := userService.Login(&domain.LoginRequest{Email: user.Email, Password: factory.DefaultPassword})
+		require.NoError(t, err)
+		tokens[i] = login.RefreshToken
+	}
+
+	// Rotating different sessions never conflicts, over several generations
+	for generation := 0; generation < 3; generation++ {
+		responses, errs := refreshConcurrently(userService, tokens)
+		for i, err := range errs {
+			require.NoError(t, err, "generation %d, session %d", generation, i)
+			tokens[i] = responses[i].RefreshToken
+		}
+	}
+}
+
+func TestRefreshRotation_RotatedTokenCannotBeReplayed(t *testing.T) {
+	userService, login := newRotationService(t)
+
+	refreshed, err := userService.RefreshToken(&domain.RefreshTokenRequest{RefreshToken: login.RefreshToken})
+	require.NoError(t, err)
+
+	// Replaying the rotated token from many goroutines all fail
+	tokens := make([]string, rotationGoroutines)
+	for i := range tokens {
+		tokens[i] = login.RefreshToken
+	}
+	_, errs := refreshConcurrently(userService, tokens)
+	for _, err := range errs {
+		assert.Equal(t, domain.ErrTokenReused, err)
+	}
+
+	_, err = userService.RefreshToken(&domain.RefreshTokenRequest{RefreshToken: refreshed.RefreshToken})
+	assert.Equal(t, domain.ErrTokenReused, err)
+}
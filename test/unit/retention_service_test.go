@@ -0,0 +1,159 @@
+package unit
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"gojwt-rest-api/internal/domain"
+	"gojwt-rest-api/internal/metrics"
+	"gojwt-rest-api/internal/service"
+	"gojwt-rest-api/test/helpers"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRetentionService_Purge(t *testing.T) {
+	day := 24 * time.Hour
+	before := func(window time.Duration) interface{} {
+		return mock.MatchedBy(func(t time.Time) bool {
+			return t.Sub(time.Now().Add(-window)).Abs() < time.Minute
+		})
+	}
+
+	t.Run("Purges every enabled dataset in batches", func(t *testing.T) {
+		tokenRepo := new(helpers.MockTokenRepository)
+		auditRepo := new(helpers.MockAuditLogRepository)
+		retentionService := service.NewRetentionService(tokenRepo, auditRepo, service.RetentionPolicy{
+			RevokedTokens:  30 * day,
+			LoginHistory:   90 * day,
+			TokenBlacklist: day,
+		})
+
+		tokenRepo.On("PurgeRevokedRefreshTokens", before(30*day), 1000).Return(int64(1000), nil).Once()
+		tokenRepo.On("PurgeRevokedRefreshTokens", before(30*day), 1000).Return(int64(12), nil).Once()
+		tokenRepo.On("PurgeExpiredRefreshTokens", before(90*day), 1000).Return(int64(3), nil).Once()
+		tokenRepo.On("PurgeBlacklist", before(day), 1000).Return(int64(0), nil).Once()
+
+		report, err := retentionService.Purge(context.Background())
+		require.NoError(t, err)
+		assert.Equal(t, map[string]int64{
+			domain.RetentionRevokedTokens:  1012,
+			domain.RetentionLoginHistory:   3,
+			domain.RetentionTokenBlacklist: 0,
+		}, report.Purged)
+		tokenRepo.AssertExpectations(t)
+		auditRepo.AssertNotCalled(t, "Purge", mock.Anything, mock.Anything)
+	})
+
+	t.Run("Reports rows purged before a failure", func(t *testing.T) {
+		tokenRepo := new(helpers.MockTokenRepository)
+		auditRepo := new(helpers.MockAuditLogRepository)
+		retentionService := service.NewRetentionService(tokenRepo, auditRepo, service.RetentionPolicy{
+			LoginHistory: 90 * day,
+			AuditLogs:    365 * day,
+		})
+
+		tokenRepo.On("PurgeExpiredRefreshTokens", mock.Anything, 1000).Return(int64(7), nil)
+		auditRepo.On("Purge", before(365*day), 1000).Return(int64(0), errors.New("lock wait timeout"))
+
+		report, err := retentionService.Purge(context.Background())
+		assert.Error(t, err)
+		assert.Equal(t, int64(7), report.Purged[domain.RetentionLoginHistory])
+	})
+}
+
+// memoryStore keeps the objects put into it
+type memoryStore struct {
+	objects map[string][]byte
+	err     error
+}
+
+func (s *memoryStore) Put(_ context.Context, key string, body []byte, _ string) error {
+	if s.err != nil {
+		return s.err
+	}
+	s.objects[key] = body
+	return nil
+}
+
+// readArchive decompresses an archived NDJSON object into audit logs
+func readArchive(t *testing.T, body []byte) []domain.AuditLogResponse {
+	t.Helper()
+	gz, err := gzip.NewReader(bytes.NewReader(body))
+	require.NoError(t, err)
+	var logs []domain.AuditLogResponse
+	decoder := json.NewDecoder(gz)
+	for decoder.More() {
+		var log domain.AuditLogResponse
+		require.NoError(t, decoder.Decode(&log))
+		logs = append(logs, log)
+	}
+	return logs
+}
+
+func TestRetentionService_ArchiveAuditLogs(t *testing.T) {
+	orgID := uint(3)
+	created := time.Date(2025, 1, 2, 10, 0, 0, 0, time.UTC)
+	logs := []*domain.AuditLog{
+		{ID: 1, Action: domain.AuditUserDeleted, CreatedAt: created},
+		{ID: 2, Action: domain.AuditUserDeleted, OrganizationID: &orgID, CreatedAt: created},
+		{ID: 5, Action: domain.AuditUserDeleted, OrganizationID: &orgID, CreatedAt: created.Add(time.Hour)},
+	}
+
+	t.Run("Archives each organization before deleting", func(t *testing.T) {
+		auditRepo := new(helpers.MockAuditLogRepository)
+		store := &memoryStore{objects: make(map[string][]byte)}
+		retentionService := service.NewRetentionService(new(helpers.MockTokenRepository), auditRepo,
+			service.RetentionPolicy{AuditLogs: 365 * 24 * time.Hour}, service.WithAuditLogArchive(store, "audit-logs/"))
+
+		auditRepo.On("FindBefore", mock.Anything, 1000).Return(logs, nil).Once()
+		auditRepo.On("DeleteByIDs", []uint{1, 2, 5}).Return(int64(3), nil).Once()
+
+		report, err := retentionService.Purge(context.Background())
+		require.NoError(t, err)
+		assert.Equal(t, int64(3), report.Purged[domain.RetentionAuditLogs])
+		require.Len(t, store.objects, 2)
+
+		global := readArchive(t, store.objects["audit-logs/global/2025/01/02/1-1.ndjson.gz"])
+		require.Len(t, global, 1)
+		assert.Equal(t, uint(1), global[0].ID)
+		org := readArchive(t, store.objects["audit-logs/org-3/2025/01/02/2-5.ndjson.gz"])
+		require.Len(t, org, 2)
+		assert.Equal(t, &orgID, org[1].OrganizationID)
+		auditRepo.AssertNotCalled(t, "Purge", mock.Anything, mock.Anything)
+	})
+
+	t.Run("Keeps audit logs that could not be archived", func(t *testing.T) {
+		auditRepo := new(helpers.MockAuditLogRepository)
+		store := &memoryStore{objects: make(map[string][]byte), err: errors.New("access denied")}
+		retentionService := service.NewRetentionService(new(helpers.MockTokenRepository), auditRepo,
+			service.RetentionPolicy{AuditLogs: 365 * 24 * time.Hour}, service.WithAuditLogArchive(store, "audit-logs/"))
+
+		auditRepo.On("FindBefore", mock.Anything, 1000).Return(logs, nil).Once()
+
+		_, err := retentionService.Purge(context.Background())
+		assert.Error(t, err)
+		auditRepo.AssertNotCalled(t, "DeleteByIDs", mock.Anything)
+	})
+}
+
+func TestRetentionMetrics(t *testing.T) {
+	registry := metrics.NewRegistry()
+	retentionMetrics := metrics.NewRetentionMetrics(registry)
+
+	retentionMetrics.Observe(map[string]int64{domain.RetentionAuditLogs: 40})
+	retentionMetrics.Observe(map[string]int64{domain.RetentionAuditLogs: 2, domain.RetentionTokenBlacklist: 5})
+
+	var buf bytes.Buffer
+	registry.Write(&buf)
+	output := buf.String()
+
+	assert.Contains(t, output, `retention_purged_rows_total{dataset="audit_logs"} 42`)
+	assert.Contains(t, output, `retention_purged_rows_total{dataset="token_blacklist"} 5`)
+}
@@ -0,0 +1,129 @@
+package unit
+
+import (
+	"gojwt-rest-api/internal/middleware"
+	"gojwt-rest-api/internal/routecheck"
+	"gojwt-rest-api/internal/service"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var routecheckRules = []routecheck.Rule{
+	{
+		Description: "authentication middleware is missing",
+		Match:       routecheck.PathPrefix("/api/v1", routecheck.Route{Method: http.MethodPost, Path: "/api/v1/auth/refresh"}),
+		AnyOf:       []interface{}{middleware.AuthMiddleware},
+	},
+	{
+		Description: "revocation middleware is missing",
+		Match:       routecheck.PathPrefix("/"),
+		When:        []interface{}{middleware.AuthMiddleware},
+		AnyOf:       []interface{}{middleware.RevocationMiddleware},
+	},
+	{
+		Description: "admin middleware is missing",
+		Match:       routecheck.PathPrefix("/api/v1/admin"),
+		AnyOf:       []interface{}{middleware.AdminMiddleware, middleware.AdminScopeMiddleware},
+	},
+}
+
+var routecheckRequired = []routecheck.Route{
+	{Method: http.MethodPost, Path: "/api/v1/auth/refresh"},
+	{Method: http.MethodPost, Path: "/api/v1/auth/logout"},
+}
+
+// newWiredRouter registers routes like the API does, leaving out the
+// middleware or routes named in omit
+func newWiredRouter(omit ...string) (*gin.Engine, *int) {
+	omitted := make(map[string]bool)
+	for _, name := range omit {
+		omitted[name] = true
+	}
+	handled := 0
+	handler := func(c *gin.Context) {
+		handled++
+		c.Status(http.StatusOK)
+	}
+	// Probes never reach the admin middleware, which needs no user service
+	var userService service.UserService
+
+	router := gin.New()
+	router.Use(routecheck.Middleware())
+	v1 := router.Group("/api/v1")
+	if !omitted["refresh"] {
+		v1.POST("/auth/refresh", handler)
+	}
+	protected := v1.Group("")
+	if !omitted["auth"] {
+		protected.Use(middleware.AuthMiddleware("secret"))
+	}
+	if !omitted["revocation"] {
+		protected.Use(middleware.RevocationMiddleware(userService))
+	}
+	protected.POST("/auth/logout", handler)
+	protected.GET("/users/:id", handler)
+	protected.GET("/users/profile", handler)
+	if omitted["admin"] {
+		protected.GET("/admin/audit-logs", handler)
+	} else {
+		protected.GET("/admin/audit-logs", middleware.AdminScopeMiddleware(userService, "admin:audit-read"), handler)
+	}
+	protected.POST("/admin/sso-connections", middleware.AdminMiddleware(userService), handler)
+	return router, &handled
+}
+
+func TestRoutecheck_Verify(t *testing.T) {
+	t.Run("Accepts correct wiring without running handlers", func(t *testing.T) {
+		router, handled := newWiredRouter()
+
+		require.NoError(t, routecheck.Verify(router, routecheckRules, routecheckRequired))
+		assert.Zero(t, *handled)
+	})
+
+	t.Run("Reports missing middleware and routes", func(t *testing.T) {
+		for omit, problem := range map[string]string{
+			"auth":       "GET /api/v1/users/:id: authentication middleware is missing",
+			"admin":      "GET /api/v1/admin/audit-logs: admin middleware is missing",
+			"refresh":    "POST /api/v1/auth/refresh: required route is not registered",
+			"revocation": "POST /api/v1/auth/logout: revocation middleware is missing",
+		} {
+			router, _ := newWiredRouter(omit)
+
+			err := routecheck.Verify(router, routecheckRules, routecheckRequired)
+			require.Error(t, err, omit)
+			assert.Contains(t, err.Error(), problem)
+		}
+	})
+
+	t.Run("Only requires revocation on authenticated routes", func(t *testing.T) {
+		router, _ := newWiredRouter("revocation")
+
+		err := routecheck.Verify(router, routecheckRules, routecheckRequired)
+		require.Error(t, err)
+		assert.NotContains(t, err.Error(), "POST /api/v1/auth/refresh: revocation")
+	})
+
+	t.Run("Requires the probe middleware to run first", func(t *testing.T) {
+		router := gin.New()
+		router.GET("/api/v1/users", middleware.AuthMiddleware("secret"), func(c *gin.Context) {})
+
+		err := routecheck.Verify(router, routecheckRules, nil)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "register it first")
+	})
+
+	t.Run("Serves regular requests", func(t *testing.T) {
+		router, handled := newWiredRouter()
+
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/api/v1/auth/refresh", nil))
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, 1, *handled)
+	})
+}
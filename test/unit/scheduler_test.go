@@ -0,0 +1,46 @@
+package unit
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"gojwt-rest-api/internal/metrics"
+	"gojwt-rest-api/internal/scheduler"
+	"gojwt-rest-api/pkg/logger"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestScheduler_JobMetrics(t *testing.T) {
+	registry := metrics.NewRegistry()
+	jobs := scheduler.New(logger.New(), scheduler.WithObserver(metrics.NewJobMetrics(registry)))
+
+	var runs atomic.Int32
+	jobs.Every("cleanup", 10*time.Millisecond, func(ctx context.Context) error {
+		// Fail the first run only
+		if runs.Add(1) == 1 {
+			return errors.New("lock wait timeout")
+		}
+		return nil
+	})
+	jobs.Every("disabled", 0, func(ctx context.Context) error { return nil })
+
+	jobs.Start()
+	require.Eventually(t, func() bool { return runs.Load() >= 2 }, time.Second, 5*time.Millisecond)
+	jobs.Stop()
+
+	var buf bytes.Buffer
+	registry.Write(&buf)
+	output := buf.String()
+
+	assert.Contains(t, output, `scheduled_job_interval_seconds{job="cleanup"} 0.01`)
+	assert.Contains(t, output, `scheduled_job_failures_total{job="cleanup"} 1`)
+	assert.Contains(t, output, `scheduled_job_running{job="cleanup"} 0`)
+	assert.Contains(t, output, `scheduled_job_last_success_timestamp_seconds{job="cleanup"}`)
+	assert.Contains(t, output, `scheduled_job_duration_seconds_count{job="cleanup"}`)
+	assert.NotContains(t, output, `job="disabled"`)
+}
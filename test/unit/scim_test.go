@@ -0,0 +1,90 @@
+package unit
+
+import (
+	"encoding/json"
+	"gojwt-rest-api/internal/domain"
+	"gojwt-rest-api/internal/scim"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSCIMParseFilter(t *testing.T) {
+	tests := []struct {
+		name     string
+		filter   string
+		expected *domain.UserFilter
+	}{
+		{"userName eq", `userName eq "john@example.com"`, &domain.UserFilter{Field: "email", Operator: "eq", Value: "john@example.com"}},
+		{"Case-insensitive attribute", `USERNAME sw "john"`, &domain.UserFilter{Field: "email", Operator: "sw", Value: "john"}},
+		{"displayName co", `displayName co "Doe"`, &domain.UserFilter{Field: "name", Operator: "co", Value: "Doe"}},
+		{"Empty filter", "", nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			filter, err := scim.ParseFilter(tt.filter)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, filter)
+		})
+	}
+
+	t.Run("active eq", func(t *testing.T) {
+		filter, err := scim.ParseFilter("active eq false")
+		require.NoError(t, err)
+		require.NotNil(t, filter.Active)
+		assert.False(t, *filter.Active)
+	})
+
+	for _, invalid := range []string{`password eq "x"`, `userName gt "a"`, `userName eq john`, `userName eq`} {
+		t.Run("Rejects "+invalid, func(t *testing.T) {
+			_, err := scim.ParseFilter(invalid)
+			assert.ErrorIs(t, err, scim.ErrInvalidFilter)
+		})
+	}
+}
+
+func TestSCIMPatchApply(t *testing.T) {
+	newUser := func() *scim.User {
+		return scim.FromUser(&domain.User{ID: 1, Name: "John Doe", Email: "john@example.com"}, "http://localhost/scim/v2/Users")
+	}
+	decode := func(t *testing.T, body string) *scim.PatchRequest {
+		var patch scim.PatchRequest
+		require.NoError(t, json.Unmarshal([]byte(body), &patch))
+		return &patch
+	}
+
+	t.Run("Okta deactivation", func(t *testing.T) {
+		user := newUser()
+		patch := decode(t, `{"Operations":[{"op":"replace","value":{"active":false}}]}`)
+
+		require.NoError(t, patch.Apply(user))
+		assert.False(t, user.IsActive())
+	})
+
+	t.Run("Azure AD string booleans and email path", func(t *testing.T) {
+		user := newUser()
+		patch := decode(t, `{"Operations":[
+			{"op":"Replace","path":"active","value":"False"},
+			{"op":"Replace","path":"emails[type eq \"work\"].value","value":"jd@example.com"}
+		]}`)
+
+		require.NoError(t, patch.Apply(user))
+		assert.False(t, user.IsActive())
+		assert.Equal(t, "jd@example.com", user.Email())
+	})
+
+	t.Run("Name components", func(t *testing.T) {
+		user := newUser()
+		patch := decode(t, `{"Operations":[{"op":"replace","path":"name","value":{"givenName":"Jane","familyName":"Roe"}}]}`)
+
+		require.NoError(t, patch.Apply(user))
+		assert.Equal(t, "Jane Roe", user.FullName())
+	})
+
+	t.Run("Unsupported operation and path", func(t *testing.T) {
+		assert.ErrorIs(t, decode(t, `{"Operations":[{"op":"remove","path":"active"}]}`).Apply(newUser()), scim.ErrInvalidPatchOp)
+		assert.ErrorIs(t, decode(t, `{"Operations":[{"op":"add","path":"password","value":"x"}]}`).Apply(newUser()), scim.ErrInvalidPath)
+	})
+}
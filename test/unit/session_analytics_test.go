@@ -0,0 +1,65 @@
+package unit
+
+import (
+	"gojwt-rest-api/internal/domain"
+	"gojwt-rest-api/internal/service"
+	"gojwt-rest-api/test/helpers"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewAnalyticsBuckets(t *testing.T) {
+	from := time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)
+
+	t.Run("Extends a partial last interval", func(t *testing.T) {
+		buckets, err := domain.NewAnalyticsBuckets(from, from.Add(150*time.Minute), time.Hour)
+
+		require.NoError(t, err)
+		assert.Equal(t, 3, buckets.Count)
+		assert.Equal(t, from.Add(3*time.Hour), buckets.To())
+	})
+
+	t.Run("Rejects invalid ranges", func(t *testing.T) {
+		for name, to := range map[string]time.Time{
+			"empty":     from,
+			"backwards": from.Add(-time.Hour),
+			"too long":  from.Add((domain.MaxAnalyticsBuckets + 1) * time.Hour),
+		} {
+			_, err := domain.NewAnalyticsBuckets(from, to, time.Hour)
+			assert.ErrorIs(t, err, domain.ErrInvalidAnalyticsRange, name)
+		}
+
+		_, err := domain.NewAnalyticsBuckets(from, from.Add(time.Hour), time.Second)
+		assert.ErrorIs(t, err, domain.ErrInvalidAnalyticsRange)
+	})
+}
+
+func TestSessionAnalyticsService_Report(t *testing.T) {
+	from := time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)
+	repo := new(helpers.MockSessionAnalyticsRepository)
+	analytics := service.NewSessionAnalyticsService(repo)
+
+	repo.On("CountRefreshes", mock.Anything).Return([]int64{120, 30}, nil)
+	repo.On("CountCreated", domain.TokenTableRefreshTokens, mock.Anything).Return([]int64{130, 40}, nil)
+	repo.On("CountCreated", domain.TokenTableBlacklist, mock.Anything).Return([]int64{5, 1}, nil)
+	repo.On("CountActiveSessions", mock.Anything).Return([]int64{900, 910}, nil)
+	repo.On("TableStats", domain.TokenTableRefreshTokens).Return(&domain.TokenTableStats{Table: domain.TokenTableRefreshTokens, Rows: 5000}, nil)
+	repo.On("TableStats", domain.TokenTableBlacklist).Return(&domain.TokenTableStats{Table: domain.TokenTableBlacklist, Rows: 60}, nil)
+
+	report, err := analytics.Report(from, from.Add(4*time.Hour), 2*time.Hour)
+
+	require.NoError(t, err)
+	assert.Equal(t, "2h0m0s", report.Interval)
+	assert.Equal(t, []*domain.SessionAnalyticsPoint{
+		{Time: from, ActiveSessions: 900, Refreshes: 120, RefreshesPerHour: 60, RefreshTokensCreated: 130, BlacklistEntriesCreated: 5},
+		{Time: from.Add(2 * time.Hour), ActiveSessions: 910, Refreshes: 30, RefreshesPerHour: 15, RefreshTokensCreated: 40, BlacklistEntriesCreated: 1},
+	}, report.Points)
+	require.Len(t, report.Tables, 2)
+	// 170 refresh tokens in 4 hours
+	assert.Equal(t, 1020.0, report.Tables[0].RowsCreatedPerDay)
+	assert.Equal(t, int64(5000), report.Tables[0].Rows)
+}
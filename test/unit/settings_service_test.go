@@ -0,0 +1,190 @@
+package unit
+
+import (
+	"gojwt-rest-api/internal/domain"
+	"gojwt-rest-api/internal/service"
+	"gojwt-rest-api/internal/utils"
+	"gojwt-rest-api/test/helpers"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+var testDefaultSettings = domain.TenantSettings{
+	AccessTokenTTL:  15 * time.Minute,
+	RefreshTokenTTL: 7 * 24 * time.Hour,
+	PasswordPolicy:  domain.PasswordPolicy{MinLength: 6},
+}
+
+func intPtr(v int) *int    { return &v }
+func boolPtr(v bool) *bool { return &v }
+
+func TestPasswordPolicy_Check(t *testing.T) {
+	policy := domain.PasswordPolicy{MinLength: 10, RequireUppercase: true, RequireDigit: true, RequireSymbol: true}
+
+	assert.NoError(t, policy.Check("Correct-horse-1"))
+	assert.Equal(t, domain.ErrPasswordPolicyViolation, policy.Check("Short-1"))
+	assert.Equal(t, domain.ErrPasswordPolicyViolation, policy.Check("no-uppercase-1"))
+	assert.Equal(t, domain.ErrPasswordPolicyViolation, policy.Check("No-digits-here"))
+	assert.Equal(t, domain.ErrPasswordPolicyViolation, policy.Check("NoSymbols123"))
+	assert.NoError(t, domain.PasswordPolicy{}.Check(""))
+}
+
+func TestSettingsService_ForOrganization(t *testing.T) {
+	orgID := uint(3)
+
+	t.Run("Applies overrides on top of the global settings", func(t *testing.T) {
+		orgRepo := new(helpers.MockOrganizationRepository)
+		settingsService := service.NewSettingsService(orgRepo, testDefaultSettings, time.Minute)
+
+		orgRepo.On("FindSettings", orgID).Return(&domain.OrganizationSettings{
+			OrganizationID:        orgID,
+			AccessTokenTTLSeconds: intPtr(300),
+			PasswordMinLength:     intPtr(12),
+			AllowedEmailDomains:   "acme.com,acme.io",
+			Require2FA:            boolPtr(true),
+		}, nil).Once()
+
+		settings, err := settingsService.ForOrganization(&orgID)
+		require.NoError(t, err)
+		assert.Equal(t, 5*time.Minute, settings.AccessTokenTTL)
+		assert.Equal(t, testDefaultSettings.RefreshTokenTTL, settings.RefreshTokenTTL)
+		assert.Equal(t, 12, settings.PasswordPolicy.MinLength)
+		assert.True(t, settings.Require2FA)
+		assert.True(t, settings.AllowsEmail("jane@ACME.io"))
+		assert.False(t, settings.AllowsEmail("jane@example.com"))
+
+		// Served from cache
+		_, err = settingsService.ForOrganization(&orgID)
+		require.NoError(t, err)
+		orgRepo.AssertExpectations(t)
+	})
+
+	t.Run("Users without organization get the global settings", func(t *testing.T) {
+		orgRepo := new(helpers.MockOrganizationRepository)
+		settingsService := service.NewSettingsService(orgRepo, testDefaultSettings, time.Minute)
+
+		settings, err := settingsService.ForOrganization(nil)
+		require.NoError(t, err)
+		assert.Equal(t, testDefaultSettings.AccessTokenTTL, settings.AccessTokenTTL)
+		assert.True(t, settings.AllowsEmail("anyone@example.com"))
+		orgRepo.AssertNotCalled(t, "FindSettings", mock.Anything)
+	})
+
+	t.Run("Updating overrides invalidates the cache", func(t *testing.T) {
+		orgRepo := new(helpers.MockOrganizationRepository)
+		settingsService := service.NewSettingsService(orgRepo, testDefaultSettings, time.Minute)
+
+		orgRepo.On("FindSettings", orgID).Return(&domain.OrganizationSettings{OrganizationID: orgID}, nil).Once()
+		orgRepo.On("FindByID", orgID).Return(&domain.Organization{ID: orgID}, nil)
+		orgRepo.On("SaveSettings", mock.AnythingOfType("*domain.OrganizationSettings")).Return(nil)
+
+		_, err := settingsService.ForOrganization(&orgID)
+		require.NoError(t, err)
+
+		saved, err := settingsService.UpdateOverrides(orgID, &domain.UpdateOrganizationSettingsRequest{
+			RefreshTokenTTLSeconds: intPtr(3600),
+			AllowedEmailDomains:    []string{" Acme.com "},
+		})
+		require.NoError(t, err)
+		assert.Equal(t, "acme.com", saved.AllowedEmailDomains)
+
+		orgRepo.On("FindSettings", orgID).Return(saved, nil).Once()
+		settings, err := settingsService.ForOrganization(&orgID)
+		require.NoError(t, err)
+		assert.Equal(t, time.Hour, settings.RefreshTokenTTL)
+		orgRepo.AssertExpectations(t)
+	})
+}
+
+func TestUserService_TenantSettings(t *testing.T) {
+	jwtSecret := "test-secret"
+	orgID := uint(3)
+
+	newSettingsService := func(overrides *domain.OrganizationSettings) service.SettingsService {
+		orgRepo := new(helpers.MockOrganizationRepository)
+		orgRepo.On("FindSettings", orgID).Return(overrides, nil)
+		return service.NewSettingsService(orgRepo, testDefaultSettings, time.Minute)
+	}
+
+	t.Run("Login uses the organization's token lifetimes", func(t *testing.T) {
+		mockRepo := new(helpers.MockUserRepository)
+		mockTokenRepo := new(helpers.MockTokenRepository)
+		userService := service.NewUserService(mockRepo, mockTokenRepo, jwtSecret, 15*time.Minute, 7*24*time.Hour,
+			service.WithSettingsService(newSettingsService(&domain.OrganizationSettings{
+				OrganizationID:         orgID,
+				AccessTokenTTLSeconds:  intPtr(120),
+				RefreshTokenTTLSeconds: intPtr(3600),
+			})),
+		)
+
+		user := helpers.CreateTestUser(1, "john@acme.com")
+		user.OrganizationID = &orgID
+		now := time.Now()
+		user.FirstLoginAt = &now
+
+		var stored *domain.RefreshToken
+		mockTokenRepo.On("CreateRefreshToken", mock.AnythingOfType("*domain.RefreshToken")).
+			Run(func(args mock.Arguments) { stored = args.Get(0).(*domain.RefreshToken) }).
+			Return(nil)
+		mockRepo.On("RecordLogin", user.ID, mock.AnythingOfType("time.Time")).Return(nil)
+
+		response, err := userService.IssueSession(user, "")
+		require.NoError(t, err)
+		assert.Equal(t, int64(120), response.ExpiresIn)
+		require.NotNil(t, stored)
+		assert.WithinDuration(t, time.Now().Add(time.Hour), stored.ExpiresAt, time.Minute)
+
+		claims, err := utils.ValidateToken(response.AccessToken, jwtSecret)
+		require.NoError(t, err)
+		assert.WithinDuration(t, time.Now().Add(2*time.Minute), claims.ExpiresAt.Time, time.Minute)
+	})
+
+	t.Run("Change password enforces the organization's password policy", func(t *testing.T) {
+		mockRepo := new(helpers.MockUserRepository)
+		mockTokenRepo := new(helpers.MockTokenRepository)
+		userService := service.NewUserService(mockRepo, mockTokenRepo, jwtSecret, 15*time.Minute, 7*24*time.Hour,
+			service.WithSettingsService(newSettingsService(&domain.OrganizationSettings{
+				OrganizationID:       orgID,
+				PasswordMinLength:    intPtr(12),
+				PasswordRequireDigit: boolPtr(true),
+			})),
+		)
+
+		user := helpers.CreateTestUser(1, "john@acme.com")
+		user.OrganizationID = &orgID
+		mockRepo.On("FindByID", uint(1)).Return(user, nil)
+
+		err := userService.ChangePassword(1, &domain.ChangePasswordRequest{
+			OldPassword: "password123",
+			NewPassword: "longbutnodigits",
+		})
+
+		assert.Equal(t, domain.ErrPasswordPolicyViolation, err)
+		mockRepo.AssertNotCalled(t, "Update", mock.Anything)
+	})
+
+	t.Run("Email changes are restricted to allowed domains", func(t *testing.T) {
+		mockRepo := new(helpers.MockUserRepository)
+		mockTokenRepo := new(helpers.MockTokenRepository)
+		userService := service.NewUserService(mockRepo, mockTokenRepo, jwtSecret, 15*time.Minute, 7*24*time.Hour,
+			service.WithSettingsService(newSettingsService(&domain.OrganizationSettings{
+				OrganizationID:      orgID,
+				AllowedEmailDomains: "acme.com",
+			})),
+		)
+
+		user := helpers.CreateTestUser(1, "john@acme.com")
+		user.OrganizationID = &orgID
+		mockRepo.On("FindByID", uint(1)).Return(user, nil)
+		mockRepo.On("FindByEmail", "john@gmail.com").Return(nil, domain.ErrUserNotFound)
+
+		_, err := userService.UpdateOwnProfile(1, &domain.UpdateProfileRequest{Email: "john@gmail.com"})
+
+		assert.Equal(t, domain.ErrEmailDomainNotAllowed, err)
+		mockRepo.AssertNotCalled(t, "Update", mock.Anything)
+	})
+}
@@ -0,0 +1,162 @@
+package unit
+
+import (
+	"gojwt-rest-api/internal/domain"
+	"gojwt-rest-api/internal/repository"
+	"gojwt-rest-api/internal/service"
+	"gojwt-rest-api/internal/utils"
+	"gojwt-rest-api/test/factory"
+	"gojwt-rest-api/test/helpers"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestKeySet(t *testing.T) {
+	t.Run("Signs with the first key not revoked", func(t *testing.T) {
+		keys := utils.NewKeySet("first", "second", "first")
+		assert.Equal(t, []string{domain.SigningKeyFingerprint("first"), domain.SigningKeyFingerprint("second")}, keys.IDs())
+
+		id, err := keys.SigningKeyID()
+		require.NoError(t, err)
+		assert.Equal(t, domain.SigningKeyFingerprint("first"), id)
+
+		keys.Revoke(domain.SigningKeyFingerprint("first"), "unknown")
+		id, err = keys.SigningKeyID()
+		require.NoError(t, err)
+		assert.Equal(t, domain.SigningKeyFingerprint("second"), id)
+
+		keys.Revoke(domain.SigningKeyFingerprint("second"))
+		_, err = keys.SigningKeyID()
+		assert.ErrorIs(t, err, domain.ErrNoSigningKey)
+		_, err = keys.GenerateToken(1, "john@example.com", time.Minute)
+		assert.ErrorIs(t, err, domain.ErrNoSigningKey)
+	})
+
+	t.Run("Verifies tokens with the key they name", func(t *testing.T) {
+		keys := utils.NewKeySet("first", "second")
+		validator := utils.NewKeySetValidator(keys)
+		token, err := utils.GenerateToken(1, "john@example.com", "second", time.Minute)
+		require.NoError(t, err)
+
+		_, err = validator.Validate(token)
+		require.NoError(t, err)
+
+		keys.Revoke(domain.SigningKeyFingerprint("second"))
+		_, err = validator.Validate(token)
+		assert.ErrorIs(t, err, domain.ErrSigningKeyRotated)
+	})
+
+	t.Run("Rejects tokens of unknown keys", func(t *testing.T) {
+		token, err := utils.GenerateToken(1, "john@example.com", "other", time.Minute)
+		require.NoError(t, err)
+
+		_, err = utils.NewKeySetValidator(utils.NewKeySet("first")).Validate(token)
+		assert.ErrorIs(t, err, domain.ErrUnknownSigningKey)
+	})
+}
+
+func TestSigningKeyService(t *testing.T) {
+	t.Run("Reporting a compromise drops the key at once", func(t *testing.T) {
+		repo := new(helpers.MockSigningKeyRepository)
+		keys := utils.NewKeySet("leaked", "standby")
+		signingKeys := service.NewSigningKeyService(repo, keys)
+
+		repo.On("RecordIncident", mock.MatchedBy(func(i *domain.SigningKeyIncident) bool {
+			return i.KeyFingerprint == domain.SigningKeyFingerprint("leaked") && i.Reason == "found in logs"
+		}), true).Run(func(args mock.Arguments) {
+			incident := args.Get(0).(*domain.SigningKeyIncident)
+			incident.ID = 1
+			incident.OutstandingSessions = 12
+			incident.RefreshTokensRevoked = 12
+		}).Return(nil)
+
+		incident, err := signingKeys.ReportCompromise(&domain.SigningKeyCompromise{
+			Secret:              "leaked",
+			Reason:              "found in logs",
+			RevokeRefreshTokens: true,
+		})
+		require.NoError(t, err)
+		assert.Equal(t, int64(12), incident.OutstandingSessions)
+
+		assert.True(t, keys.Revoked(domain.SigningKeyFingerprint("leaked")))
+		id, err := keys.SigningKeyID()
+		require.NoError(t, err)
+		assert.Equal(t, domain.SigningKeyFingerprint("standby"), id)
+	})
+
+	t.Run("Refresh drops keys reported compromised by other instances", func(t *testing.T) {
+		repo := new(helpers.MockSigningKeyRepository)
+		keys := utils.NewKeySet("leaked", "standby")
+		repo.On("FindCompromised", keys.IDs()).Return([]string{domain.SigningKeyFingerprint("leaked")}, nil)
+
+		require.NoError(t, service.NewSigningKeyService(repo, keys).Refresh())
+		assert.True(t, keys.Revoked(domain.SigningKeyFingerprint("leaked")))
+		assert.False(t, keys.Revoked(domain.SigningKeyFingerprint("standby")))
+	})
+
+	t.Run("Refresh fails when no key is left to sign with", func(t *testing.T) {
+		repo := new(helpers.MockSigningKeyRepository)
+		keys := utils.NewKeySet("leaked")
+		repo.On("FindCompromised", keys.IDs()).Return(keys.IDs(), nil)
+
+		assert.ErrorIs(t, service.NewSigningKeyService(repo, keys).Refresh(), domain.ErrNoSigningKey)
+	})
+
+	t.Run("Issued tokens name their signing key", func(t *testing.T) {
+		userRepo := helpers.NewMemoryUserRepository()
+		user := factory.New().User()
+		require.NoError(t, userRepo.Create(user))
+		keys := utils.NewKeySet(factory.DefaultSecret, "standby")
+		keys.Revoke(domain.SigningKeyFingerprint(factory.DefaultSecret))
+		userService := service.NewUserService(userRepo, helpers.NewMemoryTokenRepository(), factory.DefaultSecret, time.Minute, time.Hour,
+			service.WithSigningKeys(keys))
+
+		resp, err := userService.Login(&domain.LoginRequest{Email: user.Email, Password: factory.DefaultPassword})
+		require.NoError(t, err)
+
+		_, err = utils.NewTokenValidator(factory.DefaultSecret).Validate(resp.AccessToken)
+		assert.Error(t, err)
+		_, err = utils.NewTokenValidator("standby").Validate(resp.AccessToken)
+		assert.NoError(t, err)
+	})
+}
+
+func TestSigningKeyRepository_FindCompromised(t *testing.T) {
+	db, sqlMock := setupTokenMockDB(t)
+	repo := repository.NewSigningKeyRepository(db)
+
+	sqlMock.ExpectQuery("SELECT DISTINCT `key_fingerprint` FROM `signing_key_incidents` WHERE key_fingerprint IN \\(\\?,\\?\\)").
+		WithArgs("abc", "def").
+		WillReturnRows(sqlmock.NewRows([]string{"key_fingerprint"}).AddRow("abc"))
+
+	compromised, err := repo.FindCompromised([]string{"abc", "def"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"abc"}, compromised)
+	assert.NoError(t, sqlMock.ExpectationsWereMet())
+}
+
+func TestSigningKeyRepository_RecordIncident(t *testing.T) {
+	db, sqlMock := setupTokenMockDB(t)
+	repo := repository.NewSigningKeyRepository(db)
+	incident := &domain.SigningKeyIncident{KeyFingerprint: "abc", Reason: "leak", CreatedAt: time.Now()}
+
+	sqlMock.ExpectBegin()
+	sqlMock.ExpectQuery("SELECT count\\(\\*\\) FROM `refresh_tokens` WHERE is_revoked = \\? AND expires_at > \\?").
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(4))
+	sqlMock.ExpectExec("UPDATE `refresh_tokens` SET .* WHERE is_revoked = \\? AND expires_at > \\?").
+		WillReturnResult(sqlmock.NewResult(0, 4))
+	sqlMock.ExpectExec("INSERT INTO `signing_key_incidents`").
+		WillReturnResult(sqlmock.NewResult(2, 1))
+	sqlMock.ExpectCommit()
+
+	require.NoError(t, repo.RecordIncident(incident, true))
+	assert.Equal(t, uint(2), incident.ID)
+	assert.Equal(t, int64(4), incident.OutstandingSessions)
+	assert.Equal(t, int64(4), incident.RefreshTokensRevoked)
+	assert.NoError(t, sqlMock.ExpectationsWereMet())
+}
@@ -0,0 +1,125 @@
+package unit
+
+import (
+	"context"
+	"encoding/json"
+	"gojwt-rest-api/internal/domain"
+	"gojwt-rest-api/internal/smoke"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// smokeEmail is the smoke test account known to newSmokeServer
+const smokeEmail = "smoke@example.com"
+
+// newSmokeServer stubs the auth endpoints used by the smoke test. The refresh
+// endpoint answers with refreshStatus.
+func newSmokeServer(t *testing.T, refreshStatus int) *httptest.Server {
+	mux := http.NewServeMux()
+	writeJSON := func(w http.ResponseWriter, status int, body *domain.Response) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		require.NoError(t, json.NewEncoder(w).Encode(body))
+	}
+
+	mux.HandleFunc("POST /api/v1/auth/register", func(w http.ResponseWriter, r *http.Request) {
+		t.Error("the smoke test must not register users")
+	})
+	mux.HandleFunc("POST /api/v1/auth/login", func(w http.ResponseWriter, r *http.Request) {
+		var req domain.LoginRequest
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		if req.Email != smokeEmail || req.Password != "Smoke-Pass-1!" {
+			writeJSON(w, http.StatusUnauthorized, domain.ErrorResponse(domain.ErrInvalidCredentials.Error(), nil))
+			return
+		}
+		writeJSON(w, http.StatusOK, domain.SuccessResponse("login successful", &domain.LoginResponse{
+			AccessToken: "access-1", RefreshToken: "refresh-1", TokenType: "Bearer",
+		}))
+	})
+	mux.HandleFunc("POST /api/v1/auth/refresh", func(w http.ResponseWriter, r *http.Request) {
+		if refreshStatus != http.StatusOK {
+			writeJSON(w, refreshStatus, domain.ErrorResponse(domain.ErrInvalidRefreshToken.Error(), nil))
+			return
+		}
+		var req domain.RefreshTokenRequest
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		assert.Equal(t, "refresh-1", req.RefreshToken)
+		writeJSON(w, http.StatusOK, domain.SuccessResponse("token refreshed successfully", &domain.RefreshTokenResponse{
+			AccessToken: "access-2", RefreshToken: "refresh-2", TokenType: "Bearer",
+		}))
+	})
+	mux.HandleFunc("GET /api/v1/profile", func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "Bearer access-2", r.Header.Get("Authorization"))
+		writeJSON(w, http.StatusOK, domain.SuccessResponse("profile retrieved", &domain.UserResponse{Email: smokeEmail}))
+	})
+	mux.HandleFunc("POST /api/v1/auth/logout", func(w http.ResponseWriter, r *http.Request) {
+		var req domain.LogoutRequest
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		assert.Equal(t, "Bearer access-2", r.Header.Get("Authorization"))
+		assert.Equal(t, "refresh-2", req.RefreshToken)
+		writeJSON(w, http.StatusOK, domain.SuccessResponse("logout successful", nil))
+	})
+
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestSmokeRunner_AllStepsPass(t *testing.T) {
+	server := newSmokeServer(t, http.StatusOK)
+	runner := smoke.New(server.URL+"/", smokeEmail, "Smoke-Pass-1!", smoke.WithHTTPClient(server.Client()))
+
+	results := runner.Run(context.Background())
+
+	require.Len(t, results, 4)
+	steps := []string{smoke.StepLogin, smoke.StepRefresh, smoke.StepProfile, smoke.StepLogout}
+	for i, result := range results {
+		assert.Equal(t, steps[i], result.Step)
+		assert.True(t, result.Passed, "step %s: %v", result.Step, result.Err)
+	}
+	assert.True(t, smoke.Passed(results))
+}
+
+func TestSmokeRunner_WrongPassword(t *testing.T) {
+	server := newSmokeServer(t, http.StatusOK)
+	runner := smoke.New(server.URL, smokeEmail, "wrong", smoke.WithHTTPClient(server.Client()))
+
+	results := runner.Run(context.Background())
+
+	require.Len(t, results, 4)
+	assert.Equal(t, http.StatusUnauthorized, results[0].Status)
+	assert.Contains(t, results[0].Err.Error(), domain.ErrInvalidCredentials.Error())
+	assert.True(t, results[3].Skipped)
+}
+
+func TestSmokeRunner_SkipsStepsAfterFailure(t *testing.T) {
+	server := newSmokeServer(t, http.StatusUnauthorized)
+	runner := smoke.New(server.URL, smokeEmail, "Smoke-Pass-1!", smoke.WithHTTPClient(server.Client()))
+
+	results := runner.Run(context.Background())
+
+	require.Len(t, results, 4)
+	assert.True(t, results[0].Passed)
+	assert.False(t, results[1].Passed)
+	assert.Equal(t, http.StatusUnauthorized, results[1].Status)
+	assert.Contains(t, results[1].Err.Error(), domain.ErrInvalidRefreshToken.Error())
+	assert.True(t, results[2].Skipped)
+	assert.True(t, results[3].Skipped)
+	assert.False(t, smoke.Passed(results))
+}
+
+func TestSmokeRunner_UnreachableServer(t *testing.T) {
+	server := httptest.NewServer(http.NotFoundHandler())
+	server.Close()
+
+	results := smoke.New(server.URL, smokeEmail, "Smoke-Pass-1!").Run(context.Background())
+
+	require.Len(t, results, 4)
+	assert.False(t, results[0].Passed)
+	assert.Error(t, results[0].Err)
+	assert.False(t, smoke.Passed(results))
+}
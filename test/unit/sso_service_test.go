@@ -0,0 +1,198 @@
+package unit
+
+import (
+	"gojwt-rest-api/internal/domain"
+	"gojwt-rest-api/internal/service"
+	"gojwt-rest-api/test/helpers"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestSSOService(ssoRepo *helpers.MockSSOConnectionRepository, userRepo *helpers.MockUserRepository, auditRepo *helpers.MockAuditLogRepository) service.SSOService {
+	return service.NewSSOService(ssoRepo, new(helpers.MockOrganizationRepository), userRepo, service.NewAuditService(auditRepo))
+}
+
+func TestSSOService_DiscoverRealm(t *testing.T) {
+	conn := &domain.SSOConnection{
+		ID:       3,
+		Name:     "Acme Okta",
+		Protocol: domain.SSOProtocolOIDC,
+		LoginURL: "https://acme.okta.com/oauth2/v1/authorize?client_id=abc",
+		Domains:  []domain.SSODomain{{ConnectionID: 3, Domain: "acme.com"}},
+	}
+
+	t.Run("Mapped domain redirects to the identity provider", func(t *testing.T) {
+		ssoRepo := new(helpers.MockSSOConnectionRepository)
+		ssoService := newTestSSOService(ssoRepo, new(helpers.MockUserRepository), new(helpers.MockAuditLogRepository))
+
+		ssoRepo.On("FindByDomain", "acme.com").Return(conn, nil)
+
+		response, err := ssoService.DiscoverRealm("Jane@ACME.com")
+
+		require.NoError(t, err)
+		assert.Equal(t, domain.LoginMethodSSO, response.Method)
+		assert.Equal(t, uint(3), response.ConnectionID)
+		assert.Equal(t, domain.SSOProtocolOIDC, response.Protocol)
+		assert.Equal(t, "https://acme.okta.com/oauth2/v1/authorize?client_id=abc&login_hint=Jane%40ACME.com", response.RedirectURL)
+	})
+
+	t.Run("Unmapped domain uses password login", func(t *testing.T) {
+		ssoRepo := new(helpers.MockSSOConnectionRepository)
+		ssoService := newTestSSOService(ssoRepo, new(helpers.MockUserRepository), new(helpers.MockAuditLogRepository))
+
+		ssoRepo.On("FindByDomain", "example.com").Return(nil, domain.ErrSSOConnectionNotFound)
+
+		response, err := ssoService.DiscoverRealm("john@example.com")
+
+		require.NoError(t, err)
+		assert.Equal(t, domain.LoginMethodPassword, response.Method)
+		assert.Empty(t, response.RedirectURL)
+	})
+}
+
+func TestSSOService_CreateConnection(t *testing.T) {
+	req := &domain.CreateSSOConnectionRequest{
+		Name:     "Acme Okta",
+		Protocol: domain.SSOProtocolSAML,
+		LoginURL: "https://acme.okta.com/app/sso/saml",
+		Domains:  []string{"Acme.com", "acme.com", "acme.io"},
+	}
+
+	t.Run("Normalizes domains", func(t *testing.T) {
+		ssoRepo := new(helpers.MockSSOConnectionRepository)
+		ssoService := newTestSSOService(ssoRepo, new(helpers.MockUserRepository), new(helpers.MockAuditLogRepository))
+
+		ssoRepo.On("FindByName", req.Name).Return(nil, domain.ErrSSOConnectionNotFound)
+		ssoRepo.On("FindMappedDomains", []string{"acme.com", "acme.io"}).Return([]string{}, nil)
+		ssoRepo.On("Create", mock.AnythingOfType("*domain.SSOConnection")).Return(nil)
+
+		conn, err := ssoService.CreateConnection(req)
+
+		require.NoError(t, err)
+		assert.Equal(t, []string{"acme.com", "acme.io"}, conn.DomainList())
+	})
+
+	t.Run("Rejects domains mapped to another connection", func(t *testing.T) {
+		ssoRepo := new(helpers.MockSSOConnectionRepository)
+		ssoService := newTestSSOService(ssoRepo, new(helpers.MockUserRepository), new(helpers.MockAuditLogRepository))
+
+		ssoRepo.On("FindByName", req.Name).Return(nil, domain.ErrSSOConnectionNotFound)
+		ssoRepo.On("FindMappedDomains", mock.Anything).Return([]string{"acme.io"}, nil)
+
+		_, err := ssoService.CreateConnection(req)
+
+		assert.Equal(t, domain.ErrSSODomainTaken, err)
+		ssoRepo.AssertNotCalled(t, "Create", mock.Anything)
+	})
+}
+
+func TestSSOService_ResolveUser(t *testing.T) {
+	orgID := uint(7)
+	newConnection := func(rules domain.SSOProvisioningRules) *domain.SSOConnection {
+		return &domain.SSOConnection{
+			ID:             3,
+			Name:           "Acme Azure AD",
+			Protocol:       domain.SSOProtocolSAML,
+			OrganizationID: &orgID,
+			Provisioning:   rules.WithDefaults(),
+			Domains:        []domain.SSODomain{{ConnectionID: 3, Domain: "acme.com"}},
+		}
+	}
+	attributes := map[string]string{
+		"http://schemas.xmlsoap.org/ws/2005/05/identity/claims/emailaddress": "Jane@acme.com",
+		"http://schemas.xmlsoap.org/ws/2005/05/identity/claims/givenname":    "Jane",
+		"http://schemas.xmlsoap.org/ws/2005/05/identity/claims/surname":      "Roe",
+	}
+	mapping := domain.SSOProvisioningRules{
+		EmailAttribute:      "http://schemas.xmlsoap.org/ws/2005/05/identity/claims/emailaddress",
+		GivenNameAttribute:  "http://schemas.xmlsoap.org/ws/2005/05/identity/claims/givenname",
+		FamilyNameAttribute: "http://schemas.xmlsoap.org/ws/2005/05/identity/claims/surname",
+	}
+
+	t.Run("Provisions unknown users with the connection defaults", func(t *testing.T) {
+		ssoRepo := new(helpers.MockSSOConnectionRepository)
+		userRepo := new(helpers.MockUserRepository)
+		auditRepo := new(helpers.MockAuditLogRepository)
+		ssoService := newTestSSOService(ssoRepo, userRepo, auditRepo)
+
+		rules := mapping
+		rules.Enabled = true
+		rules.DefaultRole = domain.RoleAdmin
+		ssoRepo.On("FindByID", uint(3)).Return(newConnection(rules), nil)
+		userRepo.On("FindByEmail", "jane@acme.com").Return(nil, domain.ErrUserNotFound)
+		userRepo.On("Create", mock.AnythingOfType("*domain.User")).Run(func(args mock.Arguments) {
+			args.Get(0).(*domain.User).ID = 11
+		}).Return(nil)
+		auditRepo.On("Create", mock.MatchedBy(func(log *domain.AuditLog) bool {
+			return log.Action == domain.AuditSSOUserProvisioned && *log.UserID == 11 && log.Source == "sso_connection:3"
+		})).Return(nil)
+
+		user, err := ssoService.ResolveUser(3, attributes)
+
+		require.NoError(t, err)
+		assert.Equal(t, "Jane Roe", user.Name)
+		assert.True(t, user.IsAdmin)
+		assert.Equal(t, &orgID, user.OrganizationID)
+		auditRepo.AssertExpectations(t)
+	})
+
+	t.Run("Refuses unknown users when provisioning is disabled", func(t *testing.T) {
+		ssoRepo := new(helpers.MockSSOConnectionRepository)
+		userRepo := new(helpers.MockUserRepository)
+		auditRepo := new(helpers.MockAuditLogRepository)
+		ssoService := newTestSSOService(ssoRepo, userRepo, auditRepo)
+
+		ssoRepo.On("FindByID", uint(3)).Return(newConnection(mapping), nil)
+		userRepo.On("FindByEmail", "jane@acme.com").Return(nil, domain.ErrUserNotFound)
+		auditRepo.On("Create", mock.MatchedBy(func(log *domain.AuditLog) bool {
+			return log.Action == domain.AuditSSOProvisioningDenied && log.UserID == nil
+		})).Return(nil)
+
+		_, err := ssoService.ResolveUser(3, attributes)
+
+		assert.Equal(t, domain.ErrJITProvisioningDisabled, err)
+		userRepo.AssertNotCalled(t, "Create", mock.Anything)
+		auditRepo.AssertExpectations(t)
+	})
+
+	t.Run("Rejects emails outside the connection's domains", func(t *testing.T) {
+		ssoRepo := new(helpers.MockSSOConnectionRepository)
+		userRepo := new(helpers.MockUserRepository)
+		auditRepo := new(helpers.MockAuditLogRepository)
+		ssoService := newTestSSOService(ssoRepo, userRepo, auditRepo)
+
+		ssoRepo.On("FindByID", uint(3)).Return(newConnection(domain.SSOProvisioningRules{Enabled: true}), nil)
+		auditRepo.On("Create", mock.AnythingOfType("*domain.AuditLog")).Return(nil)
+
+		_, err := ssoService.ResolveUser(3, map[string]string{"email": "admin@example.com"})
+
+		assert.Equal(t, domain.ErrSSOEmailDomainMismatch, err)
+		userRepo.AssertNotCalled(t, "FindByEmail", mock.Anything)
+	})
+
+	t.Run("Updates existing users on login", func(t *testing.T) {
+		ssoRepo := new(helpers.MockSSOConnectionRepository)
+		userRepo := new(helpers.MockUserRepository)
+		auditRepo := new(helpers.MockAuditLogRepository)
+		ssoService := newTestSSOService(ssoRepo, userRepo, auditRepo)
+
+		rules := mapping
+		rules.UpdateOnLogin = true
+		existing := helpers.CreateTestUser(5, "jane@acme.com")
+		ssoRepo.On("FindByID", uint(3)).Return(newConnection(rules), nil)
+		userRepo.On("FindByEmail", "jane@acme.com").Return(existing, nil)
+		userRepo.On("Update", existing).Return(nil)
+		auditRepo.On("Create", mock.MatchedBy(func(log *domain.AuditLog) bool {
+			return log.Action == domain.AuditSSOUserUpdated
+		})).Return(nil)
+
+		user, err := ssoService.ResolveUser(3, attributes)
+
+		require.NoError(t, err)
+		assert.Equal(t, "Jane Roe", user.Name)
+		auditRepo.AssertExpectations(t)
+	})
+}
@@ -0,0 +1,94 @@
+package unit
+
+import (
+	"context"
+	"encoding/json"
+	"gojwt-rest-api/internal/cloudauth"
+	"gojwt-rest-api/internal/config"
+	"gojwt-rest-api/internal/storage"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStorage_Providers(t *testing.T) {
+	body := []byte("archived")
+
+	t.Run("S3 uploads are signed and addressed path-style on custom endpoints", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			received, _ := io.ReadAll(r.Body)
+
+			assert.Equal(t, http.MethodPut, r.Method)
+			assert.Equal(t, "/archive-bucket/audit-logs/org-3/2026/01/02/1-9.ndjson.gz", r.URL.Path)
+			assert.True(t, strings.HasPrefix(r.Header.Get("Authorization"), "AWS4-HMAC-SHA256 Credential=AKID/"))
+			assert.Contains(t, r.Header.Get("Authorization"), "/eu-west-1/s3/aws4_request")
+			assert.Equal(t, cloudauth.HashHex(body), r.Header.Get("X-Amz-Content-Sha256"))
+			assert.Equal(t, "application/gzip", r.Header.Get("Content-Type"))
+			assert.Equal(t, body, received)
+		}))
+		defer server.Close()
+
+		store, err := storage.New(config.StorageConfig{
+			Provider:           config.StorageProviderS3,
+			Bucket:             "archive-bucket",
+			Endpoint:           server.URL,
+			AWSRegion:          "eu-west-1",
+			AWSAccessKeyID:     "AKID",
+			AWSSecretAccessKey: "secret-key",
+		})
+		require.NoError(t, err)
+
+		require.NoError(t, store.Put(context.Background(), "audit-logs/org-3/2026/01/02/1-9.ndjson.gz", body, "application/gzip"))
+	})
+
+	t.Run("GCS uploads use the instance access token", func(t *testing.T) {
+		mux := http.NewServeMux()
+		mux.HandleFunc("/computeMetadata/v1/instance/service-accounts/default/token", func(w http.ResponseWriter, r *http.Request) {
+			assert.Equal(t, "Google", r.Header.Get("Metadata-Flavor"))
+			_ = json.NewEncoder(w).Encode(map[string]string{"access_token": "token"})
+		})
+		mux.HandleFunc("/upload/storage/v1/b/archive-bucket/o", func(w http.ResponseWriter, r *http.Request) {
+			received, _ := io.ReadAll(r.Body)
+
+			assert.Equal(t, "Bearer token", r.Header.Get("Authorization"))
+			assert.Equal(t, "media", r.URL.Query().Get("uploadType"))
+			assert.Equal(t, "audit-logs/global/2026/01/02/1-9.ndjson.gz", r.URL.Query().Get("name"))
+			assert.Equal(t, body, received)
+		})
+		server := httptest.NewServer(mux)
+		defer server.Close()
+
+		store, err := storage.New(config.StorageConfig{
+			Provider:        config.StorageProviderGCS,
+			Bucket:          "archive-bucket",
+			Endpoint:        server.URL,
+			GCPMetadataHost: strings.TrimPrefix(server.URL, "http://"),
+		})
+		require.NoError(t, err)
+
+		require.NoError(t, store.Put(context.Background(), "audit-logs/global/2026/01/02/1-9.ndjson.gz", body, "application/gzip"))
+	})
+
+	t.Run("Failed uploads are errors", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusForbidden)
+		}))
+		defer server.Close()
+
+		store, err := storage.New(config.StorageConfig{Provider: config.StorageProviderS3, Bucket: "b", Endpoint: server.URL})
+		require.NoError(t, err)
+
+		assert.Error(t, store.Put(context.Background(), "key", body, "text/plain"))
+	})
+
+	t.Run("No provider disables storage", func(t *testing.T) {
+		store, err := storage.New(config.StorageConfig{})
+		require.NoError(t, err)
+		assert.Nil(t, store)
+	})
+}
@@ -1,8 +1,10 @@
 package unit
 
 import (
+	"errors"
 	"gojwt-rest-api/internal/domain"
 	"gojwt-rest-api/internal/repository"
+	"gojwt-rest-api/test/factory"
 	"regexp"
 	"testing"
 	"time"
@@ -44,6 +46,7 @@ func TestCreateRefreshToken(t *testing.T) {
 			refreshToken.UserID,
 			refreshToken.Token,
 			refreshToken.TokenFamily,
+			refreshToken.ClientID,
 			refreshToken.ExpiresAt,
 			sqlmock.AnyArg(), // IsRevoked
 			sqlmock.AnyArg(), // RevokedAt
@@ -58,6 +61,72 @@ func TestCreateRefreshToken(t *testing.T) {
 	assert.NoError(t, mock.ExpectationsWereMet())
 }
 
+func TestRotateRefreshToken(t *testing.T) {
+	f := factory.New()
+	user := f.User()
+	updateStmt := "UPDATE `refresh_tokens` SET `is_revoked`=?,`replaced_by`=?,`revoked_at`=? WHERE id = ? AND is_revoked = ?"
+
+	t.Run("Revokes current and stores next", func(t *testing.T) {
+		db, mock := setupTokenMockDB(t)
+		repo := repository.NewTokenRepository(db)
+		current := f.RefreshToken(user)
+		next := f.RefreshToken(user, factory.InFamily(current.TokenFamily))
+		next.ID = 0
+		at := f.Clock.Now()
+
+		mock.ExpectBegin()
+		mock.ExpectExec(regexp.QuoteMeta(updateStmt)).
+			WithArgs(true, next.Token, at, current.ID, false).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec(regexp.QuoteMeta("INSERT INTO `refresh_tokens`")).
+			WillReturnResult(sqlmock.NewResult(2, 1))
+		mock.ExpectCommit()
+
+		rotated, err := repo.RotateRefreshToken(current, next, at)
+		assert.NoError(t, err)
+		assert.True(t, rotated)
+		assert.Equal(t, uint(2), next.ID)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Does not store next when current was already revoked", func(t *testing.T) {
+		db, mock := setupTokenMockDB(t)
+		repo := repository.NewTokenRepository(db)
+		current := f.RefreshToken(user)
+		next := f.RefreshToken(user, factory.InFamily(current.TokenFamily))
+
+		mock.ExpectBegin()
+		mock.ExpectExec(regexp.QuoteMeta(updateStmt)).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectCommit()
+
+		rotated, err := repo.RotateRefreshToken(current, next, f.Clock.Now())
+		assert.NoError(t, err)
+		assert.False(t, rotated)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Rolls back when next cannot be stored", func(t *testing.T) {
+		db, mock := setupTokenMockDB(t)
+		repo := repository.NewTokenRepository(db)
+		current := f.RefreshToken(user)
+		next := f.RefreshToken(user, factory.InFamily(current.TokenFamily))
+		next.ID = 0
+
+		mock.ExpectBegin()
+		mock.ExpectExec(regexp.QuoteMeta(updateStmt)).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec(regexp.QuoteMeta("INSERT INTO `refresh_tokens`")).
+			WillReturnError(errors.New("duplicate entry"))
+		mock.ExpectRollback()
+
+		rotated, err := repo.RotateRefreshToken(current, next, f.Clock.Now())
+		assert.Error(t, err)
+		assert.False(t, rotated)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
 func TestFindRefreshTokenByToken(t *testing.T) {
 	db, mock := setupTokenMockDB(t)
 	repo := repository.NewTokenRepository(db)
@@ -156,6 +225,77 @@ func TestRevokeAllUserRefreshTokens(t *testing.T) {
 	assert.NoError(t, mock.ExpectationsWereMet())
 }
 
+func TestRevokeClientRefreshTokens(t *testing.T) {
+	at := time.Now()
+
+	t.Run("Revokes the sessions of every user", func(t *testing.T) {
+		db, mock := setupTokenMockDB(t)
+		repo := repository.NewTokenRepository(db)
+
+		mock.ExpectBegin()
+		mock.ExpectExec(regexp.QuoteMeta("UPDATE `refresh_tokens` SET `is_revoked`=?,`revoked_at`=? WHERE client_id = ? AND is_revoked = ?")).
+			WithArgs(true, at, "ios", false).
+			WillReturnResult(sqlmock.NewResult(0, 4))
+		mock.ExpectCommit()
+
+		revoked, err := repo.RevokeClientRefreshTokens("ios", nil, at)
+		assert.NoError(t, err)
+		assert.Equal(t, int64(4), revoked)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Revokes the sessions of one user", func(t *testing.T) {
+		db, mock := setupTokenMockDB(t)
+		repo := repository.NewTokenRepository(db)
+		userID := uint(1)
+
+		mock.ExpectBegin()
+		mock.ExpectExec(regexp.QuoteMeta("UPDATE `refresh_tokens` SET `is_revoked`=?,`revoked_at`=? WHERE (client_id = ? AND is_revoked = ?) AND user_id = ?")).
+			WithArgs(true, at, "ios", false, userID).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+
+		revoked, err := repo.RevokeClientRefreshTokens("ios", &userID, at)
+		assert.NoError(t, err)
+		assert.Equal(t, int64(1), revoked)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestClientSessionCutoff(t *testing.T) {
+	t.Run("Stores the cutoff", func(t *testing.T) {
+		db, mock := setupTokenMockDB(t)
+		repo := repository.NewTokenRepository(db)
+		at := time.Now()
+
+		mock.ExpectBegin()
+		mock.ExpectExec(regexp.QuoteMeta("INSERT INTO `client_session_cutoffs` (`client_id`,`user_id`,`revoked_at`) VALUES (?,?,?)")).
+			WithArgs("ios", nil, at).
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectCommit()
+
+		err := repo.AddClientSessionCutoff(&domain.ClientSessionCutoff{ClientID: "ios", RevokedAt: at})
+		assert.NoError(t, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Returns the latest cutoff applying to the user", func(t *testing.T) {
+		db, mock := setupTokenMockDB(t)
+		repo := repository.NewTokenRepository(db)
+		at := time.Now().Truncate(time.Second)
+
+		mock.ExpectQuery(regexp.QuoteMeta("SELECT MAX(revoked_at) AS revoked_at FROM `client_session_cutoffs` WHERE client_id = ? AND (user_id IS NULL OR user_id = ?)")).
+			WithArgs("ios", uint(1)).
+			WillReturnRows(sqlmock.NewRows([]string{"revoked_at"}).AddRow(at))
+
+		cutoff, err := repo.FindClientSessionCutoff("ios", 1)
+		require.NoError(t, err)
+		require.NotNil(t, cutoff)
+		assert.True(t, cutoff.Equal(at))
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
 func TestRevokeTokenFamily(t *testing.T) {
 	db, mock := setupTokenMockDB(t)
 	repo := repository.NewTokenRepository(db)
@@ -261,35 +401,70 @@ func TestDeleteExpiredBlacklistTokens(t *testing.T) {
 }
 
 func TestRefreshTokenIsValid(t *testing.T) {
+	f := factory.New()
+	user := f.User()
+
 	t.Run("Valid token", func(t *testing.T) {
-		token := &domain.RefreshToken{
-			IsRevoked: false,
-			ExpiresAt: time.Now().Add(1 * time.Hour),
-		}
-		assert.True(t, token.IsValid())
+		assert.True(t, f.RefreshToken(user).IsValid())
 	})
 
 	t.Run("Revoked token is invalid", func(t *testing.T) {
-		token := &domain.RefreshToken{
-			IsRevoked: true,
-			ExpiresAt: time.Now().Add(1 * time.Hour),
-		}
-		assert.False(t, token.IsValid())
+		assert.False(t, f.RefreshToken(user, factory.Revoked()).IsValid())
 	})
 
 	t.Run("Expired token is invalid", func(t *testing.T) {
-		token := &domain.RefreshToken{
-			IsRevoked: false,
-			ExpiresAt: time.Now().Add(-1 * time.Hour),
-		}
-		assert.False(t, token.IsValid())
+		assert.False(t, f.RefreshToken(user, factory.Expired()).IsValid())
 	})
 
 	t.Run("Revoked and expired token is invalid", func(t *testing.T) {
-		token := &domain.RefreshToken{
-			IsRevoked: true,
-			ExpiresAt: time.Now().Add(-1 * time.Hour),
-		}
-		assert.False(t, token.IsValid())
+		assert.False(t, f.RefreshToken(user, factory.Revoked(), factory.Expired()).IsValid())
 	})
 }
+
+func TestCountActiveRefreshTokens(t *testing.T) {
+	db, mock := setupTokenMockDB(t)
+	repo := repository.NewTokenRepository(db)
+
+	now := time.Now()
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT count(*) FROM `refresh_tokens` WHERE user_id = ? AND is_revoked = ? AND expires_at > ?")).
+		WithArgs(1, false, now).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(3))
+
+	count, err := repo.CountActiveRefreshTokens(1, now)
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), count)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestFindLastSessionStart(t *testing.T) {
+	db, mock := setupTokenMockDB(t)
+	repo := repository.NewTokenRepository(db)
+
+	startedAt := time.Now().Add(-time.Hour).Truncate(time.Second)
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT MAX(started_at) AS started_at FROM (SELECT MIN(created_at) AS started_at FROM `refresh_tokens` WHERE user_id = ? GROUP BY `token_family`) AS families")).
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{"started_at"}).AddRow(startedAt))
+
+	result, err := repo.FindLastSessionStart(1)
+	require.NoError(t, err)
+	require.NotNil(t, result)
+	assert.True(t, startedAt.Equal(*result))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPurgeRevokedRefreshTokens(t *testing.T) {
+	db, mock := setupTokenMockDB(t)
+	repo := repository.NewTokenRepository(db)
+
+	revokedBefore := time.Now().Add(-30 * 24 * time.Hour)
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta("DELETE FROM `refresh_tokens` WHERE is_revoked = ? AND COALESCE(revoked_at, created_at) < ? AND expires_at < ? LIMIT ?")).
+		WithArgs(true, revokedBefore, sqlmock.AnyArg(), 1000).
+		WillReturnResult(sqlmock.NewResult(0, 7))
+	mock.ExpectCommit()
+
+	purged, err := repo.PurgeRevokedRefreshTokens(revokedBefore, 1000)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(7), purged)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
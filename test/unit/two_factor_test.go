@@ -0,0 +1,252 @@
+package unit
+
+import (
+	"gojwt-rest-api/internal/domain"
+	"gojwt-rest-api/internal/service"
+	"gojwt-rest-api/internal/utils"
+	"gojwt-rest-api/pkg/kv"
+	"gojwt-rest-api/test/helpers"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// rfc6238Secret is the SHA1 test key of RFC 6238 ("12345678901234567890") in base32
+const rfc6238Secret = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+
+func TestTOTP(t *testing.T) {
+	t.Run("Matches the RFC 6238 test vectors", func(t *testing.T) {
+		code, err := utils.TOTPCode(rfc6238Secret, time.Unix(59, 0))
+		require.NoError(t, err)
+		assert.Equal(t, "287082", code)
+
+		code, err = utils.TOTPCode(rfc6238Secret, time.Unix(1111111109, 0))
+		require.NoError(t, err)
+		assert.Equal(t, "081804", code)
+	})
+
+	t.Run("Accepts codes of adjacent periods only", func(t *testing.T) {
+		now := time.Unix(1111111109, 0)
+		previous, _ := utils.TOTPCode(rfc6238Secret, now.Add(-30*time.Second))
+		stale, _ := utils.TOTPCode(rfc6238Secret, now.Add(-90*time.Second))
+
+		step, ok := utils.ValidateTOTP(rfc6238Secret, previous, now)
+		assert.True(t, ok)
+		assert.Equal(t, now.Unix()/30-1, step)
+
+		_, ok = utils.ValidateTOTP(rfc6238Secret, stale, now)
+		assert.False(t, ok)
+	})
+
+	t.Run("Generated secrets produce codes", func(t *testing.T) {
+		secret, err := utils.GenerateTOTPSecret()
+		require.NoError(t, err)
+		code, err := utils.TOTPCode(secret, time.Now())
+		require.NoError(t, err)
+		assert.Len(t, code, 6)
+		assert.Contains(t, utils.TOTPURL("GoJWT", "john@example.com", secret), "secret="+secret)
+	})
+}
+
+func TestTwoFactorService_Required(t *testing.T) {
+	orgID := uint(3)
+
+	t.Run("Required for configured global and organization roles", func(t *testing.T) {
+		userRepo := new(helpers.MockUserRepository)
+		twoFactor := service.NewTwoFactorService(userRepo, nil, "GoJWT", []string{"admin", "org:owner"})
+
+		admin := helpers.CreateTestUser(1, "admin@example.com")
+		admin.IsAdmin = true
+		owner := helpers.CreateTestUser(2, "owner@acme.com")
+		owner.OrganizationID = &orgID
+		owner.OrganizationRole = domain.OrgRoleOwner
+		member := helpers.CreateTestUser(3, "member@acme.com")
+		member.OrganizationID = &orgID
+		member.OrganizationRole = domain.OrgRoleMember
+
+		required, err := twoFactor.Required(admin)
+		require.NoError(t, err)
+		assert.True(t, required)
+		required, err = twoFactor.Required(owner)
+		require.NoError(t, err)
+		assert.True(t, required)
+		required, err = twoFactor.Required(member)
+		require.NoError(t, err)
+		assert.False(t, required)
+	})
+
+	t.Run("Required by organization settings", func(t *testing.T) {
+		orgRepo := new(helpers.MockOrganizationRepository)
+		orgRepo.On("FindSettings", orgID).Return(&domain.OrganizationSettings{OrganizationID: orgID, Require2FA: boolPtr(true)}, nil)
+		settings := service.NewSettingsService(orgRepo, testDefaultSettings, time.Minute)
+		twoFactor := service.NewTwoFactorService(new(helpers.MockUserRepository), settings, "GoJWT", nil)
+
+		member := helpers.CreateTestUser(3, "member@acme.com")
+		member.OrganizationID = &orgID
+		member.OrganizationRole = domain.OrgRoleMember
+
+		required, err := twoFactor.Required(member)
+		require.NoError(t, err)
+		assert.True(t, required)
+	})
+}
+
+func TestTwoFactorService_Enrollment(t *testing.T) {
+	t.Run("Confirming with a valid code activates the enrollment", func(t *testing.T) {
+		userRepo := new(helpers.MockUserRepository)
+		userCache := new(helpers.MockUserCache)
+		twoFactor := service.NewTwoFactorService(userRepo, nil, "GoJWT", nil, service.WithTwoFactorUserCache(userCache))
+
+		userRepo.On("FindByID", uint(1)).Return(helpers.CreateTestUser(1, "john@example.com"), nil)
+		userRepo.On("FindTwoFactor", uint(1)).Return(nil, domain.ErrTwoFactorNotEnrolled).Once()
+		userCache.On("Invalidate", mock.Anything).Return(nil)
+		var saved *domain.UserTwoFactor
+		userRepo.On("SaveTwoFactor", mock.AnythingOfType("*domain.UserTwoFactor")).
+			Run(func(args mock.Arguments) { saved = args.Get(0).(*domain.UserTwoFactor) }).
+			Return(nil)
+
+		enrollment, err := twoFactor.BeginEnrollment(1)
+		require.NoError(t, err)
+		require.NotNil(t, saved)
+		assert.Equal(t, saved.Secret, enrollment.Secret)
+		assert.False(t, saved.IsConfirmed())
+
+		userRepo.On("FindTwoFactor", uint(1)).Return(saved, nil)
+		assert.Equal(t, domain.ErrInvalidTwoFactorCode, twoFactor.ConfirmEnrollment(1, "000000x"))
+
+		code, _ := utils.TOTPCode(saved.Secret, time.Now())
+		require.NoError(t, twoFactor.ConfirmEnrollment(1, code))
+		assert.True(t, saved.IsConfirmed())
+		assert.NotZero(t, saved.LastUsedStep)
+		userCache.AssertNumberOfCalls(t, "Invalidate", 1)
+	})
+
+	t.Run("Verify rejects replayed codes", func(t *testing.T) {
+		userRepo := new(helpers.MockUserRepository)
+		twoFactor := service.NewTwoFactorService(userRepo, nil, "GoJWT", nil)
+
+		secret, _ := utils.GenerateTOTPSecret()
+		now := time.Now()
+		code, _ := utils.TOTPCode(secret, now)
+		step, _ := utils.ValidateTOTP(secret, code, now)
+		userRepo.On("FindTwoFactor", uint(1)).Return(&domain.UserTwoFactor{
+			UserID:       1,
+			Secret:       secret,
+			ConfirmedAt:  &now,
+			LastUsedStep: step,
+		}, nil)
+
+		assert.Equal(t, domain.ErrInvalidTwoFactorCode, twoFactor.Verify(1, code))
+		userRepo.AssertNotCalled(t, "UseTwoFactorStep", mock.Anything, mock.Anything)
+	})
+
+	t.Run("Verify locks out after too many invalid codes", func(t *testing.T) {
+		userRepo := new(helpers.MockUserRepository)
+		attempts := kv.NewMemoryStore(time.Minute)
+		twoFactor := service.NewTwoFactorService(userRepo, nil, "GoJWT", nil,
+			service.WithTwoFactorAttemptLimit(attempts, 3, 15*time.Minute))
+
+		secret, _ := utils.GenerateTOTPSecret()
+		now := time.Now()
+		userRepo.On("FindTwoFactor", uint(1)).Return(&domain.UserTwoFactor{UserID: 1, Secret: secret, ConfirmedAt: &now}, nil)
+		userRepo.On("FindTwoFactor", uint(2)).Return(&domain.UserTwoFactor{UserID: 2, Secret: secret, ConfirmedAt: &now}, nil)
+		userRepo.On("UseTwoFactorStep", mock.Anything, mock.Anything).Return(true, nil)
+		wrong, _ := utils.TOTPCode(secret, now.Add(-time.Hour))
+		valid, _ := utils.TOTPCode(secret, now)
+
+		assert.Equal(t, domain.ErrInvalidTwoFactorCode, twoFactor.Verify(1, wrong))
+		assert.Equal(t, domain.ErrInvalidTwoFactorCode, twoFactor.Verify(1, wrong))
+		assert.Equal(t, domain.ErrTooManyTwoFactorAttempts, twoFactor.Verify(1, wrong))
+		// Even the valid code is refused during the lockout
+		assert.Equal(t, domain.ErrTooManyTwoFactorAttempts, twoFactor.Verify(1, valid))
+
+		// A valid code before the lockout resets the counter
+		assert.Equal(t, domain.ErrInvalidTwoFactorCode, twoFactor.Verify(2, wrong))
+		assert.Equal(t, domain.ErrInvalidTwoFactorCode, twoFactor.Verify(2, wrong))
+		require.NoError(t, twoFactor.Verify(2, valid))
+		assert.Equal(t, domain.ErrInvalidTwoFactorCode, twoFactor.Verify(2, wrong))
+	})
+
+	t.Run("ConfirmEnrollment shares the lockout of Verify", func(t *testing.T) {
+		userRepo := new(helpers.MockUserRepository)
+		attempts := kv.NewMemoryStore(time.Minute)
+		twoFactor := service.NewTwoFactorService(userRepo, nil, "GoJWT", nil,
+			service.WithTwoFactorAttemptLimit(attempts, 3, 15*time.Minute))
+
+		secret, _ := utils.GenerateTOTPSecret()
+		now := time.Now()
+		userRepo.On("FindTwoFactor", uint(1)).Return(&domain.UserTwoFactor{UserID: 1, Secret: secret}, nil)
+		userRepo.On("FindTwoFactor", uint(2)).Return(&domain.UserTwoFactor{UserID: 2, Secret: secret}, nil)
+		userRepo.On("SaveTwoFactor", mock.AnythingOfType("*domain.UserTwoFactor")).Return(nil)
+		wrong, _ := utils.TOTPCode(secret, now.Add(-time.Hour))
+		valid, _ := utils.TOTPCode(secret, now)
+
+		assert.Equal(t, domain.ErrInvalidTwoFactorCode, twoFactor.ConfirmEnrollment(1, wrong))
+		assert.Equal(t, domain.ErrInvalidTwoFactorCode, twoFactor.ConfirmEnrollment(1, wrong))
+		assert.Equal(t, domain.ErrTooManyTwoFactorAttempts, twoFactor.ConfirmEnrollment(1, wrong))
+		// Even the valid code is refused during the lockout, by Verify too
+		assert.Equal(t, domain.ErrTooManyTwoFactorAttempts, twoFactor.ConfirmEnrollment(1, valid))
+		assert.Equal(t, domain.ErrTooManyTwoFactorAttempts, twoFactor.Verify(1, valid))
+		userRepo.AssertNotCalled(t, "SaveTwoFactor", mock.Anything)
+
+		// A valid code before the lockout resets the counter
+		assert.Equal(t, domain.ErrInvalidTwoFactorCode, twoFactor.ConfirmEnrollment(2, wrong))
+		assert.Equal(t, domain.ErrInvalidTwoFactorCode, twoFactor.ConfirmEnrollment(2, wrong))
+		require.NoError(t, twoFactor.ConfirmEnrollment(2, valid))
+		assert.Equal(t, domain.ErrInvalidTwoFactorCode, twoFactor.Verify(2, wrong))
+	})
+}
+
+func TestUserService_TwoFactorLogin(t *testing.T) {
+	jwtSecret := "test-secret"
+	password := "password123"
+
+	newUser := func() *domain.User {
+		user := helpers.CreateTestUser(1, "john@example.com")
+		user.IsAdmin = true
+		return user
+	}
+
+	t.Run("Enrolled users get a verification token instead of a session", func(t *testing.T) {
+		userRepo := new(helpers.MockUserRepository)
+		tokenRepo := new(helpers.MockTokenRepository)
+		twoFactor := service.NewTwoFactorService(userRepo, nil, "GoJWT", nil)
+		userService := service.NewUserService(userRepo, tokenRepo, jwtSecret, 15*time.Minute, time.Hour,
+			service.WithTwoFactorService(twoFactor))
+
+		now := time.Now()
+		userRepo.On("FindByEmail", "john@example.com").Return(newUser(), nil)
+		userRepo.On("FindTwoFactor", uint(1)).Return(&domain.UserTwoFactor{UserID: 1, ConfirmedAt: &now}, nil)
+
+		response, err := userService.Login(helpers.CreateLoginRequest("john@example.com", password))
+
+		require.NoError(t, err)
+		assert.Equal(t, domain.LoginStatusTwoFactorRequired, response.Status)
+		assert.Empty(t, response.RefreshToken)
+		claims, err := utils.ValidateToken(response.AccessToken, jwtSecret)
+		require.NoError(t, err)
+		assert.Equal(t, domain.ScopeTwoFactorVerify, claims.Scope)
+		tokenRepo.AssertNotCalled(t, "CreateRefreshToken", mock.Anything)
+	})
+
+	t.Run("Users required to enroll get an enrollment token", func(t *testing.T) {
+		userRepo := new(helpers.MockUserRepository)
+		tokenRepo := new(helpers.MockTokenRepository)
+		twoFactor := service.NewTwoFactorService(userRepo, nil, "GoJWT", []string{domain.RoleAdmin})
+		userService := service.NewUserService(userRepo, tokenRepo, jwtSecret, 15*time.Minute, time.Hour,
+			service.WithTwoFactorService(twoFactor))
+
+		userRepo.On("FindByEmail", "john@example.com").Return(newUser(), nil)
+		userRepo.On("FindTwoFactor", uint(1)).Return(nil, domain.ErrTwoFactorNotEnrolled)
+
+		response, err := userService.Login(helpers.CreateLoginRequest("john@example.com", password))
+
+		require.NoError(t, err)
+		assert.Equal(t, domain.LoginStatusTwoFactorEnrollmentRequired, response.Status)
+		assert.Equal(t, domain.ScopeTwoFactorEnroll, response.Scope)
+		tokenRepo.AssertNotCalled(t, "CreateRefreshToken", mock.Anything)
+	})
+}
@@ -0,0 +1,101 @@
+package unit
+
+import (
+	"errors"
+	"gojwt-rest-api/internal/domain"
+	"gojwt-rest-api/internal/service"
+	"gojwt-rest-api/test/helpers"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUserDetailsService_GetUserDetails(t *testing.T) {
+	setup := func() (*helpers.MockUserRepository, *helpers.MockTokenRepository, *helpers.MockSSOConnectionRepository, *helpers.MockAuditLogRepository, service.UserDetailsService) {
+		userRepo := new(helpers.MockUserRepository)
+		tokenRepo := new(helpers.MockTokenRepository)
+		ssoRepo := new(helpers.MockSSOConnectionRepository)
+		auditRepo := new(helpers.MockAuditLogRepository)
+		details := service.NewUserDetailsService(userRepo, tokenRepo, ssoRepo, service.NewAuditService(auditRepo))
+		return userRepo, tokenRepo, ssoRepo, auditRepo, details
+	}
+
+	t.Run("Aggregates sessions, providers and audit events", func(t *testing.T) {
+		userRepo, tokenRepo, ssoRepo, auditRepo, details := setup()
+
+		lastLogin := time.Now().Add(-time.Hour)
+		confirmedAt := time.Now().Add(-24 * time.Hour)
+		userID := uint(1)
+		userRepo.On("FindByID", userID).Return(helpers.CreateTestUser(userID, "john@acme.com"), nil)
+		userRepo.On("FindTwoFactor", userID).Return(&domain.UserTwoFactor{UserID: userID, ConfirmedAt: &confirmedAt}, nil)
+		tokenRepo.On("CountActiveRefreshTokens", userID, mock.AnythingOfType("time.Time")).Return(int64(2), nil)
+		tokenRepo.On("FindLastSessionStart", userID).Return(&lastLogin, nil)
+		ssoRepo.On("FindByDomain", "acme.com").Return(&domain.SSOConnection{ID: 4, Name: "acme-okta", Protocol: "oidc"}, nil)
+		auditRepo.On("Find", &domain.AuditLogFilter{UserID: userID}, 0, service.RecentAuditEventsLimit).
+			Return([]*domain.AuditLog{{ID: 9, Action: domain.AuditSSOUserProvisioned, UserID: &userID}}, int64(1), nil)
+
+		result, err := details.GetUserDetails(userID, true)
+
+		require.NoError(t, err)
+		assert.Equal(t, "john@acme.com", result.User.Email)
+		assert.Equal(t, int64(2), result.ActiveSessions)
+		assert.Equal(t, &lastLogin, result.LastLoginAt)
+		assert.True(t, result.TwoFactorEnabled)
+		require.Len(t, result.LinkedProviders, 2)
+		assert.Equal(t, domain.LoginMethodPassword, result.LinkedProviders[0].Method)
+		assert.Equal(t, domain.LoginMethodSSO, result.LinkedProviders[1].Method)
+		assert.Equal(t, "acme-okta", result.LinkedProviders[1].Connection)
+		require.Len(t, result.RecentAuditEvents, 1)
+		assert.Equal(t, domain.AuditSSOUserProvisioned, result.RecentAuditEvents[0].Action)
+	})
+
+	t.Run("Falls back to the first login without sessions", func(t *testing.T) {
+		userRepo, tokenRepo, ssoRepo, auditRepo, details := setup()
+
+		firstLogin := time.Now().Add(-30 * 24 * time.Hour)
+		user := helpers.CreateTestUser(1, "john@example.com")
+		user.FirstLoginAt = &firstLogin
+		userRepo.On("FindByID", uint(1)).Return(user, nil)
+		userRepo.On("FindTwoFactor", uint(1)).Return(nil, domain.ErrTwoFactorNotEnrolled)
+		tokenRepo.On("CountActiveRefreshTokens", uint(1), mock.AnythingOfType("time.Time")).Return(int64(0), nil)
+		tokenRepo.On("FindLastSessionStart", uint(1)).Return(nil, nil)
+		ssoRepo.On("FindByDomain", "example.com").Return(nil, domain.ErrSSOConnectionNotFound)
+		auditRepo.On("Find", mock.Anything, 0, service.RecentAuditEventsLimit).Return([]*domain.AuditLog{}, int64(0), nil)
+
+		result, err := details.GetUserDetails(1, true)
+
+		require.NoError(t, err)
+		assert.Equal(t, &firstLogin, result.LastLoginAt)
+		assert.False(t, result.TwoFactorEnabled)
+		assert.Len(t, result.LinkedProviders, 1)
+		assert.Empty(t, result.RecentAuditEvents)
+	})
+
+	t.Run("Leaves out audit events without withAudit", func(t *testing.T) {
+		userRepo, tokenRepo, ssoRepo, auditRepo, details := setup()
+
+		userRepo.On("FindByID", uint(1)).Return(helpers.CreateTestUser(1, "john@example.com"), nil)
+		userRepo.On("FindTwoFactor", uint(1)).Return(nil, domain.ErrTwoFactorNotEnrolled)
+		tokenRepo.On("CountActiveRefreshTokens", uint(1), mock.AnythingOfType("time.Time")).Return(int64(1), nil)
+		tokenRepo.On("FindLastSessionStart", uint(1)).Return(nil, nil)
+		ssoRepo.On("FindByDomain", "example.com").Return(nil, domain.ErrSSOConnectionNotFound)
+
+		result, err := details.GetUserDetails(1, false)
+
+		require.NoError(t, err)
+		assert.Nil(t, result.RecentAuditEvents)
+		auditRepo.AssertNotCalled(t, "Find", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("Unknown user", func(t *testing.T) {
+		userRepo, _, _, _, details := setup()
+		userRepo.On("FindByID", uint(99)).Return(nil, domain.ErrUserNotFound)
+
+		_, err := details.GetUserDetails(99, true)
+
+		assert.True(t, errors.Is(err, domain.ErrUserNotFound))
+	})
+}
@@ -0,0 +1,60 @@
+package unit
+
+import (
+	"gojwt-rest-api/internal/domain"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSplitName(t *testing.T) {
+	tests := []struct {
+		name      string
+		firstName string
+		lastName  string
+	}{
+		{"John Doe", "John", "Doe"},
+		{"Mary Ann Smith", "Mary Ann", "Smith"},
+		{"Prince", "Prince", ""},
+		{"  John   Doe ", "John", "Doe"},
+		{"", "", ""},
+	}
+	for _, tt := range tests {
+		firstName, lastName := domain.SplitName(tt.name)
+		assert.Equal(t, tt.firstName, firstName, tt.name)
+		assert.Equal(t, tt.lastName, lastName, tt.name)
+	}
+}
+
+func TestUser_FullName(t *testing.T) {
+	t.Run("Display name wins over the name parts", func(t *testing.T) {
+		user := &domain.User{FirstName: "Mary Ann", LastName: "Smith", DisplayName: "Annie"}
+		assert.Equal(t, "Annie", user.FullName())
+		assert.Equal(t, "Annie", user.ToResponse().Name)
+	})
+
+	t.Run("Name parts are joined", func(t *testing.T) {
+		user := &domain.User{FirstName: "Prince"}
+		assert.Equal(t, "Prince", user.FullName())
+	})
+
+	t.Run("Users without name parts respond with the stored name split", func(t *testing.T) {
+		user := &domain.User{Name: "Mary Ann Smith"}
+
+		response := user.ToResponse()
+
+		assert.Equal(t, "Mary Ann Smith", response.Name)
+		assert.Equal(t, "Mary Ann", response.FirstName)
+		assert.Equal(t, "Smith", response.LastName)
+	})
+
+	t.Run("Saving a user with only a full name splits it", func(t *testing.T) {
+		user := &domain.User{Name: "John Doe"}
+
+		assert.NoError(t, user.BeforeSave(nil))
+
+		assert.Equal(t, "John", user.FirstName)
+		assert.Equal(t, "Doe", user.LastName)
+		assert.Equal(t, "John Doe", user.Name)
+	})
+}
@@ -1,11 +1,16 @@
 package unit
 
 import (
+	"context"
 	"errors"
 	"gojwt-rest-api/internal/domain"
+	"gojwt-rest-api/internal/events"
 	"gojwt-rest-api/internal/service"
+	"gojwt-rest-api/internal/tenant"
 	"gojwt-rest-api/internal/utils"
+	"gojwt-rest-api/test/factory"
 	"gojwt-rest-api/test/helpers"
+	"sync"
 	"testing"
 	"time"
 
@@ -43,6 +48,44 @@ func TestUserService_Register(t *testing.T) {
 		mockRepo.AssertExpectations(t)
 	})
 
+	t.Run("Registration with name parts", func(t *testing.T) {
+		mockRepo := new(helpers.MockUserRepository)
+		mockTokenRepo := new(helpers.MockTokenRepository)
+		userService := service.NewUserService(mockRepo, mockTokenRepo, jwtSecret, accessExpiry, refreshExpiry)
+
+		req := &domain.RegisterRequest{FirstName: "Mary Ann", LastName: "Smith", DisplayName: "Annie", Email: "mary@example.com", Password: "password123"}
+
+		mockRepo.On("FindByEmail", req.Email).Return(nil, domain.ErrUserNotFound)
+		mockRepo.On("Create", mock.AnythingOfType("*domain.User")).Return(nil)
+
+		user, err := userService.Register(req)
+
+		require.NoError(t, err)
+		assert.Equal(t, "Mary Ann", user.FirstName)
+		assert.Equal(t, "Smith", user.LastName)
+		assert.Equal(t, "Annie", user.DisplayName)
+		assert.Equal(t, "Annie", user.Name)
+	})
+
+	t.Run("Registration with a legacy full name splits it", func(t *testing.T) {
+		mockRepo := new(helpers.MockUserRepository)
+		mockTokenRepo := new(helpers.MockTokenRepository)
+		userService := service.NewUserService(mockRepo, mockTokenRepo, jwtSecret, accessExpiry, refreshExpiry)
+
+		req := helpers.CreateRegisterRequest("Mary Ann Smith", "mary@example.com", "password123")
+
+		mockRepo.On("FindByEmail", req.Email).Return(nil, domain.ErrUserNotFound)
+		mockRepo.On("Create", mock.AnythingOfType("*domain.User")).Return(nil)
+
+		user, err := userService.Register(req)
+
+		require.NoError(t, err)
+		assert.Equal(t, "Mary Ann", user.FirstName)
+		assert.Equal(t, "Smith", user.LastName)
+		assert.Empty(t, user.DisplayName)
+		assert.Equal(t, "Mary Ann Smith", user.Name)
+	})
+
 	t.Run("Registration with existing email", func(t *testing.T) {
 		mockRepo := new(helpers.MockUserRepository)
 		mockTokenRepo := new(helpers.MockTokenRepository)
@@ -103,6 +146,49 @@ func TestUserService_Register(t *testing.T) {
 
 		mockRepo.AssertExpectations(t)
 	})
+
+	t.Run("Concurrent registrations with the same email", func(t *testing.T) {
+		userRepo := &racingUserRepository{MemoryUserRepository: helpers.NewMemoryUserRepository()}
+		userRepo.lookups.Add(2)
+		userService := service.NewUserService(userRepo, helpers.NewMemoryTokenRepository(), jwtSecret, accessExpiry, refreshExpiry)
+
+		req := helpers.CreateRegisterRequest("John Doe", "john@example.com", "password123")
+		errs := make(chan error, 2)
+		for i := 0; i < 2; i++ {
+			go func() {
+				_, err := userService.Register(req)
+				errs <- err
+			}()
+		}
+
+		var created, conflicts int
+		for i := 0; i < 2; i++ {
+			switch err := <-errs; err {
+			case nil:
+				created++
+			case domain.ErrUserAlreadyExists:
+				conflicts++
+			default:
+				t.Fatalf("unexpected error: %v", err)
+			}
+		}
+		assert.Equal(t, 1, created)
+		assert.Equal(t, 1, conflicts)
+	})
+}
+
+// racingUserRepository holds email lookups until both registrations made
+// theirs, so both find the email free and race on the insert
+type racingUserRepository struct {
+	*helpers.MemoryUserRepository
+	lookups sync.WaitGroup
+}
+
+func (r *racingUserRepository) FindByEmail(email string) (*domain.User, error) {
+	user, err := r.MemoryUserRepository.FindByEmail(email)
+	r.lookups.Done()
+	r.lookups.Wait()
+	return user, err
 }
 
 func TestUserService_Login(t *testing.T) {
@@ -115,22 +201,17 @@ func TestUserService_Login(t *testing.T) {
 		mockTokenRepo := new(helpers.MockTokenRepository)
 		userService := service.NewUserService(mockRepo, mockTokenRepo, jwtSecret, accessExpiry, refreshExpiry)
 
-		password := "password123"
-		hashedPassword, _ := utils.HashPassword(password)
-		user := &domain.User{
-			ID:       1,
-			Name:     "John Doe",
-			Email:    "john@example.com",
-			Password: hashedPassword,
-			IsAdmin:  false,
-		}
+		user := factory.New().User(factory.WithName("John Doe"), factory.WithEmail("john@example.com"))
 
-		req := helpers.CreateLoginRequest(user.Email, password)
+		req := helpers.CreateLoginRequest(user.Email, factory.DefaultPassword)
 
 		// Mock: user found
 		mockRepo.On("FindByEmail", req.Email).Return(user, nil)
+		// Mock: first login recorded
+		mockRepo.On("MarkFirstLogin", user.ID, mock.AnythingOfType("time.Time")).Return(true, nil)
 		// Mock: token creation
 		mockTokenRepo.On("CreateRefreshToken", mock.AnythingOfType("*domain.RefreshToken")).Return(nil)
+		mockRepo.On("RecordLogin", user.ID, mock.AnythingOfType("time.Time")).Return(nil)
 
 		response, err := userService.Login(req)
 
@@ -151,6 +232,77 @@ func TestUserService_Login(t *testing.T) {
 		mockTokenRepo.AssertExpectations(t)
 	})
 
+	t.Run("First login publishes onboarding event", func(t *testing.T) {
+		mockRepo := new(helpers.MockUserRepository)
+		mockTokenRepo := new(helpers.MockTokenRepository)
+		publisher := new(helpers.MockEventPublisher)
+		userService := service.NewUserService(mockRepo, mockTokenRepo, jwtSecret, accessExpiry, refreshExpiry,
+			service.WithEventPublisher(publisher))
+
+		user := helpers.CreateTestUser(1, "john@example.com")
+		req := helpers.CreateLoginRequest(user.Email, "password123")
+
+		mockRepo.On("FindByEmail", req.Email).Return(user, nil)
+		mockRepo.On("MarkFirstLogin", user.ID, mock.AnythingOfType("time.Time")).Return(true, nil)
+		mockTokenRepo.On("CreateRefreshToken", mock.AnythingOfType("*domain.RefreshToken")).Return(nil)
+		mockRepo.On("RecordLogin", user.ID, mock.AnythingOfType("time.Time")).Return(nil)
+		publisher.On("Publish", events.UserFirstLogin, mock.MatchedBy(func(data *events.UserFirstLoginData) bool {
+			return data.UserID == user.ID && data.Email == user.Email
+		})).Return()
+
+		response, err := userService.Login(req)
+
+		require.NoError(t, err)
+		assert.NotNil(t, response.User.FirstLoginAt)
+		publisher.AssertExpectations(t)
+	})
+
+	t.Run("Failing first login bookkeeping does not fail the login", func(t *testing.T) {
+		mockRepo := new(helpers.MockUserRepository)
+		mockTokenRepo := new(helpers.MockTokenRepository)
+		publisher := new(helpers.MockEventPublisher)
+		userService := service.NewUserService(mockRepo, mockTokenRepo, jwtSecret, accessExpiry, refreshExpiry,
+			service.WithEventPublisher(publisher))
+
+		user := helpers.CreateTestUser(1, "john@example.com")
+		req := helpers.CreateLoginRequest(user.Email, "password123")
+
+		mockRepo.On("FindByEmail", req.Email).Return(user, nil)
+		mockRepo.On("MarkFirstLogin", user.ID, mock.AnythingOfType("time.Time")).Return(false, errors.New("database error"))
+		mockTokenRepo.On("CreateRefreshToken", mock.AnythingOfType("*domain.RefreshToken")).Return(nil)
+		mockRepo.On("RecordLogin", user.ID, mock.AnythingOfType("time.Time")).Return(nil)
+
+		response, err := userService.Login(req)
+
+		require.NoError(t, err)
+		assert.NotEmpty(t, response.AccessToken)
+		assert.Nil(t, response.User.FirstLoginAt)
+		publisher.AssertNotCalled(t, "Publish", mock.Anything, mock.Anything)
+	})
+
+	t.Run("Subsequent login does not publish onboarding event", func(t *testing.T) {
+		mockRepo := new(helpers.MockUserRepository)
+		mockTokenRepo := new(helpers.MockTokenRepository)
+		publisher := new(helpers.MockEventPublisher)
+		userService := service.NewUserService(mockRepo, mockTokenRepo, jwtSecret, accessExpiry, refreshExpiry,
+			service.WithEventPublisher(publisher))
+
+		user := helpers.CreateTestUser(1, "john@example.com")
+		firstLogin := time.Now().Add(-24 * time.Hour)
+		user.FirstLoginAt = &firstLogin
+		req := helpers.CreateLoginRequest(user.Email, "password123")
+
+		mockRepo.On("FindByEmail", req.Email).Return(user, nil)
+		mockTokenRepo.On("CreateRefreshToken", mock.AnythingOfType("*domain.RefreshToken")).Return(nil)
+		mockRepo.On("RecordLogin", user.ID, mock.AnythingOfType("time.Time")).Return(nil)
+
+		_, err := userService.Login(req)
+
+		require.NoError(t, err)
+		mockRepo.AssertNotCalled(t, "MarkFirstLogin", mock.Anything, mock.Anything)
+		publisher.AssertNotCalled(t, "Publish", mock.Anything, mock.Anything)
+	})
+
 	t.Run("Login with non-existent email", func(t *testing.T) {
 		mockRepo := new(helpers.MockUserRepository)
 		mockTokenRepo := new(helpers.MockTokenRepository)
@@ -175,16 +327,9 @@ func TestUserService_Login(t *testing.T) {
 		mockTokenRepo := new(helpers.MockTokenRepository)
 		userService := service.NewUserService(mockRepo, mockTokenRepo, jwtSecret, accessExpiry, refreshExpiry)
 
-		correctPassword := "password123"
-		wrongPassword := "wrongpassword"
-		hashedPassword, _ := utils.HashPassword(correctPassword)
-		user := &domain.User{
-			ID:       1,
-			Email:    "john@example.com",
-			Password: hashedPassword,
-		}
+		user := factory.New().User(factory.WithEmail("john@example.com"))
 
-		req := helpers.CreateLoginRequest(user.Email, wrongPassword)
+		req := helpers.CreateLoginRequest(user.Email, "wrongpassword")
 
 		// Mock: user found
 		mockRepo.On("FindByEmail", req.Email).Return(user, nil)
@@ -240,6 +385,73 @@ func TestUserService_GetUserByID(t *testing.T) {
 
 		mockRepo.AssertExpectations(t)
 	})
+
+	t.Run("Concurrent lookups of the same user are coalesced", func(t *testing.T) {
+		mockRepo := new(helpers.MockUserRepository)
+		mockTokenRepo := new(helpers.MockTokenRepository)
+		userService := service.NewUserService(mockRepo, mockTokenRepo, jwtSecret, accessExpiry, refreshExpiry)
+
+		expectedUser := helpers.CreateTestUser(1, "john@example.com")
+		release := make(chan struct{})
+
+		// Mock: slow database lookup
+		mockRepo.On("FindByID", uint(1)).Run(func(args mock.Arguments) {
+			<-release
+		}).Return(expectedUser, nil)
+
+		const callers = 10
+		var wg sync.WaitGroup
+		results := make([]*domain.User, callers)
+		for i := 0; i < callers; i++ {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				results[i], _ = userService.GetUserByID(1)
+			}(i)
+		}
+
+		// Give all callers time to join the in-flight lookup
+		time.Sleep(50 * time.Millisecond)
+		close(release)
+		wg.Wait()
+
+		mockRepo.AssertNumberOfCalls(t, "FindByID", 1)
+		for _, user := range results {
+			require.NotNil(t, user)
+			assert.Equal(t, expectedUser.Email, user.Email)
+		}
+		assert.NotSame(t, results[0], results[1], "Callers should receive independent copies")
+	})
+
+	t.Run("Concurrent lookups are coalesced across request scopes of an organization", func(t *testing.T) {
+		mockRepo := new(helpers.MockUserRepository)
+		mockTokenRepo := new(helpers.MockTokenRepository)
+		userService := service.NewUserService(mockRepo, mockTokenRepo, jwtSecret, accessExpiry, refreshExpiry)
+
+		release := make(chan struct{})
+		mockRepo.On("FindByID", uint(1)).Run(func(args mock.Arguments) {
+			<-release
+		}).Return(helpers.CreateTestUser(1, "john@example.com"), nil)
+
+		// Each caller scopes the service to its request, as handlers do
+		const callers = 10
+		var wg sync.WaitGroup
+		for i := 0; i < callers; i++ {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				ctx := tenant.WithOrganization(context.Background(), uint(i%2+1))
+				_, _ = userService.WithContext(ctx).GetUserByID(1)
+			}(i)
+		}
+
+		time.Sleep(50 * time.Millisecond)
+		close(release)
+		wg.Wait()
+
+		// One lookup per organization, results are not shared between them
+		mockRepo.AssertNumberOfCalls(t, "FindByID", 2)
+	})
 }
 
 func TestUserService_GetAllUsers(t *testing.T) {
@@ -335,7 +547,7 @@ func TestUserService_UpdateUser(t *testing.T) {
 			return u.Name == "John Updated"
 		})).Return(nil)
 
-		user, err := userService.UpdateUser(1, req)
+		user, err := userService.UpdateUser(2, 1, req)
 
 		require.NoError(t, err)
 		assert.NotNil(t, user)
@@ -344,6 +556,36 @@ func TestUserService_UpdateUser(t *testing.T) {
 		mockRepo.AssertExpectations(t)
 	})
 
+	t.Run("Update name parts keeps the others", func(t *testing.T) {
+		mockRepo := new(helpers.MockUserRepository)
+		mockTokenRepo := new(helpers.MockTokenRepository)
+		userService := service.NewUserService(mockRepo, mockTokenRepo, jwtSecret, accessExpiry, refreshExpiry)
+
+		existingUser := helpers.CreateTestUser(1, "john@example.com")
+		lastName := "Smith"
+		req := &domain.UpdateUserRequest{LastName: &lastName}
+
+		mockRepo.On("FindByID", uint(1)).Return(existingUser, nil)
+		mockRepo.On("Update", mock.AnythingOfType("*domain.User")).Return(nil)
+
+		user, err := userService.UpdateUser(2, 1, req)
+
+		require.NoError(t, err)
+		assert.Equal(t, "Test", user.FirstName)
+		assert.Equal(t, "Smith", user.LastName)
+		assert.Equal(t, "Test Smith", user.Name)
+
+		displayName := "Tess"
+		user, err = userService.UpdateUser(2, 1, &domain.UpdateUserRequest{DisplayName: &displayName})
+		require.NoError(t, err)
+		assert.Equal(t, "Tess", user.Name)
+
+		displayName = ""
+		user, err = userService.UpdateUser(2, 1, &domain.UpdateUserRequest{DisplayName: &displayName})
+		require.NoError(t, err)
+		assert.Equal(t, "Test Smith", user.Name)
+	})
+
 	t.Run("Successfully update user email", func(t *testing.T) {
 		mockRepo := new(helpers.MockUserRepository)
 		mockTokenRepo := new(helpers.MockTokenRepository)
@@ -362,7 +604,7 @@ func TestUserService_UpdateUser(t *testing.T) {
 			return u.Email == newEmail
 		})).Return(nil)
 
-		user, err := userService.UpdateUser(1, req)
+		user, err := userService.UpdateUser(2, 1, req)
 
 		require.NoError(t, err)
 		assert.NotNil(t, user)
@@ -381,7 +623,7 @@ func TestUserService_UpdateUser(t *testing.T) {
 		// Mock: user not found
 		mockRepo.On("FindByID", uint(999)).Return(nil, domain.ErrUserNotFound)
 
-		user, err := userService.UpdateUser(999, req)
+		user, err := userService.UpdateUser(2, 999, req)
 
 		assert.Error(t, err)
 		assert.Nil(t, user)
@@ -404,7 +646,7 @@ func TestUserService_UpdateUser(t *testing.T) {
 		// Mock: email already taken
 		mockRepo.On("FindByEmail", "taken@example.com").Return(anotherUser, nil)
 
-		user, err := userService.UpdateUser(1, req)
+		user, err := userService.UpdateUser(2, 1, req)
 
 		assert.Error(t, err)
 		assert.Nil(t, user)
@@ -426,7 +668,7 @@ func TestUserService_UpdateUser(t *testing.T) {
 		// Mock: update fails
 		mockRepo.On("Update", mock.AnythingOfType("*domain.User")).Return(errors.New("database error"))
 
-		user, err := userService.UpdateUser(1, req)
+		user, err := userService.UpdateUser(2, 1, req)
 
 		assert.Error(t, err)
 		assert.Nil(t, user)
@@ -447,15 +689,47 @@ func TestUserService_DeleteUser(t *testing.T) {
 		userService := service.NewUserService(mockRepo, mockTokenRepo, jwtSecret, accessExpiry, refreshExpiry)
 
 		// Mock: delete succeeds
-		mockRepo.On("Delete", uint(1)).Return(nil)
+		mockRepo.On("FindByID", uint(1)).Return(helpers.CreateTestUser(1, "john@example.com"), nil)
+		mockRepo.On("DeleteWithCleanup", mock.AnythingOfType("*domain.UserDeletion")).Return(nil)
 
-		err := userService.DeleteUser(1)
+		err := userService.DeleteUser(2, 1)
 
 		require.NoError(t, err)
 
 		mockRepo.AssertExpectations(t)
 	})
 
+	t.Run("Deletion revokes access tokens and emits an event", func(t *testing.T) {
+		mockRepo := new(helpers.MockUserRepository)
+		mockTokenRepo := new(helpers.MockTokenRepository)
+		publisher := new(helpers.MockEventPublisher)
+		userService := service.NewUserService(mockRepo, mockTokenRepo, jwtSecret, accessExpiry, refreshExpiry,
+			service.WithEventPublisher(publisher))
+
+		mockRepo.On("FindByID", uint(1)).Return(helpers.CreateTestUser(1, "john@example.com"), nil)
+		var deletion *domain.UserDeletion
+		mockRepo.On("DeleteWithCleanup", mock.AnythingOfType("*domain.UserDeletion")).
+			Run(func(args mock.Arguments) { deletion = args.Get(0).(*domain.UserDeletion) }).
+			Return(nil)
+		publisher.On("Publish", events.UserDeleted, mock.MatchedBy(func(data *events.UserDeletedData) bool {
+			return data.UserID == 1 && data.ActorID == 2 && data.Email == "john@example.com"
+		})).Return()
+
+		require.NoError(t, userService.DeleteUser(2, 1))
+
+		require.NotNil(t, deletion)
+		assert.Equal(t, uint(1), deletion.UserID)
+		assert.WithinDuration(t, time.Now().Add(accessExpiry), deletion.AccessTokensRevokedUntil, time.Minute)
+		assert.Equal(t, domain.AuditUserDeleted, deletion.Audit.Action)
+		assert.Equal(t, uint(2), *deletion.Audit.ActorID)
+		publisher.AssertExpectations(t)
+
+		mockTokenRepo.On("IsTokenBlacklisted", domain.UserTokensBlacklistKey(1)).Return(true, nil)
+		revoked, err := userService.AccessRevoked(1)
+		require.NoError(t, err)
+		assert.True(t, revoked)
+	})
+
 	t.Run("Delete with database error", func(t *testing.T) {
 		mockRepo := new(helpers.MockUserRepository)
 		mockTokenRepo := new(helpers.MockTokenRepository)
@@ -464,9 +738,10 @@ func TestUserService_DeleteUser(t *testing.T) {
 		dbError := errors.New("database error")
 
 		// Mock: delete fails
-		mockRepo.On("Delete", uint(1)).Return(dbError)
+		mockRepo.On("FindByID", uint(1)).Return(helpers.CreateTestUser(1, "john@example.com"), nil)
+		mockRepo.On("DeleteWithCleanup", mock.AnythingOfType("*domain.UserDeletion")).Return(dbError)
 
-		err := userService.DeleteUser(1)
+		err := userService.DeleteUser(2, 1)
 
 		assert.Error(t, err)
 		assert.Equal(t, dbError, err)
@@ -475,6 +750,128 @@ func TestUserService_DeleteUser(t *testing.T) {
 	})
 }
 
+func TestUserService_SuggestUsers(t *testing.T) {
+	jwtSecret := "test-secret"
+	accessExpiry := 15 * time.Minute
+	refreshExpiry := 7 * 24 * time.Hour
+
+	t.Run("Looks up the trimmed prefix with the suggestion limit", func(t *testing.T) {
+		mockRepo := new(helpers.MockUserRepository)
+		userService := service.NewUserService(mockRepo, new(helpers.MockTokenRepository), jwtSecret, accessExpiry, refreshExpiry)
+
+		expected := []*domain.UserSuggestion{{ID: 1, Name: "John Doe", Email: "john@example.com"}}
+		mockRepo.On("Suggest", "jo", service.MaxUserSuggestions).Return(expected, nil)
+
+		suggestions, err := userService.SuggestUsers("  jo ")
+
+		require.NoError(t, err)
+		assert.Equal(t, expected, suggestions)
+	})
+
+	t.Run("Blank query returns no suggestions", func(t *testing.T) {
+		mockRepo := new(helpers.MockUserRepository)
+		userService := service.NewUserService(mockRepo, new(helpers.MockTokenRepository), jwtSecret, accessExpiry, refreshExpiry)
+
+		suggestions, err := userService.SuggestUsers("  ")
+
+		require.NoError(t, err)
+		assert.Empty(t, suggestions)
+		mockRepo.AssertNotCalled(t, "Suggest", mock.Anything, mock.Anything)
+	})
+}
+
+func TestUserService_AdminSafeguards(t *testing.T) {
+	jwtSecret := "test-secret"
+	accessExpiry := 15 * time.Minute
+	refreshExpiry := 7 * 24 * time.Hour
+
+	newAdmin := func(id uint, email string) *domain.User {
+		user := helpers.CreateTestUser(id, email)
+		user.IsAdmin = true
+		return user
+	}
+	demote := false
+
+	t.Run("Admins cannot delete themselves", func(t *testing.T) {
+		mockRepo := new(helpers.MockUserRepository)
+		userService := service.NewUserService(mockRepo, new(helpers.MockTokenRepository), jwtSecret, accessExpiry, refreshExpiry)
+
+		err := userService.DeleteUser(1, 1)
+
+		assert.Equal(t, domain.ErrCannotDeleteSelf, err)
+		mockRepo.AssertNotCalled(t, "DeleteWithCleanup", mock.Anything)
+	})
+
+	t.Run("Deleting the last active admin is rejected", func(t *testing.T) {
+		mockRepo := new(helpers.MockUserRepository)
+		userService := service.NewUserService(mockRepo, new(helpers.MockTokenRepository), jwtSecret, accessExpiry, refreshExpiry)
+
+		// The acting admin was deactivated, leaving the target as the only active admin
+		actor := newAdmin(1, "actor@example.com")
+		now := time.Now()
+		actor.DeactivatedAt = &now
+		mockRepo.On("FindByID", uint(1)).Return(actor, nil)
+		mockRepo.On("FindByID", uint(2)).Return(newAdmin(2, "admin@example.com"), nil)
+		mockRepo.On("CountActiveAdmins").Return(int64(1), nil)
+
+		err := userService.DeleteUser(1, 2)
+
+		assert.Equal(t, domain.ErrLastAdmin, err)
+		mockRepo.AssertNotCalled(t, "DeleteWithCleanup", mock.Anything)
+	})
+
+	t.Run("Demoting the last active admin is rejected", func(t *testing.T) {
+		mockRepo := new(helpers.MockUserRepository)
+		userService := service.NewUserService(mockRepo, new(helpers.MockTokenRepository), jwtSecret, accessExpiry, refreshExpiry)
+
+		admin := newAdmin(1, "admin@example.com")
+		mockRepo.On("FindByID", uint(1)).Return(admin, nil)
+		mockRepo.On("CountActiveAdmins").Return(int64(1), nil)
+
+		_, err := userService.UpdateUser(1, 1, &domain.UpdateUserRequest{IsAdmin: &demote})
+
+		assert.Equal(t, domain.ErrLastAdmin, err)
+		assert.True(t, admin.IsAdmin)
+		mockRepo.AssertNotCalled(t, "Update", mock.Anything)
+	})
+
+	t.Run("Admins can demote themselves while another admin remains", func(t *testing.T) {
+		mockRepo := new(helpers.MockUserRepository)
+		userService := service.NewUserService(mockRepo, new(helpers.MockTokenRepository), jwtSecret, accessExpiry, refreshExpiry)
+
+		mockRepo.On("FindByID", uint(1)).Return(newAdmin(1, "admin@example.com"), nil)
+		mockRepo.On("CountActiveAdmins").Return(int64(2), nil)
+		mockRepo.On("Update", mock.AnythingOfType("*domain.User")).Return(nil)
+
+		user, err := userService.UpdateUser(1, 1, &domain.UpdateUserRequest{IsAdmin: &demote})
+
+		require.NoError(t, err)
+		assert.False(t, user.IsAdmin)
+	})
+
+	t.Run("Delegated admins cannot change admins", func(t *testing.T) {
+		mockRepo := new(helpers.MockUserRepository)
+		userService := service.NewUserService(mockRepo, new(helpers.MockTokenRepository), jwtSecret, accessExpiry, refreshExpiry)
+
+		support := helpers.CreateTestUser(1, "support@example.com")
+		support.AdminScopes = domain.ScopeAdminUserWrite
+		mockRepo.On("FindByID", uint(1)).Return(support, nil)
+		mockRepo.On("FindByID", uint(2)).Return(newAdmin(2, "admin@example.com"), nil)
+		promote := true
+
+		assert.Equal(t, domain.ErrAdminRoleRequired, userService.DeleteUser(1, 2))
+		_, err := userService.UpdateUser(1, 1, &domain.UpdateUserRequest{IsAdmin: &promote})
+		assert.Equal(t, domain.ErrAdminRoleRequired, err)
+		// Taking an admin's account over through their email
+		_, err = userService.UpdateUser(1, 2, &domain.UpdateUserRequest{Email: "support@example.org"})
+		assert.Equal(t, domain.ErrAdminRoleRequired, err)
+		_, err = userService.UpdateUser(1, 2, &domain.UpdateUserRequest{Name: "Renamed"})
+		assert.Equal(t, domain.ErrAdminRoleRequired, err)
+		mockRepo.AssertNotCalled(t, "DeleteWithCleanup", mock.Anything)
+		mockRepo.AssertNotCalled(t, "Update", mock.Anything)
+	})
+}
+
 func TestUserService_ChangePassword(t *testing.T) {
 	jwtSecret := "test-secret"
 	accessExpiry := 15 * time.Minute
@@ -0,0 +1,68 @@
+package unit
+
+import (
+	"gojwt-rest-api/internal/domain"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUser_ResponseTimezone(t *testing.T) {
+	jakarta := time.FixedZone("WIB", 7*60*60)
+	createdAt := time.Date(2026, 3, 1, 9, 0, 0, 0, jakarta)
+	lastLogin := createdAt.Add(time.Hour)
+
+	t.Run("Responses are in UTC", func(t *testing.T) {
+		user := &domain.User{Name: "John Doe", CreatedAt: createdAt, LastLoginAt: &lastLogin, Timezone: "Asia/Jakarta"}
+
+		response := user.ToResponse()
+
+		assert.Equal(t, time.UTC, response.CreatedAt.Location())
+		assert.Equal(t, time.Date(2026, 3, 1, 2, 0, 0, 0, time.UTC), response.CreatedAt)
+		assert.Equal(t, time.UTC, response.LastLoginAt.Location())
+		assert.Equal(t, jakarta, user.LastLoginAt.Location(), "the user is left unchanged")
+	})
+
+	t.Run("Profile responses are in the preferred timezone", func(t *testing.T) {
+		user := &domain.User{Name: "John Doe", CreatedAt: createdAt.UTC(), LastLoginAt: &lastLogin, Timezone: "Asia/Jakarta"}
+
+		response := user.ToProfileResponse()
+
+		assert.Equal(t, "Asia/Jakarta", response.CreatedAt.Location().String())
+		assert.Equal(t, "2026-03-01T09:00:00+07:00", response.CreatedAt.Format(time.RFC3339))
+		assert.Equal(t, "2026-03-01T10:00:00+07:00", response.LastLoginAt.Format(time.RFC3339))
+	})
+
+	t.Run("Unknown timezones fall back to UTC", func(t *testing.T) {
+		user := &domain.User{Timezone: "Mars/Olympus_Mons"}
+
+		assert.Equal(t, time.UTC, user.Location())
+		assert.Equal(t, time.UTC, (&domain.User{}).Location())
+	})
+}
+
+func TestResponses_AreInUTC(t *testing.T) {
+	jakarta := time.FixedZone("WIB", 7*60*60)
+	createdAt := time.Date(2026, 3, 1, 9, 0, 0, 0, jakarta)
+	expected := time.Date(2026, 3, 1, 2, 0, 0, 0, time.UTC)
+
+	t.Run("Audit logs", func(t *testing.T) {
+		response := (&domain.AuditLog{CreatedAt: createdAt}).ToResponse()
+
+		assert.Equal(t, expected, response.CreatedAt)
+		assert.Equal(t, time.UTC, response.CreatedAt.Location())
+	})
+
+	t.Run("API keys", func(t *testing.T) {
+		lastUsed := createdAt.Add(time.Hour)
+		key := &domain.APIKey{CreatedAt: createdAt, LastUsedAt: &lastUsed}
+
+		response := key.ToResponse(0)
+
+		assert.Equal(t, expected, response.CreatedAt)
+		assert.Equal(t, time.UTC, response.LastUsedAt.Location())
+		assert.Nil(t, response.RevokedAt)
+		assert.Equal(t, jakarta, key.LastUsedAt.Location(), "the key is left unchanged")
+	})
+}
@@ -0,0 +1,92 @@
+package unit
+
+import (
+	"gojwt-rest-api/internal/domain"
+	"gojwt-rest-api/internal/events"
+	"gojwt-rest-api/internal/service"
+	"gojwt-rest-api/pkg/logger"
+	"gojwt-rest-api/pkg/webhooks"
+	"gojwt-rest-api/test/helpers"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWebhookService_Deliveries(t *testing.T) {
+	var calls atomic.Int32
+	endpoint := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Fail the first delivery, accept the replay
+		if calls.Add(1) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			_, _ = w.Write([]byte("maintenance"))
+			return
+		}
+		body, _ := io.ReadAll(r.Body)
+		_, err := webhooks.Parse([]byte("whsec"), r.Header.Get(webhooks.HeaderSignature), body)
+		assert.NoError(t, err)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer endpoint.Close()
+
+	mockRepo := new(helpers.MockWebhookDeliveryRepository)
+	webhookService := service.NewWebhookService(mockRepo, []string{endpoint.URL}, "whsec", time.Second)
+
+	var recorded []*domain.WebhookDelivery
+	mockRepo.On("Create", mock.AnythingOfType("*domain.WebhookDelivery")).Run(func(args mock.Arguments) {
+		delivery := args.Get(0).(*domain.WebhookDelivery)
+		delivery.ID = uint(len(recorded) + 1)
+		recorded = append(recorded, delivery)
+	}).Return(nil)
+
+	t.Run("Records failed deliveries", func(t *testing.T) {
+		bus := events.NewBus(logger.New())
+		webhookService.Subscribe(bus)
+		bus.Publish(events.UserDeleted, map[string]interface{}{"user_id": 3})
+		bus.Wait()
+
+		require.Len(t, recorded, 1)
+		failed := recorded[0]
+		assert.False(t, failed.Success)
+		assert.Equal(t, http.StatusServiceUnavailable, failed.StatusCode)
+		assert.Equal(t, "maintenance", failed.ResponseSnippet)
+		assert.Equal(t, events.UserDeleted, failed.EventType)
+		assert.Equal(t, endpoint.URL, failed.URL)
+		assert.NotEmpty(t, failed.Error)
+		assert.Contains(t, failed.Payload, `"schema_version":1`)
+	})
+
+	t.Run("Replays the stored payload", func(t *testing.T) {
+		require.Len(t, recorded, 1)
+		mockRepo.On("FindByID", uint(1)).Return(recorded[0], nil).Once()
+
+		replay, err := webhookService.Replay(1)
+		require.NoError(t, err)
+		assert.True(t, replay.Success)
+		assert.Equal(t, http.StatusNoContent, replay.StatusCode)
+		assert.Equal(t, recorded[0].Payload, replay.Payload)
+		assert.Equal(t, recorded[0].EventID, replay.EventID)
+		require.NotNil(t, replay.ReplayOf)
+		assert.Equal(t, uint(1), *replay.ReplayOf)
+	})
+
+	t.Run("Refuses to replay to removed endpoints", func(t *testing.T) {
+		mockRepo.On("FindByID", uint(9)).Return(&domain.WebhookDelivery{ID: 9, URL: "https://removed.example.com"}, nil).Once()
+
+		_, err := webhookService.Replay(9)
+		assert.Equal(t, domain.ErrWebhookEndpointRemoved, err)
+	})
+
+	t.Run("Missing deliveries", func(t *testing.T) {
+		mockRepo.On("FindByID", uint(42)).Return(nil, domain.ErrWebhookDeliveryNotFound).Once()
+
+		_, err := webhookService.Replay(42)
+		assert.Equal(t, domain.ErrWebhookDeliveryNotFound, err)
+	})
+}
@@ -0,0 +1,76 @@
+package unit
+
+import (
+	"encoding/json"
+	"gojwt-rest-api/internal/events"
+	"gojwt-rest-api/pkg/webhooks"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWebhooks_Verify(t *testing.T) {
+	secret := []byte("whsec")
+	payload := []byte(`{"id":"evt_1"}`)
+	now := time.Unix(1700000000, 0)
+	header := webhooks.Sign(secret, now, payload)
+
+	t.Run("Accepts valid signatures within tolerance", func(t *testing.T) {
+		assert.NoError(t, webhooks.Verify(secret, header, payload, webhooks.DefaultTolerance, now.Add(time.Minute)))
+	})
+
+	t.Run("Rejects tampered payloads and wrong secrets", func(t *testing.T) {
+		assert.ErrorIs(t, webhooks.Verify(secret, header, []byte(`{"id":"evt_2"}`), webhooks.DefaultTolerance, now), webhooks.ErrSignatureMismatch)
+		assert.ErrorIs(t, webhooks.Verify([]byte("other"), header, payload, webhooks.DefaultTolerance, now), webhooks.ErrSignatureMismatch)
+	})
+
+	t.Run("Rejects replayed deliveries", func(t *testing.T) {
+		err := webhooks.Verify(secret, header, payload, webhooks.DefaultTolerance, now.Add(10*time.Minute))
+		assert.ErrorIs(t, err, webhooks.ErrTimestampOutsideTolerance)
+	})
+
+	t.Run("Accepts any of several signatures", func(t *testing.T) {
+		_, oldSignature, _ := strings.Cut(webhooks.Sign([]byte("old"), now, payload), ",")
+		rotated := header + "," + oldSignature
+		assert.NoError(t, webhooks.Verify(secret, rotated, payload, webhooks.DefaultTolerance, now))
+	})
+
+	t.Run("Rejects malformed headers", func(t *testing.T) {
+		assert.ErrorIs(t, webhooks.Verify(secret, "v1=abc", payload, webhooks.DefaultTolerance, now), webhooks.ErrInvalidHeader)
+		assert.ErrorIs(t, webhooks.Verify(secret, "garbage", payload, webhooks.DefaultTolerance, now), webhooks.ErrInvalidHeader)
+	})
+}
+
+func TestWebhookSubscriber_Delivery(t *testing.T) {
+	var envelope *webhooks.Envelope
+	var parseErr error
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		envelope, parseErr = webhooks.Parse([]byte("whsec"), r.Header.Get(webhooks.HeaderSignature), body)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	subscriber := events.NewWebhookSubscriber(server.URL, "whsec", time.Second)
+	err := subscriber.Handle(events.Event{
+		ID:      "evt_1",
+		Type:    events.UserDeleted,
+		Created: time.Now(),
+		Data:    &events.UserDeletedData{UserID: 3, Email: "john@example.com"},
+	})
+
+	require.NoError(t, err)
+	require.NoError(t, parseErr)
+	assert.Equal(t, "evt_1", envelope.ID)
+	assert.Equal(t, events.UserDeleted, envelope.Type)
+	assert.Equal(t, webhooks.SchemaVersion, envelope.SchemaVersion)
+	var data events.UserDeletedData
+	require.NoError(t, json.Unmarshal(envelope.Data, &data))
+	assert.Equal(t, uint(3), data.UserID)
+}